@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"admin-service/internal/api"
+	"admin-service/internal/client"
+	"admin-service/internal/handler"
+	"admin-service/internal/service"
+	contractsclient "contracts/client"
+	"httpmiddleware"
+
+	"github.com/gorilla/mux"
+)
+
+func main() {
+	// Define command-line flags for port, operators and service URLs
+	port := flag.Int("port", 9800, "The port number to run the Admin Service on")
+	adminTokens := flag.String("admin-tokens", "", "Comma-separated name:token pairs of the operators allowed to use the Admin Service")
+	listingServiceURL := flag.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service")
+	listingAdminToken := flag.String("listing-admin-token", "", "Admin token of the Listing Service")
+	userServiceURL := flag.String("user-service-url", "http://localhost:7000", "URL of the User Service")
+	userAdminToken := flag.String("user-admin-token", "", "Admin token of the User Service")
+	internalKey := flag.String("internal-key", "", "keyID:secret used to HMAC-sign requests to internal services (requests are unsigned when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	// Every operator has their own token, so there is no default
+	operators, err := parseOperators(*adminTokens)
+	if err != nil {
+		log.Fatalf("Invalid -admin-tokens: %v", err)
+	}
+
+	// Initialize a custom HTTP client with timeouts for inter-service communication
+	httpClient := contractsclient.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Sign internal requests for services that verify signatures
+	if *internalKey != "" {
+		keyID, secret, ok := strings.Cut(*internalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			log.Fatalf("Invalid -internal-key, expected keyID:secret")
+		}
+		httpClient = contractsclient.WithRequestSigning(httpClient, keyID, secret)
+	}
+
+	// Initialize service and handler layers
+	adminService := service.NewAdminService(
+		client.NewListingServiceClient(httpClient, *listingServiceURL, *listingAdminToken),
+		client.NewUserServiceClient(httpClient, *userServiceURL, *userAdminToken),
+	)
+	adminHandler := handler.NewAdminHandler(adminService)
+
+	// Create a new Gorilla Mux router, only serving authenticated operators
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return handler.RequireOperator(operators, next)
+	})
+
+	// Define Admin Service API routes
+	// GET /moderation/queue, POST /moderation/listings/{id}/approve and /reject, GET /reports,
+	// /listings/{id}/reports, /users/{id}/audit and /metrics: Moderate listings, review abuse
+	// reports and audit trails, and get the user and listing statistics, as specified by
+	// api/openapi.yaml
+	api.RegisterAdminRoutes(r, adminHandler)
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("admin-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Start the HTTP server
+	log.Printf("Admin Service starting on port %d with %d operators", *port, len(operators))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
+
+// parseOperators parses comma-separated name:token pairs, requiring at least one operator and
+// unique names and tokens.
+func parseOperators(value string) ([]handler.Operator, error) {
+	var operators []handler.Operator
+	names := make(map[string]bool)
+	tokens := make(map[string]bool)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, token, ok := strings.Cut(pair, ":")
+		if !ok || name == "" || token == "" {
+			return nil, fmt.Errorf("expected name:token, got %q", pair)
+		}
+		if names[name] || tokens[token] {
+			return nil, fmt.Errorf("duplicate operator name or token for %q", name)
+		}
+		names[name], tokens[token] = true, true
+		operators = append(operators, handler.Operator{Name: name, Token: token})
+	}
+	if len(operators) == 0 {
+		return nil, fmt.Errorf("at least one operator is required")
+	}
+	return operators, nil
+}
@@ -0,0 +1,16 @@
+module admin-service
+
+go 1.24.4
+
+require (
+	apperrors v0.0.0
+	contracts v0.0.0
+	github.com/gorilla/mux v1.8.1
+	httpmiddleware v0.0.0
+)
+
+replace apperrors => ../pkg/apperrors
+
+replace contracts => ../pkg/contracts
+
+replace httpmiddleware => ../pkg/httpmiddleware
@@ -0,0 +1,90 @@
+// Code generated by openapi from admin-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler handles the operations of the admin tag: moderation of listings, abuse reports,
+// audit trails and usage metrics.
+type AdminHandler interface {
+	// GetModerationQueue handles GET /moderation/queue: Get a page of the listings waiting for
+	// moderation, oldest first, each with its abuse reports.
+	GetModerationQueue(w http.ResponseWriter, r *http.Request)
+
+	// ApproveListing handles POST /moderation/listings/{id}/approve: Approve a listing, making it
+	// visible.
+	ApproveListing(w http.ResponseWriter, r *http.Request)
+
+	// RejectListing handles POST /moderation/listings/{id}/reject: Reject a listing with a reason,
+	// shown to its owner.
+	RejectListing(w http.ResponseWriter, r *http.Request)
+
+	// GetReportedListings handles GET /reports: Get a page of the listings reported since they were
+	// last moderated, most reported first.
+	GetReportedListings(w http.ResponseWriter, r *http.Request)
+
+	// GetListingReports handles GET /listings/{id}/reports: Get the abuse reports of a listing,
+	// newest first.
+	GetListingReports(w http.ResponseWriter, r *http.Request)
+
+	// GetAuditLog handles GET /users/{id}/audit: Get a page of the audit trail of a user, newest
+	// first.
+	GetAuditLog(w http.ResponseWriter, r *http.Request)
+
+	// GetMetrics handles GET /metrics: Get the user and listing statistics together.
+	GetMetrics(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedAdminHandler answers every operation of AdminHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedAdminHandler struct{}
+
+// GetModerationQueue answers GET /moderation/queue with 501 Not Implemented.
+func (UnimplementedAdminHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// ApproveListing answers POST /moderation/listings/{id}/approve with 501 Not Implemented.
+func (UnimplementedAdminHandler) ApproveListing(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RejectListing answers POST /moderation/listings/{id}/reject with 501 Not Implemented.
+func (UnimplementedAdminHandler) RejectListing(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetReportedListings answers GET /reports with 501 Not Implemented.
+func (UnimplementedAdminHandler) GetReportedListings(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetListingReports answers GET /listings/{id}/reports with 501 Not Implemented.
+func (UnimplementedAdminHandler) GetListingReports(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetAuditLog answers GET /users/{id}/audit with 501 Not Implemented.
+func (UnimplementedAdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetMetrics answers GET /metrics with 501 Not Implemented.
+func (UnimplementedAdminHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterAdminRoutes registers the routes of the operations of AdminHandler on r.
+func RegisterAdminRoutes(r *mux.Router, h AdminHandler) {
+	r.HandleFunc("/moderation/queue", h.GetModerationQueue).Methods("GET")
+	r.HandleFunc("/moderation/listings/{id}/approve", h.ApproveListing).Methods("POST")
+	r.HandleFunc("/moderation/listings/{id}/reject", h.RejectListing).Methods("POST")
+	r.HandleFunc("/reports", h.GetReportedListings).Methods("GET")
+	r.HandleFunc("/listings/{id}/reports", h.GetListingReports).Methods("GET")
+	r.HandleFunc("/users/{id}/audit", h.GetAuditLog).Methods("GET")
+	r.HandleFunc("/metrics", h.GetMetrics).Methods("GET")
+}
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"apperrors"
+)
+
+// ErrNotFound is returned when the requested listing or user does not exist.
+var ErrNotFound = apperrors.New(apperrors.NotFound, "not found")
+
+// RequestError is returned when a service rejects the parameters of a request.
+type RequestError struct {
+	Messages []string // Validation messages reported by the service
+}
+
+func (e *RequestError) Error() string {
+	return "invalid request: " + strings.Join(e.Messages, "; ")
+}
+
+// ListingServiceClient is a client for the Listing Service's admin endpoints.
+type ListingServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+	adminToken string // Sent in the X-Admin-Token header
+}
+
+// NewListingServiceClient creates a new ListingServiceClient authenticating with adminToken.
+func NewListingServiceClient(httpClient *http.Client, baseURL, adminToken string) *ListingServiceClient {
+	return &ListingServiceClient{httpClient: httpClient, baseURL: baseURL, adminToken: adminToken}
+}
+
+// listingServiceResponse is the structure of the Listing Service responses used by this client.
+type listingServiceResponse struct {
+	Result   bool              `json:"result"`
+	Listing  json.RawMessage   `json:"listing"`
+	Listings []json.RawMessage `json:"listings"`
+	Reports  []json.RawMessage `json:"reports"`
+	Stats    json.RawMessage   `json:"stats"`
+	Errors   json.RawMessage   `json:"errors,omitempty"`
+}
+
+// GetPendingListings returns a page of the moderation queue, oldest first.
+func (c *ListingServiceClient) GetPendingListings(pageNum, pageSize int) ([]json.RawMessage, error) {
+	apiResp, err := c.do(http.MethodGet, "/admin/listings/pending?"+pageParams(pageNum, pageSize), nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listings, nil
+}
+
+// GetReportedListings returns a page of the listings reported since they were last moderated,
+// most reported first.
+func (c *ListingServiceClient) GetReportedListings(pageNum, pageSize int) ([]json.RawMessage, error) {
+	apiResp, err := c.do(http.MethodGet, "/admin/listings/reported?"+pageParams(pageNum, pageSize), nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listings, nil
+}
+
+// GetReports returns the abuse reports of a listing, newest first.
+func (c *ListingServiceClient) GetReports(listingID int64) ([]json.RawMessage, error) {
+	apiResp, err := c.do(http.MethodGet, fmt.Sprintf("/admin/listings/%d/reports", listingID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Reports, nil
+}
+
+// ApproveListing approves a listing, returning the updated listing.
+func (c *ListingServiceClient) ApproveListing(listingID int64) (json.RawMessage, error) {
+	apiResp, err := c.do(http.MethodPost, fmt.Sprintf("/admin/listings/%d/approve", listingID), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listing, nil
+}
+
+// RejectListing rejects a listing, with a reason shown to its owner, returning the updated listing.
+func (c *ListingServiceClient) RejectListing(listingID int64, reason string) (json.RawMessage, error) {
+	form := url.Values{}
+	form.Set("reason", reason)
+
+	apiResp, err := c.do(http.MethodPost, fmt.Sprintf("/admin/listings/%d/reject", listingID), form)
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listing, nil
+}
+
+// GetListingStats returns the listing statistics, with days of daily counts (the Listing
+// Service's default when zero).
+func (c *ListingServiceClient) GetListingStats(days int) (json.RawMessage, error) {
+	params := url.Values{}
+	if days > 0 {
+		params.Set("days", strconv.Itoa(days))
+	}
+
+	apiResp, err := c.do(http.MethodGet, "/listings/stats?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Stats, nil
+}
+
+// do sends a request to the Listing Service, with a form-encoded body when form is not nil,
+// and decodes its response.
+func (c *ListingServiceClient) do(method, path string, form url.Values) (*listingServiceResponse, error) {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("X-Admin-Token", c.adminToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Listing Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp listingServiceResponse
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusBadRequest:
+		return nil, decodeRequestError(resp)
+	default:
+		return nil, fmt.Errorf("Listing Service returned non-OK status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Listing Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Listing Service reported error: %s", apiResp.Errors)
+	}
+	return &apiResp, nil
+}
+
+// decodeRequestError reads the error messages of a Listing Service 400 response, which
+// reports them either as a single string or as a list.
+func decodeRequestError(resp *http.Response) error {
+	var errResp struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		return fmt.Errorf("failed to decode Listing Service error response: %w", err)
+	}
+	var messages []string
+	if err := json.Unmarshal(errResp.Errors, &messages); err != nil {
+		var message string
+		if err := json.Unmarshal(errResp.Errors, &message); err != nil {
+			return fmt.Errorf("failed to decode Listing Service error response: %w", err)
+		}
+		messages = []string{message}
+	}
+	return &RequestError{Messages: messages}
+}
+
+// pageParams encodes the page_num and page_size query parameters.
+func pageParams(pageNum, pageSize int) string {
+	params := url.Values{}
+	params.Set("page_num", strconv.Itoa(pageNum))
+	params.Set("page_size", strconv.Itoa(pageSize))
+	return params.Encode()
+}
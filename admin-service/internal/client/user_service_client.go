@@ -0,0 +1,90 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// UserServiceClient is a client for the User Service's admin endpoints.
+type UserServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+	adminToken string // Sent in the X-Admin-Token header
+}
+
+// NewUserServiceClient creates a new UserServiceClient authenticating with adminToken.
+func NewUserServiceClient(httpClient *http.Client, baseURL, adminToken string) *UserServiceClient {
+	return &UserServiceClient{httpClient: httpClient, baseURL: baseURL, adminToken: adminToken}
+}
+
+// userServiceResponse is the structure of the User Service responses used by this client.
+type userServiceResponse struct {
+	Result  bool              `json:"result"`
+	Entries []json.RawMessage `json:"entries"`
+	Stats   json.RawMessage   `json:"stats"`
+	Error   string            `json:"error,omitempty"`
+}
+
+// GetAuditLog returns a page of the audit trail of a user, newest first.
+func (c *UserServiceClient) GetAuditLog(userID int64, pageNum, pageSize int) ([]json.RawMessage, error) {
+	apiResp, err := c.get(fmt.Sprintf("/users/%d/audit?%s", userID, pageParams(pageNum, pageSize)))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Entries, nil
+}
+
+// GetSignupStats returns the user totals and signups, with days of daily counts (the User
+// Service's default when zero).
+func (c *UserServiceClient) GetSignupStats(days int) (json.RawMessage, error) {
+	params := url.Values{}
+	if days > 0 {
+		params.Set("days", strconv.Itoa(days))
+	}
+
+	apiResp, err := c.get("/users/stats?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Stats, nil
+}
+
+// get sends a GET request to the User Service and decodes its response.
+func (c *UserServiceClient) get(path string) (*userServiceResponse, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to User Service: %w", err)
+	}
+	req.Header.Set("X-Admin-Token", c.adminToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp userServiceResponse
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	case http.StatusBadRequest:
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return nil, fmt.Errorf("failed to decode User Service error response: %w", err)
+		}
+		return nil, &RequestError{Messages: []string{apiResp.Error}}
+	default:
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+	return &apiResp, nil
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"admin-service/internal/api"
+	"admin-service/internal/client"
+	"admin-service/internal/model"
+	"admin-service/internal/service"
+	"apperrors"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the body of requests.
+const maxBodyBytes = 64 << 10
+
+// APIResponse is the response structure for errors.
+type APIResponse struct {
+	Result bool   `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// QueueResponse is the response structure for the moderation queue.
+type QueueResponse struct {
+	Result   bool                  `json:"result"`
+	Listings []model.QueuedListing `json:"listings"`
+}
+
+// ListingsResponse is the response structure for pages of listings.
+type ListingsResponse struct {
+	Result   bool              `json:"result"`
+	Listings []json.RawMessage `json:"listings"`
+}
+
+// ListingResponse is the response structure for single listings.
+type ListingResponse struct {
+	Result  bool            `json:"result"`
+	Listing json.RawMessage `json:"listing"`
+}
+
+// ReportsResponse is the response structure for the abuse reports of a listing.
+type ReportsResponse struct {
+	Result  bool              `json:"result"`
+	Reports []json.RawMessage `json:"reports"`
+}
+
+// AuditResponse is the response structure for pages of a user's audit trail.
+type AuditResponse struct {
+	Result  bool              `json:"result"`
+	Entries []json.RawMessage `json:"entries"`
+}
+
+// MetricsResponse is the response structure for usage metrics.
+type MetricsResponse struct {
+	Result  bool           `json:"result"`
+	Metrics *model.Metrics `json:"metrics"`
+}
+
+// AdminHandler handles HTTP requests of operators.
+type AdminHandler struct {
+	adminService *service.AdminService
+}
+
+// AdminHandler implements the operations of the spec's admin tag.
+var _ api.AdminHandler = (*AdminHandler)(nil)
+
+// NewAdminHandler creates a new instance of AdminHandler.
+func NewAdminHandler(adminService *service.AdminService) *AdminHandler {
+	return &AdminHandler{adminService: adminService}
+}
+
+// GetModerationQueue handles GET /moderation/queue requests.
+// It returns a page of the listings waiting for moderation, oldest first, each with its abuse
+// reports.
+func (h *AdminHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pageNum, pageSize := pageParams(r)
+	queue, err := h.adminService.ModerationQueue(pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "Listing not found", "getting moderation queue")
+		return
+	}
+
+	json.NewEncoder(w).Encode(QueueResponse{Result: true, Listings: queue})
+}
+
+// ApproveListing handles POST /moderation/listings/{id}/approve requests.
+func (h *AdminHandler) ApproveListing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseID(w, r, "id", "Invalid listing ID format")
+	if !ok {
+		return
+	}
+	listing, err := h.adminService.Approve(operatorFromContext(r.Context()), id)
+	if err != nil {
+		writeError(w, err, "Listing not found", "approving listing")
+		return
+	}
+
+	json.NewEncoder(w).Encode(ListingResponse{Result: true, Listing: listing})
+}
+
+// RejectListing handles POST /moderation/listings/{id}/reject requests.
+// It expects the form field reason, shown to the listing's owner.
+func (h *AdminHandler) RejectListing(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseID(w, r, "id", "Invalid listing ID format")
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	reason := strings.TrimSpace(r.PostFormValue("reason"))
+	if reason == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Reason is required"})
+		return
+	}
+
+	listing, err := h.adminService.Reject(operatorFromContext(r.Context()), id, reason)
+	if err != nil {
+		writeError(w, err, "Listing not found", "rejecting listing")
+		return
+	}
+
+	json.NewEncoder(w).Encode(ListingResponse{Result: true, Listing: listing})
+}
+
+// GetReportedListings handles GET /reports requests.
+// It returns a page of the listings reported since they were last moderated, most reported
+// first.
+func (h *AdminHandler) GetReportedListings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pageNum, pageSize := pageParams(r)
+	listings, err := h.adminService.ReportedListings(pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "Listing not found", "getting reported listings")
+		return
+	}
+
+	json.NewEncoder(w).Encode(ListingsResponse{Result: true, Listings: listings})
+}
+
+// GetListingReports handles GET /listings/{id}/reports requests.
+// It returns the abuse reports of a listing, newest first.
+func (h *AdminHandler) GetListingReports(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseID(w, r, "id", "Invalid listing ID format")
+	if !ok {
+		return
+	}
+	reports, err := h.adminService.ListingReports(id)
+	if err != nil {
+		writeError(w, err, "Listing not found", "getting listing reports")
+		return
+	}
+
+	json.NewEncoder(w).Encode(ReportsResponse{Result: true, Reports: reports})
+}
+
+// GetAuditLog handles GET /users/{id}/audit requests.
+// It returns a page of the audit trail of a user, newest first.
+func (h *AdminHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseID(w, r, "id", "Invalid user ID format")
+	if !ok {
+		return
+	}
+	pageNum, pageSize := pageParams(r)
+	entries, err := h.adminService.AuditLog(id, pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "User not found", "getting audit trail")
+		return
+	}
+
+	json.NewEncoder(w).Encode(AuditResponse{Result: true, Entries: entries})
+}
+
+// GetMetrics handles GET /metrics requests.
+// It returns the user and listing statistics together. The optional days parameter chooses
+// how many days of daily counts are returned.
+func (h *AdminHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 0 // The services' defaults
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		n, err := strconv.Atoi(daysStr)
+		if err != nil || n < 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid days format"})
+			return
+		}
+		days = n
+	}
+
+	metrics, err := h.adminService.Metrics(days)
+	if err != nil {
+		writeError(w, err, "Statistics not found", "getting metrics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(MetricsResponse{Result: true, Metrics: metrics})
+}
+
+// parseID reads a positive ID in the URL path, writing a 400 response with message and
+// returning false if it is invalid.
+func parseID(w http.ResponseWriter, r *http.Request, name, message string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: message})
+		return 0, false
+	}
+	return id, true
+}
+
+// pageParams reads the page_num and page_size query parameters, defaulting to 1 and 10.
+func pageParams(r *http.Request) (int, int) {
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+	return pageNum, pageSize
+}
+
+// writeError writes the response of a failed request, answering errors with a code with its
+// status. Requests the services rejected answer 400 Bad Request with their messages, and other
+// failures to reach them 502 Bad Gateway. notFound is the message for 404 responses, and
+// action describes the request in logs.
+func writeError(w http.ResponseWriter, err error, notFound, action string) {
+	var requestErr *client.RequestError
+	switch {
+	case errors.As(err, &requestErr):
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid request: " + strings.Join(requestErr.Messages, "; ")})
+	case apperrors.Is(err, apperrors.NotFound):
+		problem := apperrors.NewProblem(err)
+		problem.Detail = notFound
+		apperrors.WriteProblem(w, problem)
+	case apperrors.Is(err, apperrors.Internal):
+		log.Printf("Error %s: %v", action, err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Failed to reach the services"})
+	default:
+		apperrors.Write(w, err)
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import "encoding/json"
+
+// Listings, reports, audit entries and statistics are passed on as the services return them,
+// so operators see every field, including those added to the services later.
+
+// QueuedListing is a listing waiting for moderation, along with the abuse reports that
+// flagged it, if any.
+type QueuedListing struct {
+	Listing json.RawMessage   `json:"listing"`
+	Reports []json.RawMessage `json:"reports"` // Newest first
+}
+
+// Metrics combines the usage statistics of the User Service and the Listing Service.
+type Metrics struct {
+	Users    json.RawMessage `json:"users"`
+	Listings json.RawMessage `json:"listings"`
+}
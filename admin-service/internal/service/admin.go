@@ -0,0 +1,143 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"admin-service/internal/client"
+	"admin-service/internal/model"
+	"apperrors"
+)
+
+// MaxStatsDays is the largest number of days of daily statistics, as allowed by the services.
+const MaxStatsDays = 365
+
+// ErrInvalidQuery is returned for requests that cannot be served.
+var ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+
+// AdminService gathers what operators need from the other services, so they do not have to
+// call each service's admin endpoints themselves.
+type AdminService struct {
+	listings *client.ListingServiceClient
+	users    *client.UserServiceClient
+}
+
+// NewAdminService creates a new instance of AdminService.
+func NewAdminService(listings *client.ListingServiceClient, users *client.UserServiceClient) *AdminService {
+	return &AdminService{listings: listings, users: users}
+}
+
+// ModerationQueue returns a page of the listings waiting for moderation, oldest first, each
+// with its abuse reports. A listing whose reports cannot be fetched comes without them.
+func (s *AdminService) ModerationQueue(pageNum, pageSize int) ([]model.QueuedListing, error) {
+	listings, err := s.listings.GetPendingListings(pageNum, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make([]model.QueuedListing, len(listings))
+	var wg sync.WaitGroup
+	for i, listing := range listings {
+		queue[i] = model.QueuedListing{Listing: listing, Reports: []json.RawMessage{}}
+
+		var l struct {
+			ID int64 `json:"id"`
+		}
+		if err := json.Unmarshal(listing, &l); err != nil {
+			return nil, fmt.Errorf("failed to decode pending listing: %w", err)
+		}
+		wg.Add(1)
+		go func(queued *model.QueuedListing, id int64) {
+			defer wg.Done()
+			reports, err := s.listings.GetReports(id)
+			if err != nil {
+				log.Printf("Error fetching reports of listing %d from Listing Service: %v", id, err)
+				return
+			}
+			if reports != nil {
+				queued.Reports = reports
+			}
+		}(&queue[i], l.ID)
+	}
+	wg.Wait()
+	return queue, nil
+}
+
+// ReportedListings returns a page of the listings reported since they were last moderated,
+// most reported first.
+func (s *AdminService) ReportedListings(pageNum, pageSize int) ([]json.RawMessage, error) {
+	return nonNil(s.listings.GetReportedListings(pageNum, pageSize))
+}
+
+// ListingReports returns the abuse reports of a listing, newest first.
+func (s *AdminService) ListingReports(listingID int64) ([]json.RawMessage, error) {
+	return nonNil(s.listings.GetReports(listingID))
+}
+
+// Approve approves a listing on behalf of an operator, returning the updated listing.
+func (s *AdminService) Approve(operator string, listingID int64) (json.RawMessage, error) {
+	listing, err := s.listings.ApproveListing(listingID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Operator %s approved listing %d", operator, listingID)
+	return listing, nil
+}
+
+// Reject rejects a listing on behalf of an operator, with a reason shown to its owner,
+// returning the updated listing.
+func (s *AdminService) Reject(operator string, listingID int64, reason string) (json.RawMessage, error) {
+	listing, err := s.listings.RejectListing(listingID, reason)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Operator %s rejected listing %d: %s", operator, listingID, reason)
+	return listing, nil
+}
+
+// AuditLog returns a page of the audit trail of a user, newest first.
+func (s *AdminService) AuditLog(userID int64, pageNum, pageSize int) ([]json.RawMessage, error) {
+	return nonNil(s.users.GetAuditLog(userID, pageNum, pageSize))
+}
+
+// Metrics fetches the user and listing statistics concurrently, with days of daily counts
+// (the services' defaults when zero).
+func (s *AdminService) Metrics(days int) (*model.Metrics, error) {
+	if days < 0 || days > MaxStatsDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidQuery, MaxStatsDays)
+	}
+
+	var (
+		wg                    sync.WaitGroup
+		metrics               model.Metrics
+		usersErr, listingsErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		metrics.Users, usersErr = s.users.GetSignupStats(days)
+	}()
+	go func() {
+		defer wg.Done()
+		metrics.Listings, listingsErr = s.listings.GetListingStats(days)
+	}()
+	wg.Wait()
+
+	if listingsErr != nil {
+		return nil, fmt.Errorf("failed to get listing stats: %w", listingsErr)
+	}
+	if usersErr != nil {
+		return nil, fmt.Errorf("failed to get user stats: %w", usersErr)
+	}
+	return &metrics, nil
+}
+
+// nonNil replaces a nil page returned by a service with an empty one, so it is encoded as [].
+func nonNil(items []json.RawMessage, err error) ([]json.RawMessage, error) {
+	if err == nil && items == nil {
+		items = []json.RawMessage{}
+	}
+	return items, err
+}
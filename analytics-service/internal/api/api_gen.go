@@ -0,0 +1,41 @@
+// Code generated by openapi from analytics-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// AnalyticsHandler handles the operations of the analytics tag: dashboards of the domain events of
+// every service.
+type AnalyticsHandler interface {
+	// GetTimeSeries handles GET /timeseries: Count the events of a type per hour, day or week
+	// between two dates.
+	GetTimeSeries(w http.ResponseWriter, r *http.Request)
+
+	// GetFunnel handles GET /funnel: Get the conversion funnel of the listings created between two
+	// dates.
+	GetFunnel(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedAnalyticsHandler answers every operation of AnalyticsHandler with 501 Not
+// Implemented. Handlers embed it to implement the interface while their operations are written.
+type UnimplementedAnalyticsHandler struct{}
+
+// GetTimeSeries answers GET /timeseries with 501 Not Implemented.
+func (UnimplementedAnalyticsHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetFunnel answers GET /funnel with 501 Not Implemented.
+func (UnimplementedAnalyticsHandler) GetFunnel(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterAnalyticsRoutes registers the routes of the operations of AnalyticsHandler on r.
+func RegisterAnalyticsRoutes(r *mux.Router, h AnalyticsHandler) {
+	r.HandleFunc("/timeseries", h.GetTimeSeries).Methods("GET")
+	r.HandleFunc("/funnel", h.GetFunnel).Methods("GET")
+}
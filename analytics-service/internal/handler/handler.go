@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"analytics-service/internal/api"
+	"analytics-service/internal/model"
+	"analytics-service/internal/service"
+	"apperrors"
+)
+
+// maxBodyBytes bounds the JSON body of requests.
+const maxBodyBytes = 64 << 10
+
+// SeriesResponse is the response structure for time series.
+type SeriesResponse struct {
+	Result bool          `json:"result"`
+	Series *model.Series `json:"series,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// FunnelResponse is the response structure for conversion funnels.
+type FunnelResponse struct {
+	Result bool          `json:"result"`
+	Funnel *model.Funnel `json:"funnel,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// AnalyticsHandler handles HTTP requests related to analytics.
+type AnalyticsHandler struct {
+	analyticsService *service.AnalyticsService
+}
+
+// AnalyticsHandler implements the operations of the spec's analytics tag.
+var _ api.AnalyticsHandler = (*AnalyticsHandler)(nil)
+
+// NewAnalyticsHandler creates a new instance of AnalyticsHandler.
+func NewAnalyticsHandler(analyticsService *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// GetTimeSeries handles GET /timeseries requests.
+// It counts the events of event_type per interval (hour, day or week, default day) between
+// the from and to dates, e.g. the listings created per day.
+func (h *AnalyticsHandler) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query()
+	series, err := h.analyticsService.TimeSeries(query.Get("event_type"), query.Get("interval"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		writeError(w, err, "counting %s events", query.Get("event_type"))
+		return
+	}
+
+	json.NewEncoder(w).Encode(SeriesResponse{Result: true, Series: series})
+}
+
+// GetFunnel handles GET /funnel requests.
+// It returns the conversion funnel of the listings created between the from and to dates.
+func (h *AnalyticsHandler) GetFunnel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	funnel, err := h.analyticsService.Funnel(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, err, "computing funnel")
+		return
+	}
+
+	json.NewEncoder(w).Encode(FunnelResponse{Result: true, Funnel: funnel})
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the user service's
+// and the listing service's event relays. Events of every type are stored. Failures answer
+// 500, so the relays retry the event.
+func (h *AnalyticsHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.analyticsService.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "handling event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// writeError answers an error of AnalyticsService with the status of its code, logging internal
+// errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"analytics-service/internal/model"
+	"apperrors"
+	"bus"
+)
+
+// ConsumeMessage handles the domain events received from the event bus, as ConsumeEvent does
+// those POSTed by the relays. Events that cannot be handled are dropped; other errors are
+// returned for the bus to deliver the event again.
+func (h *AnalyticsHandler) ConsumeMessage(ctx context.Context, msg bus.Message) error {
+	var event model.Event
+	if err := json.Unmarshal(msg.Data, &event); err != nil || event.Type == "" {
+		log.Printf("Dropping invalid event of topic %s", msg.Topic)
+		return nil
+	}
+
+	if _, err := h.analyticsService.HandleEvent(event); err != nil {
+		if !apperrors.Is(err, apperrors.Internal) {
+			log.Printf("Dropping event %s %d: %v", event.Type, event.ID, err)
+			return nil
+		}
+		return fmt.Errorf("failed to handle event %s %d: %w", event.Type, event.ID, err)
+	}
+	return nil
+}
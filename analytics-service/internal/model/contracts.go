@@ -0,0 +1,19 @@
+package model
+
+import "contracts"
+
+// These conversions break the build when the Analytics Service's representations and the
+// contracts its clients decode them into drift apart. They compare field names and types; the
+// JSON names are kept in sync by hand.
+var (
+	_ = contracts.AnalyticsPoint(Point{})
+	_ = contracts.FunnelStage(FunnelStage{})
+	_ = contracts.AnalyticsSeries{
+		EventType: Series{}.EventType,
+		Interval:  Series{}.Interval,
+		From:      Series{}.From,
+		To:        Series{}.To,
+		Total:     Series{}.Total,
+	}
+	_ = contracts.Funnel{From: Funnel{}.From, To: Funnel{}.To}
+)
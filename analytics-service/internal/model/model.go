@@ -0,0 +1,51 @@
+package model
+
+import "encoding/json"
+
+// Intervals of time series buckets.
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// Event is a domain event as POSTed by the user service's and the listing service's relays.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// Point is the number of events in a bucket of a time series.
+type Point struct {
+	Start int64 `json:"start"` // Start of the bucket, in microseconds since the epoch
+	Count int64 `json:"count"`
+}
+
+// Series is the number of events of a type per bucket of time, between two dates.
+type Series struct {
+	EventType string  `json:"event_type"`
+	Interval  string  `json:"interval"`
+	From      string  `json:"from"` // First day, YYYY-MM-DD in UTC
+	To        string  `json:"to"`   // Last day, included
+	Total     int64   `json:"total"`
+	Points    []Point `json:"points"` // Oldest first, including empty buckets
+}
+
+// FunnelStage is a stage of the conversion funnel, reached by the listings that had an event
+// of its type and reached every previous stage.
+type FunnelStage struct {
+	Name           string  `json:"name"`
+	EventType      string  `json:"event_type"`
+	Count          int64   `json:"count"`
+	ConversionRate float64 `json:"conversion_rate"` // Of the listings at the previous stage; 0 when there were none
+	OverallRate    float64 `json:"overall_rate"`    // Of the listings at the first stage; 0 when there were none
+}
+
+// Funnel is the conversion funnel of the listings created between two dates.
+type Funnel struct {
+	From   string        `json:"from"` // First day, YYYY-MM-DD in UTC
+	To     string        `json:"to"`   // Last day, included
+	Stages []FunnelStage `json:"stages"`
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"analytics-service/internal/model"
+)
+
+// EventRepository defines the interface for the storage of domain events.
+type EventRepository interface {
+	Insert(event model.Event, listingID, userID *int64, receivedAt int64) (bool, error)
+	CountByBucket(eventType string, start, end, bucketSize int64) (map[int64]int64, error)
+	FunnelCounts(stageTypes []string, start, end int64) ([]int64, error)
+}
+
+// sqliteEventRepository implements EventRepository for SQLite database.
+type sqliteEventRepository struct {
+	db *sql.DB
+}
+
+// createEventsTableSQL creates the events table, holding every event consumed along with the
+// listing and user it concerns, if any. Events are delivered at least once, and each source
+// numbers its events separately, so events are keyed by type and ID.
+const createEventsTableSQL = `
+	CREATE TABLE IF NOT EXISTS events (
+		event_type TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		listing_id INTEGER,
+		user_id INTEGER,
+		payload TEXT NOT NULL,
+		occurred_at INTEGER NOT NULL,
+		received_at INTEGER NOT NULL,
+		PRIMARY KEY (event_type, event_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_events_type_occurred_at ON events(event_type, occurred_at);
+	CREATE INDEX IF NOT EXISTS idx_events_listing_id ON events(listing_id, event_type);`
+
+// NewSQLiteEventRepository creates a new instance of sqliteEventRepository.
+func NewSQLiteEventRepository(db *sql.DB) EventRepository {
+	return &sqliteEventRepository{db: db}
+}
+
+// Insert stores an event. It returns false, storing nothing, if the event was already stored.
+func (r *sqliteEventRepository) Insert(event model.Event, listingID, userID *int64, receivedAt int64) (bool, error) {
+	result, err := r.db.Exec(
+		`INSERT OR IGNORE INTO events(event_type, event_id, listing_id, user_id, payload, occurred_at, received_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?)`,
+		event.Type, event.ID, listingID, userID, string(event.Payload), event.CreatedAt, receivedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert event: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert event: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// CountByBucket counts the events of a type that occurred in [start, end), per bucket of
+// bucketSize microseconds starting at start. It returns the counts keyed by the index of their
+// bucket, leaving out empty buckets.
+func (r *sqliteEventRepository) CountByBucket(eventType string, start, end, bucketSize int64) (map[int64]int64, error) {
+	rows, err := r.db.Query(
+		`SELECT (occurred_at - ?) / ? AS bucket, COUNT(*) FROM events
+		WHERE event_type = ? AND occurred_at >= ? AND occurred_at < ?
+		GROUP BY bucket`,
+		start, bucketSize, eventType, start, end,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count events: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var bucket, count int64
+		if err := rows.Scan(&bucket, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan event count: %w", err)
+		}
+		counts[bucket] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration: %w", err)
+	}
+	return counts, nil
+}
+
+// FunnelCounts counts the listings at each stage of a funnel: the listings with an event of the
+// first stage's type that occurred in [start, end), then, for each later stage, those of them
+// that also had an event of its type and of every stage in between, at any time.
+func (r *sqliteEventRepository) FunnelCounts(stageTypes []string, start, end int64) ([]int64, error) {
+	if len(stageTypes) == 0 {
+		return nil, nil
+	}
+
+	// One flag per later stage telling whether each listing of the cohort had its event
+	var flags, sums []string
+	args := make([]any, 0, len(stageTypes)+2)
+	for i, eventType := range stageTypes[1:] {
+		flags = append(flags, fmt.Sprintf(
+			"EXISTS(SELECT 1 FROM events e WHERE e.listing_id = cohort.listing_id AND e.event_type = ?) AS s%d", i))
+		args = append(args, eventType)
+		reached := make([]string, i+1)
+		for j := range reached {
+			reached[j] = fmt.Sprintf("s%d", j)
+		}
+		sums = append(sums, fmt.Sprintf("COALESCE(SUM(%s), 0)", strings.Join(reached, " AND ")))
+	}
+	args = append(args, stageTypes[0], start, end)
+
+	query := fmt.Sprintf(
+		`SELECT COUNT(*)%s FROM (
+			SELECT cohort.listing_id%s FROM (
+				SELECT DISTINCT listing_id FROM events
+				WHERE event_type = ? AND occurred_at >= ? AND occurred_at < ? AND listing_id IS NOT NULL
+			) cohort
+		)`,
+		prefixed(sums), prefixed(flags),
+	)
+
+	counts := make([]int64, len(stageTypes))
+	dest := make([]any, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := r.db.QueryRow(query, args...).Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to count funnel: %w", err)
+	}
+	return counts, nil
+}
+
+// prefixed joins SQL expressions into the continuation of a column list.
+func prefixed(exprs []string) string {
+	if len(exprs) == 0 {
+		return ""
+	}
+	return ", " + strings.Join(exprs, ", ")
+}
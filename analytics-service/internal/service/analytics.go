@@ -0,0 +1,206 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	"analytics-service/internal/model"
+	"analytics-service/internal/repository"
+	"apperrors"
+)
+
+const (
+	// DefaultDays is the number of days queried when no range is requested, up to today.
+	DefaultDays = 30
+	// MaxBuckets is the maximum number of buckets of a time series.
+	MaxBuckets = 1000
+	// dateLayout is the layout of the from and to dates of queries.
+	dateLayout = "2006-01-02"
+)
+
+// bucketSizes holds the length of the buckets of each interval.
+var bucketSizes = map[string]time.Duration{
+	model.IntervalHour: time.Hour,
+	model.IntervalDay:  24 * time.Hour,
+	model.IntervalWeek: 7 * 24 * time.Hour,
+}
+
+// funnelStages are the stages of the listing conversion funnel, in order.
+var funnelStages = []struct {
+	name, eventType string
+}{
+	{"created", "listing.created"},
+	{"viewed", "listing.viewed"},
+	{"favorited", "listing.favorited"},
+	{"offer_accepted", "offer.accepted"},
+	{"sold", "listing.sold"},
+}
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus: those
+// of the user service and the stages of the funnel. Events of any type POSTed to /events are
+// stored as well.
+var ConsumedEvents = []string{
+	"user.created", "user.updated", "user.merged", "user.deleted",
+	"listing.created", "listing.viewed", "listing.favorited", "offer.accepted", "listing.sold",
+}
+
+// Errors returned by AnalyticsService.
+var (
+	// ErrInvalidQuery is returned for queries that cannot be run.
+	ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+	// ErrInvalidPayload is returned for events that cannot be stored.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+)
+
+// AnalyticsService stores the domain events of every service, and answers time series and
+// funnel queries over them.
+type AnalyticsService struct {
+	repo repository.EventRepository
+}
+
+// NewAnalyticsService creates a new instance of AnalyticsService.
+func NewAnalyticsService(repo repository.EventRepository) *AnalyticsService {
+	return &AnalyticsService{repo: repo}
+}
+
+// TimeSeries counts the events of a type per interval, between the from and to dates (both
+// YYYY-MM-DD in UTC and included, the last DefaultDays days up to today when empty). Buckets
+// start at midnight UTC of from, so weeks start on from's weekday.
+func (s *AnalyticsService) TimeSeries(eventType, interval, from, to string) (*model.Series, error) {
+	if eventType == "" {
+		return nil, fmt.Errorf("%w: event_type is required", ErrInvalidQuery)
+	}
+	if interval == "" {
+		interval = model.IntervalDay
+	}
+	bucketSize, ok := bucketSizes[interval]
+	if !ok {
+		return nil, fmt.Errorf("%w: interval must be hour, day or week", ErrInvalidQuery)
+	}
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+	buckets := int64((end.Sub(start) + bucketSize - 1) / bucketSize)
+	if buckets > MaxBuckets {
+		return nil, fmt.Errorf("%w: at most %d buckets can be returned, use a shorter range or a longer interval", ErrInvalidQuery, MaxBuckets)
+	}
+
+	counts, err := s.repo.CountByBucket(eventType, start.UnixMicro(), end.UnixMicro(), bucketSize.Microseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	series := &model.Series{
+		EventType: eventType,
+		Interval:  interval,
+		From:      start.Format(dateLayout),
+		To:        end.AddDate(0, 0, -1).Format(dateLayout),
+		Points:    make([]model.Point, buckets),
+	}
+	for i := range series.Points {
+		series.Points[i] = model.Point{Start: start.Add(time.Duration(i) * bucketSize).UnixMicro(), Count: counts[int64(i)]}
+		series.Total += series.Points[i].Count
+	}
+	return series, nil
+}
+
+// Funnel returns the conversion funnel of the listings created between the from and to dates
+// (both YYYY-MM-DD in UTC and included, the last DefaultDays days up to today when empty):
+// how many of them were created, viewed, favorited, had an offer accepted and were sold. A
+// listing counts at a stage only if it reached every previous stage as well, at any time.
+func (s *AnalyticsService) Funnel(from, to string) (*model.Funnel, error) {
+	start, end, err := parseRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	stageTypes := make([]string, len(funnelStages))
+	for i, stage := range funnelStages {
+		stageTypes[i] = stage.eventType
+	}
+	counts, err := s.repo.FunnelCounts(stageTypes, start.UnixMicro(), end.UnixMicro())
+	if err != nil {
+		return nil, err
+	}
+
+	funnel := &model.Funnel{
+		From:   start.Format(dateLayout),
+		To:     end.AddDate(0, 0, -1).Format(dateLayout),
+		Stages: make([]model.FunnelStage, len(funnelStages)),
+	}
+	for i, stage := range funnelStages {
+		previous := counts[0]
+		if i > 0 {
+			previous = counts[i-1]
+		}
+		funnel.Stages[i] = model.FunnelStage{
+			Name:           stage.name,
+			EventType:      stage.eventType,
+			Count:          counts[i],
+			ConversionRate: rate(counts[i], previous),
+			OverallRate:    rate(counts[i], counts[0]),
+		}
+	}
+	return funnel, nil
+}
+
+// HandleEvent stores an event of any type, along with the listing and user it concerns, if
+// any. Events are delivered at least once, and storing one again is harmless.
+func (s *AnalyticsService) HandleEvent(event model.Event) (bool, error) {
+	var p struct {
+		ListingID int64 `json:"listing_id"`
+		UserID    int64 `json:"user_id"`
+	}
+	if event.ID <= 0 || event.CreatedAt <= 0 || json.Unmarshal(event.Payload, &p) != nil {
+		return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+	}
+
+	var listingID, userID *int64
+	if p.ListingID > 0 {
+		listingID = &p.ListingID
+	}
+	if p.UserID > 0 {
+		userID = &p.UserID
+	}
+	if _, err := s.repo.Insert(event, listingID, userID, time.Now().UnixMicro()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// parseRange parses the from and to dates of a query, returning the start of from and the end
+// of to.
+func parseRange(from, to string) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+	if to != "" {
+		day, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: to must be a date like 2006-01-02", ErrInvalidQuery)
+		}
+		end = day.AddDate(0, 0, 1)
+	}
+	start := end.AddDate(0, 0, -DefaultDays)
+	if from != "" {
+		day, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: from must be a date like 2006-01-02", ErrInvalidQuery)
+		}
+		start = day
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from must not be after to", ErrInvalidQuery)
+	}
+	return start, end, nil
+}
+
+// rate returns count as a fraction of total, rounded to four decimals, or 0 when total is 0.
+func rate(count, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(count)/float64(total)*10000) / 10000
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"auth-service/internal/api"
+	"auth-service/internal/handler"
+	"auth-service/internal/repository"
+	"auth-service/internal/service"
+	"contracts/client"
+	"httpmiddleware"
+	"registry"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "auth.db"
+
+func main() {
+	// Define command-line flags for port, service URLs and token lifetimes
+	port := flag.Int("port", 9000, "The port number to run the Auth Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding signing keys, sessions and refresh tokens")
+	userServiceURL := flag.String("user-service-url", "http://localhost:7000", "URL of the User Service")
+	internalKey := flag.String("internal-key", "", "keyID:secret used to HMAC-sign requests to the User Service (requests are unsigned when empty)")
+	adminToken := flag.String("admin-token", "", "Token required in the X-Admin-Token header for key management endpoints (disabled when empty)")
+	issuer := flag.String("issuer", "auth-service", "Issuer (iss claim) of the access tokens")
+	accessTokenTTL := flag.Duration("access-token-ttl", service.DefaultAccessTokenTTL, "How long an access token stays valid")
+	refreshTokenTTL := flag.Duration("refresh-token-ttl", service.DefaultRefreshTokenTTL, "How long an unused refresh token stays valid")
+	keyRotationInterval := flag.Duration("key-rotation-interval", 0, "Age at which the signing key is automatically rotated (0 only rotates through POST /admin/keys/rotate)")
+	registryURL := flag.String("registry-url", "", "URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)")
+	advertiseURL := flag.String("advertise-url", "", "URL the registry gives out for this instance (default http://<hostname>:<port>)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	// Initialize the SQLite database
+	// By default this will create 'auth.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize an HTTP client with timeouts for calls to the User Service
+	httpClient := client.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Sign requests so the User Service accepts them when it verifies signatures
+	if *internalKey != "" {
+		keyID, secret, ok := strings.Cut(*internalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			log.Fatalf("Invalid -internal-key, expected keyID:secret")
+		}
+		httpClient = client.WithRequestSigning(httpClient, keyID, secret)
+	}
+	userServiceClient := client.NewUserServiceClient(httpClient, *userServiceURL)
+
+	// Initialize repository, service, and handler layers
+	keyService := service.NewKeyService(repository.NewSQLiteKeyRepository(db), *accessTokenTTL, *keyRotationInterval)
+	if _, err := keyService.SigningKey(); err != nil {
+		log.Fatalf("Failed to initialize signing key: %v", err)
+	}
+	tokenService := service.NewTokenService(repository.NewSQLiteSessionRepository(db), keyService, userServiceClient,
+		*issuer, *accessTokenTTL, *refreshTokenTTL)
+	tokenHandler := handler.NewTokenHandler(tokenService)
+	keyHandler := handler.NewKeyHandler(keyService)
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Auth Service API routes
+	// POST /token, /introspect and /revoke: Issue tokens from a name and password or from a refresh
+	// token, validate them (used by the gateway and User Service) and end their sessions, as
+	// specified by api/openapi.yaml
+	api.RegisterTokensRoutes(r, tokenHandler)
+	// GET /.well-known/jwks.json: Public keys verifying access tokens
+	api.RegisterKeysRoutes(r, keyHandler)
+	// GET /admin/keys: List the signing keys (admin only)
+	r.Handle("/admin/keys", handler.RequireAdmin(*adminToken, http.HandlerFunc(keyHandler.ListKeys))).Methods("GET")
+	// POST /admin/keys/rotate: Generate a new signing key, retiring the current one (admin only)
+	r.Handle("/admin/keys/rotate", handler.RequireAdmin(*adminToken, http.HandlerFunc(keyHandler.RotateKey))).Methods("POST")
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("auth-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Register with the registry, so the gateway reaches this instance as registry://auth-service
+	if *registryURL != "" {
+		if err := registry.Announce(context.Background(), *registryURL, "auth-service", *advertiseURL, *port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
+	// Start the HTTP server
+	log.Printf("Auth Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
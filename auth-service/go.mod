@@ -0,0 +1,19 @@
+module auth-service
+
+go 1.24.4
+
+require (
+	apperrors v0.0.0
+	contracts v0.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.28
+	httpmiddleware v0.0.0
+	registry v0.0.0
+)
+
+replace (
+	apperrors => ../pkg/apperrors
+	contracts => ../pkg/contracts
+	httpmiddleware => ../pkg/httpmiddleware
+	registry => ../pkg/registry
+)
@@ -0,0 +1,70 @@
+// Code generated by openapi from auth-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// TokensHandler handles the operations of the tokens tag: issuing, validating and revoking tokens.
+type TokensHandler interface {
+	// IssueToken handles POST /token: Issue an access token and a refresh token, from a name and
+	// password or from a refresh token.
+	IssueToken(w http.ResponseWriter, r *http.Request)
+
+	// IntrospectToken handles POST /introspect: Validate an access token or a personal access token
+	// of the User Service.
+	IntrospectToken(w http.ResponseWriter, r *http.Request)
+
+	// RevokeToken handles POST /revoke: End the session of a refresh token or an access token.
+	RevokeToken(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedTokensHandler answers every operation of TokensHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedTokensHandler struct{}
+
+// IssueToken answers POST /token with 501 Not Implemented.
+func (UnimplementedTokensHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// IntrospectToken answers POST /introspect with 501 Not Implemented.
+func (UnimplementedTokensHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RevokeToken answers POST /revoke with 501 Not Implemented.
+func (UnimplementedTokensHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterTokensRoutes registers the routes of the operations of TokensHandler on r.
+func RegisterTokensRoutes(r *mux.Router, h TokensHandler) {
+	r.HandleFunc("/token", h.IssueToken).Methods("POST")
+	r.HandleFunc("/introspect", h.IntrospectToken).Methods("POST")
+	r.HandleFunc("/revoke", h.RevokeToken).Methods("POST")
+}
+
+// KeysHandler handles the operations of the keys tag: publishing the keys verifying access tokens.
+type KeysHandler interface {
+	// GetJWKS handles GET /.well-known/jwks.json: Get the public keys verifying access tokens, as a
+	// JWK Set.
+	GetJWKS(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedKeysHandler answers every operation of KeysHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedKeysHandler struct{}
+
+// GetJWKS answers GET /.well-known/jwks.json with 501 Not Implemented.
+func (UnimplementedKeysHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterKeysRoutes registers the routes of the operations of KeysHandler on r.
+func RegisterKeysRoutes(r *mux.Router, h KeysHandler) {
+	r.HandleFunc("/.well-known/jwks.json", h.GetJWKS).Methods("GET")
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"apperrors"
+)
+
+// adminTokenHeader carries the admin token required by the key management endpoints.
+const adminTokenHeader = "X-Admin-Token"
+
+// maxBodyBytes bounds the JSON body of requests.
+const maxBodyBytes = 16 << 10
+
+// APIResponse is the response structure for requests that return no data.
+type APIResponse struct {
+	Result bool   `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RequireAdmin wraps a handler so it only serves requests carrying the configured
+// admin token in the X-Admin-Token header. When no token is configured, admin
+// endpoints are disabled entirely.
+func RequireAdmin(adminToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin API is disabled"})
+			return
+		}
+
+		provided := r.Header.Get(adminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin token is missing or invalid"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isJSONRequest reports whether the request body is declared as JSON
+// (application/json or any application/*+json media type).
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// parseBody reads the given string fields from the request body, which may be either
+// JSON or form-encoded depending on its Content-Type.
+func parseBody(w http.ResponseWriter, r *http.Request, fields ...string) (map[string]string, error) {
+	values := make(map[string]string, len(fields))
+	if isJSONRequest(r) {
+		var body map[string]any
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return nil, errors.New("Invalid JSON request body")
+		}
+		for _, field := range fields {
+			if value, ok := body[field].(string); ok {
+				values[field] = value
+			}
+		}
+		return values, nil
+	}
+
+	// Parse the form data for application/x-www-form-urlencoded
+	if err := r.ParseForm(); err != nil {
+		return nil, errors.New("Failed to parse form data")
+	}
+	for _, field := range fields {
+		values[field] = r.PostFormValue(field)
+	}
+	return values, nil
+}
+
+// writeError answers an error of TokenService or KeyService with the status of its code, logging
+// internal errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
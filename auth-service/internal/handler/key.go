@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"auth-service/internal/api"
+	"auth-service/internal/jwt"
+	"auth-service/internal/model"
+	"auth-service/internal/service"
+	"contracts"
+)
+
+// KeyHandler handles HTTP requests related to the keys signing access tokens.
+type KeyHandler struct {
+	keyService *service.KeyService
+}
+
+// KeyHandler implements the operations of the spec's keys tag.
+var _ api.KeysHandler = (*KeyHandler)(nil)
+
+// NewKeyHandler creates a new instance of KeyHandler.
+func NewKeyHandler(keyService *service.KeyService) *KeyHandler {
+	return &KeyHandler{keyService: keyService}
+}
+
+// KeysResponse is the response structure for key listings and rotations.
+type KeysResponse struct {
+	Result bool               `json:"result"`
+	Keys   []model.SigningKey `json:"keys,omitempty"`
+	Key    *model.SigningKey  `json:"key,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// GetJWKS handles GET /.well-known/jwks.json requests.
+// It returns the public keys verifying access tokens as a JWK Set, so that services can verify
+// tokens themselves instead of calling POST /introspect (at the cost of not noticing revocations).
+func (h *KeyHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.keyService.Keys()
+	if err != nil {
+		log.Printf("Error loading signing keys: %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+		return
+	}
+
+	// Ed25519 public keys in JWK form (RFC 8037)
+	set := contracts.JSONWebKeySet{Keys: []contracts.JSONWebKey{}}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, contracts.JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.PublicKey),
+			KeyID:     key.ID,
+			Use:       "sig",
+			Algorithm: jwt.Algorithm,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(set)
+}
+
+// ListKeys handles GET /admin/keys requests.
+// It returns the keys that still verify tokens, newest first, without their private part.
+func (h *KeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	keys, err := h.keyService.Keys()
+	if err != nil {
+		writeError(w, err, "loading signing keys")
+		return
+	}
+
+	json.NewEncoder(w).Encode(KeysResponse{Result: true, Keys: keys})
+}
+
+// RotateKey handles POST /admin/keys/rotate requests.
+// It generates a new signing key and retires the previous ones, which keep verifying the
+// tokens they signed until those have expired.
+func (h *KeyHandler) RotateKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	key, err := h.keyService.Rotate()
+	if err != nil {
+		writeError(w, err, "rotating signing key")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(KeysResponse{Result: true, Key: key})
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"apperrors"
+	"auth-service/internal/api"
+	"auth-service/internal/model"
+	"auth-service/internal/service"
+)
+
+// Grant types accepted by POST /token.
+const (
+	grantTypePassword     = "password"
+	grantTypeRefreshToken = "refresh_token"
+)
+
+// Error codes of token responses, those of OAuth 2.0 (RFC 6749, section 5.2).
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeInvalidGrant         = "invalid_grant"
+	codeInvalidScope         = "invalid_scope"
+	codeUnsupportedGrantType = "unsupported_grant_type"
+)
+
+// TokenHandler handles HTTP requests related to issuing, introspecting and revoking tokens.
+type TokenHandler struct {
+	tokenService *service.TokenService
+}
+
+// TokenHandler implements the operations of the spec's tokens tag.
+var _ api.TokensHandler = (*TokenHandler)(nil)
+
+// NewTokenHandler creates a new instance of TokenHandler.
+func NewTokenHandler(tokenService *service.TokenService) *TokenHandler {
+	return &TokenHandler{tokenService: tokenService}
+}
+
+// TokenResponse is the response structure for token requests. On success, the fields of
+// model.TokenPair are inlined.
+type TokenResponse struct {
+	Result bool `json:"result"`
+	*model.TokenPair
+	Code  string `json:"code,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// IntrospectionResponse is the response structure for token introspection.
+type IntrospectionResponse struct {
+	Result bool             `json:"result"`
+	Active *bool            `json:"active,omitempty"`
+	Token  *model.TokenInfo `json:"token,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+// IssueToken handles POST /token requests.
+// It accepts either form data or a JSON body. With grant_type=password, the user's name and
+// password are checked and a new session is opened with the requested scope (space-separated,
+// every scope when omitted); with grant_type=refresh_token, a refresh token is exchanged for new
+// tokens. Both return an access token and a refresh token.
+func (h *TokenHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// Responses carry credentials and must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+
+	input, err := parseBody(w, r, "grant_type", "name", "password", "scope", "refresh_token")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TokenResponse{Result: false, Code: codeInvalidRequest, Error: err.Error()})
+		return
+	}
+
+	var pair *model.TokenPair
+	switch input["grant_type"] {
+	case grantTypePassword:
+		pair, err = h.tokenService.PasswordGrant(input["name"], input["password"], strings.Fields(input["scope"]))
+	case grantTypeRefreshToken:
+		pair, err = h.tokenService.RefreshGrant(input["refresh_token"])
+	case "":
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TokenResponse{Result: false, Code: codeInvalidRequest, Error: "grant_type is required"})
+		return
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TokenResponse{Result: false, Code: codeUnsupportedGrantType,
+			Error: "grant_type must be 'password' or 'refresh_token'"})
+		return
+	}
+	if err != nil {
+		if apperrors.Is(err, apperrors.Internal) {
+			log.Printf("Error issuing tokens (grant type %s): %v", input["grant_type"], err)
+		}
+		problem := apperrors.NewProblem(err)
+		switch {
+		case errors.Is(err, service.ErrInvalidGrant):
+			problem.Code, problem.Detail = codeInvalidGrant, "Invalid name or password"
+			if input["grant_type"] == grantTypeRefreshToken {
+				problem.Detail = "Refresh token is invalid, expired or revoked"
+			}
+		case errors.Is(err, service.ErrInvalidScope):
+			problem.Code = codeInvalidScope
+		case errors.Is(err, service.ErrUserSuspended):
+			problem.Code, problem.Detail = codeInvalidGrant, "User is suspended"
+		}
+		apperrors.WriteProblem(w, problem)
+		return
+	}
+
+	json.NewEncoder(w).Encode(TokenResponse{Result: true, TokenPair: pair})
+}
+
+// IntrospectToken handles POST /introspect requests, used by the gateway and the User Service
+// to validate tokens presented by clients. It accepts a body with a token field, either an
+// access token issued by this service or a personal access token of the User Service, and
+// reports whether the token is active.
+func (h *TokenHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	input, err := parseBody(w, r, "token")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(IntrospectionResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	token, err := h.tokenService.Introspect(input["token"])
+	if err != nil {
+		writeError(w, err, "introspecting token")
+		return
+	}
+
+	active := token != nil
+	json.NewEncoder(w).Encode(IntrospectionResponse{Result: true, Active: &active, Token: token})
+}
+
+// RevokeToken handles POST /revoke requests.
+// It accepts a body with a token field, a refresh token or an access token, and ends the session
+// it belongs to. Unknown tokens are ignored, so the response is the same whether or not the
+// token was valid.
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	input, err := parseBody(w, r, "token")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	if err := h.tokenService.Revoke(input["token"]); err != nil {
+		log.Printf("Error revoking token: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true})
+}
@@ -0,0 +1,70 @@
+package model
+
+// Token scopes, naming what an access token may be used for. They are the scopes of the
+// user service's personal access tokens, so the gateway checks both kinds of tokens alike.
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeListingsRead  = "listings:read"
+	ScopeListingsWrite = "listings:write"
+)
+
+// Scopes lists every scope a token can be granted. Tokens issued without an explicit scope get all of them.
+var Scopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeListingsRead, ScopeListingsWrite}
+
+// Kinds of tokens reported by introspection.
+const (
+	TokenTypeAccess              = "access_token"          // Short-lived JWT issued by this service
+	TokenTypePersonalAccessToken = "personal_access_token" // Long-lived token issued by the user service
+)
+
+// SigningKey is an Ed25519 key pair signing access tokens. The newest key that is not retired
+// signs new tokens; older keys keep verifying the tokens they signed until those expire.
+type SigningKey struct {
+	ID         string `json:"id"`                   // Key ID, the "kid" of the tokens it signs
+	PublicKey  []byte `json:"-"`                    // Ed25519 public key
+	PrivateKey []byte `json:"-"`                    // Ed25519 private key, never exposed
+	CreatedAt  int64  `json:"created_at"`           // Timestamp of creation in microseconds
+	RetiredAt  int64  `json:"retired_at,omitempty"` // Timestamp after which the key no longer signs tokens, 0 while active
+	ExpiresAt  int64  `json:"expires_at,omitempty"` // Timestamp after which the key no longer verifies tokens, 0 while active
+}
+
+// Session groups the refresh tokens issued from one password grant. Every refresh rotates the
+// refresh token within the session, and revoking the session invalidates all of its tokens,
+// including the access tokens issued from it.
+type Session struct {
+	ID        string   `json:"id"`                   // Random session ID, the "sid" of its access tokens
+	UserID    int64    `json:"user_id"`              // ID of the user who logged in
+	Scopes    []string `json:"scopes"`               // Scopes granted to the session's tokens
+	CreatedAt int64    `json:"created_at"`           // Timestamp of the password grant in microseconds
+	RevokedAt int64    `json:"revoked_at,omitempty"` // Timestamp of revocation in microseconds, 0 if not revoked
+}
+
+// RefreshToken is a single-use token exchanged for a new access token and refresh token.
+// Only a hash of the token is stored.
+type RefreshToken struct {
+	ID        int64
+	SessionID string
+	CreatedAt int64 // Timestamp of issuance in microseconds
+	ExpiresAt int64 // Timestamp after which the token can no longer be used, in microseconds
+	UsedAt    int64 // Timestamp at which the token was exchanged, 0 if unused
+}
+
+// TokenPair is the response to a successful token request, in the shape defined by OAuth 2.0 (RFC 6749).
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"` // Always "Bearer"
+	ExpiresIn    int64  `json:"expires_in"` // Seconds until the access token expires
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"` // Space-separated scopes granted to the access token
+}
+
+// TokenInfo describes a valid token, as returned by introspection.
+type TokenInfo struct {
+	Type      string   `json:"type"`                 // TokenTypeAccess or TokenTypePersonalAccessToken
+	UserID    int64    `json:"user_id"`              // ID of the user the token acts as
+	Scopes    []string `json:"scopes"`               // What the token may be used for
+	SessionID string   `json:"session_id,omitempty"` // Session an access token was issued from
+	IssuedAt  int64    `json:"issued_at,omitempty"`  // Timestamp of issuance in microseconds
+	ExpiresAt int64    `json:"expires_at,omitempty"` // Timestamp after which the token is invalid, 0 if it never expires
+}
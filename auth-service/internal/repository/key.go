@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"auth-service/internal/model"
+)
+
+// KeyRepository defines the interface for signing key storage.
+type KeyRepository interface {
+	CreateKey(key model.SigningKey) error
+	ListKeys(now int64) ([]model.SigningKey, error)
+	RetireKeys(exceptID string, retiredAt, expiresAt int64) error
+}
+
+// sqliteKeyRepository implements KeyRepository for SQLite database.
+type sqliteKeyRepository struct {
+	db *sql.DB
+}
+
+// createSigningKeysTableSQL creates the signing_keys table. Private keys are stored as is: anyone
+// able to read the database can mint tokens, so it must be protected like any other secret.
+const createSigningKeysTableSQL = `
+	CREATE TABLE IF NOT EXISTS signing_keys (
+		id TEXT NOT NULL PRIMARY KEY,
+		public_key BLOB NOT NULL,
+		private_key BLOB NOT NULL,
+		created_at INTEGER NOT NULL,
+		retired_at INTEGER,
+		expires_at INTEGER
+	);`
+
+// NewSQLiteKeyRepository creates a new instance of sqliteKeyRepository.
+func NewSQLiteKeyRepository(db *sql.DB) KeyRepository {
+	return &sqliteKeyRepository{db: db}
+}
+
+// CreateKey stores a newly generated key.
+func (r *sqliteKeyRepository) CreateKey(key model.SigningKey) error {
+	_, err := r.db.Exec(
+		`INSERT INTO signing_keys(id, public_key, private_key, created_at) VALUES(?, ?, ?, ?)`,
+		key.ID, key.PublicKey, key.PrivateKey, key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert signing key: %w", err)
+	}
+	return nil
+}
+
+// ListKeys returns the keys that have not expired at now, newest first.
+func (r *sqliteKeyRepository) ListKeys(now int64) ([]model.SigningKey, error) {
+	rows, err := r.db.Query(
+		`SELECT id, public_key, private_key, created_at, retired_at, expires_at FROM signing_keys
+		WHERE expires_at IS NULL OR expires_at > ? ORDER BY created_at DESC, id DESC`,
+		now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query signing keys: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	keys := []model.SigningKey{}
+	for rows.Next() {
+		var key model.SigningKey
+		var retiredAt, expiresAt sql.NullInt64
+		if err := rows.Scan(&key.ID, &key.PublicKey, &key.PrivateKey, &key.CreatedAt, &retiredAt, &expiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan signing key row: %w", err)
+		}
+		key.RetiredAt = retiredAt.Int64
+		key.ExpiresAt = expiresAt.Int64
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for ListKeys: %w", err)
+	}
+	return keys, nil
+}
+
+// RetireKeys retires every active key other than exceptID, keeping them for verification until expiresAt.
+func (r *sqliteKeyRepository) RetireKeys(exceptID string, retiredAt, expiresAt int64) error {
+	_, err := r.db.Exec(
+		`UPDATE signing_keys SET retired_at = ?, expires_at = ? WHERE id != ? AND retired_at IS NULL`,
+		retiredAt, expiresAt, exceptID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to retire signing keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// NewSQLiteDB initializes and returns a new SQLite database connection,
+// creating the signing key, session and refresh token tables if necessary.
+func NewSQLiteDB(dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite supports a single writer. Token issuance writes far less than the user service
+	// does, so a single connection serializing every statement is enough to avoid SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Ping the database to verify connection
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	for _, stmt := range []string{createSigningKeysTableSQL, createSessionsTableSQL, createRefreshTokensTableSQL} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create tables: %w", err)
+		}
+	}
+	return db, nil
+}
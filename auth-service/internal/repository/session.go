@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"auth-service/internal/model"
+)
+
+// SessionRepository defines the interface for session and refresh token storage.
+type SessionRepository interface {
+	CreateSession(session model.Session, token model.RefreshToken, tokenHash string) error
+	GetSession(id string) (*model.Session, error)
+	GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error)
+	RotateRefreshToken(usedID int64, usedAt int64, next model.RefreshToken, nextHash string) (bool, error)
+	RevokeSession(id string, revokedAt int64) (bool, error)
+}
+
+// sqliteSessionRepository implements SessionRepository for SQLite database.
+type sqliteSessionRepository struct {
+	db *sql.DB
+}
+
+// createSessionsTableSQL creates the auth_sessions table.
+const createSessionsTableSQL = `
+	CREATE TABLE IF NOT EXISTS auth_sessions (
+		id TEXT NOT NULL PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		scopes TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		revoked_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_auth_sessions_user_id ON auth_sessions(user_id);`
+
+// createRefreshTokensTableSQL creates the refresh_tokens table. Only a hash of each token is stored.
+const createRefreshTokensTableSQL = `
+	CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		used_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens(session_id);`
+
+// NewSQLiteSessionRepository creates a new instance of sqliteSessionRepository.
+func NewSQLiteSessionRepository(db *sql.DB) SessionRepository {
+	return &sqliteSessionRepository{db: db}
+}
+
+// CreateSession stores a new session along with its first refresh token, in a single transaction.
+func (r *sqliteSessionRepository) CreateSession(session model.Session, token model.RefreshToken, tokenHash string) error {
+	return r.withTx("creating session", func(tx *sql.Tx) error {
+		if _, err := tx.Exec(
+			`INSERT INTO auth_sessions(id, user_id, scopes, created_at) VALUES(?, ?, ?, ?)`,
+			session.ID, session.UserID, strings.Join(session.Scopes, " "), session.CreatedAt,
+		); err != nil {
+			return fmt.Errorf("failed to insert session: %w", err)
+		}
+		return insertRefreshToken(tx, token, tokenHash)
+	})
+}
+
+// GetSession returns the session with the given ID, whatever its state, or nil if there is none.
+func (r *sqliteSessionRepository) GetSession(id string) (*model.Session, error) {
+	row := r.db.QueryRow(`SELECT id, user_id, scopes, created_at, revoked_at FROM auth_sessions WHERE id = ?`, id)
+	var session model.Session
+	var scopes string
+	var revokedAt sql.NullInt64
+	if err := row.Scan(&session.ID, &session.UserID, &scopes, &session.CreatedAt, &revokedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	session.Scopes = strings.Fields(scopes)
+	session.RevokedAt = revokedAt.Int64
+	return &session, nil
+}
+
+// GetRefreshTokenByHash returns the refresh token with the given hash, whatever its state,
+// or nil if there is none.
+func (r *sqliteSessionRepository) GetRefreshTokenByHash(tokenHash string) (*model.RefreshToken, error) {
+	row := r.db.QueryRow(
+		`SELECT id, session_id, created_at, expires_at, used_at FROM refresh_tokens WHERE token_hash = ?`,
+		tokenHash,
+	)
+	var token model.RefreshToken
+	var usedAt sql.NullInt64
+	if err := row.Scan(&token.ID, &token.SessionID, &token.CreatedAt, &token.ExpiresAt, &usedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load refresh token: %w", err)
+	}
+	token.UsedAt = usedAt.Int64
+	return &token, nil
+}
+
+// RotateRefreshToken marks a refresh token as used and stores the one replacing it, in a single
+// transaction. It returns false, storing nothing, if the token was already used, so that two
+// concurrent refreshes with the same token cannot both succeed.
+func (r *sqliteSessionRepository) RotateRefreshToken(usedID int64, usedAt int64, next model.RefreshToken, nextHash string) (bool, error) {
+	rotated := false
+	err := r.withTx("rotating refresh token", func(tx *sql.Tx) error {
+		result, err := tx.Exec(`UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`, usedAt, usedID)
+		if err != nil {
+			return fmt.Errorf("failed to mark refresh token as used: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to mark refresh token as used: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+		rotated = true
+		return insertRefreshToken(tx, next, nextHash)
+	})
+	if err != nil {
+		return false, err
+	}
+	return rotated, nil
+}
+
+// RevokeSession marks a session as revoked. It returns false if there is no such session,
+// or if it was already revoked.
+func (r *sqliteSessionRepository) RevokeSession(id string, revokedAt int64) (bool, error) {
+	result, err := r.db.Exec(`UPDATE auth_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, revokedAt, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// withTx runs fn in a transaction, committing it if fn succeeds. what describes the
+// operation in error messages.
+func (r *sqliteSessionRepository) withTx(what string, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", what, err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for %s: %w", what, err)
+	}
+	return nil
+}
+
+// insertRefreshToken stores a refresh token within tx.
+func insertRefreshToken(tx *sql.Tx, token model.RefreshToken, tokenHash string) error {
+	_, err := tx.Exec(
+		`INSERT INTO refresh_tokens(session_id, token_hash, created_at, expires_at) VALUES(?, ?, ?, ?)`,
+		token.SessionID, tokenHash, token.CreatedAt, token.ExpiresAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+	return nil
+}
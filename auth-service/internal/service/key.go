@@ -0,0 +1,181 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+)
+
+// keyReloadInterval is how often the keys are read again from the database, so that keys
+// rotated by another instance are picked up.
+const keyReloadInterval = time.Minute
+
+// keyIDBytes is the amount of randomness in a key ID.
+const keyIDBytes = 8
+
+// KeyService manages the Ed25519 keys signing access tokens.
+//
+// The newest key signs new tokens. Rotating generates a new key and retires the previous ones,
+// which keep verifying the tokens they signed until those have expired: a retired key is
+// dropped accessTTL (plus the reload interval, during which other instances may still sign
+// with it) after its retirement.
+type KeyService struct {
+	repo             repository.KeyRepository
+	accessTTL        time.Duration
+	rotationInterval time.Duration
+
+	mu       sync.Mutex
+	keys     []model.SigningKey // Newest first
+	loadedAt time.Time
+}
+
+// NewKeyService creates a new instance of KeyService. Access tokens live for accessTTL.
+// When rotationInterval is positive, the signing key is rotated once it is that old.
+func NewKeyService(repo repository.KeyRepository, accessTTL, rotationInterval time.Duration) *KeyService {
+	return &KeyService{repo: repo, accessTTL: accessTTL, rotationInterval: rotationInterval}
+}
+
+// SigningKey returns the key new tokens are signed with, generating one when there is none
+// yet or, with automatic rotation, when the current one is due for rotation.
+func (s *KeyService) SigningKey() (*model.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadIfStale(); err != nil {
+		return nil, err
+	}
+	key := s.activeKey()
+	if key != nil && (s.rotationInterval <= 0 || time.Since(time.UnixMicro(key.CreatedAt)) < s.rotationInterval) {
+		return key, nil
+	}
+	if key != nil {
+		log.Printf("Signing key %s is older than %s, rotating", key.ID, s.rotationInterval)
+	}
+	return s.rotate()
+}
+
+// Rotate generates a new signing key and retires the previous ones.
+func (s *KeyService) Rotate() (*model.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotate()
+}
+
+// Keys returns the keys that still verify tokens, newest first.
+func (s *KeyService) Keys() ([]model.SigningKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadIfStale(); err != nil {
+		return nil, err
+	}
+	keys := make([]model.SigningKey, 0, len(s.keys))
+	now := time.Now().UnixMicro()
+	for _, key := range s.keys {
+		if key.ExpiresAt == 0 || key.ExpiresAt > now {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// PublicKey returns the public key verifying tokens signed with the given key ID, or nil if the
+// key is unknown or expired. Unknown key IDs cause a reload, as another instance may have rotated.
+func (s *KeyService) PublicKey(keyID string) ed25519.PublicKey {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.loadIfStale(); err != nil {
+		log.Printf("Error loading signing keys: %v", err)
+		return nil
+	}
+	key := s.findKey(keyID)
+	if key == nil && time.Since(s.loadedAt) >= time.Second {
+		if err := s.load(); err != nil {
+			log.Printf("Error loading signing keys: %v", err)
+			return nil
+		}
+		key = s.findKey(keyID)
+	}
+	if key == nil || (key.ExpiresAt != 0 && key.ExpiresAt <= time.Now().UnixMicro()) {
+		return nil
+	}
+	return ed25519.PublicKey(key.PublicKey)
+}
+
+// rotate generates a new signing key and retires the previous ones. s.mu must be held.
+func (s *KeyService) rotate() (*model.SigningKey, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	id := make([]byte, keyIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate signing key ID: %w", err)
+	}
+
+	now := time.Now()
+	key := model.SigningKey{
+		ID:         hex.EncodeToString(id),
+		PublicKey:  publicKey,
+		PrivateKey: privateKey,
+		CreatedAt:  now.UnixMicro(),
+	}
+	if err := s.repo.CreateKey(key); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RetireKeys(key.ID, now.UnixMicro(), now.Add(s.accessTTL+keyReloadInterval).UnixMicro()); err != nil {
+		return nil, err
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	log.Printf("Generated signing key %s", key.ID)
+	return &key, nil
+}
+
+// loadIfStale reloads the keys if they were last loaded more than keyReloadInterval ago. s.mu must be held.
+func (s *KeyService) loadIfStale() error {
+	if s.keys != nil && time.Since(s.loadedAt) < keyReloadInterval {
+		return nil
+	}
+	return s.load()
+}
+
+// load reads the keys from the database. s.mu must be held.
+func (s *KeyService) load() error {
+	keys, err := s.repo.ListKeys(time.Now().UnixMicro())
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	s.loadedAt = time.Now()
+	return nil
+}
+
+// activeKey returns the newest key that is not retired, or nil. s.mu must be held.
+func (s *KeyService) activeKey() *model.SigningKey {
+	for i := range s.keys {
+		if s.keys[i].RetiredAt == 0 {
+			return &s.keys[i]
+		}
+	}
+	return nil
+}
+
+// findKey returns the loaded key with the given ID, or nil. s.mu must be held.
+func (s *KeyService) findKey(keyID string) *model.SigningKey {
+	for i := range s.keys {
+		if s.keys[i].ID == keyID {
+			return &s.keys[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"apperrors"
+	"auth-service/internal/jwt"
+	"auth-service/internal/model"
+	"auth-service/internal/repository"
+	"contracts"
+	"contracts/client"
+)
+
+const (
+	// DefaultAccessTokenTTL is how long an access token is valid by default. Access tokens are
+	// verified without consulting the user service, so they are kept short-lived.
+	DefaultAccessTokenTTL = 15 * time.Minute
+	// DefaultRefreshTokenTTL is how long an unused refresh token is valid by default.
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+)
+
+const (
+	// refreshTokenPrefix starts every refresh token, telling it apart from other tokens.
+	refreshTokenPrefix = "rt_"
+	// personalAccessTokenPrefix starts the personal access tokens issued by the user service.
+	personalAccessTokenPrefix = "pat_"
+	// tokenBytes is the amount of randomness in a refresh token.
+	tokenBytes = 32
+	// idBytes is the amount of randomness in session IDs and access token IDs.
+	idBytes = 16
+)
+
+// Errors returned by TokenService.
+var (
+	// ErrInvalidGrant is returned for wrong credentials, and unknown, expired, used or revoked refresh tokens.
+	ErrInvalidGrant = apperrors.New(apperrors.Validation, "invalid grant")
+	// ErrInvalidScope is returned when a token is requested with unknown scopes.
+	ErrInvalidScope = apperrors.New(apperrors.Validation, "invalid scope")
+	// ErrUserSuspended is returned when a suspended user requests a token.
+	ErrUserSuspended = apperrors.New(apperrors.Forbidden, "user is suspended")
+)
+
+// TokenService issues, refreshes, introspects and revokes tokens.
+//
+// Logging in with a password opens a session and returns a short-lived access token, a JWT
+// signed by the KeyService, along with a refresh token. Each refresh token can be exchanged
+// once for a new pair; exchanging it a second time means it leaked, so the whole session is
+// revoked. Revoking a session also invalidates the access tokens issued from it, as
+// introspection checks the session of every access token.
+type TokenService struct {
+	sessions   repository.SessionRepository
+	keys       *KeyService
+	users      *client.UserServiceClient
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewTokenService creates a new instance of TokenService. Access tokens carry issuer as their
+// "iss" claim and live for accessTTL, refresh tokens for refreshTTL; non-positive values use
+// DefaultAccessTokenTTL and DefaultRefreshTokenTTL.
+func NewTokenService(sessions repository.SessionRepository, keys *KeyService, users *client.UserServiceClient,
+	issuer string, accessTTL, refreshTTL time.Duration) *TokenService {
+	if accessTTL <= 0 {
+		accessTTL = DefaultAccessTokenTTL
+	}
+	if refreshTTL <= 0 {
+		refreshTTL = DefaultRefreshTokenTTL
+	}
+	return &TokenService{
+		sessions:   sessions,
+		keys:       keys,
+		users:      users,
+		issuer:     issuer,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// PasswordGrant checks the user's credentials with the user service and opens a session
+// granting the requested scopes, or every scope if none are requested.
+func (s *TokenService) PasswordGrant(name, password string, scopes []string) (*model.TokenPair, error) {
+	if name == "" || password == "" {
+		return nil, ErrInvalidGrant
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.VerifyCredentials(name, password)
+	if err != nil {
+		switch {
+		case errors.Is(err, client.ErrInvalidCredentials):
+			return nil, ErrInvalidGrant
+		case errors.Is(err, client.ErrUserSuspended):
+			return nil, ErrUserSuspended
+		default:
+			return nil, err
+		}
+	}
+
+	sessionID, err := randomHex(idBytes)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := model.Session{ID: sessionID, UserID: user.ID, Scopes: scopes, CreatedAt: now.UnixMicro()}
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.sessions.CreateSession(session, s.newRefreshTokenRecord(sessionID, now), refreshHash); err != nil {
+		return nil, err
+	}
+	return s.issue(&session, refreshToken, now)
+}
+
+// RefreshGrant exchanges a refresh token for a new access token and refresh token. The user must
+// still exist and not be suspended. Presenting a refresh token that was already exchanged
+// revokes its session.
+func (s *TokenService) RefreshGrant(refreshToken string) (*model.TokenPair, error) {
+	if !strings.HasPrefix(refreshToken, refreshTokenPrefix) {
+		return nil, ErrInvalidGrant
+	}
+	token, err := s.sessions.GetRefreshTokenByHash(hashToken(refreshToken))
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidGrant
+	}
+	session, err := s.sessions.GetSession(token.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if session == nil || session.RevokedAt != 0 || token.ExpiresAt <= now.UnixMicro() {
+		return nil, ErrInvalidGrant
+	}
+	if token.UsedAt != 0 {
+		s.revokeReused(session)
+		return nil, ErrInvalidGrant
+	}
+
+	user, err := s.users.GetUserByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrInvalidGrant
+	}
+	if user.Status == contracts.UserStatusSuspended {
+		return nil, ErrUserSuspended
+	}
+
+	next, nextHash, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	rotated, err := s.sessions.RotateRefreshToken(token.ID, now.UnixMicro(), s.newRefreshTokenRecord(session.ID, now), nextHash)
+	if err != nil {
+		return nil, err
+	}
+	if !rotated {
+		// Another request exchanged the token in the meantime
+		s.revokeReused(session)
+		return nil, ErrInvalidGrant
+	}
+	return s.issue(session, next, now)
+}
+
+// Introspect resolves a token to its metadata. Access tokens are verified locally, personal
+// access tokens by the user service. It returns (nil, nil) if the token is not valid.
+func (s *TokenService) Introspect(token string) (*model.TokenInfo, error) {
+	if strings.HasPrefix(token, personalAccessTokenPrefix) {
+		accessToken, err := s.users.IntrospectToken(token)
+		if err != nil || accessToken == nil {
+			return nil, err
+		}
+		return &model.TokenInfo{
+			Type:      model.TokenTypePersonalAccessToken,
+			UserID:    accessToken.UserID,
+			Scopes:    accessToken.Scopes,
+			IssuedAt:  accessToken.CreatedAt,
+			ExpiresAt: accessToken.ExpiresAt,
+		}, nil
+	}
+	claims, session, err := s.verifyAccessToken(token)
+	if err != nil || claims == nil {
+		return nil, err
+	}
+	return &model.TokenInfo{
+		Type:      model.TokenTypeAccess,
+		UserID:    session.UserID,
+		Scopes:    strings.Fields(claims.Scope),
+		SessionID: session.ID,
+		IssuedAt:  time.Unix(claims.IssuedAt, 0).UnixMicro(),
+		ExpiresAt: time.Unix(claims.ExpiresAt, 0).UnixMicro(),
+	}, nil
+}
+
+// Revoke ends the session of a refresh token or access token, invalidating every token issued
+// from it. Tokens that are not valid are ignored, as RFC 7009 requires.
+func (s *TokenService) Revoke(token string) error {
+	var sessionID string
+	if strings.HasPrefix(token, refreshTokenPrefix) {
+		refreshToken, err := s.sessions.GetRefreshTokenByHash(hashToken(token))
+		if err != nil || refreshToken == nil {
+			return err
+		}
+		sessionID = refreshToken.SessionID
+	} else {
+		claims, session, err := s.verifyAccessToken(token)
+		if err != nil || claims == nil {
+			return err
+		}
+		sessionID = session.ID
+	}
+	_, err := s.sessions.RevokeSession(sessionID, time.Now().UnixMicro())
+	return err
+}
+
+// verifyAccessToken checks an access token's signature, issuer and expiry and that its session
+// has not been revoked. It returns nil claims if the token is not valid.
+func (s *TokenService) verifyAccessToken(token string) (*jwt.Claims, *model.Session, error) {
+	if !jwt.LooksLikeToken(token) {
+		return nil, nil, nil
+	}
+	claims, err := jwt.Parse(token, s.keys.PublicKey)
+	if err != nil {
+		return nil, nil, nil
+	}
+	if claims.Issuer != s.issuer || claims.ExpiresAt <= time.Now().Unix() {
+		return nil, nil, nil
+	}
+	session, err := s.sessions.GetSession(claims.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil || session.RevokedAt != 0 || strconv.FormatInt(session.UserID, 10) != claims.Subject {
+		return nil, nil, nil
+	}
+	return claims, session, nil
+}
+
+// issue signs an access token for the session and pairs it with the refresh token.
+func (s *TokenService) issue(session *model.Session, refreshToken string, now time.Time) (*model.TokenPair, error) {
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return nil, err
+	}
+	jti, err := randomHex(idBytes)
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := jwt.Sign(jwt.Claims{
+		Issuer:    s.issuer,
+		Subject:   strconv.FormatInt(session.UserID, 10),
+		Scope:     strings.Join(session.Scopes, " "),
+		SessionID: session.ID,
+		ID:        jti,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.accessTTL).Unix(),
+	}, key.ID, ed25519.PrivateKey(key.PrivateKey))
+	if err != nil {
+		return nil, err
+	}
+	return &model.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL / time.Second),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(session.Scopes, " "),
+	}, nil
+}
+
+// newRefreshTokenRecord returns the stored form of a refresh token issued at now for the session.
+func (s *TokenService) newRefreshTokenRecord(sessionID string, now time.Time) model.RefreshToken {
+	return model.RefreshToken{SessionID: sessionID, CreatedAt: now.UnixMicro(), ExpiresAt: now.Add(s.refreshTTL).UnixMicro()}
+}
+
+// revokeReused revokes a session whose refresh token was presented again after being exchanged.
+func (s *TokenService) revokeReused(session *model.Session) {
+	log.Printf("Refresh token of session %s of user %d was reused, revoking the session", session.ID, session.UserID)
+	if _, err := s.sessions.RevokeSession(session.ID, time.Now().UnixMicro()); err != nil {
+		log.Printf("Error revoking session %s: %v", session.ID, err)
+	}
+}
+
+// normalizeScopes validates the requested scopes, defaulting to every scope, and returns them
+// sorted without duplicates.
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return slices.Clone(model.Scopes), nil
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.Scopes, scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidScope, scope)
+		}
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	return slices.Compact(scopes), nil
+}
+
+// newRefreshToken generates a random refresh token along with the hash stored in its place.
+func newRefreshToken() (token, tokenHash string, err error) {
+	random, err := randomHex(tokenBytes)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token = refreshTokenPrefix + random
+	return token, hashToken(token), nil
+}
+
+// randomHex returns n random bytes, hex-encoded.
+func randomHex(n int) (string, error) {
+	raw := make([]byte, n)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// hashToken returns the hash under which a refresh token is stored.
+// Tokens are long random strings, so a fast unsalted hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"booking-service/internal/api"
+	"booking-service/internal/handler"
+	"booking-service/internal/repository"
+	"booking-service/internal/service"
+	"bus"
+	"contracts/client"
+	"httpmiddleware"
+	"registry"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "bookings.db"
+
+// maxHoldMinutes is the longest hold the Listing Service accepts (a week).
+const maxHoldMinutes = 7 * 24 * 60
+
+func main() {
+	// Define command-line flags for port, database, Listing Service URL and saga settings
+	port := flag.Int("port", 9600, "The port number to run the Booking Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding transactions")
+	listingServiceURL := flag.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service")
+	holdMinutes := flag.Int("hold-minutes", 24*60, "How long listings are held for the buyer to confirm, in minutes")
+	sagaInterval := flag.Duration("saga-interval", 2*time.Second, "How often transactions waiting on a saga step are checked")
+	registryURL := flag.String("registry-url", "", "URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)")
+	advertiseURL := flag.String("advertise-url", "", "URL the registry gives out for this instance (default http://<hostname>:<port>)")
+	eventBus := flag.String("event-bus", "", "URL of the message bus to consume domain events from: nats://host:port or kafka://host:port (events are only received on POST /events when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	if *holdMinutes < 1 || *holdMinutes > maxHoldMinutes {
+		log.Fatalf("Invalid -hold-minutes, expected between 1 and %d", maxHoldMinutes)
+	}
+	if *sagaInterval <= 0 {
+		log.Fatalf("Invalid -saga-interval, expected a positive duration")
+	}
+
+	// Initialize the SQLite database
+	// By default this will create 'bookings.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize a custom HTTP client with timeouts for inter-service communication
+	httpClient := client.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Initialize repository, service, and handler layers
+	transactionRepo := repository.NewSQLiteTransactionRepository(db)
+	coordinator := service.NewCoordinator(transactionRepo, client.NewListingServiceClient(httpClient, *listingServiceURL), *holdMinutes, *sagaInterval)
+	bookingHandler := handler.NewBookingHandler(service.NewBookingService(transactionRepo, coordinator))
+
+	// Consume domain events from the event bus as well, when there is one
+	if *eventBus != "" {
+		events, err := bus.Open(*eventBus)
+		if err != nil {
+			log.Fatalf("Failed to open event bus: %v", err)
+		}
+		defer events.Close()
+		if _, err := bus.SubscribeTopics(context.Background(), events, service.ConsumedEvents, "booking-service", bookingHandler.ConsumeMessage); err != nil {
+			log.Fatalf("Failed to subscribe to the event bus: %v", err)
+		}
+		log.Printf("Consuming %v from %s", service.ConsumedEvents, *eventBus)
+	}
+
+	// Run the sagas of transactions in the background, resuming those left mid-step by a restart
+	go coordinator.Run(context.Background())
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Booking Service API routes
+	// GET /transactions and /transactions/{id}, POST /transactions/{id}/confirm and /cancel: The
+	// transactions a user buys or sells in, confirmed by the buyer and cancelled by either party,
+	// as specified by api/openapi.yaml
+	api.RegisterTransactionsRoutes(r, bookingHandler)
+	// POST /events: Consume a domain event from the listing service's relay
+	r.HandleFunc("/events", bookingHandler.ConsumeEvent).Methods("POST")
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("booking-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Register with the registry, so the gateway reaches this instance as registry://booking-service
+	if *registryURL != "" {
+		if err := registry.Announce(context.Background(), *registryURL, "booking-service", *advertiseURL, *port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
+	// Start the HTTP server
+	log.Printf("Booking Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Code generated by openapi from booking-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// TransactionsHandler handles the operations of the transactions tag: the sales of listings to the
+// buyers of accepted offers.
+type TransactionsHandler interface {
+	// ListTransactions handles GET /transactions: Get a page of the transactions a user buys or
+	// sells in, most recent first.
+	ListTransactions(w http.ResponseWriter, r *http.Request)
+
+	// GetTransaction handles GET /transactions/{id}: Get a transaction along with its saga log, on
+	// behalf of its buyer or seller.
+	GetTransaction(w http.ResponseWriter, r *http.Request)
+
+	// ConfirmTransaction handles POST /transactions/{id}/confirm: Confirm a held transaction on
+	// behalf of its buyer, having the listing marked as sold.
+	ConfirmTransaction(w http.ResponseWriter, r *http.Request)
+
+	// CancelTransaction handles POST /transactions/{id}/cancel: Cancel a pending or held
+	// transaction on behalf of either party, having the listing's hold released.
+	CancelTransaction(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedTransactionsHandler answers every operation of TransactionsHandler with 501 Not
+// Implemented. Handlers embed it to implement the interface while their operations are written.
+type UnimplementedTransactionsHandler struct{}
+
+// ListTransactions answers GET /transactions with 501 Not Implemented.
+func (UnimplementedTransactionsHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetTransaction answers GET /transactions/{id} with 501 Not Implemented.
+func (UnimplementedTransactionsHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// ConfirmTransaction answers POST /transactions/{id}/confirm with 501 Not Implemented.
+func (UnimplementedTransactionsHandler) ConfirmTransaction(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// CancelTransaction answers POST /transactions/{id}/cancel with 501 Not Implemented.
+func (UnimplementedTransactionsHandler) CancelTransaction(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterTransactionsRoutes registers the routes of the operations of TransactionsHandler on r.
+func RegisterTransactionsRoutes(r *mux.Router, h TransactionsHandler) {
+	r.HandleFunc("/transactions", h.ListTransactions).Methods("GET")
+	r.HandleFunc("/transactions/{id}", h.GetTransaction).Methods("GET")
+	r.HandleFunc("/transactions/{id}/confirm", h.ConfirmTransaction).Methods("POST")
+	r.HandleFunc("/transactions/{id}/cancel", h.CancelTransaction).Methods("POST")
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"apperrors"
+	"booking-service/internal/api"
+	"booking-service/internal/model"
+	"booking-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the body of requests.
+const maxBodyBytes = 64 << 10
+
+// TransactionResponse is the response structure for single transactions.
+type TransactionResponse struct {
+	Result      bool               `json:"result"`
+	Transaction *model.Transaction `json:"transaction,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// TransactionsResponse is the response structure for pages of transactions.
+type TransactionsResponse struct {
+	Result       bool                `json:"result"`
+	Transactions []model.Transaction `json:"transactions"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BookingHandler handles HTTP requests related to transactions.
+type BookingHandler struct {
+	bookingService *service.BookingService
+}
+
+// BookingHandler implements the operations of the spec's transactions tag.
+var _ api.TransactionsHandler = (*BookingHandler)(nil)
+
+// NewBookingHandler creates a new instance of BookingHandler.
+func NewBookingHandler(bookingService *service.BookingService) *BookingHandler {
+	return &BookingHandler{bookingService: bookingService}
+}
+
+// ListTransactions handles GET /transactions requests.
+// It returns a page of the transactions user_id buys or sells in, most recent first, paged
+// with page_num and page_size (default 1 and 10), optionally only those with the given status.
+func (h *BookingHandler) ListTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TransactionsResponse{Result: false, Error: "Invalid user_id format"})
+		return
+	}
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	transactions, err := h.bookingService.List(userID, r.URL.Query().Get("status"), pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "listing transactions of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(TransactionsResponse{Result: true, Transactions: transactions})
+}
+
+// GetTransaction handles GET /transactions/{id} requests.
+// It returns the transaction along with its saga log, if user_id is its buyer or seller.
+func (h *BookingHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, userID, ok := parseTransactionRequest(w, r, r.URL.Query().Get("user_id"))
+	if !ok {
+		return
+	}
+	tx, err := h.bookingService.Get(id, userID)
+	writeTransaction(w, tx, err, "getting")
+}
+
+// ConfirmTransaction handles POST /transactions/{id}/confirm requests.
+// It expects the form field user_id, the buyer, and has the listing marked as sold.
+func (h *BookingHandler) ConfirmTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	id, userID, ok := parseTransactionRequest(w, r, r.PostFormValue("user_id"))
+	if !ok {
+		return
+	}
+	tx, err := h.bookingService.Confirm(id, userID)
+	writeTransaction(w, tx, err, "confirming")
+}
+
+// CancelTransaction handles POST /transactions/{id}/cancel requests.
+// It expects the form field user_id, the buyer or the seller, and has the listing's hold released.
+func (h *BookingHandler) CancelTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	id, userID, ok := parseTransactionRequest(w, r, r.PostFormValue("user_id"))
+	if !ok {
+		return
+	}
+	tx, err := h.bookingService.Cancel(id, userID)
+	writeTransaction(w, tx, err, "cancelling")
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the listing
+// service's event relay. Events of other types are acknowledged without effect. Failures
+// answer 500, so the relay retries the event.
+func (h *BookingHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.bookingService.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "handling event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// parseTransactionRequest reads the transaction ID in the URL path and the acting user's ID,
+// writing a 400 response and returning false if either is invalid.
+func parseTransactionRequest(w http.ResponseWriter, r *http.Request, userValue string) (int64, int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TransactionResponse{Result: false, Error: "Invalid transaction ID format"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseInt(userValue, 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(TransactionResponse{Result: false, Error: "Invalid user_id format"})
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+// writeTransaction writes the response of a request for a single transaction. action
+// describes the request in logs.
+func writeTransaction(w http.ResponseWriter, tx *model.Transaction, err error, action string) {
+	if err != nil {
+		writeError(w, err, "%s transaction", action)
+		return
+	}
+	json.NewEncoder(w).Encode(TransactionResponse{Result: true, Transaction: tx})
+}
+
+// writeError answers an error of BookingService with the status of its code, logging internal
+// errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
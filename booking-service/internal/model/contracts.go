@@ -0,0 +1,27 @@
+package model
+
+import "contracts"
+
+// These conversions break the build when the Booking Service's representations and the
+// contracts its clients decode them into drift apart. They compare field names and types; the
+// JSON names are kept in sync by hand.
+var (
+	_ = contracts.Transaction{
+		ID:            Transaction{}.ID,
+		OfferID:       Transaction{}.OfferID,
+		ListingID:     Transaction{}.ListingID,
+		SellerID:      Transaction{}.SellerID,
+		BuyerID:       Transaction{}.BuyerID,
+		Amount:        Transaction{}.Amount,
+		Currency:      Transaction{}.Currency,
+		Status:        Transaction{}.Status,
+		HoldID:        Transaction{}.HoldID,
+		HoldExpiresAt: Transaction{}.HoldExpiresAt,
+		FailureReason: Transaction{}.FailureReason,
+		CancelledBy:   Transaction{}.CancelledBy,
+		CreatedAt:     Transaction{}.CreatedAt,
+		UpdatedAt:     Transaction{}.UpdatedAt,
+		CompletedAt:   Transaction{}.CompletedAt,
+	}
+	_ = contracts.TransactionStep(Step{})
+)
@@ -0,0 +1,82 @@
+package model
+
+import "encoding/json"
+
+// Statuses of a transaction. Pending, completing and cancelling transactions are in the
+// middle of a saga step, which the coordinator retries until it succeeds or fails for good.
+const (
+	// StatusPending transactions wait for the listing to be held for the buyer.
+	StatusPending = "pending"
+	// StatusHeld transactions hold the listing for the buyer, until they confirm or either
+	// party cancels.
+	StatusHeld = "held"
+	// StatusCompleting transactions were confirmed by the buyer and wait for the listing to be
+	// marked as sold.
+	StatusCompleting = "completing"
+	// StatusCompleted transactions sold the listing to the buyer.
+	StatusCompleted = "completed"
+	// StatusCancelling transactions wait for the listing's hold to be released.
+	StatusCancelling = "cancelling"
+	// StatusCancelled transactions were cancelled by either party.
+	StatusCancelled = "cancelled"
+	// StatusExpired transactions were not confirmed before their hold lapsed.
+	StatusExpired = "expired"
+	// StatusFailed transactions hit an error the saga could not recover from, and were
+	// compensated.
+	StatusFailed = "failed"
+)
+
+// Steps of the saga, each a request to the Listing Service.
+const (
+	StepHoldListing = "hold_listing"
+	StepMarkSold    = "mark_sold"
+	StepReleaseHold = "release_hold" // Compensates hold_listing
+)
+
+// Outcomes of a saga step attempt.
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeRetrying  = "retrying" // Failed for a reason that may not last, retried later
+)
+
+// Transaction is the sale of a listing to the buyer of an accepted offer, coordinated as a
+// saga: the listing is held for the buyer, then marked as sold once they confirm, and the
+// hold is released if the sale fails or is cancelled.
+type Transaction struct {
+	ID            int64   `json:"id"`
+	OfferID       int64   `json:"offer_id"`
+	ListingID     int64   `json:"listing_id"`
+	SellerID      int64   `json:"seller_id"`
+	BuyerID       int64   `json:"buyer_id"`
+	Amount        int64   `json:"amount"` // In minor units of Currency
+	Currency      string  `json:"currency"`
+	Status        string  `json:"status"`
+	HoldID        *int64  `json:"hold_id"`         // The Listing Service's hold, once placed
+	HoldExpiresAt *int64  `json:"hold_expires_at"` // The buyer must confirm before then
+	FailureReason *string `json:"failure_reason"`  // Why the saga failed; nil unless failing or failed
+	CancelledBy   *int64  `json:"cancelled_by"`    // The party who cancelled; nil if the hold was released through the Listing Service
+	CreatedAt     int64   `json:"created_at"`
+	UpdatedAt     int64   `json:"updated_at"`
+	CompletedAt   *int64  `json:"completed_at"`
+	Steps         []Step  `json:"steps,omitempty"` // Saga log, oldest first; only for single transactions
+
+	Attempts      int   `json:"-"` // Failed attempts of the current step
+	NextAttemptAt int64 `json:"-"` // When the coordinator attempts the current step next
+}
+
+// Step is an attempt of a saga step, as recorded in a transaction's saga log.
+type Step struct {
+	Name      string  `json:"name"`
+	Outcome   string  `json:"outcome"`
+	Error     *string `json:"error"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+// Event is a domain event as POSTed by the listing service's relay.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"apperrors"
+	"booking-service/internal/model"
+)
+
+// ErrTransactionNotFound is returned when no transaction has the requested ID.
+var ErrTransactionNotFound = apperrors.New(apperrors.NotFound, "transaction not found")
+
+// TransactionRepository defines the interface for the storage of transactions and their saga logs.
+type TransactionRepository interface {
+	Create(tx *model.Transaction) (bool, error)
+	GetByID(id int64) (*model.Transaction, error)
+	ListByUser(userID int64, status string, page, pageSize int) ([]model.Transaction, error)
+	ListHeldByListing(listingID int64) ([]model.Transaction, error)
+	FetchDue(now int64, limit int) ([]model.Transaction, error)
+	FetchLapsedHolds(now int64, limit int) ([]model.Transaction, error)
+	Save(tx *model.Transaction, fromStatus string, step *model.Step) (bool, error)
+}
+
+// sqliteTransactionRepository implements TransactionRepository for SQLite database.
+type sqliteTransactionRepository struct {
+	db *sql.DB
+}
+
+// createTransactionsTableSQL creates the transactions table. An offer is turned into at most
+// one transaction, however many times its offer.accepted event is delivered.
+const createTransactionsTableSQL = `
+	CREATE TABLE IF NOT EXISTS transactions (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		offer_id INTEGER NOT NULL UNIQUE,
+		listing_id INTEGER NOT NULL,
+		seller_id INTEGER NOT NULL,
+		buyer_id INTEGER NOT NULL,
+		amount INTEGER NOT NULL,
+		currency TEXT NOT NULL,
+		status TEXT NOT NULL,
+		hold_id INTEGER,
+		hold_expires_at INTEGER,
+		failure_reason TEXT,
+		cancelled_by INTEGER,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at INTEGER NOT NULL DEFAULT 0,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		completed_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_transactions_seller_id ON transactions(seller_id);
+	CREATE INDEX IF NOT EXISTS idx_transactions_buyer_id ON transactions(buyer_id);
+	CREATE INDEX IF NOT EXISTS idx_transactions_listing_id ON transactions(listing_id);`
+
+// createTransactionStepsTableSQL creates the transaction_steps table, the saga log recording
+// every attempt of every step of a transaction.
+const createTransactionStepsTableSQL = `
+	CREATE TABLE IF NOT EXISTS transaction_steps (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		transaction_id INTEGER NOT NULL REFERENCES transactions(id),
+		name TEXT NOT NULL,
+		outcome TEXT NOT NULL,
+		error TEXT,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_transaction_steps_transaction_id ON transaction_steps(transaction_id, id);`
+
+// transactionColumns lists the columns selected for a transaction, in the order expected by
+// scanTransaction.
+const transactionColumns = `id, offer_id, listing_id, seller_id, buyer_id, amount, currency, status, hold_id,
+	hold_expires_at, failure_reason, cancelled_by, attempts, next_attempt_at, created_at, updated_at, completed_at`
+
+// scanTransaction reads a transaction selected with transactionColumns.
+func scanTransaction(row rowScanner, tx *model.Transaction) error {
+	return row.Scan(
+		&tx.ID, &tx.OfferID, &tx.ListingID, &tx.SellerID, &tx.BuyerID, &tx.Amount, &tx.Currency, &tx.Status, &tx.HoldID,
+		&tx.HoldExpiresAt, &tx.FailureReason, &tx.CancelledBy, &tx.Attempts, &tx.NextAttemptAt, &tx.CreatedAt, &tx.UpdatedAt, &tx.CompletedAt,
+	)
+}
+
+// NewSQLiteTransactionRepository creates a new instance of sqliteTransactionRepository.
+func NewSQLiteTransactionRepository(db *sql.DB) TransactionRepository {
+	return &sqliteTransactionRepository{db: db}
+}
+
+// Create inserts a transaction, setting its ID. It returns false, inserting nothing, if the
+// transaction's offer already has one.
+func (r *sqliteTransactionRepository) Create(tx *model.Transaction) (bool, error) {
+	result, err := r.db.Exec(
+		`INSERT INTO transactions(offer_id, listing_id, seller_id, buyer_id, amount, currency, status, next_attempt_at, created_at, updated_at)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT(offer_id) DO NOTHING`,
+		tx.OfferID, tx.ListingID, tx.SellerID, tx.BuyerID, tx.Amount, tx.Currency, tx.Status, tx.NextAttemptAt, tx.CreatedAt, tx.UpdatedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to insert transaction: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to insert transaction: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return false, fmt.Errorf("failed to get transaction ID: %w", err)
+	}
+	tx.ID = id
+	return true, nil
+}
+
+// GetByID returns the transaction with the given ID, along with its saga log.
+func (r *sqliteTransactionRepository) GetByID(id int64) (*model.Transaction, error) {
+	tx := &model.Transaction{}
+	err := scanTransaction(r.db.QueryRow(`SELECT `+transactionColumns+` FROM transactions WHERE id = ?`, id), tx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTransactionNotFound
+		}
+		return nil, fmt.Errorf("failed to query transaction: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT name, outcome, error, created_at FROM transaction_steps WHERE transaction_id = ? ORDER BY id`, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transaction steps: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	tx.Steps = []model.Step{}
+	for rows.Next() {
+		var step model.Step
+		if err := rows.Scan(&step.Name, &step.Outcome, &step.Error, &step.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction step: %w", err)
+		}
+		tx.Steps = append(tx.Steps, step)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transaction steps: %w", err)
+	}
+	return tx, nil
+}
+
+// ListByUser returns a page of the transactions a user buys or sells in, most recent first,
+// optionally only those with the given status.
+func (r *sqliteTransactionRepository) ListByUser(userID int64, status string, page, pageSize int) ([]model.Transaction, error) {
+	return r.query(
+		`SELECT `+transactionColumns+` FROM transactions
+		WHERE (seller_id = ? OR buyer_id = ?) AND (? = '' OR status = ?)
+		ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		userID, userID, status, status, pageSize, (page-1)*pageSize,
+	)
+}
+
+// ListHeldByListing returns the held transactions of a listing.
+func (r *sqliteTransactionRepository) ListHeldByListing(listingID int64) ([]model.Transaction, error) {
+	return r.query(
+		`SELECT `+transactionColumns+` FROM transactions WHERE listing_id = ? AND status = ? ORDER BY id`,
+		listingID, model.StatusHeld,
+	)
+}
+
+// FetchDue returns up to limit transactions in the middle of a saga step whose next attempt
+// is due by now, the longest waiting first.
+func (r *sqliteTransactionRepository) FetchDue(now int64, limit int) ([]model.Transaction, error) {
+	return r.query(
+		`SELECT `+transactionColumns+` FROM transactions
+		WHERE status IN (?, ?, ?) AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`,
+		model.StatusPending, model.StatusCompleting, model.StatusCancelling, now, limit,
+	)
+}
+
+// FetchLapsedHolds returns up to limit held transactions whose hold expired by now.
+func (r *sqliteTransactionRepository) FetchLapsedHolds(now int64, limit int) ([]model.Transaction, error) {
+	return r.query(
+		`SELECT `+transactionColumns+` FROM transactions
+		WHERE status = ? AND hold_expires_at <= ? ORDER BY hold_expires_at, id LIMIT ?`,
+		model.StatusHeld, now, limit,
+	)
+}
+
+// Save writes the mutable fields of a transaction, provided its status is still fromStatus,
+// and appends step to its saga log when not nil, atomically. It returns false, writing
+// nothing, if the status changed in the meantime.
+func (r *sqliteTransactionRepository) Save(tx *model.Transaction, fromStatus string, step *model.Step) (bool, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := dbTx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	result, err := dbTx.Exec(
+		`UPDATE transactions SET status = ?, hold_id = ?, hold_expires_at = ?, failure_reason = ?, cancelled_by = ?,
+			attempts = ?, next_attempt_at = ?, updated_at = ?, completed_at = ?
+		WHERE id = ? AND status = ?`,
+		tx.Status, tx.HoldID, tx.HoldExpiresAt, tx.FailureReason, tx.CancelledBy,
+		tx.Attempts, tx.NextAttemptAt, tx.UpdatedAt, tx.CompletedAt, tx.ID, fromStatus,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to update transaction: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update transaction: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+	if step != nil {
+		if _, err := dbTx.Exec(
+			`INSERT INTO transaction_steps(transaction_id, name, outcome, error, created_at) VALUES(?, ?, ?, ?, ?)`,
+			tx.ID, step.Name, step.Outcome, step.Error, step.CreatedAt,
+		); err != nil {
+			return false, fmt.Errorf("failed to insert transaction step: %w", err)
+		}
+	}
+	if err := dbTx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// query runs a query selecting transactionColumns and returns every transaction found.
+func (r *sqliteTransactionRepository) query(query string, args ...any) ([]model.Transaction, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	transactions := []model.Transaction{}
+	for rows.Next() {
+		var tx model.Transaction
+		if err := scanTransaction(rows, &tx); err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read transactions: %w", err)
+	}
+	return transactions, nil
+}
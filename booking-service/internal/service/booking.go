@@ -0,0 +1,249 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"apperrors"
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+)
+
+// MaxPageSize is the maximum number of transactions per page.
+const MaxPageSize = 100
+
+// Events that start or end transactions.
+const (
+	eventOfferAccepted       = "offer.accepted"
+	eventListingHoldReleased = "listing.hold_released"
+	eventListingSold         = "listing.sold"
+	eventListingDeleted      = "listing.deleted"
+)
+
+// Reasons of listing.hold_released events.
+const (
+	holdExpired  = "expired"
+	holdReleased = "released"
+)
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus.
+var ConsumedEvents = []string{eventOfferAccepted, eventListingHoldReleased, eventListingSold, eventListingDeleted}
+
+// Errors returned by BookingService.
+var (
+	// ErrInvalidQuery is returned for transaction lookups that cannot be run.
+	ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+	// ErrForbidden is returned when users act on transactions they are not a party to, or
+	// sellers confirm transactions.
+	ErrForbidden = apperrors.New(apperrors.Forbidden, "forbidden")
+	// ErrInvalidState is returned when a transaction's status does not allow a request.
+	ErrInvalidState = apperrors.New(apperrors.Conflict, "invalid transaction state")
+	// ErrInvalidPayload is returned for events whose payload cannot be handled.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+	// ErrTransactionNotFound is returned when no transaction has the requested ID.
+	ErrTransactionNotFound = repository.ErrTransactionNotFound
+)
+
+// statuses lists every transaction status, for validating status filters.
+var statuses = []string{
+	model.StatusPending, model.StatusHeld, model.StatusCompleting, model.StatusCompleted,
+	model.StatusCancelling, model.StatusCancelled, model.StatusExpired, model.StatusFailed,
+}
+
+// BookingService turns accepted offers into transactions, and lets their parties confirm or
+// cancel them. The Coordinator runs the saga of each transaction.
+type BookingService struct {
+	repo        repository.TransactionRepository
+	coordinator *Coordinator
+}
+
+// NewBookingService creates a new instance of BookingService.
+func NewBookingService(repo repository.TransactionRepository, coordinator *Coordinator) *BookingService {
+	return &BookingService{repo: repo, coordinator: coordinator}
+}
+
+// Get returns a transaction, along with its saga log. Only its buyer and seller may see it.
+func (s *BookingService) Get(id, userID int64) (*model.Transaction, error) {
+	tx, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if userID != tx.BuyerID && userID != tx.SellerID {
+		return nil, fmt.Errorf("%w: user %d is not a party to transaction %d", ErrForbidden, userID, id)
+	}
+	return tx, nil
+}
+
+// List returns a page of the transactions a user buys or sells in, most recent first,
+// optionally only those with the given status.
+func (s *BookingService) List(userID int64, status string, pageNum, pageSize int) ([]model.Transaction, error) {
+	if status != "" && !slices.Contains(statuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q (expected one of %s)", ErrInvalidQuery, status, strings.Join(statuses, ", "))
+	}
+	if pageSize > MaxPageSize {
+		return nil, fmt.Errorf("%w: page_size must be at most %d", ErrInvalidQuery, MaxPageSize)
+	}
+	return s.repo.ListByUser(userID, status, pageNum, pageSize)
+}
+
+// Confirm completes a held transaction on behalf of its buyer: the listing is then marked as
+// sold by the coordinator.
+func (s *BookingService) Confirm(id, userID int64) (*model.Transaction, error) {
+	tx, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != tx.BuyerID {
+		return nil, fmt.Errorf("%w: only the buyer may confirm a transaction", ErrForbidden)
+	}
+	if tx.Status != model.StatusHeld {
+		return nil, fmt.Errorf("%w: transaction is %s, only held transactions can be confirmed", ErrInvalidState, tx.Status)
+	}
+	now := time.Now().UnixMicro()
+	if tx.HoldExpiresAt != nil && *tx.HoldExpiresAt <= now {
+		return nil, fmt.Errorf("%w: the hold of the listing has expired", ErrInvalidState)
+	}
+
+	tx.Status = model.StatusCompleting
+	return s.transition(tx, model.StatusHeld, now)
+}
+
+// Cancel cancels a pending or held transaction on behalf of either party: the listing's hold
+// is then released by the coordinator.
+func (s *BookingService) Cancel(id, userID int64) (*model.Transaction, error) {
+	tx, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Status != model.StatusPending && tx.Status != model.StatusHeld {
+		return nil, fmt.Errorf("%w: transaction is %s, only pending and held transactions can be cancelled", ErrInvalidState, tx.Status)
+	}
+
+	fromStatus := tx.Status
+	tx.Status = model.StatusCancelling
+	tx.CancelledBy = &userID
+	return s.transition(tx, fromStatus, time.Now().UnixMicro())
+}
+
+// transition saves a transaction moved by a party from fromStatus to a status the
+// coordinator advances, and has the coordinator advance it right away.
+func (s *BookingService) transition(tx *model.Transaction, fromStatus string, now int64) (*model.Transaction, error) {
+	tx.Attempts, tx.NextAttemptAt, tx.UpdatedAt = 0, now, now
+	saved, err := s.repo.Save(tx, fromStatus, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, fmt.Errorf("%w: transaction is no longer %s", ErrInvalidState, fromStatus)
+	}
+	s.coordinator.Kick()
+	return s.repo.GetByID(tx.ID)
+}
+
+// HandleEvent creates a transaction for each accepted offer, and ends held transactions
+// whose hold is released through the Listing Service, or whose listing is sold outside the
+// transaction or deleted. It returns false for events of other types. Events are delivered
+// at least once, and handling one again is harmless.
+func (s *BookingService) HandleEvent(event model.Event) (bool, error) {
+	switch event.Type {
+	case eventOfferAccepted:
+		var p struct {
+			OfferID   int64  `json:"offer_id"`
+			ListingID int64  `json:"listing_id"`
+			SellerID  int64  `json:"seller_id"`
+			BuyerID   int64  `json:"buyer_id"`
+			Amount    int64  `json:"amount"`
+			Currency  string `json:"currency"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.OfferID <= 0 || p.ListingID <= 0 ||
+			p.SellerID <= 0 || p.BuyerID <= 0 || p.Amount <= 0 || p.Currency == "" {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		now := time.Now().UnixMicro()
+		tx := &model.Transaction{
+			OfferID:       p.OfferID,
+			ListingID:     p.ListingID,
+			SellerID:      p.SellerID,
+			BuyerID:       p.BuyerID,
+			Amount:        p.Amount,
+			Currency:      p.Currency,
+			Status:        model.StatusPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}
+		created, err := s.repo.Create(tx)
+		if err != nil {
+			return false, err
+		}
+		if created {
+			log.Printf("Created transaction %d for offer %d on listing %d", tx.ID, p.OfferID, p.ListingID)
+			s.coordinator.Kick()
+		}
+	case eventListingHoldReleased:
+		var p struct {
+			HoldID    int64  `json:"hold_id"`
+			ListingID int64  `json:"listing_id"`
+			Reason    string `json:"reason"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.HoldID <= 0 || p.ListingID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		var status string
+		switch p.Reason {
+		case holdExpired:
+			status = model.StatusExpired
+		case holdReleased:
+			status = model.StatusCancelled
+		default:
+			return false, nil
+		}
+		if err := s.endHeld(p.ListingID, &p.HoldID, status, nil); err != nil {
+			return false, err
+		}
+	case eventListingSold, eventListingDeleted:
+		var p struct {
+			ListingID int64 `json:"listing_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		reason := "the listing was sold outside the transaction"
+		if event.Type == eventListingDeleted {
+			reason = "the listing was deleted"
+		}
+		if err := s.endHeld(p.ListingID, nil, model.StatusFailed, &reason); err != nil {
+			return false, err
+		}
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// endHeld moves the held transactions of a listing, only the one of the given hold when not
+// nil, to status. Their hold is gone already, so there is nothing to compensate.
+func (s *BookingService) endHeld(listingID int64, holdID *int64, status string, failureReason *string) error {
+	held, err := s.repo.ListHeldByListing(listingID)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UnixMicro()
+	for _, tx := range held {
+		if holdID != nil && (tx.HoldID == nil || *tx.HoldID != *holdID) {
+			continue
+		}
+		tx.Status = status
+		tx.FailureReason = failureReason
+		tx.UpdatedAt = now
+		if _, err := s.repo.Save(&tx, model.StatusHeld, nil); err != nil {
+			return err
+		}
+		log.Printf("Transaction %d is %s", tx.ID, status)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+	"contracts"
+	"contracts/client"
+)
+
+const (
+	// coordinatorBatchSize is the maximum number of transactions advanced per round.
+	coordinatorBatchSize = 100
+	// maxAttempts is the number of attempts made per saga step before it is given up.
+	maxAttempts = 8
+	// retryDelay is the delay before the first retry, doubling after each failed attempt up to maxRetryDelay.
+	retryDelay    = 5 * time.Second
+	maxRetryDelay = 5 * time.Minute
+	// maxLoggedErrorLength bounds the errors kept in the saga log.
+	maxLoggedErrorLength = 500
+)
+
+// ListingService is the part of the Listing Service's client the coordinator uses.
+type ListingService interface {
+	GetListing(id int64) (*contracts.Listing, error)
+	HoldListing(listingID, sellerID, buyerID int64, minutes int) (*contracts.ListingHold, error)
+	ReleaseHold(listingID, userID int64) error
+	MarkSold(listingID, sellerID int64) error
+}
+
+// Coordinator runs the saga of each transaction, one step at a time: it holds the listing for
+// the buyer of pending transactions, marks the listing of completing transactions as sold,
+// and releases the hold of cancelling transactions. Steps the Listing Service rejects fail
+// right away, and compensation follows: a transaction whose listing cannot be marked as sold
+// releases its hold. Other failures, like the Listing Service being down, are retried with
+// exponential backoff. Progress is persisted after each step, so sagas resume after a restart.
+type Coordinator struct {
+	repo        repository.TransactionRepository
+	listings    ListingService
+	holdMinutes int
+	interval    time.Duration
+	kick        chan struct{}
+}
+
+// NewCoordinator creates a new Coordinator polling for due transactions every interval, which
+// holds listings for holdMinutes minutes.
+func NewCoordinator(repo repository.TransactionRepository, listings ListingService, holdMinutes int, interval time.Duration) *Coordinator {
+	return &Coordinator{repo: repo, listings: listings, holdMinutes: holdMinutes, interval: interval, kick: make(chan struct{}, 1)}
+}
+
+// Run advances due transactions until ctx is cancelled.
+func (c *Coordinator) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.advanceDue()
+		c.expireLapsedHolds()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-c.kick:
+		}
+	}
+}
+
+// Kick makes the coordinator run a round now rather than at the next tick, e.g. after a
+// transaction was created or confirmed.
+func (c *Coordinator) Kick() {
+	select {
+	case c.kick <- struct{}{}:
+	default: // A round is already due
+	}
+}
+
+// advanceDue performs a single round.
+func (c *Coordinator) advanceDue() {
+	due, err := c.repo.FetchDue(time.Now().UnixMicro(), coordinatorBatchSize)
+	if err != nil {
+		log.Printf("Error fetching due transactions: %v", err)
+		return
+	}
+	for _, tx := range due {
+		switch tx.Status {
+		case model.StatusPending:
+			c.holdListing(tx)
+		case model.StatusCompleting:
+			c.markSold(tx)
+		case model.StatusCancelling:
+			c.releaseHold(tx)
+		}
+	}
+}
+
+// expireLapsedHolds expires the held transactions the buyer did not confirm in time. Their
+// holds lapse on their own, so there is nothing to compensate.
+func (c *Coordinator) expireLapsedHolds() {
+	now := time.Now().UnixMicro()
+	lapsed, err := c.repo.FetchLapsedHolds(now, coordinatorBatchSize)
+	if err != nil {
+		log.Printf("Error fetching lapsed holds: %v", err)
+		return
+	}
+	for _, tx := range lapsed {
+		tx.Status = model.StatusExpired
+		c.save(&tx, model.StatusHeld, nil, now)
+	}
+}
+
+// holdListing runs the first step of a pending transaction: holding its listing for the buyer.
+func (c *Coordinator) holdListing(tx model.Transaction) {
+	hold, err := c.listings.HoldListing(tx.ListingID, tx.SellerID, tx.BuyerID, c.holdMinutes)
+	var listingErr *client.ListingError
+	if errors.As(err, &listingErr) && listingErr.StatusCode == http.StatusConflict {
+		// The hold of an attempt whose response was lost, or one the seller placed for the
+		// buyer already, serves as well
+		listing, getErr := c.listings.GetListing(tx.ListingID)
+		if getErr != nil {
+			err = getErr
+		} else if listing != nil && listing.Hold != nil && listing.Hold.BuyerID == tx.BuyerID {
+			hold, err = listing.Hold, nil
+		}
+	}
+
+	now := time.Now().UnixMicro()
+	fromStatus := tx.Status
+	switch {
+	case err == nil:
+		tx.Status = model.StatusHeld
+		tx.HoldID, tx.HoldExpiresAt = &hold.ID, &hold.ExpiresAt
+		tx.Attempts, tx.NextAttemptAt = 0, 0
+		c.save(&tx, fromStatus, &model.Step{Name: model.StepHoldListing, Outcome: model.OutcomeSucceeded}, now)
+	case errors.As(err, &listingErr):
+		// Nothing was held, so there is nothing to compensate
+		c.fail(&tx, model.StatusFailed, model.StepHoldListing, err, now)
+	default:
+		c.retry(&tx, model.StepHoldListing, err, model.StatusFailed, now)
+	}
+}
+
+// markSold runs the second step of a completing transaction: marking its listing as sold,
+// which releases the hold.
+func (c *Coordinator) markSold(tx model.Transaction) {
+	err := c.listings.MarkSold(tx.ListingID, tx.SellerID)
+	var listingErr *client.ListingError
+	if errors.As(err, &listingErr) && listingErr.StatusCode == http.StatusConflict {
+		// The listing is sold already: by an attempt whose response was lost if the sale released
+		// this transaction's hold, outside the transaction otherwise
+		listing, getErr := c.listings.GetListing(tx.ListingID)
+		if getErr != nil {
+			err = getErr
+		} else if listing != nil && listing.SoldAt != nil && soldThroughHold(listing, tx) {
+			err = nil
+		}
+	}
+
+	now := time.Now().UnixMicro()
+	fromStatus := tx.Status
+	switch {
+	case err == nil:
+		tx.Status = model.StatusCompleted
+		tx.CompletedAt = &now
+		tx.Attempts, tx.NextAttemptAt = 0, 0
+		c.save(&tx, fromStatus, &model.Step{Name: model.StepMarkSold, Outcome: model.OutcomeSucceeded}, now)
+	case errors.As(err, &listingErr):
+		// Compensate by releasing the hold
+		c.fail(&tx, model.StatusCancelling, model.StepMarkSold, err, now)
+	default:
+		c.retry(&tx, model.StepMarkSold, err, model.StatusCancelling, now)
+	}
+}
+
+// soldThroughHold returns whether the sale of a listing released the hold of a transaction.
+func soldThroughHold(listing *contracts.Listing, tx model.Transaction) bool {
+	hold := listing.SoldHold
+	return hold != nil && tx.HoldID != nil && hold.ID == *tx.HoldID && hold.BuyerID == tx.BuyerID
+}
+
+// releaseHold runs the compensation of a cancelling transaction: releasing its listing's hold.
+func (c *Coordinator) releaseHold(tx model.Transaction) {
+	err := c.listings.ReleaseHold(tx.ListingID, tx.SellerID)
+	var listingErr *client.ListingError
+	if errors.As(err, &listingErr) && listingErr.StatusCode == http.StatusNotFound {
+		// The hold lapsed, or was released or deleted with the listing, already
+		err = nil
+	}
+
+	now := time.Now().UnixMicro()
+	fromStatus := tx.Status
+	finalStatus := model.StatusCancelled
+	if tx.FailureReason != nil {
+		finalStatus = model.StatusFailed
+	}
+	switch {
+	case err == nil:
+		tx.Status = finalStatus
+		tx.Attempts, tx.NextAttemptAt = 0, 0
+		c.save(&tx, fromStatus, &model.Step{Name: model.StepReleaseHold, Outcome: model.OutcomeSucceeded}, now)
+	case errors.As(err, &listingErr):
+		// Retrying would not help, and the hold lapses on its own anyway
+		log.Printf("Could not release hold of listing %d for transaction %d, leaving it to lapse: %v", tx.ListingID, tx.ID, err)
+		tx.Status = finalStatus
+		tx.Attempts, tx.NextAttemptAt = 0, 0
+		c.save(&tx, fromStatus, failedStep(model.StepReleaseHold, model.OutcomeFailed, err, now), now)
+	default:
+		c.retry(&tx, model.StepReleaseHold, err, finalStatus, now)
+	}
+}
+
+// fail records a step failing for good, moving the transaction to status: failed when there
+// is nothing to compensate, cancelling otherwise.
+func (c *Coordinator) fail(tx *model.Transaction, status, stepName string, err error, now int64) {
+	fromStatus := tx.Status
+	reason := truncate(err.Error())
+	tx.Status = status
+	tx.FailureReason = &reason
+	tx.Attempts, tx.NextAttemptAt = 0, now
+	c.save(tx, fromStatus, failedStep(stepName, model.OutcomeFailed, err, now), now)
+	if status == model.StatusCancelling {
+		c.Kick()
+	}
+}
+
+// retry records a step failing for a reason that may not last, scheduling another attempt
+// after a backoff. After maxAttempts attempts, the step is given up and the transaction
+// moves to giveUpStatus, like for a step that failed for good.
+func (c *Coordinator) retry(tx *model.Transaction, stepName string, err error, giveUpStatus string, now int64) {
+	attempts := tx.Attempts + 1
+	if attempts >= maxAttempts {
+		log.Printf("Giving up %s for transaction %d after %d attempts: %v", stepName, tx.ID, attempts, err)
+		if stepName != model.StepReleaseHold {
+			c.fail(tx, giveUpStatus, stepName, err, now)
+			return
+		}
+		// The compensation is given up without changing why the transaction ends, the hold
+		// lapses on its own
+		fromStatus := tx.Status
+		tx.Status = giveUpStatus
+		tx.Attempts, tx.NextAttemptAt = 0, 0
+		c.save(tx, fromStatus, failedStep(stepName, model.OutcomeFailed, err, now), now)
+		return
+	}
+
+	tx.Attempts = attempts
+	tx.NextAttemptAt = time.UnixMicro(now).Add(backoff(attempts)).UnixMicro()
+	c.save(tx, tx.Status, failedStep(stepName, model.OutcomeRetrying, err, now), now)
+}
+
+// save persists a transaction whose status was fromStatus, along with a step of its saga log.
+func (c *Coordinator) save(tx *model.Transaction, fromStatus string, step *model.Step, now int64) {
+	tx.UpdatedAt = now
+	if step != nil {
+		step.CreatedAt = now
+	}
+	saved, err := c.repo.Save(tx, fromStatus, step)
+	if err != nil {
+		log.Printf("Error saving transaction %d: %v", tx.ID, err)
+		return
+	}
+	if !saved {
+		// A party or an event moved the transaction on meanwhile; the next round picks up from there
+		log.Printf("Transaction %d is no longer %s, dropping its %s update", tx.ID, fromStatus, tx.Status)
+	}
+}
+
+// failedStep returns a saga log entry of a failed attempt of a step.
+func failedStep(name, outcome string, err error, now int64) *model.Step {
+	message := truncate(err.Error())
+	return &model.Step{Name: name, Outcome: outcome, Error: &message, CreatedAt: now}
+}
+
+// truncate bounds the length of an error message kept in the database.
+func truncate(message string) string {
+	if len(message) > maxLoggedErrorLength {
+		return message[:maxLoggedErrorLength]
+	}
+	return message
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	delay := retryDelay << (attempts - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"booking-service/internal/model"
+	"booking-service/internal/repository"
+	"contracts"
+	"contracts/client"
+)
+
+// memoryRepository is an in-memory TransactionRepository.
+type memoryRepository struct {
+	mu           sync.Mutex
+	transactions map[int64]*model.Transaction
+	nextID       int64
+}
+
+func newMemoryRepository() *memoryRepository {
+	return &memoryRepository{transactions: make(map[int64]*model.Transaction)}
+}
+
+func (r *memoryRepository) Create(tx *model.Transaction) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.transactions {
+		if existing.OfferID == tx.OfferID {
+			return false, nil
+		}
+	}
+	r.nextID++
+	tx.ID = r.nextID
+	stored := *tx
+	r.transactions[tx.ID] = &stored
+	return true, nil
+}
+
+func (r *memoryRepository) GetByID(id int64) (*model.Transaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tx, ok := r.transactions[id]
+	if !ok {
+		return nil, repository.ErrTransactionNotFound
+	}
+	found := *tx
+	found.Steps = slices.Clone(tx.Steps)
+	return &found, nil
+}
+
+func (r *memoryRepository) ListByUser(userID int64, status string, page, pageSize int) ([]model.Transaction, error) {
+	return r.filter(func(tx *model.Transaction) bool {
+		return (tx.BuyerID == userID || tx.SellerID == userID) && (status == "" || tx.Status == status)
+	}), nil
+}
+
+func (r *memoryRepository) ListHeldByListing(listingID int64) ([]model.Transaction, error) {
+	return r.filter(func(tx *model.Transaction) bool {
+		return tx.ListingID == listingID && tx.Status == model.StatusHeld
+	}), nil
+}
+
+func (r *memoryRepository) FetchDue(now int64, limit int) ([]model.Transaction, error) {
+	return r.filter(func(tx *model.Transaction) bool {
+		switch tx.Status {
+		case model.StatusPending, model.StatusCompleting, model.StatusCancelling:
+			return tx.NextAttemptAt <= now
+		}
+		return false
+	}), nil
+}
+
+func (r *memoryRepository) FetchLapsedHolds(now int64, limit int) ([]model.Transaction, error) {
+	return r.filter(func(tx *model.Transaction) bool {
+		return tx.Status == model.StatusHeld && tx.HoldExpiresAt != nil && *tx.HoldExpiresAt <= now
+	}), nil
+}
+
+func (r *memoryRepository) Save(tx *model.Transaction, fromStatus string, step *model.Step) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.transactions[tx.ID]
+	if !ok || stored.Status != fromStatus {
+		return false, nil
+	}
+	steps := stored.Steps
+	*stored = *tx
+	stored.Steps = steps
+	if step != nil {
+		stored.Steps = append(stored.Steps, *step)
+	}
+	return true, nil
+}
+
+// filter returns the transactions matching keep, by ID.
+func (r *memoryRepository) filter(keep func(tx *model.Transaction) bool) []model.Transaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matching []model.Transaction
+	for _, tx := range r.transactions {
+		if keep(tx) {
+			matching = append(matching, *tx)
+		}
+	}
+	slices.SortFunc(matching, func(a, b model.Transaction) int { return int(a.ID - b.ID) })
+	return matching
+}
+
+// fakeListingService is a ListingService answering with the errors set on it, and recording
+// the requests made to it.
+type fakeListingService struct {
+	listing    *contracts.Listing
+	holdErr    error
+	markErr    error
+	releaseErr error
+	getErr     error
+	calls      []string
+}
+
+func (f *fakeListingService) GetListing(id int64) (*contracts.Listing, error) {
+	f.calls = append(f.calls, "get")
+	return f.listing, f.getErr
+}
+
+func (f *fakeListingService) HoldListing(listingID, sellerID, buyerID int64, minutes int) (*contracts.ListingHold, error) {
+	f.calls = append(f.calls, "hold")
+	if f.holdErr != nil {
+		return nil, f.holdErr
+	}
+	return &contracts.ListingHold{ID: 7, BuyerID: buyerID, ExpiresAt: time.Now().Add(time.Duration(minutes) * time.Minute).UnixMicro()}, nil
+}
+
+func (f *fakeListingService) ReleaseHold(listingID, userID int64) error {
+	f.calls = append(f.calls, "release")
+	return f.releaseErr
+}
+
+func (f *fakeListingService) MarkSold(listingID, sellerID int64) error {
+	f.calls = append(f.calls, "sold")
+	return f.markErr
+}
+
+// conflict is the Listing Service rejecting a request with 409 Conflict.
+var conflict = &client.ListingError{StatusCode: http.StatusConflict, Messages: []string{"listing is already sold"}}
+
+// newTestCoordinator returns a coordinator over an in-memory repository holding a completing
+// transaction of buyer 3 whose listing is held by hold 7.
+func newTestCoordinator(t *testing.T, listings *fakeListingService) (*Coordinator, *memoryRepository, int64) {
+	t.Helper()
+	repo := newMemoryRepository()
+	holdID, expiresAt := int64(7), time.Now().Add(time.Hour).UnixMicro()
+	tx := &model.Transaction{
+		OfferID: 1, ListingID: 1, SellerID: 2, BuyerID: 3, Amount: 1000, Currency: "USD",
+		Status: model.StatusCompleting, HoldID: &holdID, HoldExpiresAt: &expiresAt,
+	}
+	if _, err := repo.Create(tx); err != nil {
+		t.Fatalf("Failed to create transaction: %v", err)
+	}
+	return NewCoordinator(repo, listings, 15, time.Minute), repo, tx.ID
+}
+
+// getTransaction returns a transaction of repo, failing the test if it is missing.
+func getTransaction(t *testing.T, repo *memoryRepository, id int64) *model.Transaction {
+	t.Helper()
+	tx, err := repo.GetByID(id)
+	if err != nil {
+		t.Fatalf("Failed to get transaction: %v", err)
+	}
+	return tx
+}
+
+// TestMarkSoldRejectedCompensates checks that a sale the Listing Service rejects releases the
+// hold, ending the transaction as failed.
+func TestMarkSoldRejectedCompensates(t *testing.T) {
+	listings := &fakeListingService{markErr: &client.ListingError{StatusCode: http.StatusNotFound}}
+	c, repo, id := newTestCoordinator(t, listings)
+
+	c.advanceDue()
+	tx := getTransaction(t, repo, id)
+	if tx.Status != model.StatusCancelling || tx.FailureReason == nil {
+		t.Fatalf("Expected a failing cancelling transaction, got %s", tx.Status)
+	}
+
+	c.advanceDue()
+	tx = getTransaction(t, repo, id)
+	if tx.Status != model.StatusFailed {
+		t.Errorf("Expected status %s after compensation, got %s", model.StatusFailed, tx.Status)
+	}
+	if want := []string{"sold", "release"}; !slices.Equal(listings.calls, want) {
+		t.Errorf("Expected requests %v, got %v", want, listings.calls)
+	}
+	var steps []string
+	for _, step := range tx.Steps {
+		steps = append(steps, step.Name+":"+step.Outcome)
+	}
+	if want := []string{"mark_sold:failed", "release_hold:succeeded"}; !slices.Equal(steps, want) {
+		t.Errorf("Expected saga log %v, got %v", want, steps)
+	}
+}
+
+// TestMarkSoldRetriesWithBackoff checks that a sale failing for a reason that may not last is
+// retried later, with a delay doubling per attempt, and given up after maxAttempts attempts.
+func TestMarkSoldRetriesWithBackoff(t *testing.T) {
+	listings := &fakeListingService{markErr: errors.New("connection refused")}
+	c, repo, id := newTestCoordinator(t, listings)
+
+	var delays []time.Duration
+	for range maxAttempts - 1 {
+		before := time.Now().UnixMicro()
+		c.advanceDue()
+		tx := getTransaction(t, repo, id)
+		if tx.Status != model.StatusCompleting {
+			t.Fatalf("Expected status %s while retrying, got %s", model.StatusCompleting, tx.Status)
+		}
+		delays = append(delays, time.Duration(tx.NextAttemptAt-before)*time.Microsecond)
+
+		// Nothing is attempted until the delay passes
+		c.advanceDue()
+		if n := len(listings.calls); n != len(delays) {
+			t.Fatalf("Expected %d requests before the delay passed, got %d", len(delays), n)
+		}
+		tx.NextAttemptAt = 0
+		if _, err := repo.Save(tx, tx.Status, nil); err != nil {
+			t.Fatalf("Failed to save transaction: %v", err)
+		}
+	}
+	for i, delay := range delays {
+		want := backoff(i + 1)
+		if delay < want || delay > want+time.Second {
+			t.Errorf("Expected a delay of %v after attempt %d, got %v", want, i+1, delay)
+		}
+	}
+
+	c.advanceDue()
+	tx := getTransaction(t, repo, id)
+	if tx.Status != model.StatusCancelling {
+		t.Errorf("Expected status %s once given up, got %s", model.StatusCancelling, tx.Status)
+	}
+}
+
+// TestMarkSoldConflict checks that a retried sale answered with 409 Conflict only completes
+// the transaction when the sale released the transaction's hold.
+func TestMarkSoldConflict(t *testing.T) {
+	soldAt := time.Now().UnixMicro()
+	tests := []struct {
+		name    string
+		listing *contracts.Listing
+		getErr  error
+		status  string
+	}{
+		{"SoldThroughHold", &contracts.Listing{SoldAt: &soldAt, SoldHold: &contracts.ListingHold{ID: 7, BuyerID: 3}}, nil, model.StatusCompleted},
+		{"SoldThroughOtherHold", &contracts.Listing{SoldAt: &soldAt, SoldHold: &contracts.ListingHold{ID: 8, BuyerID: 4}}, nil, model.StatusCancelling},
+		{"SoldWithoutHold", &contracts.Listing{SoldAt: &soldAt}, nil, model.StatusCancelling},
+		{"NotSold", &contracts.Listing{}, nil, model.StatusCancelling},
+		{"Deleted", nil, nil, model.StatusCancelling},
+		{"Unreachable", nil, errors.New("connection refused"), model.StatusCompleting},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			listings := &fakeListingService{markErr: conflict, listing: test.listing, getErr: test.getErr}
+			c, repo, id := newTestCoordinator(t, listings)
+
+			// The first attempt's response was lost
+			tx := getTransaction(t, repo, id)
+			tx.Attempts = 1
+			c.markSold(*tx)
+			tx = getTransaction(t, repo, id)
+			if tx.Status != test.status {
+				t.Errorf("Expected status %s, got %s", test.status, tx.Status)
+			}
+			if test.status == model.StatusCompleting && tx.Attempts != 2 {
+				t.Errorf("Expected a retry, got %d attempts", tx.Attempts)
+			}
+		})
+	}
+}
+
+// TestHandleEventIdempotent checks that delivering the events of a transaction twice has the
+// effect of delivering them once.
+func TestHandleEventIdempotent(t *testing.T) {
+	repo := newMemoryRepository()
+	listings := &fakeListingService{}
+	s := NewBookingService(repo, NewCoordinator(repo, listings, 15, time.Minute))
+
+	accepted := model.Event{ID: 1, Type: eventOfferAccepted, Payload: json.RawMessage(
+		`{"offer_id": 1, "listing_id": 1, "seller_id": 2, "buyer_id": 3, "amount": 1000, "currency": "USD"}`)}
+	for range 2 {
+		if handled, err := s.HandleEvent(accepted); !handled || err != nil {
+			t.Fatalf("Failed to handle %s: %v", accepted.Type, err)
+		}
+	}
+	all, _ := repo.ListByUser(3, "", 1, MaxPageSize)
+	if len(all) != 1 {
+		t.Fatalf("Expected 1 transaction, got %d", len(all))
+	}
+	id := all[0].ID
+
+	s.coordinator.advanceDue()
+	if tx := getTransaction(t, repo, id); tx.Status != model.StatusHeld {
+		t.Fatalf("Expected status %s, got %s", model.StatusHeld, tx.Status)
+	}
+
+	released := model.Event{ID: 2, Type: eventListingHoldReleased, Payload: json.RawMessage(
+		`{"hold_id": 7, "listing_id": 1, "reason": "released"}`)}
+	for range 2 {
+		if handled, err := s.HandleEvent(released); !handled || err != nil {
+			t.Fatalf("Failed to handle %s: %v", released.Type, err)
+		}
+	}
+	tx := getTransaction(t, repo, id)
+	if tx.Status != model.StatusCancelled {
+		t.Errorf("Expected status %s, got %s", model.StatusCancelled, tx.Status)
+	}
+	if want := []string{"hold"}; !slices.Equal(listings.calls, want) {
+		t.Errorf("Expected requests %v, got %v", want, listings.calls)
+	}
+}
@@ -0,0 +1,49 @@
+module allinone
+
+go 1.24.4
+
+require (
+	config v0.0.0
+	public-api-layer v0.0.0
+	user-service v0.0.0
+)
+
+require (
+	apperrors v0.0.0 // indirect
+	bus v0.0.0 // indirect
+	contracts v0.0.0 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/go-sql-driver/mysql v1.9.3 // indirect
+	github.com/gorilla/mux v1.8.1 // indirect
+	github.com/jackc/pgpassfile v1.0.0 // indirect
+	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
+	github.com/jackc/pgx/v5 v5.8.0 // indirect
+	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/mattn/go-sqlite3 v1.14.28 // indirect
+	github.com/nats-io/nats.go v1.48.0 // indirect
+	github.com/nats-io/nkeys v0.4.11 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/segmentio/kafka-go v0.4.50 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sync v0.17.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/text v0.29.0 // indirect
+	httpmiddleware v0.0.0 // indirect
+	money v0.0.0 // indirect
+	registry v0.0.0 // indirect
+)
+
+replace (
+	apperrors => ../../pkg/apperrors
+	bus => ../../pkg/bus
+	config => ../../pkg/config
+	contracts => ../../pkg/contracts
+	contracttest => ../../pkg/contracttest
+	httpmiddleware => ../../pkg/httpmiddleware
+	money => ../../pkg/money
+	public-api-layer => ../../public-api
+	registry => ../../pkg/registry
+	user-service => ../../user-service
+)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// listingServiceStartTimeout is how long the Listing Service may take to answer its first ping.
+const listingServiceStartTimeout = 15 * time.Second
+
+// defaultListingServiceDir returns the directory of the Listing Service in this repository, as
+// found from the source of this command.
+func defaultListingServiceDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "listing-service")
+}
+
+// startListingService runs the Listing Service script of dir with python as a child process in
+// dataDir, where it creates its listings.db, and waits until it answers pings on port. The
+// process is killed when ctx is done; the returned channel is closed once it has exited.
+func startListingService(ctx context.Context, python, dir, dataDir string, port int, userServiceURL string) (<-chan struct{}, error) {
+	cmd := exec.CommandContext(ctx, python,
+		filepath.Join(dir, "listing_service.py"),
+		"--port="+strconv.Itoa(port),
+		"--debug=false", // Autoreload would fork the service, leaving a process behind
+		"--user_service_url="+userServiceURL,
+	)
+	cmd.Dir = dataDir
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start the Listing Service with %s: %w", python, err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/listings/ping", port)
+	deadline := time.Now().Add(listingServiceStartTimeout)
+	for {
+		resp, err := http.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return exited, nil
+			}
+		}
+		select {
+		case <-exited:
+			return nil, fmt.Errorf("the Listing Service exited before it was ready (is tornado installed?)")
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			<-exited
+			return nil, fmt.Errorf("the Listing Service was not ready after %s", listingServiceStartTimeout)
+		}
+	}
+}
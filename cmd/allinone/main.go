@@ -0,0 +1,144 @@
+// Command allinone runs the gateway, the User Service and the Listing Service from one command,
+// so that the demo starts with a single go run, e.g. in workshops. The gateway and the User
+// Service run in this process; with -in-memory, the default, the gateway calls the User Service
+// without going through a socket. The Listing Service is written in Python, so it runs as a
+// child process, stopped along with this one; -listing-service-url uses one already running
+// instead.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"config"
+	gateway "public-api-layer/app"
+	userservice "user-service/app"
+)
+
+// userServiceHost is the host the gateway reaches the in-process User Service at.
+const userServiceHost = "user-service"
+
+// settings are the settings of the command.
+type settings struct {
+	Port               int    `config:"port" min:"1" max:"65535" usage:"The port number to serve the gateway on"`
+	UserServicePort    int    `config:"user-service-port" min:"1" max:"65535" usage:"The port number to serve the User Service on, for the Listing Service and direct calls"`
+	ListingServicePort int    `config:"listing-service-port" min:"1" max:"65535" usage:"The port number to run the Listing Service on"`
+	ListingServiceURL  string `config:"listing-service-url" usage:"URL of a Listing Service already running, instead of starting one"`
+	ListingServiceDir  string `config:"listing-service-dir" usage:"Directory of the Listing Service's listing_service.py"`
+	Python             string `config:"python" usage:"Python interpreter running the Listing Service, which needs tornado installed"`
+	DataDir            string `config:"data-dir,required" usage:"Directory of the databases of the services (users.db and listings.db)"`
+	InMemory           bool   `config:"in-memory" usage:"Have the gateway call the User Service inside the process instead of over HTTP"`
+	AccessLog          bool   `config:"access-log" usage:"Log every request once it is served"`
+	AdminToken         string `config:"admin-token,secret" usage:"Token required in the X-Admin-Token header for the User Service's admin endpoints (disabled when empty)"`
+}
+
+func main() {
+	// Load the settings over their defaults, from a file, the environment and flags
+	s := settings{
+		Port:               8000,
+		UserServicePort:    7000,
+		ListingServicePort: 6000,
+		ListingServiceDir:  defaultListingServiceDir(),
+		Python:             "python3",
+		DataDir:            "allinone-data",
+		InMemory:           true,
+		AccessLog:          true,
+	}
+	if err := config.Load(&s, config.Options{EnvPrefix: "ALLINONE"}); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := os.MkdirAll(s.DataDir, 0o755); err != nil {
+		log.Fatalf("Could not create the data directory: %v", err)
+	}
+	userServiceURL := fmt.Sprintf("http://127.0.0.1:%d", s.UserServicePort)
+	listings := s.ListingServiceURL
+	if listings == "" {
+		listings = fmt.Sprintf("http://127.0.0.1:%d", s.ListingServicePort)
+	}
+
+	// The User Service tells the Listing Service about renamed and deleted users
+	userCfg := userservice.DefaultConfig()
+	userCfg.DBDSN = filepath.Join(s.DataDir, "users.db")
+	userCfg.BackupDir = filepath.Join(s.DataDir, "backups")
+	userCfg.AccessLog = s.AccessLog
+	userCfg.AdminToken = s.AdminToken
+	userCfg.EventSubscribers = []string{listings + "/events"}
+	users, err := userservice.New(userCfg)
+	if err != nil {
+		log.Fatalf("Could not start the User Service: %v", err)
+	}
+	defer users.Close()
+
+	servers := []*http.Server{newServer(s.UserServicePort, users.Handler)}
+	serve(servers[0], "User Service")
+
+	// Start the Listing Service once the User Service it checks owners with is up
+	if s.ListingServiceURL == "" {
+		exited, err := startListingService(ctx, s.Python, s.ListingServiceDir, s.DataDir, s.ListingServicePort, userServiceURL)
+		if err != nil {
+			log.Fatalf("Could not start the Listing Service: %v", err)
+		}
+		log.Printf("Listing Service started on port %d", s.ListingServicePort)
+		defer func() { <-exited }() // Killed once ctx is done
+	}
+
+	gatewayCfg := gateway.DefaultConfig()
+	gatewayCfg.UserServiceURL = userServiceURL
+	gatewayCfg.ListingServiceURL = listings
+	gatewayCfg.AccessLog = s.AccessLog
+	if s.InMemory {
+		gatewayCfg.UserServiceURL = "http://" + userServiceHost
+		gatewayCfg.Transport = &memoryTransport{
+			handlers: map[string]http.Handler{userServiceHost: users.Handler},
+			next:     http.DefaultTransport,
+		}
+	}
+	handler, err := gateway.New(gatewayCfg)
+	if err != nil {
+		log.Fatalf("Could not start the gateway: %v", err)
+	}
+	servers = append(servers, newServer(s.Port, handler))
+	serve(servers[1], "Public API Layer")
+
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, server := range servers {
+		server.Shutdown(shutdownCtx)
+	}
+}
+
+// newServer returns the HTTP server of handler on port, with the timeouts of the services.
+func newServer(port int, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:         fmt.Sprintf(":%d", port),
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+}
+
+// serve starts server in the background, exiting when it cannot listen.
+func serve(server *http.Server, name string) {
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Could not serve the %s on %s: %v", name, server.Addr, err)
+		}
+	}()
+	log.Printf("%s starting on %s", name, server.Addr)
+}
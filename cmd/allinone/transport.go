@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+)
+
+// memoryTransport serves the requests to some hosts with handlers of this process instead of
+// sending them over the network, so that the gateway calls the User Service without a socket.
+// Requests to other hosts go to next.
+type memoryTransport struct {
+	handlers map[string]http.Handler // By host, e.g. user-service
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *memoryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	handler, ok := t.handlers[req.URL.Host]
+	if !ok {
+		return t.next.RoundTrip(req)
+	}
+
+	// Handlers expect a request as received by a server
+	served := req.Clone(req.Context())
+	served.RequestURI = req.URL.RequestURI()
+	served.RemoteAddr = "127.0.0.1:0"
+	if served.Body == nil {
+		served.Body = http.NoBody
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, served)
+	resp := recorder.Result()
+	resp.Request = req
+	return resp, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gateway sends requests to the Public API Layer.
+type gateway struct {
+	client      *http.Client
+	url         string
+	accessToken string // Sent as a Bearer credential when set
+}
+
+// newGateway returns the gateway at url.
+func newGateway(client *http.Client, url, accessToken string) *gateway {
+	return &gateway{client: client, url: strings.TrimSuffix(url, "/"), accessToken: accessToken}
+}
+
+// do sends a request with body encoded as JSON unless nil, decoding the response into out
+// unless nil. It returns the status of the response along with its body, for reports.
+func (g *gateway) do(method, path string, body, out any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, g.url+path, reader)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if g.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+g.accessToken)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return resp.StatusCode, data, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, data, nil
+}
+
+// errNameTaken is returned when another user already has the name of a created user.
+var errNameTaken = errors.New("user name already taken")
+
+// userResponse is the gateway's response to created and updated users.
+type userResponse struct {
+	User *struct {
+		ID      int64  `json:"id"`
+		Name    string `json:"name"`
+		Version int64  `json:"version"`
+	} `json:"user"`
+}
+
+// listingResponse is the gateway's response to created listings.
+type listingResponse struct {
+	Listing *struct {
+		ID     int64 `json:"id"`
+		UserID int64 `json:"user_id"`
+	} `json:"listing"`
+}
+
+// newListing is the body of a listing created through the gateway.
+type newListing struct {
+	UserID      int64  `json:"user_id"`
+	ListingType string `json:"listing_type"`
+	Price       int64  `json:"price"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// createUser creates a user named name, returning it.
+func (g *gateway) createUser(name string) (*userResponse, error) {
+	var created userResponse
+	status, body, err := g.do(http.MethodPost, "/public-api/users", map[string]string{"name": name}, &created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user %q: %w", name, err)
+	}
+	if status == http.StatusConflict {
+		return nil, errNameTaken
+	}
+	if status != http.StatusOK || created.User == nil {
+		return nil, fmt.Errorf("failed to create user %q: status %d: %s", name, status, bytes.TrimSpace(body))
+	}
+	return &created, nil
+}
+
+// createListing creates a listing, returning it.
+func (g *gateway) createListing(listing newListing) (*listingResponse, error) {
+	var created listingResponse
+	status, body, err := g.do(http.MethodPost, "/public-api/listings", listing, &created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create listing of user %d: %w", listing.UserID, err)
+	}
+	if (status != http.StatusOK && status != http.StatusCreated) || created.Listing == nil {
+		return nil, fmt.Errorf("failed to create listing of user %d: status %d: %s", listing.UserID, status, bytes.TrimSpace(body))
+	}
+	return &created, nil
+}
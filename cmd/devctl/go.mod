@@ -0,0 +1,3 @@
+module devctl
+
+go 1.24.4
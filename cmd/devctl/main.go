@@ -0,0 +1,40 @@
+// Command devctl gathers the chores of developing against the demo: wiping the databases of the
+// User and Listing services, filling them with users that own listings, and checking every
+// endpoint of the gateway once the services run.
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// usage describes the subcommands.
+const usage = `Usage: devctl <command> [flags]
+
+Commands:
+  reset       Delete the databases of the User and Listing services, which must be stopped
+  seed        Create users, each owning listings, through the gateway
+  smoke-test  Call every endpoint of the gateway and report which ones pass
+
+Run devctl <command> -h for the flags of a command.
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	switch os.Args[1] {
+	case "reset":
+		runReset(os.Args[2:])
+	case "seed":
+		runSeed(os.Args[2:])
+	case "smoke-test":
+		runSmokeTest(os.Args[2:])
+	case "-h", "-help", "--help", "help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// sqliteSuffixes are the suffixes of the files SQLite keeps next to a database.
+var sqliteSuffixes = []string{"", "-wal", "-shm", "-journal"}
+
+// runReset implements the "reset" subcommand, which deletes the SQLite databases of the User and
+// Listing services so that they start empty. The services keep their databases open, so it
+// refuses to run while they are listening.
+func runReset(args []string) {
+	flags := flag.NewFlagSet("reset", flag.ExitOnError)
+	root := repositoryRoot()
+	userDB := flags.String("user-db", filepath.Join(root, "user-service", "users.db"), "SQLite database of the User Service")
+	listingDB := flags.String("listing-db", filepath.Join(root, "listing-service", "listings.db"), "SQLite database of the Listing Service")
+	dataDir := flags.String("data-dir", "", "Data directory of the orchestrator, whose databases are reset instead of -user-db and -listing-db")
+	userServiceURL := flags.String("user-service-url", "http://localhost:7000", "URL of the User Service, which must not be running")
+	listingServiceURL := flags.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service, which must not be running")
+	flags.Parse(args)
+
+	if *dataDir != "" {
+		*userDB = filepath.Join(*dataDir, "user-service", "users.db")
+		*listingDB = filepath.Join(*dataDir, "listing-service", "listings.db")
+	}
+
+	for _, service := range []struct{ name, url string }{{"User Service", *userServiceURL}, {"Listing Service", *listingServiceURL}} {
+		if listening(service.url) {
+			log.Fatalf("The %s is running at %s; stop it before resetting its database", service.name, service.url)
+		}
+	}
+
+	for _, db := range []string{*userDB, *listingDB} {
+		removed := false
+		for _, suffix := range sqliteSuffixes {
+			err := os.Remove(db + suffix)
+			switch {
+			case err == nil:
+				removed = true
+			case !errors.Is(err, fs.ErrNotExist):
+				log.Fatalf("Failed to reset %s: %v", db, err)
+			}
+		}
+		if removed {
+			log.Printf("Deleted %s", db)
+		} else {
+			log.Printf("No database at %s", db)
+		}
+	}
+	log.Printf("The services create empty databases when they start; run devctl seed then")
+}
+
+// listening reports whether anything accepts connections at the host of the URL.
+func listening(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.DialTimeout("tcp", host, time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// repositoryRoot returns the directory of the repository, as found from the source of this
+// command.
+func repositoryRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
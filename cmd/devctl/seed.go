@@ -0,0 +1,79 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Words seeded users and listings are made of.
+var (
+	firstNames   = []string{"Ana", "Budi", "Chen", "Dewi", "Eko", "Farah", "Gita", "Hadi", "Indra", "Joko", "Kiki", "Lina", "Maya", "Nina", "Omar", "Putri", "Rudi", "Sari", "Tono", "Wati"}
+	lastNames    = []string{"Tan", "Lim", "Wijaya", "Santoso", "Lee", "Halim", "Gunawan", "Pratama", "Ng", "Kusuma"}
+	homes        = []string{"Studio", "1BR apartment", "2BR apartment", "3BR condo", "Terrace house", "Shophouse", "Loft", "Townhouse"}
+	neighborhood = []string{"Tiong Bahru", "Kemang", "Menteng", "Bukit Timah", "Kuningan", "Tanjong Pagar", "Senopati", "Holland Village"}
+	features     = []string{"Near the MRT", "Fully furnished", "Freshly renovated", "Quiet street", "Pool and gym", "Pet friendly", "City view"}
+)
+
+// runSeed implements the "seed" subcommand, which creates users through the gateway along with
+// listings owned by each of them, so that every listing has an existing owner.
+func runSeed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	gatewayURL := flags.String("gateway-url", "http://localhost:8000", "URL of the Public API Layer the data is created through")
+	users := flags.Int("users", 20, "Number of users to create")
+	listingsPerUser := flags.Int("listings-per-user", 3, "Number of listings created for each user")
+	accessToken := flags.String("access-token", "", "Access token sent as a Bearer credential, for gateways started with -require-auth")
+	randSeed := flags.Uint64("seed", uint64(time.Now().UnixNano()), "Random seed, for reproducible listings")
+	flags.Parse(args)
+
+	if *users < 1 || *listingsPerUser < 0 {
+		log.Fatalf("-users must be positive and -listings-per-user not negative")
+	}
+
+	g := newGateway(&http.Client{Timeout: 10 * time.Second}, *gatewayURL, *accessToken)
+	rng := rand.New(rand.NewPCG(*randSeed, 0))
+	// User names are unique, so names of earlier runs get the run's suffix
+	run := strconv.FormatInt(time.Now().Unix(), 36)
+
+	start := time.Now()
+	listings := 0
+	for i := range *users {
+		name := fmt.Sprintf("%s %s", firstNames[i%len(firstNames)], lastNames[(i/len(firstNames))%len(lastNames)])
+		created, err := g.createUser(name)
+		if errors.Is(err, errNameTaken) {
+			created, err = g.createUser(name + " " + run + strconv.Itoa(i))
+		}
+		if err != nil {
+			log.Fatalf("Seeding failed: %v", err)
+		}
+
+		for range *listingsPerUser {
+			if _, err := g.createListing(randomListing(rng, created.User.ID)); err != nil {
+				log.Fatalf("Seeding failed: %v", err)
+			}
+			listings++
+		}
+	}
+	log.Printf("Seeded %d users owning %d listings in %s (seed %d)", *users, listings, time.Since(start).Round(time.Millisecond), *randSeed)
+}
+
+// randomListing returns a listing of the user for rent or sale, priced in cents.
+func randomListing(rng *rand.Rand, userID int64) newListing {
+	listing := newListing{
+		UserID:      userID,
+		ListingType: "rent",
+		Price:       int64(500+rng.IntN(9500)) * 100,
+		Title:       homes[rng.IntN(len(homes))] + " in " + neighborhood[rng.IntN(len(neighborhood))],
+		Description: features[rng.IntN(len(features))] + ". " + features[rng.IntN(len(features))] + ".",
+	}
+	if rng.IntN(3) == 0 {
+		listing.ListingType = "sale"
+		listing.Price *= 200
+	}
+	return listing
+}
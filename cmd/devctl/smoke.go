@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Outcomes of the checks of a smoke test.
+const (
+	pass = "PASS"
+	fail = "FAIL"
+	skip = "SKIP"
+)
+
+// check is a request to an endpoint of the gateway, along with the statuses it passes with.
+type check struct {
+	method string
+	path   string
+	body   any   // Sent as JSON unless nil
+	want   []int // Statuses the check passes with
+	// optional is set for endpoints of optional services, which the gateway answers with
+	// 404 "... are not available" when the service is not configured; the check is then skipped.
+	optional bool
+	out      any          // Decodes the response unless nil
+	verify   func() error // Asserts on the decoded response, after the status passed
+}
+
+// result is the outcome of a check.
+type result struct {
+	outcome  string
+	check    string
+	status   int
+	duration time.Duration
+	detail   string
+}
+
+// smokeTest runs checks in order, recording their results.
+type smokeTest struct {
+	gateway *gateway
+	results []result
+}
+
+// run sends the request of c, returning whether the check passed.
+func (s *smokeTest) run(c check) bool {
+	name := c.method + " " + c.path
+	start := time.Now()
+	status, body, err := s.gateway.do(c.method, c.path, c.body, c.out)
+	res := result{check: name, status: status, duration: time.Since(start)}
+
+	var failure struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(body, &failure)
+	switch {
+	case err != nil && status == 0:
+		res.outcome, res.detail = fail, err.Error()
+	case c.optional && status == http.StatusNotFound && strings.HasSuffix(failure.Error, "are not available"):
+		res.outcome, res.detail = skip, failure.Error
+	case !slices.Contains(c.want, status):
+		res.outcome, res.detail = fail, fmt.Sprintf("want status %v: %s", c.want, truncate(string(bytes.TrimSpace(body)), 120))
+	case err != nil:
+		res.outcome, res.detail = fail, err.Error()
+	case c.verify != nil:
+		if err := c.verify(); err != nil {
+			res.outcome, res.detail = fail, err.Error()
+			break
+		}
+		res.outcome = pass
+	default:
+		res.outcome = pass
+	}
+	s.results = append(s.results, res)
+	return res.outcome == pass
+}
+
+// skipped records a check that could not run, because an earlier one it builds on failed.
+func (s *smokeTest) skipped(method, path, reason string) {
+	s.results = append(s.results, result{outcome: skip, check: method + " " + path, detail: reason})
+}
+
+// runSmokeTest implements the "smoke-test" subcommand, which calls every endpoint of the gateway
+// with data it creates, and exits with status 1 when any of them fails. Endpoints of optional
+// services that are not configured are skipped.
+func runSmokeTest(args []string) {
+	flags := flag.NewFlagSet("smoke-test", flag.ExitOnError)
+	gatewayURL := flags.String("gateway-url", "http://localhost:8000", "URL of the Public API Layer to test")
+	accessToken := flags.String("access-token", "", "Access token sent as a Bearer credential, for gateways started with -require-auth")
+	timeout := flags.Duration("timeout", 10*time.Second, "How long each request may take")
+	flags.Parse(args)
+
+	s := &smokeTest{gateway: newGateway(&http.Client{Timeout: *timeout}, *gatewayURL, *accessToken)}
+	s.runChecks()
+
+	counts := map[string]int{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, r := range s.results {
+		counts[r.outcome]++
+		status := "-"
+		if r.status != 0 {
+			status = strconv.Itoa(r.status)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.outcome, r.check, status, r.duration.Round(time.Millisecond), r.detail)
+	}
+	w.Flush()
+	fmt.Printf("\n%d passed, %d failed, %d skipped\n", counts[pass], counts[fail], counts[skip])
+	if counts[fail] > 0 {
+		os.Exit(1)
+	}
+}
+
+// runChecks calls every endpoint of the gateway: a user is created and renamed, a listing of
+// theirs is created and found among the listings, and the read-only endpoints are queried for
+// them. Transactions are only read, with an ID that does not exist.
+func (s *smokeTest) runChecks() {
+	name := "smoke-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	var user userResponse
+	userCreated := s.run(check{
+		method: http.MethodPost, path: "/public-api/users", body: map[string]string{"name": name},
+		want: []int{http.StatusOK}, out: &user,
+		verify: func() error {
+			if user.User == nil || user.User.Name != name {
+				return fmt.Errorf("the response has no user named %s", name)
+			}
+			return nil
+		},
+	})
+	id := "{id}"
+	if userCreated {
+		id = strconv.FormatInt(user.User.ID, 10)
+		s.run(check{
+			method: http.MethodPut, path: "/public-api/users/" + id,
+			body: map[string]any{"name": name + "-renamed", "version": user.User.Version},
+			want: []int{http.StatusOK},
+		})
+		s.runListingChecks(user, id)
+	} else {
+		s.skipped(http.MethodPut, "/public-api/users/"+id, "needs the created user")
+		s.skipped(http.MethodPost, "/public-api/listings", "needs the created user")
+	}
+
+	ok := []int{http.StatusOK}
+	for _, c := range []check{
+		{method: http.MethodGet, path: "/public-api/listings?page_num=1&page_size=5", want: ok},
+		{method: http.MethodGet, path: "/public-api/listings/search?q=smoke", want: ok},
+		{method: http.MethodGet, path: "/public-api/listings/featured?count=3", want: ok},
+		{method: http.MethodGet, path: "/public-api/listings/trending", want: ok},
+		{method: http.MethodGet, path: "/public-api/recommendations?user_id=" + id, want: ok, optional: true},
+		{method: http.MethodGet, path: "/public-api/stats", want: ok},
+		{method: http.MethodGet, path: "/public-api/analytics/timeseries?event_type=listing.created&interval=day", want: ok, optional: true},
+		{method: http.MethodGet, path: "/public-api/analytics/funnel", want: ok, optional: true},
+		{method: http.MethodGet, path: "/public-api/transactions?user_id=" + id, want: ok, optional: true},
+		// No transaction has this ID, which the gateway must answer rather than fail on
+		{method: http.MethodGet, path: "/public-api/transactions/999999999?user_id=" + id, want: []int{http.StatusNotFound}, optional: true},
+		{method: http.MethodPost, path: "/public-api/transactions/999999999/confirm?user_id=" + id, want: []int{http.StatusNotFound}, optional: true},
+		{method: http.MethodPost, path: "/public-api/transactions/999999999/cancel?user_id=" + id, want: []int{http.StatusNotFound}, optional: true},
+	} {
+		if !userCreated && strings.Contains(c.path, "user_id=") {
+			s.skipped(c.method, c.path, "needs the created user")
+			continue
+		}
+		s.run(c)
+	}
+}
+
+// runListingChecks creates a listing of user, whose ID is id, and checks that it is served
+// along with its owner.
+func (s *smokeTest) runListingChecks(user userResponse, id string) {
+	var listing listingResponse
+	listingCreated := s.run(check{
+		method: http.MethodPost, path: "/public-api/listings",
+		body: newListing{UserID: user.User.ID, ListingType: "rent", Price: 450000, Title: "Smoke test listing"},
+		want: []int{http.StatusOK, http.StatusCreated}, out: &listing,
+		verify: func() error {
+			if listing.Listing == nil {
+				return fmt.Errorf("the response has no listing")
+			}
+			return nil
+		},
+	})
+	if !listingCreated {
+		s.skipped(http.MethodGet, "/public-api/listings?user_id="+id, "needs the created listing")
+		return
+	}
+
+	var owned struct {
+		Listings []struct {
+			ID   int64 `json:"id"`
+			User *struct {
+				ID int64 `json:"id"`
+			} `json:"user"`
+		} `json:"listings"`
+	}
+	s.run(check{
+		method: http.MethodGet, path: "/public-api/listings?user_id=" + id,
+		want: []int{http.StatusOK}, out: &owned,
+		verify: func() error {
+			for _, l := range owned.Listings {
+				if l.ID == listing.Listing.ID {
+					if l.User == nil || l.User.ID != user.User.ID {
+						return fmt.Errorf("listing %d is not served with its owner", l.ID)
+					}
+					return nil
+				}
+			}
+			return fmt.Errorf("listing %d is missing from the listings of user %s", listing.Listing.ID, id)
+		},
+	})
+}
+
+// truncate shortens text to at most n bytes for reports.
+func truncate(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	return text[:n] + "..."
+}
@@ -0,0 +1,214 @@
+// Command loadgen drives a mix of reads and writes against the gateway for a while, then reports
+// the latency percentiles and error rate of each kind of request, so that performance
+// regressions in the aggregation path show up as numbers. It creates the users and listings it
+// needs through the gateway before the measured run starts.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+func main() {
+	// Define command-line flags for the target and the traffic sent to it
+	gatewayURL := flag.String("gateway-url", "http://localhost:8000", "URL of the Public API Layer to load")
+	duration := flag.Duration("duration", 30*time.Second, "How long the measured run lasts")
+	concurrency := flag.Int("concurrency", 16, "Number of requests in flight at once")
+	rate := flag.Float64("rate", 0, "Requests per second sent across all workers (0 sends as fast as the gateway answers)")
+	mixFlag := flag.String("mix", "listings=60,user-listings=25,stats=5,create-listing=8,create-user=2", "Traffic mix as <operation>=<weight> pairs; operations: "+strings.Join(operationNames(), ", "))
+	pageSizesFlag := flag.String("page-sizes", "10,50", "Comma-separated page sizes listings reads pick from")
+	pages := flag.Int("pages", 10, "Number of pages listings reads spread over")
+	users := flag.Int("users", 50, "Number of users created before the run")
+	listingsPerUser := flag.Int("listings-per-user", 2, "Number of listings created for each user before the run")
+	accessToken := flag.String("access-token", "", "Access token sent as a Bearer credential, for gateways started with -require-auth")
+	timeout := flag.Duration("timeout", 10*time.Second, "How long a request may take before it counts as an error")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "Random seed, for reproducible traffic")
+	jsonOutput := flag.Bool("json", false, "Print the report as JSON instead of a table, e.g. to compare runs")
+	maxErrorRate := flag.Float64("max-error-rate", 0, "Exit with status 1 when the overall error rate exceeds this fraction, e.g. 0.01 (0 disables the check)")
+	maxP99 := flag.Duration("max-p99", 0, "Exit with status 1 when the overall p99 latency exceeds this (0 disables the check)")
+	flag.Parse()
+
+	mix, err := parseMix(*mixFlag)
+	if err != nil {
+		log.Fatalf("Invalid -mix: %v", err)
+	}
+	pageSizes, err := parsePageSizes(*pageSizesFlag)
+	if err != nil {
+		log.Fatalf("Invalid -page-sizes: %v", err)
+	}
+	if *concurrency < 1 || *users < 1 || *pages < 1 || *listingsPerUser < 0 {
+		log.Fatalf("-concurrency, -users and -pages must be positive, and -listings-per-user not negative")
+	}
+
+	client := &http.Client{
+		Timeout:   *timeout,
+		Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency},
+	}
+	w := &workload{
+		gatewayURL:  strings.TrimSuffix(*gatewayURL, "/"),
+		runID:       strconv.FormatInt(time.Now().Unix(), 36),
+		pageSizes:   pageSizes,
+		pages:       *pages,
+		accessToken: *accessToken,
+	}
+
+	// Create the data the run reads and writes against
+	start := time.Now()
+	if err := setup(client, w, *users, *listingsPerUser, rand.New(rand.NewPCG(*seed, 0))); err != nil {
+		log.Fatalf("Setup failed: %v", err)
+	}
+	log.Printf("Created %d users with %d listings each in %s", *users, *listingsPerUser, time.Since(start).Round(time.Millisecond))
+
+	log.Printf("Sending %s for %s with %d workers", *mixFlag, *duration, *concurrency)
+	rec := newRecorder()
+	elapsed := run(client, w, mix, rec, *duration, *concurrency, *rate, *seed)
+	rep := rec.report(elapsed)
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(rep)
+	} else {
+		rep.print(os.Stdout)
+	}
+
+	failed := false
+	if *maxErrorRate > 0 && rep.Total.ErrorRate > *maxErrorRate {
+		log.Printf("Error rate %.2f%% exceeds -max-error-rate %.2f%%", 100*rep.Total.ErrorRate, 100**maxErrorRate)
+		failed = true
+	}
+	if *maxP99 > 0 && rep.Total.P99 > milliseconds(*maxP99) {
+		log.Printf("p99 latency %.1fms exceeds -max-p99 %s", rep.Total.P99, *maxP99)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// setup creates the users of the run, each with listingsPerUser listings.
+func setup(client *http.Client, w *workload, users, listingsPerUser int, rng *rand.Rand) error {
+	for range users {
+		id, err := createUser(client, w)
+		if err != nil {
+			return err
+		}
+		for range listingsPerUser {
+			req, err := jsonRequest(http.MethodPost, w.gatewayURL+"/public-api/listings", w.newListing(id, rng))
+			if err != nil {
+				return err
+			}
+			status, err := w.do(client, req, nil)
+			if err != nil {
+				return fmt.Errorf("failed to create listing: %w", err)
+			}
+			if status != http.StatusOK && status != http.StatusCreated {
+				return fmt.Errorf("failed to create listing: status %d", status)
+			}
+		}
+	}
+	return nil
+}
+
+// createdUser is the response of the gateway to a created user.
+type createdUser struct {
+	User *struct {
+		ID int64 `json:"id"`
+	} `json:"user"`
+}
+
+// createUser creates a user of the run, returning its ID.
+func createUser(client *http.Client, w *workload) (int64, error) {
+	req, err := operations["create-user"].send(w, nil)
+	if err != nil {
+		return 0, err
+	}
+	var created createdUser
+	status, err := w.do(client, req, &created)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+	if (status != http.StatusOK && status != http.StatusCreated) || created.User == nil {
+		return 0, fmt.Errorf("failed to create user: status %d", status)
+	}
+	w.addUser(created.User.ID)
+	return created.User.ID, nil
+}
+
+// run sends requests of the mix from concurrency workers for duration, at most rate per second
+// when rate is positive, recording each of them. It returns how long the run took.
+func run(client *http.Client, w *workload, mix []weightedOperation, rec *recorder, duration time.Duration, concurrency int, rate float64, seed uint64) time.Duration {
+	ctx, cancel := context.WithTimeout(context.Background(), duration)
+	defer cancel()
+
+	// Workers wait for a tick before each request when the rate is limited
+	var ticks <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	var wg sync.WaitGroup
+	start := time.Now()
+	for worker := range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, uint64(worker)+1))
+			for {
+				if ticks != nil {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticks:
+					}
+				} else if ctx.Err() != nil {
+					return
+				}
+
+				op := pick(mix, rng)
+				req, err := op.send(w, rng)
+				if err != nil {
+					log.Fatalf("Failed to create %s request: %v", op.name, err)
+				}
+				var created createdUser
+				var out any
+				if op.name == "create-user" {
+					out = &created // Later requests may use the new user
+				}
+				requestStart := time.Now()
+				status, err := w.do(client, req, out)
+				rec.record(op.name, time.Since(requestStart), status, err)
+				if err == nil && created.User != nil {
+					w.addUser(created.User.ID)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+// parsePageSizes parses a comma-separated list of positive page sizes.
+func parsePageSizes(value string) ([]int, error) {
+	var sizes []int
+	for _, item := range strings.Split(value, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || size < 1 {
+			return nil, fmt.Errorf("invalid page size %q", item)
+		}
+		sizes = append(sizes, size)
+	}
+	return sizes, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// recorder collects the outcome of every request of a run, by operation.
+type recorder struct {
+	mu         sync.Mutex
+	latencies  map[string][]time.Duration
+	errors     map[string]int
+	errorKinds map[string]int // Failed requests by operation and status, or by transport error
+}
+
+// newRecorder creates a new empty recorder.
+func newRecorder() *recorder {
+	return &recorder{
+		latencies:  make(map[string][]time.Duration),
+		errors:     make(map[string]int),
+		errorKinds: make(map[string]int),
+	}
+}
+
+// record adds a request of op that took latency. Requests failing with a transport error or a
+// status other than 2xx count as errors.
+func (r *recorder) record(op string, latency time.Duration, status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[op] = append(r.latencies[op], latency)
+	switch {
+	case err != nil:
+		r.errors[op]++
+		r.errorKinds[op+": "+errorKind(err)]++
+	case status < 200 || status >= 300:
+		r.errors[op]++
+		r.errorKinds[fmt.Sprintf("%s: status %d", op, status)]++
+	}
+}
+
+// errorKind shortens a transport error into a kind counted across requests.
+func errorKind(err error) string {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "Client.Timeout"), strings.Contains(message, "deadline exceeded"):
+		return "timeout"
+	case strings.Contains(message, "connection refused"):
+		return "connection refused"
+	case strings.Contains(message, "connection reset"):
+		return "connection reset"
+	default:
+		return message
+	}
+}
+
+// summary is the outcome of the requests of an operation, or of all of them.
+type summary struct {
+	Operation string  `json:"operation"`
+	Requests  int     `json:"requests"`
+	Errors    int     `json:"errors"`
+	ErrorRate float64 `json:"error_rate"`
+	PerSecond float64 `json:"per_second"`
+	P50       float64 `json:"p50_ms"`
+	P90       float64 `json:"p90_ms"`
+	P99       float64 `json:"p99_ms"`
+	Max       float64 `json:"max_ms"`
+}
+
+// report is the outcome of a run.
+type report struct {
+	Duration   float64        `json:"duration_seconds"`
+	Operations []summary      `json:"operations"`
+	Total      summary        `json:"total"`
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"`
+}
+
+// report summarizes the requests recorded over a run that lasted elapsed.
+func (r *recorder) report(elapsed time.Duration) report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ops := make([]string, 0, len(r.latencies))
+	for op := range r.latencies {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+
+	rep := report{Duration: elapsed.Seconds(), ErrorKinds: r.errorKinds}
+	var all []time.Duration
+	errors := 0
+	for _, op := range ops {
+		rep.Operations = append(rep.Operations, summarize(op, r.latencies[op], r.errors[op], elapsed))
+		all = append(all, r.latencies[op]...)
+		errors += r.errors[op]
+	}
+	rep.Total = summarize("total", all, errors, elapsed)
+	return rep
+}
+
+// summarize computes the summary of the latencies of an operation's requests.
+func summarize(op string, latencies []time.Duration, errors int, elapsed time.Duration) summary {
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	s := summary{
+		Operation: op,
+		Requests:  len(sorted),
+		Errors:    errors,
+		PerSecond: float64(len(sorted)) / elapsed.Seconds(),
+		P50:       milliseconds(percentile(sorted, 50)),
+		P90:       milliseconds(percentile(sorted, 90)),
+		P99:       milliseconds(percentile(sorted, 99)),
+	}
+	if len(sorted) > 0 {
+		s.ErrorRate = float64(errors) / float64(len(sorted))
+		s.Max = milliseconds(sorted[len(sorted)-1])
+	}
+	return s
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies, zero without any.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100 // ceil(p/100 * n)
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// print writes the report as a table, followed by the kinds of errors seen.
+func (rep report) print(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "operation\trequests\terrors\terror rate\treq/s\tp50 ms\tp90 ms\tp99 ms\tmax ms\t\n")
+	for _, s := range append(rep.Operations, rep.Total) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f%%\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t\n",
+			s.Operation, s.Requests, s.Errors, 100*s.ErrorRate, s.PerSecond, s.P50, s.P90, s.P99, s.Max)
+	}
+	tw.Flush()
+
+	if len(rep.ErrorKinds) > 0 {
+		kinds := make([]string, 0, len(rep.ErrorKinds))
+		for kind := range rep.ErrorKinds {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		fmt.Fprintln(w, "\nerrors:")
+		for _, kind := range kinds {
+			fmt.Fprintf(w, "  %s: %d\n", kind, rep.ErrorKinds[kind])
+		}
+	}
+}
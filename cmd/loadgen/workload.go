@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// operation is a kind of request sent to the gateway.
+type operation struct {
+	name  string
+	write bool // Whether the request creates data
+	send  func(w *workload, rng *rand.Rand) (*http.Request, error)
+}
+
+// operations are the requests a traffic mix may contain, by name.
+var operations = map[string]operation{
+	// The aggregation path: a page of listings, each enriched with its owner
+	"listings": {"listings", false, func(w *workload, rng *rand.Rand) (*http.Request, error) {
+		query := url.Values{}
+		query.Set("page_num", strconv.Itoa(rng.IntN(w.pages)+1))
+		query.Set("page_size", strconv.Itoa(w.pageSizes[rng.IntN(len(w.pageSizes))]))
+		return http.NewRequest(http.MethodGet, w.gatewayURL+"/public-api/listings?"+query.Encode(), nil)
+	}},
+	// The listings of one user, enriched with that user
+	"user-listings": {"user-listings", false, func(w *workload, rng *rand.Rand) (*http.Request, error) {
+		query := url.Values{}
+		query.Set("user_id", strconv.FormatInt(w.randomUser(rng), 10))
+		query.Set("page_size", strconv.Itoa(w.pageSizes[rng.IntN(len(w.pageSizes))]))
+		return http.NewRequest(http.MethodGet, w.gatewayURL+"/public-api/listings?"+query.Encode(), nil)
+	}},
+	// User and listing statistics, fetched from both services concurrently
+	"stats": {"stats", false, func(w *workload, rng *rand.Rand) (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, w.gatewayURL+"/public-api/stats", nil)
+	}},
+	"create-listing": {"create-listing", true, func(w *workload, rng *rand.Rand) (*http.Request, error) {
+		return jsonRequest(http.MethodPost, w.gatewayURL+"/public-api/listings", w.newListing(w.randomUser(rng), rng))
+	}},
+	"create-user": {"create-user", true, func(w *workload, rng *rand.Rand) (*http.Request, error) {
+		return jsonRequest(http.MethodPost, w.gatewayURL+"/public-api/users", map[string]string{"name": w.newUserName()})
+	}},
+}
+
+// weightedOperation is an operation of a traffic mix along with its share of the requests.
+type weightedOperation struct {
+	operation
+	weight int
+}
+
+// parseMix parses a traffic mix such as "listings=60,user-listings=30,create-listing=10", where
+// each operation is sent in proportion to its weight.
+func parseMix(value string) ([]weightedOperation, error) {
+	var mix []weightedOperation
+	for _, item := range strings.Split(value, ",") {
+		name, weightText, ok := strings.Cut(strings.TrimSpace(item), "=")
+		op, known := operations[name]
+		if !ok || !known {
+			return nil, fmt.Errorf("invalid mix item %q, expected <operation>=<weight> with an operation among %s", item, strings.Join(operationNames(), ", "))
+		}
+		weight, err := strconv.Atoi(weightText)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight of %s: %q", name, weightText)
+		}
+		if weight > 0 {
+			mix = append(mix, weightedOperation{op, weight})
+		}
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("the mix %q has no operation with a positive weight", value)
+	}
+	return mix, nil
+}
+
+// operationNames returns the names of the operations, sorted.
+func operationNames() []string {
+	names := make([]string, 0, len(operations))
+	for name := range operations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pick returns an operation of the mix, with a probability proportional to its weight.
+func pick(mix []weightedOperation, rng *rand.Rand) operation {
+	total := 0
+	for _, op := range mix {
+		total += op.weight
+	}
+	n := rng.IntN(total)
+	for _, op := range mix {
+		if n < op.weight {
+			return op.operation
+		}
+		n -= op.weight
+	}
+	return mix[len(mix)-1].operation
+}
+
+// workload holds what the operations need to build their requests: the gateway, the users
+// created for the run, and the shape of the reads.
+type workload struct {
+	gatewayURL  string
+	runID       string // Distinguishes the names of this run's users from earlier runs'
+	pageSizes   []int
+	pages       int // Number of pages listings reads spread over
+	accessToken string
+
+	mu      sync.RWMutex
+	userIDs []int64
+
+	created atomic.Int64 // Number of users created so far, numbering their names
+}
+
+// randomUser returns the ID of a user of the run.
+func (w *workload) randomUser(rng *rand.Rand) int64 {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.userIDs[rng.IntN(len(w.userIDs))]
+}
+
+// addUser makes a user created during the run available to the operations.
+func (w *workload) addUser(id int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.userIDs = append(w.userIDs, id)
+}
+
+// newUserName returns a user name unused by the run and earlier runs.
+func (w *workload) newUserName() string {
+	return fmt.Sprintf("loadgen-%s-%d", w.runID, w.created.Add(1))
+}
+
+// newListing returns the body of a listing of userID.
+func (w *workload) newListing(userID int64, rng *rand.Rand) map[string]any {
+	listingType := "sale"
+	if rng.IntN(2) == 0 {
+		listingType = "rent"
+	}
+	return map[string]any{
+		"user_id":      userID,
+		"listing_type": listingType,
+		"price":        (rng.Int64N(5000) + 1) * 1000,
+		"title":        fmt.Sprintf("Load test listing %d", rng.IntN(1000000)),
+	}
+}
+
+// do sends the request, returning its status and the decoded JSON response.
+func (w *workload) do(client *http.Client, req *http.Request, out any) (int, error) {
+	if w.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+w.accessToken)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		// Drain the body so the connection is reused
+		_, err = io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, err
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonRequest creates a request with a JSON body.
+func jsonRequest(method, url string, body any) (*http.Request, error) {
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(encoded))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// logMux writes the output of several processes to one writer, a line at a time, each line
+// prefixed with the name of its process so that interleaved logs stay readable.
+type logMux struct {
+	mu    sync.Mutex
+	out   io.Writer
+	width int // Of the longest prefix, to align the lines
+}
+
+// writer returns the writer of the output of the named process.
+func (m *logMux) writer(name string) *prefixWriter {
+	return &prefixWriter{mux: m, prefix: name}
+}
+
+// printf writes a line of the orchestrator itself.
+func (m *logMux) printf(format string, args ...any) {
+	m.line("orchestrator", []byte(fmt.Sprintf(format, args...)))
+}
+
+// line writes a line of output of the named process.
+func (m *logMux) line(name string, line []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fmt.Fprintf(m.out, "%-*s | %s\n", m.width, name, line)
+}
+
+// prefixWriter splits the output of a process into lines for its logMux.
+type prefixWriter struct {
+	mux     *logMux
+	prefix  string
+	pending []byte // Output after the last newline
+}
+
+// Write implements io.Writer. The writer is both the stdout and stderr of its process, which
+// exec.Cmd then writes from a single goroutine, so the pending line needs no lock.
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.mux.line(w.prefix, bytes.TrimRight(w.pending[:i], "\r"))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush writes the last line of output when it has no newline, once the process exited.
+func (w *prefixWriter) flush() {
+	if len(w.pending) > 0 {
+		w.mux.line(w.prefix, w.pending)
+		w.pending = nil
+	}
+}
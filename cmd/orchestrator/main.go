@@ -0,0 +1,129 @@
+// Command orchestrator runs the services of the demo as child processes from one command,
+// instead of a terminal or a shell script per service. It builds the Go services, starts each
+// service on its default port with the flags pointing it at the others that run, restarts the
+// services that exit with an increasing delay, and prints the logs of all of them with the name
+// of their service in front of each line. Ctrl+C stops them all.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+func main() {
+	// Define command-line flags for the services to run and where they live
+	root := flag.String("root", defaultRoot(), "Directory of the repository holding the services")
+	servicesFlag := flag.String("services", strings.Join(coreServices, ","), "Comma-separated services to run, or all; services: "+strings.Join(serviceOrder, ", "))
+	dataDir := flag.String("data-dir", "orchestrator-data", "Directory the services keep their databases and files in, one subdirectory each, along with the built binaries")
+	python := flag.String("python", "python3", "Python interpreter running the Listing Service, which needs tornado installed")
+	minBackoff := flag.Duration("min-backoff", time.Second, "Delay before restarting a service that exited")
+	maxBackoff := flag.Duration("max-backoff", 30*time.Second, "Longest delay before restarting a service that keeps exiting, the delay doubling after each exit in a row")
+	flag.Parse()
+
+	running, err := selectServices(*servicesFlag)
+	if err != nil {
+		log.Fatalf("Invalid -services: %v", err)
+	}
+	data, err := filepath.Abs(*dataDir)
+	if err != nil {
+		log.Fatalf("Invalid -data-dir: %v", err)
+	}
+	binDir := filepath.Join(data, "bin")
+
+	logs := &logMux{out: os.Stdout, width: len("orchestrator")}
+	for name := range running {
+		logs.width = max(logs.width, len(name))
+	}
+
+	// Build the Go services first, so that none starts before all of them compile
+	for _, name := range serviceOrder {
+		s := running[name]
+		if s == nil || s.python() {
+			continue
+		}
+		logs.printf("Building %s", name)
+		build := exec.Command("go", "build", "-o", filepath.Join(binDir, name), "./cmd")
+		build.Dir = filepath.Join(*root, s.dir)
+		output := logs.writer(name)
+		build.Stdout = output
+		build.Stderr = output
+		err := build.Run()
+		output.flush()
+		if err != nil {
+			log.Fatalf("Could not build %s: %v", name, err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	for _, name := range serviceOrder {
+		s := running[name]
+		if s == nil {
+			continue
+		}
+		dir := filepath.Join(data, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			log.Fatalf("Could not create the data directory of %s: %v", name, err)
+		}
+		path, args := s.command(*root, binDir, *python, running)
+		logs.printf("Starting %s on port %d: %s %s", name, s.port, filepath.Base(path), strings.Join(args, " "))
+		p := &process{name: name, path: path, args: args, dir: dir, logs: logs, minBackoff: *minBackoff, maxBackoff: *maxBackoff}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.run(ctx)
+		}()
+	}
+
+	<-ctx.Done()
+	stop() // A second Ctrl+C exits right away
+	logs.printf("Stopping %d service(s)", len(running))
+	wg.Wait()
+}
+
+// selectServices returns the services named by the -services flag, by name.
+func selectServices(value string) (map[string]*service, error) {
+	names := coreServices
+	if value == "all" {
+		names = serviceOrder
+	} else if value != "" {
+		names = nil
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+
+	running := make(map[string]*service)
+	for _, name := range names {
+		s, ok := services[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q", name)
+		}
+		running[name] = s
+	}
+	if len(running) == 0 {
+		return nil, fmt.Errorf("no service to run")
+	}
+	return running, nil
+}
+
+// defaultRoot returns the directory of the repository, as found from the source of this command.
+func defaultRoot() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package main
+
+import "os/exec"
+
+// bindToParent does nothing outside Linux, where services outlive an orchestrator that is
+// killed without the chance to stop them.
+func bindToParent(cmd *exec.Cmd) {}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+)
+
+// service is a service the orchestrator may run.
+type service struct {
+	name string
+	dir  string // In the repository
+	port int
+	// wire returns the flags connecting the service to the others that run, by name.
+	wire func(running map[string]*service) []string
+}
+
+// url returns the URL the service is reached at.
+func (s *service) url() string {
+	return fmt.Sprintf("http://localhost:%d", s.port)
+}
+
+// python reports whether the service is the Python Listing Service rather than a Go one.
+func (s *service) python() bool {
+	return s.name == "listing-service"
+}
+
+// command returns the program and arguments running the service, given the directory of the
+// repository and where the Go services were built.
+func (s *service) command(root, binDir, pythonPath string, running map[string]*service) (string, []string) {
+	var args []string
+	if s.wire != nil {
+		args = s.wire(running)
+	}
+	if s.python() {
+		// The Listing Service takes its options as --name=value
+		return pythonPath, append([]string{
+			filepath.Join(root, s.dir, "listing_service.py"),
+			"--port=" + strconv.Itoa(s.port),
+			"--debug=false", // Autoreload would fork the service, escaping supervision
+		}, args...)
+	}
+	return filepath.Join(binDir, s.name), append([]string{"-port", strconv.Itoa(s.port)}, args...)
+}
+
+// urlFlags returns flag=URL for each of the running services, as named by flags.
+func urlFlags(running map[string]*service, flags map[string]string) []string {
+	var args []string
+	for _, name := range serviceOrder {
+		if flag, ok := flags[name]; ok && running[name] != nil {
+			args = append(args, fmt.Sprintf("%s=%s", flag, running[name].url()))
+		}
+	}
+	return args
+}
+
+// serviceOrder is the order services start in, the services others depend on first. The
+// gateway starts last.
+var serviceOrder = []string{
+	"listing-service", "user-service", "auth-service", "notification-service", "search-service",
+	"media-service", "review-service", "recommendations-service", "booking-service",
+	"analytics-service", "admin-service", "scheduler-service", "public-api",
+}
+
+// coreServices are the services run by default: the gateway and the services it needs.
+var coreServices = []string{"listing-service", "user-service", "public-api"}
+
+// services are the services of the repository, on their default ports, by name.
+var services = map[string]*service{
+	"listing-service": {name: "listing-service", dir: "listing-service", port: 6000, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"user-service": "--user_service_url"})
+	}},
+	"user-service": {name: "user-service", dir: "user-service", port: 7000, wire: func(running map[string]*service) []string {
+		args := urlFlags(running, map[string]string{"auth-service": "-auth-service-url"})
+		// The Listing Service keeps the names of the owners of listings from the user events
+		if listings := running["listing-service"]; listings != nil {
+			args = append(args, "-event-subscribers="+listings.url()+"/events")
+		}
+		return args
+	}},
+	"auth-service": {name: "auth-service", dir: "auth-service", port: 9000, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"user-service": "-user-service-url"})
+	}},
+	"notification-service": {name: "notification-service", dir: "notification-service", port: 9100},
+	"search-service": {name: "search-service", dir: "search-service", port: 9200, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"user-service": "-user-service-url", "listing-service": "-listing-service-url"})
+	}},
+	"media-service": {name: "media-service", dir: "media-service", port: 9300},
+	"review-service": {name: "review-service", dir: "review-service", port: 9400, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"user-service": "-user-service-url", "listing-service": "-listing-service-url"})
+	}},
+	"recommendations-service": {name: "recommendations-service", dir: "recommendations-service", port: 9500},
+	"booking-service": {name: "booking-service", dir: "booking-service", port: 9600, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"listing-service": "-listing-service-url"})
+	}},
+	"analytics-service": {name: "analytics-service", dir: "analytics-service", port: 9700},
+	"admin-service": {name: "admin-service", dir: "admin-service", port: 9800, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{"user-service": "-user-service-url", "listing-service": "-listing-service-url"})
+	}},
+	"scheduler-service": {name: "scheduler-service", dir: "scheduler-service", port: 9900},
+	"public-api": {name: "public-api", dir: "public-api", port: 8000, wire: func(running map[string]*service) []string {
+		return urlFlags(running, map[string]string{
+			"user-service":            "-user-service-url",
+			"listing-service":         "-listing-service-url",
+			"auth-service":            "-auth-service-url",
+			"search-service":          "-search-service-url",
+			"review-service":          "-review-service-url",
+			"recommendations-service": "-recommendations-service-url",
+			"booking-service":         "-booking-service-url",
+			"analytics-service":       "-analytics-service-url",
+		})
+	}},
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// stableAfter is how long a process must run for its next crash to restart it without delay
+// again.
+const stableAfter = 30 * time.Second
+
+// stopTimeout is how long a process may take to exit once asked to, before it is killed.
+const stopTimeout = 10 * time.Second
+
+// process supervises a service: it runs the service's command, and restarts it whenever it
+// exits until the orchestrator stops, waiting longer after each crash in a row.
+type process struct {
+	name       string
+	path       string
+	args       []string
+	dir        string // Working directory, where the service keeps its files
+	logs       *logMux
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// run supervises the process until ctx is done, then stops it.
+func (p *process) run(ctx context.Context) {
+	backoff := p.minBackoff
+	for {
+		started := time.Now()
+		err := p.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// A process that ran for a while crashed anew rather than in a crash loop
+		if time.Since(started) >= stableAfter {
+			backoff = p.minBackoff
+		}
+		if err != nil {
+			p.logs.printf("%s exited: %v; restarting in %s", p.name, err, backoff)
+		} else {
+			p.logs.printf("%s exited; restarting in %s", p.name, backoff)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, p.maxBackoff)
+	}
+}
+
+// runOnce runs the process until it exits, or until ctx is done, when it is interrupted and
+// given stopTimeout to exit before it is killed.
+func (p *process) runOnce(ctx context.Context) error {
+	output := p.logs.writer(p.name)
+	defer output.flush()
+
+	cmd := exec.Command(p.path, p.args...)
+	cmd.Dir = p.dir
+	cmd.Stdout = output
+	cmd.Stderr = output
+	bindToParent(cmd)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.logs.printf("%s started (pid %d)", p.name, cmd.Process.Pid)
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-ctx.Done():
+	}
+
+	cmd.Process.Signal(os.Interrupt)
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(stopTimeout):
+		p.logs.printf("%s did not exit within %s, killing it", p.name, stopTimeout)
+		cmd.Process.Signal(syscall.SIGKILL)
+		return <-exited
+	}
+}
@@ -0,0 +1,46 @@
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.ioloop
+
+# Name of the lease held by the instance running the archive worker
+LEASE_NAME = "listing_archive"
+
+# Maximum number of listings archived per batch
+ARCHIVE_BATCH_SIZE = 100
+
+class ArchiveWorker:
+    """Periodically moves listings sold more than archive_after_days days ago from the listings
+    table to listings_archive, emitting listing.archived, so that the table queries run against
+    only holds listings that are still of interest.
+
+    Like the ExpiryWorker, only the instance holding the listing_archive lease runs.
+    """
+
+    def __init__(self, listing_service, repo, archive_after_days, interval):
+        self.listing_service = listing_service
+        self.repo = repo
+        self.archive_after_days = archive_after_days
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.archive_due, self.interval * 1000).start()
+
+    def archive_due(self):
+        try:
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, int(time.time() * 1e6), self.lease_duration):
+                return
+            # Archive every due listing, a batch at a time
+            while True:
+                count = self.listing_service.archive_sold_listings(self.archive_after_days, ARCHIVE_BATCH_SIZE)
+                if count > 0:
+                    logging.info("Archived {} sold listings".format(count))
+                if count < ARCHIVE_BATCH_SIZE:
+                    break
+        except Exception:
+            logging.exception("Error archiving listings")
@@ -0,0 +1,130 @@
+"""Benchmarks the listing list queries of GET /listings on a generated database.
+
+Compares, on the same data, offset pagination against keyset pagination (the cursor param),
+and the single-column user_id index and a missing type/price index against the composite
+indexes that replaced them. Run it from this directory, e.g.
+
+    python3 bench_listings.py --rows=1000000
+
+The database is generated in a temporary directory and removed afterwards.
+"""
+import os
+import random
+import sqlite3
+import statistics
+import tempfile
+import time
+
+import tornado.options
+
+from repository import CategoryRepository, ListingRepository
+
+# Listings inserted per transaction while generating the database
+INSERT_BATCH_SIZE = 50000
+
+def generate(db, rows, users):
+    # Inserts rows listings of users owners, one a minute going back from now, mostly approved
+    now = int(time.time() * 1e6)
+    cursor = db.cursor()
+    for start in range(0, rows, INSERT_BATCH_SIZE):
+        batch = []
+        for i in range(start, min(start + INSERT_BATCH_SIZE, rows)):
+            created_at = now - (rows - i) * 60 * 1000000
+            batch.append((
+                random.randint(1, users),
+                random.choice(("rent", "sale")),
+                random.randint(100, 5000000),
+                "USD",
+                "approved" if random.random() < 0.95 else "pending",
+                created_at,
+                created_at,
+                created_at,
+            ))
+        cursor.executemany(
+            "INSERT INTO listings (user_id, listing_type, price, currency, moderation_status, created_at, updated_at, matched_at) "
+            + "VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+            batch
+        )
+        db.commit()
+    cursor.execute("ANALYZE")
+    db.commit()
+
+def timed(fn, runs):
+    # Returns the median duration of fn, in milliseconds
+    durations = []
+    for _ in range(runs):
+        started = time.perf_counter()
+        fn()
+        durations.append((time.perf_counter() - started) * 1000)
+    return statistics.median(durations)
+
+def keyset_after(db, where, args, order_by, sort_column, offset):
+    # Returns the (sort value, id) of the listing at offset, which a cursor would carry
+    row = db.execute(
+        "SELECT {}, id FROM listings WHERE hidden_at IS NULL{} ORDER BY {} LIMIT 1 OFFSET ?".format(sort_column, where, order_by),
+        args + [offset]
+    ).fetchone()
+    return row[0], row[1]
+
+def report(name, before, after):
+    print("{:<48} {:>10.2f} ms {:>10.2f} ms {:>8.1f}x".format(name, before, after, before / after if after else 0))
+
+def main():
+    tornado.options.define("rows", default=1000000, help="number of listings generated")
+    tornado.options.define("users", default=2000, help="number of listing owners")
+    tornado.options.define("page_size", default=20)
+    tornado.options.define("page_num", default=1000, help="page read by the deep pagination benchmarks")
+    tornado.options.define("runs", default=5, help="runs per measurement, the median is reported")
+    tornado.options.parse_command_line()
+    options = tornado.options.options
+
+    with tempfile.TemporaryDirectory() as directory:
+        db = sqlite3.connect(os.path.join(directory, "listings.db"))
+        db.row_factory = sqlite3.Row
+        CategoryRepository(db).init_schema()
+        repo = ListingRepository(db)
+        repo.init_schema("USD")
+        print("Generating {} listings...".format(options.rows))
+        generate(db, options.rows, options.users)
+
+        page_size, page_num, runs = options.page_size, options.page_num, options.runs
+        offset = (page_num - 1) * page_size
+        visible = dict(moderation_status="approved", active_at=int(time.time() * 1e6))
+        visible_where = " AND moderation_status='approved' AND (expires_at IS NULL OR expires_at > {})".format(visible["active_at"])
+        print("{:<48} {:>13} {:>13} {:>9}".format("query (page_size={})".format(page_size), "before", "after", "speedup"))
+
+        # Newest listings, deep page: OFFSET scans every skipped row, a cursor seeks to the page
+        after = keyset_after(db, visible_where, [], "created_at DESC, id DESC", "created_at", offset - 1)
+        report(
+            "newest, page {}".format(page_num),
+            timed(lambda: repo.list(page_num, page_size, visible), runs),
+            timed(lambda: repo.list(1, page_size, visible, None, after), runs),
+        )
+
+        # type:rent sorted by price, deep page; before the composite index, with offsets
+        rent = dict(visible, conditions=[("listings.listing_type", "=", "rent")])
+        sort = ("price", "asc")
+        after = keyset_after(
+            db, visible_where + " AND listing_type='rent'", [], "price ASC, id ASC", "price", offset - 1
+        )
+        db.execute("DROP INDEX idx_listings_status_type_price")
+        before = timed(lambda: repo.list(page_num, page_size, rent, sort), runs)
+        db.execute("CREATE INDEX idx_listings_status_type_price ON listings(moderation_status, listing_type, price, id)")
+        db.execute("ANALYZE")
+        report("type:rent by price, page {}".format(page_num), before, timed(lambda: repo.list(1, page_size, rent, sort, after), runs))
+
+        # A user's listings, newest first; before with the single-column user_id index
+        user_ids = [random.randint(1, options.users) for _ in range(runs)]
+        by_user = lambda: [repo.list(1, page_size, dict(visible, user_id=user_id)) for user_id in user_ids]
+        db.execute("DROP INDEX idx_listings_user_created")
+        db.execute("CREATE INDEX idx_listings_user_id ON listings(user_id)")
+        db.execute("ANALYZE")
+        before = timed(by_user, 1) / runs
+        db.execute("DROP INDEX idx_listings_user_id")
+        db.execute("CREATE INDEX idx_listings_user_created ON listings(user_id, created_at, id)")
+        db.execute("ANALYZE")
+        report("user_id, newest first", before, timed(by_user, 1) / runs)
+        db.close()
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,86 @@
+import json
+import logging
+import time
+
+import tornado.gen
+import tornado.httpclient
+import tornado.ioloop
+
+# Event types emitted by the listing service
+LISTING_CREATED = "listing.created"
+LISTING_UPDATED = "listing.updated"
+LISTING_PRICE_DROPPED = "listing.price_dropped"
+LISTING_SOLD = "listing.sold"
+LISTING_DELETED = "listing.deleted"
+LISTING_EXPIRED = "listing.expired"
+LISTING_ARCHIVED = "listing.archived"
+LISTING_PROMOTION_ENDED = "listing.promotion_ended"
+LISTING_HELD = "listing.held"
+LISTING_HOLD_RELEASED = "listing.hold_released"
+LISTING_APPROVED = "listing.approved"
+LISTING_REJECTED = "listing.rejected"
+LISTING_FLAGGED = "listing.flagged"
+LISTING_VIEWED = "listing.viewed"
+LISTING_FAVORITED = "listing.favorited"
+LISTING_UNFAVORITED = "listing.unfavorited"
+SAVED_SEARCH_MATCHED = "saved_search.matched"
+OFFER_ACCEPTED = "offer.accepted"
+
+# Maximum number of outbox events delivered per polling round
+RELAY_BATCH_SIZE = 100
+
+def new_event(event_type, payload):
+    # Builds an event of the given type, timestamped now
+    return dict(type=event_type, payload=payload, created_at=int(time.time() * 1e6))
+
+class EventRelay:
+    """Delivers outbox events to subscriber URLs over HTTP.
+
+    Each event is POSTed as JSON to every subscriber and marked as published once all of
+    them acknowledged it with a 2xx status; otherwise it is retried on the next round.
+    Delivery is therefore at-least-once, and consumers must handle duplicates.
+    """
+
+    def __init__(self, repo, subscribers, interval):
+        self.repo = repo
+        self.subscribers = subscribers
+        self.interval = interval # Seconds between polling rounds
+        self.http_client = tornado.httpclient.AsyncHTTPClient()
+        self.delivering = False
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.deliver_pending, self.interval * 1000).start()
+
+    @tornado.gen.coroutine
+    def deliver_pending(self):
+        # Skip the round if the previous one is still delivering, so events stay in order
+        if self.delivering:
+            return
+        self.delivering = True
+        try:
+            published = []
+            for event in self.repo.fetch_pending_events(RELAY_BATCH_SIZE):
+                try:
+                    yield self.deliver(event)
+                except Exception as e:
+                    logging.warning("Error delivering event {} ({}): {}".format(event["id"], event["type"], e))
+                    self.repo.mark_event_failed(event["id"], str(e))
+                    # Stop here so events are delivered in order
+                    break
+                published.append(event["id"])
+            self.repo.mark_events_published(published, int(time.time() * 1e6))
+        finally:
+            self.delivering = False
+
+    @tornado.gen.coroutine
+    def deliver(self, event):
+        # Sends an event to every subscriber, raising on failures and non-2xx responses
+        body = json.dumps(event)
+        for url in self.subscribers:
+            yield self.http_client.fetch(
+                url,
+                method="POST",
+                body=body,
+                headers={"Content-Type": "application/json"},
+                request_timeout=5,
+            )
@@ -0,0 +1,59 @@
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.ioloop
+
+# Name of the lease held by the instance running the expiry worker
+LEASE_NAME = "listing_expiry"
+
+# Maximum number of listings (or promotions, or holds) expired per batch
+EXPIRY_BATCH_SIZE = 100
+
+class ExpiryWorker:
+    """Periodically marks listings past their expires_at as expired, emitting listing.expired,
+    ends promotions past their ends_at, emitting listing.promotion_ended, and releases holds
+    past their expires_at, emitting listing.hold_released.
+
+    When several instances share the database, only the one holding the listing_expiry lease
+    runs the worker. The lease lasts a few intervals and is renewed on every round, so another
+    instance takes over within that time if the holder stops.
+    """
+
+    def __init__(self, listing_service, repo, interval):
+        self.listing_service = listing_service
+        self.repo = repo
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.expire_due, self.interval * 1000).start()
+
+    def expire_due(self):
+        try:
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, int(time.time() * 1e6), self.lease_duration):
+                return
+            # Expire every due listing, a batch at a time
+            while True:
+                count = self.listing_service.expire_due_listings(EXPIRY_BATCH_SIZE)
+                if count > 0:
+                    logging.info("Expired {} listings".format(count))
+                if count < EXPIRY_BATCH_SIZE:
+                    break
+            while True:
+                count = self.listing_service.expire_due_promotions(EXPIRY_BATCH_SIZE)
+                if count > 0:
+                    logging.info("Ended {} promotions".format(count))
+                if count < EXPIRY_BATCH_SIZE:
+                    break
+            while True:
+                count = self.listing_service.expire_due_holds(EXPIRY_BATCH_SIZE)
+                if count > 0:
+                    logging.info("Released {} lapsed holds".format(count))
+                if count < EXPIRY_BATCH_SIZE:
+                    break
+        except Exception:
+            logging.exception("Error expiring listings")
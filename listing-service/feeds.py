@@ -0,0 +1,174 @@
+import datetime
+import email.utils
+import hashlib
+import json
+import time
+import xml.etree.ElementTree as ET
+
+from money import Money
+
+# Content types of the supported feed formats
+FEED_CONTENT_TYPES = {
+    "rss": "application/rss+xml; charset=utf-8",
+    "atom": "application/atom+xml; charset=utf-8",
+    "json": "application/feed+json; charset=utf-8",
+}
+
+# Maximum number of rendered feeds cached at once, one per format and query
+MAX_CACHED_FEEDS = 1000
+
+ATOM_NAMESPACE = "http://www.w3.org/2005/Atom"
+ET.register_namespace("atom", ATOM_NAMESPACE)
+
+class Feed:
+    """A rendered feed, with the validators conditional GETs are checked against."""
+
+    def __init__(self, body, content_type, last_modified):
+        self.body = body
+        self.content_type = content_type
+        # Microseconds since the epoch of the newest change to the feed's listings, None when empty
+        self.last_modified = last_modified
+        self.etag = '"{}"'.format(hashlib.sha1(body).hexdigest())
+
+    def not_modified(self, if_none_match, if_modified_since):
+        # Returns whether a client holding the given validators already has this feed.
+        # If-None-Match takes precedence over If-Modified-Since, as in RFC 9110
+        if if_none_match:
+            tags = [tag.strip() for tag in if_none_match.split(",")]
+            # Weak comparison: a W/ prefix does not matter for a GET
+            return "*" in tags or self.etag in [tag[2:] if tag.startswith("W/") else tag for tag in tags]
+        if if_modified_since and self.last_modified is not None:
+            try:
+                since = email.utils.parsedate_to_datetime(if_modified_since)
+            except (TypeError, ValueError):
+                return False
+            # HTTP dates have a resolution of one second
+            return self.last_modified // 1000000 <= since.timestamp()
+        return False
+
+class FeedCache:
+    """Keeps rendered feeds for ttl seconds, so aggregators polling them do not hit the db."""
+
+    def __init__(self, ttl):
+        self.ttl = ttl
+        self.feeds = {} # key -> (Feed, cached until)
+
+    def get(self, key):
+        cached = self.feeds.get(key)
+        if cached is not None and cached[1] > time.monotonic():
+            return cached[0]
+        return None
+
+    def put(self, key, feed):
+        if self.ttl <= 0:
+            return
+        if len(self.feeds) >= MAX_CACHED_FEEDS:
+            self.feeds.clear()
+        self.feeds[key] = (feed, time.monotonic() + self.ttl)
+
+def render(feed_format, listings, title, feed_url, site_url):
+    # Renders listings, newest first, as a feed in feed_format (see FEED_CONTENT_TYPES). feed_url
+    # is the feed's own URL, and listings link to {site_url}/listings/{id}
+    site_url = site_url.rstrip("/")
+    entries = [_entry(listing, site_url) for listing in listings]
+    last_modified = max((listing["updated_at"] for listing in listings), default=None)
+    renderer = {"rss": _rss, "atom": _atom, "json": _json_feed}[feed_format]
+    body = renderer(entries, title, feed_url, site_url, last_modified)
+    return Feed(body, FEED_CONTENT_TYPES[feed_format], last_modified)
+
+def _entry(listing, site_url):
+    # Returns the feed-independent fields of a listing's entry
+    price = "{} {}".format(Money(listing["price"], listing["currency"]).format(), listing["currency"])
+    if listing["rental_period"]:
+        price += " " + listing["rental_period"]
+    title = listing["title"] or "{} listing {}".format(listing["listing_type"].capitalize(), listing["id"])
+    return dict(
+        id=listing["id"],
+        url="{}/listings/{}".format(site_url, listing["id"]),
+        title="{} - {}".format(title, price),
+        summary=listing["description"] or "",
+        author=listing["user_name"],
+        category=listing["category"]["name"] if listing["category"] else None,
+        image=listing["images"][0]["url"] if listing["images"] else None,
+        published=listing["created_at"],
+        updated=listing["updated_at"],
+    )
+
+def http_date(micros):
+    # Formats microseconds since the epoch as an HTTP (and RSS) date
+    return email.utils.formatdate(micros / 1e6, usegmt=True)
+
+def _rfc3339(micros):
+    return datetime.datetime.fromtimestamp(micros // 1000000, datetime.timezone.utc).isoformat().replace("+00:00", "Z")
+
+def _xml(root):
+    return ET.tostring(root, encoding="utf-8", xml_declaration=True)
+
+def _rss(entries, title, feed_url, site_url, last_modified):
+    rss = ET.Element("rss", version="2.0")
+    channel = ET.SubElement(rss, "channel")
+    ET.SubElement(channel, "title").text = title
+    ET.SubElement(channel, "link").text = site_url
+    ET.SubElement(channel, "description").text = title
+    # RSS readers find the feed's own URL in an Atom self link
+    ET.SubElement(channel, "{%s}link" % ATOM_NAMESPACE, href=feed_url, rel="self", type=FEED_CONTENT_TYPES["rss"].split(";")[0])
+    if last_modified is not None:
+        ET.SubElement(channel, "lastBuildDate").text = http_date(last_modified)
+    for entry in entries:
+        item = ET.SubElement(channel, "item")
+        ET.SubElement(item, "title").text = entry["title"]
+        ET.SubElement(item, "link").text = entry["url"]
+        ET.SubElement(item, "guid", isPermaLink="true").text = entry["url"]
+        ET.SubElement(item, "description").text = entry["summary"]
+        if entry["category"] is not None:
+            ET.SubElement(item, "category").text = entry["category"]
+        ET.SubElement(item, "pubDate").text = http_date(entry["published"])
+    return _xml(rss)
+
+def _atom(entries, title, feed_url, site_url, last_modified):
+    # Atom is the document's default namespace, so its elements need no prefix
+    feed = ET.Element("feed", xmlns=ATOM_NAMESPACE)
+    ET.SubElement(feed, "id").text = feed_url
+    ET.SubElement(feed, "title").text = title
+    ET.SubElement(feed, "updated").text = _rfc3339(last_modified if last_modified is not None else int(time.time() * 1e6))
+    ET.SubElement(feed, "link", href=feed_url, rel="self")
+    ET.SubElement(feed, "link", href=site_url)
+    # Atom requires an author; entries of users without a known name fall back to the feed's
+    ET.SubElement(ET.SubElement(feed, "author"), "name").text = title
+    for entry in entries:
+        element = ET.SubElement(feed, "entry")
+        ET.SubElement(element, "id").text = entry["url"]
+        ET.SubElement(element, "title").text = entry["title"]
+        ET.SubElement(element, "link", href=entry["url"])
+        ET.SubElement(element, "published").text = _rfc3339(entry["published"])
+        ET.SubElement(element, "updated").text = _rfc3339(entry["updated"])
+        if entry["author"]:
+            ET.SubElement(ET.SubElement(element, "author"), "name").text = entry["author"]
+        if entry["category"] is not None:
+            ET.SubElement(element, "category", term=entry["category"])
+        if entry["image"] is not None:
+            ET.SubElement(element, "link", href=entry["image"], rel="enclosure")
+        ET.SubElement(element, "summary").text = entry["summary"]
+    return _xml(feed)
+
+def _json_feed(entries, title, feed_url, site_url, last_modified):
+    # JSON Feed 1.1, https://jsonfeed.org/version/1.1
+    items = []
+    for entry in entries:
+        item = dict(
+            id=str(entry["id"]),
+            url=entry["url"],
+            title=entry["title"],
+            content_text=entry["summary"],
+            date_published=_rfc3339(entry["published"]),
+            date_modified=_rfc3339(entry["updated"]),
+        )
+        if entry["author"]:
+            item["authors"] = [dict(name=entry["author"])]
+        if entry["category"] is not None:
+            item["tags"] = [entry["category"]]
+        if entry["image"] is not None:
+            item["image"] = entry["image"]
+        items.append(item)
+    feed = dict(version="https://jsonfeed.org/version/1.1", title=title, home_page_url=site_url, feed_url=feed_url, items=items)
+    return json.dumps(feed).encode()
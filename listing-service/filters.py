@@ -0,0 +1,116 @@
+import datetime
+import re
+
+# Maximum number of conditions in a filter expression
+MAX_CONDITIONS = 10
+
+# A condition is a field, an operator and a value, e.g. price<5000000, type:rent or
+# attributes.bedrooms>=2
+CONDITION_PATTERN = re.compile(r"^([a-z_]+(?:\.[a-z0-9_]+)?)(<=|>=|!=|:|<|>)(.+)$")
+
+# Prefix of the fields filtering on a listing attribute
+ATTRIBUTES_PREFIX = "attributes."
+
+# SQL for each operator; ":" means equals
+OPERATORS = {":": "=", "!=": "!=", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+EQUALITY = (":", "!=")
+COMPARISON = tuple(OPERATORS)
+
+def parse_int(value):
+    return int(value)
+
+def parse_listing_type(value):
+    if value not in ("rent", "sale"):
+        raise ValueError()
+    return value
+
+def parse_currency(value):
+    if not re.match(r"^[A-Za-z]{3}$", value):
+        raise ValueError()
+    return value.upper()
+
+def parse_time(value):
+    # Accepts microseconds since the epoch or an ISO 8601 date or date-time, UTC unless an offset is given
+    if value.isdigit():
+        return int(value)
+    parsed = datetime.datetime.fromisoformat(value)
+    if parsed.tzinfo is None:
+        parsed = parsed.replace(tzinfo=datetime.timezone.utc)
+    return int(parsed.timestamp() * 1e6)
+
+def parse_attribute_value(value):
+    # Attribute values are untyped in filters: true and false match booleans, which JSON1
+    # extracts as 1 and 0, numbers match numbers and anything else matches strings
+    if value in ("true", "false"):
+        return 1 if value == "true" else 0
+    if re.match(r"^-?[0-9]+$", value):
+        return int(value)
+    if re.match(r"^-?[0-9]*\.[0-9]+$", value):
+        return float(value)
+    return value
+
+# The fields that can be filtered on: field -> (column, allowed operators, value parser)
+FIELDS = {
+    "type": ("listings.listing_type", EQUALITY, parse_listing_type),
+    "price": ("listings.price", COMPARISON, parse_int),
+    "currency": ("listings.currency", EQUALITY, parse_currency),
+    "user_id": ("listings.user_id", EQUALITY, parse_int),
+    "category_id": ("listings.category_id", EQUALITY, parse_int),
+    "created_at": ("listings.created_at", COMPARISON, parse_time),
+    "updated_at": ("listings.updated_at", COMPARISON, parse_time),
+}
+
+# Shorthands for common time ranges, as (field, operator)
+ALIASES = {
+    "created_after": ("created_at", ">"),
+    "created_before": ("created_at", "<"),
+    "updated_after": ("updated_at", ">"),
+    "updated_before": ("updated_at", "<"),
+}
+
+def parse(expression):
+    # Parses a filter expression such as "type:rent,price<5000000,created_after:2024-01-01"
+    # into a list of (column, SQL operator, value) conditions, all of which must hold.
+    # Raises ValueError with a message for the caller on invalid input
+    terms = expression.split(",")
+    if len(terms) > MAX_CONDITIONS:
+        raise ValueError("invalid filter. At most {} conditions are allowed".format(MAX_CONDITIONS))
+
+    conditions = []
+    for term in terms:
+        match = CONDITION_PATTERN.match(term.strip())
+        if match is None:
+            raise ValueError("invalid filter condition '{}'. Must be <field><operator><value>".format(term))
+        field, operator, value = match.groups()
+
+        if field.startswith(ATTRIBUTES_PREFIX):
+            # The name only has lowercase letters, digits and underscores, so it is safe in the
+            # JSON path. Listings without the attribute never match, not even with !=
+            column = "json_extract(listings.attributes, '$.{}')".format(field[len(ATTRIBUTES_PREFIX):])
+            conditions.append((column, OPERATORS[operator], parse_attribute_value(value)))
+            continue
+        if field in ALIASES:
+            if operator != ":":
+                raise ValueError("invalid filter condition '{}'. {} only supports ':'".format(term, field))
+            field, operator = ALIASES[field]
+        if field not in FIELDS:
+            raise ValueError("invalid filter field '{}'. Supported fields: {}, attributes.<name>".format(
+                field, ", ".join(sorted(list(FIELDS) + list(ALIASES)))
+            ))
+
+        column, operators, parse_value = FIELDS[field]
+        if operator not in operators:
+            raise ValueError("invalid filter condition '{}'. {} supports {}".format(term, field, " ".join(operators)))
+        try:
+            value = parse_value(value)
+        except ValueError:
+            raise ValueError("invalid value in filter condition '{}'".format(term))
+        conditions.append((column, OPERATORS[operator], value))
+    return conditions
+
+def to_sql(conditions):
+    # Returns the WHERE clauses, each starting with AND, and their args for parsed conditions.
+    # Columns and operators come from the allowlists above, or attribute names matching
+    # CONDITION_PATTERN; values are always bound as args
+    clauses = "".join(" AND {} {} ?".format(column, operator) for column, operator, _ in conditions)
+    return clauses, [value for _, _, value in conditions]
@@ -0,0 +1,66 @@
+import math
+
+# Mean radius of the Earth, in kilometers
+EARTH_RADIUS_KM = 6371.0088
+
+# Kilometers spanned by one degree of latitude
+KM_PER_DEGREE = math.pi * EARTH_RADIUS_KM / 180
+
+# Radius used when near is given without radius_km, and the largest radius accepted
+DEFAULT_RADIUS_KM = 10
+MAX_RADIUS_KM = 1000
+
+def is_valid_latitude(latitude):
+    return -90 <= latitude <= 90
+
+def is_valid_longitude(longitude):
+    return -180 <= longitude <= 180
+
+def haversine_km(lat1, lng1, lat2, lng2):
+    # Great-circle distance between two points, in kilometers. None if either point is unknown
+    if None in (lat1, lng1, lat2, lng2):
+        return None
+    phi1, phi2 = math.radians(lat1), math.radians(lat2)
+    d_phi = phi2 - phi1
+    d_lambda = math.radians(lng2 - lng1)
+    a = math.sin(d_phi / 2) ** 2 + math.cos(phi1) * math.cos(phi2) * math.sin(d_lambda / 2) ** 2
+    return 2 * EARTH_RADIUS_KM * math.asin(min(1.0, math.sqrt(a)))
+
+def bounding_box(latitude, longitude, radius_km):
+    # Returns (min_lat, max_lat, min_lng, max_lng) enclosing the circle around a point.
+    # The longitude bounds are None when the box covers a pole, and min_lng > max_lng when it
+    # crosses the antimeridian
+    d_lat = radius_km / KM_PER_DEGREE
+    min_lat, max_lat = latitude - d_lat, latitude + d_lat
+    if min_lat <= -90 or max_lat >= 90:
+        return max(min_lat, -90), min(max_lat, 90), None, None
+
+    d_lng = d_lat / math.cos(math.radians(latitude))
+    min_lng, max_lng = longitude - d_lng, longitude + d_lng
+    if d_lng >= 180:
+        return min_lat, max_lat, None, None
+    if min_lng < -180:
+        min_lng += 360
+    if max_lng > 180:
+        max_lng -= 360
+    return min_lat, max_lat, min_lng, max_lng
+
+def parse_near(near, radius_km):
+    # Parses the near ("lat,lng") and radius_km query params into (latitude, longitude, radius_km),
+    # raising ValueError with a message for the caller on invalid input
+    try:
+        latitude, longitude = (float(part) for part in near.split(","))
+    except ValueError:
+        raise ValueError("invalid near. Must be 'lat,lng'")
+    if not is_valid_latitude(latitude) or not is_valid_longitude(longitude):
+        raise ValueError("invalid near. Latitude must be within [-90, 90] and longitude within [-180, 180]")
+
+    if radius_km is None:
+        return latitude, longitude, DEFAULT_RADIUS_KM
+    try:
+        radius_km = float(radius_km)
+    except ValueError:
+        raise ValueError("invalid radius_km")
+    if not 0 < radius_km <= MAX_RADIUS_KM:
+        raise ValueError("radius_km must be greater than 0 and at most {}".format(MAX_RADIUS_KM))
+    return latitude, longitude, radius_km
@@ -0,0 +1,132 @@
+import csv
+import json
+
+import tornado.gen
+
+from service import form_value
+
+# Default number of rows validated and inserted per transaction
+DEFAULT_IMPORT_CHUNK_SIZE = 500
+
+# Maximum number of row errors included in an import report
+MAX_REPORTED_ERRORS = 1000
+
+# Columns an import row may set; others are ignored
+IMPORT_COLUMNS = (
+    "user_id", "listing_type", "price", "currency", "title", "description", "category_id",
+    "latitude", "longitude", "expires_at", "available_from", "available_to",
+    "rental_period", "deposit", "negotiable", "attributes",
+)
+
+# Columns every row must have
+REQUIRED_COLUMNS = ("user_id", "listing_type", "price")
+
+class InvalidImportError(Exception):
+    """Raised when an import file cannot be processed at all, e.g. a CSV header is missing columns."""
+
+class ListingImport:
+    """Parses a CSV or NDJSON listing file fed in chunks, importing its rows as they arrive.
+
+    CSV files start with a header row naming the columns and hold one listing per line; NDJSON
+    files hold one JSON object per line. Blank lines are skipped. Rows are imported chunk_size
+    at a time, so memory use does not grow with the size of the file. feed and close are
+    coroutines, as importing checks the rows' users with the user service.
+    """
+
+    def __init__(self, listing_service, file_format, chunk_size=DEFAULT_IMPORT_CHUNK_SIZE):
+        self.listing_service = listing_service
+        self.file_format = file_format # "csv" or "ndjson"
+        self.chunk_size = chunk_size
+        self.buffer = b""
+        self.header = None
+        self.line_number = 0
+        self.pending = [] # (line number, params) of rows not imported yet
+        self.total = 0
+        self.imported = 0
+        self.failed = 0
+        self.errors = []
+
+    @tornado.gen.coroutine
+    def feed(self, chunk):
+        # Parses the complete lines of chunk, keeping a trailing partial line for the next chunk.
+        # Raises InvalidImportError if the file cannot be imported
+        self.buffer += chunk
+        *lines, self.buffer = self.buffer.split(b"\n")
+        for line in lines:
+            self._parse_line(line)
+            if len(self.pending) >= self.chunk_size:
+                yield self._import_pending()
+
+    @tornado.gen.coroutine
+    def close(self):
+        # Imports the remaining rows and returns the import report.
+        # Raises InvalidImportError if the file cannot be imported
+        if self.buffer:
+            self._parse_line(self.buffer)
+            self.buffer = b""
+        if self.file_format == "csv" and self.header is None:
+            raise InvalidImportError("file is empty")
+        yield self._import_pending()
+        self.errors.sort(key=lambda error: error["row"])
+        return dict(total=self.total, imported=self.imported, failed=self.failed, errors=self.errors)
+
+    def _parse_line(self, line):
+        self.line_number += 1
+        try:
+            # The first line may start with a byte order mark, e.g. in CSV files saved by spreadsheets
+            text = line.decode("utf-8-sig" if self.line_number == 1 else "utf-8").strip()
+        except UnicodeDecodeError:
+            if self.header is None and self.file_format == "csv":
+                raise InvalidImportError("header is not valid UTF-8")
+            self._fail(self.line_number, ["invalid UTF-8"])
+            return
+        if not text:
+            return
+
+        if self.file_format == "csv" and self.header is None:
+            self.header = [name.strip() for name in next(csv.reader([text]))]
+            missing = [name for name in REQUIRED_COLUMNS if name not in self.header]
+            if missing:
+                raise InvalidImportError("header is missing columns: {}".format(", ".join(missing)))
+            return
+
+        self.total += 1
+        if self.file_format == "csv":
+            values = next(csv.reader([text]))
+            if len(values) != len(self.header):
+                self._fail(self.line_number, ["expected {} columns, got {}".format(len(self.header), len(values))])
+                return
+            row = dict(zip(self.header, values))
+        else:
+            try:
+                row = json.loads(text)
+            except ValueError:
+                self._fail(self.line_number, ["invalid JSON"])
+                return
+            if not isinstance(row, dict):
+                self._fail(self.line_number, ["expected a JSON object"])
+                return
+            # Values are validated like form values, so e.g. a user_id of 1.5 or true is rejected
+            row = {name: form_value(value) for name, value in row.items() if value is not None}
+
+        # Absent optional columns are left out, as if the form field was not sent, and absent
+        # required ones fail validation like empty values
+        params = {name: row[name] for name in IMPORT_COLUMNS if name in row}
+        for name in REQUIRED_COLUMNS:
+            params.setdefault(name, "")
+        self.pending.append((self.line_number, params))
+
+    @tornado.gen.coroutine
+    def _import_pending(self):
+        if not self.pending:
+            return
+        imported, errors = yield self.listing_service.import_listings(self.pending)
+        self.pending = []
+        self.imported += imported
+        for line_number, row_errors in errors:
+            self._fail(line_number, row_errors)
+
+    def _fail(self, line_number, errors):
+        self.failed += 1
+        if len(self.errors) < MAX_REPORTED_ERRORS:
+            self.errors.append(dict(row=line_number, errors=errors))
@@ -1,179 +1,1419 @@
+import tornado.ioloop
 import tornado.web
 import tornado.log
 import tornado.options
 import sqlite3
 import logging
+import hmac
 import json
-import time
+
+import currencies
+import feeds
+import filters as filter_expressions
+import geo
+from archive import ArchiveWorker
+from events import EventRelay
+from expiry import ExpiryWorker
+from matcher import SavedSearchMatcher
+from metrics import Metrics, MetricsHandler, route_templates
+from stats import StatsWorker
+from trending import TrendingWorker
+from imports import DEFAULT_IMPORT_CHUNK_SIZE, InvalidImportError, ListingImport
+from owners import OwnerChecker, OwnerCheckUnavailableError
+from querylog import DEFAULT_SLOW_QUERY_MS, QueryLoggingConnection
+from requestlog import REQUEST_ID_HEADER, log_request, request_id
+from storage import ImageStorageError, make_image_storage
+from views import ViewCounter
+from webhooks import WebhookDispatcher
+from repository import SORT_COLUMNS, CategoryRepository, ListingRepository
+from service import (
+    DEFAULT_FEED_SIZE, ORPHAN_POLICIES, CategoryService, ConflictError, ForbiddenError, ListingService, NotFoundError,
+    QuotaExceededError, RateLimitedError, ValidationError
+)
+
+# Maximum number of listings looked up at once with the ids param
+MAX_BATCH_IDS = 100
+
+# Number of features per page of GET /listings.geojson unless page_size is given
+GEOJSON_PAGE_SIZE = 100
+
+# Maximum size, in bytes, of a listing import file
+MAX_IMPORT_BYTES = 50 * 1024 * 1024
+
+# Seconds a request may take before it is answered with 503, unless the request_timeout option is given
+DEFAULT_REQUEST_TIMEOUT = 10.0
+
+# Body of the 503 responses of requests that timed out, as answered by the Go services' httpmiddleware
+TIMEOUT_BODY = '{"result":false,"error":"Request timed out"}'
 
 class App(tornado.web.Application):
 
-    def __init__(self, handlers, **kwargs):
-        super().__init__(handlers, **kwargs)
+    def __init__(self, handlers, default_currency, admin_token, require_approval, report_threshold,
+            import_chunk_size=DEFAULT_IMPORT_CHUNK_SIZE, slow_query_ms=DEFAULT_SLOW_QUERY_MS, max_active_listings=0,
+            user_service_url="", user_service_key="", owner_cache_ttl=60.0, orphan_policy="hide", orphan_owner_id=0,
+            image_storage_url="", image_base_url="", feed_title="New listings", feed_site_url="", feed_cache_ttl=60.0,
+            request_timeout=DEFAULT_REQUEST_TIMEOUT, **kwargs):
+        super().__init__(handlers, default_handler_class=NotFoundHandler, **kwargs)
+        self.admin_token = admin_token
+        # Requests not served within request_timeout seconds are answered with 503; 0 disables this
+        self.request_timeout = request_timeout
+        # Requests are counted and timed by route, served at /metrics on the metrics_port
+        self.metrics = Metrics("listing-service", route_templates(handlers))
+        # Non-positive chunk sizes keep the default
+        self.import_chunk_size = import_chunk_size if import_chunk_size > 0 else DEFAULT_IMPORT_CHUNK_SIZE
+
+        # Initialising db connection
+        self.db = sqlite3.connect("listings.db", factory=QueryLoggingConnection)
+        # Queries slower than slow_query_ms are logged with their query plan; 0 disables this
+        self.db.slow_query_threshold = slow_query_ms / 1000 if slow_query_ms > 0 else None
+        self.db.row_factory = sqlite3.Row
+
+        # Wiring the layers: handlers call the service, which stores listings through the repository
+        self.category_repo = CategoryRepository(self.db)
+        self.category_repo.init_schema()
+        self.repo = ListingRepository(self.db)
+        self.repo.init_schema(default_currency)
+        self.view_counter = ViewCounter(self.repo)
+        # Listings are only created for users the user service knows, when its URL is configured
+        owners = OwnerChecker(user_service_url, owner_cache_ttl, user_service_key or None) if user_service_url else None
+        # Images uploaded with multipart listing creation are stored here, when it is configured
+        image_storage = make_image_storage(image_storage_url, image_base_url)
+        self.listing_service = ListingService(
+            self.repo, self.category_repo, default_currency, self.view_counter, require_approval, report_threshold,
+            max_active_listings, owners, orphan_policy, orphan_owner_id or None, image_storage
+        )
+        self.category_service = CategoryService(self.category_repo)
+        # Feeds of new listings link to feed_site_url, or else to this service
+        self.feed_title = feed_title
+        self.feed_site_url = feed_site_url
+        self.feed_cache = feeds.FeedCache(feed_cache_ttl)
+
+    def find_handler(self, request, **kwargs):
+        self.metrics.started()
+        return super().find_handler(request, **kwargs)
+
+    def log_request(self, handler):
+        self.metrics.observe(handler)
+        super().log_request(handler)
+
+class BaseHandler(tornado.web.RequestHandler):
+    # Handlers answering streamed or long-running requests set this, so they are not answered
+    # with 503 after the request_timeout
+    timeout_exempt = False
+    # Size of the response body sent, for the access log
+    bytes_written = 0
+    timed_out = False
+    request_timer = None
+
+    def set_default_headers(self):
+        # Echoes the caller's X-Request-ID, or the one generated for the request
+        self.set_header(REQUEST_ID_HEADER, request_id(self.request))
+
+    def prepare(self):
+        # Answers 503 once the request_timeout passes, like the Go services' Timeout middleware.
+        # The timer only fires while the handler waits, e.g. on the user service; a handler
+        # blocking the IOLoop is answered late
+        timeout = self.application.request_timeout
+        if timeout > 0 and not self.timeout_exempt:
+            self.request_timer = tornado.ioloop.IOLoop.current().call_later(timeout, self.on_timeout)
+
+    def on_timeout(self):
+        # The handler keeps running until it returns, but what it writes then is discarded
+        self.clear()
+        self.set_status(503)
+        self.set_header("Content-Type", "application/json")
+        self.finish(TIMEOUT_BODY)
+        self.timed_out = True
+
+    def on_finish(self):
+        if self.request_timer is not None:
+            tornado.ioloop.IOLoop.current().remove_timeout(self.request_timer)
+
+    def write(self, chunk):
+        if not self.timed_out:
+            super().write(chunk)
+
+    def flush(self, include_footers=False):
+        # Counts the body about to be sent, which tornado drops for HEAD requests
+        if self.request.method != "HEAD":
+            self.bytes_written += sum(len(chunk) for chunk in self._write_buffer)
+        return super().flush(include_footers)
+
+    def finish(self, chunk=None):
+        if self.timed_out:
+            return None
+        return super().finish(chunk)
+
+    def write_json(self, obj, status_code=200, content_type="application/json"):
+        self.set_header("Content-Type", content_type)
+        self.set_status(status_code)
+        self.write(json.dumps(obj))
+
+    def require_admin(self):
+        # Checks the X-Admin-Token header against the admin_token option.
+        # Returns False after writing a 403 or 401 response if the caller is not an admin
+        if not self.application.admin_token:
+            self.write_json({"result": False, "errors": ["admin API is disabled"]}, status_code=403)
+            return False
+        provided = self.request.headers.get("X-Admin-Token", "")
+        if not hmac.compare_digest(provided.encode(), self.application.admin_token.encode()):
+            self.write_json({"result": False, "errors": ["admin token is missing or invalid"]}, status_code=401)
+            return False
+        return True
+
+    def listing_params(self, required=("user_id", "listing_type", "price")):
+        # Collects the parameters of a listing create or update; missing required ones yield a 400
+        params = {name: self.get_argument(name) for name in required}
+        for name in ("listing_type", "price", "currency", "title", "description", "category_id", "latitude",
+                "longitude", "expires_at", "available_from", "available_to", "rental_period", "deposit", "negotiable",
+                "attributes"):
+            value = self.get_argument(name, None)
+            if value is not None and name not in params:
+                params[name] = value
+        return params
+
+    def list_params(self, default_page_size=10):
+        # Parses the pagination, filter and sort params of listing queries into
+        # (page_num, page_size, filters, sort), where sort is None unless requested.
+        # Returns None after writing a 400 response if any is invalid
+        filters = {}
+
+        # Parsing the ids param of batch lookups, which return every listing found in one page by default
+        ids = self.get_argument("ids", None)
+        if ids is not None:
+            try:
+                filters["ids"] = sorted({int(listing_id) for listing_id in ids.split(",")})
+            except ValueError:
+                self.write_json({"result": False, "errors": "invalid ids. Must be a comma-separated list of listing ids"}, status_code=400)
+                return None
+            if len(filters["ids"]) > MAX_BATCH_IDS:
+                self.write_json({"result": False, "errors": "at most {} ids can be looked up at once".format(MAX_BATCH_IDS)}, status_code=400)
+                return None
+
+        page_num = self.get_argument("page_num", 1)
+        page_size = self.get_argument("page_size", len(filters["ids"]) if "ids" in filters else default_page_size)
+        try:
+            page_num = int(page_num)
+        except:
+            logging.exception("Error while parsing page_num: {}".format(page_num))
+            self.write_json({"result": False, "errors": "invalid page_num"}, status_code=400)
+            return None
+
+        try:
+            page_size = int(page_size)
+        except:
+            logging.exception("Error while parsing page_size: {}".format(page_size))
+            self.write_json({"result": False, "errors": "invalid page_size"}, status_code=400)
+            return None
+
+        # Parsing user_id and category_id filter params
+        for name in ("user_id", "category_id"):
+            value = self.get_argument(name, None)
+            if value is not None:
+                try:
+                    filters[name] = int(value)
+                except:
+                    self.write_json({"result": False, "errors": "invalid {}".format(name)}, status_code=400)
+                    return None
+
+        # Parsing the near and radius_km params of radius queries
+        near = self.get_argument("near", None)
+        if near is not None:
+            try:
+                filters["near"] = geo.parse_near(near, self.get_argument("radius_km", None))
+            except ValueError as e:
+                self.write_json({"result": False, "errors": str(e)}, status_code=400)
+                return None
+
+        # Parsing the filter expression, which combines conditions on several fields
+        expression = self.get_argument("filter", None)
+        if expression is not None:
+            try:
+                filters["conditions"] = filter_expressions.parse(expression)
+            except ValueError as e:
+                self.write_json({"result": False, "errors": str(e)}, status_code=400)
+                return None
+
+        # Parsing the available_on param, which only matches rentals available at that time
+        available_on = self.get_argument("available_on", None)
+        if available_on is not None:
+            try:
+                filters["available_on"] = filter_expressions.parse_time(available_on)
+            except ValueError:
+                self.write_json({"result": False, "errors": "invalid available_on. Must be microseconds since the epoch or an ISO 8601 date or date-time"}, status_code=400)
+                return None
+
+        # Expired listings are only included on request, e.g. for their owners
+        include_expired = self.get_argument("include_expired", "false")
+        if include_expired not in ("true", "false"):
+            self.write_json({"result": False, "errors": "invalid include_expired. Supported values: 'true', 'false'"}, status_code=400)
+            return None
+        filters["include_expired"] = include_expired == "true"
+
+        # Parsing sort and order params
+        sort = None
+        sort_field = self.get_argument("sort", None)
+        if sort_field is not None:
+            if sort_field not in SORT_COLUMNS:
+                self.write_json({"result": False, "errors": "invalid sort. Supported values: {}".format(
+                    ", ".join("'{}'".format(field) for field in SORT_COLUMNS)
+                )}, status_code=400)
+                return None
+            order = self.get_argument("order", "desc")
+            if order not in ("asc", "desc"):
+                self.write_json({"result": False, "errors": "invalid order. Supported values: 'asc', 'desc'"}, status_code=400)
+                return None
+            sort = (sort_field, order)
+
+        return page_num, page_size, filters, sort
+
+# /listings
+class ListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, filters, sort = list_params
+
+        # Facets summarize all matching listings, e.g. to render filter UIs, and are only computed on request
+        facets = self.get_argument("facets", "false")
+        if facets not in ("true", "false"):
+            self.write_json({"result": False, "errors": "invalid facets. Supported values: 'true', 'false'"}, status_code=400)
+            return
+
+        # cursor, the next_cursor of a previous page, pages by keyset instead of page_num
+        try:
+            listings, next_cursor = self.application.listing_service.get_listings_page(
+                page_num, page_size, filters, sort, self.get_argument("cursor", None)
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        response = {"result": True, "listings": listings, "next_cursor": next_cursor}
+        if facets == "true":
+            response["facets"] = self.application.listing_service.get_listing_facets(filters)
+        self.write_json(response)
+
+    @tornado.gen.coroutine
+    def post(self):
+        # multipart/form-data requests may carry the listing's images as "images" files
+        uploads = [
+            dict(body=f["body"], content_type=f["content_type"], filename=f["filename"])
+            for f in self.request.files.get("images", [])
+        ]
+        try:
+            listing = yield self.application.listing_service.create_listing(self.listing_params(), uploads)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except OwnerCheckUnavailableError:
+            self.write_json({"result": False, "errors": ["user_id could not be verified, the user service is unavailable"]}, status_code=503)
+            return
+        except ImageStorageError:
+            self.write_json({"result": False, "errors": ["images could not be stored, the image storage is unavailable"]}, status_code=503)
+            return
+        except QuotaExceededError as e:
+            self.write_json({"result": False, "code": e.code, "errors": [str(e)]}, status_code=409)
+            return
+
+        # Error out if we fail to retrieve the newly created listing
+        if listing is None:
+            self.write_json({"result": False, "errors": ["Error while adding listing to db"]}, status_code=500)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings.geojson
+class ListingsGeoJSONHandler(BaseHandler):
+    """Returns active listings with coordinates as a GeoJSON FeatureCollection, for map frontends."""
+
+    @tornado.gen.coroutine
+    def get(self):
+        list_params = self.list_params(default_page_size=GEOJSON_PAGE_SIZE)
+        if list_params is None:
+            return
+        page_num, page_size, filters, sort = list_params
+
+        properties = self.get_argument("properties", None)
+        if properties is not None:
+            properties = [name.strip() for name in properties.split(",") if name.strip()]
+        try:
+            collection = self.application.listing_service.get_listings_geojson(page_num, page_size, filters, sort, properties)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json(collection, content_type="application/geo+json")
+
+# /listings/feed.rss, /listings/feed.atom, /listings/feed.json
+class ListingFeedHandler(BaseHandler):
+    """Serves the newest active listings as an RSS, Atom or JSON Feed, for aggregators."""
+
+    @tornado.gen.coroutine
+    def get(self, feed_format):
+        # Rendered feeds are cached per format and query, and carry an Etag and Last-Modified so
+        # aggregators polling them mostly get a 304 Not Modified
+        key = (feed_format, self.request.query)
+        feed = self.application.feed_cache.get(key)
+        if feed is None:
+            list_params = self.list_params(default_page_size=DEFAULT_FEED_SIZE)
+            if list_params is None:
+                return
+            _, page_size, filters, _ = list_params
+            try:
+                listings = self.application.listing_service.get_feed_listings(page_size, filters)
+            except ValidationError as e:
+                self.write_json({"result": False, "errors": e.errors}, status_code=400)
+                return
+            site_url = self.application.feed_site_url or "{}://{}".format(self.request.protocol, self.request.host)
+            feed = feeds.render(feed_format, listings, self.application.feed_title, self.request.full_url(), site_url)
+            self.application.feed_cache.put(key, feed)
+
+        self.set_header("Etag", feed.etag)
+        if feed.last_modified is not None:
+            self.set_header("Last-Modified", feeds.http_date(feed.last_modified))
+        self.set_header("Cache-Control", "public, max-age={}".format(int(self.application.feed_cache.ttl)))
+        if feed.not_modified(self.request.headers.get("If-None-Match"), self.request.headers.get("If-Modified-Since")):
+            self.set_status(304)
+            return
+        self.set_header("Content-Type", feed.content_type)
+        self.write(feed.body)
+
+# /listings/import
+@tornado.web.stream_request_body
+class ListingImportHandler(BaseHandler):
+    """Imports listings from a CSV or NDJSON file streamed as the request body."""
+
+    # Reading and importing the body takes as long as the file is large
+    timeout_exempt = True
+
+    def prepare(self):
+        self.invalid_import = None
+
+        # The format comes from the format param, or else the Content-Type header
+        content_type = self.request.headers.get("Content-Type", "")
+        file_format = self.get_query_argument("format", "csv" if content_type.startswith("text/csv") else "ndjson")
+        if file_format not in ("csv", "ndjson"):
+            self.write_json({"result": False, "errors": ["invalid format. Supported values: 'csv', 'ndjson'"]}, status_code=400)
+            self.finish()
+            return
+
+        self.request.connection.set_max_body_size(MAX_IMPORT_BYTES)
+        self.listing_import = ListingImport(self.application.listing_service, file_format, self.application.import_chunk_size)
+
+    @tornado.gen.coroutine
+    def data_received(self, chunk):
+        # Once the file is known to be invalid, the rest of the body is discarded. Tornado waits
+        # for each chunk to be imported before reading the next
+        if self.invalid_import is not None:
+            return
+        try:
+            yield self.listing_import.feed(chunk)
+        except InvalidImportError as e:
+            self.invalid_import = e
+
+    @tornado.gen.coroutine
+    def post(self):
+        try:
+            if self.invalid_import is not None:
+                raise self.invalid_import
+            report = yield self.listing_import.close()
+        except InvalidImportError as e:
+            self.write_json({"result": False, "errors": ["invalid import file: {}".format(e)]}, status_code=400)
+            return
+        self.write_json({"result": True, "report": report})
+
+# /listings/search
+class ListingSearchHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, filters, sort = list_params
+
+        try:
+            listings = self.application.listing_service.search_listings(
+                self.get_argument("q", ""), page_num, page_size, filters, sort
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "listings": listings})
+
+# /listings/featured
+class ListingFeaturedHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        weighted = self.get_argument("weighted", "false")
+        if weighted not in ("true", "false"):
+            self.write_json({"result": False, "errors": ["invalid weighted. Supported values: 'true', 'false'"]}, status_code=400)
+            return
+
+        try:
+            listings = self.application.listing_service.get_featured_listings(
+                self.get_argument("count", None), weighted == "true"
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "listings": listings})
+
+# /listings/archive
+class ArchivedListingsHandler(BaseHandler):
+    """Lists the sold listings the archive worker moved out of the listings table."""
+
+    @tornado.gen.coroutine
+    def get(self):
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, filters, _ = list_params
+
+        listings = self.application.listing_service.get_archived_listings(page_num, page_size, dict(
+            user_id=filters.get("user_id"), category_id=filters.get("category_id")
+        ))
+        self.write_json({"result": True, "listings": listings})
+
+# /listings/archive/{id}
+class ArchivedListingHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, listing_id):
+        try:
+            listing = self.application.listing_service.get_archived_listing(int(listing_id))
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["archived listing not found"]}, status_code=404)
+            return
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/similar
+class ListingSimilarHandler(BaseHandler):
+    """Returns listings like a listing, for "you may also like" widgets."""
+
+    @tornado.gen.coroutine
+    def get(self, listing_id):
+        try:
+            listings = self.application.listing_service.get_similar_listings(
+                int(listing_id), self.get_argument("count", None)
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "listings": listings})
+
+# /listings/trending
+class ListingTrendingHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, filters, sort = list_params
+        if sort is not None:
+            self.write_json({"result": False, "errors": "trending listings cannot be sorted"}, status_code=400)
+            return
+
+        try:
+            listings = self.application.listing_service.get_trending_listings(
+                self.get_argument("window", "24h"), page_num, page_size, filters
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "listings": listings})
+
+# /listings/stats
+class ListingStatsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        try:
+            stats = self.application.listing_service.get_listing_stats(self.get_argument("days", None))
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "stats": stats})
+
+# /listings/price-suggestion
+class PriceSuggestionHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        params = {name: self.get_argument(name, None) for name in ("listing_type", "category_id", "currency", "rental_period")}
+        try:
+            suggestion = self.application.listing_service.get_price_suggestion(params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "suggestion": suggestion})
+
+# /listings/{id}
+class ListingHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def put(self, listing_id):
+        # A full update replaces every field, so omitted optional ones are cleared (except currency)
+        try:
+            listing = self.application.listing_service.update_listing(int(listing_id), self.listing_params())
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+    @tornado.gen.coroutine
+    def patch(self, listing_id):
+        # A partial update: the body is a JSON merge patch of the fields to change, and the
+        # owner's user_id is a query parameter
+        content_type = self.request.headers.get("Content-Type", "").split(";")[0].strip()
+        if content_type not in ("application/merge-patch+json", "application/json"):
+            self.write_json({"result": False, "errors": ["Content-Type must be application/merge-patch+json"]}, status_code=415)
+            return
+        try:
+            patch = json.loads(self.request.body)
+        except ValueError:
+            self.write_json({"result": False, "errors": ["invalid JSON"]}, status_code=400)
+            return
+
+        try:
+            listing = self.application.listing_service.patch_listing(
+                int(listing_id), self.get_query_argument("user_id"), patch
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+    @tornado.gen.coroutine
+    def delete(self, listing_id):
+        # The owner's user_id is required, either as a query or a form parameter
+        user_id = self.get_argument("user_id")
+
+        try:
+            self.application.listing_service.delete_listing(int(listing_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True})
+
+# /listings/{id}/images
+class ListingImagesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        params = {name: self.get_argument(name) for name in ("user_id", "url")}
+        for name in ("thumbnail_url", "width", "height"):
+            params[name] = self.get_argument(name, None)
+
+        try:
+            listing = self.application.listing_service.add_image(int(listing_id), params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/images/order
+class ListingImageOrderHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def put(self, listing_id):
+        user_id = self.get_argument("user_id")
+        image_ids = self.get_argument("image_ids")
+
+        try:
+            listing = self.application.listing_service.reorder_images(int(listing_id), user_id, image_ids)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/images/{image_id}
+class ListingImageHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def delete(self, listing_id, image_id):
+        # The owner's user_id is required, either as a query or a form parameter
+        user_id = self.get_argument("user_id")
+
+        try:
+            listing = self.application.listing_service.remove_image(int(listing_id), int(image_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing or image not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/favorite
+class ListingFavoriteHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        self._update_favorite(self.application.listing_service.add_favorite, listing_id)
+
+    @tornado.gen.coroutine
+    def delete(self, listing_id):
+        self._update_favorite(self.application.listing_service.remove_favorite, listing_id)
+
+    def _update_favorite(self, update, listing_id):
+        # The favoriting user's user_id is required, either as a query or a form parameter
+        user_id = self.get_argument("user_id")
+
+        try:
+            listing = update(int(listing_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/view
+class ListingViewHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        try:
+            self.application.listing_service.record_view(int(listing_id), self.get_argument("user_id", None))
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True})
+
+# /listings/{id}/bump
+class ListingBumpHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        user_id = self.get_argument("user_id")
+
+        try:
+            listing = self.application.listing_service.bump_listing(
+                int(listing_id), user_id, self.get_argument("extend_days", None)
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except RateLimitedError as e:
+            self.set_header("Retry-After", str(e.retry_after))
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=429)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/promotions
+class ListingPromotionsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, listing_id):
+        try:
+            promotions = self.application.listing_service.get_promotions(int(listing_id))
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "promotions": promotions})
+
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        params = dict(
+            user_id=self.get_argument("user_id"),
+            discount_percent=self.get_argument("discount_percent"),
+            starts_at=self.get_argument("starts_at", None),
+            ends_at=self.get_argument("ends_at", None)
+        )
+        try:
+            promotion = self.application.listing_service.create_promotion(int(listing_id), params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "promotion": promotion})
+
+# /listings/{id}/promotions/{promotion_id}
+class ListingPromotionHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def delete(self, listing_id, promotion_id):
+        user_id = self.get_argument("user_id")
+        try:
+            promotion = self.application.listing_service.cancel_promotion(int(listing_id), int(promotion_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing or promotion not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "promotion": promotion})
+
+# /listings/{id}/hold
+class ListingHoldHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        params = dict(
+            user_id=self.get_argument("user_id"),
+            buyer_id=self.get_argument("buyer_id"),
+            minutes=self.get_argument("minutes")
+        )
+        try:
+            hold = self.application.listing_service.hold_listing(int(listing_id), params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "hold": hold})
 
-        # Initialising db connection
-        self.db = sqlite3.connect("listings.db")
-        self.db.row_factory = sqlite3.Row
-        self.init_db()
-
-    def init_db(self):
-        cursor = self.db.cursor()
-
-        # Create table
-        cursor.execute(
-            "CREATE TABLE IF NOT EXISTS 'listings' ("
-            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
-            + "user_id INTEGER NOT NULL,"
-            + "listing_type TEXT NOT NULL,"
-            + "price INTEGER NOT NULL,"
-            + "created_at INTEGER NOT NULL,"
-            + "updated_at INTEGER NOT NULL"
-            + ");"
-        )
-        self.db.commit()
+    @tornado.gen.coroutine
+    def delete(self, listing_id):
+        user_id = self.get_argument("user_id")
+        try:
+            hold = self.application.listing_service.release_listing_hold(int(listing_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing or hold not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["only the listing's owner or buyer may release its hold"]}, status_code=403)
+            return
 
-class BaseHandler(tornado.web.RequestHandler):
-    def write_json(self, obj, status_code=200):
-        self.set_header("Content-Type", "application/json")
-        self.set_status(status_code)
-        self.write(json.dumps(obj))
+        self.write_json({"result": True, "hold": hold})
 
-# /listings
-class ListingsHandler(BaseHandler):
+# /listings/{id}/sold
+class ListingSoldHandler(BaseHandler):
     @tornado.gen.coroutine
-    def get(self):
-        # Parsing pagination params
-        page_num = self.get_argument("page_num", 1)
-        page_size = self.get_argument("page_size", 10)
+    def post(self, listing_id):
+        user_id = self.get_argument("user_id")
+
         try:
-            page_num = int(page_num)
-        except:
-            logging.exception("Error while parsing page_num: {}".format(page_num))
-            self.write_json({"result": False, "errors": "invalid page_num"}, status_code=400)
+            listing = self.application.listing_service.mark_listing_sold(int(listing_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["listing does not belong to user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /listings/{id}/report
+class ListingReportHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        user_id = self.get_argument("user_id")
+        reason = self.get_argument("reason")
+
+        try:
+            self.application.listing_service.report_listing(int(listing_id), user_id, reason)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
             return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True})
 
+# /listings/{id}/offers
+class ListingOffersHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, listing_id):
+        user_id = self.get_argument("user_id")
         try:
-            page_size = int(page_size)
-        except:
-            logging.exception("Error while parsing page_size: {}".format(page_size))
-            self.write_json({"result": False, "errors": "invalid page_size"}, status_code=400)
+            offers = self.application.listing_service.get_offers(int(listing_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
             return
 
-        # Parsing user_id param
-        user_id = self.get_argument("user_id", None)
-        if user_id is not None:
-            try:
-                user_id = int(user_id)
-            except:
-                self.write_json({"result": False, "errors": "invalid user_id"}, status_code=400)
-                return
+        self.write_json({"result": True, "offers": offers})
+
+    @tornado.gen.coroutine
+    def post(self, listing_id):
+        params = dict(user_id=self.get_argument("user_id"), amount=self.get_argument("amount"))
+        try:
+            offer = self.application.listing_service.make_offer(int(listing_id), params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "offer": offer})
+
+# /offers/{id}/(accept|reject|counter)
+class OfferResponseHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, offer_id, action):
+        user_id = self.get_argument("user_id")
+        try:
+            offer = self.application.listing_service.respond_to_offer(
+                int(offer_id), action, user_id, self.get_argument("amount", None)
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["offer not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["offer does not concern user_id"]}, status_code=403)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "offer": offer})
+
+# /users/{id}/favorites
+class UserFavoritesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, user_id):
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, filters, sort = list_params
+
+        listings = self.application.listing_service.get_favorites(int(user_id), page_num, page_size, filters, sort)
+        self.write_json({"result": True, "listings": listings})
+
+# /admin/listings/pending
+class PendingListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        if not self.require_admin():
+            return
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, _, _ = list_params
+
+        listings = self.application.listing_service.get_pending_listings(page_num, page_size)
+        self.write_json({"result": True, "listings": listings})
+
+# /admin/listings/reported
+class ReportedListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        if not self.require_admin():
+            return
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, _, _ = list_params
+
+        listings = self.application.listing_service.get_reported_listings(page_num, page_size)
+        self.write_json({"result": True, "listings": listings})
+
+# /admin/webhooks/deliveries
+class WebhookDeliveriesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        if not self.require_admin():
+            return
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, _, _ = list_params
+
+        try:
+            deliveries = self.application.listing_service.get_webhook_deliveries(
+                self.get_argument("status", None), page_num, page_size
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        self.write_json({"result": True, "deliveries": deliveries})
 
-        # Building select statement
-        select_stmt = "SELECT * FROM listings"
-        # Adding user_id filter clause if param is specified
-        if user_id is not None:
-            select_stmt += " WHERE user_id=?"
-        # Order by and pagination
-        limit = page_size
-        offset = (page_num - 1) * page_size
-        select_stmt += " ORDER BY created_at DESC LIMIT ? OFFSET ?"
-
-        # Fetching listings from db
-        if user_id is not None:
-            args = (user_id, limit, offset)
-        else:
-            args = (limit, offset)
-        cursor = self.application.db.cursor()
-        results = cursor.execute(select_stmt, args)
-
-        listings = []
-        for row in results:
-            fields = ["id", "user_id", "listing_type", "price", "created_at", "updated_at"]
-            listing = {
-                field: row[field] for field in fields
-            }
-            listings.append(listing)
+# /admin/listings/orphaned
+class OrphanedListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        if not self.require_admin():
+            return
+        list_params = self.list_params()
+        if list_params is None:
+            return
+        page_num, page_size, _, _ = list_params
 
+        listings = self.application.listing_service.get_orphaned_listings(page_num, page_size)
         self.write_json({"result": True, "listings": listings})
 
+# /admin/listings/{id}/(approve|reject)
+class ModerationHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, listing_id, action):
+        if not self.require_admin():
+            return
+
+        try:
+            if action == "approve":
+                listing = self.application.listing_service.approve_listing(int(listing_id))
+            else:
+                listing = self.application.listing_service.reject_listing(int(listing_id), self.get_argument("reason"))
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /admin/listings/{id}/reports
+class ListingReportsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, listing_id):
+        if not self.require_admin():
+            return
+
+        try:
+            reports = self.application.listing_service.get_reports(int(listing_id))
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["listing not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "reports": reports})
+
+# /categories
+class CategoriesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        categories = self.application.category_service.get_categories()
+        self.write_json({"result": True, "categories": categories})
+
     @tornado.gen.coroutine
     def post(self):
-        # Collecting required params
-        user_id = self.get_argument("user_id")
-        listing_type = self.get_argument("listing_type")
-        price = self.get_argument("price")
-
-        # Validating inputs
-        errors = []
-        user_id_val = self._validate_user_id(user_id, errors)
-        listing_type_val = self._validate_listing_type(listing_type, errors)
-        price_val = self._validate_price(price, errors)
-        time_now = int(time.time() * 1e6) # Converting current time to microseconds
-
-        # End if we have any validation errors
-        if len(errors) > 0:
-            self.write_json({"result": False, "errors": errors}, status_code=400)
-            return
-
-        # Proceed to store the listing in our db
-        cursor = self.application.db.cursor()
-        cursor.execute(
-            "INSERT INTO 'listings' "
-            + "('user_id', 'listing_type', 'price', 'created_at', 'updated_at') "
-            + "VALUES (?, ?, ?, ?, ?)",
-            (user_id_val, listing_type_val, price_val, time_now, time_now)
-        )
-        self.application.db.commit()
+        name = self.get_argument("name")
+        try:
+            category = self.application.category_service.create_category(name, self.get_argument("attributes", None))
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
 
-        # Error out if we fail to retrieve the newly created listing
-        if cursor.lastrowid is None:
-            self.write_json({"result": False, "errors": ["Error while adding listing to db"]}, status_code=500)
+        self.write_json({"result": True, "category": category})
+
+# /categories/{id}
+class CategoryHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, category_id):
+        try:
+            category = self.application.category_service.get_category(int(category_id))
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["category not found"]}, status_code=404)
             return
 
-        listing = dict(
-            id=cursor.lastrowid,
-            user_id=user_id_val,
-            listing_type=listing_type_val,
-            price=price_val,
-            created_at=time_now,
-            updated_at=time_now
-        )
+        self.write_json({"result": True, "category": category})
+
+    @tornado.gen.coroutine
+    def put(self, category_id):
+        name = self.get_argument("name")
+        try:
+            category = self.application.category_service.update_category(
+                int(category_id), name, self.get_argument("attributes", None)
+            )
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["category not found"]}, status_code=404)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "category": category})
+
+    @tornado.gen.coroutine
+    def delete(self, category_id):
+        try:
+            self.application.category_service.delete_category(int(category_id))
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["category not found"]}, status_code=404)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True})
+
+# /listings/drafts
+class ListingDraftsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        user_id = self.get_argument("user_id")
+        try:
+            drafts = self.application.listing_service.get_drafts(user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "drafts": drafts})
+
+# /listings/drafts/{draft_id}
+class ListingDraftHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self, draft_id):
+        user_id = self.get_argument("user_id")
+        try:
+            draft = self.application.listing_service.get_draft(draft_id, user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["draft not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True, "draft": draft})
+
+    @tornado.gen.coroutine
+    def put(self, draft_id):
+        # An autosave: the body is the draft as a JSON object, replacing any saved before, and
+        # the owner's user_id is a query parameter
+        try:
+            data = json.loads(self.request.body)
+        except ValueError:
+            self.write_json({"result": False, "errors": ["invalid JSON"]}, status_code=400)
+            return
+
+        try:
+            draft = self.application.listing_service.save_draft(draft_id, self.get_query_argument("user_id"), data)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "draft": draft})
+
+    @tornado.gen.coroutine
+    def delete(self, draft_id):
+        user_id = self.get_argument("user_id")
+        try:
+            self.application.listing_service.delete_draft(draft_id, user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["draft not found"]}, status_code=404)
+            return
+
+        self.write_json({"result": True})
+
+# /listings/drafts/{draft_id}/listings
+class ListingDraftListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, draft_id):
+        overrides = self.listing_params(required=("user_id",))
+        user_id = overrides.pop("user_id")
+        try:
+            listing = yield self.application.listing_service.create_listing_from_draft(draft_id, user_id, overrides)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except OwnerCheckUnavailableError:
+            self.write_json({"result": False, "errors": ["user_id could not be verified, the user service is unavailable"]}, status_code=503)
+            return
+        except QuotaExceededError as e:
+            self.write_json({"result": False, "code": e.code, "errors": [str(e)]}, status_code=409)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["draft not found"]}, status_code=404)
+            return
 
         self.write_json({"result": True, "listing": listing})
 
-    def _validate_user_id(self, user_id, errors):
+# /listing-templates
+class ListingTemplatesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        user_id = self.get_argument("user_id")
         try:
-            user_id = int(user_id)
-            return user_id
-        except Exception as e:
-            logging.exception("Error while converting user_id to int: {}".format(user_id))
-            errors.append("invalid user_id")
-            return None
+            templates = self.application.listing_service.get_templates(user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
 
-    def _validate_listing_type(self, listing_type, errors):
-        if listing_type not in {"rent", "sale"}:
-            errors.append("invalid listing_type. Supported values: 'rent', 'sale'")
-            return None
-        else:
-            return listing_type
+        self.write_json({"result": True, "templates": templates})
 
-    def _validate_price(self, price, errors):
-        # Convert string to int
+    @tornado.gen.coroutine
+    def post(self):
+        name = self.get_argument("name")
+        params = self.listing_params()
         try:
-            price = int(price)
-        except Exception as e:
-            logging.exception("Error while converting price to int: {}".format(price))
-            errors.append("invalid price. Must be an integer")
-            return None
+            template = self.application.listing_service.create_template(params["user_id"], name, params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
 
-        if price < 1:
-            errors.append("price must be greater than 0")
-            return None
-        else:
-            return price
+        self.write_json({"result": True, "template": template})
+
+# /listing-templates/{id}
+class ListingTemplateHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def delete(self, template_id):
+        user_id = self.get_argument("user_id")
+        try:
+            self.application.listing_service.delete_template(int(template_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["template not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["template does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True})
+
+# /listing-templates/{id}/listings
+class ListingTemplateListingsHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def post(self, template_id):
+        overrides = self.listing_params(required=("user_id",))
+        user_id = overrides.pop("user_id")
+        try:
+            listing = yield self.application.listing_service.create_listing_from_template(int(template_id), user_id, overrides)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except OwnerCheckUnavailableError:
+            self.write_json({"result": False, "errors": ["user_id could not be verified, the user service is unavailable"]}, status_code=503)
+            return
+        except QuotaExceededError as e:
+            self.write_json({"result": False, "code": e.code, "errors": [str(e)]}, status_code=409)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["template not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["template does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True, "listing": listing})
+
+# /saved-searches
+class SavedSearchesHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def get(self):
+        user_id = self.get_argument("user_id")
+        try:
+            saved_searches = self.application.listing_service.get_saved_searches(user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+
+        self.write_json({"result": True, "saved_searches": saved_searches})
+
+    @tornado.gen.coroutine
+    def post(self):
+        params = dict(
+            user_id=self.get_argument("user_id"),
+            listing_type=self.get_argument("listing_type", None),
+            currency=self.get_argument("currency", None),
+            min_price=self.get_argument("min_price", None),
+            max_price=self.get_argument("max_price", None),
+            category_id=self.get_argument("category_id", None)
+        )
+        try:
+            saved_search = self.application.listing_service.create_saved_search(params)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except ConflictError as e:
+            self.write_json({"result": False, "errors": [str(e)]}, status_code=409)
+            return
+
+        self.write_json({"result": True, "saved_search": saved_search})
+
+# /saved-searches/{id}
+class SavedSearchHandler(BaseHandler):
+    @tornado.gen.coroutine
+    def delete(self, saved_search_id):
+        user_id = self.get_argument("user_id")
+        try:
+            self.application.listing_service.delete_saved_search(int(saved_search_id), user_id)
+        except ValidationError as e:
+            self.write_json({"result": False, "errors": e.errors}, status_code=400)
+            return
+        except NotFoundError:
+            self.write_json({"result": False, "errors": ["saved search not found"]}, status_code=404)
+            return
+        except ForbiddenError:
+            self.write_json({"result": False, "errors": ["saved search does not belong to user_id"]}, status_code=403)
+            return
+
+        self.write_json({"result": True})
+
+# /events
+class EventsHandler(BaseHandler):
+    """Consumes domain events delivered by other services (at-least-once, so handlers must be idempotent)."""
+
+    @tornado.gen.coroutine
+    def post(self):
+        try:
+            event = json.loads(self.request.body)
+            event_type = event["type"]
+            payload = event["payload"]
+        except Exception:
+            logging.exception("Error while parsing event: {}".format(self.request.body))
+            self.write_json({"result": False, "errors": ["invalid event"]}, status_code=400)
+            return
+
+        handler = self.event_handlers.get(event_type)
+        if handler is None:
+            # Acknowledge events we don't care about so the sender doesn't retry them
+            self.write_json({"result": True, "handled": False})
+            return
+
+        try:
+            handler(self, payload)
+        except (KeyError, TypeError, ValueError):
+            logging.exception("Invalid payload for event {}: {}".format(event_type, payload))
+            self.write_json({"result": False, "errors": ["invalid payload for {}".format(event_type)]}, status_code=400)
+            return
+
+        self.write_json({"result": True, "handled": True})
+
+    def _on_user_created(self, payload):
+        user_id = int(payload["user_id"])
+        name = str(payload["name"])
+        created_at = int(payload["created_at"])
+        self.application.listing_service.on_user_renamed(user_id, name, created_at)
+
+    def _on_user_updated(self, payload):
+        user_id = int(payload["user_id"])
+        name = str(payload["name"])
+        updated_at = int(payload["updated_at"])
+        self.application.listing_service.on_user_renamed(user_id, name, updated_at)
+
+    def _on_user_merged(self, payload):
+        source_user_id = int(payload["source_user_id"])
+        target_user_id = int(payload["target_user_id"])
+        self.application.listing_service.on_user_merged(source_user_id, target_user_id)
+
+    def _on_user_deleted(self, payload):
+        user_id = int(payload["user_id"])
+        deleted_at = int(payload["deleted_at"])
+        self.application.listing_service.on_user_deleted(user_id, deleted_at)
+
+    event_handlers = {
+        "user.created": _on_user_created,
+        "user.updated": _on_user_updated,
+        "user.merged": _on_user_merged,
+        "user.deleted": _on_user_deleted,
+    }
+
+# Requests matching no route
+class NotFoundHandler(BaseHandler):
+    def prepare(self):
+        raise tornado.web.HTTPError(404)
 
 # /listings/ping
-class PingHandler(tornado.web.RequestHandler):
+class PingHandler(BaseHandler):
     @tornado.gen.coroutine
     def get(self):
         self.write("pong!")
@@ -182,7 +1422,59 @@ def make_app(options):
     return App([
         (r"/listings/ping", PingHandler),
         (r"/listings", ListingsHandler),
-    ], debug=options.debug)
+        (r"/listings\.geojson", ListingsGeoJSONHandler),
+        (r"/listings/feed\.(rss|atom|json)", ListingFeedHandler),
+        (r"/listings/search", ListingSearchHandler),
+        (r"/listings/import", ListingImportHandler),
+        (r"/listings/featured", ListingFeaturedHandler),
+        (r"/listings/trending", ListingTrendingHandler),
+        (r"/listings/stats", ListingStatsHandler),
+        (r"/listings/price-suggestion", PriceSuggestionHandler),
+        (r"/listings/drafts", ListingDraftsHandler),
+        (r"/listings/drafts/([^/]+)", ListingDraftHandler),
+        (r"/listings/drafts/([^/]+)/listings", ListingDraftListingsHandler),
+        (r"/listings/archive", ArchivedListingsHandler),
+        (r"/listings/archive/([0-9]+)", ArchivedListingHandler),
+        (r"/listings/([0-9]+)", ListingHandler),
+        (r"/listings/([0-9]+)/similar", ListingSimilarHandler),
+        (r"/listings/([0-9]+)/images", ListingImagesHandler),
+        (r"/listings/([0-9]+)/images/order", ListingImageOrderHandler),
+        (r"/listings/([0-9]+)/images/([0-9]+)", ListingImageHandler),
+        (r"/listings/([0-9]+)/favorite", ListingFavoriteHandler),
+        (r"/listings/([0-9]+)/view", ListingViewHandler),
+        (r"/listings/([0-9]+)/report", ListingReportHandler),
+        (r"/listings/([0-9]+)/bump", ListingBumpHandler),
+        (r"/listings/([0-9]+)/sold", ListingSoldHandler),
+        (r"/listings/([0-9]+)/promotions", ListingPromotionsHandler),
+        (r"/listings/([0-9]+)/promotions/([0-9]+)", ListingPromotionHandler),
+        (r"/listings/([0-9]+)/hold", ListingHoldHandler),
+        (r"/listings/([0-9]+)/offers", ListingOffersHandler),
+        (r"/offers/([0-9]+)/(accept|reject|counter)", OfferResponseHandler),
+        (r"/users/([0-9]+)/favorites", UserFavoritesHandler),
+        (r"/listing-templates", ListingTemplatesHandler),
+        (r"/listing-templates/([0-9]+)", ListingTemplateHandler),
+        (r"/listing-templates/([0-9]+)/listings", ListingTemplateListingsHandler),
+        (r"/saved-searches", SavedSearchesHandler),
+        (r"/saved-searches/([0-9]+)", SavedSearchHandler),
+        (r"/categories", CategoriesHandler),
+        (r"/categories/([0-9]+)", CategoryHandler),
+        (r"/events", EventsHandler),
+        (r"/admin/listings/pending", PendingListingsHandler),
+        (r"/admin/listings/orphaned", OrphanedListingsHandler),
+        (r"/admin/listings/reported", ReportedListingsHandler),
+        (r"/admin/listings/([0-9]+)/(approve|reject)", ModerationHandler),
+        (r"/admin/listings/([0-9]+)/reports", ListingReportsHandler),
+        (r"/admin/webhooks/deliveries", WebhookDeliveriesHandler),
+    ], default_currency=options.default_currency, admin_token=options.admin_token,
+        require_approval=options.require_approval, report_threshold=options.report_threshold,
+        import_chunk_size=options.import_chunk_size, slow_query_ms=options.slow_query_ms,
+        max_active_listings=options.max_active_listings, user_service_url=options.user_service_url,
+        user_service_key=options.user_service_key, owner_cache_ttl=options.owner_cache_ttl,
+        orphan_policy=options.orphan_policy, orphan_owner_id=options.orphan_owner_id,
+        image_storage_url=options.image_storage_url, image_base_url=options.image_base_url,
+        feed_title=options.feed_title, feed_site_url=options.feed_site_url, feed_cache_ttl=options.feed_cache_ttl,
+        request_timeout=options.request_timeout, log_function=log_request if options.access_log else lambda handler: None,
+        debug=options.debug)
 
 if __name__ == "__main__":
     # Define settings/options for the web app
@@ -191,16 +1483,127 @@ if __name__ == "__main__":
     # Specify whether the app should run in debug mode
     # Debug mode restarts the app automatically on file changes
     tornado.options.define("debug", default=True)
+    # ISO 4217 currency of listings created without one
+    tornado.options.define("default_currency", default="USD")
+    # Comma-separated URLs that domain events (e.g. listing.deleted) are POSTed to
+    tornado.options.define("event_subscribers", default="")
+    # How often, in seconds, pending domain events are delivered to subscribers
+    tornado.options.define("event_relay_interval", default=2.0)
+    # How often, in seconds, listings past their expires_at are marked as expired
+    tornado.options.define("expiry_interval", default=60.0)
+    # Number of days after their sale that sold listings are moved to the archive (0 disables archiving)
+    tornado.options.define("archive_after_days", default=90)
+    # How often, in seconds, sold listings are checked for archiving
+    tornado.options.define("archive_interval", default=3600.0)
+    # Token required in the X-Admin-Token header of admin endpoints; they are disabled when empty
+    tornado.options.define("admin_token", default="")
+    # Whether new listings wait for an admin's approval before they are publicly visible
+    tornado.options.define("require_approval", default=False)
+    # Number of abuse reports that moves an approved listing back to the moderation queue (0 disables it)
+    tornado.options.define("report_threshold", default=3)
+    # How often, in seconds, buffered listing views are written to the db
+    tornado.options.define("view_flush_interval", default=5.0)
+    # How often, in seconds, trending scores are recomputed from recent views
+    tornado.options.define("trending_interval", default=60.0)
+    # How often, in seconds, the daily listing stats rollups are refreshed
+    tornado.options.define("stats_interval", default=300.0)
+    # How often, in seconds, new listings are matched against saved searches
+    tornado.options.define("saved_search_interval", default=30.0)
+    # Queries taking longer than this many milliseconds are logged with their query plan (0 disables it)
+    tornado.options.define("slow_query_ms", default=DEFAULT_SLOW_QUERY_MS)
+    # Number of rows inserted per transaction by POST /listings/import
+    tornado.options.define("import_chunk_size", default=DEFAULT_IMPORT_CHUNK_SIZE)
+
+    # Maximum number of active (unexpired, unsold) listings per user (0 means unlimited)
+    tornado.options.define("max_active_listings", default=0)
+
+    # URL of the user service, e.g. http://localhost:7000, used to check that listing owners exist (empty disables it)
+    tornado.options.define("user_service_url", default="")
+    # "keyID:secret" key signing the requests to the user service, when it requires signed requests
+    tornado.options.define("user_service_key", default="")
+    # How long, in seconds, the existence of a user is cached
+    tornado.options.define("owner_cache_ttl", default=60.0)
+
+    # What happens to the listings of deleted users: hide, reassign (to orphan_owner_id) or orphan (keep visible)
+    tornado.options.define("orphan_policy", default="hide")
+    # User the listings of deleted users are handed over to with orphan_policy=reassign
+    tornado.options.define("orphan_owner_id", default=0)
+
+    # Where images uploaded with multipart POST /listings are stored: a file:// directory URL, or an http(s) URL
+    # accepting PUT requests (empty disables uploads)
+    tornado.options.define("image_storage_url", default="")
+    # Public URL stored images are served from, required with a file:// image_storage_url
+    tornado.options.define("image_base_url", default="")
+
+    # Comma-separated URLs that listing.created, listing.sold and listing.price_dropped events are POSTed to
+    tornado.options.define("webhook_urls", default="")
+    # Secret the webhook payloads are signed with, required with webhook_urls
+    tornado.options.define("webhook_secret", default="")
+    # How often, in seconds, new and retried webhook deliveries are sent
+    tornado.options.define("webhook_interval", default=5.0)
+
+    # Title of the feeds of new listings
+    tornado.options.define("feed_title", default="New listings")
+    # Public website the feeds of new listings link to, as {feed_site_url}/listings/{id} (defaults to this service)
+    tornado.options.define("feed_site_url", default="")
+    # How long, in seconds, rendered feeds are cached and may be cached by clients (0 disables caching)
+    tornado.options.define("feed_cache_ttl", default=60.0)
+    # Whether every request is logged once served, with its status, response size, duration and request ID
+    tornado.options.define("access_log", default=True)
+    # Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)
+    tornado.options.define("metrics_port", default=0)
+    # Seconds a request may take before it is answered with 503 (0 disables it; imports are exempt)
+    tornado.options.define("request_timeout", default=DEFAULT_REQUEST_TIMEOUT)
 
     # Read settings/options from command line
     tornado.options.parse_command_line()
 
     # Access the settings defined
     options = tornado.options.options
+    options.default_currency = options.default_currency.upper()
+    if not currencies.is_valid_currency(options.default_currency):
+        raise SystemExit("Invalid default_currency {}, expected an ISO 4217 code".format(options.default_currency))
+    if options.orphan_policy not in ORPHAN_POLICIES:
+        raise SystemExit("Invalid orphan_policy {}, expected one of {}".format(options.orphan_policy, ", ".join(ORPHAN_POLICIES)))
+    if options.orphan_policy == "reassign" and options.orphan_owner_id <= 0:
+        raise SystemExit("orphan_policy reassign requires an orphan_owner_id")
+    webhook_urls = [url.strip() for url in options.webhook_urls.split(",") if url.strip()]
+    if webhook_urls and not options.webhook_secret:
+        raise SystemExit("webhook_urls requires a webhook_secret")
 
     # Create web app
-    app = make_app(options)
+    try:
+        app = make_app(options)
+    except ValueError as e:
+        raise SystemExit(str(e))
     app.listen(options.port)
+    if options.metrics_port > 0:
+        tornado.web.Application([(r"/metrics", MetricsHandler, dict(metrics=app.metrics))]).listen(options.metrics_port)
+
+    # Deliver domain events from the outbox to subscribers in the background.
+    # Without subscribers, events are kept in the outbox until some are configured.
+    subscribers = [url.strip() for url in options.event_subscribers.split(",") if url.strip()]
+    if subscribers:
+        EventRelay(app.repo, subscribers, options.event_relay_interval).start()
+        logging.info("Delivering domain events to {}".format(subscribers))
+    # Write buffered listing views in batches
+    app.view_counter.start(options.view_flush_interval)
+
+    # Expire listings in the background; a lease keeps other instances sharing the db from doing the same
+    ExpiryWorker(app.listing_service, app.repo, options.expiry_interval).start()
+    # Move listings sold long ago to the archive, with a lease of its own
+    if options.archive_after_days > 0:
+        ArchiveWorker(app.listing_service, app.repo, options.archive_after_days, options.archive_interval).start()
+    # Rank listings by recent views for /listings/trending, with a lease of its own
+    TrendingWorker(app.repo, options.trending_interval).start()
+    # Roll up daily listing stats for /listings/stats, with a lease of its own
+    StatsWorker(app.repo, options.stats_interval).start()
+    # Match new listings against saved searches, emitting saved_search.matched, with a lease of its own
+    SavedSearchMatcher(app.listing_service, app.repo, options.saved_search_interval).start()
+    # POST signed listing events to the webhook URLs, retrying failed deliveries, with a lease of its own
+    if webhook_urls:
+        WebhookDispatcher(app.repo, webhook_urls, options.webhook_secret, options.webhook_interval).start()
+        logging.info("Delivering webhooks to {}".format(webhook_urls))
     logging.info("Starting listing service. PORT: {}, DEBUG: {}".format(options.port, options.debug))
 
     # Start event loop
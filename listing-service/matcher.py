@@ -0,0 +1,46 @@
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.ioloop
+
+# Name of the lease held by the instance running the saved search matcher
+LEASE_NAME = "saved_search_matcher"
+
+# Maximum number of listings matched per batch
+MATCH_BATCH_SIZE = 100
+
+class SavedSearchMatcher:
+    """Periodically matches new listings against saved searches, emitting saved_search.matched.
+
+    Every approved, visible listing is matched once, after it is created or approved. Matches
+    are recorded in the outbox together with the listing being marked as matched, so each of
+    them is emitted once and delivered to the notification pipeline by the EventRelay. Like
+    the ExpiryWorker, only the instance holding the saved_search_matcher lease runs.
+    """
+
+    def __init__(self, listing_service, repo, interval):
+        self.listing_service = listing_service
+        self.repo = repo
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.match_new, self.interval * 1000).start()
+
+    def match_new(self):
+        try:
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, int(time.time() * 1e6), self.lease_duration):
+                return
+            # Match every new listing, a batch at a time
+            while True:
+                count = self.listing_service.match_saved_searches(MATCH_BATCH_SIZE)
+                if count > 0:
+                    logging.info("Matched {} listings against saved searches".format(count))
+                if count < MATCH_BATCH_SIZE:
+                    break
+        except Exception:
+            logging.exception("Error matching saved searches")
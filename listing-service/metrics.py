@@ -0,0 +1,111 @@
+import bisect
+import inspect
+import re
+
+import tornado.web
+
+# Upper bounds, in seconds, of the request duration histogram, as in the Go services' httpmiddleware
+DURATION_BUCKETS = [0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]
+
+# Route of requests matching no route of the application
+OTHER_ROUTE = "other"
+
+# Groups of route patterns, replaced by the names of the handler's arguments in route templates
+_GROUP = re.compile(r"\([^)]*\)")
+
+def route_templates(handlers):
+    """Names the route of each handler class of an application by its path template, e.g.
+    /listings/{listing_id}/offers for r"/listings/([0-9]+)/offers", the names of the path
+    arguments being those of the handler's methods, like the path templates of the Go services'
+    routers."""
+    templates = {}
+    for pattern, handler_class, *_ in handlers:
+        names = []
+        for method in ("get", "post", "put", "patch", "delete"):
+            function = getattr(handler_class, method)
+            if function is not getattr(tornado.web.RequestHandler, method):
+                names = list(inspect.signature(function).parameters)[1:]
+                break
+        groups = iter(names)
+        template = _GROUP.sub(lambda match: "{" + next(groups, "param") + "}", pattern)
+        templates[handler_class] = template.replace("\\", "").rstrip("$")
+    return templates
+
+def _escape_label(value):
+    return value.replace("\\", "\\\\").replace("\"", "\\\"").replace("\n", "\\n")
+
+class Metrics:
+    """Counts the requests served by the service, by method, route and status, and measures their
+    duration, exposing them in the Prometheus text format under the names and labels of the Go
+    services' httpmiddleware, so that one dashboard covers every service."""
+
+    def __init__(self, service, templates):
+        self.service = service
+        self.templates = templates
+        self.in_flight = 0
+        self.requests = {}
+        self.durations = {}
+
+    def started(self):
+        """Records a request starting to be served. Call it from the application's find_handler."""
+        self.in_flight += 1
+
+    def observe(self, handler):
+        """Records a request served by handler. Call it from the application's log_request."""
+        self.in_flight = max(self.in_flight - 1, 0)
+        request = handler.request
+        route = self.templates.get(type(handler), OTHER_ROUTE)
+        key = (request.method, route, handler.get_status())
+        self.requests[key] = self.requests.get(key, 0) + 1
+
+        seconds = request.request_time()
+        histogram = self.durations.setdefault((request.method, route), dict(
+            counts=[0] * (len(DURATION_BUCKETS) + 1), sum=0.0, count=0
+        ))
+        histogram["counts"][bisect.bisect_left(DURATION_BUCKETS, seconds)] += 1
+        histogram["sum"] += seconds
+        histogram["count"] += 1
+
+    def render(self):
+        """Returns the metrics in the Prometheus text format:
+        - http_requests_total, a counter of requests by method, route and status
+        - http_request_duration_seconds, a histogram of request durations by method and route
+        - http_requests_in_flight, a gauge of the requests being served"""
+        service = "service=\"{}\"".format(_escape_label(self.service))
+        lines = [
+            "# HELP http_requests_total Requests served, by method, route and status.",
+            "# TYPE http_requests_total counter",
+        ]
+        for (method, route, status), count in sorted(self.requests.items(), key=lambda item: (item[0][1], item[0][0], item[0][2])):
+            lines.append("http_requests_total{{{},method=\"{}\",route=\"{}\",status=\"{}\"}} {}".format(
+                service, _escape_label(method), _escape_label(route), status, count))
+
+        lines += [
+            "# HELP http_request_duration_seconds Time taken to serve requests, by method and route.",
+            "# TYPE http_request_duration_seconds histogram",
+        ]
+        for (method, route), histogram in sorted(self.durations.items(), key=lambda item: (item[0][1], item[0][0])):
+            labels = "{},method=\"{}\",route=\"{}\"".format(service, _escape_label(method), _escape_label(route))
+            cumulative = 0
+            for bound, count in zip(DURATION_BUCKETS, histogram["counts"]):
+                cumulative += count
+                lines.append("http_request_duration_seconds_bucket{{{},le=\"{:g}\"}} {}".format(labels, bound, cumulative))
+            lines.append("http_request_duration_seconds_bucket{{{},le=\"+Inf\"}} {}".format(labels, histogram["count"]))
+            lines.append("http_request_duration_seconds_sum{{{}}} {!r}".format(labels, histogram["sum"]))
+            lines.append("http_request_duration_seconds_count{{{}}} {}".format(labels, histogram["count"]))
+
+        lines += [
+            "# HELP http_requests_in_flight Requests being served.",
+            "# TYPE http_requests_in_flight gauge",
+            "http_requests_in_flight{{{}}} {}".format(service, self.in_flight),
+        ]
+        return "\n".join(lines) + "\n"
+
+# /metrics, served on the metrics_port apart from the API so the metrics are not exposed along with it
+class MetricsHandler(tornado.web.RequestHandler):
+    def initialize(self, metrics):
+        self.metrics = metrics
+
+    def get(self):
+        self.set_header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        self.write(self.metrics.render())
@@ -0,0 +1,116 @@
+import decimal
+import re
+
+import currencies
+
+# Digits after the decimal point of each currency's minor unit, for the currencies whose minor
+# unit is not a hundredth (ISO 4217). Mirrors minorUnits of the shared Go module pkg/money,
+# whose tests check that the two tables agree
+MINOR_UNITS = dict(
+    {code: 0 for code in (
+        "BIF", "CLP", "DJF", "GNF", "ISK", "JPY", "KMF", "KRW", "PYG", "RWF", "UGX", "VND", "VUV", "XAF", "XOF", "XPF"
+    )},
+    **{code: 3 for code in ("BHD", "IQD", "JOD", "KWD", "LYD", "OMR", "TND")}
+)
+DEFAULT_MINOR_UNITS = 2
+
+# Plain decimal amounts accepted by Money.parse, e.g. "12.50"
+DECIMAL_PATTERN = re.compile(r"^-?[0-9]+(\.[0-9]+)?$")
+
+# Largest amount, in minor units, that JSON clients can represent exactly (2^53 - 1)
+MAX_AMOUNT = 2 ** 53 - 1
+
+class CurrencyMismatchError(ValueError):
+    """Raised when amounts in different currencies are combined."""
+
+def minor_units(currency):
+    # Returns the number of digits after the decimal point of currency's minor unit
+    return MINOR_UNITS.get(currency, DEFAULT_MINOR_UNITS)
+
+class Money:
+    """An amount of money, as an integer number of minor units (e.g. cents) of a currency.
+
+    Amounts are never floats: arithmetic stays in integers, and conversions and parsing go
+    through decimal with explicit rounding. Combining different currencies raises
+    CurrencyMismatchError.
+    """
+
+    __slots__ = ("amount", "currency")
+
+    def __init__(self, amount, currency):
+        if not isinstance(amount, int) or isinstance(amount, bool):
+            raise TypeError("amount must be an integer number of minor units")
+        if not currencies.is_valid_currency(currency):
+            raise ValueError("invalid currency {}".format(currency))
+        if abs(amount) > MAX_AMOUNT:
+            raise ValueError("amount must be at most {} minor units".format(MAX_AMOUNT))
+        self.amount = amount
+        self.currency = currency
+
+    @classmethod
+    def parse(cls, value, currency):
+        # Parses a decimal string in major units, e.g. "12.50" USD, into Money. Raises
+        # ValueError if it has more decimals than the currency's minor unit allows
+        value = value.strip()
+        if not DECIMAL_PATTERN.match(value):
+            raise ValueError("invalid amount {}".format(value))
+        minor = decimal.Decimal(value).scaleb(minor_units(currency))
+        if minor != minor.to_integral_value():
+            raise ValueError("{} has at most {} decimals".format(currency, minor_units(currency)))
+        return cls(int(minor), currency)
+
+    def format(self):
+        # Returns the amount in major units with the currency's decimals, e.g. "1234.50"
+        digits = minor_units(self.currency)
+        return "{:.{}f}".format(decimal.Decimal(self.amount).scaleb(-digits), digits)
+
+    def discounted(self, percent):
+        # Returns the amount after a discount of percent, rounded down to a whole minor unit,
+        # so a discount never charges more than advertised
+        return Money(self.amount * (100 - percent) // 100, self.currency)
+
+    def convert(self, rate, currency):
+        # Returns the amount in currency, given the rate of one major unit of this currency in
+        # major units of the other, e.g. "0.92" for USD to EUR. The rate should be a decimal
+        # string, as floats are inexact. The result is rounded half to even
+        minor = (
+            decimal.Decimal(self.amount) * decimal.Decimal(rate)
+        ).scaleb(minor_units(currency) - minor_units(self.currency))
+        return Money(int(minor.to_integral_value(rounding=decimal.ROUND_HALF_EVEN)), currency)
+
+    def _same_currency(self, other):
+        # Returns whether other is Money in this currency, False if it is not Money at all
+        if not isinstance(other, Money):
+            return False
+        if other.currency != self.currency:
+            raise CurrencyMismatchError("cannot combine {} and {}".format(self.currency, other.currency))
+        return True
+
+    def __add__(self, other):
+        if not self._same_currency(other):
+            return NotImplemented
+        return Money(self.amount + other.amount, self.currency)
+
+    def __sub__(self, other):
+        if not self._same_currency(other):
+            return NotImplemented
+        return Money(self.amount - other.amount, self.currency)
+
+    def __lt__(self, other):
+        if not self._same_currency(other):
+            return NotImplemented
+        return self.amount < other.amount
+
+    def __eq__(self, other):
+        if not isinstance(other, Money):
+            return NotImplemented
+        return self.amount == other.amount and self.currency == other.currency
+
+    def __hash__(self):
+        return hash((self.amount, self.currency))
+
+    def __str__(self):
+        return "{} {}".format(self.format(), self.currency)
+
+    def __repr__(self):
+        return "Money({!r}, {!r})".format(self.amount, self.currency)
@@ -0,0 +1,104 @@
+import hashlib
+import hmac
+import logging
+import time
+
+import tornado.gen
+import tornado.httpclient
+
+# Seconds to wait for the user service before counting the check as failed
+REQUEST_TIMEOUT = 2.0
+
+# Consecutive failures that open the circuit, and seconds it stays open before a trial request
+FAILURE_THRESHOLD = 5
+OPEN_SECONDS = 30.0
+
+# Maximum number of user ids whose existence is cached
+MAX_CACHED_OWNERS = 10000
+
+class OwnerCheckUnavailableError(Exception):
+    """Raised when the user service cannot tell whether a user exists."""
+
+class OwnerChecker:
+    """Checks that users exist with the user service's GET /users/{id}.
+
+    Answers are cached for cache_ttl seconds. After FAILURE_THRESHOLD consecutive failed
+    requests the circuit opens: checks fail right away for OPEN_SECONDS, then a single trial
+    request decides whether it closes again, while other checks keep failing. Requests are
+    made with tornado's AsyncHTTPClient so they never block the IOLoop, and are signed like
+    the gateway's when a "keyID:secret" key is given.
+    """
+
+    def __init__(self, user_service_url, cache_ttl, key=None):
+        self.user_service_url = user_service_url.rstrip("/")
+        self.cache_ttl = cache_ttl
+        self.key_id, self.secret = key.split(":", 1) if key else (None, None)
+        self.cache = {} # user id -> (exists, cached until)
+        self.failures = 0
+        self.opened_at = None # When the circuit opened, None while it is closed
+        self.trial_pending = False # Whether the trial request of the half-open circuit is in flight
+        self.http_client = tornado.httpclient.AsyncHTTPClient()
+
+    @tornado.gen.coroutine
+    def exists(self, user_id):
+        # Returns whether user_id is an existing (not deleted) user,
+        # raising OwnerCheckUnavailableError if the user service cannot tell
+        now = time.monotonic()
+        cached = self.cache.get(user_id)
+        if cached is not None and cached[1] > now:
+            return cached[0]
+
+        trial = False
+        if self.opened_at is not None:
+            if now - self.opened_at < OPEN_SECONDS or self.trial_pending:
+                raise OwnerCheckUnavailableError("user service circuit is open")
+            # Half-open: this request is the trial, and another failure reopens the circuit right away
+            self.failures = FAILURE_THRESHOLD - 1
+            self.trial_pending = trial = True
+
+        try:
+            exists = yield self._fetch(user_id)
+        except Exception as e:
+            now = time.monotonic()
+            self.failures += 1
+            if self.failures >= FAILURE_THRESHOLD:
+                if self.opened_at is None or now - self.opened_at >= OPEN_SECONDS:
+                    logging.warning("Opening the user service circuit after {} failures: {}".format(self.failures, e))
+                self.opened_at = now
+            raise OwnerCheckUnavailableError("user service is unavailable: {}".format(e))
+        finally:
+            if trial:
+                self.trial_pending = False
+
+        now = time.monotonic()
+        if self.opened_at is not None:
+            logging.info("Closing the user service circuit")
+        self.failures = 0
+        self.opened_at = None
+        if len(self.cache) >= MAX_CACHED_OWNERS:
+            self.cache.clear()
+        self.cache[user_id] = (exists, now + self.cache_ttl)
+        return exists
+
+    def forget(self, user_id):
+        # Drops the cached answer for user_id, e.g. once it is deleted
+        self.cache.pop(user_id, None)
+
+    @tornado.gen.coroutine
+    def _fetch(self, user_id):
+        path = "/users/{}?fields=id".format(user_id)
+        headers = {}
+        if self.key_id is not None:
+            timestamp = str(int(time.time()))
+            canonical = "\n".join(["GET", path, timestamp, hashlib.sha256(b"").hexdigest()])
+            headers["X-Signature-Key-Id"] = self.key_id
+            headers["X-Signature-Timestamp"] = timestamp
+            headers["X-Signature"] = hmac.new(self.secret.encode(), canonical.encode(), hashlib.sha256).hexdigest()
+        try:
+            yield self.http_client.fetch(self.user_service_url + path, headers=headers, request_timeout=REQUEST_TIMEOUT)
+        except tornado.httpclient.HTTPClientError as e:
+            # Timeouts are HTTPClientErrors too, with code 599
+            if e.code == 404:
+                return False
+            raise
+        return True
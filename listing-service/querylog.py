@@ -0,0 +1,58 @@
+import logging
+import sqlite3
+import time
+
+# Default duration, in milliseconds, above which a query is logged as slow
+DEFAULT_SLOW_QUERY_MS = 100
+
+# Statements whose query plan is logged along with them
+EXPLAINABLE = ("SELECT", "INSERT", "UPDATE", "DELETE", "WITH")
+
+class QueryLoggingConnection(sqlite3.Connection):
+    """An SQLite connection whose cursors log queries slower than slow_query_threshold.
+
+    Pass it as the factory of sqlite3.connect. Slow queries are logged with their EXPLAIN QUERY
+    PLAN, which shows e.g. full table scans where an index is missing. The time of a SELECT
+    covers running it up to its first row, where sorting and most filtering happen, but not
+    fetching the remaining rows. Parameters are left out of the log, as they may hold user data.
+    """
+
+    # Seconds a query may take before it is logged; None disables logging
+    slow_query_threshold = DEFAULT_SLOW_QUERY_MS / 1000
+
+    def cursor(self, factory=None):
+        return super().cursor(factory or QueryLoggingCursor)
+
+    def log_slow_query(self, sql, parameters, elapsed):
+        plan = ""
+        if sql.lstrip().upper().startswith(EXPLAINABLE) and parameters is not None:
+            try:
+                # A plain cursor, so explaining cannot itself be logged
+                rows = super().cursor().execute("EXPLAIN QUERY PLAN " + sql, parameters).fetchall()
+                plan = "".join("\n    " + row[3] for row in rows)
+            except sqlite3.Error as e:
+                plan = "\n    (no query plan: {})".format(e)
+        logging.warning("Slow query ({:.1f} ms): {}{}".format(elapsed * 1000, " ".join(sql.split()), plan))
+
+class QueryLoggingCursor(sqlite3.Cursor):
+    """Times the statements it executes, reporting slow ones to its QueryLoggingConnection."""
+
+    def execute(self, sql, parameters=()):
+        start = time.monotonic()
+        try:
+            return super().execute(sql, parameters)
+        finally:
+            self._check(sql, parameters, time.monotonic() - start)
+
+    def executemany(self, sql, seq_of_parameters):
+        # Timed as a whole; its query plan is not logged, as there is no single set of parameters
+        start = time.monotonic()
+        try:
+            return super().executemany(sql, seq_of_parameters)
+        finally:
+            self._check(sql, None, time.monotonic() - start)
+
+    def _check(self, sql, parameters, elapsed):
+        threshold = self.connection.slow_query_threshold
+        if threshold is not None and elapsed >= threshold:
+            self.connection.log_slow_query(sql, parameters, elapsed)
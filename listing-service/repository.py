@@ -0,0 +1,2032 @@
+import itertools
+import json
+import logging
+import random
+import sqlite3
+import time
+
+import filters as filter_expressions
+import geo
+import trending
+from money import Money, minor_units
+
+# Columns returned for a listing, in the order they appear in API responses
+LISTING_FIELDS = ["id", "user_id", "user_name", "listing_type", "price", "currency", "title", "description",
+                  "latitude", "longitude", "created_at", "updated_at", "expires_at", "expired_at",
+                  "available_from", "available_to", "rental_period", "deposit", "negotiable", "bumped_at",
+                  "sold_at", "orphaned_at", "favorites_count", "view_count", "moderation_status", "moderation_reason",
+                  "attributes"]
+
+# Columns listings can be sorted by, each covered by an index
+SORT_COLUMNS = {
+    "price": "listings.price",
+    "created_at": "listings.created_at",
+    "updated_at": "listings.updated_at",
+}
+
+# Order of listings unless sorted explicitly: newest first
+DEFAULT_SORT = ("created_at", "desc")
+
+# Selects listings along with the name of their category, if any, and how many users favorited them
+SELECT_LISTINGS = (
+    "SELECT listings.*, categories.name AS category_name, "
+    + "(SELECT COUNT(*) FROM listing_favorites WHERE listing_favorites.listing_id = listings.id) AS favorites_count "
+    + "FROM listings LEFT JOIN categories ON categories.id = listings.category_id"
+)
+
+PROMOTION_FIELDS = ["id", "listing_id", "discount_percent", "starts_at", "ends_at", "created_at", "ended_at"]
+
+def ensure_column(cursor, table, column, definition):
+    # Adds a column to tables created before it was introduced. Returns whether it was added
+    columns = [row["name"] for row in cursor.execute("PRAGMA table_info('{}')".format(table))]
+    if column in columns:
+        return False
+    cursor.execute("ALTER TABLE '{}' ADD COLUMN {} {}".format(table, column, definition))
+    logging.info("Added missing column {}.{}".format(table, column))
+    return True
+
+def percentile(values, fraction):
+    # The fraction (0 to 1) percentile of sorted values, interpolating between the closest ranks
+    position = (len(values) - 1) * fraction
+    lower = int(position)
+    if lower + 1 >= len(values):
+        return values[lower]
+    return values[lower] + (values[lower + 1] - values[lower]) * (position - lower)
+
+# Largest number of buckets in the price histogram of listing facets
+MAX_PRICE_BUCKETS = 10
+
+# Columns returned for a listing image, in display order
+IMAGE_FIELDS = ["id", "url", "thumbnail_url", "position", "width", "height"]
+
+# Columns returned for an abuse report
+REPORT_FIELDS = ["id", "listing_id", "user_id", "reason", "created_at"]
+
+# Columns returned for a category
+CATEGORY_FIELDS = ["id", "name", "attributes", "created_at", "updated_at"]
+
+def price_bucket_width(min_price, max_price):
+    # Returns the smallest round width (1, 2 or 5 times a power of ten) that splits
+    # [min_price, max_price] into at most MAX_PRICE_BUCKETS buckets aligned on multiples of it
+    width = 1
+    while True:
+        for step in (1, 2, 5):
+            if max_price // (width * step) - min_price // (width * step) < MAX_PRICE_BUCKETS:
+                return width * step
+        width *= 10
+
+class DuplicateError(Exception):
+    """Raised when a write would violate a uniqueness constraint."""
+
+class ListingRepository:
+    """Stores listings in SQLite. All SQL used by the service lives here."""
+
+    def __init__(self, db):
+        self.db = db
+        # Used by radius queries to filter and order listings by distance
+        self.db.create_function("haversine_km", 4, geo.haversine_km, deterministic=True)
+        # Used to compute trending scores, weighing recent views more
+        self.db.create_function("decay_weight", 2, trending.decay_weight, deterministic=True)
+
+    def init_schema(self, default_currency):
+        cursor = self.db.cursor()
+
+        # Create table
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listings' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "user_id INTEGER NOT NULL,"
+            + "listing_type TEXT NOT NULL,"
+            + "price INTEGER NOT NULL,"
+            + "currency TEXT NOT NULL,"
+            + "title TEXT NOT NULL DEFAULT '',"
+            + "description TEXT NOT NULL DEFAULT '',"
+            + "created_at INTEGER NOT NULL,"
+            + "updated_at INTEGER NOT NULL,"
+            + "hidden_at INTEGER,"
+            + "category_id INTEGER REFERENCES categories(id),"
+            + "latitude REAL,"
+            + "longitude REAL,"
+            + "expires_at INTEGER,"
+            + "expired_at INTEGER,"
+            + "view_count INTEGER NOT NULL DEFAULT 0,"
+            + "moderation_status TEXT NOT NULL DEFAULT 'approved',"
+            + "moderation_reason TEXT,"
+            + "moderated_at INTEGER,"
+            + "available_from INTEGER,"
+            + "available_to INTEGER,"
+            + "bumped_at INTEGER,"
+            + "rental_period TEXT,"
+            + "deposit INTEGER,"
+            + "negotiable INTEGER,"
+            + "sold_at INTEGER,"
+            + "user_name TEXT,"
+            + "orphaned_at INTEGER,"
+            + "orphaned_user_id INTEGER,"
+            + "matched_at INTEGER,"
+            + "attributes TEXT NOT NULL DEFAULT '{}'"
+            + ");"
+        )
+
+        # Listings of deleted users are hidden rather than removed
+        ensure_column(cursor, "listings", "hidden_at", "INTEGER")
+        ensure_column(cursor, "listings", "title", "TEXT NOT NULL DEFAULT ''")
+        ensure_column(cursor, "listings", "description", "TEXT NOT NULL DEFAULT ''")
+        # Listings created before currencies were introduced are priced in the default currency
+        ensure_column(cursor, "listings", "currency", "TEXT NOT NULL DEFAULT '{}'".format(default_currency))
+        ensure_column(cursor, "listings", "category_id", "INTEGER REFERENCES categories(id)")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_category_id ON listings(category_id)")
+        # Listings without a location have NULL coordinates
+        ensure_column(cursor, "listings", "latitude", "REAL")
+        ensure_column(cursor, "listings", "longitude", "REAL")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_location ON listings(latitude, longitude)")
+        # Listings expire at expires_at, if set; expired_at records when the expiry worker handled it
+        ensure_column(cursor, "listings", "expires_at", "INTEGER")
+        ensure_column(cursor, "listings", "expired_at", "INTEGER")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_expires_at ON listings(expires_at)")
+        ensure_column(cursor, "listings", "view_count", "INTEGER NOT NULL DEFAULT 0")
+        # Listings created before moderation was introduced count as approved
+        ensure_column(cursor, "listings", "moderation_status", "TEXT NOT NULL DEFAULT 'approved'")
+        ensure_column(cursor, "listings", "moderation_reason", "TEXT")
+        ensure_column(cursor, "listings", "moderated_at", "INTEGER")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_moderation_status ON listings(moderation_status, created_at)")
+        # Rentals may be limited to the window [available_from, available_to); NULL bounds are open
+        ensure_column(cursor, "listings", "available_from", "INTEGER")
+        ensure_column(cursor, "listings", "available_to", "INTEGER")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_availability ON listings(available_from, available_to)")
+        # When the owner last bumped the listing, which is allowed once a day
+        ensure_column(cursor, "listings", "bumped_at", "INTEGER")
+        # Type-specific fields: rental_period and deposit of rentals, negotiable of sales
+        ensure_column(cursor, "listings", "rental_period", "TEXT")
+        ensure_column(cursor, "listings", "deposit", "INTEGER")
+        ensure_column(cursor, "listings", "negotiable", "INTEGER")
+        # When the owner marked a sale as sold
+        ensure_column(cursor, "listings", "sold_at", "INTEGER")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_sold_at ON listings(sold_at) WHERE sold_at IS NOT NULL")
+        # Copy of the owner's name, kept in sync with user.created and user.updated events
+        ensure_column(cursor, "listings", "user_name", "TEXT")
+        # A user's listings are listed newest first, which this index serves without sorting; it
+        # supersedes the former index on user_id alone
+        cursor.execute("DROP INDEX IF EXISTS idx_listings_user_id")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_user_created ON listings(user_id, created_at, id)")
+        # When the listing's owner was deleted, and who that owner was, for admins to review
+        ensure_column(cursor, "listings", "orphaned_at", "INTEGER")
+        ensure_column(cursor, "listings", "orphaned_user_id", "INTEGER")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_orphaned_at ON listings(orphaned_at)")
+        # When the listing was matched against saved searches. Listings that existed before saved
+        # searches count as matched, so that they do not trigger notifications
+        if ensure_column(cursor, "listings", "matched_at", "INTEGER"):
+            cursor.execute("UPDATE listings SET matched_at=created_at")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_unmatched ON listings(id) WHERE matched_at IS NULL")
+        # Category-specific attributes, e.g. {"bedrooms": 2}, as a JSON object
+        ensure_column(cursor, "listings", "attributes", "TEXT NOT NULL DEFAULT '{}'")
+        for column in SORT_COLUMNS:
+            cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_{0} ON listings({0}, id)".format(column))
+        # Public listings of a type sorted by price, e.g. filter=type:rent&sort=price
+        cursor.execute(
+            "CREATE INDEX IF NOT EXISTS idx_listings_status_type_price ON listings(moderation_status, listing_type, price, id)"
+        )
+
+        self.init_search_index(cursor)
+
+        # Listings sold long ago, moved out of the listings table by the archive worker. data is
+        # the listing as it was read when archived; the other columns are those queries and the
+        # stats rollups need. Listing ids are never reused, so they stay unique across both tables
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listings_archive' ("
+            + "id INTEGER NOT NULL PRIMARY KEY,"
+            + "user_id INTEGER NOT NULL,"
+            + "listing_type TEXT NOT NULL,"
+            + "price INTEGER NOT NULL,"
+            + "currency TEXT NOT NULL,"
+            + "category_id INTEGER,"
+            + "created_at INTEGER NOT NULL,"
+            + "sold_at INTEGER NOT NULL,"
+            + "archived_at INTEGER NOT NULL,"
+            + "data TEXT NOT NULL"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_archive_sold_at ON listings_archive(sold_at, id)")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listings_archive_user_id ON listings_archive(user_id, sold_at, id)")
+
+        # Image metadata; the images themselves are hosted elsewhere, for example by the media
+        # service, and referenced by URL. position orders the images of a listing, starting at 0
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_images' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "url TEXT NOT NULL,"
+            + "thumbnail_url TEXT,"
+            + "position INTEGER NOT NULL,"
+            + "width INTEGER,"
+            + "height INTEGER,"
+            + "created_at INTEGER NOT NULL"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_images_listing_id ON listing_images(listing_id, position)")
+        ensure_column(cursor, "listing_images", "thumbnail_url", "TEXT")
+
+        # Names of users, as last seen in user.created and user.updated events
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'user_names' ("
+            + "user_id INTEGER NOT NULL PRIMARY KEY,"
+            + "name TEXT NOT NULL,"
+            + "updated_at INTEGER NOT NULL"
+            + ");"
+        )
+
+        # Users' favorite listings
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_favorites' ("
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "user_id INTEGER NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "PRIMARY KEY (listing_id, user_id)"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_favorites_user_id ON listing_favorites(user_id, created_at)")
+
+        # Listing templates saved by users, with their validated listing fields as JSON
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_templates' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "user_id INTEGER NOT NULL,"
+            + "name TEXT NOT NULL,"
+            + "fields TEXT NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "UNIQUE (user_id, name)"
+            + ");"
+        )
+
+        # In-progress listing drafts autosaved by users, keyed by an id chosen by the client. The
+        # data is whatever JSON object the client sent, only validated when a listing is created
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_drafts' ("
+            + "user_id INTEGER NOT NULL,"
+            + "draft_id TEXT NOT NULL,"
+            + "data TEXT NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "updated_at INTEGER NOT NULL,"
+            + "PRIMARY KEY (user_id, draft_id)"
+            + ");"
+        )
+
+        # Search criteria saved by users, who are notified of new listings matching them.
+        # NULL criteria match any listing
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'saved_searches' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "user_id INTEGER NOT NULL,"
+            + "listing_type TEXT,"
+            + "currency TEXT,"
+            + "min_price INTEGER,"
+            + "max_price INTEGER,"
+            + "category_id INTEGER REFERENCES categories(id),"
+            + "created_at INTEGER NOT NULL"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_saved_searches_user_id ON saved_searches(user_id)")
+
+        # Abuse reports, at most one per user and listing
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_reports' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "user_id INTEGER NOT NULL,"
+            + "reason TEXT NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "UNIQUE (listing_id, user_id)"
+            + ");"
+        )
+
+        # Domain events are written to an outbox in the transaction of the change they describe
+        # and delivered to subscribers by the EventRelay
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'outbox_events' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "event_type TEXT NOT NULL,"
+            + "payload TEXT NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "published_at INTEGER,"
+            + "attempts INTEGER NOT NULL DEFAULT 0,"
+            + "last_error TEXT"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(published_at, id)")
+
+        # Webhook deliveries, one per outbox event and webhook URL, which also serve as the delivery
+        # log. next_attempt_at is NULL once a delivery is delivered or failed
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'webhook_deliveries' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "event_id INTEGER NOT NULL REFERENCES outbox_events(id),"
+            + "url TEXT NOT NULL,"
+            + "status TEXT NOT NULL,"
+            + "attempts INTEGER NOT NULL DEFAULT 0,"
+            + "next_attempt_at INTEGER,"
+            + "last_attempt_at INTEGER,"
+            + "last_status_code INTEGER,"
+            + "last_error TEXT,"
+            + "created_at INTEGER NOT NULL,"
+            + "delivered_at INTEGER"
+            + ");"
+        )
+        cursor.execute(
+            "CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) "
+            + "WHERE next_attempt_at IS NOT NULL"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries(status, id)")
+        # Positions of workers reading the outbox, e.g. the id of the last event turned into webhook deliveries
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'worker_checkpoints' ("
+            + "name TEXT NOT NULL PRIMARY KEY,"
+            + "position INTEGER NOT NULL"
+            + ");"
+        )
+
+        # Views per listing and hour, kept for the largest trending window
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_view_buckets' ("
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "bucket_start INTEGER NOT NULL,"
+            + "views INTEGER NOT NULL,"
+            + "PRIMARY KEY (bucket_start, listing_id)"
+            + ");"
+        )
+        # Trending scores per window, recomputed from the buckets by the TrendingWorker
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'trending_scores' ("
+            + "window_hours INTEGER NOT NULL,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "score REAL NOT NULL,"
+            + "PRIMARY KEY (window_hours, listing_id)"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_trending_scores_rank ON trending_scores(window_hours, score)")
+
+        # Number and total price of the visible listings created each UTC day, per listing_type and
+        # currency, refreshed by the StatsWorker. day is the start of the day, in microseconds
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_daily_stats' ("
+            + "day INTEGER NOT NULL,"
+            + "listing_type TEXT NOT NULL,"
+            + "currency TEXT NOT NULL,"
+            + "listing_count INTEGER NOT NULL,"
+            + "price_sum INTEGER NOT NULL,"
+            + "PRIMARY KEY (day, listing_type, currency)"
+            + ");"
+        )
+
+        # Number, total and median price of the visible listings per listing_type, status and
+        # currency as of refreshed_at, refreshed by the StatsWorker. status is one of pending,
+        # rejected, sold, expired and active
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_status_stats' ("
+            + "listing_type TEXT NOT NULL,"
+            + "status TEXT NOT NULL,"
+            + "currency TEXT NOT NULL,"
+            + "listing_count INTEGER NOT NULL,"
+            + "price_sum INTEGER NOT NULL,"
+            + "median_price REAL NOT NULL,"
+            + "refreshed_at INTEGER NOT NULL,"
+            + "PRIMARY KEY (listing_type, status, currency)"
+            + ");"
+        )
+
+        # Discounts of a listing's price during a time window. ended_at is set once a promotion is
+        # over, either because its window passed or because the owner cancelled it
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_promotions' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "discount_percent INTEGER NOT NULL,"
+            + "starts_at INTEGER NOT NULL,"
+            + "ends_at INTEGER NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "ended_at INTEGER"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_promotions_listing_id ON listing_promotions(listing_id, starts_at)")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_promotions_due ON listing_promotions(ends_at) WHERE ended_at IS NULL")
+
+        # Offers of buyers on listings, negotiated with the listing's owner. amount is the latest
+        # proposal: the buyer's while the offer is pending, the owner's once countered. A buyer has at most one open offer per listing,
+        # and a listing at most one accepted offer
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_offers' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "buyer_id INTEGER NOT NULL,"
+            + "amount INTEGER NOT NULL,"
+            + "currency TEXT NOT NULL,"
+            + "status TEXT NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "updated_at INTEGER NOT NULL,"
+            + "decided_at INTEGER"
+            + ");"
+        )
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_offers_listing_id ON listing_offers(listing_id, buyer_id)")
+        cursor.execute(
+            "CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_offers_open ON listing_offers(listing_id, buyer_id) "
+            + "WHERE status IN ('pending', 'countered')"
+        )
+        cursor.execute(
+            "CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_offers_accepted ON listing_offers(listing_id) "
+            + "WHERE status = 'accepted'"
+        )
+
+        # Reservations of listings for a buyer until expires_at. released_at is set once a hold is
+        # over, because it lapsed, was released early or the listing sold, and release_reason says
+        # which. A listing has at most one unreleased hold
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'listing_holds' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "listing_id INTEGER NOT NULL REFERENCES listings(id),"
+            + "buyer_id INTEGER NOT NULL,"
+            + "created_at INTEGER NOT NULL,"
+            + "expires_at INTEGER NOT NULL,"
+            + "released_at INTEGER,"
+            + "release_reason TEXT"
+            + ");"
+        )
+        cursor.execute("CREATE UNIQUE INDEX IF NOT EXISTS idx_listing_holds_active ON listing_holds(listing_id) WHERE released_at IS NULL")
+        cursor.execute("CREATE INDEX IF NOT EXISTS idx_listing_holds_due ON listing_holds(expires_at) WHERE released_at IS NULL")
+
+        # Suggested price ranges per listing_type, category, currency and rental_period, from the
+        # percentiles of recent listings, refreshed by the StatsWorker. A NULL category_id covers
+        # every category. Only combinations with enough listings have a row
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'price_suggestions' ("
+            + "listing_type TEXT NOT NULL,"
+            + "category_id INTEGER,"
+            + "currency TEXT NOT NULL,"
+            + "rental_period TEXT,"
+            + "sample_size INTEGER NOT NULL,"
+            + "low REAL NOT NULL,"
+            + "median REAL NOT NULL,"
+            + "high REAL NOT NULL,"
+            + "refreshed_at INTEGER NOT NULL"
+            + ");"
+        )
+        cursor.execute(
+            "CREATE INDEX IF NOT EXISTS idx_price_suggestions ON price_suggestions(listing_type, category_id, currency, rental_period)"
+        )
+
+        # Leases elect a single instance to run a background worker when several share the db
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'worker_leases' ("
+            + "name TEXT NOT NULL PRIMARY KEY,"
+            + "holder TEXT NOT NULL,"
+            + "expires_at INTEGER NOT NULL"
+            + ");"
+        )
+
+        # One-time data migrations already applied to this database, by name
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'schema_migrations' ("
+            + "name TEXT NOT NULL PRIMARY KEY,"
+            + "applied_at INTEGER NOT NULL"
+            + ");"
+        )
+        self.migrate_to_minor_units(cursor, default_currency)
+        self.db.commit()
+
+    def migrate_to_minor_units(self, cursor, default_currency):
+        # Amounts used to be whole units of their currency and are now minor units, so amounts
+        # stored before are multiplied by 10^minor_units(currency), once. Saved searches without
+        # a currency are taken to be in the default currency. The stats rollups and price
+        # suggestions are not migrated, as the StatsWorker rebuilds them all on its first round
+        if cursor.execute("SELECT 1 FROM schema_migrations WHERE name='minor_units'").fetchone() is not None:
+            return
+        currencies = {row[0] for row in cursor.execute(
+            "SELECT currency FROM listings UNION SELECT currency FROM listings_archive "
+            + "UNION SELECT currency FROM listing_offers UNION SELECT currency FROM saved_searches WHERE currency IS NOT NULL"
+        )} | {default_currency}
+        for currency in currencies:
+            factor = 10 ** minor_units(currency)
+            if factor == 1:
+                continue
+            cursor.execute("UPDATE listings SET price=price*?, deposit=deposit*? WHERE currency=?", (factor, factor, currency))
+            cursor.execute("UPDATE listings_archive SET price=price*? WHERE currency=?", (factor, currency))
+            cursor.execute("UPDATE listing_offers SET amount=amount*? WHERE currency=?", (factor, currency))
+            cursor.execute(
+                "UPDATE saved_searches SET min_price=min_price*?, max_price=max_price*? "
+                + "WHERE coalesce(currency, ?)=?",
+                (factor, factor, default_currency, currency)
+            )
+        # Archived listings and templates keep amounts inside their JSON too
+        for table, key, column in (("listings_archive", "id", "data"), ("listing_templates", "id", "fields")):
+            for row in cursor.execute("SELECT {}, {} FROM {}".format(key, column, table)).fetchall():
+                values = json.loads(row[1])
+                factor = 10 ** minor_units(values.get("currency") or default_currency)
+                for field in ("price", "deposit", "effective_price"):
+                    if values.get(field) is not None:
+                        values[field] *= factor
+                cursor.execute("UPDATE {} SET {}=? WHERE {}=?".format(table, column, key), (json.dumps(values), row[0]))
+        cursor.execute(
+            "INSERT INTO schema_migrations (name, applied_at) VALUES ('minor_units', ?)", (int(time.time() * 1e6),)
+        )
+
+    def init_search_index(self, cursor):
+        # Full-text index over titles and descriptions, kept in sync with listings by triggers
+        exists = cursor.execute("SELECT 1 FROM sqlite_master WHERE type='table' AND name='listings_fts'").fetchone()
+        cursor.execute(
+            "CREATE VIRTUAL TABLE IF NOT EXISTS listings_fts "
+            + "USING fts5(title, description, content='listings', content_rowid='id')"
+        )
+        cursor.execute(
+            "CREATE TRIGGER IF NOT EXISTS listings_fts_insert AFTER INSERT ON listings BEGIN "
+            + "INSERT INTO listings_fts(rowid, title, description) VALUES (new.id, new.title, new.description); "
+            + "END"
+        )
+        cursor.execute(
+            "CREATE TRIGGER IF NOT EXISTS listings_fts_delete AFTER DELETE ON listings BEGIN "
+            + "INSERT INTO listings_fts(listings_fts, rowid, title, description) "
+            + "VALUES ('delete', old.id, old.title, old.description); "
+            + "END"
+        )
+        cursor.execute(
+            "CREATE TRIGGER IF NOT EXISTS listings_fts_update AFTER UPDATE OF title, description ON listings BEGIN "
+            + "INSERT INTO listings_fts(listings_fts, rowid, title, description) "
+            + "VALUES ('delete', old.id, old.title, old.description); "
+            + "INSERT INTO listings_fts(rowid, title, description) VALUES (new.id, new.title, new.description); "
+            + "END"
+        )
+        # Index the listings created before the index existed
+        if not exists:
+            cursor.execute("INSERT INTO listings_fts(listings_fts) VALUES ('rebuild')")
+            logging.info("Built the listings full-text search index")
+
+    def list(self, page_num, page_size, filters, sort=None, after=None):
+        # Building select statement, leaving out listings hidden because their owner was deleted
+        select_stmt = SELECT_LISTINGS + " WHERE listings.hidden_at IS NULL"
+        filter_clauses, args = self._filter_clauses(filters)
+        select_stmt += filter_clauses
+        # Keyset pagination: after is the (sort value, id) of the last listing of the previous
+        # page, and the page starts right after it instead of skipping page_num - 1 pages. The
+        # sort index then seeks to the page directly, however deep it is. Not for radius
+        # queries ordered by distance
+        if after is not None:
+            field, direction = sort or DEFAULT_SORT
+            select_stmt += " AND ({}, listings.id) {} (?, ?)".format(SORT_COLUMNS[field], "<" if direction == "desc" else ">")
+            args += list(after)
+            page_num = 1
+        # Order by and pagination. Unless sorted explicitly, radius queries list the nearest
+        # listings first and other queries the newest
+        near = filters.get("near")
+        if sort is not None:
+            select_stmt += self._order_by(sort)
+        elif near is not None:
+            select_stmt += " ORDER BY haversine_km(?, ?, listings.latitude, listings.longitude), listings.created_at DESC"
+            args += [near[0], near[1]]
+        else:
+            select_stmt += self._order_by(DEFAULT_SORT)
+        select_stmt += " LIMIT ? OFFSET ?"
+        args += [page_size, (page_num - 1) * page_size]
+
+        cursor = self.db.cursor()
+        return self._with_details([self._to_listing(row, near) for row in cursor.execute(select_stmt, args)])
+
+    def facets(self, filters):
+        # Returns the number of visible listings matching the filters per listing_type, and a
+        # histogram of their prices in buckets of equal width, listing only non-empty buckets
+        where = " WHERE listings.hidden_at IS NULL"
+        filter_clauses, args = self._filter_clauses(filters)
+        where += filter_clauses
+
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT listing_type, COUNT(*) AS count, MIN(price) AS min_price, MAX(price) AS max_price "
+            + "FROM listings" + where + " GROUP BY listing_type",
+            args
+        ).fetchall()
+        facets = dict(
+            total=sum(row["count"] for row in rows),
+            listing_type={listing_type: 0 for listing_type in ("rent", "sale")},
+            price=[],
+        )
+        if not rows:
+            return facets
+        for row in rows:
+            facets["listing_type"][row["listing_type"]] = row["count"]
+
+        width = price_bucket_width(min(row["min_price"] for row in rows), max(row["max_price"] for row in rows))
+        buckets = cursor.execute(
+            "SELECT price / ? AS bucket, COUNT(*) AS count FROM listings" + where + " GROUP BY bucket ORDER BY bucket",
+            [width] + args
+        )
+        facets["price"] = [
+            dict(min=row["bucket"] * width, max=(row["bucket"] + 1) * width, count=row["count"]) for row in buckets
+        ]
+        return facets
+
+    def random_ids(self, count, filters):
+        # Returns up to count distinct ids of visible listings matching the filters, picked at random.
+        # Each pick jumps to the first match at or after a random id, using the primary key index
+        # instead of shuffling the whole table, so listings after gaps in the ids are a little likelier
+        cursor = self.db.cursor()
+        min_id, max_id = cursor.execute("SELECT MIN(id), MAX(id) FROM listings").fetchone()
+        if min_id is None:
+            return []
+
+        filter_clauses, filter_args = self._filter_clauses(filters)
+        select_stmt = (
+            "SELECT listings.id FROM listings WHERE listings.id >= ? AND listings.hidden_at IS NULL"
+            + filter_clauses + " ORDER BY listings.id LIMIT 1"
+        )
+        ids = set()
+        # Picks land on listings already picked more and more often as matches run out, so give up after a while
+        for _ in range(count * 4):
+            row = cursor.execute(select_stmt, [random.randint(min_id, max_id)] + filter_args).fetchone()
+            if row is None:
+                # Past the last match; wrap around to the first one
+                row = cursor.execute(select_stmt, [min_id] + filter_args).fetchone()
+                if row is None:
+                    break # Nothing matches
+            ids.add(row["id"])
+            if len(ids) == count:
+                break
+        return list(ids)
+
+    def search(self, match, page_num, page_size, filters, sort=None):
+        # Returns visible listings matching the FTS5 match expression. Unless sorted explicitly,
+        # they are ranked by BM25 with title matches weighing more than description matches
+        select_stmt = (
+            SELECT_LISTINGS + " JOIN listings_fts ON listings_fts.rowid = listings.id "
+            + "WHERE listings_fts MATCH ? AND listings.hidden_at IS NULL"
+        )
+        filter_clauses, args = self._filter_clauses(filters)
+        select_stmt += filter_clauses
+        args = [match] + args
+        if sort is not None:
+            select_stmt += self._order_by(sort)
+        else:
+            select_stmt += " ORDER BY bm25(listings_fts, 10.0, 1.0), listings.created_at DESC"
+        select_stmt += " LIMIT ? OFFSET ?"
+        args += [page_size, (page_num - 1) * page_size]
+
+        cursor = self.db.cursor()
+        return self._with_details([self._to_listing(row, filters.get("near")) for row in cursor.execute(select_stmt, args)])
+
+    def _order_by(self, sort):
+        # Builds the ORDER BY clause for sort, a (field, direction) tuple with a field of SORT_COLUMNS
+        # and a direction of "asc" or "desc". Ties are broken by id so pages don't overlap
+        field, direction = sort
+        direction = {"asc": "ASC", "desc": "DESC"}[direction]
+        return " ORDER BY {0} {1}, listings.id {1}".format(SORT_COLUMNS[field], direction)
+
+    def _filter_clauses(self, filters):
+        # Returns the WHERE clauses, each starting with AND, and their args for the filters
+        # specified: ids, user_id, category_id, near, a (latitude, longitude, radius_km) tuple,
+        # conditions, parsed from a filter expression, active_at, leaving out listings
+        # expired by that time, available_on, only rentals available at that time,
+        # moderation_status, located, only listings with coordinates, and unsold
+        clauses = ""
+        args = []
+        if filters.get("located"):
+            clauses += " AND listings.latitude IS NOT NULL AND listings.longitude IS NOT NULL"
+        if filters.get("unsold"):
+            clauses += " AND listings.sold_at IS NULL"
+        if filters.get("moderation_status") is not None:
+            clauses += " AND listings.moderation_status=?"
+            args.append(filters["moderation_status"])
+        if filters.get("active_at") is not None:
+            clauses += " AND (listings.expires_at IS NULL OR listings.expires_at > ?)"
+            args.append(filters["active_at"])
+        if filters.get("available_on") is not None:
+            clauses += (
+                " AND listings.listing_type='rent'"
+                + " AND (listings.available_from IS NULL OR listings.available_from <= ?)"
+                + " AND (listings.available_to IS NULL OR listings.available_to > ?)"
+            )
+            args += [filters["available_on"], filters["available_on"]]
+        if filters.get("ids") is not None:
+            clauses += " AND listings.id IN ({})".format(", ".join("?" * len(filters["ids"])))
+            args += filters["ids"]
+        for column in ("user_id", "category_id"):
+            if filters.get(column) is not None:
+                clauses += " AND listings.{}=?".format(column)
+                args.append(filters[column])
+
+        near = filters.get("near")
+        if near is not None:
+            latitude, longitude, radius_km = near
+            # The bounding box lets the location index narrow down candidates before
+            # computing exact distances
+            min_lat, max_lat, min_lng, max_lng = geo.bounding_box(latitude, longitude, radius_km)
+            clauses += " AND listings.latitude BETWEEN ? AND ?"
+            args += [min_lat, max_lat]
+            if min_lng is not None and min_lng <= max_lng:
+                clauses += " AND listings.longitude BETWEEN ? AND ?"
+                args += [min_lng, max_lng]
+            elif min_lng is not None:
+                # The box crosses the antimeridian
+                clauses += " AND (listings.longitude >= ? OR listings.longitude <= ?)"
+                args += [min_lng, max_lng]
+            clauses += " AND haversine_km(?, ?, listings.latitude, listings.longitude) <= ?"
+            args += [latitude, longitude, radius_km]
+
+        if filters.get("conditions"):
+            condition_clauses, condition_args = filter_expressions.to_sql(filters["conditions"])
+            clauses += condition_clauses
+            args += condition_args
+        return clauses, args
+
+    def list_favorites(self, user_id, page_num, page_size, filters, sort=None):
+        # Returns the visible listings favorited by user_id, most recently favorited first unless sorted explicitly
+        select_stmt = (
+            SELECT_LISTINGS + " JOIN listing_favorites ON listing_favorites.listing_id = listings.id "
+            + "WHERE listing_favorites.user_id=? AND listings.hidden_at IS NULL"
+        )
+        filter_clauses, args = self._filter_clauses(filters)
+        select_stmt += filter_clauses
+        args = [user_id] + args
+        if sort is not None:
+            select_stmt += self._order_by(sort)
+        else:
+            select_stmt += " ORDER BY listing_favorites.created_at DESC, listings.id DESC"
+        select_stmt += " LIMIT ? OFFSET ?"
+        args += [page_size, (page_num - 1) * page_size]
+
+        cursor = self.db.cursor()
+        return self._with_details([self._to_listing(row, filters.get("near")) for row in cursor.execute(select_stmt, args)])
+
+    def get(self, listing_id):
+        # Returns the listing, or None if it does not exist or is hidden
+        cursor = self.db.cursor()
+        row = cursor.execute(
+            SELECT_LISTINGS + " WHERE listings.id=? AND listings.hidden_at IS NULL", (listing_id,)
+        ).fetchone()
+        if row is None:
+            return None
+        return self._with_details([self._to_listing(row)])[0]
+
+    def create(self, values, time_now, created_event, images=()):
+        # Inserts a listing with the given column values and its images (dicts with url, width
+        # and height, in display order), and records the event built by created_event(listing id)
+        # in the outbox, atomically. Returns the id of the new listing
+        with self.db:
+            cursor = self.db.cursor()
+            listing_id = self._insert_listing(cursor, values, time_now)
+            for position, image in enumerate(images):
+                cursor.execute(
+                    "INSERT INTO listing_images (listing_id, url, thumbnail_url, position, width, height, created_at) "
+                    + "VALUES (?, ?, ?, ?, ?, ?, ?)",
+                    (listing_id, image["url"], image["thumbnail_url"], position, image["width"], image["height"],
+                     time_now)
+                )
+            self._insert_event(cursor, created_event(listing_id))
+        return listing_id
+
+    def create_many(self, rows, time_now, created_event):
+        # Inserts listings with the given column values in a single transaction, recording
+        # the event built by created_event(listing id, values) for each
+        with self.db:
+            cursor = self.db.cursor()
+            for values in rows:
+                listing_id = self._insert_listing(cursor, values, time_now)
+                self._insert_event(cursor, created_event(listing_id, values))
+
+    def _insert_listing(self, cursor, values, time_now):
+        columns = list(values) + ["created_at", "updated_at"]
+        cursor.execute(
+            "INSERT INTO 'listings' ({}) VALUES ({})".format(
+                ", ".join(columns), ", ".join("?" * len(columns))
+            ),
+            self._column_args(values) + [time_now, time_now]
+        )
+        return cursor.lastrowid
+
+    def _column_args(self, values):
+        # Returns the SQL args of listing column values, storing attributes as JSON
+        return [json.dumps(value) if column == "attributes" else value for column, value in values.items()]
+
+    def update(self, listing_id, values, time_now, events):
+        # Sets the given column values of a visible listing and records events in the outbox,
+        # atomically. Returns whether a visible listing was updated
+        assignments = "".join("{}=?, ".format(column) for column in values)
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listings SET {}updated_at=? WHERE id=? AND hidden_at IS NULL".format(assignments),
+                self._column_args(values) + [time_now, listing_id]
+            )
+            if cursor.rowcount == 0:
+                return False
+            for event in events:
+                self._insert_event(cursor, event)
+        return True
+
+    def mark_sold(self, listing_id, time_now, event):
+        # Marks a visible listing that is not sold yet as sold, releasing its hold, and records
+        # event in the outbox, atomically. Returns whether the listing was marked
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listings SET sold_at=?, updated_at=? WHERE id=? AND hidden_at IS NULL AND sold_at IS NULL",
+                (time_now, time_now, listing_id)
+            )
+            if cursor.rowcount == 0:
+                return False
+            cursor.execute(
+                "UPDATE listing_holds SET released_at=?, release_reason='sold' "
+                + "WHERE listing_id=? AND released_at IS NULL AND expires_at > ?",
+                (time_now, listing_id, time_now)
+            )
+            self._insert_event(cursor, event)
+        return True
+
+    def bump(self, listing_id, values, time_now, last_bumped_before):
+        # Sets the given column values of a visible listing along with its updated_at and bumped_at,
+        # unless it was bumped after last_bumped_before. Returns whether the listing was bumped
+        assignments = "".join("{}=?, ".format(column) for column in values)
+        cursor = self.db.cursor()
+        cursor.execute(
+            "UPDATE listings SET {}updated_at=?, bumped_at=? ".format(assignments)
+            + "WHERE id=? AND hidden_at IS NULL AND (bumped_at IS NULL OR bumped_at <= ?)",
+            list(values.values()) + [time_now, time_now, listing_id, last_bumped_before]
+        )
+        self.db.commit()
+        return cursor.rowcount > 0
+
+    def get_bumped_at(self, listing_id):
+        # Returns when a visible listing was last bumped, 0 if never, or None if there is no such listing
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT bumped_at FROM listings WHERE id=? AND hidden_at IS NULL", (listing_id,)).fetchone()
+        if row is None:
+            return None
+        return row["bumped_at"] or 0
+
+    def delete(self, listing_id, event):
+        # Deletes a visible listing and records event in the outbox, atomically.
+        # Returns whether the listing was deleted
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute("DELETE FROM listings WHERE id=? AND hidden_at IS NULL", (listing_id,))
+            if cursor.rowcount == 0:
+                return False
+            self._delete_listing_rows(cursor, listing_id)
+            self._insert_event(cursor, event)
+        return True
+
+    def _delete_listing_rows(self, cursor, listing_id):
+        # Deletes the rows of other tables that belong to a deleted listing
+        cursor.execute("DELETE FROM listing_images WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_favorites WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_reports WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_view_buckets WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM trending_scores WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_promotions WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_offers WHERE listing_id=?", (listing_id,))
+        cursor.execute("DELETE FROM listing_holds WHERE listing_id=?", (listing_id,))
+
+    def count_images(self, listing_id):
+        cursor = self.db.cursor()
+        return cursor.execute("SELECT COUNT(*) FROM listing_images WHERE listing_id=?", (listing_id,)).fetchone()[0]
+
+    def add_image(self, listing_id, values, time_now):
+        # Appends an image with the given column values (url, width, height) to a listing,
+        # bumping the listing's updated_at. Returns the id of the new image
+        with self.db:
+            cursor = self.db.cursor()
+            position = cursor.execute(
+                "SELECT COALESCE(MAX(position) + 1, 0) FROM listing_images WHERE listing_id=?", (listing_id,)
+            ).fetchone()[0]
+            cursor.execute(
+                "INSERT INTO listing_images (listing_id, url, thumbnail_url, position, width, height, created_at) "
+                + "VALUES (?, ?, ?, ?, ?, ?, ?)",
+                (listing_id, values["url"], values["thumbnail_url"], position, values["width"], values["height"],
+                 time_now)
+            )
+            cursor.execute("UPDATE listings SET updated_at=? WHERE id=?", (time_now, listing_id))
+        return cursor.lastrowid
+
+    def image_ids(self, listing_id):
+        # Returns the ids of the images of a listing, in display order
+        cursor = self.db.cursor()
+        return [row["id"] for row in cursor.execute(
+            "SELECT id FROM listing_images WHERE listing_id=? ORDER BY position", (listing_id,)
+        )]
+
+    def reorder_images(self, listing_id, image_ids, time_now):
+        # Positions the images of a listing in the order of image_ids, which must hold all of them
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.executemany(
+                "UPDATE listing_images SET position=? WHERE id=? AND listing_id=?",
+                [(position, image_id, listing_id) for position, image_id in enumerate(image_ids)]
+            )
+            cursor.execute("UPDATE listings SET updated_at=? WHERE id=?", (time_now, listing_id))
+
+    def delete_image(self, listing_id, image_id, time_now):
+        # Removes an image of a listing, closing the gap it leaves in positions.
+        # Returns whether the image existed
+        with self.db:
+            cursor = self.db.cursor()
+            row = cursor.execute(
+                "SELECT position FROM listing_images WHERE id=? AND listing_id=?", (image_id, listing_id)
+            ).fetchone()
+            if row is None:
+                return False
+            cursor.execute("DELETE FROM listing_images WHERE id=?", (image_id,))
+            cursor.execute(
+                "UPDATE listing_images SET position=position - 1 WHERE listing_id=? AND position > ?",
+                (listing_id, row["position"])
+            )
+            cursor.execute("UPDATE listings SET updated_at=? WHERE id=?", (time_now, listing_id))
+        return True
+
+    def fetch_due_expirations(self, time_now, limit):
+        # Returns visible listings that expired by time_now and were not handled yet, oldest first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT id, user_id, expires_at FROM listings "
+            + "WHERE expires_at <= ? AND expired_at IS NULL AND hidden_at IS NULL ORDER BY expires_at LIMIT ?",
+            (time_now, limit)
+        )
+        return [dict(id=row["id"], user_id=row["user_id"], expires_at=row["expires_at"]) for row in rows]
+
+    def mark_expired(self, listing_id, expired_at, event):
+        # Marks a due listing as expired and records event in the outbox, atomically.
+        # Returns whether the listing was still due, as it may have been extended meanwhile
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listings SET expired_at=? WHERE id=? AND expires_at <= ? AND expired_at IS NULL",
+                (expired_at, listing_id, expired_at)
+            )
+            if cursor.rowcount == 0:
+                return False
+            self._insert_event(cursor, event)
+        return True
+
+    def fetch_due_archivals(self, sold_before, limit):
+        # Returns visible listings sold before the given time, longest sold first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT id, user_id, sold_at FROM listings WHERE sold_at < ? AND hidden_at IS NULL ORDER BY sold_at LIMIT ?",
+            (sold_before, limit)
+        )
+        return [dict(id=row["id"], user_id=row["user_id"], sold_at=row["sold_at"]) for row in rows]
+
+    def archive(self, listing_id, archived_at, event):
+        # Moves a visible, sold listing to listings_archive, deleting its images, favorites,
+        # offers and other rows, and records event in the outbox, atomically. Returns whether
+        # the listing was archived
+        with self.db:
+            listing = self.get(listing_id)
+            if listing is None or listing["sold_at"] is None:
+                return False
+            cursor = self.db.cursor()
+            cursor.execute("DELETE FROM listings WHERE id=?", (listing_id,))
+            self._delete_listing_rows(cursor, listing_id)
+            cursor.execute(
+                "INSERT INTO listings_archive "
+                + "(id, user_id, listing_type, price, currency, category_id, created_at, sold_at, archived_at, data) "
+                + "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+                (listing_id, listing["user_id"], listing["listing_type"], listing["price"], listing["currency"],
+                 listing["category"]["id"] if listing["category"] is not None else None, listing["created_at"],
+                 listing["sold_at"], archived_at, json.dumps(listing))
+            )
+            self._insert_event(cursor, event)
+        return True
+
+    def get_archived(self, listing_id):
+        # Returns the archived listing, or None if there is no such archived listing
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT data, archived_at FROM listings_archive WHERE id=?", (listing_id,)).fetchone()
+        return self._to_archived_listing(row) if row is not None else None
+
+    def list_archived(self, page_num, page_size, filters):
+        # Returns a page of archived listings, most recently sold first. filters may hold
+        # user_id and category_id
+        clauses = []
+        args = []
+        for column in ("user_id", "category_id"):
+            if filters.get(column) is not None:
+                clauses.append("{}=?".format(column))
+                args.append(filters[column])
+        where = " WHERE " + " AND ".join(clauses) if clauses else ""
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT data, archived_at FROM listings_archive" + where + " ORDER BY sold_at DESC, id DESC LIMIT ? OFFSET ?",
+            args + [page_size, (page_num - 1) * page_size]
+        )
+        return [self._to_archived_listing(row) for row in rows]
+
+    def delete_user_archived_listings(self, user_id):
+        # Deletes the archived listings of user_id, returning how many were deleted
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listings_archive WHERE user_id=?", (user_id,))
+        self.db.commit()
+        return cursor.rowcount
+
+    def create_promotion(self, listing_id, discount_percent, starts_at, ends_at, time_now):
+        # Adds a promotion to a listing, returning its id, or None if it overlaps another
+        # promotion of the listing that has not ended
+        with self.db:
+            cursor = self.db.cursor()
+            overlapping = cursor.execute(
+                "SELECT 1 FROM listing_promotions WHERE listing_id=? AND ended_at IS NULL AND starts_at < ? AND ends_at > ?",
+                (listing_id, ends_at, starts_at)
+            ).fetchone()
+            if overlapping is not None:
+                return None
+            cursor.execute(
+                "INSERT INTO listing_promotions (listing_id, discount_percent, starts_at, ends_at, created_at) "
+                + "VALUES (?, ?, ?, ?, ?)",
+                (listing_id, discount_percent, starts_at, ends_at, time_now)
+            )
+            return cursor.lastrowid
+
+    def get_promotion(self, promotion_id):
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT * FROM listing_promotions WHERE id=?", (promotion_id,)).fetchone()
+        return self._to_promotion(row) if row is not None else None
+
+    def list_promotions(self, listing_id):
+        # Returns the promotions of a listing, past, current and scheduled, by start time
+        cursor = self.db.cursor()
+        rows = cursor.execute("SELECT * FROM listing_promotions WHERE listing_id=? ORDER BY starts_at, id", (listing_id,))
+        return [self._to_promotion(row) for row in rows]
+
+    def end_promotion(self, promotion_id, time_now):
+        # Ends a promotion that has not ended yet. Returns whether it was ended
+        cursor = self.db.cursor()
+        cursor.execute("UPDATE listing_promotions SET ended_at=? WHERE id=? AND ended_at IS NULL", (time_now, promotion_id))
+        self.db.commit()
+        return cursor.rowcount > 0
+
+    def fetch_due_promotions(self, time_now, limit):
+        # Returns promotions whose window passed by time_now and that were not ended yet, oldest
+        # first, along with the owner of their listing
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT listing_promotions.*, listings.user_id FROM listing_promotions "
+            + "JOIN listings ON listings.id = listing_promotions.listing_id "
+            + "WHERE listing_promotions.ends_at <= ? AND listing_promotions.ended_at IS NULL "
+            + "ORDER BY listing_promotions.ends_at LIMIT ?",
+            (time_now, limit)
+        )
+        return [dict(self._to_promotion(row), user_id=row["user_id"]) for row in rows]
+
+    def mark_promotion_ended(self, promotion_id, ended_at, event):
+        # Ends a due promotion and records event in the outbox, atomically. Returns whether the
+        # promotion was still due, as it may have been cancelled meanwhile
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listing_promotions SET ended_at=? WHERE id=? AND ends_at <= ? AND ended_at IS NULL",
+                (ended_at, promotion_id, ended_at)
+            )
+            if cursor.rowcount == 0:
+                return False
+            self._insert_event(cursor, event)
+        return True
+
+    def create_offer(self, values, time_now):
+        # Inserts an offer with the given column values, returning its id; raises DuplicateError
+        # if the buyer already has an open offer on the listing
+        columns = list(values) + ["created_at", "updated_at"]
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "INSERT INTO listing_offers ({}) VALUES ({})".format(", ".join(columns), ", ".join("?" * len(columns))),
+                    list(values.values()) + [time_now, time_now]
+                )
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return cursor.lastrowid
+
+    def get_offer(self, offer_id):
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT * FROM listing_offers WHERE id=?", (offer_id,)).fetchone()
+        return dict(row) if row is not None else None
+
+    def list_offers(self, listing_id, buyer_id=None):
+        # Returns the offers on a listing, only those of buyer_id if given, newest first
+        select_stmt = "SELECT * FROM listing_offers WHERE listing_id=?"
+        args = [listing_id]
+        if buyer_id is not None:
+            select_stmt += " AND buyer_id=?"
+            args.append(buyer_id)
+        cursor = self.db.cursor()
+        return [dict(row) for row in cursor.execute(select_stmt + " ORDER BY id DESC", args)]
+
+    def transition_offer(self, offer_id, from_status, to_status, amount, time_now, event=None):
+        # Moves an offer from from_status to to_status with the given amount, recording event in
+        # the outbox if given, atomically. decided_at is set when the offer is accepted or
+        # rejected. Returns whether the offer was still in from_status; raises DuplicateError if
+        # it would be the listing's second accepted offer
+        decided_at = time_now if to_status in ("accepted", "rejected") else None
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "UPDATE listing_offers SET status=?, amount=?, updated_at=?, decided_at=? WHERE id=? AND status=?",
+                    (to_status, amount, time_now, decided_at, offer_id, from_status)
+                )
+                if cursor.rowcount == 0:
+                    return False
+                if event is not None:
+                    self._insert_event(cursor, event)
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return True
+
+    def create_hold(self, listing_id, buyer_id, expires_at, time_now, held_event):
+        # Holds a listing for buyer_id until expires_at and records the event built by
+        # held_event(hold id) in the outbox, atomically. Returns the id of the hold; raises
+        # DuplicateError if the listing already has an unreleased hold
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "INSERT INTO listing_holds (listing_id, buyer_id, created_at, expires_at) VALUES (?, ?, ?, ?)",
+                    (listing_id, buyer_id, time_now, expires_at)
+                )
+                hold_id = cursor.lastrowid
+                self._insert_event(cursor, held_event(hold_id))
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return hold_id
+
+    def get_hold(self, hold_id):
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT * FROM listing_holds WHERE id=?", (hold_id,)).fetchone()
+        return dict(row) if row is not None else None
+
+    def get_unreleased_hold(self, listing_id):
+        # Returns the hold of a listing that was not released yet, which may have lapsed, or None
+        cursor = self.db.cursor()
+        row = cursor.execute(
+            "SELECT * FROM listing_holds WHERE listing_id=? AND released_at IS NULL", (listing_id,)
+        ).fetchone()
+        return dict(row) if row is not None else None
+
+    def release_hold(self, hold_id, released_at, reason, event, lapsed_only=False):
+        # Releases a hold that was not released yet and records event in the outbox, atomically.
+        # With lapsed_only, only a hold past its expires_at is released. Returns whether it was
+        lapsed_clause = " AND expires_at <= ?" if lapsed_only else ""
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listing_holds SET released_at=?, release_reason=? WHERE id=? AND released_at IS NULL" + lapsed_clause,
+                (released_at, reason, hold_id) + ((released_at,) if lapsed_only else ())
+            )
+            if cursor.rowcount == 0:
+                return False
+            self._insert_event(cursor, event)
+        return True
+
+    def fetch_due_holds(self, time_now, limit):
+        # Returns unreleased holds past their expires_at by time_now, oldest first, along with
+        # the owner of their listing
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT listing_holds.*, listings.user_id FROM listing_holds "
+            + "JOIN listings ON listings.id = listing_holds.listing_id "
+            + "WHERE listing_holds.expires_at <= ? AND listing_holds.released_at IS NULL "
+            + "ORDER BY listing_holds.expires_at LIMIT ?",
+            (time_now, limit)
+        )
+        return [dict(row) for row in rows]
+
+    def acquire_lease(self, name, holder, time_now, duration):
+        # Takes or renews the lease called name for holder until time_now + duration, unless
+        # another holder has an unexpired lease. Returns whether holder has the lease
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "INSERT INTO worker_leases (name, holder, expires_at) VALUES (?, ?, ?) "
+                + "ON CONFLICT(name) DO UPDATE SET holder=excluded.holder, expires_at=excluded.expires_at "
+                + "WHERE worker_leases.holder=excluded.holder OR worker_leases.expires_at <= ?",
+                (name, holder, time_now + duration, time_now)
+            )
+        return cursor.rowcount > 0
+
+    def moderate(self, listing_id, status, reason, time_now, event):
+        # Sets the moderation status and reason of a visible listing and records event in
+        # the outbox, atomically. Returns whether the listing was found
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "UPDATE listings SET moderation_status=?, moderation_reason=?, moderated_at=? "
+                + "WHERE id=? AND hidden_at IS NULL",
+                (status, reason, time_now, listing_id)
+            )
+            if cursor.rowcount == 0:
+                return False
+            self._insert_event(cursor, event)
+        return True
+
+    def add_report(self, listing_id, user_id, reason, time_now):
+        # Records an abuse report, raising DuplicateError if user_id already reported the listing
+        cursor = self.db.cursor()
+        try:
+            cursor.execute(
+                "INSERT INTO listing_reports (listing_id, user_id, reason, created_at) VALUES (?, ?, ?, ?)",
+                (listing_id, user_id, reason, time_now)
+            )
+        except sqlite3.IntegrityError:
+            self.db.rollback()
+            raise DuplicateError()
+        self.db.commit()
+
+    def count_open_reports(self, listing_id):
+        # Counts the reports of a listing made since an admin last moderated it
+        cursor = self.db.cursor()
+        return cursor.execute(
+            "SELECT COUNT(*) FROM listing_reports JOIN listings ON listings.id = listing_reports.listing_id "
+            + "WHERE listing_reports.listing_id=? AND listing_reports.created_at > COALESCE(listings.moderated_at, 0)",
+            (listing_id,)
+        ).fetchone()[0]
+
+    def list_reports(self, listing_id):
+        # Returns the reports of a listing, newest first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT * FROM listing_reports WHERE listing_id=? ORDER BY created_at DESC, id DESC", (listing_id,)
+        )
+        return [{field: row[field] for field in REPORT_FIELDS} for row in rows]
+
+    def add_views(self, views, time_now, viewed_events=()):
+        # Adds views, a dict of listing id -> number of views, to the listings' view counts and
+        # to their view buckets of the current hour, and records viewed_events, in one transaction
+        bucket_start = time_now - time_now % trending.BUCKET_MICROS
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.executemany(
+                "UPDATE listings SET view_count = view_count + ? WHERE id=?",
+                [(count, listing_id) for listing_id, count in views.items()]
+            )
+            cursor.executemany(
+                "INSERT INTO listing_view_buckets (listing_id, bucket_start, views) VALUES (?, ?, ?) "
+                + "ON CONFLICT(bucket_start, listing_id) DO UPDATE SET views = views + excluded.views",
+                [(listing_id, bucket_start, count) for listing_id, count in views.items()]
+            )
+            for event in viewed_events:
+                self._insert_event(cursor, event)
+
+    def prune_view_buckets(self, before):
+        # Deletes the view buckets that started before the given time
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute("DELETE FROM listing_view_buckets WHERE bucket_start < ?", (before,))
+
+    def refresh_trending_scores(self, window_hours, half_life, time_now):
+        # Replaces the trending scores of the window ending at time_now with the decayed sum of
+        # the views in the buckets overlapping it. A bucket's age is measured from its middle
+        window_start = time_now - window_hours * trending.BUCKET_MICROS
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute("DELETE FROM trending_scores WHERE window_hours=?", (window_hours,))
+            cursor.execute(
+                "INSERT INTO trending_scores (window_hours, listing_id, score) "
+                + "SELECT ?, listing_id, SUM(views * decay_weight(? - bucket_start, ?)) FROM listing_view_buckets "
+                + "WHERE bucket_start > ? GROUP BY listing_id",
+                (window_hours, time_now - trending.BUCKET_MICROS // 2, half_life, window_start - trending.BUCKET_MICROS)
+            )
+
+    def days_changed_since(self, since, day_micros):
+        # Returns the set of days (of day_micros each) in which the listings updated since the
+        # given time were created
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT DISTINCT created_at - created_at % ? FROM listings WHERE updated_at >= ?", (day_micros, since)
+        )
+        return {row[0] for row in rows}
+
+    def refresh_daily_stats(self, days, day_micros):
+        # Recomputes the listing_daily_stats rows of the given days (of day_micros each),
+        # or of every day if days is None. Archived listings still count on the day they were created
+        def insert_stmt(day_range):
+            return (
+                "INSERT INTO listing_daily_stats (day, listing_type, currency, listing_count, price_sum) "
+                + "SELECT created_at - created_at % ? AS day, listing_type, currency, COUNT(*), SUM(price) FROM ("
+                + "SELECT created_at, listing_type, currency, price FROM listings WHERE hidden_at IS NULL"
+                + (" AND " + day_range if day_range else "")
+                + " UNION ALL SELECT created_at, listing_type, currency, price FROM listings_archive"
+                + (" WHERE " + day_range if day_range else "")
+                + ") GROUP BY day, listing_type, currency"
+            )
+        with self.db:
+            cursor = self.db.cursor()
+            if days is None:
+                cursor.execute("DELETE FROM listing_daily_stats")
+                cursor.execute(insert_stmt(None), (day_micros,))
+                return
+            for day in days:
+                cursor.execute("DELETE FROM listing_daily_stats WHERE day=?", (day,))
+                cursor.execute(
+                    insert_stmt("created_at >= ? AND created_at < ?"),
+                    (day_micros, day, day + day_micros, day, day + day_micros)
+                )
+
+    def refresh_status_stats(self, time_now):
+        # Recomputes every listing_status_stats row as of time_now. Moderation comes first, so a
+        # pending listing counts as pending even if it expired. Archived listings count as sold
+        status = (
+            "CASE WHEN moderation_status != 'approved' THEN moderation_status "
+            + "WHEN sold_at IS NOT NULL THEN 'sold' "
+            + "WHEN expires_at IS NOT NULL AND expires_at <= ? THEN 'expired' "
+            + "ELSE 'active' END"
+        )
+        with self.db:
+            cursor = self.db.cursor()
+            rows = cursor.execute(
+                "SELECT listing_type, {} AS status, currency, price FROM listings WHERE hidden_at IS NULL ".format(status)
+                + "UNION ALL SELECT listing_type, 'sold', currency, price FROM listings_archive "
+                + "ORDER BY listing_type, status, currency, price",
+                (time_now,)
+            ).fetchall()
+            cursor.execute("DELETE FROM listing_status_stats")
+            for (listing_type, status, currency), group in itertools.groupby(rows, key=lambda row: tuple(row)[:3]):
+                prices = [row["price"] for row in group]
+                median = percentile(prices, 0.5)
+                cursor.execute(
+                    "INSERT INTO listing_status_stats "
+                    + "(listing_type, status, currency, listing_count, price_sum, median_price, refreshed_at) "
+                    + "VALUES (?, ?, ?, ?, ?, ?, ?)",
+                    (listing_type, status, currency, len(prices), sum(prices), median, time_now)
+                )
+
+    def refresh_price_suggestions(self, since, min_samples, time_now):
+        # Recomputes every price_suggestions row from the approved, visible listings created since
+        # the given time: the 25th, 50th and 75th percentile of their prices per combination with
+        # at least min_samples listings, both per category and across categories
+        with self.db:
+            cursor = self.db.cursor()
+            rows = cursor.execute(
+                "SELECT listing_type, category_id, currency, rental_period, price FROM listings "
+                + "WHERE hidden_at IS NULL AND moderation_status = 'approved' AND created_at >= ?",
+                (since,)
+            )
+            prices = {}
+            for row in rows:
+                listing_type, category_id, currency, rental_period, price = tuple(row)
+                prices.setdefault((listing_type, category_id, currency, rental_period), []).append(price)
+                if category_id is not None:
+                    prices.setdefault((listing_type, None, currency, rental_period), []).append(price)
+            cursor.execute("DELETE FROM price_suggestions")
+            for key, values in prices.items():
+                if len(values) < min_samples:
+                    continue
+                values.sort()
+                cursor.execute(
+                    "INSERT INTO price_suggestions "
+                    + "(listing_type, category_id, currency, rental_period, sample_size, low, median, high, refreshed_at) "
+                    + "VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+                    key + (len(values), percentile(values, 0.25), percentile(values, 0.5), percentile(values, 0.75), time_now)
+                )
+
+    def price_suggestion(self, listing_type, category_id, currency, rental_period):
+        # Returns the price_suggestions row of the combination, or None if it has too few listings
+        cursor = self.db.cursor()
+        row = cursor.execute(
+            "SELECT * FROM price_suggestions WHERE listing_type=? AND category_id IS ? AND currency=? AND rental_period IS ?",
+            (listing_type, category_id, currency, rental_period)
+        ).fetchone()
+        return dict(row) if row is not None else None
+
+    def status_stats(self):
+        # Returns the listing_status_stats rows
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT listing_type, status, currency, listing_count, price_sum, median_price, refreshed_at "
+            + "FROM listing_status_stats ORDER BY listing_type, status, currency"
+        )
+        return [dict(row) for row in rows]
+
+    def daily_stats(self, since):
+        # Returns the listing_daily_stats rows of the days starting at or after since, oldest first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT day, listing_type, currency, listing_count, price_sum FROM listing_daily_stats "
+            + "WHERE day >= ? ORDER BY day, listing_type, currency",
+            (since,)
+        )
+        return [dict(row) for row in rows]
+
+    def trending_ids(self, window_hours, page_num, page_size, filters):
+        # Returns a page of (listing id, score) of visible listings matching the filters, highest score first
+        select_stmt = (
+            "SELECT listings.id, trending_scores.score FROM trending_scores "
+            + "JOIN listings ON listings.id = trending_scores.listing_id "
+            + "WHERE trending_scores.window_hours=? AND listings.hidden_at IS NULL"
+        )
+        filter_clauses, args = self._filter_clauses(filters)
+        select_stmt += filter_clauses + " ORDER BY trending_scores.score DESC, listings.id DESC LIMIT ? OFFSET ?"
+        args = [window_hours] + args + [page_size, (page_num - 1) * page_size]
+        cursor = self.db.cursor()
+        return [(row["id"], row["score"]) for row in cursor.execute(select_stmt, args)]
+
+    def add_favorite(self, listing_id, user_id, time_now, event):
+        # Favorites a listing for user_id and records event; favoriting it again keeps the
+        # original time and records nothing
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "INSERT OR IGNORE INTO listing_favorites (listing_id, user_id, created_at) VALUES (?, ?, ?)",
+                (listing_id, user_id, time_now)
+            )
+            if cursor.rowcount > 0:
+                self._insert_event(cursor, event)
+
+    def remove_favorite(self, listing_id, user_id, event):
+        # Unfavorites a listing for user_id and records event, unless it was not a favorite
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute("DELETE FROM listing_favorites WHERE listing_id=? AND user_id=?", (listing_id, user_id))
+            if cursor.rowcount > 0:
+                self._insert_event(cursor, event)
+
+    def create_template(self, user_id, name, fields, time_now):
+        # Returns the id of the new template; raises DuplicateError if user_id already has one named name
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "INSERT INTO listing_templates (user_id, name, fields, created_at) VALUES (?, ?, ?, ?)",
+                    (user_id, name, json.dumps(fields), time_now)
+                )
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return cursor.lastrowid
+
+    def get_template(self, template_id):
+        cursor = self.db.cursor()
+        row = cursor.execute(
+            "SELECT id, user_id, name, fields, created_at FROM listing_templates WHERE id=?", (template_id,)
+        ).fetchone()
+        return self._to_template(row) if row is not None else None
+
+    def list_templates(self, user_id):
+        # Returns the templates of user_id, by name
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT id, user_id, name, fields, created_at FROM listing_templates WHERE user_id=? ORDER BY name, id",
+            (user_id,)
+        ).fetchall()
+        return [self._to_template(row) for row in rows]
+
+    def delete_template(self, template_id):
+        # Returns whether the template existed
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listing_templates WHERE id=?", (template_id,))
+        self.db.commit()
+        return cursor.rowcount > 0
+
+    def delete_user_templates(self, user_id):
+        # Deletes the templates of user_id, returning how many were deleted
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listing_templates WHERE user_id=?", (user_id,))
+        self.db.commit()
+        return cursor.rowcount
+
+    def save_draft(self, user_id, draft_id, data, time_now):
+        # Inserts or replaces the data of a draft of user_id, keeping its created_at
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "INSERT INTO listing_drafts (user_id, draft_id, data, created_at, updated_at) VALUES (?, ?, ?, ?, ?) "
+                + "ON CONFLICT (user_id, draft_id) DO UPDATE SET data=excluded.data, updated_at=excluded.updated_at",
+                (user_id, draft_id, json.dumps(data), time_now, time_now)
+            )
+
+    def get_draft(self, user_id, draft_id):
+        cursor = self.db.cursor()
+        row = cursor.execute(
+            "SELECT draft_id, user_id, data, created_at, updated_at FROM listing_drafts WHERE user_id=? AND draft_id=?",
+            (user_id, draft_id)
+        ).fetchone()
+        return self._to_draft(row) if row is not None else None
+
+    def list_drafts(self, user_id):
+        # Returns the drafts of user_id, most recently saved first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT draft_id, user_id, data, created_at, updated_at FROM listing_drafts WHERE user_id=? "
+            + "ORDER BY updated_at DESC, draft_id",
+            (user_id,)
+        ).fetchall()
+        return [self._to_draft(row) for row in rows]
+
+    def count_drafts(self, user_id):
+        cursor = self.db.cursor()
+        return cursor.execute("SELECT COUNT(*) FROM listing_drafts WHERE user_id=?", (user_id,)).fetchone()[0]
+
+    def delete_draft(self, user_id, draft_id):
+        # Returns whether the draft existed
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listing_drafts WHERE user_id=? AND draft_id=?", (user_id, draft_id))
+        self.db.commit()
+        return cursor.rowcount > 0
+
+    def delete_user_drafts(self, user_id):
+        # Deletes the drafts of user_id, returning how many were deleted
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listing_drafts WHERE user_id=?", (user_id,))
+        self.db.commit()
+        return cursor.rowcount
+
+    def create_saved_search(self, values, time_now):
+        # Inserts a saved search with the given column values, returning its id
+        columns = list(values) + ["created_at"]
+        cursor = self.db.cursor()
+        cursor.execute(
+            "INSERT INTO saved_searches ({}) VALUES ({})".format(", ".join(columns), ", ".join("?" * len(columns))),
+            list(values.values()) + [time_now]
+        )
+        self.db.commit()
+        return cursor.lastrowid
+
+    def get_saved_search(self, saved_search_id):
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT * FROM saved_searches WHERE id=?", (saved_search_id,)).fetchone()
+        return dict(row) if row is not None else None
+
+    def list_saved_searches(self, user_id):
+        # Returns the saved searches of user_id, oldest first
+        cursor = self.db.cursor()
+        rows = cursor.execute("SELECT * FROM saved_searches WHERE user_id=? ORDER BY id", (user_id,))
+        return [dict(row) for row in rows]
+
+    def count_saved_searches(self, user_id):
+        cursor = self.db.cursor()
+        return cursor.execute("SELECT COUNT(*) FROM saved_searches WHERE user_id=?", (user_id,)).fetchone()[0]
+
+    def delete_saved_search(self, saved_search_id):
+        # Returns whether the saved search existed
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM saved_searches WHERE id=?", (saved_search_id,))
+        self.db.commit()
+        return cursor.rowcount > 0
+
+    def delete_user_saved_searches(self, user_id):
+        # Deletes the saved searches of user_id, returning how many were deleted
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM saved_searches WHERE user_id=?", (user_id,))
+        self.db.commit()
+        return cursor.rowcount
+
+    def match_saved_searches(self, moderation_status, time_now, limit, matched_event):
+        # Matches up to limit visible listings with the given moderation status that were not
+        # matched yet against the saved searches of other users, oldest first. Records the event
+        # built by matched_event(saved search row, listing row) for each match in the outbox and
+        # marks the listings as matched, atomically; expired listings are marked without matching.
+        # Returns how many listings were matched
+        with self.db:
+            cursor = self.db.cursor()
+            listing_ids = [row[0] for row in cursor.execute(
+                "SELECT id FROM listings WHERE matched_at IS NULL AND moderation_status=? AND hidden_at IS NULL "
+                + "ORDER BY id LIMIT ?",
+                (moderation_status, limit)
+            )]
+            if not listing_ids:
+                return 0
+            placeholders = ", ".join("?" * len(listing_ids))
+            rows = cursor.execute(
+                "SELECT saved_searches.id AS saved_search_id, saved_searches.user_id AS saved_search_user_id, "
+                + "listings.id, listings.listing_type, listings.price, listings.currency FROM listings "
+                + "JOIN saved_searches ON saved_searches.user_id != listings.user_id "
+                + "AND (saved_searches.listing_type IS NULL OR saved_searches.listing_type = listings.listing_type) "
+                + "AND (saved_searches.currency IS NULL OR saved_searches.currency = listings.currency) "
+                + "AND (saved_searches.min_price IS NULL OR listings.price >= saved_searches.min_price) "
+                + "AND (saved_searches.max_price IS NULL OR listings.price <= saved_searches.max_price) "
+                + "AND (saved_searches.category_id IS NULL OR saved_searches.category_id = listings.category_id) "
+                + "WHERE listings.id IN ({}) AND (listings.expires_at IS NULL OR listings.expires_at > ?) ".format(placeholders)
+                + "ORDER BY listings.id, saved_searches.id",
+                listing_ids + [time_now]
+            ).fetchall()
+            for row in rows:
+                self._insert_event(cursor, matched_event(row))
+            cursor.execute(
+                "UPDATE listings SET matched_at=? WHERE id IN ({})".format(placeholders), [time_now] + listing_ids
+            )
+        return len(listing_ids)
+
+    def reassign_user(self, source_user_id, target_user_id, time_now):
+        # Moves every listing, archived listing, favorite, template, draft and saved search of source_user_id to
+        # target_user_id, returning how many listings were moved. Favorites both users share are
+        # kept once, and templates named like one of target_user_id's, or drafts with the same id
+        # as one of theirs, are dropped
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute("UPDATE saved_searches SET user_id=? WHERE user_id=?", (target_user_id, source_user_id))
+            cursor.execute(
+                "UPDATE OR IGNORE listing_templates SET user_id=? WHERE user_id=?", (target_user_id, source_user_id)
+            )
+            cursor.execute("DELETE FROM listing_templates WHERE user_id=?", (source_user_id,))
+            cursor.execute(
+                "UPDATE OR IGNORE listing_drafts SET user_id=? WHERE user_id=?", (target_user_id, source_user_id)
+            )
+            cursor.execute("DELETE FROM listing_drafts WHERE user_id=?", (source_user_id,))
+            cursor.execute(
+                "INSERT OR IGNORE INTO listing_favorites (listing_id, user_id, created_at) "
+                + "SELECT listing_id, ?, created_at FROM listing_favorites WHERE user_id=?",
+                (target_user_id, source_user_id)
+            )
+            cursor.execute("DELETE FROM listing_favorites WHERE user_id=?", (source_user_id,))
+            cursor.execute(
+                "UPDATE listings_archive SET user_id=?, "
+                + "data=json_set(data, '$.user_id', ?, '$.user_name', (SELECT name FROM user_names WHERE user_id=?)) "
+                + "WHERE user_id=?",
+                (target_user_id, target_user_id, target_user_id, source_user_id)
+            )
+            cursor.execute(
+                "UPDATE listings SET user_id=?, user_name=(SELECT name FROM user_names WHERE user_id=?), updated_at=? "
+                + "WHERE user_id=?",
+                (target_user_id, target_user_id, time_now, source_user_id)
+            )
+        return cursor.rowcount
+
+    def count_active_listings(self, user_id, time_now):
+        # Counts the visible listings of user_id that are neither expired nor sold
+        cursor = self.db.cursor()
+        return cursor.execute(
+            "SELECT COUNT(*) FROM listings WHERE user_id=? AND hidden_at IS NULL AND sold_at IS NULL "
+            + "AND (expires_at IS NULL OR expires_at > ?)",
+            (user_id, time_now)
+        ).fetchone()[0]
+
+    def get_user_name(self, user_id):
+        # Returns the last known name of user_id, or None if it is unknown
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT name FROM user_names WHERE user_id=?", (user_id,)).fetchone()
+        return row[0] if row is not None else None
+
+    def set_user_name(self, user_id, name, updated_at):
+        # Records the name of user_id as of updated_at and copies it to the user's listings and
+        # archived listings, unless a later name was already recorded. Returns how many listings
+        # were renamed
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "INSERT INTO user_names (user_id, name, updated_at) VALUES (?, ?, ?) "
+                + "ON CONFLICT(user_id) DO UPDATE SET name=excluded.name, updated_at=excluded.updated_at "
+                + "WHERE excluded.updated_at >= user_names.updated_at",
+                (user_id, name, updated_at)
+            )
+            if cursor.rowcount == 0:
+                return 0
+            cursor.execute(
+                "UPDATE listings_archive SET data=json_set(data, '$.user_name', ?) WHERE user_id=?", (name, user_id)
+            )
+            cursor.execute(
+                "UPDATE listings SET user_name=? WHERE user_id=? AND user_name IS NOT ?",
+                (name, user_id, name)
+            )
+        return cursor.rowcount
+
+    def delete_user_favorites(self, user_id):
+        # Deletes the favorites of user_id, returning how many were deleted
+        cursor = self.db.cursor()
+        cursor.execute("DELETE FROM listing_favorites WHERE user_id=?", (user_id,))
+        self.db.commit()
+        return cursor.rowcount
+
+    def hide_user_listings(self, user_id, hidden_at):
+        # Hides the visible listings of user_id, marking them as orphaned. Returns how many were hidden
+        cursor = self.db.cursor()
+        cursor.execute(
+            "UPDATE listings SET hidden_at=?, updated_at=?, orphaned_at=?, orphaned_user_id=user_id "
+            + "WHERE user_id=? AND hidden_at IS NULL",
+            (hidden_at, hidden_at, hidden_at, user_id)
+        )
+        self.db.commit()
+        return cursor.rowcount
+
+    def orphan_user_listings(self, user_id, orphaned_at, new_user_id=None):
+        # Marks the visible listings of user_id as orphaned, leaving them visible, and hands them
+        # over to new_user_id if given. Returns how many were orphaned
+        cursor = self.db.cursor()
+        if new_user_id is None:
+            cursor.execute(
+                "UPDATE listings SET orphaned_at=?, orphaned_user_id=user_id "
+                + "WHERE user_id=? AND hidden_at IS NULL AND orphaned_at IS NULL",
+                (orphaned_at, user_id)
+            )
+        else:
+            cursor.execute(
+                "UPDATE listings SET user_id=?, user_name=(SELECT name FROM user_names WHERE user_id=?), "
+                + "updated_at=?, orphaned_at=?, orphaned_user_id=user_id WHERE user_id=? AND hidden_at IS NULL",
+                (new_user_id, new_user_id, orphaned_at, orphaned_at, user_id)
+            )
+        self.db.commit()
+        return cursor.rowcount
+
+    def list_orphaned(self, page_num, page_size):
+        # Returns the listings whose owner was deleted, hidden or not, most recently orphaned first.
+        # Each also carries its orphaned_user_id and hidden_at
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            SELECT_LISTINGS + " WHERE listings.orphaned_at IS NOT NULL "
+            + "ORDER BY listings.orphaned_at DESC, listings.id DESC LIMIT ? OFFSET ?",
+            (page_size, (page_num - 1) * page_size)
+        ).fetchall()
+        listings = self._with_details([self._to_listing(row) for row in rows])
+        for listing, row in zip(listings, rows):
+            listing["orphaned_user_id"] = row["orphaned_user_id"]
+            listing["hidden_at"] = row["hidden_at"]
+        return listings
+
+    def list_reported(self, page_num, page_size):
+        # Returns the visible listings with reports made since an admin last moderated them, most
+        # reported first. Each also carries its open_report_count and last_reported_at
+        cursor = self.db.cursor()
+        counts = cursor.execute(
+            "SELECT listing_reports.listing_id, COUNT(*) AS open_report_count, "
+            + "MAX(listing_reports.created_at) AS last_reported_at "
+            + "FROM listing_reports JOIN listings ON listings.id = listing_reports.listing_id "
+            + "WHERE listings.hidden_at IS NULL "
+            + "AND listing_reports.created_at > COALESCE(listings.moderated_at, 0) "
+            + "GROUP BY listing_reports.listing_id "
+            + "ORDER BY open_report_count DESC, last_reported_at DESC, listing_reports.listing_id DESC "
+            + "LIMIT ? OFFSET ?",
+            (page_size, (page_num - 1) * page_size)
+        ).fetchall()
+        if not counts:
+            return []
+
+        ids = [row["listing_id"] for row in counts]
+        rows = cursor.execute(
+            SELECT_LISTINGS + " WHERE listings.id IN ({})".format(", ".join("?" * len(ids))), ids
+        ).fetchall()
+        by_id = {listing["id"]: listing for listing in self._with_details([self._to_listing(row) for row in rows])}
+        listings = []
+        for row in counts:
+            listing = by_id.get(row["listing_id"])
+            if listing is None:
+                continue
+            listing["open_report_count"] = row["open_report_count"]
+            listing["last_reported_at"] = row["last_reported_at"]
+            listings.append(listing)
+        return listings
+
+    def fetch_pending_events(self, limit):
+        # Returns undelivered events, oldest first
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT id, event_type, payload, created_at FROM outbox_events "
+            + "WHERE published_at IS NULL ORDER BY id LIMIT ?",
+            (limit,)
+        )
+        return [
+            dict(id=row["id"], type=row["event_type"], payload=json.loads(row["payload"]), created_at=row["created_at"])
+            for row in rows
+        ]
+
+    def mark_events_published(self, event_ids, published_at):
+        if not event_ids:
+            return
+        cursor = self.db.cursor()
+        cursor.execute(
+            "UPDATE outbox_events SET published_at=? WHERE id IN ({})".format(", ".join("?" * len(event_ids))),
+            [published_at] + list(event_ids)
+        )
+        self.db.commit()
+
+    def mark_event_failed(self, event_id, reason):
+        cursor = self.db.cursor()
+        cursor.execute(
+            "UPDATE outbox_events SET attempts=attempts+1, last_error=? WHERE id=?",
+            (reason, event_id)
+        )
+        self.db.commit()
+
+    def init_checkpoint(self, name):
+        # Creates the checkpoint called name at the latest outbox event, unless it exists, so a
+        # worker reading the outbox for the first time does not replay it
+        with self.db:
+            cursor = self.db.cursor()
+            cursor.execute(
+                "INSERT OR IGNORE INTO worker_checkpoints (name, position) "
+                + "SELECT ?, COALESCE(MAX(id), 0) FROM outbox_events",
+                (name,)
+            )
+
+    def enqueue_webhook_deliveries(self, event_types, urls, time_now, limit):
+        # Records a delivery to each of urls for the outbox events of event_types after the
+        # webhooks checkpoint, reading at most limit events, and moves the checkpoint past them.
+        # Returns how many events were read
+        with self.db:
+            cursor = self.db.cursor()
+            position = cursor.execute("SELECT position FROM worker_checkpoints WHERE name='webhooks'").fetchone()[0]
+            rows = cursor.execute(
+                "SELECT id, event_type FROM outbox_events WHERE id > ? ORDER BY id LIMIT ?", (position, limit)
+            ).fetchall()
+            cursor.executemany(
+                "INSERT INTO webhook_deliveries (event_id, url, status, next_attempt_at, created_at) "
+                + "VALUES (?, ?, 'pending', ?, ?)",
+                [(row[0], url, time_now, time_now) for row in rows if row[1] in event_types for url in urls]
+            )
+            if rows:
+                cursor.execute("UPDATE worker_checkpoints SET position=? WHERE name='webhooks'", (rows[-1][0],))
+        return len(rows)
+
+    def fetch_due_webhook_deliveries(self, time_now, limit):
+        # Returns the pending deliveries due by time_now, longest due first, with their event
+        cursor = self.db.cursor()
+        rows = cursor.execute(
+            "SELECT webhook_deliveries.id, url, webhook_deliveries.attempts, outbox_events.id, event_type, payload, "
+            + "outbox_events.created_at "
+            + "FROM webhook_deliveries JOIN outbox_events ON outbox_events.id = webhook_deliveries.event_id "
+            + "WHERE next_attempt_at <= ? ORDER BY next_attempt_at, webhook_deliveries.id LIMIT ?",
+            (time_now, limit)
+        ).fetchall()
+        return [
+            dict(
+                id=row[0], url=row[1], attempts=row[2],
+                event=dict(id=row[3], type=row[4], payload=json.loads(row[5]), created_at=row[6])
+            )
+            for row in rows
+        ]
+
+    def record_webhook_attempt(self, delivery_id, status, status_code, error, time_now, next_attempt_at):
+        # Records an attempt of a delivery, which is then delivered, failed or pending until
+        # next_attempt_at
+        cursor = self.db.cursor()
+        cursor.execute(
+            "UPDATE webhook_deliveries SET status=?, attempts=attempts+1, last_status_code=?, last_error=?, "
+            + "last_attempt_at=?, next_attempt_at=?, delivered_at=? WHERE id=?",
+            (status, status_code, error, time_now, next_attempt_at, time_now if status == "delivered" else None, delivery_id)
+        )
+        self.db.commit()
+
+    def list_webhook_deliveries(self, status, page_num, page_size):
+        # Returns a page of the delivery log, newest first, optionally only deliveries with status
+        query = (
+            "SELECT webhook_deliveries.id, event_id, event_type, url, status, webhook_deliveries.attempts, next_attempt_at, "
+            + "last_attempt_at, last_status_code, webhook_deliveries.last_error, webhook_deliveries.created_at, delivered_at "
+            + "FROM webhook_deliveries JOIN outbox_events ON outbox_events.id = webhook_deliveries.event_id"
+        )
+        args = []
+        if status is not None:
+            query += " WHERE status=?"
+            args.append(status)
+        query += " ORDER BY webhook_deliveries.id DESC LIMIT ? OFFSET ?"
+        args += [page_size, (page_num - 1) * page_size]
+        cursor = self.db.cursor()
+        return [dict(row) for row in cursor.execute(query, args).fetchall()]
+
+    def _insert_event(self, cursor, event):
+        cursor.execute(
+            "INSERT INTO outbox_events (event_type, payload, created_at) VALUES (?, ?, ?)",
+            (event["type"], json.dumps(event["payload"]), event["created_at"])
+        )
+
+    def _with_details(self, listings):
+        # Attaches its images, active promotion, active hold and the hold its sale released to each
+        # listing, loading those of all the listings in one query each. effective_price is the price
+        # after the promotion's discount
+        images = {listing["id"]: [] for listing in listings}
+        promotions = {}
+        holds = {}
+        sold_holds = {}
+        if images:
+            cursor = self.db.cursor()
+            placeholders = ", ".join("?" * len(images))
+            rows = cursor.execute(
+                "SELECT listing_id, {} FROM listing_images WHERE listing_id IN ({}) ORDER BY listing_id, position".format(
+                    ", ".join(IMAGE_FIELDS), placeholders
+                ),
+                list(images)
+            )
+            for row in rows:
+                images[row["listing_id"]].append({field: row[field] for field in IMAGE_FIELDS})
+            time_now = int(time.time() * 1e6)
+            rows = cursor.execute(
+                "SELECT * FROM listing_promotions WHERE listing_id IN ({}) ".format(placeholders)
+                + "AND ended_at IS NULL AND starts_at <= ? AND ends_at > ?",
+                list(images) + [time_now, time_now]
+            )
+            for row in rows:
+                promotions[row["listing_id"]] = self._to_promotion(row)
+            rows = cursor.execute(
+                "SELECT id, listing_id, buyer_id, expires_at FROM listing_holds WHERE listing_id IN ({}) ".format(placeholders)
+                + "AND released_at IS NULL AND expires_at > ?",
+                list(images) + [time_now]
+            )
+            for row in rows:
+                holds[row["listing_id"]] = dict(id=row["id"], buyer_id=row["buyer_id"], expires_at=row["expires_at"])
+            rows = cursor.execute(
+                "SELECT id, listing_id, buyer_id, expires_at FROM listing_holds WHERE listing_id IN ({}) ".format(placeholders)
+                + "AND release_reason='sold'",
+                list(images)
+            )
+            for row in rows:
+                sold_holds[row["listing_id"]] = dict(id=row["id"], buyer_id=row["buyer_id"], expires_at=row["expires_at"])
+        for listing in listings:
+            listing["images"] = images[listing["id"]]
+            listing["promotion"] = promotions.get(listing["id"])
+            listing["hold"] = holds.get(listing["id"])
+            listing["sold_hold"] = sold_holds.get(listing["id"])
+            listing["effective_price"] = listing["price"]
+            if listing["promotion"] is not None:
+                price = Money(listing["price"], listing["currency"])
+                listing["effective_price"] = price.discounted(listing["promotion"]["discount_percent"]).amount
+        return listings
+
+    def _to_promotion(self, row):
+        return {field: row[field] for field in PROMOTION_FIELDS}
+
+    def _to_listing(self, row, near=None):
+        # near is the (latitude, longitude, radius_km) of a radius query, whose results
+        # carry their distance from that point
+        listing = {field: row[field] for field in LISTING_FIELDS}
+        listing["attributes"] = json.loads(listing["attributes"])
+        if listing["negotiable"] is not None:
+            listing["negotiable"] = bool(listing["negotiable"])
+        listing["category"] = None
+        if row["category_id"] is not None:
+            listing["category"] = dict(id=row["category_id"], name=row["category_name"])
+        if near is not None:
+            listing["distance_km"] = round(geo.haversine_km(near[0], near[1], row["latitude"], row["longitude"]), 3)
+        return listing
+
+    def _to_archived_listing(self, row):
+        listing = json.loads(row["data"])
+        listing["archived_at"] = row["archived_at"]
+        return listing
+
+    def _to_template(self, row):
+        return dict(id=row[0], user_id=row[1], name=row[2], fields=json.loads(row[3]), created_at=row[4])
+
+    def _to_draft(self, row):
+        return dict(id=row[0], user_id=row[1], data=json.loads(row[2]), created_at=row[3], updated_at=row[4])
+
+class CategoryRepository:
+    """Stores listing categories in SQLite."""
+
+    def __init__(self, db):
+        self.db = db
+
+    def init_schema(self):
+        cursor = self.db.cursor()
+        cursor.execute(
+            "CREATE TABLE IF NOT EXISTS 'categories' ("
+            + "id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,"
+            + "name TEXT NOT NULL UNIQUE COLLATE NOCASE,"
+            + "created_at INTEGER NOT NULL,"
+            + "updated_at INTEGER NOT NULL,"
+            + "attributes TEXT NOT NULL DEFAULT '{}'"
+            + ");"
+        )
+        # The attributes listings of the category can have, as a JSON object of name -> type
+        ensure_column(cursor, "categories", "attributes", "TEXT NOT NULL DEFAULT '{}'")
+        self.db.commit()
+
+    def list(self):
+        cursor = self.db.cursor()
+        rows = cursor.execute("SELECT * FROM categories ORDER BY name COLLATE NOCASE")
+        return [self._to_category(row) for row in rows]
+
+    def get(self, category_id):
+        # Returns the category, or None if it does not exist
+        cursor = self.db.cursor()
+        row = cursor.execute("SELECT * FROM categories WHERE id=?", (category_id,)).fetchone()
+        return self._to_category(row) if row is not None else None
+
+    def create(self, name, attributes, time_now):
+        # Returns the id of the new category; raises DuplicateError if the name is taken
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "INSERT INTO categories (name, attributes, created_at, updated_at) VALUES (?, ?, ?, ?)",
+                    (name, json.dumps(attributes), time_now, time_now)
+                )
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return cursor.lastrowid
+
+    def update(self, category_id, name, attributes, time_now):
+        # Renames the category and, unless attributes is None, replaces its attributes. Returns
+        # whether the category exists; raises DuplicateError if the name is taken
+        try:
+            with self.db:
+                cursor = self.db.cursor()
+                cursor.execute(
+                    "UPDATE categories SET name=?, attributes=COALESCE(?, attributes), updated_at=? WHERE id=?",
+                    (name, json.dumps(attributes) if attributes is not None else None, time_now, category_id)
+                )
+        except sqlite3.IntegrityError:
+            raise DuplicateError()
+        return cursor.rowcount > 0
+
+    def delete_unused(self, category_id):
+        # Deletes the category unless a listing (hidden or not) still refers to it.
+        # Returns "deleted", "in_use" or "not_found"
+        with self.db:
+            cursor = self.db.cursor()
+            if cursor.execute("SELECT 1 FROM listings WHERE category_id=? LIMIT 1", (category_id,)).fetchone():
+                return "in_use"
+            cursor.execute("DELETE FROM categories WHERE id=?", (category_id,))
+            return "deleted" if cursor.rowcount > 0 else "not_found"
+
+    def _to_category(self, row):
+        category = {field: row[field] for field in CATEGORY_FIELDS}
+        category["attributes"] = json.loads(category["attributes"])
+        return category
@@ -0,0 +1,54 @@
+import logging
+import uuid
+
+# Header carrying the correlation ID of a request, in requests and responses, like the Go services' httpmiddleware
+REQUEST_ID_HEADER = "X-Request-ID"
+
+# Longest request ID accepted from callers
+MAX_REQUEST_ID_LENGTH = 128
+
+access_log = logging.getLogger("tornado.access")
+
+def request_id(request):
+    """Returns the ID of a request: the one in its X-Request-ID header, or a new random one when
+    missing. It is kept on the request, so the response and the access log use the same one."""
+    if not hasattr(request, "request_id"):
+        provided = request.headers.get(REQUEST_ID_HEADER, "").strip()
+        request.request_id = provided if provided and len(provided) <= MAX_REQUEST_ID_LENGTH else uuid.uuid4().hex
+    return request.request_id
+
+def format_duration(seconds):
+    """Formats a duration rounded to the microsecond as Go formats a time.Duration, e.g. 1.234ms."""
+    micros = int(round(seconds * 1e6))
+    if micros < 1000:
+        return "{}µs".format(micros) if micros > 0 else "0s"
+    if micros < 1000000:
+        return _trim(micros / 1e3, 3) + "ms"
+    minutes, micros = divmod(micros, 60000000)
+    hours, minutes = divmod(minutes, 60)
+    text = _trim(micros / 1e6, 6) + "s"
+    if hours > 0:
+        return "{}h{}m{}".format(hours, minutes, text)
+    if minutes > 0:
+        return "{}m{}".format(minutes, text)
+    return text
+
+def _trim(value, decimals):
+    return "{:.{}f}".format(value, decimals).rstrip("0").rstrip(".")
+
+def log_request(handler):
+    """Logs a request once it is served, in the format of the Go services' access logs: its
+    method, URI, status, response size, duration and request ID.
+
+    Pass it as the log_function setting of the application."""
+    status = handler.get_status()
+    if status < 400:
+        log = access_log.info
+    elif status < 500:
+        log = access_log.warning
+    else:
+        log = access_log.error
+    request = handler.request
+    log("{} {} {} {}B {} request_id={}".format(
+        request.method, request.uri, status, getattr(handler, "bytes_written", 0),
+        format_duration(request.request_time()), request_id(request)))
@@ -0,0 +1,1852 @@
+import base64
+import binascii
+import json
+import logging
+import random
+import re
+import time
+import urllib.parse
+
+import tornado.gen
+
+import currencies
+import events
+import filters as filter_expressions
+import geo
+import stats
+import trending
+from money import MAX_AMOUNT
+from owners import OwnerCheckUnavailableError
+from repository import DEFAULT_SORT, LISTING_FIELDS, DuplicateError
+from storage import IMAGE_TYPES, MAX_IMAGE_BYTES, image_dimensions, new_image_key
+from webhooks import WEBHOOK_STATUSES
+
+LISTING_TYPES = {"rent", "sale"}
+
+# Periods a rental price can be quoted per
+RENTAL_PERIODS = ("daily", "weekly", "monthly", "yearly")
+
+# Maximum lengths, in characters, of the free-text listing fields
+MAX_TITLE_LENGTH = 100
+MAX_DESCRIPTION_LENGTH = 2000
+
+# Fields a merge patch may change; the rest of a listing is read-only or managed elsewhere
+PATCHABLE_FIELDS = (
+    "listing_type", "price", "currency", "title", "description", "category_id",
+    "latitude", "longitude", "expires_at", "available_from", "available_to",
+    "rental_period", "deposit", "negotiable", "attributes",
+)
+
+# Listing fields a template can hold. Expiry and availability are points in time, so they
+# are left to each listing created from the template
+TEMPLATE_FIELDS = (
+    "listing_type", "price", "currency", "title", "description", "category_id",
+    "latitude", "longitude", "rental_period", "deposit", "negotiable", "attributes",
+)
+
+# Maximum length, in characters, of a template name
+MAX_TEMPLATE_NAME_LENGTH = 100
+
+# Draft ids are chosen by the client, e.g. a UUID generated when the form is opened, so the
+# first autosave needs no separate create request
+DRAFT_ID_PATTERN = re.compile(r"^[A-Za-z0-9_-]{1,64}$")
+
+# Maximum size, in bytes, of a draft's JSON data, and number of drafts a user can keep
+MAX_DRAFT_BYTES = 64 * 1024
+MAX_DRAFTS_PER_USER = 20
+
+# How often, in microseconds, a listing may be bumped (once a day), and the longest
+# extension of its expiry per bump, in days
+BUMP_INTERVAL = 24 * 3600 * 1000000
+MAX_BUMP_EXTEND_DAYS = 90
+
+# Default and maximum number of listings in the feeds of new listings
+DEFAULT_FEED_SIZE = 20
+MAX_FEED_SIZE = 100
+
+# Maximum length, in characters, of a search query
+MAX_SEARCH_QUERY_LENGTH = 200
+
+# Default and maximum number of featured listings sampled at once, and how many candidates
+# a weighted sample is drawn from per listing returned
+DEFAULT_FEATURED_COUNT = 10
+MAX_FEATURED_COUNT = 50
+FEATURED_POOL_FACTOR = 5
+
+# Default and maximum number of similar listings returned at once, how many candidates they
+# are ranked from, and how far, as a fraction of the listing's price, candidate prices may be
+DEFAULT_SIMILAR_COUNT = 10
+MAX_SIMILAR_COUNT = 50
+SIMILAR_POOL_SIZE = 200
+SIMILAR_PRICE_RANGE = 0.5
+
+# Distance, in kilometers, at which a candidate's location stops adding to its similarity
+SIMILAR_RADIUS_KM = 25
+
+# Weights of the same category, a close price and a close location in similarity scores
+SIMILARITY_WEIGHTS = dict(category=0.3, price=0.5, location=0.2)
+
+# Default and maximum number of days covered by listing stats
+DEFAULT_STATS_DAYS = 30
+MAX_STATS_DAYS = 365
+
+# Largest discount of a promotion, in percent, and longest promotion window
+MAX_DISCOUNT_PERCENT = 90
+MAX_PROMOTION_DAYS = 90
+
+# Listing fields that can be selected as properties of GeoJSON features, and those returned by
+# default. Coordinates are the geometry of the features
+GEOJSON_PROPERTIES = tuple(field for field in LISTING_FIELDS if field not in ("latitude", "longitude")) + (
+    "category", "images", "distance_km", "promotion", "effective_price"
+)
+DEFAULT_GEOJSON_PROPERTIES = ("id", "listing_type", "price", "currency", "title")
+
+# Maximum number of saved searches per user
+MAX_SAVED_SEARCHES = 20
+
+# Maximum number of images per listing, and maximum length of their URLs
+MAX_IMAGES_PER_LISTING = 20
+MAX_IMAGE_URL_LENGTH = 2048
+
+# Moderation states of listings. Only approved listings are publicly visible
+PENDING = "pending"
+APPROVED = "approved"
+REJECTED = "rejected"
+
+# Statuses listings are counted by in listing stats: moderation first, then sold, expired or active
+LISTING_STATUSES = (PENDING, REJECTED, "sold", "expired", "active")
+
+# States of offers. Pending offers wait for the listing's owner, countered ones for the buyer
+OFFER_PENDING = "pending"
+OFFER_COUNTERED = "countered"
+OFFER_ACCEPTED = "accepted"
+OFFER_REJECTED = "rejected"
+
+# Maximum number of minutes a listing can be held for a buyer
+MAX_HOLD_MINUTES = 7 * 24 * 60
+
+# Reasons holds are released for
+HOLD_EXPIRED = "expired"
+HOLD_RELEASED = "released"
+
+# What happens to the listings of deleted users: hidden, handed over to another user, or
+# left visible and marked as orphaned
+ORPHAN_POLICIES = ("hide", "reassign", "orphan")
+
+# Maximum length, in characters, of a rejection or report reason
+MAX_REJECTION_REASON_LENGTH = 500
+MAX_REPORT_REASON_LENGTH = 500
+
+# Maximum length, in characters, of a category name
+MAX_CATEGORY_NAME_LENGTH = 50
+
+# Types of category attributes; a list of strings instead of a type allows only those values
+ATTRIBUTE_TYPES = ("int", "number", "bool", "str")
+
+# Attribute names, which filters use as JSON paths, e.g. attributes.bedrooms>=2
+ATTRIBUTE_NAME_PATTERN = re.compile(r"^[a-z][a-z0-9_]{0,31}$")
+
+# Maximum number of attributes per category, and length, in characters, of str attribute values
+MAX_CATEGORY_ATTRIBUTES = 30
+MAX_ATTRIBUTE_VALUE_LENGTH = 100
+
+class ValidationError(Exception):
+    """Raised when request parameters are invalid. Carries the list of error messages."""
+
+    def __init__(self, errors):
+        super().__init__("; ".join(errors))
+        self.errors = errors
+
+class NotFoundError(Exception):
+    """Raised when a listing does not exist or is hidden."""
+
+class ForbiddenError(Exception):
+    """Raised when a user acts on a listing they do not own."""
+
+class ConflictError(Exception):
+    """Raised when a change conflicts with the current state, e.g. a duplicate name."""
+
+class QuotaExceededError(Exception):
+    """Raised when a user already has as many active listings as allowed."""
+
+    code = "listing_quota_exceeded"
+
+class RateLimitedError(Exception):
+    """Raised when an action is repeated too soon. Carries the seconds until it is allowed again."""
+
+    def __init__(self, message, retry_after):
+        super().__init__(message)
+        self.retry_after = retry_after
+
+def form_value(value):
+    # Converts a JSON value to the form value it stands for, e.g. true to "true" and objects,
+    # like attributes, to JSON
+    return json.dumps(value) if isinstance(value, (bool, dict, list)) else str(value)
+
+def attribute_matches(attribute_type, value):
+    # Returns whether value, decoded from JSON, is of a category attribute's type
+    if isinstance(attribute_type, list):
+        return value in attribute_type
+    if attribute_type == "bool":
+        return isinstance(value, bool)
+    if isinstance(value, bool):
+        return False # JSON booleans are not numbers
+    if attribute_type == "int":
+        return isinstance(value, int)
+    if attribute_type == "number":
+        return isinstance(value, (int, float))
+    return isinstance(value, str) and len(value) <= MAX_ATTRIBUTE_VALUE_LENGTH
+
+def merge_patch(target, patch):
+    # Applies a JSON merge patch (RFC 7396) to target, returning the result
+    if not isinstance(patch, dict):
+        return patch
+    result = dict(target) if isinstance(target, dict) else {}
+    for name, value in patch.items():
+        if value is None:
+            result.pop(name, None)
+        else:
+            result[name] = merge_patch(result.get(name), value)
+    return result
+
+def now_micros():
+    # Converting current time to microseconds
+    return int(time.time() * 1e6)
+
+class ListingService:
+    """Business rules for listings, on top of a ListingRepository."""
+
+    def __init__(self, repo, categories, default_currency, view_counter, require_approval, report_threshold,
+            max_active_listings, owners=None, orphan_policy="hide", orphan_owner_id=None, image_storage=None):
+        self.repo = repo
+        self.categories = categories
+        self.default_currency = default_currency
+        self.view_counter = view_counter
+        # Whether new listings wait in the moderation queue until an admin approves them
+        self.require_approval = require_approval
+        # Number of reports that moves an approved listing back to the moderation queue; 0 disables it
+        self.report_threshold = report_threshold
+        # Maximum number of active (unexpired, unsold) listings per user; 0 means unlimited
+        self.max_active_listings = max_active_listings
+        # OwnerChecker verifying that listings are created for existing users; None skips the check
+        self.owners = owners
+        # One of ORPHAN_POLICIES, and the user the reassign policy hands listings over to
+        self.orphan_policy = orphan_policy
+        self.orphan_owner_id = orphan_owner_id
+        # Storage for images uploaded along with new listings; None disables uploads
+        self.image_storage = image_storage
+
+    def get_listings(self, page_num, page_size, filters, sort=None):
+        # filters may hold ids, user_id, category_id, near, conditions, available_on and
+        # include_expired; sort is a (field, direction) tuple
+        return self.repo.list(page_num, page_size, self._visible(filters), sort)
+
+    def get_listings_page(self, page_num, page_size, filters, sort=None, cursor=None):
+        # Like get_listings, but also returns the cursor of the next page, or None after the last
+        # page, as (listings, next cursor). Given a cursor, from a previous page of the same
+        # query, the page after it is returned and page_num is ignored. Cursors page by the sort
+        # order, so radius queries ordered by distance only page by page_num
+        keyset = sort is not None or filters.get("near") is None
+        after = None
+        if cursor is not None:
+            if not keyset:
+                raise ValidationError(["cursor cannot be used with near unless sorted"])
+            after = self._decode_cursor(cursor, sort or DEFAULT_SORT)
+        listings = self.repo.list(page_num, page_size, self._visible(filters), sort, after)
+        next_cursor = None
+        if keyset and len(listings) == page_size and page_size > 0:
+            field, direction = sort or DEFAULT_SORT
+            last = listings[-1]
+            next_cursor = base64.urlsafe_b64encode(
+                json.dumps([field, direction, last[field], last["id"]]).encode()
+            ).decode()
+        return listings, next_cursor
+
+    def _decode_cursor(self, cursor, sort):
+        # Returns the (sort value, id) a cursor of a page sorted by sort points after
+        try:
+            field, direction, value, listing_id = json.loads(base64.urlsafe_b64decode(cursor.encode()))
+            if not isinstance(value, int) or not isinstance(listing_id, int):
+                raise ValueError()
+        except (binascii.Error, ValueError, TypeError):
+            raise ValidationError(["invalid cursor"])
+        if (field, direction) != tuple(sort):
+            raise ValidationError(["cursor does not match the sort and order of the query"])
+        return value, listing_id
+
+    def get_listings_geojson(self, page_num, page_size, filters, sort=None, properties=None):
+        # Returns the active listings with coordinates that get_listings would return as a GeoJSON
+        # FeatureCollection. Each feature carries the given properties, DEFAULT_GEOJSON_PROPERTIES
+        # when None
+        if properties is None:
+            properties = DEFAULT_GEOJSON_PROPERTIES
+        invalid = [name for name in properties if name not in GEOJSON_PROPERTIES]
+        if invalid:
+            raise ValidationError(["invalid properties {}. Supported values: {}".format(
+                ", ".join(invalid), ", ".join(GEOJSON_PROPERTIES)
+            )])
+
+        filters = dict(self._visible(filters), located=True, unsold=True)
+        features = []
+        for listing in self.repo.list(page_num, page_size, filters, sort):
+            features.append(dict(
+                type="Feature",
+                id=listing["id"],
+                # GeoJSON positions are [longitude, latitude]
+                geometry=dict(type="Point", coordinates=[listing["longitude"], listing["latitude"]]),
+                properties={name: listing.get(name) for name in properties}
+            ))
+        return dict(type="FeatureCollection", features=features)
+
+    def get_feed_listings(self, count, filters):
+        # Returns the count newest active listings matching filters, for the feeds of new
+        # listings. Feeds always leave out sold and expired listings
+        if count < 1 or count > MAX_FEED_SIZE:
+            raise ValidationError(["invalid page_size. Must be between 1 and {}".format(MAX_FEED_SIZE)])
+        filters = dict(filters, include_expired=False)
+        return self.repo.list(1, count, dict(self._visible(filters), unsold=True))
+
+    def get_listing_facets(self, filters):
+        # Counts the listings get_listings would return across all pages, per listing_type and price bucket
+        return self.repo.facets(self._visible(filters))
+
+    def search_listings(self, query, page_num, page_size, filters, sort=None):
+        # Returns the listings whose title or description match every term of query, best matches first
+        terms = query.split()
+        if not terms or len(query) > MAX_SEARCH_QUERY_LENGTH:
+            raise ValidationError(["q must be between 1 and {} characters long".format(MAX_SEARCH_QUERY_LENGTH)])
+
+        # Quote each term so user input cannot use FTS5 query syntax, and match it as a prefix
+        match = " ".join('"{}"*'.format(term.replace('"', '""')) for term in terms)
+        return self.repo.search(match, page_num, page_size, self._visible(filters), sort)
+
+    def get_featured_listings(self, count, weighted):
+        # Returns a random sample of up to count (DEFAULT_FEATURED_COUNT when None) active listings,
+        # in random order. Weighted samples favor popular listings: they are drawn from a larger
+        # random pool, each candidate weighing 1 + its view count
+        if count is None:
+            count = DEFAULT_FEATURED_COUNT
+        errors = []
+        try:
+            count = int(count)
+        except ValueError:
+            errors.append("invalid count")
+        else:
+            if not 1 <= count <= MAX_FEATURED_COUNT:
+                errors.append("count must be between 1 and {}".format(MAX_FEATURED_COUNT))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        filters = self._visible({})
+        pool_size = count * FEATURED_POOL_FACTOR if weighted else count
+        ids = self.repo.random_ids(pool_size, filters)
+        if not ids:
+            return []
+        listings = self.repo.list(1, len(ids), dict(filters, ids=ids))
+        if weighted:
+            # Weighted sampling without replacement: keep the count largest random()^(1/weight)
+            listings.sort(key=lambda listing: random.random() ** (1 / (1 + listing["view_count"])), reverse=True)
+            return listings[:count]
+        random.shuffle(listings)
+        return listings
+
+    def get_trending_listings(self, window, page_num, page_size, filters):
+        # Returns the listings with the highest trending score in window (a key of trending.WINDOWS),
+        # each with its trending_score, with the same filters as get_listings
+        if window not in trending.WINDOWS:
+            raise ValidationError(["invalid window. Supported values: {}".format(
+                ", ".join("'{}'".format(name) for name in trending.WINDOWS)
+            )])
+
+        scores = dict(self.repo.trending_ids(trending.WINDOWS[window], page_num, page_size, self._visible(filters)))
+        if not scores:
+            return []
+        listings = self.repo.list(1, len(scores), dict(self._visible(filters), ids=list(scores)))
+        for listing in listings:
+            listing["trending_score"] = round(scores[listing["id"]], 3)
+        listings.sort(key=lambda listing: (listing["trending_score"], listing["id"]), reverse=True)
+        return listings
+
+    def get_similar_listings(self, listing_id, count):
+        # Returns up to count (DEFAULT_SIMILAR_COUNT when None) active listings like a visible
+        # listing, most similar first, each with its similarity between 0 and 1. Candidates have
+        # the same listing_type, currency and rental_period and a price within SIMILAR_PRICE_RANGE
+        # of the listing's, and are scored by SIMILARITY_WEIGHTS; location only counts when both
+        # listings have coordinates
+        if count is None:
+            count = DEFAULT_SIMILAR_COUNT
+        try:
+            count = int(count)
+            if not 1 <= count <= MAX_SIMILAR_COUNT:
+                raise ValueError()
+        except ValueError:
+            raise ValidationError(["count must be an integer between 1 and {}".format(MAX_SIMILAR_COUNT)])
+
+        listing = self.repo.get(listing_id)
+        if listing is None or listing["moderation_status"] != APPROVED:
+            raise NotFoundError()
+        price = listing["price"]
+        conditions = [
+            ("listings.id", "!=", listing_id),
+            ("listings.listing_type", "=", listing["listing_type"]),
+            ("listings.currency", "=", listing["currency"]),
+            ("listings.price", ">=", int(price * (1 - SIMILAR_PRICE_RANGE))),
+            ("listings.price", "<=", int(price * (1 + SIMILAR_PRICE_RANGE))),
+        ]
+        if listing["rental_period"] is not None:
+            conditions.append(("listings.rental_period", "=", listing["rental_period"]))
+        filters = dict(self._visible({}), unsold=True, conditions=conditions)
+        candidates = self.repo.list(1, SIMILAR_POOL_SIZE, filters)
+
+        for candidate in candidates:
+            scores = dict(
+                category=1.0 if listing["category"] is not None and candidate["category"] == listing["category"] else 0.0,
+                price=1 - abs(candidate["price"] - price) / (price * SIMILAR_PRICE_RANGE) if price > 0 else 1.0,
+            )
+            if listing["latitude"] is not None and candidate["latitude"] is not None:
+                distance_km = geo.haversine_km(
+                    listing["latitude"], listing["longitude"], candidate["latitude"], candidate["longitude"]
+                )
+                scores["location"] = max(0.0, 1 - distance_km / SIMILAR_RADIUS_KM)
+            total_weight = sum(SIMILARITY_WEIGHTS[name] for name in scores)
+            candidate["similarity"] = round(
+                sum(SIMILARITY_WEIGHTS[name] * score for name, score in scores.items()) / total_weight, 3
+            )
+        candidates.sort(key=lambda candidate: (candidate["similarity"], candidate["id"]), reverse=True)
+        return candidates[:count]
+
+    def get_listing_stats(self, days):
+        # Returns listing totals per listing_type and status, the average and median price of
+        # active listings per listing_type and currency, and the number and average price of the
+        # listings created on each of the last days UTC days, including today, per listing_type
+        # and currency. Everything comes from the rollups of the StatsWorker, as of refreshed_at.
+        # Days without listings are left out
+        if days is None:
+            days = DEFAULT_STATS_DAYS
+        else:
+            try:
+                days = int(days)
+            except ValueError:
+                raise ValidationError(["invalid days"])
+            if not 1 <= days <= MAX_STATS_DAYS:
+                raise ValidationError(["days must be between 1 and {}".format(MAX_STATS_DAYS)])
+
+        summary = dict(
+            total=0,
+            by_type={listing_type: 0 for listing_type in ("rent", "sale")},
+            by_status={status: 0 for status in LISTING_STATUSES},
+            prices=[],
+            refreshed_at=None,
+        )
+        for row in self.repo.status_stats():
+            summary["total"] += row["listing_count"]
+            summary["by_type"][row["listing_type"]] += row["listing_count"]
+            summary["by_status"][row["status"]] += row["listing_count"]
+            summary["refreshed_at"] = max(summary["refreshed_at"] or 0, row["refreshed_at"])
+            if row["status"] == "active":
+                summary["prices"].append(dict(
+                    listing_type=row["listing_type"],
+                    currency=row["currency"],
+                    count=row["listing_count"],
+                    average_price=round(row["price_sum"] / row["listing_count"], 2),
+                    median_price=row["median_price"],
+                ))
+
+        time_now = now_micros()
+        since = time_now - time_now % stats.DAY_MICROS - (days - 1) * stats.DAY_MICROS
+        summary["days"] = [
+            dict(
+                day=row["day"],
+                listing_type=row["listing_type"],
+                currency=row["currency"],
+                count=row["listing_count"],
+                average_price=round(row["price_sum"] / row["listing_count"], 2),
+            )
+            for row in self.repo.daily_stats(since)
+        ]
+        return summary
+
+    def get_price_suggestion(self, params):
+        # Returns the suggested price range (low, median and high) of a listing of listing_type in
+        # currency (the default one when omitted), from the percentiles of recent listings' prices
+        # as of the StatsWorker's last refresh. Rentals are compared per rental_period. When the
+        # category has too few recent listings, the range of every category is returned, with a
+        # null category_id. Returns None without enough listings at all
+        errors = []
+        listing_type = self._validate_listing_type(params.get("listing_type"), errors)
+        currency = self._validate_currency(params.get("currency"), False, errors)
+        category_id = self._validate_category_id(params.get("category_id"), errors)
+        rental_period = params.get("rental_period") or None
+        if rental_period is not None and (listing_type != "rent" or rental_period not in RENTAL_PERIODS):
+            errors.append("rental_period must be one of {}, for rentals only".format(", ".join(RENTAL_PERIODS)))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        suggestion = None
+        if category_id is not None:
+            suggestion = self.repo.price_suggestion(listing_type, category_id, currency, rental_period)
+        if suggestion is None:
+            suggestion = self.repo.price_suggestion(listing_type, None, currency, rental_period)
+        return suggestion
+
+    def get_favorites(self, user_id, page_num, page_size, filters, sort=None):
+        # Returns the listings favorited by user_id, with the same filters as get_listings
+        return self.repo.list_favorites(user_id, page_num, page_size, self._visible(filters), sort)
+
+    @tornado.gen.coroutine
+    def create_listing(self, params, uploads=()):
+        # Validating inputs; params maps parameter names to their raw values. uploads holds the
+        # images sent along with the listing, as dicts with body, content_type and filename
+        upload_errors = self._validate_uploads(uploads)
+        try:
+            values = self._validate_listing(params, for_update=False)
+        except ValidationError as e:
+            raise ValidationError(e.errors + upload_errors)
+        if len(upload_errors) > 0:
+            raise ValidationError(upload_errors)
+        if self.owners is not None:
+            exists = yield self.owners.exists(values["user_id"])
+            if not exists:
+                raise ValidationError(["user_id does not exist"])
+        time_now = now_micros()
+        if self.max_active_listings > 0:
+            if self.repo.count_active_listings(values["user_id"], time_now) >= self.max_active_listings:
+                raise QuotaExceededError(self._quota_message())
+        values["moderation_status"] = PENDING if self.require_approval else APPROVED
+        values["user_name"] = self.repo.get_user_name(values["user_id"])
+
+        # Images are stored before the listing is created, so it never refers to missing blobs.
+        # A failed upload raises ImageStorageError and nothing is created
+        images = []
+        for upload in uploads:
+            width, height = image_dimensions(upload["body"])
+            url = self.image_storage.store(new_image_key(upload["content_type"]), upload["body"], upload["content_type"])
+            images.append(dict(url=url, thumbnail_url=None, width=width, height=height))
+        listing_id = self.repo.create(
+            values, time_now, lambda listing_id: self._created_event(listing_id, values, time_now), images
+        )
+        return self.repo.get(listing_id)
+
+    @tornado.gen.coroutine
+    def import_listings(self, rows):
+        # Validates rows, a list of (row number, params), and creates the valid ones in one
+        # transaction. Returns the number of listings created and a list of (row number, errors)
+        valid = []
+        failed = []
+        user_names = {}
+        active_counts = {} # Active listings per user, including those created by earlier rows
+        time_now = now_micros()
+        for row_number, params in rows:
+            try:
+                values = self._validate_listing(params, for_update=False)
+            except ValidationError as e:
+                failed.append((row_number, e.errors))
+                continue
+            if self.owners is not None:
+                try:
+                    exists = yield self.owners.exists(values["user_id"])
+                    if not exists:
+                        failed.append((row_number, ["user_id does not exist"]))
+                        continue
+                except OwnerCheckUnavailableError:
+                    failed.append((row_number, ["user_id could not be verified, the user service is unavailable"]))
+                    continue
+            if self.max_active_listings > 0:
+                user_id = values["user_id"]
+                if user_id not in active_counts:
+                    active_counts[user_id] = self.repo.count_active_listings(user_id, time_now)
+                if active_counts[user_id] >= self.max_active_listings:
+                    failed.append((row_number, [self._quota_message()]))
+                    continue
+                active_counts[user_id] += 1
+            values["moderation_status"] = PENDING if self.require_approval else APPROVED
+            if values["user_id"] not in user_names:
+                user_names[values["user_id"]] = self.repo.get_user_name(values["user_id"])
+            values["user_name"] = user_names[values["user_id"]]
+            valid.append(values)
+        self.repo.create_many(
+            valid, time_now, lambda listing_id, values: self._created_event(listing_id, values, time_now)
+        )
+        return len(valid), failed
+
+    def update_listing(self, listing_id, params):
+        # Replaces the fields of a listing owned by params["user_id"]
+        values = self._validate_listing(params, for_update=True)
+
+        listing = self._owned_listing(listing_id, values["user_id"])
+        del values["user_id"] # Ownership cannot be changed
+        if values.get("currency") is None:
+            values.pop("currency", None) # Prices keep their currency unless a new one is given
+        return self._save_update(listing, values)
+
+    def patch_listing(self, listing_id, user_id, patch):
+        # Applies a JSON merge patch (RFC 7396) to a listing owned by user_id: fields in patch
+        # are replaced, fields set to null are cleared, and other fields are kept. The patched
+        # listing is validated as a whole, so e.g. a rental's availability window must be
+        # cleared along with switching it to a sale
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if not isinstance(patch, dict):
+            errors.append("the patch must be a JSON object")
+        else:
+            for name in patch:
+                if name not in PATCHABLE_FIELDS:
+                    errors.append("{} cannot be patched. Patchable fields: {}".format(name, ", ".join(PATCHABLE_FIELDS)))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._owned_listing(listing_id, user_id_val)
+
+        # Turn the listing back into params, so the patched listing is validated like an update
+        params = {name: listing[name] for name in PATCHABLE_FIELDS if name != "category_id"}
+        params["category_id"] = listing["category"]["id"] if listing["category"] is not None else None
+        if "expires_at" not in patch:
+            # An unchanged expiry is kept as is, even if it has passed
+            del params["expires_at"]
+        for name, value in patch.items():
+            # Attributes are an object, so the patch is merged into them rather than replacing them
+            params[name] = merge_patch(params[name], value) if name == "attributes" else value
+        if params["currency"] is None:
+            params["currency"] = self.default_currency # Like a listing created without one
+        # Validate JSON values like form values, so e.g. a price of 1.5 or true is rejected;
+        # absent required fields fail validation like empty values
+        params = {name: form_value(value) for name, value in params.items() if value is not None}
+        for name in ("listing_type", "price"):
+            params.setdefault(name, "")
+        params["user_id"] = str(user_id_val)
+
+        values = self._validate_listing(params, for_update=True)
+        del values["user_id"]
+        if "expires_at" not in patch:
+            del values["expires_at"]
+            del values["expired_at"]
+        return self._save_update(listing, values)
+
+    def bump_listing(self, listing_id, user_id, extend_days=None):
+        # Refreshes the updated_at of a listing owned by user_id, moving it to the top of results
+        # sorted by updated_at, at most once per BUMP_INTERVAL. With extend_days, also pushes its
+        # expires_at back by that many days from the later of now and the current expiry,
+        # renewing it if it had expired
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if extend_days is not None:
+            try:
+                extend_days = int(extend_days)
+            except ValueError:
+                errors.append("invalid extend_days")
+            else:
+                if not 1 <= extend_days <= MAX_BUMP_EXTEND_DAYS:
+                    errors.append("extend_days must be between 1 and {}".format(MAX_BUMP_EXTEND_DAYS))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._owned_listing(listing_id, user_id_val)
+        time_now = now_micros()
+        values = {}
+        if extend_days is not None:
+            if listing["expires_at"] is None:
+                raise ValidationError(["the listing never expires, so its expiry cannot be extended"])
+            values["expires_at"] = max(listing["expires_at"], time_now) + extend_days * 24 * 3600 * 1000000
+            values["expired_at"] = None
+
+        if not self.repo.bump(listing_id, values, time_now, time_now - BUMP_INTERVAL):
+            bumped_at = self.repo.get_bumped_at(listing_id)
+            if bumped_at is None:
+                raise NotFoundError()
+            raise RateLimitedError(
+                "the listing can only be bumped once a day",
+                max(1, (bumped_at + BUMP_INTERVAL - time_now + 999999) // 1000000)
+            )
+        return self.repo.get(listing_id)
+
+    def _save_update(self, listing, values):
+        # Writes the validated column values of an update of listing, returning the updated listing
+        if listing["moderation_status"] == REJECTED:
+            # Updating a rejected listing resubmits it for moderation
+            values["moderation_status"] = PENDING
+            values["moderation_reason"] = None
+
+        # Emit listing.updated with the fields that actually changed, and listing.price_dropped
+        # when the price went down in the same currency
+        time_now = now_micros()
+        changed = [column for column, value in values.items() if value != self._column_value(listing, column)]
+        update_events = []
+        if changed:
+            update_events.append(events.new_event(events.LISTING_UPDATED, dict(
+                listing_id=listing["id"],
+                user_id=listing["user_id"],
+                changed=changed,
+                updated_at=time_now
+            )))
+        if values.get("currency", listing["currency"]) == listing["currency"] and values["price"] < listing["price"]:
+            update_events.append(events.new_event(events.LISTING_PRICE_DROPPED, dict(
+                listing_id=listing["id"],
+                user_id=listing["user_id"],
+                old_price=listing["price"],
+                new_price=values["price"],
+                currency=listing["currency"]
+            )))
+        if not self.repo.update(listing["id"], values, time_now, update_events):
+            raise NotFoundError()
+        return self.repo.get(listing["id"])
+
+    def mark_listing_sold(self, listing_id, user_id):
+        # Marks a sale listing owned by user_id as sold and emits listing.sold. The listing stays
+        # listed, with its sold_at set, and can only be sold once
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._owned_listing(listing_id, user_id_val)
+        if listing["listing_type"] != "sale":
+            raise ValidationError(["only sale listings can be marked as sold"])
+        if listing["sold_at"] is not None:
+            raise ConflictError("listing is already sold")
+
+        time_now = now_micros()
+        event = events.new_event(events.LISTING_SOLD, dict(
+            listing_id=listing_id,
+            user_id=user_id_val,
+            price=listing["price"],
+            currency=listing["currency"],
+            sold_at=time_now
+        ))
+        if not self.repo.mark_sold(listing_id, time_now, event):
+            if self.repo.get(listing_id) is None:
+                raise NotFoundError()
+            raise ConflictError("listing is already sold")
+        return self.repo.get(listing_id)
+
+    def _quota_message(self):
+        return "user_id already has {} active listings, the most allowed".format(self.max_active_listings)
+
+    def _created_event(self, listing_id, values, time_now):
+        return events.new_event(events.LISTING_CREATED, dict(
+            listing_id=listing_id,
+            user_id=values["user_id"],
+            listing_type=values["listing_type"],
+            price=values["price"],
+            currency=values["currency"],
+            moderation_status=values["moderation_status"],
+            created_at=time_now
+        ))
+
+    def _column_value(self, listing, column):
+        # Returns the value of column in listing, as returned by the repository
+        if column == "category_id":
+            return listing["category"]["id"] if listing["category"] is not None else None
+        return listing[column]
+
+    def delete_listing(self, listing_id, user_id):
+        # Deletes a listing owned by user_id and emits listing.deleted
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        self._owned_listing(listing_id, user_id_val)
+
+        event = events.new_event(events.LISTING_DELETED, dict(
+            listing_id=listing_id,
+            user_id=user_id_val,
+            deleted_at=now_micros()
+        ))
+        if not self.repo.delete(listing_id, event):
+            raise NotFoundError()
+
+    def add_image(self, listing_id, params):
+        # Appends an image to a listing owned by params["user_id"], returning the updated listing
+        errors = []
+        user_id = self._validate_user_id(params["user_id"], errors)
+        values = dict(
+            url=self._validate_image_url("url", params["url"], errors),
+            thumbnail_url=self._validate_image_url("thumbnail_url", params.get("thumbnail_url"), errors, optional=True),
+            width=self._validate_dimension("width", params.get("width"), errors),
+            height=self._validate_dimension("height", params.get("height"), errors),
+        )
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        self._owned_listing(listing_id, user_id)
+        if self.repo.count_images(listing_id) >= MAX_IMAGES_PER_LISTING:
+            raise ConflictError("a listing can have at most {} images".format(MAX_IMAGES_PER_LISTING))
+        self.repo.add_image(listing_id, values, now_micros())
+        return self.repo.get(listing_id)
+
+    def reorder_images(self, listing_id, user_id, image_ids):
+        # Reorders the images of a listing owned by user_id. image_ids is a comma-separated
+        # list of the ids of all its images, in their new order
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        try:
+            image_ids = [int(image_id) for image_id in image_ids.split(",")]
+        except ValueError:
+            errors.append("invalid image_ids. Must be a comma-separated list of image ids")
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        self._owned_listing(listing_id, user_id_val)
+        if sorted(image_ids) != sorted(self.repo.image_ids(listing_id)):
+            raise ValidationError(["image_ids must list every image of the listing exactly once"])
+        self.repo.reorder_images(listing_id, image_ids, now_micros())
+        return self.repo.get(listing_id)
+
+    def remove_image(self, listing_id, image_id, user_id):
+        # Removes an image from a listing owned by user_id, returning the updated listing
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        self._owned_listing(listing_id, user_id_val)
+        if not self.repo.delete_image(listing_id, image_id, now_micros()):
+            raise NotFoundError()
+        return self.repo.get(listing_id)
+
+    def expire_due_listings(self, limit):
+        # Marks up to limit listings whose expires_at has passed as expired, emitting listing.expired
+        # for each. Returns how many were expired
+        time_now = now_micros()
+        count = 0
+        for listing in self.repo.fetch_due_expirations(time_now, limit):
+            event = events.new_event(events.LISTING_EXPIRED, dict(
+                listing_id=listing["id"],
+                user_id=listing["user_id"],
+                expires_at=listing["expires_at"],
+                expired_at=time_now
+            ))
+            if self.repo.mark_expired(listing["id"], time_now, event):
+                count += 1
+        return count
+
+    def archive_sold_listings(self, archive_after_days, limit):
+        # Moves up to limit listings sold more than archive_after_days days ago to the archive,
+        # emitting listing.archived for each. Returns how many were archived
+        time_now = now_micros()
+        count = 0
+        for listing in self.repo.fetch_due_archivals(time_now - archive_after_days * stats.DAY_MICROS, limit):
+            event = events.new_event(events.LISTING_ARCHIVED, dict(
+                listing_id=listing["id"],
+                user_id=listing["user_id"],
+                sold_at=listing["sold_at"],
+                archived_at=time_now
+            ))
+            if self.repo.archive(listing["id"], time_now, event):
+                count += 1
+        return count
+
+    def get_archived_listings(self, page_num, page_size, filters):
+        # Returns archived listings, most recently sold first. filters may hold user_id and category_id
+        return self.repo.list_archived(page_num, page_size, filters)
+
+    def get_archived_listing(self, listing_id):
+        listing = self.repo.get_archived(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        return listing
+
+    def create_promotion(self, listing_id, params):
+        # Schedules a discount of a listing owned by user_id between starts_at (now when omitted)
+        # and ends_at. While it runs, reads return the discounted effective_price next to price
+        errors = []
+        user_id_val = self._validate_user_id(params.get("user_id"), errors)
+        discount_percent = None
+        try:
+            discount_percent = int(params.get("discount_percent"))
+            if not 1 <= discount_percent <= MAX_DISCOUNT_PERCENT:
+                raise ValueError()
+        except (TypeError, ValueError):
+            errors.append("discount_percent must be an integer between 1 and {}".format(MAX_DISCOUNT_PERCENT))
+        time_now = now_micros()
+        starts_at = self._validate_promotion_time("starts_at", params.get("starts_at") or time_now, time_now, errors)
+        ends_at = self._validate_promotion_time("ends_at", params.get("ends_at"), time_now, errors)
+        if starts_at is not None and ends_at is not None:
+            if ends_at <= starts_at:
+                errors.append("ends_at must be after starts_at")
+            elif ends_at - starts_at > MAX_PROMOTION_DAYS * stats.DAY_MICROS:
+                errors.append("promotions may last at most {} days".format(MAX_PROMOTION_DAYS))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._owned_listing(listing_id, user_id_val)
+        if listing["sold_at"] is not None:
+            raise ConflictError("sold listings cannot be promoted")
+        promotion_id = self.repo.create_promotion(listing_id, discount_percent, starts_at, ends_at, time_now)
+        if promotion_id is None:
+            raise ConflictError("the listing already has a promotion during this time")
+        return self.repo.get_promotion(promotion_id)
+
+    def get_promotions(self, listing_id):
+        # Returns the promotions of a visible listing, past, current and scheduled
+        if self.repo.get(listing_id) is None:
+            raise NotFoundError()
+        return self.repo.list_promotions(listing_id)
+
+    def cancel_promotion(self, listing_id, promotion_id, user_id):
+        # Ends a current or scheduled promotion of a listing owned by user_id
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        self._owned_listing(listing_id, user_id_val)
+        promotion = self.repo.get_promotion(promotion_id)
+        if promotion is None or promotion["listing_id"] != listing_id:
+            raise NotFoundError()
+        if not self.repo.end_promotion(promotion_id, now_micros()):
+            raise ConflictError("the promotion already ended")
+        return self.repo.get_promotion(promotion_id)
+
+    def expire_due_promotions(self, limit):
+        # Ends up to limit promotions whose window has passed, emitting listing.promotion_ended
+        # for each. Returns how many were ended
+        time_now = now_micros()
+        count = 0
+        for promotion in self.repo.fetch_due_promotions(time_now, limit):
+            event = events.new_event(events.LISTING_PROMOTION_ENDED, dict(
+                promotion_id=promotion["id"],
+                listing_id=promotion["listing_id"],
+                user_id=promotion["user_id"],
+                discount_percent=promotion["discount_percent"],
+                ends_at=promotion["ends_at"],
+                ended_at=time_now
+            ))
+            if self.repo.mark_promotion_ended(promotion["id"], time_now, event):
+                count += 1
+        return count
+
+    def hold_listing(self, listing_id, params):
+        # Reserves an active listing owned by params["user_id"] for params["buyer_id"] during
+        # params["minutes"] minutes, emitting listing.held. A listing has one hold at a time
+        errors = []
+        user_id_val = self._validate_user_id(params.get("user_id"), errors)
+        buyer_id = None
+        try:
+            buyer_id = int(params.get("buyer_id"))
+        except (TypeError, ValueError):
+            errors.append("invalid buyer_id")
+        minutes = None
+        try:
+            minutes = int(params.get("minutes"))
+            if not 1 <= minutes <= MAX_HOLD_MINUTES:
+                raise ValueError()
+        except (TypeError, ValueError):
+            errors.append("minutes must be an integer between 1 and {}".format(MAX_HOLD_MINUTES))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._owned_listing(listing_id, user_id_val)
+        if buyer_id == user_id_val:
+            raise ValidationError(["users cannot hold their own listings for themselves"])
+        self._offerable_listing(listing_id)
+        time_now = now_micros()
+        # A lapsed hold the ExpiryWorker has not released yet does not block a new one
+        hold = self.repo.get_unreleased_hold(listing_id)
+        if hold is not None and hold["expires_at"] <= time_now:
+            self._release_hold(hold, listing["user_id"], HOLD_EXPIRED, time_now, lapsed_only=True)
+        expires_at = time_now + minutes * 60 * 1000000
+        held_event = lambda hold_id: events.new_event(events.LISTING_HELD, dict(
+            hold_id=hold_id,
+            listing_id=listing_id,
+            user_id=user_id_val,
+            buyer_id=buyer_id,
+            expires_at=expires_at
+        ))
+        try:
+            hold_id = self.repo.create_hold(listing_id, buyer_id, expires_at, time_now, held_event)
+        except DuplicateError:
+            raise ConflictError("the listing is already held")
+        return self.repo.get_hold(hold_id)
+
+    def release_listing_hold(self, listing_id, user_id):
+        # Releases the active hold of a listing before it lapses, emitting listing.hold_released.
+        # The listing's owner and the buyer it is held for may release it
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self.repo.get(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        hold = self.repo.get_unreleased_hold(listing_id)
+        if hold is None or hold["expires_at"] <= now_micros():
+            raise NotFoundError()
+        if user_id_val not in (listing["user_id"], hold["buyer_id"]):
+            raise ForbiddenError()
+        if not self._release_hold(hold, listing["user_id"], HOLD_RELEASED, now_micros()):
+            raise NotFoundError()
+        return self.repo.get_hold(hold["id"])
+
+    def expire_due_holds(self, limit):
+        # Releases up to limit holds past their expires_at, emitting listing.hold_released for
+        # each. Returns how many were released
+        time_now = now_micros()
+        count = 0
+        for hold in self.repo.fetch_due_holds(time_now, limit):
+            if self._release_hold(hold, hold["user_id"], HOLD_EXPIRED, time_now, lapsed_only=True):
+                count += 1
+        return count
+
+    def _release_hold(self, hold, owner_id, reason, time_now, lapsed_only=False):
+        event = events.new_event(events.LISTING_HOLD_RELEASED, dict(
+            hold_id=hold["id"],
+            listing_id=hold["listing_id"],
+            user_id=owner_id,
+            buyer_id=hold["buyer_id"],
+            reason=reason,
+            released_at=time_now
+        ))
+        return self.repo.release_hold(hold["id"], time_now, reason, event, lapsed_only)
+
+    def make_offer(self, listing_id, params):
+        # Records an offer of amount, in the listing's currency, by a buyer on an active listing
+        # of another user. A buyer has at most one open offer per listing
+        errors = []
+        buyer_id = self._validate_user_id(params.get("user_id"), errors)
+        amount = self._validate_offer_amount(params.get("amount"), errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self._offerable_listing(listing_id)
+        if listing["user_id"] == buyer_id:
+            raise ValidationError(["users cannot make offers on their own listings"])
+        values = dict(
+            listing_id=listing_id,
+            buyer_id=buyer_id,
+            amount=amount,
+            currency=listing["currency"],
+            status=OFFER_PENDING
+        )
+        try:
+            offer_id = self.repo.create_offer(values, now_micros())
+        except DuplicateError:
+            raise ConflictError("user_id already has an open offer on this listing")
+        return self.repo.get_offer(offer_id)
+
+    def get_offers(self, listing_id, user_id):
+        # Returns every offer on a listing to its owner, and their own offers to other users
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self.repo.get(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        if listing["user_id"] == user_id_val:
+            return self.repo.list_offers(listing_id)
+        return self.repo.list_offers(listing_id, buyer_id=user_id_val)
+
+    def respond_to_offer(self, offer_id, action, user_id, amount=None):
+        # Accepts, rejects or counters an open offer with amount. The listing's owner responds to
+        # pending offers and the buyer to countered ones; countering hands the offer over to the
+        # other party. Accepting emits offer.accepted, e.g. for a booking service to take over
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if action == "counter":
+            amount = self._validate_offer_amount(amount, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        offer = self.repo.get_offer(offer_id)
+        if offer is None:
+            raise NotFoundError()
+        listing = self.repo.get(offer["listing_id"])
+        if listing is None:
+            raise NotFoundError()
+        if user_id_val not in (listing["user_id"], offer["buyer_id"]):
+            raise ForbiddenError()
+        if offer["status"] not in (OFFER_PENDING, OFFER_COUNTERED):
+            raise ConflictError("offer is already {}".format(offer["status"]))
+        responder_id = listing["user_id"] if offer["status"] == OFFER_PENDING else offer["buyer_id"]
+        if user_id_val != responder_id:
+            raise ConflictError("offer is waiting for the {} to respond".format(
+                "listing's owner" if offer["status"] == OFFER_PENDING else "buyer"
+            ))
+
+        time_now = now_micros()
+        event = None
+        if action == "accept":
+            if listing["sold_at"] is not None:
+                raise ConflictError("listing is already sold")
+            to_status, amount = OFFER_ACCEPTED, offer["amount"]
+            event = events.new_event(events.OFFER_ACCEPTED, dict(
+                offer_id=offer_id,
+                listing_id=listing["id"],
+                seller_id=listing["user_id"],
+                buyer_id=offer["buyer_id"],
+                amount=amount,
+                currency=offer["currency"],
+                accepted_at=time_now
+            ))
+        elif action == "reject":
+            to_status, amount = OFFER_REJECTED, offer["amount"]
+        else:
+            to_status = OFFER_COUNTERED if offer["status"] == OFFER_PENDING else OFFER_PENDING
+
+        try:
+            changed = self.repo.transition_offer(offer_id, offer["status"], to_status, amount, time_now, event)
+        except DuplicateError:
+            raise ConflictError("listing already has an accepted offer")
+        if not changed:
+            raise ConflictError("offer was updated meanwhile")
+        return self.repo.get_offer(offer_id)
+
+    def _offerable_listing(self, listing_id):
+        # Returns a listing open to offers: publicly visible and unsold
+        listing = self.repo.get(listing_id)
+        if listing is None or listing["moderation_status"] != APPROVED:
+            raise NotFoundError()
+        if listing["expires_at"] is not None and listing["expires_at"] <= now_micros():
+            raise ConflictError("listing has expired")
+        if listing["sold_at"] is not None:
+            raise ConflictError("listing is already sold")
+        return listing
+
+    def _validate_offer_amount(self, amount, errors):
+        try:
+            amount = int(amount)
+        except (TypeError, ValueError):
+            errors.append("invalid amount. Must be an integer")
+            return None
+        if amount < 1:
+            errors.append("amount must be greater than 0")
+            return None
+        if amount > MAX_AMOUNT:
+            errors.append("amount must be at most {}".format(MAX_AMOUNT))
+            return None
+        return amount
+
+    def get_orphaned_listings(self, page_num, page_size):
+        # Returns the listings of deleted users, whatever the orphan policy did with them
+        return self.repo.list_orphaned(page_num, page_size)
+
+    def get_pending_listings(self, page_num, page_size):
+        # Returns the listings waiting for moderation, oldest first
+        return self.repo.list(page_num, page_size, dict(moderation_status=PENDING), ("created_at", "asc"))
+
+    def get_reported_listings(self, page_num, page_size):
+        # Returns the listings with reports since they were last moderated, most reported first
+        return self.repo.list_reported(page_num, page_size)
+
+    def get_webhook_deliveries(self, status, page_num, page_size):
+        # Returns a page of the webhook delivery log, newest first, optionally only those with status
+        if status is not None and status not in WEBHOOK_STATUSES:
+            raise ValidationError(["invalid status. Supported values: {}".format(
+                ", ".join("'{}'".format(status) for status in WEBHOOK_STATUSES)
+            )])
+        return self.repo.list_webhook_deliveries(status, page_num, page_size)
+
+    def approve_listing(self, listing_id):
+        return self._moderate(listing_id, APPROVED, None, events.LISTING_APPROVED)
+
+    def reject_listing(self, listing_id, reason):
+        reason = reason.strip()
+        if not reason or len(reason) > MAX_REJECTION_REASON_LENGTH:
+            raise ValidationError(["reason must be between 1 and {} characters long".format(MAX_REJECTION_REASON_LENGTH)])
+        return self._moderate(listing_id, REJECTED, reason, events.LISTING_REJECTED)
+
+    def report_listing(self, listing_id, user_id, reason):
+        # Records an abuse report by user_id. Once a listing collects report_threshold reports
+        # since it was last moderated, it goes back to the moderation queue
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        reason = reason.strip()
+        if not reason or len(reason) > MAX_REPORT_REASON_LENGTH:
+            errors.append("reason must be between 1 and {} characters long".format(MAX_REPORT_REASON_LENGTH))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        listing = self.repo.get(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        try:
+            self.repo.add_report(listing_id, user_id_val, reason, now_micros())
+        except DuplicateError:
+            raise ConflictError("listing was already reported by user_id")
+
+        if self.report_threshold > 0 and listing["moderation_status"] == APPROVED:
+            reports = self.repo.count_open_reports(listing_id)
+            if reports >= self.report_threshold:
+                self._moderate(listing_id, PENDING, "reported by {} users".format(reports), events.LISTING_FLAGGED)
+                logging.info("Queued listing {} for moderation after {} reports".format(listing_id, reports))
+
+    def get_reports(self, listing_id):
+        if self.repo.get(listing_id) is None:
+            raise NotFoundError()
+        return self.repo.list_reports(listing_id)
+
+    def _moderate(self, listing_id, status, reason, event_type):
+        # Sets the moderation status of a listing and emits event_type, returning the updated listing
+        listing = self.repo.get(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        time_now = now_micros()
+        event = events.new_event(event_type, dict(
+            listing_id=listing_id,
+            user_id=listing["user_id"],
+            status=status,
+            reason=reason,
+            moderated_at=time_now
+        ))
+        if not self.repo.moderate(listing_id, status, reason, time_now, event):
+            raise NotFoundError()
+        return self.repo.get(listing_id)
+
+    def record_view(self, listing_id, user_id=None):
+        # Counts a view of a visible listing, by user_id if given. The count is buffered and
+        # written in batches, along with a listing.viewed event per viewing user
+        user_id_val = None
+        if user_id is not None and user_id != "":
+            user_id_val = self._validate_favorite_user_id(user_id)
+        if self.repo.get(listing_id) is None:
+            raise NotFoundError()
+        self.view_counter.record(listing_id, user_id_val)
+
+    def add_favorite(self, listing_id, user_id):
+        # Favorites a visible listing for user_id, returning the listing. Favoriting twice is a no-op
+        user_id_val = self._validate_favorite_user_id(user_id)
+        if self.repo.get(listing_id) is None:
+            raise NotFoundError()
+        time_now = now_micros()
+        self.repo.add_favorite(listing_id, user_id_val, time_now, events.new_event(events.LISTING_FAVORITED, dict(
+            listing_id=listing_id,
+            user_id=user_id_val,
+            favorited_at=time_now
+        )))
+        return self.repo.get(listing_id)
+
+    def remove_favorite(self, listing_id, user_id):
+        # Unfavorites a visible listing for user_id, returning the listing. Unfavoriting twice is a no-op
+        user_id_val = self._validate_favorite_user_id(user_id)
+        if self.repo.get(listing_id) is None:
+            raise NotFoundError()
+        self.repo.remove_favorite(listing_id, user_id_val, events.new_event(events.LISTING_UNFAVORITED, dict(
+            listing_id=listing_id,
+            user_id=user_id_val,
+            unfavorited_at=now_micros()
+        )))
+        return self.repo.get(listing_id)
+
+    def create_template(self, user_id, name, params):
+        # Saves the listing fields in params as a template of user_id. The fields are validated
+        # like a new listing, so listing_type and price are required
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        name = name.strip()
+        if not name or len(name) > MAX_TEMPLATE_NAME_LENGTH:
+            errors.append("name must be between 1 and {} characters long".format(MAX_TEMPLATE_NAME_LENGTH))
+        for field in params:
+            if field != "user_id" and field not in TEMPLATE_FIELDS:
+                errors.append("{} cannot be saved in a template. Template fields: {}".format(field, ", ".join(TEMPLATE_FIELDS)))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        values = self._validate_listing(params, for_update=False)
+        fields = {field: values[field] for field in TEMPLATE_FIELDS}
+        try:
+            template_id = self.repo.create_template(user_id_val, name, fields, now_micros())
+        except DuplicateError:
+            raise ConflictError("user_id already has a template with this name")
+        return self.repo.get_template(template_id)
+
+    def get_templates(self, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return self.repo.list_templates(user_id_val)
+
+    def delete_template(self, template_id, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        self._owned_template(template_id, user_id_val)
+        if not self.repo.delete_template(template_id):
+            raise NotFoundError()
+
+    @tornado.gen.coroutine
+    def create_listing_from_template(self, template_id, user_id, overrides):
+        # Creates a listing for user_id from one of their templates. Fields in overrides replace
+        # those of the template, and empty ones clear them; the result is validated like any
+        # new listing
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        template = self._owned_template(template_id, user_id_val)
+        params = {name: form_value(value) for name, value in template["fields"].items() if value is not None}
+        params.update(overrides)
+        params["user_id"] = str(user_id_val)
+        listing = yield self.create_listing(params)
+        return listing
+
+    def _owned_template(self, template_id, user_id):
+        # Returns the template, raising NotFoundError if it is missing and ForbiddenError if
+        # user_id does not own it
+        template = self.repo.get_template(template_id)
+        if template is None:
+            raise NotFoundError()
+        if template["user_id"] != user_id:
+            raise ForbiddenError()
+        return template
+
+    def save_draft(self, draft_id, user_id, data):
+        # Creates or replaces the draft draft_id of user_id. data is any JSON object: drafts hold
+        # unfinished forms, so their fields are only validated when a listing is created
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if not DRAFT_ID_PATTERN.match(draft_id):
+            errors.append("invalid draft_id. Must be 1 to 64 letters, digits, - or _")
+        if not isinstance(data, dict):
+            errors.append("draft must be a JSON object")
+        elif len(json.dumps(data)) > MAX_DRAFT_BYTES:
+            errors.append("draft must be at most {} bytes".format(MAX_DRAFT_BYTES))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        if self.repo.get_draft(user_id_val, draft_id) is None:
+            if self.repo.count_drafts(user_id_val) >= MAX_DRAFTS_PER_USER:
+                raise ConflictError("user_id already has {} drafts, the maximum".format(MAX_DRAFTS_PER_USER))
+        self.repo.save_draft(user_id_val, draft_id, data, now_micros())
+        return self.repo.get_draft(user_id_val, draft_id)
+
+    def get_draft(self, draft_id, user_id):
+        # Returns the draft draft_id of user_id, raising NotFoundError if they have none
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        draft = self.repo.get_draft(user_id_val, draft_id)
+        if draft is None:
+            raise NotFoundError()
+        return draft
+
+    def get_drafts(self, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return self.repo.list_drafts(user_id_val)
+
+    def delete_draft(self, draft_id, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        if not self.repo.delete_draft(user_id_val, draft_id):
+            raise NotFoundError()
+
+    @tornado.gen.coroutine
+    def create_listing_from_draft(self, draft_id, user_id, overrides):
+        # Creates a listing for user_id from one of their drafts, which is then deleted. Draft
+        # keys that are not listing fields, e.g. form state, are ignored, and fields in overrides
+        # replace those of the draft. The result is validated like any new listing, and the
+        # draft is kept if it is invalid so the user can fix it
+        draft = self.get_draft(draft_id, user_id)
+        params = {
+            name: form_value(value) for name, value in draft["data"].items()
+            if name in PATCHABLE_FIELDS and value is not None
+        }
+        params.update(overrides)
+        params["user_id"] = str(draft["user_id"])
+        missing = [name for name in ("listing_type", "price") if name not in params]
+        if len(missing) > 0:
+            raise ValidationError(["{} is required".format(name) for name in missing])
+        listing = yield self.create_listing(params)
+        self.repo.delete_draft(draft["user_id"], draft_id)
+        return listing
+
+    def create_saved_search(self, params):
+        # Saves search criteria for user_id, who is notified of new listings matching them. Every
+        # criterion is optional, but at least one is required
+        errors = []
+        user_id_val = self._validate_user_id(params.get("user_id"), errors)
+        values = {}
+        if params.get("listing_type"):
+            values["listing_type"] = self._validate_listing_type(params["listing_type"], errors)
+        if params.get("currency"):
+            values["currency"] = self._validate_currency(params["currency"], True, errors)
+        for name in ("min_price", "max_price"):
+            if params.get(name):
+                price = self._validate_price(params[name], [])
+                if price is None:
+                    errors.append("invalid {}. Must be a positive integer".format(name))
+                values[name] = price
+        if values.get("min_price") and values.get("max_price") and values["min_price"] > values["max_price"]:
+            errors.append("min_price must not be greater than max_price")
+        if params.get("category_id"):
+            values["category_id"] = self._validate_category_id(params["category_id"], errors)
+        if not values and len(errors) == 0:
+            errors.append("at least one of listing_type, currency, min_price, max_price and category_id is required")
+        if len(errors) > 0:
+            raise ValidationError(errors)
+
+        if self.repo.count_saved_searches(user_id_val) >= MAX_SAVED_SEARCHES:
+            raise ConflictError("user_id already has {} saved searches".format(MAX_SAVED_SEARCHES))
+        values["user_id"] = user_id_val
+        saved_search_id = self.repo.create_saved_search(values, now_micros())
+        return self.repo.get_saved_search(saved_search_id)
+
+    def get_saved_searches(self, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return self.repo.list_saved_searches(user_id_val)
+
+    def delete_saved_search(self, saved_search_id, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        saved_search = self.repo.get_saved_search(saved_search_id)
+        if saved_search is None:
+            raise NotFoundError()
+        if saved_search["user_id"] != user_id_val:
+            raise ForbiddenError()
+        if not self.repo.delete_saved_search(saved_search_id):
+            raise NotFoundError()
+
+    def match_saved_searches(self, limit):
+        # Matches up to limit new approved listings against saved searches, emitting
+        # saved_search.matched for each match. Returns how many listings were matched
+        time_now = now_micros()
+        def matched_event(row):
+            return events.new_event(events.SAVED_SEARCH_MATCHED, dict(
+                saved_search_id=row["saved_search_id"],
+                user_id=row["saved_search_user_id"],
+                listing_id=row["id"],
+                listing_type=row["listing_type"],
+                price=row["price"],
+                currency=row["currency"],
+                matched_at=time_now
+            ))
+        return self.repo.match_saved_searches(APPROVED, time_now, limit, matched_event)
+
+    def on_user_renamed(self, user_id, name, updated_at):
+        # Keeps the user_name of the user's listings in sync; user.created and user.updated events
+        # both carry the current name, and stale events are ignored
+        count = self.repo.set_user_name(user_id, name, updated_at)
+        if count > 0:
+            logging.info("Renamed {} listings of user {}".format(count, user_id))
+
+    def on_user_merged(self, source_user_id, target_user_id):
+        # Listings of the merged (duplicate) account now belong to the account it was merged into
+        if self.owners is not None:
+            self.owners.forget(source_user_id) # The merged account is deleted
+        count = self.repo.reassign_user(source_user_id, target_user_id, now_micros())
+        logging.info("Reassigned {} listings from user {} to user {}".format(count, source_user_id, target_user_id))
+
+    def on_user_deleted(self, user_id, deleted_at):
+        # Listings of a deleted user are orphaned according to the orphan policy: by default they
+        # are hidden so they no longer show up with a dangling owner. Their favorites no longer
+        # count and their templates, drafts, saved searches and archived listings are deleted
+        if self.owners is not None:
+            self.owners.forget(user_id)
+        if self.orphan_policy == "reassign":
+            count = self.repo.orphan_user_listings(user_id, deleted_at, self.orphan_owner_id)
+            logging.info("Reassigned {} listings of deleted user {} to user {}".format(count, user_id, self.orphan_owner_id))
+        elif self.orphan_policy == "orphan":
+            count = self.repo.orphan_user_listings(user_id, deleted_at)
+            logging.info("Marked {} listings of deleted user {} as orphaned".format(count, user_id))
+        else:
+            count = self.repo.hide_user_listings(user_id, deleted_at)
+            logging.info("Hid {} listings of deleted user {}".format(count, user_id))
+        count = self.repo.delete_user_favorites(user_id)
+        logging.info("Deleted {} favorites of deleted user {}".format(count, user_id))
+        count = self.repo.delete_user_templates(user_id)
+        logging.info("Deleted {} listing templates of deleted user {}".format(count, user_id))
+        count = self.repo.delete_user_drafts(user_id)
+        logging.info("Deleted {} listing drafts of deleted user {}".format(count, user_id))
+        count = self.repo.delete_user_saved_searches(user_id)
+        logging.info("Deleted {} saved searches of deleted user {}".format(count, user_id))
+        count = self.repo.delete_user_archived_listings(user_id)
+        logging.info("Deleted {} archived listings of deleted user {}".format(count, user_id))
+
+    def _validate_favorite_user_id(self, user_id):
+        errors = []
+        user_id_val = self._validate_user_id(user_id, errors)
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return user_id_val
+
+    def _visible(self, filters):
+        # Public reads only return approved listings, and leave out expired ones unless include_expired is set
+        filters = dict(filters, moderation_status=APPROVED)
+        if not filters.get("include_expired"):
+            filters["active_at"] = now_micros()
+        return filters
+
+    def _owned_listing(self, listing_id, user_id):
+        # Returns the listing, raising NotFoundError if it is missing or hidden
+        # and ForbiddenError if user_id does not own it
+        listing = self.repo.get(listing_id)
+        if listing is None:
+            raise NotFoundError()
+        if listing["user_id"] != user_id:
+            raise ForbiddenError()
+        return listing
+
+    def _validate_listing(self, params, for_update):
+        # Returns the column values for a listing, raising ValidationError listing every problem
+        errors = []
+        values = dict(
+            user_id=self._validate_user_id(params["user_id"], errors),
+            listing_type=self._validate_listing_type(params["listing_type"], errors),
+            price=self._validate_price(params["price"], errors),
+            currency=self._validate_currency(params.get("currency"), for_update, errors),
+            title=self._validate_text("title", params.get("title", ""), MAX_TITLE_LENGTH, errors),
+            description=self._validate_text("description", params.get("description", ""), MAX_DESCRIPTION_LENGTH, errors),
+            category_id=self._validate_category_id(params.get("category_id"), errors),
+        )
+        values["latitude"], values["longitude"] = self._validate_location(
+            params.get("latitude"), params.get("longitude"), errors
+        )
+        values["expires_at"] = self._validate_expires_at(params.get("expires_at"), errors)
+        values["available_from"], values["available_to"] = self._validate_availability(
+            values["listing_type"], params.get("available_from"), params.get("available_to"), errors
+        )
+        values.update(self._validate_type_fields(values["listing_type"], params, errors))
+        if values["category_id"] is not None or not params.get("category_id"):
+            # Attributes are only checked against a valid category, or the lack of one
+            values["attributes"] = self._validate_attributes(params.get("attributes"), values["category_id"], errors)
+        if for_update:
+            values["expired_at"] = None # A new expiry starts over
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return values
+
+    def _validate_category_id(self, category_id, errors):
+        # Listings may be uncategorized; otherwise the category must exist
+        if category_id is None or category_id == "":
+            return None
+        try:
+            category_id = int(category_id)
+        except ValueError:
+            errors.append("invalid category_id")
+            return None
+        if self.categories.get(category_id) is None:
+            errors.append("category {} does not exist".format(category_id))
+            return None
+        return category_id
+
+    def _validate_attributes(self, attributes, category_id, errors):
+        # Attributes are a JSON object of the attributes of the listing's category, e.g.
+        # {"bedrooms": 2, "furnished": true}; null values are left out
+        if attributes is None or attributes == "":
+            return {}
+        try:
+            attributes = json.loads(attributes)
+        except ValueError:
+            attributes = None
+        if not isinstance(attributes, dict):
+            errors.append("invalid attributes. Must be a JSON object")
+            return None
+        attributes = {name: value for name, value in attributes.items() if value is not None}
+        if not attributes:
+            return {}
+        if category_id is None:
+            errors.append("attributes require a category_id")
+            return None
+
+        schema = self.categories.get(category_id)["attributes"]
+        valid = True
+        for name, value in attributes.items():
+            if name not in schema:
+                errors.append("invalid attribute {}. Attributes of category {}: {}".format(
+                    name, category_id, ", ".join(sorted(schema)) or "none"
+                ))
+                valid = False
+            elif not attribute_matches(schema[name], value):
+                if isinstance(schema[name], list):
+                    errors.append("invalid attribute {}. Supported values: {}".format(
+                        name, ", ".join("'{}'".format(option) for option in schema[name])
+                    ))
+                elif schema[name] == "str":
+                    errors.append("invalid attribute {}. Must be a string of at most {} characters".format(
+                        name, MAX_ATTRIBUTE_VALUE_LENGTH
+                    ))
+                else:
+                    errors.append("invalid attribute {}. Must be of type {}".format(name, schema[name]))
+                valid = False
+        return attributes if valid else None
+
+    def _validate_uploads(self, uploads):
+        # Returns the errors of images uploaded along with a new listing
+        if len(uploads) == 0:
+            return []
+        if self.image_storage is None:
+            return ["image uploads are disabled, add images by URL instead"]
+        errors = []
+        if len(uploads) > MAX_IMAGES_PER_LISTING:
+            errors.append("a listing can have at most {} images".format(MAX_IMAGES_PER_LISTING))
+        for upload in uploads:
+            name = upload.get("filename") or "image"
+            if upload["content_type"] not in IMAGE_TYPES:
+                errors.append("invalid content type of {}. Supported values: {}".format(name, ", ".join(IMAGE_TYPES)))
+            if len(upload["body"]) == 0:
+                errors.append("{} is empty".format(name))
+            elif len(upload["body"]) > MAX_IMAGE_BYTES:
+                errors.append("{} is larger than {} bytes".format(name, MAX_IMAGE_BYTES))
+        return errors
+
+    def _validate_image_url(self, name, url, errors, optional=False):
+        if optional and (url is None or url == ""):
+            return None
+        parsed = urllib.parse.urlparse(url)
+        if parsed.scheme not in ("http", "https") or not parsed.netloc or len(url) > MAX_IMAGE_URL_LENGTH:
+            errors.append("{} must be an http(s) URL of at most {} characters".format(name, MAX_IMAGE_URL_LENGTH))
+            return None
+        return url
+
+    def _validate_dimension(self, name, value, errors):
+        # Image dimensions, in pixels, are optional until a media service fills them in
+        if value is None or value == "":
+            return None
+        try:
+            value = int(value)
+        except ValueError:
+            errors.append("invalid {}. Must be an integer".format(name))
+            return None
+        if value < 1:
+            errors.append("{} must be greater than 0".format(name))
+            return None
+        return value
+
+    def _validate_expires_at(self, expires_at, errors):
+        # Listings without expires_at never expire; otherwise it must be in the future
+        if expires_at is None or expires_at == "":
+            return None
+        try:
+            expires_at = filter_expressions.parse_time(expires_at)
+        except ValueError:
+            errors.append("invalid expires_at. Must be microseconds since the epoch or an ISO 8601 date-time")
+            return None
+        if expires_at <= now_micros():
+            errors.append("expires_at must be in the future")
+            return None
+        return expires_at
+
+    def _validate_promotion_time(self, name, value, time_now, errors):
+        # Promotion windows may not start or end in the past
+        if value is None or value == "":
+            errors.append("{} is required".format(name))
+            return None
+        try:
+            value = filter_expressions.parse_time(str(value))
+        except ValueError:
+            errors.append("invalid {}. Must be microseconds since the epoch or an ISO 8601 date-time".format(name))
+            return None
+        if value < time_now:
+            errors.append("{} must not be in the past".format(name))
+            return None
+        return value
+
+    def _validate_availability(self, listing_type, available_from, available_to, errors):
+        # Rentals may be available from and/or until a time; either bound may be left open
+        window = []
+        for name, value in (("available_from", available_from), ("available_to", available_to)):
+            if value is None or value == "":
+                window.append(None)
+                continue
+            try:
+                window.append(filter_expressions.parse_time(value))
+            except ValueError:
+                errors.append("invalid {}. Must be microseconds since the epoch or an ISO 8601 date-time".format(name))
+                window.append(None)
+        if window == [None, None]:
+            return None, None
+        if listing_type != "rent":
+            errors.append("available_from and available_to only apply to rental listings")
+            return None, None
+        if None not in window and window[0] >= window[1]:
+            errors.append("available_from must be before available_to")
+            return None, None
+        return window[0], window[1]
+
+    def _validate_type_fields(self, listing_type, params, errors):
+        # Rentals may have a rental_period and a deposit, and sales a negotiable flag; each is
+        # rejected on the other type. Returns the column values of all three
+        values = dict(rental_period=None, deposit=None, negotiable=None)
+        given = {name for name in values if params.get(name) is not None and params.get(name) != ""}
+
+        if "rental_period" in given:
+            if params["rental_period"] in RENTAL_PERIODS:
+                values["rental_period"] = params["rental_period"]
+            else:
+                errors.append("invalid rental_period. Supported values: {}".format(
+                    ", ".join("'{}'".format(period) for period in RENTAL_PERIODS)
+                ))
+        if "deposit" in given:
+            try:
+                values["deposit"] = int(params["deposit"])
+            except ValueError:
+                errors.append("invalid deposit. Must be an integer")
+            else:
+                if values["deposit"] < 0:
+                    errors.append("deposit must not be negative")
+                elif values["deposit"] > MAX_AMOUNT:
+                    errors.append("deposit must be at most {}".format(MAX_AMOUNT))
+        if "negotiable" in given:
+            if params["negotiable"] in ("true", "false"):
+                values["negotiable"] = params["negotiable"] == "true"
+            else:
+                errors.append("invalid negotiable. Supported values: 'true', 'false'")
+
+        if listing_type == "sale" and given & {"rental_period", "deposit"}:
+            errors.append("rental_period and deposit only apply to rental listings")
+        if listing_type == "rent" and "negotiable" in given:
+            errors.append("negotiable only applies to sale listings")
+        return values
+
+    def _validate_location(self, latitude, longitude, errors):
+        # Listings may have no location; otherwise both coordinates are required
+        if (latitude is None or latitude == "") and (longitude is None or longitude == ""):
+            return None, None
+        try:
+            latitude, longitude = float(latitude), float(longitude)
+        except (TypeError, ValueError):
+            errors.append("latitude and longitude must both be given as numbers")
+            return None, None
+        if not geo.is_valid_latitude(latitude):
+            errors.append("latitude must be within [-90, 90]")
+        if not geo.is_valid_longitude(longitude):
+            errors.append("longitude must be within [-180, 180]")
+        return latitude, longitude
+
+    def _validate_currency(self, currency, for_update, errors):
+        # Omitted currencies fall back to the default on creation and are kept on update
+        if currency is None or currency == "":
+            return None if for_update else self.default_currency
+        currency = currency.upper()
+        if not currencies.is_valid_currency(currency):
+            errors.append("invalid currency. Must be an ISO 4217 code such as 'USD'")
+            return None
+        return currency
+
+    def _validate_user_id(self, user_id, errors):
+        try:
+            user_id = int(user_id)
+            return user_id
+        except Exception as e:
+            logging.exception("Error while converting user_id to int: {}".format(user_id))
+            errors.append("invalid user_id")
+            return None
+
+    def _validate_listing_type(self, listing_type, errors):
+        if listing_type not in LISTING_TYPES:
+            errors.append("invalid listing_type. Supported values: 'rent', 'sale'")
+            return None
+        else:
+            return listing_type
+
+    def _validate_price(self, price, errors):
+        # Prices are integers of minor units of their currency, e.g. cents
+        try:
+            price = int(price)
+        except Exception as e:
+            logging.exception("Error while converting price to int: {}".format(price))
+            errors.append("invalid price. Must be an integer")
+            return None
+
+        if price < 1:
+            errors.append("price must be greater than 0")
+            return None
+        elif price > MAX_AMOUNT:
+            errors.append("price must be at most {}".format(MAX_AMOUNT))
+            return None
+        else:
+            return price
+
+    def _validate_text(self, name, value, max_length, errors):
+        # Free-text fields are optional, so empty values are accepted
+        if len(value) > max_length:
+            errors.append("{} must be at most {} characters long".format(name, max_length))
+            return None
+        return value
+
+class CategoryService:
+    """Business rules for listing categories, on top of a CategoryRepository."""
+
+    def __init__(self, repo):
+        self.repo = repo
+
+    def get_categories(self):
+        return self.repo.list()
+
+    def get_category(self, category_id):
+        category = self.repo.get(category_id)
+        if category is None:
+            raise NotFoundError()
+        return category
+
+    def create_category(self, name, attributes=None):
+        name = self._validate_name(name)
+        attributes = self._validate_attribute_types(attributes) if attributes is not None else {}
+        try:
+            category_id = self.repo.create(name, attributes, now_micros())
+        except DuplicateError:
+            raise ConflictError("category name is already taken")
+        return self.repo.get(category_id)
+
+    def update_category(self, category_id, name, attributes=None):
+        # Renames the category and, unless attributes is None, replaces its attributes. Listings
+        # keep their attribute values until they are next updated, when they are checked
+        # against the new attributes
+        name = self._validate_name(name)
+        if attributes is not None:
+            attributes = self._validate_attribute_types(attributes)
+        try:
+            if not self.repo.update(category_id, name, attributes, now_micros()):
+                raise NotFoundError()
+        except DuplicateError:
+            raise ConflictError("category name is already taken")
+        return self.repo.get(category_id)
+
+    def delete_category(self, category_id):
+        # Categories still used by listings cannot be deleted
+        result = self.repo.delete_unused(category_id)
+        if result == "not_found":
+            raise NotFoundError()
+        if result == "in_use":
+            raise ConflictError("category is still used by listings")
+
+    def _validate_name(self, name):
+        name = name.strip()
+        if not name or len(name) > MAX_CATEGORY_NAME_LENGTH:
+            raise ValidationError(["name must be between 1 and {} characters long".format(MAX_CATEGORY_NAME_LENGTH)])
+        return name
+
+    def _validate_attribute_types(self, attributes):
+        # Attributes are a JSON object of attribute name -> type, one of ATTRIBUTE_TYPES or a
+        # list of the allowed string values, e.g. {"bedrooms": "int", "heating": ["gas", "electric"]}
+        try:
+            attributes = json.loads(attributes) if attributes != "" else {}
+        except ValueError:
+            attributes = None
+        if not isinstance(attributes, dict):
+            raise ValidationError(["invalid attributes. Must be a JSON object of attribute names and types"])
+        errors = []
+        if len(attributes) > MAX_CATEGORY_ATTRIBUTES:
+            errors.append("a category can have at most {} attributes".format(MAX_CATEGORY_ATTRIBUTES))
+        for name, attribute_type in attributes.items():
+            if not ATTRIBUTE_NAME_PATTERN.match(name):
+                errors.append("invalid attribute name {}. Must be 1 to 32 lowercase letters, digits or _, starting with a letter".format(name))
+            if isinstance(attribute_type, list):
+                if not attribute_type or not all(
+                        isinstance(option, str) and 0 < len(option) <= MAX_ATTRIBUTE_VALUE_LENGTH for option in attribute_type):
+                    errors.append("invalid values of attribute {}. Must be a non-empty list of strings of at most {} characters".format(
+                        name, MAX_ATTRIBUTE_VALUE_LENGTH
+                    ))
+            elif attribute_type not in ATTRIBUTE_TYPES:
+                errors.append("invalid type of attribute {}. Supported values: {}, or a list of strings".format(
+                    name, ", ".join("'{}'".format(supported) for supported in ATTRIBUTE_TYPES)
+                ))
+        if len(errors) > 0:
+            raise ValidationError(errors)
+        return attributes
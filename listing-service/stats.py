@@ -0,0 +1,65 @@
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.ioloop
+
+# Name of the lease held by the instance running the stats worker
+LEASE_NAME = "listing_stats"
+
+# Listings are counted per UTC day of creation, in microseconds
+DAY_MICROS = 24 * 3600 * 1000000
+
+# Price suggestions are computed from the listings created in this many microseconds (180 days),
+# for combinations of at least PRICE_SUGGESTION_MIN_SAMPLES listings
+PRICE_SUGGESTION_WINDOW = 180 * DAY_MICROS
+PRICE_SUGGESTION_MIN_SAMPLES = 5
+
+# How often, in microseconds, the rollups are rebuilt from scratch (a day), so that days
+# emptied by deleted listings are corrected too
+FULL_REFRESH_INTERVAL = DAY_MICROS
+
+class StatsWorker:
+    """Periodically refreshes the rollups read by GET /listings/stats and the price suggestions
+    of GET /listings/price-suggestion.
+
+    The first round, and one round a day, rebuilds every day's listing_daily_stats rollup.
+    Other rounds only recompute the days of listings changed since the previous round, along
+    with the current day. The listing_status_stats totals and the price_suggestions are
+    recomputed on every round, as listings change status when they expire and leave the
+    suggestions' window as they age. Like the ExpiryWorker, only the instance holding
+    the listing_stats lease runs; it starts with a full rebuild when it takes the lease over.
+    """
+
+    def __init__(self, repo, interval):
+        self.repo = repo
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+        self.refreshed_at = None # Start of the last round that refreshed the rollups
+        self.rebuilt_at = None # Start of the last full rebuild
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.refresh, self.interval * 1000).start()
+
+    def refresh(self):
+        try:
+            time_now = int(time.time() * 1e6)
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, time_now, self.lease_duration):
+                # Another instance refreshes the rollups; rebuild them if this one takes over
+                self.refreshed_at = self.rebuilt_at = None
+                return
+            if self.rebuilt_at is None or time_now - self.rebuilt_at >= FULL_REFRESH_INTERVAL:
+                self.repo.refresh_daily_stats(None, DAY_MICROS)
+                self.rebuilt_at = time_now
+            else:
+                days = self.repo.days_changed_since(self.refreshed_at, DAY_MICROS)
+                days.add(time_now - time_now % DAY_MICROS)
+                self.repo.refresh_daily_stats(sorted(days), DAY_MICROS)
+            self.repo.refresh_status_stats(time_now)
+            self.repo.refresh_price_suggestions(time_now - PRICE_SUGGESTION_WINDOW, PRICE_SUGGESTION_MIN_SAMPLES, time_now)
+            self.refreshed_at = time_now
+        except Exception:
+            logging.exception("Error refreshing listing stats")
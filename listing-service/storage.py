@@ -0,0 +1,98 @@
+import logging
+import os
+import struct
+import urllib.parse
+import urllib.request
+import uuid
+
+# Content types accepted for uploaded images, and the extension they are stored with
+IMAGE_TYPES = {"image/jpeg": "jpg", "image/png": "png", "image/gif": "gif", "image/webp": "webp"}
+
+# Maximum size, in bytes, of an uploaded image
+MAX_IMAGE_BYTES = 10 * 1024 * 1024
+
+# Seconds to wait for the storage before giving up on an upload
+REQUEST_TIMEOUT = 10.0
+
+class ImageStorageError(Exception):
+    """Raised when an uploaded image cannot be stored."""
+
+def image_dimensions(body):
+    # Returns the (width, height) of a PNG, GIF or JPEG image, or (None, None) when they cannot
+    # be read from its header
+    try:
+        if body.startswith(b"\x89PNG\r\n\x1a\n"):
+            return struct.unpack(">II", body[16:24])
+        if body[:6] in (b"GIF87a", b"GIF89a"):
+            return struct.unpack("<HH", body[6:10])
+        if body.startswith(b"\xff\xd8"):
+            # Walk the JPEG segments up to the start of frame, which holds the dimensions
+            offset = 2
+            while offset + 9 < len(body):
+                marker, length = body[offset + 1], struct.unpack(">H", body[offset + 2:offset + 4])[0]
+                if marker in (0xc0, 0xc1, 0xc2):
+                    height, width = struct.unpack(">HH", body[offset + 5:offset + 9])
+                    return width, height
+                offset += 2 + length
+    except struct.error:
+        pass
+    return None, None
+
+def new_image_key(content_type):
+    # Returns a unique storage key for an image of content_type
+    return "listings/{}.{}".format(uuid.uuid4().hex, IMAGE_TYPES[content_type])
+
+class DirectoryImageStorage:
+    """Stores images as files in a directory, served by something else under base_url."""
+
+    def __init__(self, directory, base_url):
+        self.directory = directory
+        self.base_url = base_url.rstrip("/")
+
+    def store(self, key, body, content_type):
+        # Writes the image and returns its public URL
+        path = os.path.join(self.directory, key)
+        try:
+            os.makedirs(os.path.dirname(path), exist_ok=True)
+            with open(path, "wb") as f:
+                f.write(body)
+        except OSError as e:
+            raise ImageStorageError("could not write {}: {}".format(path, e))
+        return "{}/{}".format(self.base_url, key)
+
+class HTTPImageStorage:
+    """Stores images with PUT requests under upload_url, e.g. an object store bucket.
+
+    Images are served from base_url, which defaults to upload_url.
+    """
+
+    def __init__(self, upload_url, base_url=None):
+        self.upload_url = upload_url.rstrip("/")
+        self.base_url = (base_url or upload_url).rstrip("/")
+
+    def store(self, key, body, content_type):
+        # Uploads the image and returns its public URL
+        request = urllib.request.Request(
+            "{}/{}".format(self.upload_url, key), data=body, method="PUT", headers={"Content-Type": content_type}
+        )
+        try:
+            with urllib.request.urlopen(request, timeout=REQUEST_TIMEOUT):
+                pass
+        except Exception as e:
+            logging.warning("Error uploading image {}: {}".format(key, e))
+            raise ImageStorageError("could not upload {}: {}".format(key, e))
+        return "{}/{}".format(self.base_url, key)
+
+def make_image_storage(storage_url, base_url):
+    # Returns the storage for storage_url: a file:// URL of a directory or an http(s) URL
+    # accepting PUT requests. Returns None when storage_url is empty, disabling uploads
+    if not storage_url:
+        return None
+    parsed = urllib.parse.urlparse(storage_url)
+    if parsed.scheme == "file":
+        if not base_url:
+            raise ValueError("image_base_url is required with a file:// image_storage_url")
+        return DirectoryImageStorage(parsed.path, base_url)
+    if parsed.scheme in ("http", "https"):
+        return HTTPImageStorage(storage_url, base_url)
+    raise ValueError("image_storage_url must be a file:// or http(s) URL")
@@ -0,0 +1,120 @@
+import unittest.mock
+
+import tornado.gen
+import tornado.testing
+import tornado.web
+
+import owners
+from owners import FAILURE_THRESHOLD, OPEN_SECONDS, OwnerChecker, OwnerCheckUnavailableError
+
+class StubUserHandler(tornado.web.RequestHandler):
+    """Answers GET /users/{id} as the stub user service is told to."""
+
+    def initialize(self, stub):
+        self.stub = stub
+
+    @tornado.gen.coroutine
+    def get(self, user_id):
+        self.stub.requests += 1
+        if self.stub.delay:
+            yield tornado.gen.sleep(self.stub.delay)
+        self.set_status(self.stub.status)
+        self.write({"id": int(user_id)})
+
+class OwnerCheckerTest(tornado.testing.AsyncHTTPTestCase):
+    """Checks OwnerChecker against a stub user service answering status after delay seconds."""
+
+    def get_app(self):
+        self.requests = 0
+        self.status = 200
+        self.delay = 0
+        return tornado.web.Application([(r"/users/([0-9]+)", StubUserHandler, dict(stub=self))])
+
+    def setUp(self):
+        super().setUp()
+        self.checker = OwnerChecker(self.get_url(""), cache_ttl=60)
+
+    @tornado.testing.gen_test
+    def test_existing_user_is_cached(self):
+        self.assertTrue((yield self.checker.exists(1)))
+        self.assertTrue((yield self.checker.exists(1)))
+        self.assertEqual(self.requests, 1)
+
+    @tornado.testing.gen_test
+    def test_missing_user(self):
+        self.status = 404
+        self.assertFalse((yield self.checker.exists(1)))
+        self.assertEqual(self.checker.failures, 0)
+
+    @tornado.testing.gen_test
+    def test_timeout(self):
+        self.delay = 0.2
+        with unittest.mock.patch.object(owners, "REQUEST_TIMEOUT", 0.05):
+            with self.assertRaises(OwnerCheckUnavailableError):
+                yield self.checker.exists(1)
+        self.assertEqual(self.checker.failures, 1)
+        # Let the stub answer before the test's server stops
+        yield tornado.gen.sleep(self.delay)
+
+    @tornado.testing.gen_test
+    def test_circuit_opens_after_failures(self):
+        self.status = 500
+        for _ in range(FAILURE_THRESHOLD):
+            with self.assertRaises(OwnerCheckUnavailableError):
+                yield self.checker.exists(1)
+        self.assertIsNotNone(self.checker.opened_at)
+
+        # While open, checks fail without asking the user service
+        self.status = 200
+        with self.assertRaises(OwnerCheckUnavailableError):
+            yield self.checker.exists(2)
+        self.assertEqual(self.requests, FAILURE_THRESHOLD)
+
+    @tornado.testing.gen_test
+    def test_half_open_trial_closes_circuit(self):
+        yield self.open_circuit()
+        self.status = 200
+        self.checker.opened_at -= OPEN_SECONDS
+        self.assertTrue((yield self.checker.exists(2)))
+        self.assertIsNone(self.checker.opened_at)
+        self.assertEqual(self.checker.failures, 0)
+
+    @tornado.testing.gen_test
+    def test_half_open_trial_failure_reopens_circuit(self):
+        yield self.open_circuit()
+        self.checker.opened_at -= OPEN_SECONDS
+        requests = self.requests
+        with self.assertRaises(OwnerCheckUnavailableError):
+            yield self.checker.exists(2)
+        self.assertEqual(self.requests, requests + 1)
+
+        # A single failed trial reopens the circuit
+        self.status = 200
+        with self.assertRaises(OwnerCheckUnavailableError):
+            yield self.checker.exists(3)
+        self.assertEqual(self.requests, requests + 1)
+
+    @tornado.testing.gen_test
+    def test_half_open_sends_a_single_trial(self):
+        yield self.open_circuit()
+        self.checker.opened_at -= OPEN_SECONDS
+        self.status = 200
+        self.delay = 0.1
+        trial = self.checker.exists(2)
+        with self.assertRaises(OwnerCheckUnavailableError):
+            yield self.checker.exists(3)
+        self.assertTrue((yield trial))
+        self.assertEqual(self.checker.failures, 0)
+
+    @tornado.gen.coroutine
+    def open_circuit(self):
+        self.status = 500
+        for _ in range(FAILURE_THRESHOLD):
+            try:
+                yield self.checker.exists(1)
+            except OwnerCheckUnavailableError:
+                pass
+        self.assertIsNotNone(self.checker.opened_at)
+
+if __name__ == "__main__":
+    unittest.main()
@@ -0,0 +1,49 @@
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.ioloop
+
+# Name of the lease held by the instance running the trending worker
+LEASE_NAME = "listing_trending"
+
+# Views are aggregated per listing in buckets of this many microseconds (an hour)
+BUCKET_MICROS = 3600 * 1000000
+
+# Supported trending windows, in hours. Scores are kept for each of them
+WINDOWS = {"1h": 1, "6h": 6, "24h": 24, "7d": 168}
+
+def decay_weight(age_micros, half_life_micros):
+    # Weight of views age_micros old, halving every half_life_micros
+    return 0.5 ** (max(age_micros, 0) / half_life_micros)
+
+class TrendingWorker:
+    """Periodically recomputes the trending score of listings for every window in WINDOWS.
+
+    The score of a listing is the number of views it got during the window, each weighing less
+    the older it is: the weight halves every quarter of the window. Views come from the hourly
+    buckets written when the ViewCounter flushes; buckets older than the largest window are
+    dropped. Like the ExpiryWorker, only the instance holding the listing_trending lease runs.
+    """
+
+    def __init__(self, repo, interval):
+        self.repo = repo
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+
+    def start(self):
+        tornado.ioloop.PeriodicCallback(self.refresh, self.interval * 1000).start()
+
+    def refresh(self):
+        try:
+            time_now = int(time.time() * 1e6)
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, time_now, self.lease_duration):
+                return
+            self.repo.prune_view_buckets(time_now - max(WINDOWS.values()) * BUCKET_MICROS - BUCKET_MICROS)
+            for hours in WINDOWS.values():
+                self.repo.refresh_trending_scores(hours, hours * BUCKET_MICROS // 4, time_now)
+        except Exception:
+            logging.exception("Error refreshing trending listings")
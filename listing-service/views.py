@@ -0,0 +1,54 @@
+import logging
+import time
+
+import tornado.ioloop
+
+import events
+
+# Number of listings with buffered views that triggers a flush before the next interval
+MAX_BUFFERED_LISTINGS = 10000
+
+class ViewCounter:
+    """Counts listing views in memory and adds them to the listings' view_count in batches.
+
+    This turns a write per page view into one write per viewed listing per flush. Views
+    buffered since the last flush are lost if the process stops, so counts are approximate.
+    Views by known users also emit a listing.viewed event at the flush, once per user and
+    listing per flush, for consumers such as recommendations.
+    """
+
+    def __init__(self, repo):
+        self.repo = repo
+        self.pending = {} # listing id -> views not flushed yet
+        self.viewers = {} # (listing id, user id) -> time of the last view not flushed yet
+
+    def start(self, interval):
+        # Flushes buffered views every interval seconds
+        tornado.ioloop.PeriodicCallback(self.flush, interval * 1000).start()
+
+    def record(self, listing_id, user_id=None):
+        self.pending[listing_id] = self.pending.get(listing_id, 0) + 1
+        if user_id is not None:
+            self.viewers[(listing_id, user_id)] = int(time.time() * 1e6)
+        if len(self.pending) >= MAX_BUFFERED_LISTINGS or len(self.viewers) >= MAX_BUFFERED_LISTINGS:
+            self.flush()
+
+    def flush(self):
+        if not self.pending:
+            return
+        # Swap the buffers first so views recorded while writing go to the next batch
+        pending, self.pending = self.pending, {}
+        viewers, self.viewers = self.viewers, {}
+        viewed_events = [
+            events.new_event(events.LISTING_VIEWED, dict(listing_id=listing_id, user_id=user_id, viewed_at=viewed_at))
+            for (listing_id, user_id), viewed_at in viewers.items()
+        ]
+        try:
+            self.repo.add_views(pending, int(time.time() * 1e6), viewed_events)
+        except Exception:
+            logging.exception("Error flushing views of {} listings".format(len(pending)))
+            # Keep the views for the next flush
+            for listing_id, count in pending.items():
+                self.pending[listing_id] = self.pending.get(listing_id, 0) + count
+            for key, viewed_at in viewers.items():
+                self.viewers[key] = max(viewed_at, self.viewers.get(key, 0))
@@ -0,0 +1,139 @@
+import hashlib
+import hmac
+import json
+import logging
+import os
+import socket
+import time
+import uuid
+
+import tornado.gen
+import tornado.httpclient
+import tornado.ioloop
+
+import events
+
+# Name of the lease held by the instance delivering webhooks
+LEASE_NAME = "webhook_dispatcher"
+
+# Events POSTed to the webhook URLs
+WEBHOOK_EVENT_TYPES = (events.LISTING_CREATED, events.LISTING_SOLD, events.LISTING_PRICE_DROPPED)
+
+# Statuses of deliveries: pending until delivered, or failed once every attempt failed
+PENDING = "pending"
+DELIVERED = "delivered"
+FAILED = "failed"
+WEBHOOK_STATUSES = (PENDING, DELIVERED, FAILED)
+
+# Outbox events turned into deliveries, and deliveries attempted, per batch
+WEBHOOK_BATCH_SIZE = 100
+
+# Attempts made per delivery before it is given up, and the delay before the first retry, in
+# seconds, which doubles after each failed attempt up to MAX_RETRY_DELAY
+MAX_WEBHOOK_ATTEMPTS = 10
+RETRY_DELAY = 30
+MAX_RETRY_DELAY = 6 * 3600
+
+# Seconds to wait for a webhook URL to answer
+REQUEST_TIMEOUT = 10.0
+
+# Maximum length, in characters, of the errors kept in the delivery log
+MAX_LOGGED_ERROR_LENGTH = 500
+
+def retry_delay(attempts):
+    # Returns the seconds to wait after the given number of failed attempts
+    return min(RETRY_DELAY * 2 ** (attempts - 1), MAX_RETRY_DELAY)
+
+def sign(secret, timestamp, body):
+    # Returns the signature of a delivery: the hex HMAC-SHA256 of "{timestamp}.{body}" with the
+    # webhook secret. Receivers recompute it, and reject old timestamps to prevent replays
+    message = "{}.".format(timestamp).encode() + body
+    return hmac.new(secret.encode(), message, hashlib.sha256).hexdigest()
+
+class WebhookDispatcher:
+    """POSTs signed listing events to the configured webhook URLs.
+
+    Every round, new outbox events of the WEBHOOK_EVENT_TYPES are recorded as one delivery per
+    URL in the webhook_deliveries table, which doubles as the delivery log, and due deliveries
+    are attempted. A delivery succeeds on a 2xx response; otherwise it is retried with
+    exponential backoff, and marked as failed after MAX_WEBHOOK_ATTEMPTS attempts. URLs are
+    independent, so one that is down does not hold up the others, and deliveries may arrive
+    out of order or more than once. Like the ExpiryWorker, only the instance holding the
+    webhook_dispatcher lease runs.
+    """
+
+    def __init__(self, repo, urls, secret, interval):
+        self.repo = repo
+        self.urls = urls
+        self.secret = secret
+        self.interval = interval # Seconds between rounds
+        self.lease_duration = int(interval * 3 * 1e6)
+        self.holder = "{}:{}:{}".format(socket.gethostname(), os.getpid(), uuid.uuid4().hex[:8])
+        self.http_client = tornado.httpclient.AsyncHTTPClient()
+        self.dispatching = False
+
+    def start(self):
+        # Events recorded before webhooks were first enabled are not delivered
+        self.repo.init_checkpoint("webhooks")
+        tornado.ioloop.PeriodicCallback(self.dispatch, self.interval * 1000).start()
+
+    @tornado.gen.coroutine
+    def dispatch(self):
+        # Skip the round if the previous one is still delivering
+        if self.dispatching:
+            return
+        self.dispatching = True
+        try:
+            if not self.repo.acquire_lease(LEASE_NAME, self.holder, int(time.time() * 1e6), self.lease_duration):
+                return
+            # Record deliveries for every new event, a batch at a time
+            while True:
+                count = self.repo.enqueue_webhook_deliveries(
+                    WEBHOOK_EVENT_TYPES, self.urls, int(time.time() * 1e6), WEBHOOK_BATCH_SIZE
+                )
+                if count < WEBHOOK_BATCH_SIZE:
+                    break
+            for delivery in self.repo.fetch_due_webhook_deliveries(int(time.time() * 1e6), WEBHOOK_BATCH_SIZE):
+                yield self.deliver(delivery)
+        except Exception:
+            logging.exception("Error dispatching webhooks")
+        finally:
+            self.dispatching = False
+
+    @tornado.gen.coroutine
+    def deliver(self, delivery):
+        # Attempts a delivery and records its outcome in the delivery log
+        body = json.dumps(delivery["event"]).encode()
+        timestamp = int(time.time())
+        headers = {
+            "Content-Type": "application/json",
+            "X-Webhook-Id": str(delivery["id"]),
+            "X-Webhook-Event": delivery["event"]["type"],
+            "X-Webhook-Timestamp": str(timestamp),
+            "X-Webhook-Signature": "sha256=" + sign(self.secret, timestamp, body),
+        }
+        status_code = None
+        try:
+            response = yield self.http_client.fetch(
+                delivery["url"], method="POST", body=body, headers=headers, request_timeout=REQUEST_TIMEOUT
+            )
+            status_code = response.code
+            error = None
+        except Exception as e:
+            # Non-2xx responses raise errors carrying their status code
+            status_code = getattr(e, "code", None)
+            error = str(e)[:MAX_LOGGED_ERROR_LENGTH]
+
+        time_now = int(time.time() * 1e6)
+        attempts = delivery["attempts"] + 1
+        if error is None:
+            self.repo.record_webhook_attempt(delivery["id"], DELIVERED, status_code, None, time_now, None)
+            return
+        if attempts >= MAX_WEBHOOK_ATTEMPTS:
+            logging.warning("Giving up webhook delivery {} to {} after {} attempts: {}".format(
+                delivery["id"], delivery["url"], attempts, error
+            ))
+            self.repo.record_webhook_attempt(delivery["id"], FAILED, status_code, error, time_now, None)
+            return
+        next_attempt_at = time_now + retry_delay(attempts) * 1000000
+        self.repo.record_webhook_attempt(delivery["id"], PENDING, status_code, error, time_now, next_attempt_at)
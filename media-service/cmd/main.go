@@ -0,0 +1,175 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"contracts/client"
+	"httpmiddleware"
+	"media-service/internal/api"
+	"media-service/internal/handler"
+	"media-service/internal/repository"
+	"media-service/internal/service"
+	"media-service/internal/storage"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "media.db"
+
+func main() {
+	// Define command-line flags for port, upload limits and the storage backend
+	port := flag.Int("port", 9300, "The port number to run the Media Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding media metadata")
+	maxUploadBytes := flag.Int64("max-upload-bytes", 10<<20, "Maximum size of uploaded files, in bytes")
+	thumbnailWidths := flag.String("thumbnail-widths", "320,960", "Comma-separated widths, in pixels, of the thumbnails generated for each upload")
+	storageBackend := flag.String("storage", "local", "Storage backend of uploaded files: local or s3")
+	storageDir := flag.String("storage-dir", "media", "Directory of the local storage backend")
+	publicURL := flag.String("public-url", "", "Base URL files are served at (defaults to http://localhost:<port>/files for local storage, and to the bucket URL for S3)")
+	s3Endpoint := flag.String("s3-endpoint", "", "Base URL of the S3-compatible API, e.g. https://s3.us-east-1.amazonaws.com")
+	s3Bucket := flag.String("s3-bucket", "", "Bucket of the S3 storage backend")
+	s3Region := flag.String("s3-region", "us-east-1", "Region of the S3 storage backend")
+	s3AccessKey := flag.String("s3-access-key", "", "Access key of the S3 storage backend")
+	s3SecretKey := flag.String("s3-secret-key", "", "Secret key of the S3 storage backend")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it; uploads and files are exempt)")
+	flag.Parse()
+
+	if *maxUploadBytes < 1 {
+		log.Fatalf("Invalid -max-upload-bytes %d, expected a positive size", *maxUploadBytes)
+	}
+	widths, err := parseWidths(*thumbnailWidths)
+	if err != nil {
+		log.Fatalf("Invalid -thumbnail-widths: %v", err)
+	}
+
+	// Initialize the SQLite database
+	// By default this will create 'media.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Initialize the storage backend
+	var store storage.Storage
+	switch *storageBackend {
+	case "local":
+		if *publicURL == "" {
+			*publicURL = fmt.Sprintf("http://localhost:%d/files", *port)
+		}
+		localStorage, err := storage.NewLocalStorage(*storageDir, *publicURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize local storage: %v", err)
+		}
+		store = localStorage
+		// GET /files/{key}: Serve a stored file (local storage only)
+		r.Handle("/files/{key}", handler.ServeFiles(localStorage.Dir())).Methods("GET", "HEAD")
+	case "s3":
+		// Initialize a custom HTTP client with timeouts for the S3 API; uploads of large files
+		// need more than the usual overall timeout
+		httpClient := client.NewHTTPClient(
+			60*time.Second, // Overall request timeout
+			5*time.Second,  // Dial timeout
+			5*time.Second,  // TLS handshake timeout
+			30*time.Second, // Response header timeout
+		)
+		store, err = storage.NewS3Storage(httpClient, storage.S3Config{
+			Endpoint:  *s3Endpoint,
+			Bucket:    *s3Bucket,
+			Region:    *s3Region,
+			AccessKey: *s3AccessKey,
+			SecretKey: *s3SecretKey,
+			PublicURL: *publicURL,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize S3 storage: %v", err)
+		}
+	default:
+		log.Fatalf("Invalid -storage %q, expected local or s3", *storageBackend)
+	}
+
+	// Initialize repository, service, and handler layers
+	mediaRepo := repository.NewSQLiteMediaRepository(db)
+	mediaService := service.NewMediaService(mediaRepo, store, *maxUploadBytes, widths)
+	mediaHandler := handler.NewMediaHandler(mediaService)
+
+	// Define Media Service API routes
+	// POST /media, GET and DELETE /media/{id}: Upload images (multipart/form-data with user_id and
+	// file), get them along with their thumbnails, and delete them (uploader only), as specified
+	// by api/openapi.yaml
+	api.RegisterMediaRoutes(r, mediaHandler)
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("media-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+		NoTimeout: func(req *http.Request) bool {
+			// Uploads are read and stored in full, and files are streamed
+			return req.URL.Path == "/media" || strings.HasPrefix(req.URL.Path, "/files/")
+		},
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  60 * time.Second, // Max time to read request from client, long enough for uploads
+		WriteTimeout: 60 * time.Second, // Max time to write response to client, including storing uploads
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Start the HTTP server
+	log.Printf("Media Service starting on port %d with %s storage", *port, *storageBackend)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
+
+// parseWidths parses a comma-separated list of thumbnail widths, returned in increasing order.
+func parseWidths(value string) ([]int, error) {
+	var widths []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		width, err := strconv.Atoi(field)
+		if err != nil || width < 1 || width > 4096 {
+			return nil, fmt.Errorf("%q is not a width between 1 and 4096", field)
+		}
+		widths = append(widths, width)
+	}
+	slices.Sort(widths)
+	return slices.Compact(widths), nil
+}
@@ -0,0 +1,18 @@
+module media-service
+
+go 1.24.4
+
+require (
+	apperrors v0.0.0
+	contracts v0.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.28
+	golang.org/x/image v0.25.0
+	httpmiddleware v0.0.0
+)
+
+replace apperrors => ../pkg/apperrors
+
+replace contracts => ../pkg/contracts
+
+replace httpmiddleware => ../pkg/httpmiddleware
@@ -0,0 +1,48 @@
+// Code generated by openapi from media-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// MediaHandler handles the operations of the media tag: uploaded images and their thumbnails.
+type MediaHandler interface {
+	// UploadMedia handles POST /media: Upload an image, whose URLs can be added to listings.
+	UploadMedia(w http.ResponseWriter, r *http.Request)
+
+	// GetMedia handles GET /media/{id}: Get an uploaded image along with its thumbnails.
+	GetMedia(w http.ResponseWriter, r *http.Request)
+
+	// DeleteMedia handles DELETE /media/{id}: Delete an uploaded image and its files, on behalf of
+	// its uploader.
+	DeleteMedia(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedMediaHandler answers every operation of MediaHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedMediaHandler struct{}
+
+// UploadMedia answers POST /media with 501 Not Implemented.
+func (UnimplementedMediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetMedia answers GET /media/{id} with 501 Not Implemented.
+func (UnimplementedMediaHandler) GetMedia(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// DeleteMedia answers DELETE /media/{id} with 501 Not Implemented.
+func (UnimplementedMediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterMediaRoutes registers the routes of the operations of MediaHandler on r.
+func RegisterMediaRoutes(r *mux.Router, h MediaHandler) {
+	r.HandleFunc("/media", h.UploadMedia).Methods("POST")
+	r.HandleFunc("/media/{id}", h.GetMedia).Methods("GET")
+	r.HandleFunc("/media/{id}", h.DeleteMedia).Methods("DELETE")
+}
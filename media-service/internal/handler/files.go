@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"net/http"
+	"path/filepath"
+
+	"media-service/internal/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// ServeFiles returns a handler for GET /files/{key} requests, serving the files of the local
+// storage backend from dir. Stored files never change, as every upload gets a new key, so
+// they can be cached for good.
+func ServeFiles(dir string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := mux.Vars(r)["key"]
+		if !storage.ValidKey(key) {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		http.ServeFile(w, r, filepath.Join(dir, key))
+	})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"apperrors"
+	"media-service/internal/api"
+	"media-service/internal/model"
+	"media-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// multipartOverheadBytes is allowed on top of the maximum file size for the other form
+	// fields and the multipart framing.
+	multipartOverheadBytes = 64 << 10
+	// maxMemoryBytes is the part of a multipart form kept in memory, the rest is buffered to
+	// temporary files.
+	maxMemoryBytes = 1 << 20
+)
+
+// APIResponse is the response structure for requests that return no data.
+type APIResponse struct {
+	Result bool   `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// MediaResponse is the response structure for requests returning media.
+type MediaResponse struct {
+	Result bool         `json:"result"`
+	Media  *model.Media `json:"media,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// MediaHandler handles HTTP requests related to media.
+type MediaHandler struct {
+	mediaService *service.MediaService
+}
+
+// MediaHandler implements the operations of the spec's media tag.
+var _ api.MediaHandler = (*MediaHandler)(nil)
+
+// NewMediaHandler creates a new instance of MediaHandler.
+func NewMediaHandler(mediaService *service.MediaService) *MediaHandler {
+	return &MediaHandler{mediaService: mediaService}
+}
+
+// UploadMedia handles POST /media requests.
+// It expects a multipart/form-data body with the uploader's user_id and the image as file,
+// and responds with the stored media, whose URLs can be added to listings.
+func (h *MediaHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.mediaService.MaxUploadBytes()+multipartOverheadBytes)
+	if err := r.ParseMultipartForm(maxMemoryBytes); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: "File is too large"})
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: "Expected a multipart/form-data body"})
+		return
+	}
+	defer func() {
+		if err := r.MultipartForm.RemoveAll(); err != nil {
+			log.Printf("Error removing multipart files: %v", err)
+		}
+	}()
+
+	userID, err := strconv.ParseInt(r.FormValue("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: "Invalid user_id"})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: "Missing file"})
+		return
+	}
+	defer file.Close()
+
+	// Read one byte past the limit, so oversized files are told apart from files at the limit
+	data, err := io.ReadAll(io.LimitReader(file, h.mediaService.MaxUploadBytes()+1))
+	if err != nil {
+		log.Printf("Error reading uploaded file: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: "Could not read file"})
+		return
+	}
+
+	media, err := h.mediaService.Upload(r.Context(), userID, header.Header.Get("Content-Type"), data)
+	if err != nil {
+		// Unsupported types have a status of their own, which no code maps to
+		if errors.Is(err, service.ErrUnsupportedType) {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			json.NewEncoder(w).Encode(MediaResponse{Result: false, Error: err.Error()})
+			return
+		}
+		writeError(w, err, "uploading media")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(MediaResponse{Result: true, Media: media})
+}
+
+// GetMedia handles GET /media/{id} requests.
+// It responds with the media, including the URLs of its thumbnails.
+func (h *MediaHandler) GetMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseMediaID(w, r)
+	if !ok {
+		return
+	}
+
+	media, err := h.mediaService.Get(id)
+	if err != nil {
+		writeError(w, err, "getting media %d", id)
+		return
+	}
+
+	json.NewEncoder(w).Encode(MediaResponse{Result: true, Media: media})
+}
+
+// DeleteMedia handles DELETE /media/{id} requests.
+// It deletes the media and its files, provided the user_id query parameter is its uploader's.
+func (h *MediaHandler) DeleteMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseMediaID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user_id"})
+		return
+	}
+
+	if err := h.mediaService.Delete(id, userID); err != nil {
+		writeError(w, err, "deleting media %d", id)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true})
+}
+
+// parseMediaID reads the media ID from the URL path, answering 400 if it is invalid.
+func parseMediaID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid media ID"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeError answers an error of MediaService with the status of its code, logging internal
+// errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
@@ -0,0 +1,114 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder
+)
+
+const (
+	// jpegQuality is the quality of JPEG thumbnails.
+	jpegQuality = 85
+	// MaxPixels bounds the size of decoded images, so a small file declaring huge dimensions
+	// (a decompression bomb) is rejected before it is decoded.
+	MaxPixels = 40_000_000
+)
+
+// Types maps the image content types accepted for upload to their file extensions.
+var Types = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+var (
+	// ErrUnsupportedType is returned for files that are not images of a supported type.
+	ErrUnsupportedType = errors.New("unsupported image type")
+	// ErrInvalidImage is returned for images that cannot be decoded.
+	ErrInvalidImage = errors.New("invalid image")
+)
+
+// Image is a decoded image along with its content type.
+type Image struct {
+	ContentType string
+	Width       int
+	Height      int
+	img         image.Image
+}
+
+// Thumbnail is an encoded downscaled copy of an image.
+type Thumbnail struct {
+	ContentType string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// DetectType returns the content type of data from its first bytes, whatever its name or
+// declared type, failing with ErrUnsupportedType unless it is an accepted image type.
+func DetectType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := Types[contentType]; !ok {
+		return "", fmt.Errorf("%w: %s (expected JPEG, PNG, GIF or WebP)", ErrUnsupportedType, contentType)
+	}
+	return contentType, nil
+}
+
+// Decode decodes data, an image of the given content type as returned by DetectType. Animated
+// GIFs are decoded as their first frame.
+func Decode(data []byte, contentType string) (*Image, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if "image/"+format != contentType {
+		return nil, fmt.Errorf("%w: %s content decoded as %s", ErrInvalidImage, contentType, format)
+	}
+	if config.Width < 1 || config.Height < 1 {
+		return nil, fmt.Errorf("%w: image has no pixels", ErrInvalidImage)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d image exceeds %d pixels", ErrInvalidImage, config.Width, config.Height, MaxPixels)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	bounds := img.Bounds()
+	return &Image{ContentType: contentType, Width: bounds.Dx(), Height: bounds.Dy(), img: img}, nil
+}
+
+// Thumbnail returns a copy of the image scaled down to width, keeping its aspect ratio. Images
+// with transparency (PNG and GIF) become PNG thumbnails, others JPEG ones.
+func (i *Image) Thumbnail(width int) (*Thumbnail, error) {
+	height := max(1, (i.Height*width+i.Width/2)/i.Width)
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), i.img, i.img.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	thumbnail := &Thumbnail{Width: width, Height: height}
+	switch i.ContentType {
+	case "image/png", "image/gif":
+		thumbnail.ContentType = "image/png"
+		if err := png.Encode(&buf, dst); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+	default:
+		thumbnail.ContentType = "image/jpeg"
+		if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: jpegQuality}); err != nil {
+			return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+		}
+	}
+	thumbnail.Data = buf.Bytes()
+	return thumbnail, nil
+}
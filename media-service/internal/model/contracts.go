@@ -0,0 +1,25 @@
+package model
+
+import "contracts"
+
+// These composite literals break the build when the Media Service's representations and the
+// contracts generated from its spec drift apart. They compare field names and types, leaving
+// out the storage keys, which are not served; the JSON names are kept in sync by hand.
+var (
+	_ = contracts.Media{
+		ID:          Media{}.ID,
+		UserID:      Media{}.UserID,
+		URL:         Media{}.URL,
+		ContentType: Media{}.ContentType,
+		Size:        Media{}.Size,
+		Width:       Media{}.Width,
+		Height:      Media{}.Height,
+		CreatedAt:   Media{}.CreatedAt,
+	}
+	_ = contracts.MediaThumbnail{
+		URL:         Thumbnail{}.URL,
+		ContentType: Thumbnail{}.ContentType,
+		Width:       Thumbnail{}.Width,
+		Height:      Thumbnail{}.Height,
+	}
+)
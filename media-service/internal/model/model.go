@@ -0,0 +1,24 @@
+package model
+
+// Media is an uploaded image, stored along with its thumbnails.
+type Media struct {
+	ID          int64       `json:"id"`
+	UserID      int64       `json:"user_id"` // Uploader, the only user who may delete it
+	Key         string      `json:"-"`       // Storage key of the original image
+	URL         string      `json:"url"`     // Derived from Key by the configured storage
+	ContentType string      `json:"content_type"`
+	Size        int64       `json:"size"` // In bytes
+	Width       int         `json:"width"`
+	Height      int         `json:"height"`
+	Thumbnails  []Thumbnail `json:"thumbnails"` // Smallest first
+	CreatedAt   int64       `json:"created_at"`
+}
+
+// Thumbnail is a downscaled copy of an image.
+type Thumbnail struct {
+	Key         string `json:"-"` // Storage key
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
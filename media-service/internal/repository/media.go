@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"apperrors"
+	"media-service/internal/model"
+)
+
+// ErrMediaNotFound is returned when no media has the requested ID.
+var ErrMediaNotFound = apperrors.New(apperrors.NotFound, "media not found")
+
+// MediaRepository defines the interface for media storage.
+type MediaRepository interface {
+	Create(media *model.Media) error
+	GetByID(id int64) (*model.Media, error)
+	Delete(id int64) error
+}
+
+// sqliteMediaRepository implements MediaRepository for SQLite database.
+type sqliteMediaRepository struct {
+	db *sql.DB
+}
+
+// createMediaTablesSQL creates the media table and the media_thumbnails table, holding the
+// thumbnails of each media. Files themselves live in the storage backend, under their keys.
+const createMediaTablesSQL = `
+	CREATE TABLE IF NOT EXISTS media (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		storage_key TEXT NOT NULL UNIQUE,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		width INTEGER NOT NULL,
+		height INTEGER NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS media_thumbnails (
+		media_id INTEGER NOT NULL,
+		width INTEGER NOT NULL,
+		height INTEGER NOT NULL,
+		storage_key TEXT NOT NULL UNIQUE,
+		content_type TEXT NOT NULL,
+		PRIMARY KEY (media_id, width)
+	);`
+
+// NewSQLiteMediaRepository creates a new instance of sqliteMediaRepository.
+func NewSQLiteMediaRepository(db *sql.DB) MediaRepository {
+	return &sqliteMediaRepository{db: db}
+}
+
+// Create inserts the media and its thumbnails, setting the media's ID.
+func (r *sqliteMediaRepository) Create(media *model.Media) error {
+	return r.withTx("creating media", func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`INSERT INTO media(user_id, storage_key, content_type, size, width, height, created_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?)`,
+			media.UserID, media.Key, media.ContentType, media.Size, media.Width, media.Height, media.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert media: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get media ID: %w", err)
+		}
+
+		for _, t := range media.Thumbnails {
+			if _, err := tx.Exec(
+				`INSERT INTO media_thumbnails(media_id, width, height, storage_key, content_type) VALUES(?, ?, ?, ?, ?)`,
+				id, t.Width, t.Height, t.Key, t.ContentType,
+			); err != nil {
+				return fmt.Errorf("failed to insert thumbnail: %w", err)
+			}
+		}
+		media.ID = id
+		return nil
+	})
+}
+
+// GetByID returns the media with the given ID and its thumbnails, smallest first.
+func (r *sqliteMediaRepository) GetByID(id int64) (*model.Media, error) {
+	media := &model.Media{}
+	err := r.db.QueryRow(
+		`SELECT id, user_id, storage_key, content_type, size, width, height, created_at FROM media WHERE id = ?`, id,
+	).Scan(&media.ID, &media.UserID, &media.Key, &media.ContentType, &media.Size, &media.Width, &media.Height, &media.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, fmt.Errorf("failed to query media: %w", err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT storage_key, content_type, width, height FROM media_thumbnails WHERE media_id = ? ORDER BY width`, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query thumbnails: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	media.Thumbnails = []model.Thumbnail{}
+	for rows.Next() {
+		var t model.Thumbnail
+		if err := scanThumbnail(rows, &t); err != nil {
+			return nil, fmt.Errorf("failed to scan thumbnail: %w", err)
+		}
+		media.Thumbnails = append(media.Thumbnails, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read thumbnails: %w", err)
+	}
+	return media, nil
+}
+
+// Delete removes the media with the given ID and its thumbnails.
+func (r *sqliteMediaRepository) Delete(id int64) error {
+	return r.withTx("deleting media", func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM media WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete media: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to delete media: %w", err)
+		}
+		if affected == 0 {
+			return ErrMediaNotFound
+		}
+		if _, err := tx.Exec(`DELETE FROM media_thumbnails WHERE media_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete thumbnails: %w", err)
+		}
+		return nil
+	})
+}
+
+// scanThumbnail reads a thumbnail selected as storage_key, content_type, width, height.
+func scanThumbnail(row rowScanner, t *model.Thumbnail) error {
+	return row.Scan(&t.Key, &t.ContentType, &t.Width, &t.Height)
+}
+
+// withTx runs fn in a transaction, committed if fn succeeds and rolled back otherwise. what
+// describes the operation in error messages.
+func (r *sqliteMediaRepository) withTx(what string, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", what, err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for %s: %w", what, err)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
+	"apperrors"
+	"media-service/internal/imaging"
+	"media-service/internal/model"
+	"media-service/internal/repository"
+	"media-service/internal/storage"
+)
+
+// Errors returned by MediaService.
+var (
+	// ErrInvalidUpload is returned for uploads that are empty, too large or not a valid image.
+	ErrInvalidUpload = apperrors.New(apperrors.Validation, "invalid upload")
+	// ErrUnsupportedType is returned for uploads that are not images of an accepted type.
+	ErrUnsupportedType = imaging.ErrUnsupportedType
+	// ErrMediaNotFound is returned when no media has the requested ID.
+	ErrMediaNotFound = repository.ErrMediaNotFound
+	// ErrForbidden is returned when a user deletes media uploaded by someone else.
+	ErrForbidden = apperrors.New(apperrors.Forbidden, "media belongs to another user")
+)
+
+// genericContentType is the part content type of clients that do not know the file's type; it
+// is never checked against the detected type.
+const genericContentType = "application/octet-stream"
+
+// MediaService validates uploaded images, stores them along with their thumbnails, and
+// manages them.
+type MediaService struct {
+	repo            repository.MediaRepository
+	storage         storage.Storage
+	maxUploadBytes  int64
+	thumbnailWidths []int
+}
+
+// NewMediaService creates a new instance of MediaService. Uploads are limited to
+// maxUploadBytes, and get a thumbnail for each of thumbnailWidths narrower than themselves.
+func NewMediaService(repo repository.MediaRepository, storage storage.Storage, maxUploadBytes int64, thumbnailWidths []int) *MediaService {
+	return &MediaService{repo: repo, storage: storage, maxUploadBytes: maxUploadBytes, thumbnailWidths: thumbnailWidths}
+}
+
+// MaxUploadBytes returns the maximum size of uploaded files.
+func (s *MediaService) MaxUploadBytes() int64 {
+	return s.maxUploadBytes
+}
+
+// Upload stores the image data uploaded by a user, along with its thumbnails. The image type is
+// detected from the data itself; declaredType, the type the client declared if any, must
+// match it. Nothing is stored if any file fails to be.
+func (s *MediaService) Upload(ctx context.Context, userID int64, declaredType string, data []byte) (*model.Media, error) {
+	if userID < 1 {
+		return nil, fmt.Errorf("%w: user_id must be a positive integer", ErrInvalidUpload)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidUpload)
+	}
+	if int64(len(data)) > s.maxUploadBytes {
+		return nil, fmt.Errorf("%w: file must be at most %d bytes", ErrInvalidUpload, s.maxUploadBytes)
+	}
+
+	contentType, err := imaging.DetectType(data)
+	if err != nil {
+		return nil, err
+	}
+	if declaredType != "" && declaredType != genericContentType && declaredType != contentType {
+		return nil, fmt.Errorf("%w: file declared as %s but contains %s", ErrUnsupportedType, declaredType, contentType)
+	}
+	img, err := imaging.Decode(data, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidUpload, err)
+	}
+
+	name, err := randomName()
+	if err != nil {
+		return nil, err
+	}
+	media := &model.Media{
+		UserID:      userID,
+		Key:         name + "." + imaging.Types[contentType],
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Width,
+		Height:      img.Height,
+		Thumbnails:  []model.Thumbnail{},
+		CreatedAt:   time.Now().UnixMicro(),
+	}
+
+	// Remove whatever was stored should a later step fail
+	var stored []string
+	ok := false
+	defer func() {
+		if !ok {
+			s.deleteFiles(stored)
+		}
+	}()
+
+	if err := s.storage.Put(ctx, media.Key, contentType, data); err != nil {
+		return nil, err
+	}
+	stored = append(stored, media.Key)
+
+	for _, width := range s.thumbnailWidths {
+		if width >= img.Width {
+			continue // Never upscale
+		}
+		thumbnail, err := img.Thumbnail(width)
+		if err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%s_%d.%s", name, width, imaging.Types[thumbnail.ContentType])
+		if err := s.storage.Put(ctx, key, thumbnail.ContentType, thumbnail.Data); err != nil {
+			return nil, err
+		}
+		stored = append(stored, key)
+		media.Thumbnails = append(media.Thumbnails, model.Thumbnail{
+			Key:         key,
+			ContentType: thumbnail.ContentType,
+			Width:       thumbnail.Width,
+			Height:      thumbnail.Height,
+		})
+	}
+
+	if err := s.repo.Create(media); err != nil {
+		return nil, err
+	}
+	ok = true
+	s.setURLs(media)
+	return media, nil
+}
+
+// Get returns the media with the given ID.
+func (s *MediaService) Get(id int64) (*model.Media, error) {
+	media, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.setURLs(media)
+	return media, nil
+}
+
+// Delete deletes media uploaded by the user, and its files. Files that fail to be deleted are
+// only logged: the media is gone either way.
+func (s *MediaService) Delete(id, userID int64) error {
+	media, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if media.UserID != userID {
+		return ErrForbidden
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	keys := []string{media.Key}
+	for _, t := range media.Thumbnails {
+		keys = append(keys, t.Key)
+	}
+	s.deleteFiles(keys)
+	return nil
+}
+
+// setURLs fills in the URLs of the media and its thumbnails from their storage keys.
+func (s *MediaService) setURLs(media *model.Media) {
+	media.URL = s.storage.URL(media.Key)
+	for i := range media.Thumbnails {
+		media.Thumbnails[i].URL = s.storage.URL(media.Thumbnails[i].Key)
+	}
+}
+
+// deleteFiles deletes the files stored under keys, logging failures.
+func (s *MediaService) deleteFiles(keys []string) {
+	// Files are deleted even if the request that triggered this was cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, key := range keys {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Error deleting file %s: %v", key, err)
+		}
+	}
+}
+
+// randomName returns a random, unguessable file name.
+func randomName() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate file name: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// s3Service is the service name in AWS Signature Version 4 scopes.
+const s3Service = "s3"
+
+// S3Config configures an S3Storage.
+type S3Config struct {
+	Endpoint  string // Base URL of the S3-compatible API, e.g. https://s3.us-east-1.amazonaws.com
+	Bucket    string
+	Region    string
+	AccessKey string
+	SecretKey string
+	PublicURL string // Base URL objects are served at; defaults to the bucket's URL on the endpoint
+}
+
+// S3Storage stores files as objects of a bucket of an S3-compatible API (AWS S3, MinIO, ...),
+// addressed path-style and signed with AWS Signature Version 4. Objects must be publicly
+// readable, through a bucket policy or a CDN in front of PublicURL.
+type S3Storage struct {
+	httpClient *http.Client
+	config     S3Config
+	bucketURL  *url.URL
+}
+
+// NewS3Storage creates an S3Storage with the given configuration.
+func NewS3Storage(httpClient *http.Client, config S3Config) (*S3Storage, error) {
+	if config.Endpoint == "" || config.Bucket == "" || config.Region == "" || config.AccessKey == "" || config.SecretKey == "" {
+		return nil, fmt.Errorf("S3 storage requires an endpoint, bucket, region, access key and secret key")
+	}
+	bucketURL, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/") + "/" + url.PathEscape(config.Bucket))
+	if err != nil || bucketURL.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", config.Endpoint)
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = bucketURL.String()
+	}
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &S3Storage{httpClient: httpClient, config: config, bucketURL: bucketURL}, nil
+}
+
+// Put uploads data as the object key.
+func (s *S3Storage) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return s.do(req, "upload", http.StatusOK)
+}
+
+// Delete deletes the object key. S3 answers 204 whether or not the object exists.
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	if !ValidKey(key) {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, "delete", http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// URL returns the URL of the object under the public URL.
+func (s *S3Storage) URL(key string) string {
+	return s.config.PublicURL + "/" + key
+}
+
+// newRequest builds a signed request for the object key with the given body.
+func (s *S3Storage) newRequest(ctx context.Context, method, key string, body []byte) (*http.Request, error) {
+	objectURL := *s.bucketURL
+	objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + key
+	objectURL.RawPath = ""
+
+	req, err := http.NewRequestWithContext(ctx, method, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	s.sign(req, body, time.Now().UTC())
+	return req, nil
+}
+
+// do sends req, failing unless the response status is one of expected.
+func (s *S3Storage) do(req *http.Request, what string, expected ...int) error {
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to %s S3 object: %w", what, err)
+	}
+	defer resp.Body.Close()
+
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	// S3 errors are small XML documents, worth logging as they are
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("failed to %s S3 object: %s: %s", what, resp.Status, strings.TrimSpace(string(detail)))
+}
+
+// sign adds the AWS Signature Version 4 headers of req, signing its host, its payload hash and
+// its date.
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + req.Header.Get("X-Amz-Content-Sha256"),
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	canonicalHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretKey), date)
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.config.AccessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// ErrInvalidKey is returned for keys that are not safe to use as object names or file paths.
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// keyPattern matches the keys generated by the Media Service: a flat name of letters, digits,
+// underscores and dashes, with a file extension.
+var keyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[a-z0-9]+$`)
+
+// Storage stores uploaded files and tells where they are served from.
+type Storage interface {
+	// Put stores data under key, replacing any previous file.
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	// Delete removes the file stored under key. Deleting a missing file is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the public URL of the file stored under key.
+	URL(key string) string
+}
+
+// ValidKey reports whether key can be stored by every backend.
+func ValidKey(key string) bool {
+	return keyPattern.MatchString(key)
+}
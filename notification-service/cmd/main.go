@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bus"
+	"httpmiddleware"
+	"notification-service/internal/api"
+	"notification-service/internal/channel"
+	"notification-service/internal/handler"
+	"notification-service/internal/repository"
+	"notification-service/internal/service"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "notifications.db"
+
+func main() {
+	// Define command-line flags for port, channels and delivery
+	port := flag.Int("port", 9100, "The port number to run the Notification Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding preferences and notifications")
+	defaultChannels := flag.String("default-channels", channel.NameLog, "Comma-separated channels notifying users who did not choose any")
+	dispatchInterval := flag.Duration("dispatch-interval", 2*time.Second, "How often due notifications are delivered")
+	smtpAddr := flag.String("smtp-addr", "", "host:port of the SMTP server sending emails (the email channel is disabled when empty)")
+	smtpFrom := flag.String("smtp-from", "", "Sender address of emails")
+	smtpUsername := flag.String("smtp-username", "", "Username for SMTP PLAIN authentication (no authentication when empty)")
+	smtpPassword := flag.String("smtp-password", "", "Password for SMTP PLAIN authentication")
+	webhookSecret := flag.String("webhook-secret", "", "Secret signing the requests of the webhook channel (unsigned when empty)")
+	eventBus := flag.String("event-bus", "", "URL of the message bus to consume domain events from: nats://host:port or kafka://host:port (events are only received on POST /events when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	// Initialize the SQLite database
+	// By default this will create 'notifications.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Register the delivery channels users can choose from
+	channels := channel.NewRegistry()
+	channels.Register(channel.NameLog, channel.NewLogChannel())
+	channels.Register(channel.NameWebhook, channel.NewWebhookChannel(&http.Client{Timeout: 10 * time.Second}, *webhookSecret))
+	if *smtpAddr != "" {
+		emailChannel, err := channel.NewEmailChannel(*smtpAddr, *smtpFrom, *smtpUsername, *smtpPassword)
+		if err != nil {
+			log.Fatalf("Invalid email channel settings: %v", err)
+		}
+		channels.Register(channel.NameEmail, emailChannel)
+	}
+	defaults := splitList(*defaultChannels)
+	for _, name := range defaults {
+		if channels.Get(name) == nil {
+			log.Fatalf("Invalid -default-channels: channel %q is not enabled (enabled: %v)", name, channels.Names())
+		}
+	}
+	log.Printf("Enabled channels: %v, default channels: %v", channels.Names(), defaults)
+
+	// Initialize repository, service, and handler layers
+	notificationRepo := repository.NewSQLiteNotificationRepository(db)
+	notificationService := service.NewNotificationService(repository.NewSQLitePreferencesRepository(db), notificationRepo, channels, defaults)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+
+	// Consume domain events from the event bus as well, when there is one
+	if *eventBus != "" {
+		events, err := bus.Open(*eventBus)
+		if err != nil {
+			log.Fatalf("Failed to open event bus: %v", err)
+		}
+		defer events.Close()
+		if _, err := bus.SubscribeTopics(context.Background(), events, service.ConsumedEvents, "notification-service", notificationHandler.ConsumeMessage); err != nil {
+			log.Fatalf("Failed to subscribe to the event bus: %v", err)
+		}
+		log.Printf("Consuming %v from %s", service.ConsumedEvents, *eventBus)
+	}
+
+	// Deliver queued notifications in the background
+	dispatcher := service.NewDispatcher(notificationRepo, channels, *dispatchInterval)
+	go dispatcher.Run(context.Background())
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Notification Service API routes
+	// POST /events: Consume a domain event from the user or listing service's relay
+	r.HandleFunc("/events", notificationHandler.ConsumeEvent).Methods("POST")
+	// GET and PUT /users/{id}/preferences, GET /users/{id}/notifications: Get and replace the
+	// notification preferences of a user, and list their notifications and delivery status, as
+	// specified by api/openapi.yaml
+	api.RegisterNotificationsRoutes(r, notificationHandler)
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("notification-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Start the HTTP server
+	log.Printf("Notification Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
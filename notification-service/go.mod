@@ -0,0 +1,34 @@
+module notification-service
+
+go 1.24.4
+
+require (
+	apperrors v0.0.0
+	bus v0.0.0
+	contracts v0.0.0
+	github.com/gorilla/mux v1.8.1
+	github.com/mattn/go-sqlite3 v1.14.28
+	httpmiddleware v0.0.0
+	money v0.0.0
+)
+
+require (
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/nats-io/nats.go v1.48.0 // indirect
+	github.com/nats-io/nkeys v0.4.11 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/pierrec/lz4/v4 v4.1.15 // indirect
+	github.com/segmentio/kafka-go v0.4.50 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+)
+
+replace apperrors => ../pkg/apperrors
+
+replace bus => ../pkg/bus
+
+replace contracts => ../pkg/contracts
+
+replace httpmiddleware => ../pkg/httpmiddleware
+
+replace money => ../pkg/money
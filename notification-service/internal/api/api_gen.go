@@ -0,0 +1,51 @@
+// Code generated by openapi from notification-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// NotificationsHandler handles the operations of the notifications tag: the notification
+// preferences of users, and their notifications.
+type NotificationsHandler interface {
+	// GetPreferences handles GET /users/{id}/preferences: Get the notification preferences of a
+	// user, the defaults if they never saved any.
+	GetPreferences(w http.ResponseWriter, r *http.Request)
+
+	// UpdatePreferences handles PUT /users/{id}/preferences: Replace the notification preferences
+	// of a user.
+	UpdatePreferences(w http.ResponseWriter, r *http.Request)
+
+	// ListNotifications handles GET /users/{id}/notifications: Get a page of the notifications of a
+	// user and their delivery status, most recent first.
+	ListNotifications(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedNotificationsHandler answers every operation of NotificationsHandler with 501 Not
+// Implemented. Handlers embed it to implement the interface while their operations are written.
+type UnimplementedNotificationsHandler struct{}
+
+// GetPreferences answers GET /users/{id}/preferences with 501 Not Implemented.
+func (UnimplementedNotificationsHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// UpdatePreferences answers PUT /users/{id}/preferences with 501 Not Implemented.
+func (UnimplementedNotificationsHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// ListNotifications answers GET /users/{id}/notifications with 501 Not Implemented.
+func (UnimplementedNotificationsHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterNotificationsRoutes registers the routes of the operations of NotificationsHandler on r.
+func RegisterNotificationsRoutes(r *mux.Router, h NotificationsHandler) {
+	r.HandleFunc("/users/{id}/preferences", h.GetPreferences).Methods("GET")
+	r.HandleFunc("/users/{id}/preferences", h.UpdatePreferences).Methods("PUT")
+	r.HandleFunc("/users/{id}/notifications", h.ListNotifications).Methods("GET")
+}
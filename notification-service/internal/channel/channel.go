@@ -0,0 +1,61 @@
+// Package channel implements the ways notifications are delivered to users. Each channel is
+// registered under a name that users pick in their preferences.
+package channel
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"notification-service/internal/model"
+)
+
+// Names of the built-in channels.
+const (
+	NameLog     = "log"
+	NameEmail   = "email"
+	NameWebhook = "webhook"
+)
+
+// ErrNoDestination is returned when a notification needs a destination (an email address,
+// a URL) and has none.
+var ErrNoDestination = errors.New("notification has no destination")
+
+// Channel delivers notifications. Implementations must be safe for concurrent use.
+type Channel interface {
+	// Send delivers a notification to its Destination. Errors are retried later.
+	Send(ctx context.Context, n model.Notification) error
+	// NeedsDestination reports whether notifications require a Destination, taken from the
+	// user's preferences.
+	NeedsDestination() bool
+}
+
+// Registry holds the channels enabled in this instance.
+type Registry struct {
+	channels map[string]Channel
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{channels: make(map[string]Channel)}
+}
+
+// Register enables a channel under the given name, replacing any channel of that name.
+func (r *Registry) Register(name string, channel Channel) {
+	r.channels[name] = channel
+}
+
+// Get returns the channel registered under name, or nil.
+func (r *Registry) Get(name string) Channel {
+	return r.channels[name]
+}
+
+// Names returns the names of the registered channels, sorted.
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.channels))
+	for name := range r.channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,68 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"notification-service/internal/model"
+)
+
+// EmailChannel sends notifications as plain text emails through an SMTP server.
+type EmailChannel struct {
+	addr string    // host:port of the SMTP server
+	from string    // Sender address
+	auth smtp.Auth // nil when the server needs no authentication
+}
+
+// NewEmailChannel creates a new EmailChannel sending from the given address through the SMTP
+// server at addr (host:port). PLAIN authentication is used when username is not empty; the
+// standard library only allows it over TLS or to localhost.
+func NewEmailChannel(addr, from, username, password string) (*EmailChannel, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP address %q: %w", addr, err)
+	}
+	if from == "" {
+		return nil, fmt.Errorf("a sender address is required")
+	}
+	channel := &EmailChannel{addr: addr, from: from}
+	if username != "" {
+		channel.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return channel, nil
+}
+
+// Send implements Channel.
+func (c *EmailChannel) Send(ctx context.Context, n model.Notification) error {
+	if n.Destination == "" {
+		return ErrNoDestination
+	}
+	// Header values come from events; newlines would allow injecting headers
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(n.Subject)
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "From: %s\r\n", c.from)
+	fmt.Fprintf(&message, "To: %s\r\n", n.Destination)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	message.WriteString("MIME-Version: 1.0\r\n")
+	message.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	message.WriteString("\r\n")
+	message.WriteString(strings.ReplaceAll(n.Body, "\n", "\r\n"))
+	message.WriteString("\r\n")
+
+	if err := smtp.SendMail(c.addr, c.auth, c.from, []string{n.Destination}, []byte(message.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// NeedsDestination implements Channel.
+func (c *EmailChannel) NeedsDestination() bool {
+	return true
+}
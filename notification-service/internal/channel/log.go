@@ -0,0 +1,28 @@
+package channel
+
+import (
+	"context"
+	"log"
+
+	"notification-service/internal/model"
+)
+
+// LogChannel writes notifications to the service log. It is meant for development, and as a
+// record of what would have been sent.
+type LogChannel struct{}
+
+// NewLogChannel creates a new LogChannel.
+func NewLogChannel() *LogChannel {
+	return &LogChannel{}
+}
+
+// Send implements Channel.
+func (c *LogChannel) Send(ctx context.Context, n model.Notification) error {
+	log.Printf("Notification %d to user %d (%s): %s", n.ID, n.UserID, n.EventType, n.Subject)
+	return nil
+}
+
+// NeedsDestination implements Channel.
+func (c *LogChannel) NeedsDestination() bool {
+	return false
+}
@@ -0,0 +1,91 @@
+package channel
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"notification-service/internal/model"
+)
+
+// WebhookChannel POSTs notifications as JSON to the URL in the user's preferences.
+//
+// Requests are signed like the listing service's webhooks: X-Notification-Signature is
+// "sha256=" followed by the hex HMAC-SHA256 of "{timestamp}.{body}" with the secret, and
+// X-Notification-Timestamp is the Unix time in seconds, so receivers can reject replays.
+type WebhookChannel struct {
+	httpClient *http.Client
+	secret     string // Unsigned when empty
+}
+
+// NewWebhookChannel creates a new WebhookChannel signing its requests with secret.
+func NewWebhookChannel(httpClient *http.Client, secret string) *WebhookChannel {
+	return &WebhookChannel{httpClient: httpClient, secret: secret}
+}
+
+// webhookPayload is the body POSTed for a notification.
+type webhookPayload struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	EventType string `json:"event_type"`
+	EventID   int64  `json:"event_id"`
+	Subject   string `json:"subject"`
+	Body      string `json:"body"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// Send implements Channel. Any response other than 2xx is an error.
+func (c *WebhookChannel) Send(ctx context.Context, n model.Notification) error {
+	if n.Destination == "" {
+		return ErrNoDestination
+	}
+	body, err := json.Marshal(webhookPayload{
+		ID:        n.ID,
+		UserID:    n.UserID,
+		EventType: n.EventType,
+		EventID:   n.EventID,
+		Subject:   n.Subject,
+		Body:      n.Body,
+		CreatedAt: n.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", n.Destination, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Notification-Id", strconv.FormatInt(n.ID, 10))
+	if c.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(c.secret))
+		mac.Write([]byte(timestamp + "."))
+		mac.Write(body)
+		req.Header.Set("X-Notification-Timestamp", timestamp)
+		req.Header.Set("X-Notification-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned non-OK status: %s", resp.Status)
+	}
+	return nil
+}
+
+// NeedsDestination implements Channel.
+func (c *WebhookChannel) NeedsDestination() bool {
+	return true
+}
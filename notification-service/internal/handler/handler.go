@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"apperrors"
+	"contracts"
+	"notification-service/internal/api"
+	"notification-service/internal/model"
+	"notification-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the JSON body of requests.
+const maxBodyBytes = 64 << 10
+
+// maxPageSize bounds the page size of notification listings.
+const maxPageSize = 100
+
+// NotificationHandler handles HTTP requests related to events, preferences and notifications.
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NotificationHandler implements the operations of the spec's notifications tag.
+var _ api.NotificationsHandler = (*NotificationHandler)(nil)
+
+// NewNotificationHandler creates a new instance of NotificationHandler.
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{notificationService: notificationService}
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PreferencesResponse is the response structure for preferences.
+type PreferencesResponse struct {
+	Result      bool               `json:"result"`
+	Preferences *model.Preferences `json:"preferences,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// NotificationsResponse is the response structure for notification listings.
+type NotificationsResponse struct {
+	Result        bool                 `json:"result"`
+	Notifications []model.Notification `json:"notifications,omitempty"`
+	Error         string               `json:"error,omitempty"`
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the user service's
+// and the listing service's event relays. Events are delivered at least once; duplicates and
+// events of other types are acknowledged without effect, so the relays do not retry them.
+func (h *NotificationHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.notificationService.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "handling event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// GetPreferences handles GET /users/{id}/preferences requests.
+// Users who never saved preferences get the defaults.
+func (h *NotificationHandler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notificationService.GetPreferences(userID)
+	if err != nil {
+		writeError(w, err, "loading preferences of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(PreferencesResponse{Result: true, Preferences: prefs})
+}
+
+// UpdatePreferences handles PUT /users/{id}/preferences requests.
+// It accepts a JSON body with the user's email, webhook_url and channels per event type, and
+// replaces the previous preferences. Event types left out use the default channels.
+func (h *NotificationHandler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var input contracts.UpdatePreferencesRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PreferencesResponse{Result: false, Error: "Invalid JSON request body"})
+		return
+	}
+
+	prefs, err := h.notificationService.UpdatePreferences(model.Preferences{
+		UserID:     userID,
+		Email:      input.Email,
+		WebhookURL: input.WebhookURL,
+		Channels:   input.Channels,
+	})
+	if err != nil {
+		writeError(w, err, "saving preferences of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(PreferencesResponse{Result: true, Preferences: prefs})
+}
+
+// ListNotifications handles GET /users/{id}/notifications requests.
+// It returns a page of the user's notifications and their delivery status, most recent first,
+// with page_num and page_size query parameters (default 1 and 10, at most 100 per page).
+func (h *NotificationHandler) ListNotifications(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	notifications, err := h.notificationService.ListNotifications(userID, pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "listing notifications of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(NotificationsResponse{Result: true, Notifications: notifications})
+}
+
+// parseUserID reads the {id} route variable, writing a 400 response if it is not a valid user ID.
+func parseUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PreferencesResponse{Result: false, Error: "Invalid user ID format"})
+		return 0, false
+	}
+	return userID, true
+}
+
+// writeError answers an error of NotificationService with the status of its code, logging
+// internal errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
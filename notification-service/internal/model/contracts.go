@@ -0,0 +1,11 @@
+package model
+
+import "contracts"
+
+// These conversions break the build when the Notification Service's representations and the
+// contracts generated from its spec drift apart. They compare field names and types; the JSON
+// names are kept in sync by hand.
+var (
+	_ = contracts.NotificationPreferences(Preferences{})
+	_ = contracts.Notification(Notification{})
+)
@@ -0,0 +1,60 @@
+package model
+
+import "encoding/json"
+
+// Domain events turned into notifications. user.created comes from the user service, the
+// others from the listing service.
+const (
+	EventUserCreated        = "user.created"
+	EventListingCreated     = "listing.created"
+	EventOfferAccepted      = "offer.accepted"
+	EventSavedSearchMatched = "saved_search.matched"
+)
+
+// EventTypes lists the events users can be notified of.
+var EventTypes = []string{EventUserCreated, EventListingCreated, EventOfferAccepted, EventSavedSearchMatched}
+
+// Event is a domain event delivered by the user service or the listing service.
+// Event IDs are only unique per service, but event types are too, so (type, id) identifies an event.
+type Event struct {
+	ID        int64           `json:"id"`         // ID of the event in its service's outbox
+	Type      string          `json:"type"`       // Event type, e.g. "offer.accepted"
+	Payload   json.RawMessage `json:"payload"`    // Event-specific data
+	CreatedAt int64           `json:"created_at"` // Timestamp of the event in microseconds
+}
+
+// Preferences are a user's notification settings: where to reach them, and through which
+// channels each event is delivered.
+type Preferences struct {
+	UserID     int64               `json:"user_id"`
+	Email      string              `json:"email,omitempty"`       // Address of the email channel
+	WebhookURL string              `json:"webhook_url,omitempty"` // URL of the webhook channel
+	Channels   map[string][]string `json:"channels"`              // Channels per event type; an empty list mutes the event
+	UpdatedAt  int64               `json:"updated_at,omitempty"`  // Timestamp of the last change in microseconds, 0 for defaults
+}
+
+// Statuses of notifications: pending until delivered, or failed once every attempt failed.
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// Notification is a message to a user about an event, delivered through one channel.
+// The notifications table doubles as the delivery log.
+type Notification struct {
+	ID            int64  `json:"id"`                        // Notification ID, auto-generated by the database
+	UserID        int64  `json:"user_id"`                   // Recipient
+	EventType     string `json:"event_type"`                // Type of the event notified of
+	EventID       int64  `json:"event_id"`                  // ID of the event notified of
+	Channel       string `json:"channel"`                   // Channel delivering the notification, e.g. "email"
+	Destination   string `json:"destination,omitempty"`     // Email address or URL, when the channel needs one
+	Subject       string `json:"subject"`                   // One-line summary
+	Body          string `json:"body"`                      // Plain text message
+	Status        string `json:"status"`                    // StatusPending, StatusSent or StatusFailed
+	Attempts      int    `json:"attempts"`                  // Delivery attempts made so far
+	LastError     string `json:"last_error,omitempty"`      // Error of the last failed attempt
+	CreatedAt     int64  `json:"created_at"`                // Timestamp of creation in microseconds
+	NextAttemptAt int64  `json:"next_attempt_at,omitempty"` // Timestamp of the next attempt while pending
+	SentAt        int64  `json:"sent_at,omitempty"`         // Timestamp of delivery in microseconds
+}
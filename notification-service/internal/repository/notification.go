@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"notification-service/internal/model"
+)
+
+// NotificationRepository defines the interface for notification storage.
+type NotificationRepository interface {
+	RecordEvent(event model.Event, notifications []model.Notification, processedAt int64) (bool, error)
+	FetchDueNotifications(now int64, limit int) ([]model.Notification, error)
+	RecordAttempt(id int64, status string, lastError string, attemptedAt, nextAttemptAt int64) error
+	ListNotifications(userID int64, page, pageSize int) ([]model.Notification, error)
+	CancelPendingNotifications(userID int64, reason string) (int64, error)
+}
+
+// sqliteNotificationRepository implements NotificationRepository for SQLite database.
+type sqliteNotificationRepository struct {
+	db *sql.DB
+}
+
+// createNotificationsTableSQL creates the notifications table, which is both the queue of
+// deliveries and their log.
+const createNotificationsTableSQL = `
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		channel TEXT NOT NULL,
+		destination TEXT NOT NULL DEFAULT '',
+		subject TEXT NOT NULL,
+		body TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT,
+		created_at INTEGER NOT NULL,
+		next_attempt_at INTEGER,
+		sent_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id, id);
+	CREATE INDEX IF NOT EXISTS idx_notifications_due ON notifications(next_attempt_at) WHERE status = 'pending';`
+
+// createProcessedEventsTableSQL creates the processed_events table. Events are delivered at
+// least once, so each event is recorded to ignore duplicates.
+const createProcessedEventsTableSQL = `
+	CREATE TABLE IF NOT EXISTS processed_events (
+		event_type TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		processed_at INTEGER NOT NULL,
+		PRIMARY KEY (event_type, event_id)
+	);`
+
+// notificationColumns lists the columns selected for a notification, in the order expected by scanNotification.
+const notificationColumns = `id, user_id, event_type, event_id, channel, destination, subject, body, status, attempts,
+	last_error, created_at, next_attempt_at, sent_at`
+
+// scanNotification reads a notification selected with notificationColumns.
+func scanNotification(row rowScanner, n *model.Notification) error {
+	var lastError sql.NullString
+	var nextAttemptAt, sentAt sql.NullInt64
+	if err := row.Scan(&n.ID, &n.UserID, &n.EventType, &n.EventID, &n.Channel, &n.Destination, &n.Subject, &n.Body,
+		&n.Status, &n.Attempts, &lastError, &n.CreatedAt, &nextAttemptAt, &sentAt); err != nil {
+		return err
+	}
+	n.LastError = lastError.String
+	n.NextAttemptAt = nextAttemptAt.Int64
+	n.SentAt = sentAt.Int64
+	return nil
+}
+
+// NewSQLiteNotificationRepository creates a new instance of sqliteNotificationRepository.
+func NewSQLiteNotificationRepository(db *sql.DB) NotificationRepository {
+	return &sqliteNotificationRepository{db: db}
+}
+
+// RecordEvent marks an event as processed and queues its notifications, in a single
+// transaction. It returns false, queuing nothing, if the event was already processed.
+func (r *sqliteNotificationRepository) RecordEvent(event model.Event, notifications []model.Notification, processedAt int64) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction for recording event: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	result, err := tx.Exec(
+		`INSERT OR IGNORE INTO processed_events(event_type, event_id, processed_at) VALUES(?, ?, ?)`,
+		event.Type, event.ID, processedAt,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to record event: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record event: %w", err)
+	}
+	if affected == 0 {
+		return false, nil
+	}
+
+	for _, n := range notifications {
+		if _, err := tx.Exec(
+			`INSERT INTO notifications(user_id, event_type, event_id, channel, destination, subject, body, status,
+				created_at, next_attempt_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			n.UserID, n.EventType, n.EventID, n.Channel, n.Destination, n.Subject, n.Body, model.StatusPending,
+			n.CreatedAt, n.CreatedAt,
+		); err != nil {
+			return false, fmt.Errorf("failed to insert notification: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction for recording event: %w", err)
+	}
+	return true, nil
+}
+
+// FetchDueNotifications returns up to limit pending notifications whose next attempt is due at
+// now, oldest first.
+func (r *sqliteNotificationRepository) FetchDueNotifications(now int64, limit int) ([]model.Notification, error) {
+	rows, err := r.db.Query(
+		`SELECT `+notificationColumns+` FROM notifications
+		WHERE status = 'pending' AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due notifications: %w", err)
+	}
+	return collectNotifications(rows, "FetchDueNotifications")
+}
+
+// RecordAttempt records the outcome of a delivery attempt. nextAttemptAt is only kept for
+// notifications that stay pending, and attemptedAt is recorded as the sending time of those
+// that were sent.
+func (r *sqliteNotificationRepository) RecordAttempt(id int64, status string, lastError string, attemptedAt, nextAttemptAt int64) error {
+	_, err := r.db.Exec(
+		`UPDATE notifications SET status = ?, attempts = attempts + 1, last_error = ?,
+			next_attempt_at = CASE WHEN ? = 'pending' THEN ? END,
+			sent_at = CASE WHEN ? = 'sent' THEN ? END
+		WHERE id = ?`,
+		status, sql.NullString{String: lastError, Valid: lastError != ""},
+		status, nextAttemptAt, status, attemptedAt, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery attempt: %w", err)
+	}
+	return nil
+}
+
+// ListNotifications returns a page of the user's notifications, most recent first.
+func (r *sqliteNotificationRepository) ListNotifications(userID int64, page, pageSize int) ([]model.Notification, error) {
+	rows, err := r.db.Query(
+		`SELECT `+notificationColumns+` FROM notifications WHERE user_id = ? ORDER BY id DESC LIMIT ? OFFSET ?`,
+		userID, pageSize, (page-1)*pageSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	return collectNotifications(rows, "ListNotifications")
+}
+
+// CancelPendingNotifications marks the user's pending notifications as failed with the given
+// reason, and returns how many there were.
+func (r *sqliteNotificationRepository) CancelPendingNotifications(userID int64, reason string) (int64, error) {
+	result, err := r.db.Exec(
+		`UPDATE notifications SET status = 'failed', last_error = ?, next_attempt_at = NULL
+		WHERE user_id = ? AND status = 'pending'`,
+		reason, userID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel notifications: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel notifications: %w", err)
+	}
+	return affected, nil
+}
+
+// collectNotifications reads every notification from rows and closes them. what names the
+// query in error messages.
+func collectNotifications(rows *sql.Rows, what string) ([]model.Notification, error) {
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	notifications := []model.Notification{}
+	for rows.Next() {
+		var n model.Notification
+		if err := scanNotification(rows, &n); err != nil {
+			return nil, fmt.Errorf("failed to scan notification row: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for %s: %w", what, err)
+	}
+	return notifications, nil
+}
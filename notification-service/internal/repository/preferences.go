@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"notification-service/internal/model"
+)
+
+// PreferencesRepository defines the interface for notification preferences storage.
+type PreferencesRepository interface {
+	GetPreferences(userID int64) (*model.Preferences, error)
+	SavePreferences(prefs model.Preferences) error
+	DeletePreferences(userID int64) error
+}
+
+// sqlitePreferencesRepository implements PreferencesRepository for SQLite database.
+type sqlitePreferencesRepository struct {
+	db *sql.DB
+}
+
+// createPreferencesTableSQL creates the preferences table. The channels per event type are
+// stored as a JSON object.
+const createPreferencesTableSQL = `
+	CREATE TABLE IF NOT EXISTS preferences (
+		user_id INTEGER NOT NULL PRIMARY KEY,
+		email TEXT NOT NULL DEFAULT '',
+		webhook_url TEXT NOT NULL DEFAULT '',
+		channels TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);`
+
+// NewSQLitePreferencesRepository creates a new instance of sqlitePreferencesRepository.
+func NewSQLitePreferencesRepository(db *sql.DB) PreferencesRepository {
+	return &sqlitePreferencesRepository{db: db}
+}
+
+// GetPreferences returns the user's stored preferences, or nil if the user never saved any.
+func (r *sqlitePreferencesRepository) GetPreferences(userID int64) (*model.Preferences, error) {
+	row := r.db.QueryRow(`SELECT user_id, email, webhook_url, channels, updated_at FROM preferences WHERE user_id = ?`, userID)
+	var prefs model.Preferences
+	var channels string
+	if err := row.Scan(&prefs.UserID, &prefs.Email, &prefs.WebhookURL, &channels, &prefs.UpdatedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load preferences: %w", err)
+	}
+	if err := json.Unmarshal([]byte(channels), &prefs.Channels); err != nil {
+		return nil, fmt.Errorf("failed to decode channels of user %d: %w", userID, err)
+	}
+	return &prefs, nil
+}
+
+// SavePreferences stores the user's preferences, replacing any previous ones.
+func (r *sqlitePreferencesRepository) SavePreferences(prefs model.Preferences) error {
+	channels, err := json.Marshal(prefs.Channels)
+	if err != nil {
+		return fmt.Errorf("failed to encode channels: %w", err)
+	}
+	_, err = r.db.Exec(
+		`INSERT INTO preferences(user_id, email, webhook_url, channels, updated_at) VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET email = excluded.email, webhook_url = excluded.webhook_url,
+			channels = excluded.channels, updated_at = excluded.updated_at`,
+		prefs.UserID, prefs.Email, prefs.WebhookURL, string(channels), prefs.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
+
+// DeletePreferences deletes the user's preferences, if any.
+func (r *sqlitePreferencesRepository) DeletePreferences(userID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM preferences WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete preferences: %w", err)
+	}
+	return nil
+}
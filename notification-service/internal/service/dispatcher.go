@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"notification-service/internal/channel"
+	"notification-service/internal/model"
+	"notification-service/internal/repository"
+)
+
+const (
+	// dispatchBatchSize is the maximum number of notifications attempted per round.
+	dispatchBatchSize = 100
+	// maxAttempts is the number of attempts made per notification before it is given up.
+	maxAttempts = 8
+	// retryDelay is the delay before the first retry, doubling after each failed attempt up to maxRetryDelay.
+	retryDelay    = 30 * time.Second
+	maxRetryDelay = time.Hour
+	// sendTimeout bounds a single delivery attempt.
+	sendTimeout = 10 * time.Second
+	// maxLoggedErrorLength bounds the errors kept in the delivery log.
+	maxLoggedErrorLength = 500
+)
+
+// Dispatcher delivers queued notifications through their channels. A failed delivery is
+// retried with exponential backoff, and marked as failed after maxAttempts attempts.
+// Notifications are independent, so one channel being down does not hold up the others.
+type Dispatcher struct {
+	notifications repository.NotificationRepository
+	channels      *channel.Registry
+	interval      time.Duration
+}
+
+// NewDispatcher creates a new Dispatcher polling for due notifications every interval.
+func NewDispatcher(notifications repository.NotificationRepository, channels *channel.Registry, interval time.Duration) *Dispatcher {
+	return &Dispatcher{notifications: notifications, channels: channels, interval: interval}
+}
+
+// Run delivers due notifications until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchDue performs a single round.
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	due, err := d.notifications.FetchDueNotifications(time.Now().UnixMicro(), dispatchBatchSize)
+	if err != nil {
+		log.Printf("Error fetching due notifications: %v", err)
+		return
+	}
+	for _, n := range due {
+		d.deliver(ctx, n)
+	}
+}
+
+// deliver attempts a notification and records the outcome.
+func (d *Dispatcher) deliver(ctx context.Context, n model.Notification) {
+	var err error
+	if ch := d.channels.Get(n.Channel); ch == nil {
+		// Give up right away, the channel was disabled since the notification was queued
+		n.Attempts = maxAttempts - 1
+		err = fmt.Errorf("channel %s is not enabled", n.Channel)
+	} else {
+		sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+		err = ch.Send(sendCtx, n)
+		cancel()
+	}
+
+	now := time.Now()
+	status, lastError, nextAttemptAt := model.StatusSent, "", int64(0)
+	if err != nil {
+		lastError = err.Error()
+		if len(lastError) > maxLoggedErrorLength {
+			lastError = lastError[:maxLoggedErrorLength]
+		}
+		attempts := n.Attempts + 1
+		if attempts >= maxAttempts {
+			log.Printf("Giving up notification %d to user %d through %s after %d attempts: %v", n.ID, n.UserID, n.Channel, attempts, err)
+			status = model.StatusFailed
+		} else {
+			status = model.StatusPending
+			nextAttemptAt = now.Add(backoff(attempts)).UnixMicro()
+		}
+	}
+	if err := d.notifications.RecordAttempt(n.ID, status, lastError, now.UnixMicro(), nextAttemptAt); err != nil {
+		log.Printf("Error recording delivery of notification %d: %v", n.ID, err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	delay := retryDelay << (attempts - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"money"
+	"notification-service/internal/model"
+)
+
+// message is a notification to render for one user.
+type message struct {
+	userID  int64
+	subject string
+	body    string
+}
+
+// Payloads of the events notified of, as emitted by the user service and the listing service.
+type (
+	userCreatedPayload struct {
+		UserID int64  `json:"user_id"`
+		Name   string `json:"name"`
+	}
+	listingCreatedPayload struct {
+		ListingID        int64  `json:"listing_id"`
+		UserID           int64  `json:"user_id"`
+		ListingType      string `json:"listing_type"`
+		Price            int64  `json:"price"`
+		Currency         string `json:"currency"`
+		ModerationStatus string `json:"moderation_status"`
+	}
+	offerAcceptedPayload struct {
+		OfferID   int64  `json:"offer_id"`
+		ListingID int64  `json:"listing_id"`
+		SellerID  int64  `json:"seller_id"`
+		BuyerID   int64  `json:"buyer_id"`
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+	}
+	savedSearchMatchedPayload struct {
+		SavedSearchID int64  `json:"saved_search_id"`
+		UserID        int64  `json:"user_id"`
+		ListingID     int64  `json:"listing_id"`
+		ListingType   string `json:"listing_type"`
+		Price         int64  `json:"price"`
+		Currency      string `json:"currency"`
+	}
+)
+
+// renderMessages returns the notifications an event produces, one per recipient. Events of
+// other types produce none.
+func renderMessages(event model.Event) ([]message, error) {
+	switch event.Type {
+	case model.EventUserCreated:
+		var p userCreatedPayload
+		if err := decodePayload(event, &p); err != nil {
+			return nil, err
+		}
+		return []message{{
+			userID:  p.UserID,
+			subject: "Welcome, " + p.Name,
+			body:    fmt.Sprintf("Hi %s,\n\nYour account has been created. You can now list properties to rent or sell, and save searches to hear about new ones.", p.Name),
+		}}, nil
+
+	case model.EventListingCreated:
+		var p listingCreatedPayload
+		if err := decodePayload(event, &p); err != nil {
+			return nil, err
+		}
+		body := fmt.Sprintf("Your listing %d (%s, %s) has been created.", p.ListingID, p.ListingType, formatAmount(p.Price, p.Currency))
+		if p.ModerationStatus == "pending" {
+			body += " It will be publicly listed once a moderator approves it."
+		}
+		return []message{{userID: p.UserID, subject: fmt.Sprintf("Listing %d created", p.ListingID), body: body}}, nil
+
+	case model.EventOfferAccepted:
+		var p offerAcceptedPayload
+		if err := decodePayload(event, &p); err != nil {
+			return nil, err
+		}
+		return []message{{
+			userID:  p.BuyerID,
+			subject: fmt.Sprintf("Your offer on listing %d was accepted", p.ListingID),
+			body: fmt.Sprintf("The seller accepted your offer %d of %s on listing %d. They will be in touch to complete the sale.",
+				p.OfferID, formatAmount(p.Amount, p.Currency), p.ListingID),
+		}}, nil
+
+	case model.EventSavedSearchMatched:
+		var p savedSearchMatchedPayload
+		if err := decodePayload(event, &p); err != nil {
+			return nil, err
+		}
+		return []message{{
+			userID:  p.UserID,
+			subject: "New listing matching your saved search",
+			body: fmt.Sprintf("Listing %d (%s, %s) matches your saved search %d.",
+				p.ListingID, p.ListingType, formatAmount(p.Price, p.Currency), p.SavedSearchID),
+		}}, nil
+	}
+	return nil, nil
+}
+
+// decodePayload decodes an event's payload into v, one of the payload types above, and checks it.
+func decodePayload(event model.Event, v any) error {
+	if err := json.Unmarshal(event.Payload, v); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, event.Type, err)
+	}
+	return checkPayload(event.Type, v)
+}
+
+// checkPayload reports payloads lacking the IDs notifications are addressed with.
+func checkPayload(eventType string, v any) error {
+	var missing bool
+	switch p := v.(type) {
+	case *userCreatedPayload:
+		missing = p.UserID <= 0
+	case *listingCreatedPayload:
+		missing = p.ListingID <= 0 || p.UserID <= 0
+	case *offerAcceptedPayload:
+		missing = p.OfferID <= 0 || p.BuyerID <= 0
+	case *savedSearchMatchedPayload:
+		missing = p.SavedSearchID <= 0 || p.UserID <= 0
+	}
+	if missing {
+		return fmt.Errorf("%w: %s: missing IDs", ErrInvalidPayload, eventType)
+	}
+	return nil
+}
+
+// formatAmount formats an amount of minor units in major units, e.g. "12.50 USD".
+func formatAmount(amount int64, currency string) string {
+	return money.Money{Amount: amount, Currency: currency}.String()
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"slices"
+	"time"
+
+	"apperrors"
+	"notification-service/internal/channel"
+	"notification-service/internal/model"
+	"notification-service/internal/repository"
+)
+
+// eventUserDeleted is the user service event after which a user's preferences are forgotten
+// and their pending notifications cancelled.
+const eventUserDeleted = "user.deleted"
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus: those
+// users can be notified of, and user.deleted.
+var ConsumedEvents = append(slices.Clone(model.EventTypes), eventUserDeleted)
+
+// Errors returned by NotificationService.
+var (
+	// ErrInvalidPayload is returned for events whose payload cannot be notified of.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+	// ErrInvalidPreferences is returned when preferences name unknown events or channels, or
+	// lack the address a chosen channel needs.
+	ErrInvalidPreferences = apperrors.New(apperrors.Validation, "invalid preferences")
+)
+
+// NotificationService turns domain events into notifications according to each user's
+// preferences, and manages those preferences.
+type NotificationService struct {
+	prefs           repository.PreferencesRepository
+	notifications   repository.NotificationRepository
+	channels        *channel.Registry
+	defaultChannels []string
+}
+
+// NewNotificationService creates a new instance of NotificationService. Users without
+// preferences for an event are notified through defaultChannels.
+func NewNotificationService(prefs repository.PreferencesRepository, notifications repository.NotificationRepository,
+	channels *channel.Registry, defaultChannels []string) *NotificationService {
+	return &NotificationService{
+		prefs:           prefs,
+		notifications:   notifications,
+		channels:        channels,
+		defaultChannels: defaultChannels,
+	}
+}
+
+// HandleEvent queues the notifications of an event. It returns false for events of other
+// types, and for events already handled, which are delivered again whenever the sender missed
+// the acknowledgement.
+func (s *NotificationService) HandleEvent(event model.Event) (bool, error) {
+	if event.Type == eventUserDeleted {
+		return true, s.forgetUser(event)
+	}
+	messages, err := renderMessages(event)
+	if err != nil || messages == nil {
+		return false, err
+	}
+
+	now := time.Now().UnixMicro()
+	var notifications []model.Notification
+	for _, msg := range messages {
+		prefs, err := s.GetPreferences(msg.userID)
+		if err != nil {
+			return false, err
+		}
+		for _, name := range prefs.Channels[event.Type] {
+			ch := s.channels.Get(name)
+			if ch == nil {
+				// The channel was disabled after the preferences were saved
+				continue
+			}
+			destination := destinationFor(name, prefs)
+			if ch.NeedsDestination() && destination == "" {
+				continue
+			}
+			notifications = append(notifications, model.Notification{
+				UserID:      msg.userID,
+				EventType:   event.Type,
+				EventID:     event.ID,
+				Channel:     name,
+				Destination: destination,
+				Subject:     msg.subject,
+				Body:        msg.body,
+				CreatedAt:   now,
+			})
+		}
+	}
+
+	recorded, err := s.notifications.RecordEvent(event, notifications, now)
+	if err != nil {
+		return false, err
+	}
+	if recorded && len(notifications) > 0 {
+		log.Printf("Queued %d notifications for event %s %d", len(notifications), event.Type, event.ID)
+	}
+	return true, nil
+}
+
+// GetPreferences returns the user's preferences, or the defaults if the user never saved any.
+// Event types missing from saved preferences use the default channels too.
+func (s *NotificationService) GetPreferences(userID int64) (*model.Preferences, error) {
+	prefs, err := s.prefs.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+	if prefs == nil {
+		prefs = &model.Preferences{UserID: userID}
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = make(map[string][]string)
+	}
+	for _, eventType := range model.EventTypes {
+		if _, ok := prefs.Channels[eventType]; !ok {
+			prefs.Channels[eventType] = slices.Clone(s.defaultChannels)
+		}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences validates and saves the user's preferences, replacing the previous ones.
+func (s *NotificationService) UpdatePreferences(prefs model.Preferences) (*model.Preferences, error) {
+	if prefs.Email != "" {
+		address, err := mail.ParseAddress(prefs.Email)
+		if err != nil || address.Name != "" {
+			return nil, fmt.Errorf("%w: invalid email address %q", ErrInvalidPreferences, prefs.Email)
+		}
+	}
+	if prefs.WebhookURL != "" {
+		u, err := url.Parse(prefs.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: webhook_url must be an http or https URL", ErrInvalidPreferences)
+		}
+	}
+	channels := make(map[string][]string, len(prefs.Channels))
+	for eventType, names := range prefs.Channels {
+		if !slices.Contains(model.EventTypes, eventType) {
+			return nil, fmt.Errorf("%w: unknown event %q (expected one of %v)", ErrInvalidPreferences, eventType, model.EventTypes)
+		}
+		names = slices.Clone(names)
+		slices.Sort(names)
+		names = slices.Compact(names)
+		for _, name := range names {
+			ch := s.channels.Get(name)
+			if ch == nil {
+				return nil, fmt.Errorf("%w: unknown channel %q (expected one of %v)", ErrInvalidPreferences, name, s.channels.Names())
+			}
+			if ch.NeedsDestination() && destinationFor(name, &prefs) == "" {
+				return nil, fmt.Errorf("%w: channel %q requires %s", ErrInvalidPreferences, name, destinationField(name))
+			}
+		}
+		if names == nil {
+			names = []string{}
+		}
+		channels[eventType] = names
+	}
+	prefs.Channels = channels
+	prefs.UpdatedAt = time.Now().UnixMicro()
+
+	if err := s.prefs.SavePreferences(prefs); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(prefs.UserID)
+}
+
+// ListNotifications returns a page of the user's notifications, most recent first.
+func (s *NotificationService) ListNotifications(userID int64, page, pageSize int) ([]model.Notification, error) {
+	return s.notifications.ListNotifications(userID, page, pageSize)
+}
+
+// forgetUser deletes the preferences of a deleted user, which hold their contact details, and
+// cancels their pending notifications. Doing it again for a duplicate event is harmless.
+func (s *NotificationService) forgetUser(event model.Event) error {
+	var p struct {
+		UserID int64 `json:"user_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &p); err != nil || p.UserID <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+	}
+	if err := s.prefs.DeletePreferences(p.UserID); err != nil {
+		return err
+	}
+	cancelled, err := s.notifications.CancelPendingNotifications(p.UserID, "user was deleted")
+	if err != nil {
+		return err
+	}
+	log.Printf("Deleted preferences of deleted user %d and cancelled %d pending notifications", p.UserID, cancelled)
+	return nil
+}
+
+// destinationFor returns where a channel delivers the user's notifications, "" if nowhere.
+func destinationFor(channelName string, prefs *model.Preferences) string {
+	switch channelName {
+	case channel.NameEmail:
+		return prefs.Email
+	case channel.NameWebhook:
+		return prefs.WebhookURL
+	}
+	return ""
+}
+
+// destinationField names the preferences field holding a channel's destination.
+func destinationField(channelName string) string {
+	if channelName == channel.NameWebhook {
+		return "webhook_url"
+	}
+	return channelName
+}
@@ -0,0 +1,52 @@
+// Package apperrors classifies the errors of the services by what went wrong rather than by
+// their messages, and maps them to HTTP responses in one place. Service and repository layers
+// declare their sentinel errors with New and wrap them with fmt.Errorf("%w: ...") as before;
+// handlers answer any error with Write, which responds with the status of its code and a
+// problem+json body (RFC 9457).
+package apperrors
+
+import "errors"
+
+// Code classifies an error by what went wrong.
+type Code string
+
+// Supported codes. Errors without one are Internal.
+const (
+	NotFound     Code = "not_found"    // The requested entity does not exist
+	Conflict     Code = "conflict"     // The request conflicts with the entity's current state
+	Validation   Code = "validation"   // The request's parameters or fields are invalid
+	Forbidden    Code = "forbidden"    // The caller may not act on the entity
+	Unauthorized Code = "unauthorized" // The caller's credentials are missing or invalid
+	Unavailable  Code = "unavailable"  // A dependency is down or overloaded; retrying may help
+	Internal     Code = "internal"     // Anything else, e.g. a bug or a failed query
+)
+
+// Error is an error with a code, usually a sentinel of a service or repository layer.
+type Error struct {
+	Code    Code
+	Message string
+}
+
+// New returns an error with the given code and message.
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// CodeOf returns the code of the first Error in err's chain, or Internal if there is none.
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return Internal
+}
+
+// Is reports whether err has the given code.
+func Is(err error, code Code) bool {
+	return err != nil && CodeOf(err) == code
+}
@@ -0,0 +1,3 @@
+module apperrors
+
+go 1.24.4
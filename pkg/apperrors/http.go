@@ -0,0 +1,71 @@
+package apperrors
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of the bodies written by Write.
+const ProblemContentType = "application/problem+json"
+
+// internalDetail is the detail of internal errors, whose messages are only logged.
+const internalDetail = "Internal server error"
+
+// statuses maps codes to the statuses they are answered with.
+var statuses = map[Code]int{
+	NotFound:     http.StatusNotFound,
+	Conflict:     http.StatusConflict,
+	Validation:   http.StatusBadRequest,
+	Forbidden:    http.StatusForbidden,
+	Unauthorized: http.StatusUnauthorized,
+	Unavailable:  http.StatusServiceUnavailable,
+	Internal:     http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the status err is answered with.
+func HTTPStatus(err error) int {
+	return statuses[CodeOf(err)]
+}
+
+// Problem is a problem details body (RFC 9457). Result and Error repeat the outcome in the
+// {"result": ..., "error": ...} envelope of the services, so existing clients keep reading it.
+type Problem struct {
+	Type   string `json:"type"`   // urn:apperrors: followed by the error's code
+	Title  string `json:"title"`  // Text of the status
+	Status int    `json:"status"` // Repeated from the response
+	Detail string `json:"detail"` // The error's message; generic for internal errors
+	// Machine-readable code of a specific error, e.g. the User Service's name_taken
+	Code   string `json:"code,omitempty"`
+	Result bool   `json:"result"`
+	Error  string `json:"error"` // Set to Detail by WriteProblem
+}
+
+// NewProblem describes err as a problem. The messages of internal errors are not exposed, as
+// they may contain details of the implementation; callers log them instead.
+func NewProblem(err error) Problem {
+	code := CodeOf(err)
+	status := statuses[code]
+	detail := internalDetail
+	if code != Internal {
+		detail = err.Error()
+	}
+	return Problem{
+		Type:   "urn:apperrors:" + string(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// WriteProblem writes problem as the response.
+func WriteProblem(w http.ResponseWriter, problem Problem) {
+	problem.Error = problem.Detail
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// Write answers err with the status of its code and a problem+json body.
+func Write(w http.ResponseWriter, err error) {
+	WriteProblem(w, NewProblem(err))
+}
@@ -0,0 +1,141 @@
+// Package bus moves messages between services through a message broker, behind Publisher and
+// Subscriber interfaces with three implementations: NATS, Kafka, and a channel bus inside the
+// process, so event-driven features can be developed and tested without any broker running.
+//
+// Subscribers sharing a group split the messages of a topic among them, and every group receives
+// every message. Handlers failing a message are retried a few times before it is dropped, so
+// delivery is at least once as far as the broker allows, and handlers must be idempotent.
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ErrClosed is returned when publishing to or subscribing to a closed bus.
+var ErrClosed = errors.New("bus is closed")
+
+// Message is a message published to the subscribers of a topic.
+type Message struct {
+	Topic   string            // Topic of the message, e.g. the type of the event it carries
+	Key     string            // Messages of the same key are kept in order where the broker partitions topics
+	Data    []byte            // Body of the message
+	Headers map[string]string // Optional metadata, e.g. a request ID
+}
+
+// Handler handles a message delivered to a subscription. A returned error has the message
+// delivered again, up to handlerAttempts times.
+type Handler func(ctx context.Context, msg Message) error
+
+// Publisher publishes messages to a broker.
+type Publisher interface {
+	// Publish publishes msg, returning once the broker accepted it.
+	Publish(ctx context.Context, msg Message) error
+	// Close releases the connection to the broker.
+	Close() error
+}
+
+// Subscriber subscribes handlers to the topics of a broker.
+type Subscriber interface {
+	// Subscribe delivers the messages of topic to handler, one at a time, until the subscription
+	// is closed or ctx is cancelled. Subscriptions of the same group share the messages between
+	// them; an empty group receives every message published while it is subscribed.
+	Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error)
+	// Close closes every subscription and releases the connection to the broker.
+	Close() error
+}
+
+// Subscription is a handler subscribed to a topic.
+type Subscription interface {
+	// Close stops the delivery of messages, waiting for the handler to return.
+	Close() error
+}
+
+// Bus is both a Publisher and a Subscriber.
+type Bus interface {
+	Publisher
+	Subscriber
+}
+
+// Open opens the bus at url, whose scheme picks the implementation:
+//   - memory:// for a MemoryBus, only reaching subscribers in the same process
+//   - nats://host:port[,nats://host:port...] for a NATS server or cluster
+//   - kafka://host:port[,host:port...] for Kafka brokers
+func Open(url string) (Bus, error) {
+	scheme, rest, _ := strings.Cut(url, "://")
+	switch scheme {
+	case "memory":
+		return NewMemoryBus(), nil
+	case "nats":
+		return DialNATS(url)
+	case "kafka":
+		return DialKafka(strings.Split(rest, ","))
+	default:
+		return nil, fmt.Errorf("unsupported bus url %q: the scheme must be memory, nats or kafka", url)
+	}
+}
+
+const (
+	// handlerAttempts is the number of times a message is handed to a failing handler before it
+	// is dropped.
+	handlerAttempts = 3
+	// handlerRetryDelay is the delay before a message is handed again to a failing handler,
+	// doubling after each attempt.
+	handlerRetryDelay = 100 * time.Millisecond
+)
+
+// deliver hands msg to handler, retrying it after failures until ctx is cancelled or
+// handlerAttempts is reached.
+func deliver(ctx context.Context, handler Handler, msg Message) {
+	delay := handlerRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := handler(ctx, msg)
+		if err == nil {
+			return
+		}
+		if attempt == handlerAttempts {
+			log.Printf("Error handling message of topic %s, dropping it after %d attempts: %v", msg.Topic, attempt, err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// SubscribeTopics subscribes handler to each of topics in group, as Subscribe does. The returned
+// subscription closes all of them.
+func SubscribeTopics(ctx context.Context, s Subscriber, topics []string, group string, handler Handler) (Subscription, error) {
+	subs := make(multiSubscription, 0, len(topics))
+	for _, topic := range topics {
+		sub, err := s.Subscribe(ctx, topic, group, handler)
+		if err != nil {
+			subs.Close()
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// multiSubscription is a subscription to several topics.
+type multiSubscription []Subscription
+
+// Close closes every subscription, returning the first error.
+func (m multiSubscription) Close() error {
+	var first error
+	for _, sub := range m {
+		if err := sub.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
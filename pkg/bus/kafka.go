@@ -0,0 +1,198 @@
+//go:build kafka
+
+package bus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	// kafkaBatchTimeout bounds how long Publish waits for other messages to batch with.
+	kafkaBatchTimeout = 10 * time.Millisecond
+	// kafkaRetryDelay is the delay before fetching again after a failed fetch.
+	kafkaRetryDelay = time.Second
+)
+
+// KafkaBus is a Bus over Kafka brokers, topics being Kafka topics and groups consumer groups.
+// Messages are partitioned by key, and a message is committed once handled, so messages left
+// unhandled by a crash are delivered again. Topics are created on first use where the brokers
+// allow it.
+type KafkaBus struct {
+	brokers []string
+	writer  *kafka.Writer
+
+	mu     sync.Mutex
+	subs   map[*kafkaSubscription]struct{}
+	closed bool
+}
+
+// DialKafka creates a KafkaBus over the brokers at addrs, each a host:port. Kafka clients
+// connect lazily, so an unreachable broker fails the first Publish rather than DialKafka.
+func DialKafka(addrs []string) (*KafkaBus, error) {
+	if len(addrs) == 0 || addrs[0] == "" {
+		return nil, errors.New("at least one Kafka broker is required")
+	}
+	return &KafkaBus{
+		brokers: addrs,
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(addrs...),
+			Balancer:               &kafka.Hash{},
+			BatchTimeout:           kafkaBatchTimeout,
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		subs: make(map[*kafkaSubscription]struct{}),
+	}, nil
+}
+
+// Publish writes msg to its topic, returning once every in-sync replica stored it.
+func (b *KafkaBus) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	closed := b.closed
+	b.mu.Unlock()
+	if closed {
+		return ErrClosed
+	}
+
+	m := kafka.Message{Topic: msg.Topic, Value: msg.Data}
+	if msg.Key != "" {
+		m.Key = []byte(msg.Key)
+	}
+	for k, v := range msg.Headers {
+		m.Headers = append(m.Headers, kafka.Header{Key: k, Value: []byte(v)})
+	}
+	if err := b.writer.WriteMessages(ctx, m); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe reads topic as a member of the consumer group of group. Without a group, the
+// subscription gets a consumer group of its own, starting at the messages published after it.
+func (b *KafkaBus) Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	startOffset := kafka.FirstOffset
+	if group == "" {
+		id := make([]byte, 8)
+		rand.Read(id)
+		group = "bus-" + hex.EncodeToString(id)
+		startOffset = kafka.LastOffset
+	}
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     b.brokers,
+		GroupID:     group,
+		Topic:       topic,
+		StartOffset: startOffset,
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &kafkaSubscription{bus: b, reader: reader, cancel: cancel, stopped: make(chan struct{})}
+	b.subs[sub] = struct{}{}
+	go sub.run(ctx, handler)
+	return sub, nil
+}
+
+// Close closes every subscription, then flushes pending publications.
+func (b *KafkaBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	subs := make([]*kafkaSubscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+	if err := b.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close Kafka writer: %w", err)
+	}
+	return nil
+}
+
+// kafkaSubscription is a subscription to a KafkaBus.
+type kafkaSubscription struct {
+	bus     *KafkaBus
+	reader  *kafka.Reader
+	cancel  context.CancelFunc
+	stopped chan struct{} // Closed when run returns
+}
+
+// run fetches, handles and commits messages until ctx is cancelled.
+func (s *kafkaSubscription) run(ctx context.Context, handler Handler) {
+	defer close(s.stopped)
+	defer func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		s.bus.mu.Unlock()
+		if err := s.reader.Close(); err != nil {
+			log.Printf("Error closing Kafka reader of %s: %v", s.reader.Config().Topic, err)
+		}
+	}()
+
+	for {
+		m, err := s.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Error fetching message of %s from Kafka: %v", s.reader.Config().Topic, err)
+			if !sleep(ctx, kafkaRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		msg := Message{Topic: m.Topic, Key: string(m.Key), Data: m.Value}
+		for _, h := range m.Headers {
+			if msg.Headers == nil {
+				msg.Headers = make(map[string]string, len(m.Headers))
+			}
+			msg.Headers[h.Key] = string(h.Value)
+		}
+		deliver(ctx, handler, msg)
+		if ctx.Err() != nil {
+			// Left uncommitted, so the group delivers it again
+			return
+		}
+
+		if err := s.reader.CommitMessages(ctx, m); err != nil && ctx.Err() == nil {
+			log.Printf("Error committing message of %s at offset %d: %v", m.Topic, m.Offset, err)
+		}
+	}
+}
+
+// Close stops the subscription, waiting for the message being handled, if any, and leaves the
+// consumer group.
+func (s *kafkaSubscription) Close() error {
+	s.cancel()
+	<-s.stopped
+	return nil
+}
+
+// sleep waits for d, returning false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
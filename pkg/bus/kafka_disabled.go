@@ -0,0 +1,17 @@
+//go:build !kafka
+
+package bus
+
+import "errors"
+
+// KafkaBus is a Bus over Kafka brokers. This build was made without the kafka build tag, which
+// keeps the Kafka client and its compression codecs out of services not using them, so it
+// cannot connect to any.
+type KafkaBus struct {
+	Bus
+}
+
+// DialKafka fails, since this build has no Kafka support: build with -tags kafka for it.
+func DialKafka(addrs []string) (*KafkaBus, error) {
+	return nil, errors.New("this build has no Kafka support, build with -tags kafka for it")
+}
@@ -0,0 +1,159 @@
+package bus
+
+import (
+	"context"
+	"maps"
+	"strconv"
+	"sync"
+)
+
+// memoryQueueSize is the number of messages a group of a MemoryBus buffers before Publish
+// blocks.
+const memoryQueueSize = 1024
+
+// MemoryBus is a Bus delivering messages through channels to the subscribers of the same
+// process. Messages do not survive the process, and those buffered for a group are dropped when
+// its last subscription closes.
+type MemoryBus struct {
+	mu     sync.Mutex
+	groups map[string]map[string]*memoryGroup // Groups by topic, then name
+	subs   map[*memorySubscription]struct{}
+	seq    int // Used to name the groups of subscriptions without a group
+	closed bool
+}
+
+// memoryGroup is the queue of messages shared by the subscriptions of a group.
+type memoryGroup struct {
+	messages chan Message
+	members  int
+	done     chan struct{} // Closed when the last member leaves
+}
+
+// NewMemoryBus creates a new, empty MemoryBus.
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{
+		groups: make(map[string]map[string]*memoryGroup),
+		subs:   make(map[*memorySubscription]struct{}),
+	}
+}
+
+// Publish queues a copy of msg for every group subscribed to its topic, blocking while the
+// queue of a group is full.
+func (b *MemoryBus) Publish(ctx context.Context, msg Message) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrClosed
+	}
+	groups := make([]*memoryGroup, 0, len(b.groups[msg.Topic]))
+	for _, g := range b.groups[msg.Topic] {
+		groups = append(groups, g)
+	}
+	b.mu.Unlock()
+
+	for _, g := range groups {
+		copied := msg
+		copied.Data = append([]byte(nil), msg.Data...)
+		copied.Headers = maps.Clone(msg.Headers)
+		select {
+		case g.messages <- copied:
+		case <-g.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Subscribe delivers the messages of topic to handler from a goroutine of its own.
+func (b *MemoryBus) Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+
+	if group == "" {
+		// A group of its own, which no other subscription can join
+		b.seq++
+		group = "\x00" + strconv.Itoa(b.seq)
+	}
+	if b.groups[topic] == nil {
+		b.groups[topic] = make(map[string]*memoryGroup)
+	}
+	g := b.groups[topic][group]
+	if g == nil {
+		g = &memoryGroup{messages: make(chan Message, memoryQueueSize), done: make(chan struct{})}
+		b.groups[topic][group] = g
+	}
+	g.members++
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &memorySubscription{bus: b, topic: topic, group: group, cancel: cancel, stopped: make(chan struct{})}
+	b.subs[sub] = struct{}{}
+	go sub.run(ctx, g, handler)
+	return sub, nil
+}
+
+// Close closes every subscription. Messages still queued are dropped.
+func (b *MemoryBus) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	subs := make([]*memorySubscription, 0, len(b.subs))
+	for sub := range b.subs {
+		subs = append(subs, sub)
+	}
+	b.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+	return nil
+}
+
+// leave removes sub from its group, removing the group with its last member.
+func (b *MemoryBus) leave(sub *memorySubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.subs, sub)
+	g := b.groups[sub.topic][sub.group]
+	g.members--
+	if g.members == 0 {
+		close(g.done)
+		delete(b.groups[sub.topic], sub.group)
+		if len(b.groups[sub.topic]) == 0 {
+			delete(b.groups, sub.topic)
+		}
+	}
+}
+
+// memorySubscription is a subscription to a MemoryBus.
+type memorySubscription struct {
+	bus          *MemoryBus
+	topic, group string
+	cancel       context.CancelFunc
+	stopped      chan struct{} // Closed when run returns
+}
+
+// run delivers the messages of g to handler until ctx is cancelled.
+func (s *memorySubscription) run(ctx context.Context, g *memoryGroup, handler Handler) {
+	defer close(s.stopped)
+	defer s.bus.leave(s)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-g.messages:
+			deliver(ctx, handler, msg)
+		}
+	}
+}
+
+// Close stops the subscription, waiting for the message being handled, if any.
+func (s *memorySubscription) Close() error {
+	s.cancel()
+	<-s.stopped
+	return nil
+}
@@ -0,0 +1,136 @@
+package bus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// natsKeyHeader carries the key of messages, which NATS has no notion of.
+const natsKeyHeader = "Bus-Key"
+
+// NATSBus is a Bus over a NATS server, topics being subjects and groups queue groups. Core NATS
+// delivers at most once: messages published while no subscription listens are lost, as are
+// those whose handler kept failing.
+type NATSBus struct {
+	conn   *nats.Conn
+	closed chan struct{} // Closed once the connection is
+}
+
+// DialNATS connects to the NATS servers at url, a comma-separated list of nats:// URLs.
+func DialNATS(url string) (*NATSBus, error) {
+	closed := make(chan struct{})
+	conn, err := nats.Connect(url, nats.MaxReconnects(-1), nats.ClosedHandler(func(*nats.Conn) { close(closed) }))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS at %s: %w", url, err)
+	}
+	return &NATSBus{conn: conn, closed: closed}, nil
+}
+
+// Publish publishes msg to the subject of its topic.
+func (b *NATSBus) Publish(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b.conn.IsClosed() {
+		return ErrClosed
+	}
+
+	m := nats.NewMsg(msg.Topic)
+	m.Data = msg.Data
+	for k, v := range msg.Headers {
+		m.Header.Set(k, v)
+	}
+	if msg.Key != "" {
+		m.Header.Set(natsKeyHeader, msg.Key)
+	}
+	if err := b.conn.PublishMsg(m); err != nil {
+		return fmt.Errorf("failed to publish to %s: %w", msg.Topic, err)
+	}
+	return nil
+}
+
+// Subscribe subscribes handler to the subject of topic, in the queue group of group if any.
+// NATS hands the messages of a subscription to one goroutine, so they are handled in order.
+func (b *NATSBus) Subscribe(ctx context.Context, topic, group string, handler Handler) (Subscription, error) {
+	if b.conn.IsClosed() {
+		return nil, ErrClosed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &natsSubscription{cancel: cancel}
+	cb := func(m *nats.Msg) {
+		sub.handling.Lock()
+		defer sub.handling.Unlock()
+		if ctx.Err() == nil {
+			deliver(ctx, handler, natsMessage(m))
+		}
+	}
+
+	var err error
+	if group == "" {
+		sub.sub, err = b.conn.Subscribe(topic, cb)
+	} else {
+		sub.sub, err = b.conn.QueueSubscribe(topic, group, cb)
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to subscribe to %s: %w", topic, err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		sub.sub.Unsubscribe()
+	}()
+	return sub, nil
+}
+
+// Close drains the connection, so subscriptions finish handling the messages they received
+// and pending publications are flushed, and waits for it to close.
+func (b *NATSBus) Close() error {
+	if !b.conn.IsClosed() {
+		if err := b.conn.Drain(); err != nil {
+			return fmt.Errorf("failed to drain NATS connection: %w", err)
+		}
+	}
+	<-b.closed
+	return nil
+}
+
+// natsMessage converts a NATS message to a Message.
+func natsMessage(m *nats.Msg) Message {
+	msg := Message{Topic: m.Subject, Data: m.Data}
+	for k := range m.Header {
+		if k == natsKeyHeader {
+			msg.Key = m.Header.Get(k)
+			continue
+		}
+		if msg.Headers == nil {
+			msg.Headers = make(map[string]string)
+		}
+		msg.Headers[k] = m.Header.Get(k)
+	}
+	return msg
+}
+
+// natsSubscription is a subscription to a NATSBus.
+type natsSubscription struct {
+	sub      *nats.Subscription
+	cancel   context.CancelFunc
+	handling sync.Mutex // Held while a message is handled
+}
+
+// Close unsubscribes, waiting for the message being handled, if any.
+func (s *natsSubscription) Close() error {
+	s.cancel()
+	err := s.sub.Unsubscribe()
+	s.handling.Lock()
+	s.handling.Unlock()
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) && !errors.Is(err, nats.ErrBadSubscription) {
+		return fmt.Errorf("failed to unsubscribe from %s: %w", s.sub.Subject, err)
+	}
+	return nil
+}
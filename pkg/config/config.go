@@ -0,0 +1,166 @@
+// Package config loads the settings of a command into a typed struct, in layers: the values the
+// struct holds are the defaults, a JSON file overrides them, environment variables override the
+// file and command-line flags override everything else.
+//
+// The fields loaded are those with a config tag naming their setting, e.g.
+//
+//	Port       int    `config:"port" min:"1" max:"65535" usage:"The port number to serve on"`
+//	AdminToken string `config:"admin-token,secret" usage:"Token required by admin endpoints"`
+//
+// The name is the flag (-port), the key of the file ("port") and, upper-cased with dashes turned
+// into underscores after the prefix of the command, the environment variable (USER_SERVICE_PORT).
+// Options follow the name: secret redacts the value when the settings are printed, flag only
+// reads it from the command line, e.g. for actions such as -backup, and required rejects the
+// zero value. The min, max and oneof tags bound the value. Embedded structs are loaded as if
+// their fields were declared in place, so a command can embed the configuration of its service
+// and add its own settings next to it.
+//
+// Fields may be strings, booleans, integers, floats, time.Duration and []string, the latter
+// being comma-separated in flags and environment variables.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Options tell Load where the settings of a command come from.
+type Options struct {
+	// Name is the name of the command in usage messages; os.Args[0] when empty.
+	Name string
+	// EnvPrefix is put in front of the names of the environment variables, e.g. USER_SERVICE;
+	// the variables have no prefix when empty. <EnvPrefix>_CONFIG names the file of settings
+	// when the -config flag is not set.
+	EnvPrefix string
+	// Args are the command-line arguments, without the command; os.Args[1:] when nil.
+	Args []string
+}
+
+// Load fills cfg, a pointer to a struct holding the default settings, from the file of settings,
+// the environment and the command line, then validates it. Besides the flags of the settings,
+// -config names the file of settings and -print-config prints the settings loaded, with secrets
+// redacted, then exits. Invalid flags exit the process as the flag package does; other problems
+// are returned, the invalid settings as an *Error.
+func Load(cfg any, opts Options) error {
+	fields, err := fieldsOf(cfg)
+	if err != nil {
+		return err
+	}
+	if opts.Name == "" {
+		opts.Name = os.Args[0]
+	}
+	if opts.Args == nil {
+		opts.Args = os.Args[1:]
+	}
+
+	// Flags are parsed first, for -config, but applied last
+	flags := flag.NewFlagSet(opts.Name, flag.ExitOnError)
+	configFile := flags.String("config", "", fmt.Sprintf("JSON file of settings, overridden by environment variables and flags (default $%s)", opts.envName("config")))
+	printConfig := flags.Bool("print-config", false, "Print the settings, with secrets redacted, and exit")
+	byName := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		f.define(flags)
+		byName[f.name] = f
+	}
+	flags.Parse(opts.Args)
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", flags.Arg(0))
+	}
+
+	// Then come the file and the environment, in this order
+	path := *configFile
+	if path == "" {
+		path = os.Getenv(opts.envName("config"))
+	}
+	if path != "" {
+		if err := loadFile(path, fields); err != nil {
+			return err
+		}
+	}
+	for _, f := range fields {
+		if f.flagOnly {
+			continue
+		}
+		name := opts.envName(f.name)
+		if value, ok := os.LookupEnv(name); ok {
+			if err := f.set(value); err != nil {
+				return fmt.Errorf("invalid %s: %w", name, err)
+			}
+		}
+	}
+	flags.Visit(func(fl *flag.Flag) {
+		if f, ok := byName[fl.Name]; ok {
+			f.set(fl.Value.String()) // Parsed by the flag already
+		}
+	})
+
+	if err := validate(fields); err != nil {
+		return err
+	}
+	if *printConfig {
+		writeSettings(os.Stdout, fields)
+		os.Exit(0)
+	}
+	return nil
+}
+
+// envName returns the environment variable of the setting named name.
+func (o Options) envName(name string) string {
+	name = strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+	if o.EnvPrefix == "" {
+		return name
+	}
+	return o.EnvPrefix + "_" + name
+}
+
+// loadFile sets the fields named by the keys of the JSON object in the file at path.
+func loadFile(path string, fields []*field) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var settings map[string]json.RawMessage
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to decode config file %s: %w", path, err)
+	}
+
+	byName := make(map[string]*field, len(fields))
+	for _, f := range fields {
+		if !f.flagOnly {
+			byName[f.name] = f
+		}
+	}
+	var problems []string
+	for key, raw := range settings {
+		f, ok := byName[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown setting %q", key))
+			continue
+		}
+		if err := f.setJSON(raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return fmt.Errorf("invalid config file %s: %w", path, &Error{Problems: problems})
+	}
+	return nil
+}
+
+// Error lists the settings that are not valid.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// errNotStruct is returned for configurations that are not pointers to structs.
+var errNotStruct = errors.New("config: the configuration must be a pointer to a struct")
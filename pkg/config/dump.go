@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// redacted replaces the values of secrets when settings are printed.
+const redacted = "[redacted]"
+
+// Dump writes the settings of cfg to w, one per line, with the values of secrets redacted, as
+// does the -print-config flag. The passwords of URLs are redacted as well, e.g. in data sources.
+func Dump(w io.Writer, cfg any) error {
+	fields, err := fieldsOf(cfg)
+	if err != nil {
+		return err
+	}
+	return writeSettings(w, fields)
+}
+
+// writeSettings writes the settings of fields to w, with secrets redacted.
+func writeSettings(w io.Writer, fields []*field) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	for _, f := range fields {
+		fmt.Fprintf(tw, "%s\t= %s\n", f.name, f.redactedText())
+	}
+	return tw.Flush()
+}
+
+// redactedText returns the value of the field as printed: quoted when a string, and redacted
+// when a secret that is set.
+func (f *field) redactedText() string {
+	text := f.text()
+	switch {
+	case f.secret && text != "":
+		return redacted
+	case f.value.Type() == stringSliceType:
+		items := f.value.Interface().([]string)
+		redactedItems := make([]string, len(items))
+		for i, item := range items {
+			redactedItems[i] = redactURL(item)
+		}
+		return strconv.Quote(strings.Join(redactedItems, ","))
+	case f.value.Kind() == reflect.String:
+		return strconv.Quote(redactURL(text))
+	}
+	return text
+}
+
+// redactURL returns text with the password replaced by xxxxx when text is a URL holding one.
+func redactURL(text string) string {
+	u, err := url.Parse(text)
+	if err != nil || u.User == nil {
+		return text
+	}
+	if _, ok := u.User.Password(); !ok {
+		return text
+	}
+	return u.Redacted()
+}
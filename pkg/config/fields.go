@@ -0,0 +1,252 @@
+package config
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	stringSliceType = reflect.TypeOf([]string(nil))
+)
+
+// field is a setting of a configuration.
+type field struct {
+	name     string
+	usage    string
+	secret   bool     // Redacted when printed
+	flagOnly bool     // Only set by its flag
+	required bool     // Rejects the zero value
+	min, max string   // Bounds of numbers and durations, empty when unbounded
+	oneOf    []string // Values allowed, empty when any is
+	value    reflect.Value
+}
+
+// fieldsOf returns the settings of cfg, in the order of their declaration.
+func fieldsOf(cfg any) ([]*field, error) {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil, errNotStruct
+	}
+	fields, err := appendFields(nil, v.Elem())
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		if f.name == "config" || f.name == "print-config" {
+			return nil, fmt.Errorf("config: setting %q is reserved", f.name)
+		}
+		if seen[f.name] {
+			return nil, fmt.Errorf("config: setting %q is declared twice", f.name)
+		}
+		seen[f.name] = true
+	}
+	return fields, nil
+}
+
+// appendFields appends the settings of the struct v to fields, along with those of its embedded
+// structs.
+func appendFields(fields []*field, v reflect.Value) ([]*field, error) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("config")
+		if sf.Anonymous && !tagged && sf.Type.Kind() == reflect.Struct {
+			var err error
+			if fields, err = appendFields(fields, v.Field(i)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !tagged || tag == "-" || !sf.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		f := &field{name: name, usage: sf.Tag.Get("usage"), min: sf.Tag.Get("min"), max: sf.Tag.Get("max"), value: v.Field(i)}
+		if oneOf := sf.Tag.Get("oneof"); oneOf != "" {
+			f.oneOf = strings.Fields(oneOf)
+		}
+		for _, option := range strings.Split(options, ",") {
+			switch option {
+			case "":
+			case "secret":
+				f.secret = true
+			case "flag":
+				f.flagOnly = true
+			case "required":
+				f.required = true
+			default:
+				return nil, fmt.Errorf("config: unknown option %q of setting %q", option, name)
+			}
+		}
+		if !supported(sf.Type) {
+			return nil, fmt.Errorf("config: setting %q has unsupported type %s", name, sf.Type)
+		}
+		for _, bound := range []string{f.min, f.max} {
+			if bound == "" {
+				continue
+			}
+			kind := sf.Type.Kind()
+			if kind != reflect.Int && kind != reflect.Int64 && kind != reflect.Float64 {
+				return nil, fmt.Errorf("config: setting %q cannot be bounded", name)
+			}
+			if err := f.scratch().set(bound); err != nil {
+				return nil, fmt.Errorf("config: bound of setting %q: %w", name, err)
+			}
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// supported returns whether fields of type t can hold settings.
+func supported(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+		return true
+	}
+	return t == stringSliceType
+}
+
+// set sets the field to value, in the form of flags and environment variables.
+func (f *field) set(value string) error {
+	v := f.value
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		v.SetInt(int64(d))
+	case v.Type() == stringSliceType:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case v.Kind() == reflect.String:
+		v.SetString(value)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(value, 0, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		v.SetFloat(n)
+	}
+	return nil
+}
+
+// setJSON sets the field to the JSON value raw. Strings are read as in flags, so that durations
+// are written e.g. "30s" and lists may be comma-separated.
+func (f *field) setJSON(raw json.RawMessage) error {
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return f.set(text)
+	}
+	if f.value.Type() == durationType {
+		return fmt.Errorf("durations must be strings such as \"30s\"")
+	}
+	value := reflect.New(f.value.Type())
+	if err := json.Unmarshal(raw, value.Interface()); err != nil {
+		return fmt.Errorf("invalid value %s", raw)
+	}
+	f.value.Set(value.Elem())
+	return nil
+}
+
+// text returns the value of the field, in the form of flags.
+func (f *field) text() string {
+	v := f.value
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Type() == stringSliceType:
+		return strings.Join(v.Interface().([]string), ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// scratch returns a copy of the field with a value of its own, to parse values into.
+func (f *field) scratch() *field {
+	c := *f
+	c.value = reflect.New(f.value.Type()).Elem()
+	return &c
+}
+
+// define defines the flag of the field in flags, with the value of the field as its default.
+// The values of the flags are applied to their fields once the other layers are.
+func (f *field) define(flags *flag.FlagSet) {
+	v := f.value
+	switch {
+	case v.Type() == durationType:
+		flags.Duration(f.name, time.Duration(v.Int()), f.usage)
+	case v.Kind() == reflect.Bool:
+		flags.Bool(f.name, v.Bool(), f.usage)
+	case v.Kind() == reflect.Int:
+		flags.Int(f.name, int(v.Int()), f.usage)
+	case v.Kind() == reflect.Int64:
+		flags.Int64(f.name, v.Int(), f.usage)
+	case v.Kind() == reflect.Float64:
+		flags.Float64(f.name, v.Float(), f.usage)
+	default:
+		flags.String(f.name, f.text(), f.usage)
+	}
+}
+
+// validate checks the fields against their options and bounds.
+func validate(fields []*field) error {
+	var problems []string
+	for _, f := range fields {
+		if f.required && f.value.IsZero() {
+			problems = append(problems, fmt.Sprintf("%s is required", f.name))
+			continue
+		}
+		if len(f.oneOf) > 0 && !slices.Contains(f.oneOf, f.text()) {
+			problems = append(problems, fmt.Sprintf("%s must be one of %s", f.name, strings.Join(f.oneOf, ", ")))
+		}
+		if f.min != "" && f.compare(f.min) < 0 {
+			problems = append(problems, fmt.Sprintf("%s must be at least %s", f.name, f.min))
+		}
+		if f.max != "" && f.compare(f.max) > 0 {
+			problems = append(problems, fmt.Sprintf("%s must be at most %s", f.name, f.max))
+		}
+	}
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+// compare compares the value of the field, a number or a duration, with bound, returning -1,
+// 0 or 1. Bounds are checked when the fields are read.
+func (f *field) compare(bound string) int {
+	b := f.scratch()
+	b.set(bound)
+	if f.value.Kind() == reflect.Float64 {
+		return cmp.Compare(f.value.Float(), b.value.Float())
+	}
+	return cmp.Compare(f.value.Int(), b.value.Int())
+}
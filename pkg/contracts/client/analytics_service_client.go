@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// AnalyticsServiceClient handles communication with the Analytics Service, which stores the
+// domain events of every service for dashboards.
+type AnalyticsServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewAnalyticsServiceClient creates a new AnalyticsServiceClient.
+func NewAnalyticsServiceClient(httpClient *http.Client, baseURL string) *AnalyticsServiceClient {
+	return &AnalyticsServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// GetTimeSeries sends a GET request to the Analytics Service, counting the events of a type per
+// interval between the from and to dates. Empty parameters are left to the Analytics
+// Service's defaults.
+func (c *AnalyticsServiceClient) GetTimeSeries(eventType, interval, from, to string) (*contracts.AnalyticsSeries, error) {
+	apiResp, err := c.query(c.newGetTimeSeriesRequest(contracts.GetTimeSeriesParams{EventType: eventType, Interval: interval, From: from, To: to}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Series, nil
+}
+
+// GetFunnel sends a GET request to the Analytics Service, returning the conversion funnel of
+// the listings created between the from and to dates. Empty dates are left to the Analytics
+// Service's defaults.
+func (c *AnalyticsServiceClient) GetFunnel(from, to string) (*contracts.Funnel, error) {
+	apiResp, err := c.query(c.newGetFunnelRequest(contracts.GetFunnelParams{From: from, To: to}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Funnel, nil
+}
+
+// query sends a request to the Analytics Service, built by one of the request builders along
+// with the error building it, and decodes its response.
+func (c *AnalyticsServiceClient) query(req *http.Request, err error) (*contracts.AnalyticsServiceResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Analytics Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp contracts.AnalyticsServiceResponse
+	if resp.StatusCode == http.StatusBadRequest {
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return nil, fmt.Errorf("failed to decode Analytics Service error response: %w", err)
+		}
+		// Drop the Analytics Service's own "invalid query: " prefix, callers add theirs
+		return nil, &InvalidQueryError{Messages: []string{strings.TrimPrefix(apiResp.Error, "invalid query: ")}}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Analytics Service returned non-OK status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Analytics Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Analytics Service reported error: %s", apiResp.Error)
+	}
+	return &apiResp, nil
+}
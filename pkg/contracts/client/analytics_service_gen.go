@@ -0,0 +1,38 @@
+// Code generated by openapi from analytics-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"contracts"
+)
+
+// newGetTimeSeriesRequest builds the request of GET /timeseries: Count the events of a type per
+// hour, day or week between two dates.
+func (c *AnalyticsServiceClient) newGetTimeSeriesRequest(params contracts.GetTimeSeriesParams) (*http.Request, error) {
+	target := c.baseURL + "/timeseries"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Analytics Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetFunnelRequest builds the request of GET /funnel: Get the conversion funnel of the listings
+// created between two dates.
+func (c *AnalyticsServiceClient) newGetFunnelRequest(params contracts.GetFunnelParams) (*http.Request, error) {
+	target := c.baseURL + "/funnel"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Analytics Service: %w", err)
+	}
+	return req, nil
+}
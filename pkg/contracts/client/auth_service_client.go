@@ -0,0 +1,53 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"contracts"
+)
+
+// AuthServiceClient is a client for the Auth Service, which validates both its own access
+// tokens and the User Service's personal access tokens.
+type AuthServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewAuthServiceClient creates a new AuthServiceClient.
+func NewAuthServiceClient(httpClient *http.Client, baseURL string) *AuthServiceClient {
+	return &AuthServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// IntrospectToken asks the Auth Service whether a token is valid.
+// It returns nil and a nil error if the token is unknown, expired or revoked.
+func (c *AuthServiceClient) IntrospectToken(token string) (*contracts.AccessToken, error) {
+	req, err := c.newIntrospectTokenRequest(contracts.TokenIntrospectionRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Auth Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Auth Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.IntrospectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Auth Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Auth Service reported error: %s", apiResp.Error)
+	}
+	if !apiResp.Active {
+		return nil, nil
+	}
+	return apiResp.Token, nil
+}
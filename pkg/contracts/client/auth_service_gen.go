@@ -0,0 +1,64 @@
+// Code generated by openapi from auth-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// newIssueTokenRequest builds the request of POST /token: Issue an access token and a refresh
+// token, from a name and password or from a refresh token.
+func (c *AuthServiceClient) newIssueTokenRequest(body contracts.IssueTokenRequest) (*http.Request, error) {
+	target := c.baseURL + "/token"
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Auth Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newIntrospectTokenRequest builds the request of POST /introspect: Validate an access token or a
+// personal access token of the User Service.
+func (c *AuthServiceClient) newIntrospectTokenRequest(body contracts.TokenIntrospectionRequest) (*http.Request, error) {
+	target := c.baseURL + "/introspect"
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request to Auth Service: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Auth Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// newRevokeTokenRequest builds the request of POST /revoke: End the session of a refresh token or
+// an access token.
+func (c *AuthServiceClient) newRevokeTokenRequest(body contracts.TokenRevocationRequest) (*http.Request, error) {
+	target := c.baseURL + "/revoke"
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Auth Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newGetJWKSRequest builds the request of GET /.well-known/jwks.json: Get the public keys verifying
+// access tokens, as a JWK Set.
+func (c *AuthServiceClient) newGetJWKSRequest() (*http.Request, error) {
+	target := c.baseURL + "/.well-known/jwks.json"
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Auth Service: %w", err)
+	}
+	return req, nil
+}
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// ErrTransactionForbidden is returned when the user is not allowed to see or change a transaction.
+var ErrTransactionForbidden = errors.New("user may not act on this transaction")
+
+// TransactionStateError is returned when a transaction's status does not allow a request,
+// e.g. confirming a cancelled transaction.
+type TransactionStateError struct {
+	Message string // As reported by the Booking Service
+}
+
+func (e *TransactionStateError) Error() string {
+	return e.Message
+}
+
+// BookingServiceClient handles communication with the Booking Service, which turns accepted
+// offers into transactions.
+type BookingServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewBookingServiceClient creates a new BookingServiceClient.
+func NewBookingServiceClient(httpClient *http.Client, baseURL string) *BookingServiceClient {
+	return &BookingServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// ListTransactions sends a GET request to the Booking Service, returning a page of the
+// transactions a user buys or sells in, most recent first, optionally only those with the
+// given status.
+func (c *BookingServiceClient) ListTransactions(userID int64, status string, pageNum, pageSize int) ([]contracts.Transaction, error) {
+	params := contracts.ListTransactionsParams{UserID: userID, Status: status, PageNum: pageNum, PageSize: pageSize}
+	apiResp, err := c.do(c.newListTransactionsRequest(params))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Transactions, nil
+}
+
+// GetTransaction sends a GET request to the Booking Service, returning a transaction along
+// with its saga log. It returns nil and a nil error if the transaction does not exist.
+func (c *BookingServiceClient) GetTransaction(id, userID int64) (*contracts.Transaction, error) {
+	return c.transaction(c.newGetTransactionRequest(id, contracts.GetTransactionParams{UserID: userID}))
+}
+
+// ConfirmTransaction sends a POST request to the Booking Service, confirming a held
+// transaction on behalf of its buyer. It returns nil and a nil error if the transaction does
+// not exist.
+func (c *BookingServiceClient) ConfirmTransaction(id, userID int64) (*contracts.Transaction, error) {
+	return c.transaction(c.newConfirmTransactionRequest(id, contracts.TransactionActionRequest{UserID: userID}))
+}
+
+// CancelTransaction sends a POST request to the Booking Service, cancelling a pending or held
+// transaction on behalf of either party. It returns nil and a nil error if the transaction
+// does not exist.
+func (c *BookingServiceClient) CancelTransaction(id, userID int64) (*contracts.Transaction, error) {
+	return c.transaction(c.newCancelTransactionRequest(id, contracts.TransactionActionRequest{UserID: userID}))
+}
+
+// transaction sends a request about a single transaction, built by one of the request builders
+// along with the error building it, and returns the transaction of its response.
+func (c *BookingServiceClient) transaction(req *http.Request, err error) (*contracts.Transaction, error) {
+	apiResp, err := c.do(req, err)
+	if err != nil || apiResp == nil {
+		return nil, err
+	}
+	return apiResp.Transaction, nil
+}
+
+// do sends a request to the Booking Service, built by one of the request builders along with
+// the error building it, and decodes its response. It returns a nil response and a nil error
+// for 404 Not Found.
+func (c *BookingServiceClient) do(req *http.Request, err error) (*contracts.BookingServiceResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Booking Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp contracts.BookingServiceResponse
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusForbidden:
+		return nil, ErrTransactionForbidden
+	case http.StatusBadRequest, http.StatusConflict:
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return nil, fmt.Errorf("failed to decode Booking Service error response: %w", err)
+		}
+		// Drop the Booking Service's own error prefixes, callers add theirs
+		if resp.StatusCode == http.StatusConflict {
+			return nil, &TransactionStateError{Message: strings.TrimPrefix(apiResp.Error, "invalid transaction state: ")}
+		}
+		return nil, &InvalidQueryError{Messages: []string{strings.TrimPrefix(apiResp.Error, "invalid query: ")}}
+	default:
+		return nil, fmt.Errorf("Booking Service returned non-OK status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Booking Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Booking Service reported error: %s", apiResp.Error)
+	}
+	return &apiResp, nil
+}
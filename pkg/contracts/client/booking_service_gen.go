@@ -0,0 +1,63 @@
+// Code generated by openapi from booking-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// newListTransactionsRequest builds the request of GET /transactions: Get a page of the
+// transactions a user buys or sells in, most recent first.
+func (c *BookingServiceClient) newListTransactionsRequest(params contracts.ListTransactionsParams) (*http.Request, error) {
+	target := c.baseURL + "/transactions"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Booking Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetTransactionRequest builds the request of GET /transactions/{id}: Get a transaction along
+// with its saga log, on behalf of its buyer or seller.
+func (c *BookingServiceClient) newGetTransactionRequest(id int64, params contracts.GetTransactionParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/transactions/%d", c.baseURL, id)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Booking Service: %w", err)
+	}
+	return req, nil
+}
+
+// newConfirmTransactionRequest builds the request of POST /transactions/{id}/confirm: Confirm a
+// held transaction on behalf of its buyer, having the listing marked as sold.
+func (c *BookingServiceClient) newConfirmTransactionRequest(id int64, body contracts.TransactionActionRequest) (*http.Request, error) {
+	target := fmt.Sprintf("%s/transactions/%d/confirm", c.baseURL, id)
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Booking Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newCancelTransactionRequest builds the request of POST /transactions/{id}/cancel: Cancel a
+// pending or held transaction on behalf of either party, having the listing's hold released.
+func (c *BookingServiceClient) newCancelTransactionRequest(id int64, body contracts.TransactionActionRequest) (*http.Request, error) {
+	target := fmt.Sprintf("%s/transactions/%d/cancel", c.baseURL, id)
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Booking Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
@@ -1,3 +1,5 @@
+// Package client implements typed clients of the services, decoding their responses into the
+// types of the contracts package.
 package client
 
 import (
@@ -0,0 +1,402 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"contracts"
+)
+
+// ErrListingQuotaExceeded is returned when the user already has as many active listings as
+// the Listing Service allows.
+var ErrListingQuotaExceeded = errors.New("listing quota exceeded")
+
+// ListingInput holds the fields a client sets when creating a listing.
+// Optional fields are left out of the request when empty.
+type ListingInput struct {
+	UserID      int64
+	ListingType string
+	Price       int64
+	Currency    string // ISO 4217 code, the Listing Service default when empty
+	Title       string
+	Description string
+	CategoryID  int64    // Uncategorized when zero
+	Latitude    *float64 // No location when nil; set together with Longitude
+	Longitude   *float64
+	ExpiresAt   int64 // Microseconds since the epoch; never expires when zero
+
+	// Availability window of rentals, in microseconds since the epoch; open when zero
+	AvailableFrom int64
+	AvailableTo   int64
+
+	// Type-specific fields, left out when nil
+	RentalPeriod string // Rentals only
+	Deposit      *int64 // Rentals only
+	Negotiable   *bool  // Sales only
+
+	Attributes map[string]any // Attributes of the listing's category, left out when empty
+}
+
+// InvalidListingError is returned when the Listing Service rejects a listing's fields.
+type InvalidListingError struct {
+	Messages []string // Validation messages reported by the Listing Service
+}
+
+func (e *InvalidListingError) Error() string {
+	return "invalid listing: " + strings.Join(e.Messages, "; ")
+}
+
+// InvalidQueryError is returned when the Listing Service rejects the parameters of a
+// listing query or search.
+type InvalidQueryError struct {
+	Messages []string // Validation messages reported by the Listing Service
+}
+
+func (e *InvalidQueryError) Error() string {
+	return "invalid query: " + strings.Join(e.Messages, "; ")
+}
+
+// ListingError is returned when the Listing Service rejects a hold or sale with a 4xx status,
+// which sending the request again would not change.
+type ListingError struct {
+	StatusCode int
+	Messages   []string // Error messages reported by the Listing Service
+}
+
+func (e *ListingError) Error() string {
+	return fmt.Sprintf("Listing Service answered %d: %s", e.StatusCode, strings.Join(e.Messages, "; "))
+}
+
+// decodeQueryError reads the error messages of a Listing Service 400 response to a query,
+// which reports them either as a single string or as a list.
+func decodeQueryError(resp *http.Response) error {
+	var errResp struct {
+		Errors json.RawMessage `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+		return fmt.Errorf("failed to decode Listing Service error response: %w", err)
+	}
+	var messages []string
+	if err := json.Unmarshal(errResp.Errors, &messages); err != nil {
+		var message string
+		if err := json.Unmarshal(errResp.Errors, &message); err != nil {
+			return fmt.Errorf("failed to decode Listing Service error response: %w", err)
+		}
+		messages = []string{message}
+	}
+	return &InvalidQueryError{Messages: messages}
+}
+
+// ListingServiceClient handles communication with the Listing Service.
+type ListingServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewListingServiceClient creates a new ListingServiceClient.
+func NewListingServiceClient(httpClient *http.Client, baseURL string) *ListingServiceClient {
+	return &ListingServiceClient{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+	}
+}
+
+// CreateListing sends a POST request to the Listing Service to create a new listing.
+// ErrListingQuotaExceeded is returned if the user already has the maximum number of active listings.
+func (c *ListingServiceClient) CreateListing(input ListingInput) (*contracts.Listing, error) {
+	body := contracts.CreateListingRequest{
+		UserID:        input.UserID,
+		ListingType:   input.ListingType,
+		Price:         input.Price,
+		Currency:      input.Currency,
+		Title:         input.Title,
+		Description:   input.Description,
+		CategoryID:    input.CategoryID,
+		ExpiresAt:     input.ExpiresAt,
+		AvailableFrom: input.AvailableFrom,
+		AvailableTo:   input.AvailableTo,
+		RentalPeriod:  input.RentalPeriod,
+		Deposit:       input.Deposit,
+		Negotiable:    input.Negotiable,
+	}
+	if input.Latitude != nil && input.Longitude != nil {
+		body.Latitude, body.Longitude = input.Latitude, input.Longitude
+	}
+	if len(input.Attributes) > 0 {
+		attributes, err := json.Marshal(input.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode listing attributes: %w", err)
+		}
+		body.Attributes = string(attributes)
+	}
+
+	req, err := c.newCreateListingRequest(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Listing Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		var errResp struct {
+			Errors []string `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			return nil, fmt.Errorf("failed to decode Listing Service error response: %w", err)
+		}
+		return nil, &InvalidListingError{Messages: errResp.Errors}
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		var errResp struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err == nil && errResp.Code == contracts.ErrorCodeListingQuotaExceeded {
+			return nil, ErrListingQuotaExceeded
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Listing Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.ListingServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Listing Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Listing Service reported error: %s", apiResp.Error)
+	}
+
+	return apiResp.Listing, nil
+}
+
+// ListingQuery holds the parameters of a listing query. Empty optional fields are left out,
+// and are validated by the Listing Service.
+type ListingQuery struct {
+	PageNum  int
+	Cursor   string  // NextCursor of a previous page of the same query; replaces PageNum
+	PageSize int     // The Listing Service default when zero: 10, or all of IDs
+	IDs      []int64 // Only these listings, looked up in a single request (at most 100)
+	UserID   string  // Only listings of this user
+	Near     string  // "lat,lng"; only listings within RadiusKm of it, nearest first
+	RadiusKm string  // The Listing Service default when empty
+	Filter   string  // Filter expression, e.g. "type:rent,price<5000000"
+	Sort     string  // price, created_at or updated_at
+	Order    string  // asc or desc
+	// Only rentals available at this time, in microseconds since the epoch or ISO 8601
+	AvailableOn string
+	Facets      bool // Also count all matching listings per listing type and price bucket
+}
+
+// ListingPage is a page of listings along with what the Listing Service tells about the query.
+type ListingPage struct {
+	Listings   []contracts.Listing
+	Facets     *contracts.ListingFacets // Nil unless requested
+	NextCursor string                   // Empty after the last page, and for radius queries ordered by distance
+}
+
+// GetListings sends a GET request to the Listing Service to retrieve a page of listings.
+// The facets are nil unless query.Facets is set.
+func (c *ListingServiceClient) GetListings(query ListingQuery) (*ListingPage, error) {
+	params := contracts.GetListingsParams{
+		PageNum:     query.PageNum,
+		Cursor:      query.Cursor,
+		PageSize:    query.PageSize,
+		UserID:      query.UserID,
+		Near:        query.Near,
+		RadiusKm:    query.RadiusKm,
+		Filter:      query.Filter,
+		Sort:        query.Sort,
+		Order:       query.Order,
+		AvailableOn: query.AvailableOn,
+		Facets:      query.Facets,
+	}
+	if len(query.IDs) > 0 {
+		ids := make([]string, len(query.IDs))
+		for i, id := range query.IDs {
+			ids[i] = strconv.FormatInt(id, 10)
+		}
+		params.IDs = strings.Join(ids, ",")
+	}
+
+	apiResp, err := c.queryListings(c.newGetListingsRequest(params))
+	if err != nil {
+		return nil, err
+	}
+	return &ListingPage{Listings: apiResp.Listings, Facets: apiResp.Facets, NextCursor: apiResp.NextCursor}, nil
+}
+
+// GetListing looks up a listing by ID. It returns nil and a nil error if the listing does not
+// exist or is not publicly visible (pending moderation, expired or hidden).
+func (c *ListingServiceClient) GetListing(id int64) (*contracts.Listing, error) {
+	page, err := c.GetListings(ListingQuery{PageNum: 1, IDs: []int64{id}})
+	if err != nil {
+		return nil, err
+	}
+	for _, listing := range page.Listings {
+		if listing.ID == id {
+			return &listing, nil
+		}
+	}
+	return nil, nil
+}
+
+// SearchListings sends a GET request to the Listing Service's full-text search,
+// returning listings matching query with the best matches first.
+func (c *ListingServiceClient) SearchListings(query string, pageNum, pageSize int) ([]contracts.Listing, error) {
+	apiResp, err := c.queryListings(c.newSearchListingsRequest(contracts.SearchListingsParams{Q: query, PageNum: pageNum, PageSize: pageSize}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listings, nil
+}
+
+// GetFeaturedListings sends a GET request to the Listing Service for a random sample of up to
+// count active listings. Weighted samples favor listings with more views.
+func (c *ListingServiceClient) GetFeaturedListings(count int, weighted bool) ([]contracts.Listing, error) {
+	apiResp, err := c.queryListings(c.newGetFeaturedListingsRequest(contracts.GetFeaturedListingsParams{Count: count, Weighted: weighted}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listings, nil
+}
+
+// GetTrendingListings sends a GET request to the Listing Service for the listings with the most
+// recent views in window (1h, 6h, 24h or 7d; the Listing Service default when empty).
+func (c *ListingServiceClient) GetTrendingListings(window string, pageNum, pageSize int) ([]contracts.Listing, error) {
+	apiResp, err := c.queryListings(c.newGetTrendingListingsRequest(contracts.GetTrendingListingsParams{Window: window, PageNum: pageNum, PageSize: pageSize}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Listings, nil
+}
+
+// GetListingStats sends a GET request to the Listing Service for listing statistics, with new
+// listings per day for the last days days (the Listing Service default when 0).
+func (c *ListingServiceClient) GetListingStats(days int) (*contracts.ListingStats, error) {
+	apiResp, err := c.queryListings(c.newGetListingStatsRequest(contracts.GetListingStatsParams{Days: days}))
+	if err != nil {
+		return nil, err
+	}
+	return apiResp.Stats, nil
+}
+
+// queryListings sends a GET request for listings, built by one of the request builders along with
+// the error building it. It returns an *InvalidQueryError if the Listing Service rejects the
+// parameters.
+func (c *ListingServiceClient) queryListings(req *http.Request, err error) (*contracts.ListingServiceResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Listing Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, decodeQueryError(resp)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Listing Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.ListingServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Listing Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Listing Service reported error: %s", apiResp.Error)
+	}
+
+	return &apiResp, nil
+}
+
+// HoldListing holds a listing owned by sellerID for buyerID during the given minutes.
+// Rejections, e.g. of a listing held already, are returned as *ListingError.
+func (c *ListingServiceClient) HoldListing(listingID, sellerID, buyerID int64, minutes int) (*contracts.ListingHold, error) {
+	apiResp, err := c.do(c.newHoldListingRequest(listingID, contracts.HoldListingRequest{UserID: sellerID, BuyerID: buyerID, Minutes: minutes}))
+	if err != nil {
+		return nil, err
+	}
+	if apiResp.Hold == nil {
+		return nil, fmt.Errorf("Listing Service returned no hold")
+	}
+	return apiResp.Hold, nil
+}
+
+// ReleaseHold releases the active hold of a listing, on behalf of userID.
+func (c *ListingServiceClient) ReleaseHold(listingID, userID int64) error {
+	_, err := c.do(c.newReleaseHoldRequest(listingID, contracts.ReleaseHoldParams{UserID: userID}))
+	return err
+}
+
+// MarkSold marks a listing owned by sellerID as sold, which releases its hold.
+func (c *ListingServiceClient) MarkSold(listingID, sellerID int64) error {
+	_, err := c.do(c.newMarkSoldRequest(listingID, contracts.MarkSoldRequest{UserID: sellerID}))
+	return err
+}
+
+// do sends a request to the Listing Service, built by one of the request builders along with
+// the error building it, and decodes its response. Rejections with a 4xx status are returned as
+// *ListingError.
+func (c *ListingServiceClient) do(req *http.Request, err error) (*contracts.ListingServiceResponse, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Listing Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+		var errResp struct {
+			Errors json.RawMessage `json:"errors"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
+			return nil, &ListingError{StatusCode: resp.StatusCode, Messages: []string{resp.Status}}
+		}
+		return nil, &ListingError{StatusCode: resp.StatusCode, Messages: decodeMessages(errResp.Errors)}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Listing Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.ListingServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Listing Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Listing Service reported error: %s", apiResp.Error)
+	}
+	return &apiResp, nil
+}
+
+// decodeMessages reads the errors of a Listing Service response, reported either as a single
+// string or as a list.
+func decodeMessages(raw json.RawMessage) []string {
+	var messages []string
+	if err := json.Unmarshal(raw, &messages); err == nil {
+		return messages
+	}
+	var message string
+	if err := json.Unmarshal(raw, &message); err == nil && message != "" {
+		return []string{message}
+	}
+	return nil
+}
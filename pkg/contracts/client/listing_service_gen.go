@@ -0,0 +1,130 @@
+// Code generated by openapi from listing-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// newGetListingsRequest builds the request of GET /listings: Get a page of listings, optionally
+// with facets counting every listing of the query.
+func (c *ListingServiceClient) newGetListingsRequest(params contracts.GetListingsParams) (*http.Request, error) {
+	target := c.baseURL + "/listings"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newCreateListingRequest builds the request of POST /listings: Create a listing.
+func (c *ListingServiceClient) newCreateListingRequest(body contracts.CreateListingRequest) (*http.Request, error) {
+	target := c.baseURL + "/listings"
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newSearchListingsRequest builds the request of GET /listings/search: Search listings by full
+// text, best matches first.
+func (c *ListingServiceClient) newSearchListingsRequest(params contracts.SearchListingsParams) (*http.Request, error) {
+	target := c.baseURL + "/listings/search"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetFeaturedListingsRequest builds the request of GET /listings/featured: Get a random sample
+// of active listings.
+func (c *ListingServiceClient) newGetFeaturedListingsRequest(params contracts.GetFeaturedListingsParams) (*http.Request, error) {
+	target := c.baseURL + "/listings/featured"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetTrendingListingsRequest builds the request of GET /listings/trending: Get the listings with
+// the most recent views.
+func (c *ListingServiceClient) newGetTrendingListingsRequest(params contracts.GetTrendingListingsParams) (*http.Request, error) {
+	target := c.baseURL + "/listings/trending"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetListingStatsRequest builds the request of GET /listings/stats: Get listing statistics, as
+// of the last rollup refresh.
+func (c *ListingServiceClient) newGetListingStatsRequest(params contracts.GetListingStatsParams) (*http.Request, error) {
+	target := c.baseURL + "/listings/stats"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newHoldListingRequest builds the request of POST /listings/{id}/hold: Hold a listing for a buyer,
+// on behalf of its owner.
+func (c *ListingServiceClient) newHoldListingRequest(id int64, body contracts.HoldListingRequest) (*http.Request, error) {
+	target := fmt.Sprintf("%s/listings/%d/hold", c.baseURL, id)
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newReleaseHoldRequest builds the request of DELETE /listings/{id}/hold: Release the active hold
+// of a listing, on behalf of its owner or buyer.
+func (c *ListingServiceClient) newReleaseHoldRequest(id int64, params contracts.ReleaseHoldParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/listings/%d/hold", c.baseURL, id)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodDelete, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	return req, nil
+}
+
+// newMarkSoldRequest builds the request of POST /listings/{id}/sold: Mark a listing as sold, on
+// behalf of its owner, which releases its hold.
+func (c *ListingServiceClient) newMarkSoldRequest(id int64, body contracts.MarkSoldRequest) (*http.Request, error) {
+	target := fmt.Sprintf("%s/listings/%d/sold", c.baseURL, id)
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Listing Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
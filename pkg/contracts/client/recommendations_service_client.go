@@ -0,0 +1,52 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"contracts"
+)
+
+// RecommendationsServiceClient handles communication with the Recommendations Service, which
+// recommends listings from the views and favorites of every user.
+type RecommendationsServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewRecommendationsServiceClient creates a new RecommendationsServiceClient.
+func NewRecommendationsServiceClient(httpClient *http.Client, baseURL string) *RecommendationsServiceClient {
+	return &RecommendationsServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// GetRecommendations sends a GET request to the Recommendations Service, returning up to
+// limit listings recommended to a user, best first.
+func (c *RecommendationsServiceClient) GetRecommendations(userID int64, limit int) ([]contracts.Recommendation, error) {
+	if limit > contracts.MaxRecommendations {
+		return nil, fmt.Errorf("at most %d listings can be recommended at once", contracts.MaxRecommendations)
+	}
+	req, err := c.newGetRecommendationsRequest(userID, contracts.GetRecommendationsParams{Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Recommendations Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Recommendations Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.RecommendationsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Recommendations Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Recommendations Service reported error: %s", apiResp.Error)
+	}
+	return apiResp.Recommendations, nil
+}
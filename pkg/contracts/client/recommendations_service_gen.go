@@ -0,0 +1,24 @@
+// Code generated by openapi from recommendations-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"contracts"
+)
+
+// newGetRecommendationsRequest builds the request of GET /recommendations/{user_id}: Get the
+// listings recommended to a user, best first.
+func (c *RecommendationsServiceClient) newGetRecommendationsRequest(userID int64, params contracts.GetRecommendationsParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/recommendations/%d", c.baseURL, userID)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Recommendations Service: %w", err)
+	}
+	return req, nil
+}
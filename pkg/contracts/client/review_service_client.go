@@ -0,0 +1,58 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"contracts"
+)
+
+// ReviewServiceClient handles communication with the Review Service, which stores the reviews
+// of listings and users.
+type ReviewServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewReviewServiceClient creates a new ReviewServiceClient.
+func NewReviewServiceClient(httpClient *http.Client, baseURL string) *ReviewServiceClient {
+	return &ReviewServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// GetListingRatings sends a GET request to the Review Service, returning the ratings of the
+// given listings by ID, including listings without reviews.
+func (c *ReviewServiceClient) GetListingRatings(ids []int64) (map[int64]contracts.Rating, error) {
+	if len(ids) > contracts.MaxRatingBatchSize {
+		return nil, fmt.Errorf("at most %d listings can be rated at once", contracts.MaxRatingBatchSize)
+	}
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.FormatInt(id, 10)
+	}
+	req, err := c.newGetListingRatingsRequest(contracts.GetListingRatingsParams{IDs: strings.Join(values, ",")})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Review Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Review Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.RatingsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Review Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Review Service reported error: %s", apiResp.Error)
+	}
+	return apiResp.Ratings, nil
+}
@@ -0,0 +1,92 @@
+// Code generated by openapi from review-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// newCreateReviewRequest builds the request of POST /reviews: Review a listing or a user.
+func (c *ReviewServiceClient) newCreateReviewRequest(body contracts.CreateReviewRequest) (*http.Request, error) {
+	target := c.baseURL + "/reviews"
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(body.Values().Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newDeleteReviewRequest builds the request of DELETE /reviews/{id}: Delete a review, on behalf of
+// its author.
+func (c *ReviewServiceClient) newDeleteReviewRequest(id int64, params contracts.DeleteReviewParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/reviews/%d", c.baseURL, id)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodDelete, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	return req, nil
+}
+
+// newListListingReviewsRequest builds the request of GET /listings/{id}/reviews: Get a page of a
+// listing's reviews, most recent first, and its rating.
+func (c *ReviewServiceClient) newListListingReviewsRequest(id int64, params contracts.ListListingReviewsParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/listings/%d/reviews", c.baseURL, id)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	return req, nil
+}
+
+// newListUserReviewsRequest builds the request of GET /users/{id}/reviews: Get a page of a user's
+// reviews, most recent first, and their rating.
+func (c *ReviewServiceClient) newListUserReviewsRequest(id int64, params contracts.ListUserReviewsParams) (*http.Request, error) {
+	target := fmt.Sprintf("%s/users/%d/reviews", c.baseURL, id)
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetListingRatingsRequest builds the request of GET /ratings/listings: Get the ratings of
+// several listings, including those without reviews.
+func (c *ReviewServiceClient) newGetListingRatingsRequest(params contracts.GetListingRatingsParams) (*http.Request, error) {
+	target := c.baseURL + "/ratings/listings"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	return req, nil
+}
+
+// newGetUserRatingsRequest builds the request of GET /ratings/users: Get the ratings of several
+// users, including those without reviews.
+func (c *ReviewServiceClient) newGetUserRatingsRequest(params contracts.GetUserRatingsParams) (*http.Request, error) {
+	target := c.baseURL + "/ratings/users"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Review Service: %w", err)
+	}
+	return req, nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"contracts"
+)
+
+// SearchServiceClient handles communication with the Search Service, which indexes listings
+// and users from their domain events.
+type SearchServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewSearchServiceClient creates a new SearchServiceClient.
+func NewSearchServiceClient(httpClient *http.Client, baseURL string) *SearchServiceClient {
+	return &SearchServiceClient{httpClient: httpClient, baseURL: baseURL}
+}
+
+// SearchListings sends a GET request to the Search Service, returning listings matching query
+// with the best matches first, along with their owners by user ID. Unlike the Listing Service's
+// search, no User Service lookup is needed to enrich the listings.
+func (c *SearchServiceClient) SearchListings(query string, pageNum, pageSize int) ([]contracts.Listing, map[int64]*contracts.User, error) {
+	req, err := c.newSearchRequest(contracts.SearchParams{Q: query, Type: contracts.HitTypeListing, PageNum: pageNum, PageSize: pageSize})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to send request to Search Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var apiResp contracts.SearchServiceResponse
+	if resp.StatusCode == http.StatusBadRequest {
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode Search Service error response: %w", err)
+		}
+		// Drop the Search Service's own "invalid search: " prefix, callers add theirs
+		return nil, nil, &InvalidQueryError{Messages: []string{strings.TrimPrefix(apiResp.Error, "invalid search: ")}}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("Search Service returned non-OK status: %s", resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode Search Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, nil, fmt.Errorf("Search Service reported error: %s", apiResp.Error)
+	}
+
+	listings := make([]contracts.Listing, 0, len(apiResp.Hits))
+	users := make(map[int64]*contracts.User)
+	for _, hit := range apiResp.Hits {
+		if hit.Type != contracts.HitTypeListing || hit.Listing == nil {
+			continue
+		}
+		listings = append(listings, *hit.Listing)
+		if hit.User != nil {
+			users[hit.Listing.UserID] = hit.User
+		}
+	}
+	return listings, users, nil
+}
@@ -0,0 +1,24 @@
+// Code generated by openapi from search-service/api/openapi.yaml. DO NOT EDIT.
+
+package client
+
+import (
+	"fmt"
+	"net/http"
+
+	"contracts"
+)
+
+// newSearchRequest builds the request of GET /search: Search listings and users, best matches
+// first.
+func (c *SearchServiceClient) newSearchRequest(params contracts.SearchParams) (*http.Request, error) {
+	target := c.baseURL + "/search"
+	if query := params.Values().Encode(); query != "" {
+		target += "?" + query
+	}
+	req, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Search Service: %w", err)
+	}
+	return req, nil
+}
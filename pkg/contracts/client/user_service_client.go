@@ -0,0 +1,344 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"contracts"
+)
+
+// ErrUserVersionConflict is returned when an update is based on a stale user version.
+var ErrUserVersionConflict = errors.New("user version conflict")
+
+// ErrUserNameTaken is returned when another user already has the requested name.
+var ErrUserNameTaken = errors.New("user name already taken")
+
+// Errors returned by VerifyCredentials.
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUserSuspended      = errors.New("user is suspended")
+)
+
+// maxCachedUsers bounds the number of user representations kept for revalidation.
+const maxCachedUsers = 1000
+
+// cachedUser is a user representation along with the ETag it was served with.
+type cachedUser struct {
+	etag string
+	user *contracts.User
+}
+
+// UserServiceClient handles communication with the User Service.
+type UserServiceClient struct {
+	httpClient *http.Client
+	baseURL    string
+
+	// cache holds previously fetched users keyed by ID so lookups can be
+	// revalidated with If-None-Match instead of transferring the full body.
+	mu    sync.Mutex
+	cache map[int64]cachedUser
+}
+
+// NewUserServiceClient creates a new UserServiceClient.
+func NewUserServiceClient(httpClient *http.Client, baseURL string) *UserServiceClient {
+	return &UserServiceClient{
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		cache:      make(map[int64]cachedUser),
+	}
+}
+
+// CreateUser sends a POST request to the User Service to create a new user.
+// ErrUserNameTaken is returned if another user already has the name.
+func (c *UserServiceClient) CreateUser(name string) (*contracts.User, error) {
+	req, err := c.newCreateUserRequest(contracts.CreateUserRequest{Name: name})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusConflict {
+		return nil, ErrUserNameTaken
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.UserServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+
+	return apiResp.User, nil
+}
+
+// GetUserByID sends a GET request to the User Service to retrieve a user by ID.
+// Previously fetched users are revalidated with If-None-Match; a 304 response
+// reuses the cached representation.
+func (c *UserServiceClient) GetUserByID(id int64) (*contracts.User, error) {
+	req, err := c.newGetUserByIDRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, hasCached := c.getCached(id)
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		return cached.user, nil
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		c.evictCached(id)
+		return nil, nil // User not found, return nil user and nil error
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.UserServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && apiResp.User != nil {
+		c.putCached(id, cachedUser{etag: etag, user: apiResp.User})
+	}
+
+	return apiResp.User, nil
+}
+
+// UpdateUser sends a PUT request to the User Service to rename a user.
+// The update only succeeds if version is still the user's current version; otherwise
+// ErrUserVersionConflict is returned. ErrUserNameTaken is returned if another user already has
+// the name. A nil user and nil error mean the user does not exist.
+func (c *UserServiceClient) UpdateUser(id int64, name string, version int64) (*contracts.User, error) {
+	req, err := c.newUpdateUserRequest(id, contracts.UpdateUserRequest{Name: name, Version: version})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Whatever the outcome, a previously cached representation may now be stale
+	c.evictCached(id)
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, nil
+	case http.StatusConflict:
+		// Both stale versions and taken names are conflicts; the error code tells them apart
+		var apiResp contracts.UserServiceResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiResp); err == nil && apiResp.Code == contracts.ErrorCodeNameTaken {
+			return nil, ErrUserNameTaken
+		}
+		return nil, ErrUserVersionConflict
+	case http.StatusOK:
+	default:
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.UserServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+
+	return apiResp.User, nil
+}
+
+// getCached returns the cached user for the given ID, if any.
+func (c *UserServiceClient) getCached(id int64) (cachedUser, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.cache[id]
+	return entry, ok
+}
+
+// putCached stores a user representation, evicting an arbitrary entry when the cache is full.
+func (c *UserServiceClient) putCached(id int64, entry cachedUser) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.cache[id]; !exists && len(c.cache) >= maxCachedUsers {
+		for evictID := range c.cache {
+			delete(c.cache, evictID)
+			break
+		}
+	}
+	c.cache[id] = entry
+}
+
+// evictCached removes a user from the cache.
+func (c *UserServiceClient) evictCached(id int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cache, id)
+}
+
+// VerifyCredentials asks the User Service to check a user's name and password, without opening
+// a session. It returns ErrInvalidCredentials for unknown names and wrong passwords, and
+// ErrUserSuspended for suspended users.
+func (c *UserServiceClient) VerifyCredentials(name, password string) (*contracts.User, error) {
+	body, err := json.Marshal(map[string]string{"name": name, "password": password})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request to User Service: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/credentials/verify", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to User Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidCredentials
+	case http.StatusForbidden:
+		return nil, ErrUserSuspended
+	default:
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.UserServiceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+	if apiResp.User == nil {
+		return nil, fmt.Errorf("User Service returned no user")
+	}
+	return apiResp.User, nil
+}
+
+// IntrospectToken asks the User Service whether a personal access token is valid.
+// It returns nil and a nil error if the token is unknown, expired or revoked.
+func (c *UserServiceClient) IntrospectToken(token string) (*contracts.AccessToken, error) {
+	req, err := c.newIntrospectTokenRequest(contracts.IntrospectTokenRequest{Token: token})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.AccessTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+	if !apiResp.Active {
+		return nil, nil
+	}
+	return apiResp.AccessToken, nil
+}
+
+// GetSignupStats sends a GET request to the User Service for user totals and signups, per day
+// for the last days days (the User Service default when 0) and per week.
+func (c *UserServiceClient) GetSignupStats(days int) (*contracts.SignupStats, error) {
+	req, err := c.newGetSignupStatsRequest(contracts.GetSignupStatsParams{Days: days})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp contracts.SignupStatsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode User Service response: %w", err)
+	}
+
+	if !apiResp.Result {
+		return nil, fmt.Errorf("User Service reported error: %s", apiResp.Error)
+	}
+	return apiResp.Stats, nil
+}
+
+// ExportUsers streams every user that is not deleted from the User Service's NDJSON export,
+// calling fn for each of them. It stops at the first error returned by fn. Exports take as long
+// as there are users, so the client's HTTP client should not bound the duration of requests.
+func (c *UserServiceClient) ExportUsers(fn func(user contracts.User) error) error {
+	resp, err := c.httpClient.Get(c.baseURL + "/users/export?format=ndjson")
+	if err != nil {
+		return fmt.Errorf("failed to send request to User Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("User Service returned non-OK status: %s", resp.Status)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var user contracts.User
+		if err := decoder.Decode(&user); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode User Service export: %w", err)
+		}
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Package contracts holds the canonical representations the services exchange over HTTP: the
+// entities each service serves, such as User and Listing, and the envelopes its responses are
+// wrapped in. Clients decode responses into these types, and services written in Go check
+// their own models against them, so a change to a contract breaks the build of every service
+// that depends on it instead of failing at runtime. The types are generated from the OpenAPI
+// spec of each service into <service>_gen.go by pkg/openapi. The typed clients of each service
+// live in the client package.
+package contracts
@@ -0,0 +1,3 @@
+module contracts
+
+go 1.24.4
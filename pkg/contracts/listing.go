@@ -0,0 +1,8 @@
+package contracts
+
+// The types of the Listing Service's contracts are generated from
+// listing-service/api/openapi.yaml into listing_gen.go.
+
+// ErrorCodeListingQuotaExceeded is the error code the Listing Service reports when a user already
+// has as many active listings as it allows.
+const ErrorCodeListingQuotaExceeded = "listing_quota_exceeded"
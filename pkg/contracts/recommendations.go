@@ -0,0 +1,8 @@
+package contracts
+
+// The types of the Recommendations Service's contracts are generated from
+// recommendations-service/api/openapi.yaml into recommendations_gen.go.
+
+// MaxRecommendations is the maximum number of recommendations the Recommendations Service
+// returns at once.
+const MaxRecommendations = 50
@@ -0,0 +1,7 @@
+package contracts
+
+// The types of the Review Service's contracts are generated from
+// review-service/api/openapi.yaml into review_gen.go.
+
+// MaxRatingBatchSize is the maximum number of listings the Review Service rates at once.
+const MaxRatingBatchSize = 100
@@ -0,0 +1,7 @@
+package contracts
+
+// The types of the Search Service's contracts are generated from
+// search-service/api/openapi.yaml into search_gen.go.
+
+// HitTypeListing is the type of the Search Service's listing hits.
+const HitTypeListing = "listing"
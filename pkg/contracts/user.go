@@ -0,0 +1,10 @@
+package contracts
+
+// The types of the User Service's contracts are generated from user-service/api/openapi.yaml
+// into user_gen.go.
+
+// ErrorCodeNameTaken is the error code the User Service reports for names that are already taken.
+const ErrorCodeNameTaken = "name_taken"
+
+// UserStatusSuspended is the status of users that may not act on the platform.
+const UserStatusSuspended = "suspended"
@@ -0,0 +1,14 @@
+package contracts
+
+import "strings"
+
+// ValidationError lists the constraints of a contract a request violates. The Validate methods
+// and Parse functions generated from the OpenAPI specs return it.
+type ValidationError struct {
+	Messages []string
+}
+
+// Error returns the messages, separated by semicolons.
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Messages, "; ")
+}
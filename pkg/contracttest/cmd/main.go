@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"contracttest"
+)
+
+func main() {
+	// Define command-line flags for the provider and the pacts it must honor
+	providerURL := flag.String("provider-url", "", "URL of the running provider to verify, e.g. http://localhost:7000")
+	timeout := flag.Duration("timeout", 10*time.Second, "How long each request to the provider may take")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -provider-url URL PACT...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *providerURL == "" || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// Replay every pact, reporting all the interactions the provider breaks before failing
+	verifier := contracttest.NewVerifier(&http.Client{Timeout: *timeout}, *providerURL)
+	failed := false
+	for _, path := range flag.Args() {
+		pact, err := contracttest.Load(path)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := verifier.Verify(context.Background(), pact); err != nil {
+			log.Printf("%s breaks its pact with %s:\n%v", pact.Provider, pact.Consumer, err)
+			failed = true
+			continue
+		}
+		log.Printf("%s honors its %d interaction(s) with %s", pact.Provider, len(pact.Interactions), pact.Consumer)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
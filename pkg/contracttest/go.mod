@@ -0,0 +1,3 @@
+module contracttest
+
+go 1.24.4
@@ -0,0 +1,129 @@
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Match compares the shape of a decoded JSON value to the shape of the expected one, returning
+// a message per mismatch, e.g. "$.user.id: expected a number, got a string". Objects match when
+// they have every expected field, arrays when each of their elements matches the first expected
+// element, and other values when they have the same type. An expected null matches anything.
+func Match(expected, actual any) []string {
+	return match("$", expected, actual)
+}
+
+func match(path string, expected, actual any) []string {
+	if expected == nil {
+		return nil
+	}
+	if kindOf(expected) != kindOf(actual) {
+		return []string{fmt.Sprintf("%s: expected %s, got %s", path, kindOf(expected), kindOf(actual))}
+	}
+
+	var mismatches []string
+	switch expected := expected.(type) {
+	case map[string]any:
+		actual := actual.(map[string]any)
+		keys := make([]string, 0, len(expected))
+		for key := range expected {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok := actual[key]
+			if !ok {
+				mismatches = append(mismatches, fmt.Sprintf("%s.%s: missing", path, key))
+				continue
+			}
+			mismatches = append(mismatches, match(path+"."+key, expected[key], value)...)
+		}
+	case []any:
+		if len(expected) == 0 {
+			return nil
+		}
+		for i, element := range actual.([]any) {
+			mismatches = append(mismatches, match(fmt.Sprintf("%s[%d]", path, i), expected[0], element)...)
+		}
+	}
+	return mismatches
+}
+
+// kindOf describes the JSON type of a decoded value.
+func kindOf(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "an object"
+	case []any:
+		return "an array"
+	case string:
+		return "a string"
+	case float64:
+		return "a number"
+	case bool:
+		return "a boolean"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+// matchRequest reports why the request r does not match the expanded expected request, or
+// returns "" when it does. Paths and queries must be equal, expected headers present, and
+// bodies equal once decoded according to their Content-Type.
+func matchRequest(expected Request, r *http.Request, body []byte) string {
+	if r.Method != expected.Method {
+		return fmt.Sprintf("method %s, expected %s", r.Method, expected.Method)
+	}
+	expectedURL, err := url.Parse(expected.Path)
+	if err != nil {
+		return fmt.Sprintf("invalid expected path %q", expected.Path)
+	}
+	if r.URL.Path != expectedURL.Path {
+		return fmt.Sprintf("path %s, expected %s", r.URL.Path, expectedURL.Path)
+	}
+	if query := r.URL.Query(); !reflect.DeepEqual(query, expectedURL.Query()) {
+		return fmt.Sprintf("query %q, expected %q", query.Encode(), expectedURL.Query().Encode())
+	}
+	for name, value := range expected.Headers {
+		if got := r.Header.Get(name); got != value {
+			return fmt.Sprintf("header %s %q, expected %q", name, got, value)
+		}
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(expected.Headers["Content-Type"])
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		got, err := url.ParseQuery(string(body))
+		if err != nil {
+			return fmt.Sprintf("invalid form body: %v", err)
+		}
+		want, _ := url.ParseQuery(expected.Body)
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Sprintf("form %q, expected %q", got.Encode(), want.Encode())
+		}
+	case "application/json":
+		var got, want any
+		if err := json.Unmarshal(body, &got); err != nil {
+			return fmt.Sprintf("invalid JSON body: %v", err)
+		}
+		if err := json.Unmarshal([]byte(expected.Body), &want); err != nil {
+			return fmt.Sprintf("invalid expected JSON body: %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			return fmt.Sprintf("body %s, expected %s", body, expected.Body)
+		}
+	default:
+		if strings.TrimSpace(string(body)) != strings.TrimSpace(expected.Body) {
+			return fmt.Sprintf("body %q, expected %q", body, expected.Body)
+		}
+	}
+	return ""
+}
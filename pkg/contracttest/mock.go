@@ -0,0 +1,98 @@
+package contracttest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// MockProvider stands in for a provider while a consumer exercises its client: it answers the
+// requests of its interactions with their expected responses, and keeps track of the requests
+// it was not given an interaction for and the interactions never requested.
+type MockProvider struct {
+	// URL is the base URL of the mock, for the consumer's client.
+	URL string
+
+	pact   Pact
+	server *httptest.Server
+
+	mu         sync.Mutex
+	requested  []bool // Whether each interaction was requested
+	unexpected []error
+}
+
+// NewMockProvider starts a mock of provider answering the interactions consumer expects.
+// Close must be called to stop it.
+func NewMockProvider(consumer, provider string, interactions ...Interaction) *MockProvider {
+	m := &MockProvider{
+		pact:      Pact{Consumer: consumer, Provider: provider, Interactions: interactions},
+		requested: make([]bool, len(interactions)),
+	}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	m.URL = m.server.URL
+	return m
+}
+
+// serve answers a request with the response of the first interaction it matches.
+func (m *MockProvider) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return
+	}
+
+	var mismatches []string
+	for i, interaction := range m.pact.Interactions {
+		expected, err := interaction.Request.expand(interaction.Examples)
+		if err != nil {
+			mismatches = append(mismatches, fmt.Sprintf("%q: %v", interaction.Description, err))
+			continue
+		}
+		if mismatch := matchRequest(expected, r, body); mismatch != "" {
+			mismatches = append(mismatches, fmt.Sprintf("%q: %s", interaction.Description, mismatch))
+			continue
+		}
+
+		m.mu.Lock()
+		m.requested[i] = true
+		m.mu.Unlock()
+		if len(interaction.Response.Body) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(interaction.Response.Status)
+		w.Write(interaction.Response.Body)
+		return
+	}
+
+	m.mu.Lock()
+	m.unexpected = append(m.unexpected, fmt.Errorf("unexpected request %s %s, mismatching %v", r.Method, r.URL, mismatches))
+	m.mu.Unlock()
+	http.Error(w, "no interaction matches the request", http.StatusInternalServerError)
+}
+
+// Err returns the unexpected requests the mock received and the interactions it did not, or
+// nil when the consumer sent exactly the requests of the pact.
+func (m *MockProvider) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	errs := append([]error(nil), m.unexpected...)
+	for i, requested := range m.requested {
+		if !requested {
+			errs = append(errs, fmt.Errorf("interaction %q was never requested", m.pact.Interactions[i].Description))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Pact returns the pact of the mock's interactions.
+func (m *MockProvider) Pact() Pact {
+	return m.pact
+}
+
+// Close stops the mock.
+func (m *MockProvider) Close() {
+	m.server.Close()
+}
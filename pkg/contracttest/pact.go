@@ -0,0 +1,152 @@
+// Package contracttest checks the contracts between the gateway and the services it calls from
+// the consumer's side. The gateway records, in a Pact, the requests its clients send to a
+// service and the parts of the responses they read, by exercising the clients against a
+// MockProvider. Each service then runs a Verifier replaying the pact against it, which fails
+// when the service changes its JSON in a way that breaks the gateway, while fields the gateway
+// does not read may change freely.
+package contracttest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Unique is the placeholder replaced by a value unique to each verification of an interaction,
+// to create entities whose names must not be taken, e.g. "name=pact-{{unique}}".
+const Unique = "unique"
+
+// Pact is the set of interactions a consumer expects a provider to answer.
+type Pact struct {
+	Consumer     string        `json:"consumer"`
+	Provider     string        `json:"provider"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request of the consumer along with the response it expects.
+//
+// Requests may contain placeholders such as {{user_id}} in their path and body. While
+// verifying, they are replaced by the values captured from the responses to the Setup requests,
+// which put the provider in the state the interaction needs, e.g. by creating the user it reads.
+// The mock provider has no state and replaces them by the Examples instead.
+type Interaction struct {
+	Description string            `json:"description"`
+	Setup       []Request         `json:"setup,omitempty"`
+	Request     Request           `json:"request"`
+	Response    Response          `json:"response"`
+	Examples    map[string]string `json:"examples,omitempty"`
+}
+
+// Request is an HTTP request to the provider.
+type Request struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // Along with the query, e.g. /users/stats?days=7
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"` // Form or JSON, as given by the Content-Type header
+
+	// Capture names values of the JSON response to keep as placeholders of the following
+	// requests, by their dotted path, e.g. {"user_id": "user.id"}. Only used in setups.
+	Capture map[string]string `json:"capture,omitempty"`
+}
+
+// Response is the part of a response the consumer relies on.
+type Response struct {
+	Status int `json:"status"`
+	// Body is an example of the JSON the consumer reads. A provider's body matches when it has
+	// at least the same fields with values of the same types; its values are not compared.
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// FileName returns the name of the file the pact between consumer and provider is saved as.
+func FileName(consumer, provider string) string {
+	return consumer + "-" + provider + ".json"
+}
+
+// Load reads the pact saved at path.
+func Load(path string) (Pact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Pact{}, fmt.Errorf("failed to read pact: %w", err)
+	}
+	var pact Pact
+	if err := json.Unmarshal(data, &pact); err != nil {
+		return Pact{}, fmt.Errorf("failed to decode pact %s: %w", path, err)
+	}
+	return pact, nil
+}
+
+// Save writes the pact to its file in dir, returning the path of the file.
+func (p Pact) Save(dir string) (string, error) {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode pact: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create pact directory: %w", err)
+	}
+	path := filepath.Join(dir, FileName(p.Consumer, p.Provider))
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write pact: %w", err)
+	}
+	return path, nil
+}
+
+// placeholderPattern matches the placeholders of requests.
+var placeholderPattern = regexp.MustCompile(`\{\{([a-z_]+)\}\}`)
+
+// expand returns the request with its placeholders replaced by values. Values are inserted as
+// they are, so they must not need escaping in the path or body.
+func (r Request) expand(values map[string]string) (Request, error) {
+	var missing []string
+	replace := func(s string) string {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(placeholder string) string {
+			name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+			value, ok := values[name]
+			if !ok {
+				missing = append(missing, name)
+			}
+			return value
+		})
+	}
+	r.Path = replace(r.Path)
+	r.Body = replace(r.Body)
+	if len(missing) > 0 {
+		return r, fmt.Errorf("no value for placeholders %s of %s %s", strings.Join(missing, ", "), r.Method, r.Path)
+	}
+	return r, nil
+}
+
+// capture adds the values r.Capture names in the JSON body of its response to values.
+func (r Request) capture(body []byte, values map[string]string) error {
+	if len(r.Capture) == 0 {
+		return nil
+	}
+	var decoded any
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return fmt.Errorf("failed to decode response to %s %s: %w", r.Method, r.Path, err)
+	}
+	for name, path := range r.Capture {
+		value := decoded
+		for _, key := range strings.Split(path, ".") {
+			object, ok := value.(map[string]any)
+			if !ok {
+				value = nil
+				break
+			}
+			value = object[key]
+		}
+		switch v := value.(type) {
+		case string:
+			values[name] = v
+		case float64, bool:
+			encoded, _ := json.Marshal(v)
+			values[name] = string(encoded)
+		default:
+			return fmt.Errorf("response to %s %s has no value at %s to capture as %s", r.Method, r.Path, path, name)
+		}
+	}
+	return nil
+}
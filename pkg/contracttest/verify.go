@@ -0,0 +1,109 @@
+package contracttest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxResponseSize bounds the responses a Verifier reads.
+const maxResponseSize = 10 << 20
+
+// Verifier replays pacts against a running provider.
+type Verifier struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewVerifier creates a new Verifier of the provider at baseURL.
+func NewVerifier(httpClient *http.Client, baseURL string) *Verifier {
+	return &Verifier{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Verify replays the interactions of pact in order, returning an error per interaction the
+// provider does not honor, or nil when it honors them all.
+func (v *Verifier) Verify(ctx context.Context, pact Pact) error {
+	var errs []error
+	for _, interaction := range pact.Interactions {
+		if err := v.verify(ctx, interaction); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q: %w", pact.Provider, interaction.Description, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verify sets the provider up for interaction, then checks its response to the request.
+func (v *Verifier) verify(ctx context.Context, interaction Interaction) error {
+	unique := make([]byte, 6)
+	rand.Read(unique)
+	values := map[string]string{Unique: hex.EncodeToString(unique)}
+
+	for _, setup := range interaction.Setup {
+		status, body, err := v.send(ctx, setup, values)
+		if err != nil {
+			return fmt.Errorf("setup failed: %w", err)
+		}
+		if status >= 300 {
+			return fmt.Errorf("setup %s %s answered %d: %s", setup.Method, setup.Path, status, body)
+		}
+		if err := setup.capture(body, values); err != nil {
+			return fmt.Errorf("setup failed: %w", err)
+		}
+	}
+
+	status, body, err := v.send(ctx, interaction.Request, values)
+	if err != nil {
+		return err
+	}
+	if status != interaction.Response.Status {
+		return fmt.Errorf("expected status %d, got %d: %s", interaction.Response.Status, status, body)
+	}
+	if len(interaction.Response.Body) == 0 {
+		return nil
+	}
+
+	var expected, actual any
+	if err := json.Unmarshal(interaction.Response.Body, &expected); err != nil {
+		return fmt.Errorf("invalid expected body: %w", err)
+	}
+	if err := json.Unmarshal(body, &actual); err != nil {
+		return fmt.Errorf("response is not JSON: %w", err)
+	}
+	if mismatches := Match(expected, actual); len(mismatches) > 0 {
+		return errors.New("response does not match: " + strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// send sends the request with its placeholders replaced by values, returning the status and
+// body of the response.
+func (v *Verifier) send(ctx context.Context, request Request, values map[string]string) (int, []byte, error) {
+	request, err := request.expand(values)
+	if err != nil {
+		return 0, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, request.Method, v.baseURL+request.Path, strings.NewReader(request.Body))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	for name, value := range request.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to send %s %s: %w", request.Method, request.Path, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response to %s %s: %w", request.Method, request.Path, err)
+	}
+	return resp.StatusCode, body, nil
+}
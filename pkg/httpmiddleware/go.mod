@@ -0,0 +1,5 @@
+module httpmiddleware
+
+go 1.24.4
+
+require github.com/gorilla/mux v1.8.1
@@ -0,0 +1,21 @@
+package httpmiddleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// AccessLog logs every request once it is served, with its status, response size, duration
+// and request ID.
+func AccessLog(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := newStatusRecorder(w)
+			next.ServeHTTP(rec, r)
+			logger.Printf("%s %s %d %dB %s request_id=%s", r.Method, r.URL.RequestURI(), rec.status, rec.bytes,
+				time.Since(start).Round(time.Microsecond), RequestIDFromContext(r.Context()))
+		})
+	}
+}
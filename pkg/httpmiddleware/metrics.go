@@ -0,0 +1,194 @@
+package httpmiddleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// durationBuckets are the upper bounds, in seconds, of the request duration histogram.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// otherRoute names the route of requests when routes are not named, or match none.
+const otherRoute = "other"
+
+// requestKey identifies a series of the request counter.
+type requestKey struct {
+	method, route string
+	status        int
+}
+
+// routeKey identifies a series of the request duration histogram.
+type routeKey struct {
+	method, route string
+}
+
+// histogram counts observations per bucket of durationBuckets, the last count being +Inf.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Metrics counts the requests served by a service, by method, route and status, and
+// measures their duration, exposing them in the Prometheus text format.
+type Metrics struct {
+	service   string
+	inFlight  atomic.Int64
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[routeKey]*histogram
+}
+
+// NewMetrics creates a new Metrics for a service, labelling its series with service.
+func NewMetrics(service string) *Metrics {
+	return &Metrics{service: service, requests: make(map[requestKey]uint64), durations: make(map[routeKey]*histogram)}
+}
+
+// Middleware records the requests served by next, their route being named by route.
+func (m *Metrics) Middleware(route func(*http.Request) string) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name := otherRoute
+			if route != nil {
+				name = route(r)
+			}
+
+			m.inFlight.Add(1)
+			start := time.Now()
+			rec := newStatusRecorder(w)
+			defer func() {
+				m.inFlight.Add(-1)
+				m.observe(r.Method, name, rec.status, time.Since(start))
+			}()
+			next.ServeHTTP(rec, r)
+		})
+	}
+}
+
+// observe records a request served.
+func (m *Metrics) observe(method, route string, status int, elapsed time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method, route, status}]++
+	h := m.durations[routeKey{method, route}]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(durationBuckets)+1)}
+		m.durations[routeKey{method, route}] = h
+	}
+	seconds := elapsed.Seconds()
+	i := sort.SearchFloat64s(durationBuckets, seconds)
+	h.counts[i]++
+	h.sum += seconds
+	h.count++
+}
+
+// Handler serves the metrics in the Prometheus text format:
+//   - http_requests_total, a counter of requests by method, route and status
+//   - http_request_duration_seconds, a histogram of request durations by method and route
+//   - http_requests_in_flight, a gauge of the requests being served
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		var b strings.Builder
+		service := fmt.Sprintf(`service="%s"`, escapeLabel(m.service))
+
+		m.mu.Lock()
+		requestKeys := make([]requestKey, 0, len(m.requests))
+		for k := range m.requests {
+			requestKeys = append(requestKeys, k)
+		}
+		sort.Slice(requestKeys, func(i, j int) bool {
+			x, y := requestKeys[i], requestKeys[j]
+			if x.route != y.route {
+				return x.route < y.route
+			}
+			if x.method != y.method {
+				return x.method < y.method
+			}
+			return x.status < y.status
+		})
+		b.WriteString("# HELP http_requests_total Requests served, by method, route and status.\n")
+		b.WriteString("# TYPE http_requests_total counter\n")
+		for _, k := range requestKeys {
+			fmt.Fprintf(&b, "http_requests_total{%s,method=\"%s\",route=\"%s\",status=\"%d\"} %d\n",
+				service, escapeLabel(k.method), escapeLabel(k.route), k.status, m.requests[k])
+		}
+
+		routeKeys := make([]routeKey, 0, len(m.durations))
+		for k := range m.durations {
+			routeKeys = append(routeKeys, k)
+		}
+		sort.Slice(routeKeys, func(i, j int) bool {
+			if routeKeys[i].route != routeKeys[j].route {
+				return routeKeys[i].route < routeKeys[j].route
+			}
+			return routeKeys[i].method < routeKeys[j].method
+		})
+		b.WriteString("# HELP http_request_duration_seconds Time taken to serve requests, by method and route.\n")
+		b.WriteString("# TYPE http_request_duration_seconds histogram\n")
+		for _, k := range routeKeys {
+			h := m.durations[k]
+			labels := fmt.Sprintf(`%s,method="%s",route="%s"`, service, escapeLabel(k.method), escapeLabel(k.route))
+			var cumulative uint64
+			for i, bound := range durationBuckets {
+				cumulative += h.counts[i]
+				fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+			}
+			fmt.Fprintf(&b, "http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+			fmt.Fprintf(&b, "http_request_duration_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+			fmt.Fprintf(&b, "http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+		}
+		m.mu.Unlock()
+
+		b.WriteString("# HELP http_requests_in_flight Requests being served.\n")
+		b.WriteString("# TYPE http_requests_in_flight gauge\n")
+		fmt.Fprintf(&b, "http_requests_in_flight{%s} %d\n", service, m.inFlight.Load())
+
+		w.Write([]byte(b.String()))
+	})
+}
+
+// ListenAndServe serves the metrics at /metrics on addr, apart from the service's API so they
+// are not exposed along with it. It always returns a non-nil error, like http.ListenAndServe.
+func (m *Metrics) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", m.Handler())
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+	return server.ListenAndServe()
+}
+
+// MuxRoute names the route of a request by the path template of the router's route it
+// matches, e.g. /users/{id}, or "other" when it matches none.
+func MuxRoute(router *mux.Router) func(*http.Request) string {
+	return func(r *http.Request) string {
+		var match mux.RouteMatch
+		if !router.Match(r, &match) || match.Route == nil {
+			return otherRoute
+		}
+		template, err := match.Route.GetPathTemplate()
+		if err != nil {
+			return otherRoute
+		}
+		return template
+	}
+}
+
+// escapeLabel escapes a label value of the Prometheus text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
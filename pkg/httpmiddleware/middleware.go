@@ -0,0 +1,64 @@
+// Package httpmiddleware implements the cross-cutting concerns of the services' HTTP servers
+// once: request IDs, panic recovery, access logging, metrics and timeouts. Each service
+// configures the stack it needs with a Config and wraps its router with Wrap.
+package httpmiddleware
+
+import (
+	"log"
+	"net/http"
+	"time"
+)
+
+// Middleware wraps an http.Handler. It is compatible with mux.MiddlewareFunc.
+type Middleware func(http.Handler) http.Handler
+
+// Config configures the middleware stack of a service.
+type Config struct {
+	// Logger receives access logs and recovered panics; log.Default() when nil.
+	Logger *log.Logger
+	// AccessLog enables logging every request once it is served.
+	AccessLog bool
+	// Metrics records the requests served; nil disables metrics.
+	Metrics *Metrics
+	// Route names the route of a request in metrics, e.g. MuxRoute(router), so requests for
+	// different IDs are counted together. Requests are counted under "other" when nil.
+	Route func(*http.Request) string
+	// Timeout bounds the time taken to serve a request, answering 503 Service Unavailable
+	// when it runs out; zero disables it. It should be shorter than the server's WriteTimeout.
+	Timeout time.Duration
+	// NoTimeout exempts requests from Timeout, e.g. streamed responses or long uploads, which
+	// cannot be buffered.
+	NoTimeout func(*http.Request) bool
+}
+
+// Wrap wraps h with the middleware enabled by cfg. From the outside in: every request gets a
+// request ID, is logged and measured, and panics are recovered before they reach them, so
+// they are logged and counted as 500s; the timeout is innermost, as it serves the handler
+// from another goroutine.
+func Wrap(h http.Handler, cfg Config) http.Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+
+	stack := []Middleware{RequestID}
+	if cfg.AccessLog {
+		stack = append(stack, AccessLog(logger))
+	}
+	if cfg.Metrics != nil {
+		stack = append(stack, cfg.Metrics.Middleware(cfg.Route))
+	}
+	stack = append(stack, Recovery(logger))
+	if cfg.Timeout > 0 {
+		stack = append(stack, Timeout(cfg.Timeout, cfg.NoTimeout))
+	}
+	return Chain(h, stack...)
+}
+
+// Chain wraps h with the middleware, the first being the outermost.
+func Chain(h http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,37 @@
+package httpmiddleware
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// Recovery recovers from panics in handlers, logging them with their stack trace and the
+// request ID, and answering 500 Internal Server Error instead of dropping the connection.
+// http.ErrAbortHandler is passed on, as it is meant to abort the response.
+func Recovery(logger *log.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+				logger.Printf("Panic serving %s %s (request %s): %v\n%s", r.Method, r.URL.Path, RequestIDFromContext(r.Context()), p, debug.Stack())
+				// The response may be partly written already, in which case this is ignored
+				writeError(w, http.StatusInternalServerError, "Internal server error")
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeError writes a JSON error response in the format of the services.
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"result":false,"error":"` + message + `"}`))
+}
@@ -0,0 +1,28 @@
+package httpmiddleware
+
+import (
+	"net/http"
+	"time"
+)
+
+// timeoutBody is the body of the 503 responses of requests that timed out.
+const timeoutBody = `{"result":false,"error":"Request timed out"}`
+
+// Timeout answers 503 Service Unavailable for requests not served within d, except those
+// exempt reports true for (exempt may be nil). Responses are buffered until the handler
+// returns, so streamed responses must be exempt. A handler that times out keeps running
+// until it returns, but its writes are discarded.
+func Timeout(d time.Duration, exempt func(*http.Request) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		timeout := http.TimeoutHandler(next, d, timeoutBody)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt != nil && exempt(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			// Headers set by the handler replace this one; it only remains on timeouts
+			w.Header().Set("Content-Type", "application/json")
+			timeout.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+// Package money represents amounts of money as integer numbers of minor units of a currency,
+// like the Listing Service's money module. It is shared by the Go services as the module money.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// MaxAmount is the largest amount, in minor units, that JSON clients can represent exactly (2^53 - 1).
+const MaxAmount = 1<<53 - 1
+
+// defaultMinorUnits is the number of decimals of currencies missing from minorUnits.
+const defaultMinorUnits = 2
+
+// minorUnits holds the decimals of the currencies whose minor unit is not a hundredth (ISO 4217).
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// decimalPattern matches the plain decimal amounts Parse accepts, e.g. "12.50".
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// ErrCurrencyMismatch is returned when amounts in different currencies are combined.
+var ErrCurrencyMismatch = errors.New("currencies do not match")
+
+// Money is an amount of minor units (e.g. cents) of a currency, given by its ISO 4217 code.
+// Arithmetic stays in integers; parsing and conversions go through exact rationals.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// MinorUnits returns the number of digits after the decimal point of currency's minor unit.
+func MinorUnits(currency string) int {
+	if digits, ok := minorUnits[currency]; ok {
+		return digits
+	}
+	return defaultMinorUnits
+}
+
+// New returns amount minor units of currency, checking that the amount is within MaxAmount
+// and that currency looks like an ISO 4217 code.
+func New(amount int64, currency string) (Money, error) {
+	if len(currency) != 3 || strings.ToUpper(currency) != currency {
+		return Money{}, fmt.Errorf("invalid currency %q", currency)
+	}
+	if amount > MaxAmount || amount < -MaxAmount {
+		return Money{}, fmt.Errorf("amount must be at most %d minor units", int64(MaxAmount))
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Parse parses a decimal amount in major units, e.g. "12.50" USD. It fails if the amount has
+// more decimals than the currency's minor unit allows.
+func Parse(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	major, ok := new(big.Rat).SetString(value)
+	if !ok || !decimalPattern.MatchString(value) {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	minor := major.Mul(major, scale(MinorUnits(currency)))
+	if !minor.IsInt() {
+		return Money{}, fmt.Errorf("%s has at most %d decimals", currency, MinorUnits(currency))
+	}
+	if !minor.Num().IsInt64() {
+		return Money{}, fmt.Errorf("amount must be at most %d minor units", int64(MaxAmount))
+	}
+	return New(minor.Num().Int64(), currency)
+}
+
+// Format returns the amount in major units with the currency's decimals, e.g. "1234.50".
+func (m Money) Format() string {
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), scale(MinorUnits(m.Currency)).Num()).FloatString(MinorUnits(m.Currency))
+}
+
+// String returns the formatted amount followed by the currency, e.g. "1234.50 USD".
+func (m Money) String() string {
+	return m.Format() + " " + m.Currency
+}
+
+// Add returns m + other, which must be in the same currency.
+func (m Money) Add(other Money) (Money, error) {
+	if other.Currency != m.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return New(m.Amount+other.Amount, m.Currency)
+}
+
+// Sub returns m - other, which must be in the same currency.
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Currency != m.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return New(m.Amount-other.Amount, m.Currency)
+}
+
+// Discounted returns the amount after a discount of percent, rounded down to a whole minor unit
+// like the Listing Service's effective prices.
+func (m Money) Discounted(percent int64) Money {
+	discounted := new(big.Int).Mul(big.NewInt(m.Amount), big.NewInt(100-percent))
+	return Money{Amount: discounted.Div(discounted, big.NewInt(100)).Int64(), Currency: m.Currency}
+}
+
+// Convert returns the amount in currency, given the rate of one major unit of m's currency in
+// major units of the other as a decimal string, e.g. "0.92" for USD to EUR. The result is
+// rounded half to even.
+func (m Money) Convert(rate, currency string) (Money, error) {
+	r, ok := new(big.Rat).SetString(rate)
+	if !ok || r.Sign() <= 0 {
+		return Money{}, fmt.Errorf("invalid rate %q", rate)
+	}
+	minor := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r)
+	minor.Mul(minor, scale(MinorUnits(currency)))
+	minor.Quo(minor, scale(MinorUnits(m.Currency)))
+	rounded := roundHalfEven(minor)
+	if !rounded.IsInt64() {
+		return Money{}, fmt.Errorf("amount must be at most %d minor units", int64(MaxAmount))
+	}
+	return New(rounded.Int64(), currency)
+}
+
+// scale returns 10^digits.
+func scale(digits int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil))
+}
+
+// roundHalfEven rounds x to the nearest integer, ties to even.
+func roundHalfEven(x *big.Rat) *big.Int {
+	quo, rem := new(big.Int).QuoRem(x.Num(), x.Denom(), new(big.Int))
+	// Compare twice the remainder to the denominator to find which integer is nearer
+	twice := new(big.Int).Abs(rem)
+	twice.Lsh(twice, 1)
+	switch cmp := twice.Cmp(x.Denom()); {
+	case cmp > 0, cmp == 0 && quo.Bit(0) == 1:
+		if x.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+	return quo
+}
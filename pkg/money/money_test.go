@@ -0,0 +1,37 @@
+package money
+
+import (
+	"encoding/json"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestMinorUnitsMatchListingService checks that the currency table of this package and the
+// one of the Listing Service's money module agree, so that both sides read amounts alike.
+func TestMinorUnitsMatchListingService(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skipf("Skipping: reading the Listing Service's money module needs python3: %v", err)
+	}
+	cmd := exec.Command("python3", "-c",
+		`import json, money; print(json.dumps({"minor_units": money.MINOR_UNITS, "default": money.DEFAULT_MINOR_UNITS}))`)
+	cmd.Dir = filepath.Join("..", "..", "listing-service")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Failed to read the Listing Service's money module: %v", err)
+	}
+	var listing struct {
+		MinorUnits map[string]int `json:"minor_units"`
+		Default    int            `json:"default"`
+	}
+	if err := json.Unmarshal(out, &listing); err != nil {
+		t.Fatalf("Failed to decode %q: %v", out, err)
+	}
+	if listing.Default != defaultMinorUnits {
+		t.Errorf("Listing Service defaults to %d minor units, want %d", listing.Default, defaultMinorUnits)
+	}
+	if !reflect.DeepEqual(listing.MinorUnits, minorUnits) {
+		t.Errorf("Listing Service's minor units are %v, want %v", listing.MinorUnits, minorUnits)
+	}
+}
@@ -0,0 +1,97 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pathParam matches the parameters in paths, e.g. {id}.
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GenerateClient returns the source of package pkg declaring, for each operation, a method of
+// the client type building its request: new<Operation>Request. Its arguments are the path
+// parameters, then the body and the query parameters as types of the models package, imported
+// from modelsImport. The client type is expected to have a baseURL field.
+func (s *Spec) GenerateClient(pkg, source, clientType, modelsImport string) ([]byte, error) {
+	f := newFile(pkg, source)
+	f.use("net/http")
+	models := modelsImport[strings.LastIndex(modelsImport, "/")+1:]
+
+	for _, op := range s.operations() {
+		name := "new" + goName(op.OperationID) + "Request"
+		var args []string
+
+		// Path parameters are escaped into the path
+		path := op.Path
+		var pathArgs []string
+		for _, p := range op.paramsIn("path") {
+			arg := argName(p.Name)
+			typ := goType(p.Schema, "")
+			args = append(args, arg+" "+typ)
+			verb, value := "%d", arg
+			switch typ {
+			case "string":
+				f.use("net/url")
+				verb, value = "%s", "url.PathEscape("+arg+")"
+			case "bool", "float64":
+				verb = "%v"
+			}
+			path = strings.Replace(path, "{"+p.Name+"}", verb, 1)
+			pathArgs = append(pathArgs, value)
+		}
+		if pathParam.MatchString(path) {
+			return nil, fmt.Errorf("%s %s: path parameter without a definition", op.Method, op.Path)
+		}
+
+		body := op.Body()
+		if body != nil {
+			if body.Schema.Ref == "" {
+				return nil, fmt.Errorf("%s: request bodies must reference a schema", op.OperationID)
+			}
+			f.useModule(modelsImport)
+			args = append(args, "body "+models+"."+refName(body.Schema.Ref))
+		}
+		query := op.paramsIn("query")
+		if len(query) > 0 {
+			f.useModule(modelsImport)
+			args = append(args, "params "+models+"."+goName(op.OperationID)+"Params")
+		}
+
+		f.comment(0, fmt.Sprintf("%s builds the request of %s %s: %s", name, op.Method, op.Path, op.Summary))
+		f.printf("func (c *%s) %s(%s) (*http.Request, error) {\n", clientType, name, strings.Join(args, ", "))
+		if len(pathArgs) > 0 {
+			f.use("fmt")
+			f.printf("target := fmt.Sprintf(%q, c.baseURL, %s)\n", "%s"+path, strings.Join(pathArgs, ", "))
+		} else {
+			f.printf("target := c.baseURL + %q\n", path)
+		}
+		if len(query) > 0 {
+			f.printf("if query := params.Values().Encode(); query != \"\" {\ntarget += \"?\" + query\n}\n")
+		}
+
+		method := "http.Method" + strings.ToUpper(op.Method[:1]) + strings.ToLower(op.Method[1:])
+		switch {
+		case body == nil:
+			f.printf("req, err := http.NewRequest(%s, target, nil)\n", method)
+			f.printf("if err != nil {\nreturn nil, fmt.Errorf(\"failed to create request to %s: %%w\", err)\n}\n", s.Info.Title)
+		case body.ContentType == "application/x-www-form-urlencoded":
+			f.use("strings")
+			f.printf("req, err := http.NewRequest(%s, target, strings.NewReader(body.Values().Encode()))\n", method)
+			f.printf("if err != nil {\nreturn nil, fmt.Errorf(\"failed to create request to %s: %%w\", err)\n}\n", s.Info.Title)
+			f.printf("req.Header.Set(\"Content-Type\", %q)\n", body.ContentType)
+		case body.ContentType == "application/json":
+			f.use("bytes", "encoding/json")
+			f.printf("data, err := json.Marshal(body)\nif err != nil {\nreturn nil, fmt.Errorf(\"failed to encode request to %s: %%w\", err)\n}\n", s.Info.Title)
+			f.printf("req, err := http.NewRequest(%s, target, bytes.NewReader(data))\n", method)
+			f.printf("if err != nil {\nreturn nil, fmt.Errorf(\"failed to create request to %s: %%w\", err)\n}\n", s.Info.Title)
+			f.printf("req.Header.Set(\"Content-Type\", %q)\n", body.ContentType)
+		default:
+			return nil, fmt.Errorf("%s: unsupported request body %s", op.OperationID, body.ContentType)
+		}
+		f.use("fmt")
+		f.printf("return req, nil\n}\n\n")
+	}
+
+	return f.bytes()
+}
@@ -0,0 +1,90 @@
+// Command openapi generates the models, client requests and handler interfaces of a service
+// from its OpenAPI spec. With -check, it fails instead when the generated files are stale, so
+// that a spec edited without regenerating is caught.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"openapi"
+)
+
+func main() {
+	// Define command-line flags for the spec and the files generated from it
+	specPath := flag.String("spec", "", "Path of the OpenAPI spec to generate from")
+	modelsPath := flag.String("models", "", "Path of the generated models, skipped when empty")
+	modelsImport := flag.String("models-import", "contracts", "Import path of the package of the models, used by the client")
+	clientPath := flag.String("client", "", "Path of the generated client requests, skipped when empty")
+	clientPackage := flag.String("client-package", "client", "Package of the generated client requests")
+	clientType := flag.String("client-type", "", "Type of the client the request builders are methods of, e.g. UserServiceClient")
+	serverPath := flag.String("server", "", "Path of the generated handler interfaces and routes, skipped when empty")
+	serverPackage := flag.String("server-package", "api", "Package of the generated handler interfaces")
+	check := flag.Bool("check", false, "Report stale generated files and exit with status 1 instead of writing them")
+	flag.Parse()
+	if *specPath == "" || (*clientPath != "" && *clientType == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	spec, err := openapi.Load(*specPath)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	// Generated files name the spec by its path in the repository
+	source := filepath.ToSlash(filepath.Clean(*specPath))
+	for strings.HasPrefix(source, "../") {
+		source = strings.TrimPrefix(source, "../")
+	}
+
+	var outputs []output
+	if *modelsPath != "" {
+		pkg := *modelsImport
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		outputs = append(outputs, output{*modelsPath, func() ([]byte, error) { return spec.GenerateModels(pkg, source) }})
+	}
+	if *clientPath != "" {
+		outputs = append(outputs, output{*clientPath, func() ([]byte, error) {
+			return spec.GenerateClient(*clientPackage, source, *clientType, *modelsImport)
+		}})
+	}
+	if *serverPath != "" {
+		outputs = append(outputs, output{*serverPath, func() ([]byte, error) { return spec.GenerateServer(*serverPackage, source) }})
+	}
+
+	stale := false
+	for _, out := range outputs {
+		data, err := out.generate()
+		if err != nil {
+			log.Fatalf("Failed to generate %s: %v", out.path, err)
+		}
+		if *check {
+			current, err := os.ReadFile(out.path)
+			if err != nil || !bytes.Equal(current, data) {
+				fmt.Fprintf(os.Stderr, "%s is stale, run go generate in pkg/openapi\n", out.path)
+				stale = true
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(out.path), 0o755); err != nil {
+			log.Fatalf("%v", err)
+		}
+		if err := os.WriteFile(out.path, data, 0o644); err != nil {
+			log.Fatalf("Failed to write %s: %v", out.path, err)
+		}
+	}
+	if stale {
+		os.Exit(1)
+	}
+}
+
+// output is a generated file.
+type output struct {
+	path     string
+	generate func() ([]byte, error)
+}
@@ -0,0 +1,276 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// commentWidth is the column doc comments are wrapped at.
+const commentWidth = 100
+
+// initialisms are the words written in upper case in Go names.
+var initialisms = map[string]string{"id": "ID", "ids": "IDs", "url": "URL", "uri": "URI", "api": "API", "http": "HTTP", "json": "JSON", "ip": "IP"}
+
+// goName returns the exported Go name of a property, operation or tag, e.g. UserID for user_id
+// and GetUserByID for getUserByID.
+func goName(name string) string {
+	var words []string
+	start := 0
+	for i, r := range name {
+		switch {
+		case r == '_' || r == '-' || r == ' ':
+			words = append(words, name[start:i])
+			start = i + 1
+		case unicode.IsUpper(r) && i > start:
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+	words = append(words, name[start:])
+
+	var b strings.Builder
+	for _, word := range words {
+		if word == "" {
+			continue
+		}
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(word)
+		b.WriteRune(unicode.ToUpper(r))
+		b.WriteString(word[size:])
+	}
+	return b.String()
+}
+
+// unexported returns name with its first letter in lower case.
+func unexported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
+
+// argName returns the unexported Go name of a parameter, e.g. id for id and userID for user_id.
+func argName(name string) string {
+	name = goName(name)
+	upper := 0
+	for upper < len(name) && unicode.IsUpper(rune(name[upper])) {
+		upper++
+	}
+	switch {
+	case upper == len(name):
+		return strings.ToLower(name)
+	case upper > 1:
+		// Keep the first letter of the next word, as in URLPath
+		return strings.ToLower(name[:upper-1]) + name[upper-1:]
+	default:
+		return unexported(name)
+	}
+}
+
+// article returns the indefinite article of a name.
+func article(name string) string {
+	if strings.ContainsRune("AEIOU", rune(name[0])) {
+		return "an"
+	}
+	return "a"
+}
+
+// trailingComment returns a description as a comment following a field.
+func trailingComment(description string) string {
+	return strings.TrimSuffix(strings.Join(strings.Fields(description), " "), ".")
+}
+
+// fieldName returns the Go name of the field of a property.
+func fieldName(property string, s *Schema) string {
+	if s.GoName != "" {
+		return s.GoName
+	}
+	return goName(property)
+}
+
+// goType returns the Go type of values of s. Types of the spec's schemas are qualified with
+// models, the package they are generated in, unless it is empty.
+func goType(s *Schema, models string) string {
+	qualify := func(name string) string {
+		if models == "" {
+			return name
+		}
+		return models + "." + name
+	}
+	switch {
+	case s.GoType != "":
+		return s.GoType
+	case s.Ref != "":
+		return qualify(refName(s.Ref))
+	}
+	switch s.Type {
+	case "integer":
+		switch s.Format {
+		case "int64":
+			return "int64"
+		case "int32":
+			return "int32"
+		default:
+			return "int"
+		}
+	case "number":
+		return "float64"
+	case "string":
+		return "string"
+	case "boolean":
+		return "bool"
+	case "array":
+		if s.Items == nil {
+			return "[]any"
+		}
+		return "[]" + goType(s.Items, models)
+	case "object":
+		if s.AdditionalProperties != nil {
+			return "map[string]" + goType(s.AdditionalProperties, models)
+		}
+	}
+	return "any"
+}
+
+// isNumeric reports whether values of the Go type are numbers.
+func isNumeric(goType string) bool {
+	switch goType {
+	case "int", "int32", "int64", "float64":
+		return true
+	}
+	return false
+}
+
+// formatValue returns the expression formatting the value expr of a scalar Go type as text.
+func formatValue(goType, expr string) string {
+	switch goType {
+	case "int":
+		return "strconv.Itoa(" + expr + ")"
+	case "int32":
+		return "strconv.FormatInt(int64(" + expr + "), 10)"
+	case "int64":
+		return "strconv.FormatInt(" + expr + ", 10)"
+	case "float64":
+		return "strconv.FormatFloat(" + expr + ", 'f', -1, 64)"
+	case "bool":
+		return "strconv.FormatBool(" + expr + ")"
+	default:
+		return expr
+	}
+}
+
+// docSentence turns a description written as a noun phrase or a verb phrase, as in specs, into
+// a doc comment starting with name: "A user." becomes "User is a user." and "Counts users."
+// becomes "Users counts users.".
+func docSentence(name, description string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		return ""
+	}
+	first, _, _ := strings.Cut(description, " ")
+	switch first {
+	case "A", "An", "The":
+		return name + " is " + unexported(description)
+	}
+	if r, size := utf8.DecodeRuneInString(description); unicode.IsUpper(r) {
+		// Lower the verb, not a proper noun such as "User Service"
+		if next, _ := utf8.DecodeRuneInString(description[size:]); unicode.IsLower(next) {
+			return name + " " + unexported(description)
+		}
+	}
+	return name + " " + description
+}
+
+// file accumulates the source of a generated Go file.
+type file struct {
+	pkg     string
+	source  string          // Spec the file is generated from, for its header
+	imports map[string]bool // By path, true for packages outside the standard library
+	body    bytes.Buffer
+}
+
+// newFile starts a generated file of package pkg.
+func newFile(pkg, source string) *file {
+	return &file{pkg: pkg, source: source, imports: make(map[string]bool)}
+}
+
+// use adds imports of the standard library, or of modules when their path has a domain, to
+// the file.
+func (f *file) use(paths ...string) {
+	for _, path := range paths {
+		f.imports[path] = strings.Contains(strings.Split(path, "/")[0], ".")
+	}
+}
+
+// useModule adds the import of a package of a module of the repository to the file.
+func (f *file) useModule(path string) {
+	f.imports[path] = true
+}
+
+// printf appends formatted source to the file.
+func (f *file) printf(format string, args ...any) {
+	fmt.Fprintf(&f.body, format, args...)
+}
+
+// comment appends text as a comment wrapped at commentWidth, indented by indent tabs.
+func (f *file) comment(indent int, text string) {
+	prefix := strings.Repeat("\t", indent) + "// "
+	width := commentWidth - 4*indent - 3
+	line := ""
+	for _, word := range strings.Fields(text) {
+		if line != "" && len(line)+1+len(word) > width {
+			f.printf("%s%s\n", prefix, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += word
+	}
+	if line != "" {
+		f.printf("%s%s\n", prefix, line)
+	}
+}
+
+// bytes returns the formatted source of the file.
+func (f *file) bytes() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by openapi from %s. DO NOT EDIT.\n\npackage %s\n\n", f.source, f.pkg)
+
+	var std, other []string
+	for path, module := range f.imports {
+		if module {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	if len(std)+len(other) > 0 {
+		out.WriteString("import (\n")
+		for _, path := range std {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		if len(std) > 0 && len(other) > 0 {
+			out.WriteString("\n")
+		}
+		for _, path := range other {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(f.body.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go: %w\n%s", err, out.Bytes())
+	}
+	return formatted, nil
+}
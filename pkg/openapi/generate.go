@@ -0,0 +1,18 @@
+package openapi
+
+// The contracts of the services, the gateway's requests to the services it calls and the handler
+// interfaces of the services written in Go are generated from their specs. The Listing Service is
+// written in Python, so only its contracts and requests are generated. TestGeneratedFilesUpToDate
+// runs each line with -check.
+//go:generate go run ./cmd -spec ../../user-service/api/openapi.yaml -models ../contracts/user_gen.go -client ../contracts/client/user_service_gen.go -client-type UserServiceClient -server ../../user-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../listing-service/api/openapi.yaml -models ../contracts/listing_gen.go -client ../contracts/client/listing_service_gen.go -client-type ListingServiceClient
+//go:generate go run ./cmd -spec ../../auth-service/api/openapi.yaml -models ../contracts/auth_gen.go -client ../contracts/client/auth_service_gen.go -client-type AuthServiceClient -server ../../auth-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../search-service/api/openapi.yaml -models ../contracts/search_gen.go -client ../contracts/client/search_service_gen.go -client-type SearchServiceClient -server ../../search-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../review-service/api/openapi.yaml -models ../contracts/review_gen.go -client ../contracts/client/review_service_gen.go -client-type ReviewServiceClient -server ../../review-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../recommendations-service/api/openapi.yaml -models ../contracts/recommendations_gen.go -client ../contracts/client/recommendations_service_gen.go -client-type RecommendationsServiceClient -server ../../recommendations-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../booking-service/api/openapi.yaml -models ../contracts/booking_gen.go -client ../contracts/client/booking_service_gen.go -client-type BookingServiceClient -server ../../booking-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../analytics-service/api/openapi.yaml -models ../contracts/analytics_gen.go -client ../contracts/client/analytics_service_gen.go -client-type AnalyticsServiceClient -server ../../analytics-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../notification-service/api/openapi.yaml -models ../contracts/notification_gen.go -server ../../notification-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../media-service/api/openapi.yaml -models ../contracts/media_gen.go -server ../../media-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../admin-service/api/openapi.yaml -models ../contracts/admin_gen.go -server ../../admin-service/internal/api/api_gen.go
+//go:generate go run ./cmd -spec ../../scheduler-service/api/openapi.yaml -models ../contracts/scheduler_gen.go -server ../../scheduler-service/internal/api/api_gen.go
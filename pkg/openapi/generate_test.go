@@ -0,0 +1,40 @@
+package openapi
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// TestGeneratedFilesUpToDate runs every go:generate line of generate.go with -check, so that a
+// spec edited without regenerating, or a generated file edited by hand, fails the build.
+func TestGeneratedFilesUpToDate(t *testing.T) {
+	data, err := os.ReadFile("generate.go")
+	if err != nil {
+		t.Fatalf("Failed to read generate.go: %v", err)
+	}
+	const prefix = "//go:generate go run ./cmd "
+	specs := 0
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		specs++
+		args := strings.Fields(strings.TrimPrefix(line, prefix))
+		// Read the spec and the generated files here too, so that the cached result of the test
+		// is dropped when one of them changes
+		for i := 1; i < len(args); i += 2 {
+			if strings.HasSuffix(args[i], ".go") || strings.HasSuffix(args[i], ".yaml") {
+				os.ReadFile(args[i])
+			}
+		}
+		cmd := exec.Command("go", append([]string{"run", "./cmd"}, append(args, "-check")...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Errorf("Generated files of %s are stale, run go generate in pkg/openapi: %v\n%s", args[1], err, out)
+		}
+	}
+	if specs == 0 {
+		t.Fatal("generate.go has no go:generate lines")
+	}
+}
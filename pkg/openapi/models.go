@@ -0,0 +1,290 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GenerateModels returns the source of package pkg declaring a type per schema of the spec, and
+// a type per operation holding its query parameters. Types of request bodies and parameters
+// validate themselves, and form bodies and parameters are encoded to and parsed from url.Values.
+func (s *Spec) GenerateModels(pkg, source string) ([]byte, error) {
+	f := newFile(pkg, source)
+
+	// Request bodies and parameters are validated where they are received
+	forms := make(map[string]bool)
+	validated := make(map[string]bool)
+	for _, op := range s.operations() {
+		if body := op.Body(); body != nil && body.Schema.Ref != "" {
+			validated[refName(body.Schema.Ref)] = true
+			if body.ContentType == "application/x-www-form-urlencoded" {
+				forms[refName(body.Schema.Ref)] = true
+			}
+		}
+	}
+
+	for _, schema := range s.Components.Schemas {
+		fields := schemaFields(schema.Value)
+		f.comment(0, docSentence(schema.Name, schema.Value.Description))
+		f.printf("type %s struct {\n", schema.Name)
+		for _, field := range fields {
+			if field.schema.GoTypeImport != "" {
+				f.use(field.schema.GoTypeImport)
+			}
+			tag := field.property
+			if !field.required {
+				tag += ",omitempty"
+			}
+			f.printf("%s %s `json:%q`", field.name, field.goType, tag)
+			if field.schema.Description != "" {
+				f.printf(" // %s", trailingComment(field.schema.Description))
+			}
+			f.printf("\n")
+		}
+		f.printf("}\n\n")
+
+		if validated[schema.Name] {
+			generateValidate(f, schema.Name, fields)
+		}
+		if forms[schema.Name] {
+			generateValues(f, schema.Name, fields)
+			generateParse(f, schema.Name, fields, "form values")
+		}
+	}
+
+	for _, op := range s.operations() {
+		params := op.paramsIn("query")
+		if len(params) == 0 {
+			continue
+		}
+		name := goName(op.OperationID) + "Params"
+		var fields []field
+		for _, p := range params {
+			fields = append(fields, field{property: p.Name, name: fieldName(p.Name, p.Schema), goType: goType(p.Schema, ""), required: p.Required, schema: p.Schema, description: p.Description})
+		}
+		f.comment(0, fmt.Sprintf("%s holds the query parameters of %s %s.", name, op.Method, op.Path))
+		f.printf("type %s struct {\n", name)
+		for _, field := range fields {
+			f.printf("%s %s", field.name, field.goType)
+			if field.description != "" {
+				f.printf(" // %s", trailingComment(field.description))
+			}
+			f.printf("\n")
+		}
+		f.printf("}\n\n")
+		generateValidate(f, name, fields)
+		generateValues(f, name, fields)
+		generateParse(f, name, fields, "query parameters")
+	}
+
+	return f.bytes()
+}
+
+// field is a property of a schema, or a parameter, as a field of a Go struct.
+type field struct {
+	property    string // Name in the spec
+	name        string // Name of the Go field
+	goType      string
+	required    bool
+	schema      *Schema
+	description string // Of a parameter; those of properties are in their schema
+}
+
+// label returns the name of the field in validation messages.
+func (fd field) label() string {
+	if fd.schema.Title != "" {
+		return fd.schema.Title
+	}
+	return fd.property
+}
+
+// schemaFields returns the fields of the properties of an object schema. Optional objects and
+// nullable values are pointers, so that their absence is told apart from their zero value.
+func schemaFields(s *Schema) []field {
+	var fields []field
+	for _, prop := range s.Properties {
+		required := slices.Contains(s.Required, prop.Name)
+		typ := goType(prop.Value, "")
+		if ((!required && prop.Value.Ref != "") || prop.Value.Nullable) && prop.Value.GoType == "" {
+			typ = "*" + typ
+		}
+		fields = append(fields, field{property: prop.Name, name: fieldName(prop.Name, prop.Value), goType: typ, required: required, schema: prop.Value})
+	}
+	return fields
+}
+
+// generateValidate appends the Validate method of type name, checking the constraints of the
+// spec on its fields.
+func generateValidate(f *file, name string, fields []field) {
+	f.comment(0, fmt.Sprintf("Validate checks the %s against the constraints of the spec, returning a *ValidationError listing every violation.", name))
+	f.printf("func (v %s) Validate() error {\n", name)
+	f.printf("var messages []string\n")
+	var patterns [][3]string // Variable, field and expression, declared after the method
+	for _, fd := range fields {
+		typ, value := scalarType(fd.goType), "v."+fd.name
+		label := fd.label()
+		if typ != "string" && !isNumeric(typ) {
+			continue // Only scalar fields are constrained
+		}
+		zero := "0"
+		if typ == "string" {
+			zero = `""`
+		}
+		unset, set := value+" == "+zero, value+" != "+zero
+		if typ != fd.goType {
+			// Nullable values are unset when nil, and checked through the pointer
+			unset, set = value+" == nil", value+" != nil"
+			value = "*" + value
+		}
+
+		var checks []string
+		if typ == "string" {
+			if n := fd.schema.MinLength; n != nil {
+				checks = append(checks, fmt.Sprintf("if utf8.RuneCountInString(%s) < %d {\nmessages = append(messages, %q)\n}\n", value, *n, fmt.Sprintf("%s must be at least %d characters", label, *n)))
+				f.use("unicode/utf8")
+			}
+			if n := fd.schema.MaxLength; n != nil {
+				checks = append(checks, fmt.Sprintf("if utf8.RuneCountInString(%s) > %d {\nmessages = append(messages, %q)\n}\n", value, *n, fmt.Sprintf("%s must be at most %d characters", label, *n)))
+				f.use("unicode/utf8")
+			}
+			if len(fd.schema.Enum) > 0 {
+				var cases []string
+				for _, e := range fd.schema.Enum {
+					cases = append(cases, strconv.Quote(e))
+				}
+				checks = append(checks, fmt.Sprintf("switch %s {\ncase %s:\ndefault:\nmessages = append(messages, %q)\n}\n", value, strings.Join(cases, ", "), fmt.Sprintf("%s must be one of %s", label, strings.Join(fd.schema.Enum, ", "))))
+			}
+			if fd.schema.Pattern != "" {
+				pattern := unexported(name) + fd.name + "Pattern"
+				patterns = append(patterns, [3]string{pattern, fd.name, fd.schema.Pattern})
+				f.use("regexp")
+				checks = append(checks, fmt.Sprintf("if !%s.MatchString(%s) {\nmessages = append(messages, %q)\n}\n", pattern, value, label+" is invalid"))
+			}
+		} else {
+			if n := fd.schema.Minimum; n != nil {
+				checks = append(checks, fmt.Sprintf("if %s < %s {\nmessages = append(messages, %q)\n}\n", value, strconv.FormatFloat(*n, 'f', -1, 64), fmt.Sprintf("%s must be at least %s", label, strconv.FormatFloat(*n, 'f', -1, 64))))
+			}
+			if n := fd.schema.Maximum; n != nil {
+				checks = append(checks, fmt.Sprintf("if %s > %s {\nmessages = append(messages, %q)\n}\n", value, strconv.FormatFloat(*n, 'f', -1, 64), fmt.Sprintf("%s must be at most %s", label, strconv.FormatFloat(*n, 'f', -1, 64))))
+			}
+		}
+
+		// Unset values are only checked for being required, as they are not sent
+		switch {
+		case fd.required:
+			f.printf("if %s {\nmessages = append(messages, %q)\n}", unset, label+" is required")
+			if len(checks) > 0 {
+				f.printf(" else {\n%s}", strings.Join(checks, ""))
+			}
+			f.printf("\n")
+		case len(checks) > 0:
+			f.printf("if %s {\n%s}\n", set, strings.Join(checks, ""))
+		}
+	}
+	f.printf("if len(messages) > 0 {\nreturn &ValidationError{Messages: messages}\n}\nreturn nil\n}\n\n")
+	for _, p := range patterns {
+		f.comment(0, fmt.Sprintf("%s is the pattern of %s.%s.", p[0], name, p[1]))
+		f.printf("var %s = regexp.MustCompile(%q)\n\n", p[0], p[2])
+	}
+}
+
+// generateValues appends the Values method of type name, encoding its scalar fields. Optional
+// and nullable fields are left out when unset.
+func generateValues(f *file, name string, fields []field) {
+	f.use("net/url")
+	f.comment(0, fmt.Sprintf("Values encodes the %s as form or query values.", name))
+	f.printf("func (v %s) Values() url.Values {\n", name)
+	f.printf("values := url.Values{}\n")
+	for _, fd := range fields {
+		typ := scalarType(fd.goType)
+		if typ != "string" && typ != "bool" && !isNumeric(typ) {
+			continue
+		}
+		if typ != "string" {
+			f.use("strconv")
+		}
+		if typ != fd.goType {
+			f.printf("if v.%s != nil {\nvalues.Set(%q, %s)\n}\n", fd.name, fd.property, formatValue(typ, "*v."+fd.name))
+			continue
+		}
+		set := fmt.Sprintf("values.Set(%q, %s)\n", fd.property, formatValue(fd.goType, "v."+fd.name))
+		switch {
+		case fd.required:
+			f.printf("%s", set)
+		case fd.goType == "string":
+			f.printf("if v.%s != \"\" {\n%s}\n", fd.name, set)
+		case fd.goType == "bool":
+			f.printf("if v.%s {\n%s}\n", fd.name, set)
+		default:
+			f.printf("if v.%s != 0 {\n%s}\n", fd.name, set)
+		}
+	}
+	f.printf("return values\n}\n\n")
+}
+
+// generateParse appends the Parse<name> function of type name, decoding it from url.Values and
+// validating it.
+func generateParse(f *file, name string, fields []field, what string) {
+	f.use("net/url")
+	f.comment(0, fmt.Sprintf("Parse%s decodes %s %s from %s and validates it. Malformed and invalid values are reported by a *ValidationError.", name, article(name), name, what))
+	f.printf("func Parse%s(values url.Values) (%s, error) {\n", name, name)
+	f.printf("var v %s\n", name)
+	parsed := false // Whether values are parsed, and may be malformed
+	for _, fd := range fields {
+		typ := scalarType(fd.goType)
+		pointer := typ != fd.goType
+		raw := fmt.Sprintf("values.Get(%q)", fd.property)
+		switch typ {
+		case "string":
+			if pointer {
+				f.printf("if raw := %s; raw != \"\" {\nv.%s = &raw\n}\n", raw, fd.name)
+			} else {
+				f.printf("v.%s = %s\n", fd.name, raw)
+			}
+			continue
+		case "int", "int32", "int64", "float64", "bool":
+		default:
+			continue
+		}
+		f.use("strconv")
+		if !parsed {
+			f.printf("var messages []string\n")
+			parsed = true
+		}
+		var parse, kind string
+		switch typ {
+		case "int":
+			parse, kind = "strconv.Atoi(raw)", "an integer"
+		case "int32":
+			parse, kind = "strconv.ParseInt(raw, 10, 32)", "an integer"
+		case "int64":
+			parse, kind = "strconv.ParseInt(raw, 10, 64)", "an integer"
+		case "float64":
+			parse, kind = "strconv.ParseFloat(raw, 64)", "a number"
+		case "bool":
+			parse, kind = "strconv.ParseBool(raw)", "true or false"
+		}
+		assign := fmt.Sprintf("v.%s = n", fd.name)
+		switch {
+		case typ == "int32" && pointer:
+			assign = fmt.Sprintf("n := int32(n)\nv.%s = &n", fd.name)
+		case typ == "int32":
+			assign = fmt.Sprintf("v.%s = int32(n)", fd.name)
+		case pointer:
+			assign = fmt.Sprintf("v.%s = &n", fd.name)
+		}
+		f.printf("if raw := %s; raw != \"\" {\n", raw)
+		f.printf("if n, err := %s; err != nil {\nmessages = append(messages, %q)\n} else {\n%s\n}\n}\n", parse, fd.label()+" must be "+kind, assign)
+	}
+	if parsed {
+		f.printf("if len(messages) > 0 {\nreturn v, &ValidationError{Messages: messages}\n}\n")
+	}
+	f.printf("return v, v.Validate()\n}\n\n")
+}
+
+// scalarType returns the type a nullable value points to, and other types unchanged.
+func scalarType(goType string) string {
+	return strings.TrimPrefix(goType, "*")
+}
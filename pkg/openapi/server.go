@@ -0,0 +1,58 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateServer returns the source of package pkg declaring, for each tag of the spec, the
+// interface of the handlers of its operations, a stub implementing it that answers 501 Not
+// Implemented, and a function registering its routes on a gorilla/mux router in the order of
+// the spec.
+func (s *Spec) GenerateServer(pkg, source string) ([]byte, error) {
+	f := newFile(pkg, source)
+	f.use("net/http", "github.com/gorilla/mux")
+
+	for _, tag := range s.Tags {
+		var ops []boundOperation
+		for _, op := range s.operations() {
+			if len(op.Tags) > 0 && op.Tags[0] == tag.Name {
+				ops = append(ops, op)
+			}
+		}
+		if len(ops) == 0 {
+			continue
+		}
+		iface := goName(tag.Name) + "Handler"
+		description := strings.TrimSuffix(strings.Join(strings.Fields(tag.Description), " "), ".")
+
+		f.comment(0, fmt.Sprintf("%s handles the operations of the %s tag: %s.", iface, tag.Name, unexported(description)))
+		f.printf("type %s interface {\n", iface)
+		for i, op := range ops {
+			if i > 0 {
+				f.printf("\n")
+			}
+			f.comment(1, fmt.Sprintf("%s handles %s %s: %s", goName(op.OperationID), op.Method, op.Path, op.Summary))
+			f.printf("%s(w http.ResponseWriter, r *http.Request)\n", goName(op.OperationID))
+		}
+		f.printf("}\n\n")
+
+		f.comment(0, fmt.Sprintf("Unimplemented%s answers every operation of %s with 501 Not Implemented. Handlers embed it to implement the interface while their operations are written.", iface, iface))
+		f.printf("type Unimplemented%s struct{}\n\n", iface)
+		for _, op := range ops {
+			f.comment(0, fmt.Sprintf("%s answers %s %s with 501 Not Implemented.", goName(op.OperationID), op.Method, op.Path))
+			f.printf("func (Unimplemented%s) %s(w http.ResponseWriter, r *http.Request) {\n", iface, goName(op.OperationID))
+			f.printf("http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)\n}\n\n")
+		}
+
+		register := "Register" + goName(tag.Name) + "Routes"
+		f.comment(0, fmt.Sprintf("%s registers the routes of the operations of %s on r.", register, iface))
+		f.printf("func %s(r *mux.Router, h %s) {\n", register, iface)
+		for _, op := range ops {
+			f.printf("r.HandleFunc(%q, h.%s).Methods(%q)\n", op.Path, goName(op.OperationID), op.Method)
+		}
+		f.printf("}\n\n")
+	}
+
+	return f.bytes()
+}
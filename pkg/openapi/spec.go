@@ -0,0 +1,254 @@
+// Package openapi generates Go code from the OpenAPI specs of the services, so that a field is
+// added by editing one spec rather than the structs of the service, the contracts and the
+// gateway's client by hand. From a spec it generates:
+//
+//   - Models: a type per schema, in the contracts package, with validation of the request bodies
+//     and parameters of the operations, and their encoding as form or query values.
+//   - Client: a method per operation on the gateway's typed client, building its request.
+//   - Server: an interface per tag that the service's handlers implement, a stub answering 501
+//     Not Implemented, and the registration of the routes.
+//
+// Only the part of OpenAPI 3 the services use is supported: schemas referenced from
+// components, path and query parameters, and form or JSON request bodies.
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is an OpenAPI document.
+type Spec struct {
+	Info struct {
+		Title string `yaml:"title"`
+	} `yaml:"info"`
+	Tags       []Tag                `yaml:"tags"`
+	Paths      OrderedMap[PathItem] `yaml:"paths"`
+	Components struct {
+		Schemas OrderedMap[*Schema] `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+// Tag groups operations.
+type Tag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+}
+
+// PathItem is the operations of a path, by method, along with the parameters they share.
+type PathItem struct {
+	Parameters []Parameter
+	Operations OrderedMap[*Operation]
+}
+
+// methods are the keys of path items naming operations.
+var methods = map[string]bool{"get": true, "put": true, "post": true, "delete": true, "patch": true, "head": true, "options": true}
+
+// UnmarshalYAML implements yaml.Unmarshaler, keeping the operations in the order of the spec.
+func (p *PathItem) UnmarshalYAML(node *yaml.Node) error {
+	var fields OrderedMap[yaml.Node]
+	if err := node.Decode(&fields); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		switch {
+		case field.Name == "parameters":
+			if err := field.Value.Decode(&p.Parameters); err != nil {
+				return err
+			}
+		case methods[field.Name]:
+			var op Operation
+			if err := field.Value.Decode(&op); err != nil {
+				return err
+			}
+			p.Operations = append(p.Operations, Named[*Operation]{field.Name, &op})
+		}
+	}
+	return nil
+}
+
+// Operation is a method of a path.
+type Operation struct {
+	OperationID string      `yaml:"operationId"`
+	Summary     string      `yaml:"summary"`
+	Tags        []string    `yaml:"tags"`
+	Parameters  []Parameter `yaml:"parameters"`
+	RequestBody *struct {
+		Content OrderedMap[struct {
+			Schema *Schema `yaml:"schema"`
+		}] `yaml:"content"`
+	} `yaml:"requestBody"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `yaml:"name"`
+	In          string  `yaml:"in"`
+	Description string  `yaml:"description"`
+	Required    bool    `yaml:"required"`
+	Schema      *Schema `yaml:"schema"`
+}
+
+// Schema describes a value.
+type Schema struct {
+	Ref                  string              `yaml:"$ref"`
+	Type                 string              `yaml:"type"`
+	Format               string              `yaml:"format"`
+	Title                string              `yaml:"title"`
+	Description          string              `yaml:"description"`
+	Properties           OrderedMap[*Schema] `yaml:"properties"`
+	Required             []string            `yaml:"required"`
+	Items                *Schema             `yaml:"items"`
+	AdditionalProperties *Schema             `yaml:"additionalProperties"`
+	Nullable             bool                `yaml:"nullable"`
+	Enum                 []string            `yaml:"enum"`
+	MinLength            *int                `yaml:"minLength"`
+	MaxLength            *int                `yaml:"maxLength"`
+	Minimum              *float64            `yaml:"minimum"`
+	Maximum              *float64            `yaml:"maximum"`
+	Pattern              string              `yaml:"pattern"`
+
+	GoName       string `yaml:"x-go-name"`        // Name of the Go field, instead of the one derived from the property
+	GoType       string `yaml:"x-go-type"`        // Go type of the value, instead of the one derived from the schema
+	GoTypeImport string `yaml:"x-go-type-import"` // Import path of the package of GoType, e.g. encoding/json
+}
+
+// Named is an entry of an OrderedMap.
+type Named[T any] struct {
+	Name  string
+	Value T
+}
+
+// OrderedMap is a YAML mapping decoded in the order of its keys, so that the generated code
+// follows the order of the spec.
+type OrderedMap[T any] []Named[T]
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (m *OrderedMap[T]) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping", node.Line)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		var value T
+		if err := node.Content[i+1].Decode(&value); err != nil {
+			return err
+		}
+		*m = append(*m, Named[T]{node.Content[i].Value, value})
+	}
+	return nil
+}
+
+// Get returns the value of name, and whether the map has it.
+func (m OrderedMap[T]) Get(name string) (T, bool) {
+	for _, entry := range m {
+		if entry.Name == name {
+			return entry.Value, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Load reads the spec at path, checking the references of its schemas.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec %s: %w", path, err)
+	}
+
+	var check func(where string, s *Schema) error
+	check = func(where string, s *Schema) error {
+		if s == nil {
+			return nil
+		}
+		if s.Ref != "" {
+			if _, ok := spec.Components.Schemas.Get(refName(s.Ref)); !ok {
+				return fmt.Errorf("%s: unknown schema %s", where, s.Ref)
+			}
+		}
+		for _, prop := range s.Properties {
+			if err := check(where+"."+prop.Name, prop.Value); err != nil {
+				return err
+			}
+		}
+		if err := check(where+"[]", s.Items); err != nil {
+			return err
+		}
+		return check(where+"{}", s.AdditionalProperties)
+	}
+	for _, schema := range spec.Components.Schemas {
+		if err := check(schema.Name, schema.Value); err != nil {
+			return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+		}
+	}
+	for _, op := range spec.operations() {
+		if op.OperationID == "" {
+			return nil, fmt.Errorf("invalid spec %s: %s %s has no operationId", path, strings.ToUpper(op.Method), op.Path)
+		}
+		if body := op.Body(); body != nil {
+			if err := check(op.OperationID, body.Schema); err != nil {
+				return nil, fmt.Errorf("invalid spec %s: %w", path, err)
+			}
+		}
+	}
+	return &spec, nil
+}
+
+// refName returns the name of the schema a reference points to.
+func refName(ref string) string {
+	return strings.TrimPrefix(ref, "#/components/schemas/")
+}
+
+// boundOperation is an operation along with its method, path and parameters, including those
+// of its path item.
+type boundOperation struct {
+	*Operation
+	Method string // Upper case
+	Path   string
+	Params []Parameter
+}
+
+// operations returns the operations of the spec, in the order of the spec.
+func (s *Spec) operations() []boundOperation {
+	var ops []boundOperation
+	for _, path := range s.Paths {
+		for _, op := range path.Value.Operations {
+			params := append(append([]Parameter(nil), path.Value.Parameters...), op.Value.Parameters...)
+			ops = append(ops, boundOperation{op.Value, strings.ToUpper(op.Name), path.Name, params})
+		}
+	}
+	return ops
+}
+
+// requestBody is the body of an operation's requests.
+type requestBody struct {
+	ContentType string
+	Schema      *Schema
+}
+
+// Body returns the body of the operation's requests, nil when they have none.
+func (op boundOperation) Body() *requestBody {
+	if op.RequestBody == nil || len(op.RequestBody.Content) == 0 {
+		return nil
+	}
+	content := op.RequestBody.Content[0]
+	return &requestBody{ContentType: content.Name, Schema: content.Value.Schema}
+}
+
+// paramsIn returns the parameters of the operation in the given location.
+func (op boundOperation) paramsIn(in string) []Parameter {
+	var params []Parameter
+	for _, p := range op.Params {
+		if p.In == in {
+			params = append(params, p)
+		}
+	}
+	return params
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a client of the HTTP API of a Registry, served by NewHandler.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a new Client of the registry at baseURL.
+func NewClient(httpClient *http.Client, baseURL string) *Client {
+	return &Client{httpClient: httpClient, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Register registers inst, or renews its registration, for ttl.
+func (c *Client) Register(ctx context.Context, inst Instance, ttl time.Duration) error {
+	body, err := json.Marshal(map[string]any{"url": inst.URL, "ttl": int(ttl.Seconds())})
+	if err != nil {
+		return fmt.Errorf("failed to encode registration: %w", err)
+	}
+	var apiResp InstanceResponse
+	return c.do(ctx, "PUT", c.instanceURL(inst.Service, inst.ID), body, &apiResp)
+}
+
+// Deregister removes an instance of service.
+func (c *Client) Deregister(ctx context.Context, service, id string) error {
+	var apiResp InstanceResponse
+	return c.do(ctx, "DELETE", c.instanceURL(service, id), nil, &apiResp)
+}
+
+// Lookup returns the live instances of service.
+func (c *Client) Lookup(ctx context.Context, service string) ([]Instance, error) {
+	var apiResp InstancesResponse
+	if err := c.do(ctx, "GET", fmt.Sprintf("%s/services/%s/instances", c.baseURL, url.PathEscape(service)), nil, &apiResp); err != nil {
+		return nil, err
+	}
+	if len(apiResp.Instances) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInstances, service)
+	}
+	return apiResp.Instances, nil
+}
+
+// instanceURL returns the URL of an instance of service.
+func (c *Client) instanceURL(service, id string) string {
+	return fmt.Sprintf("%s/services/%s/instances/%s", c.baseURL, url.PathEscape(service), url.PathEscape(id))
+}
+
+// do sends a request to the registry and decodes its response into apiResp.
+func (c *Client) do(ctx context.Context, method, url string, body []byte, apiResp any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request to registry: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach registry: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		return fmt.Errorf("registry returned %s: %s", resp.Status, errResp.Error)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(apiResp); err != nil {
+		return fmt.Errorf("failed to decode registry response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"registry"
+)
+
+func main() {
+	// Define command-line flags for port
+	port := flag.Int("port", 8500, "The port number to run the registry on")
+	flag.Parse()
+
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      registry.NewHandler(registry.New()),
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Start the HTTP server
+	log.Printf("Registry starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
@@ -0,0 +1,64 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DNSResolver looks services up in DNS SRV records, as served by Consul, CoreDNS or headless
+// Kubernetes services: the instances of a service are the targets of _<service>._tcp.<domain>.
+// Instances register with the DNS server's own mechanism rather than with Heartbeat.
+type DNSResolver struct {
+	domain   string
+	resolver *net.Resolver
+}
+
+// NewDNSResolver creates a new DNSResolver of the services under domain, e.g. service.consul.
+func NewDNSResolver(domain string) *DNSResolver {
+	return &DNSResolver{domain: domain, resolver: net.DefaultResolver}
+}
+
+// Lookup returns an instance of service per SRV record, with the URL http://<target>:<port>,
+// ordered by priority.
+func (r *DNSResolver) Lookup(ctx context.Context, service string) ([]Instance, error) {
+	_, records, err := r.resolver.LookupSRV(ctx, service, "tcp", r.domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrNoInstances, service)
+		}
+		return nil, fmt.Errorf("failed to look up %s in DNS: %w", service, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInstances, service)
+	}
+
+	instances := make([]Instance, len(records))
+	for i, srv := range records {
+		host := net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port)))
+		instances[i] = Instance{Service: service, ID: host, URL: "http://" + host}
+	}
+	return instances, nil
+}
+
+// NewResolver creates the Resolver at url: a Client for http:// and https:// URLs of a registry,
+// or a DNSResolver for dns://<domain>.
+func NewResolver(httpClient *http.Client, url string) (Resolver, error) {
+	switch {
+	case strings.HasPrefix(url, "http://"), strings.HasPrefix(url, "https://"):
+		return NewClient(httpClient, url), nil
+	case strings.HasPrefix(url, "dns://"):
+		domain := strings.TrimPrefix(url, "dns://")
+		if domain == "" {
+			return nil, errors.New("a DNS registry needs a domain, e.g. dns://service.consul")
+		}
+		return NewDNSResolver(domain), nil
+	default:
+		return nil, fmt.Errorf("unsupported registry url %q: the scheme must be http, https or dns", url)
+	}
+}
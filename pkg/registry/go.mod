@@ -0,0 +1,10 @@
+module registry
+
+go 1.24.4
+
+require (
+	apperrors v0.0.0
+	github.com/gorilla/mux v1.8.1
+)
+
+replace apperrors => ../apperrors
@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"apperrors"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the body of registration requests.
+const maxBodyBytes = 4 << 10
+
+// InstanceResponse is the response structure for registrations.
+type InstanceResponse struct {
+	Result   bool      `json:"result"`
+	Instance *Instance `json:"instance,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// InstancesResponse is the response structure for lookups.
+type InstancesResponse struct {
+	Result    bool       `json:"result"`
+	Instances []Instance `json:"instances"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ServicesResponse is the response structure for the list of services.
+type ServicesResponse struct {
+	Result   bool     `json:"result"`
+	Services []string `json:"services"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// NewHandler serves the HTTP API of reg:
+//   - PUT /services/{service}/instances/{id} registers an instance, or renews its registration,
+//     from a JSON body of the form {"url": "...", "ttl": seconds}
+//   - DELETE /services/{service}/instances/{id} deregisters an instance
+//   - GET /services/{service}/instances returns the live instances of a service
+//   - GET /services returns the names of the services with live instances
+func NewHandler(reg *Registry) http.Handler {
+	r := mux.NewRouter()
+	r.HandleFunc("/services/{service}/instances/{id}", registerHandler(reg)).Methods("PUT")
+	r.HandleFunc("/services/{service}/instances/{id}", deregisterHandler(reg)).Methods("DELETE")
+	r.HandleFunc("/services/{service}/instances", lookupHandler(reg)).Methods("GET")
+	r.HandleFunc("/services", servicesHandler(reg)).Methods("GET")
+	return r
+}
+
+// registerHandler handles PUT /services/{service}/instances/{id} requests.
+func registerHandler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var input struct {
+			URL string `json:"url"`
+			TTL int    `json:"ttl"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(InstanceResponse{Result: false, Error: "Invalid JSON request body"})
+			return
+		}
+
+		vars := mux.Vars(r)
+		inst, err := reg.Register(Instance{Service: vars["service"], ID: vars["id"], URL: input.URL}, time.Duration(input.TTL)*time.Second)
+		if err != nil {
+			apperrors.Write(w, err)
+			return
+		}
+		json.NewEncoder(w).Encode(InstanceResponse{Result: true, Instance: &inst})
+	}
+}
+
+// deregisterHandler handles DELETE /services/{service}/instances/{id} requests.
+func deregisterHandler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		vars := mux.Vars(r)
+		if !reg.Deregister(vars["service"], vars["id"]) {
+			apperrors.Write(w, apperrors.New(apperrors.NotFound, fmt.Sprintf("instance %s of %s is not registered", vars["id"], vars["service"])))
+			return
+		}
+		json.NewEncoder(w).Encode(InstanceResponse{Result: true})
+	}
+}
+
+// lookupHandler handles GET /services/{service}/instances requests. A service without live
+// instances has an empty list of them.
+func lookupHandler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		instances, err := reg.Lookup(r.Context(), mux.Vars(r)["service"])
+		if err != nil && !apperrors.Is(err, apperrors.NotFound) {
+			apperrors.Write(w, err)
+			return
+		}
+		if instances == nil {
+			instances = []Instance{}
+		}
+		json.NewEncoder(w).Encode(InstancesResponse{Result: true, Instances: instances})
+	}
+}
+
+// servicesHandler handles GET /services requests.
+func servicesHandler(reg *Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ServicesResponse{Result: true, Services: reg.Services()})
+	}
+}
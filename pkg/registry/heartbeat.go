@@ -0,0 +1,73 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// heartbeatTimeout bounds each heartbeat request.
+const heartbeatTimeout = 5 * time.Second
+
+// Heartbeat registers inst with the registry of c every third of ttl until ctx is cancelled,
+// then deregisters it. Failed heartbeats are logged and retried at the next one, so a service
+// keeps serving its existing callers while the registry is down.
+func Heartbeat(ctx context.Context, c *Client, inst Instance, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	registered := false
+	for {
+		beatCtx, cancel := context.WithTimeout(ctx, heartbeatTimeout)
+		err := c.Register(beatCtx, inst, ttl)
+		cancel()
+		switch {
+		case err != nil && ctx.Err() == nil:
+			log.Printf("Error registering %s instance %s with the registry: %v", inst.Service, inst.ID, err)
+		case err == nil && !registered:
+			log.Printf("Registered %s instance %s at %s", inst.Service, inst.ID, inst.URL)
+		}
+		registered = err == nil
+
+		select {
+		case <-ctx.Done():
+			// The instance expires anyway, deregistering only drops it sooner
+			deregisterCtx, cancel := context.WithTimeout(context.Background(), heartbeatTimeout)
+			defer cancel()
+			if err := c.Deregister(deregisterCtx, inst.Service, inst.ID); err != nil {
+				log.Printf("Error deregistering %s instance %s: %v", inst.Service, inst.ID, err)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Announce registers this process as an instance of service with the registry at registryURL,
+// heartbeating in the background with DefaultTTL until ctx is cancelled. The instance serves at
+// advertiseURL, by default http://<hostname>:<port>, and is identified by its host and port.
+func Announce(ctx context.Context, registryURL, service, advertiseURL string, port int) error {
+	if !strings.HasPrefix(registryURL, "http://") && !strings.HasPrefix(registryURL, "https://") {
+		return fmt.Errorf("instances register with an HTTP registry, not %q", registryURL)
+	}
+	if advertiseURL == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("failed to get hostname to advertise: %w", err)
+		}
+		advertiseURL = fmt.Sprintf("http://%s:%d", hostname, port)
+	}
+	u, err := url.Parse(advertiseURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid advertised URL %q", advertiseURL)
+	}
+
+	c := NewClient(&http.Client{Timeout: heartbeatTimeout}, registryURL)
+	go Heartbeat(ctx, c, Instance{Service: service, ID: u.Host, URL: strings.TrimSuffix(advertiseURL, "/")}, DefaultTTL)
+	return nil
+}
@@ -0,0 +1,138 @@
+// Package registry keeps track of the running instances of the services, so callers find them
+// by name instead of by hardcoded URLs when several instances of a service run. Instances
+// register with heartbeats that expire unless renewed, and callers look them up over HTTP from
+// a Registry, or in DNS SRV records.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"apperrors"
+)
+
+const (
+	// DefaultTTL is how long an instance stays registered after its last heartbeat.
+	DefaultTTL = 30 * time.Second
+	// MaxTTL is the longest an instance may stay registered after its last heartbeat.
+	MaxTTL = 5 * time.Minute
+)
+
+var (
+	// ErrNoInstances is returned when looking up a service without any live instance.
+	ErrNoInstances = apperrors.New(apperrors.NotFound, "no instances registered")
+	// ErrInvalidInstance is returned when registering an instance without a service, ID or URL.
+	ErrInvalidInstance = apperrors.New(apperrors.Validation, "invalid instance")
+)
+
+// Instance is a running instance of a service.
+type Instance struct {
+	Service   string `json:"service"`              // Name of the service, e.g. "user-service"
+	ID        string `json:"id"`                   // Identifies the instance among those of its service
+	URL       string `json:"url"`                  // Base URL the instance serves at
+	ExpiresAt int64  `json:"expires_at,omitempty"` // Timestamp in microseconds when the instance is dropped without another heartbeat
+}
+
+// Resolver looks up the live instances of services.
+type Resolver interface {
+	// Lookup returns the live instances of service, or an error wrapping ErrNoInstances when
+	// there are none.
+	Lookup(ctx context.Context, service string) ([]Instance, error)
+}
+
+// Registry is an in-memory registry of instances, dropping those that stop heartbeating.
+type Registry struct {
+	mu        sync.Mutex
+	instances map[string]map[string]Instance // Instances by service, then ID
+}
+
+// New creates a new, empty Registry.
+func New() *Registry {
+	return &Registry{instances: make(map[string]map[string]Instance)}
+}
+
+// Register registers inst, or renews its registration, for ttl (DefaultTTL when zero, at most
+// MaxTTL). It returns the instance with its expiry.
+func (r *Registry) Register(inst Instance, ttl time.Duration) (Instance, error) {
+	if inst.Service == "" || inst.ID == "" || inst.URL == "" {
+		return Instance{}, fmt.Errorf("%w: service, id and url are required", ErrInvalidInstance)
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return Instance{}, fmt.Errorf("%w: ttl must be between 1 and %d seconds", ErrInvalidInstance, int(MaxTTL.Seconds()))
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inst.ExpiresAt = time.Now().Add(ttl).UnixMicro()
+	if r.instances[inst.Service] == nil {
+		r.instances[inst.Service] = make(map[string]Instance)
+	}
+	r.instances[inst.Service][inst.ID] = inst
+	return inst, nil
+}
+
+// Deregister removes an instance of service, returning false if it was not registered.
+func (r *Registry) Deregister(service, id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.instances[service][id]; !ok {
+		return false
+	}
+	delete(r.instances[service], id)
+	if len(r.instances[service]) == 0 {
+		delete(r.instances, service)
+	}
+	return true
+}
+
+// Lookup returns the live instances of service by ID.
+func (r *Registry) Lookup(ctx context.Context, service string) ([]Instance, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropExpired(service)
+
+	instances := make([]Instance, 0, len(r.instances[service]))
+	for _, inst := range r.instances[service] {
+		instances = append(instances, inst)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNoInstances, service)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
+
+// Services returns the names of the services with live instances, sorted.
+func (r *Registry) Services() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	services := make([]string, 0, len(r.instances))
+	for service := range r.instances {
+		r.dropExpired(service)
+		if len(r.instances[service]) > 0 {
+			services = append(services, service)
+		}
+	}
+	sort.Strings(services)
+	return services
+}
+
+// dropExpired removes the instances of service whose registration expired. r.mu must be held.
+func (r *Registry) dropExpired(service string) {
+	now := time.Now().UnixMicro()
+	for id, inst := range r.instances[service] {
+		if inst.ExpiresAt <= now {
+			delete(r.instances[service], id)
+		}
+	}
+	if len(r.instances[service]) == 0 {
+		delete(r.instances, service)
+	}
+}
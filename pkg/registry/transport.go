@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Scheme is the scheme of URLs naming a service rather than a host, e.g. registry://user-service,
+// which WithDiscovery resolves to an instance of the service for each request.
+const Scheme = "registry"
+
+// DefaultCacheTTL is how long WithDiscovery reuses the instances it looked up.
+const DefaultCacheTTL = 5 * time.Second
+
+// discoveryTransport resolves registry:// URLs before handing requests to its base transport.
+type discoveryTransport struct {
+	base     http.RoundTripper
+	resolver Resolver
+	cacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*cachedInstances // Instances by service
+}
+
+// cachedInstances are the instances of a service last looked up.
+type cachedInstances struct {
+	instances []Instance
+	fetchedAt time.Time
+	next      int // Index of the instance the next request goes to
+}
+
+// WithDiscovery wraps the client's transport so that requests to registry://<service> URLs go
+// to an instance of the service, taking turns between the instances resolver returns. Lookups
+// are reused for cacheTTL, and the last instances found keep being used while resolver fails.
+// Requests to other URLs are sent unchanged.
+func WithDiscovery(httpClient *http.Client, resolver Resolver, cacheTTL time.Duration) *http.Client {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	discovering := *httpClient
+	discovering.Transport = &discoveryTransport{
+		base:     base,
+		resolver: resolver,
+		cacheTTL: cacheTTL,
+		cache:    make(map[string]*cachedInstances),
+	}
+	return &discovering
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *discoveryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != Scheme {
+		return t.base.RoundTrip(req)
+	}
+
+	inst, err := t.pick(req.Context(), req.URL.Host)
+	if err == nil {
+		var target *url.URL
+		target, err = url.Parse(inst.URL)
+		if err == nil {
+			// RoundTrippers must not modify the caller's request
+			resolved := req.Clone(req.Context())
+			resolved.URL.Scheme = target.Scheme
+			resolved.URL.Host = target.Host
+			if prefix := strings.TrimSuffix(target.Path, "/"); prefix != "" {
+				resolved.URL.Path = prefix + req.URL.Path
+				resolved.URL.RawPath = ""
+			}
+			resolved.Host = ""
+			return t.base.RoundTrip(resolved)
+		}
+		err = fmt.Errorf("invalid URL of %s instance %s: %w", inst.Service, inst.ID, err)
+	}
+
+	// RoundTrippers must close the body, even on errors
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	return nil, err
+}
+
+// pick returns the instance of service the next request goes to.
+func (t *discoveryTransport) pick(ctx context.Context, service string) (Instance, error) {
+	t.mu.Lock()
+	cached := t.cache[service]
+	fresh := cached != nil && time.Since(cached.fetchedAt) < t.cacheTTL
+	t.mu.Unlock()
+
+	if !fresh {
+		instances, err := t.resolver.Lookup(ctx, service)
+		switch {
+		case err == nil:
+			t.mu.Lock()
+			cached = &cachedInstances{instances: instances, fetchedAt: time.Now()}
+			t.cache[service] = cached
+			t.mu.Unlock()
+		case cached != nil:
+			log.Printf("Error looking up %s, using the instances found before: %v", service, err)
+		default:
+			return Instance{}, fmt.Errorf("failed to discover %s: %w", service, err)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	inst := cached.instances[cached.next%len(cached.instances)]
+	cached.next++
+	return inst, nil
+}
@@ -0,0 +1,219 @@
+// Package testharness boots the gateway, the User Service and the Listing Service inside a test,
+// so full-stack scenarios such as creating a user, creating a listing of theirs and reading it
+// back through the gateway can be asserted with go test. The Go services run in the test's own
+// process on ephemeral ports, each with a fresh SQLite database in a temporary directory. The
+// Listing Service is written in Python, so it runs as a child process instead; tests are skipped
+// when python3 or tornado is not installed.
+package testharness
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	gateway "public-api-layer/app"
+	userservice "user-service/app"
+)
+
+// listingServiceStartTimeout is how long the Listing Service may take to answer its first ping.
+const listingServiceStartTimeout = 15 * time.Second
+
+// Stack is a running gateway along with the services behind it, stopped when the test ends.
+type Stack struct {
+	GatewayURL        string
+	UserServiceURL    string
+	ListingServiceURL string
+
+	// Client sends the requests of the scenario helpers.
+	Client *http.Client
+
+	t testing.TB
+}
+
+// Option adjusts the configuration of the services of a Stack.
+type Option func(*options)
+
+// options are the configurations Start boots the services with.
+type options struct {
+	userService []func(*userservice.Config)
+	gateway     []func(*gateway.Config)
+	listingArgs []string
+}
+
+// WithUserService adjusts the configuration of the User Service, e.g. to set an admin token.
+func WithUserService(configure func(*userservice.Config)) Option {
+	return func(o *options) { o.userService = append(o.userService, configure) }
+}
+
+// WithGateway adjusts the configuration of the gateway, e.g. to require access tokens.
+func WithGateway(configure func(*gateway.Config)) Option {
+	return func(o *options) { o.gateway = append(o.gateway, configure) }
+}
+
+// WithListingServiceArgs passes command-line options to the Listing Service, e.g.
+// --require_approval=true.
+func WithListingServiceArgs(args ...string) Option {
+	return func(o *options) { o.listingArgs = append(o.listingArgs, args...) }
+}
+
+// Start boots the User Service, the Listing Service and the gateway in front of them, failing
+// the test if any of them cannot start. Everything is stopped and removed when the test ends.
+func Start(t testing.TB, opts ...Option) *Stack {
+	t.Helper()
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	// Check for the Listing Service's runtime before booting anything
+	if err := exec.Command("python3", "-c", "import tornado").Run(); err != nil {
+		t.Skipf("Skipping: the Listing Service needs python3 with tornado installed: %v", err)
+	}
+
+	users := startUserService(t, o.userService)
+	listings := startListingService(t, users, o.listingArgs)
+
+	cfg := gateway.DefaultConfig()
+	cfg.UserServiceURL = users
+	cfg.ListingServiceURL = listings
+	cfg.AccessLog = false
+	for _, configure := range o.gateway {
+		configure(&cfg)
+	}
+	handler, err := gateway.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start the gateway: %v", err)
+	}
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return &Stack{
+		GatewayURL:        server.URL,
+		UserServiceURL:    users,
+		ListingServiceURL: listings,
+		Client:            &http.Client{Timeout: 10 * time.Second},
+		t:                 t,
+	}
+}
+
+// startUserService serves the User Service over a SQLite file in a temporary directory,
+// returning its URL.
+func startUserService(t testing.TB, configure []func(*userservice.Config)) string {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := userservice.DefaultConfig()
+	cfg.DBDSN = filepath.Join(dir, "users.db")
+	cfg.BackupDir = filepath.Join(dir, "backups")
+	cfg.DBConnectTimeout = 0
+	cfg.AccessLog = false
+	for _, c := range configure {
+		c(&cfg)
+	}
+
+	users, err := userservice.New(cfg)
+	if err != nil {
+		t.Fatalf("Failed to start the User Service: %v", err)
+	}
+	server := httptest.NewServer(users.Handler)
+	// Cleanups run last-in first-out, so the server stops before its database is closed
+	t.Cleanup(users.Close)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// startListingService runs the Listing Service in a temporary directory, where it creates its
+// listings.db, and waits until it answers pings. It returns the URL of the service.
+func startListingService(t testing.TB, userServiceURL string, args []string) string {
+	t.Helper()
+	port, err := freePort()
+	if err != nil {
+		t.Fatalf("Failed to find a port for the Listing Service: %v", err)
+	}
+	args = append([]string{
+		listingServicePath(),
+		"--port=" + strconv.Itoa(port),
+		"--debug=false", // Autoreload would fork the service, leaving a process behind
+		"--user_service_url=" + userServiceURL,
+	}, args...)
+
+	var output lockedBuffer
+	cmd := exec.Command("python3", args...)
+	cmd.Dir = t.TempDir()
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("Failed to start the Listing Service: %v", err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		<-exited
+	})
+
+	url := fmt.Sprintf("http://127.0.0.1:%d", port)
+	deadline := time.Now().Add(listingServiceStartTimeout)
+	for {
+		resp, err := http.Get(url + "/listings/ping")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return url
+			}
+		}
+		select {
+		case <-exited:
+			t.Fatalf("The Listing Service exited before it was ready:\n%s", output.String())
+		case <-time.After(100 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("The Listing Service was not ready after %s:\n%s", listingServiceStartTimeout, output.String())
+		}
+	}
+}
+
+// listingServicePath returns the path of the Listing Service's script in this repository.
+func listingServicePath() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "listing-service", "listing_service.py")
+}
+
+// freePort returns a TCP port that nothing listens on, for servers that cannot be handed a
+// listener.
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
+
+// lockedBuffer collects the output of a child process while the test may read it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,63 @@
+package testharness_test
+
+import (
+	"testing"
+
+	"testharness"
+)
+
+// TestListingServedWithItsOwner creates a user and a listing of theirs through the gateway, and
+// checks that the aggregated listings embed the user.
+func TestListingServedWithItsOwner(t *testing.T) {
+	stack := testharness.Start(t)
+
+	user := stack.CreateUser("harness-owner")
+	listing := stack.CreateListing(testharness.NewListing{
+		UserID:      user.ID,
+		ListingType: "rent",
+		Price:       450000,
+		Title:       "Harness listing",
+	})
+
+	listings := stack.Listings(user.ID)
+	if len(listings) != 1 {
+		t.Fatalf("Got %d listings of user %d, want 1: %+v", len(listings), user.ID, listings)
+	}
+	got := listings[0]
+	if got.ID != listing.ID {
+		t.Errorf("Got listing %d, want %d", got.ID, listing.ID)
+	}
+	if got.User == nil {
+		t.Fatalf("Listing %d is served without its user", got.ID)
+	}
+	if got.User.ID != user.ID || got.User.Name != user.Name {
+		t.Errorf("Listing %d is served with user %d %q, want %d %q", got.ID, got.User.ID, got.User.Name, user.ID, user.Name)
+	}
+}
+
+// TestListingsOfAllUsers checks that the listings of several users are each served with their
+// own owner.
+func TestListingsOfAllUsers(t *testing.T) {
+	stack := testharness.Start(t)
+
+	owners := make(map[int64]int64) // Owner of each listing
+	for _, name := range []string{"harness-alice", "harness-bob"} {
+		user := stack.CreateUser(name)
+		listing := stack.CreateListing(testharness.NewListing{UserID: user.ID, ListingType: "sale", Price: 1000000})
+		owners[listing.ID] = user.ID
+	}
+
+	for _, l := range stack.Listings(0) {
+		want, ok := owners[l.ID]
+		if !ok {
+			continue
+		}
+		delete(owners, l.ID)
+		if l.User == nil || l.User.ID != want {
+			t.Errorf("Listing %d is served with user %+v, want user %d", l.ID, l.User, want)
+		}
+	}
+	if len(owners) > 0 {
+		t.Errorf("Listings %v are missing from the aggregated listings", owners)
+	}
+}
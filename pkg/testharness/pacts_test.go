@@ -0,0 +1,47 @@
+package testharness
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"contracttest"
+)
+
+// pactsDir returns the directory of the gateway's pacts in this repository.
+func pactsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "pacts")
+}
+
+// verifyPact replays the pact between the gateway and provider against the service at url.
+func verifyPact(t *testing.T, provider, url string) {
+	t.Helper()
+	pact, err := contracttest.Load(filepath.Join(pactsDir(), contracttest.FileName("public-api", provider)))
+	if err != nil {
+		t.Fatalf("Failed to load the pact: %v", err)
+	}
+	verifier := contracttest.NewVerifier(&http.Client{Timeout: 10 * time.Second}, url)
+	if err := verifier.Verify(context.Background(), pact); err != nil {
+		t.Errorf("%s breaks its pact with %s:\n%v", pact.Provider, pact.Consumer, err)
+	}
+}
+
+// TestUserServicePact verifies the User Service against the pact of the gateway. The User
+// Service runs alone, so the pact is verified even without the Listing Service's runtime.
+func TestUserServicePact(t *testing.T) {
+	verifyPact(t, "user-service", startUserService(t, nil))
+}
+
+// TestListingServicePact verifies the Listing Service against the pact of the gateway.
+func TestListingServicePact(t *testing.T) {
+	stack := Start(t)
+	// The listings of the pact belong to user 1, the first user of the fresh User Service
+	if user := stack.CreateUser("pact-owner"); user.ID != 1 {
+		t.Fatalf("The owner of the pact's listings got ID %d, want 1", user.ID)
+	}
+	verifyPact(t, "listing-service", stack.ListingServiceURL)
+}
@@ -0,0 +1,111 @@
+package testharness
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"contracts"
+)
+
+// NewListing is the body of a listing created through the gateway.
+type NewListing struct {
+	UserID      int64  `json:"user_id"`
+	ListingType string `json:"listing_type"`
+	Price       int64  `json:"price"`
+	Currency    string `json:"currency,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// PublicListing is a listing as aggregated by the gateway, with its owner embedded.
+type PublicListing struct {
+	contracts.Listing
+	User *contracts.User `json:"user"`
+}
+
+// Do sends a request with a JSON body to the gateway, decoding the JSON response into out
+// unless it is nil. It returns the status of the response, failing the test only when the
+// request cannot be sent or its response decoded.
+func (s *Stack) Do(method, path string, body, out any) int {
+	s.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			s.t.Fatalf("Failed to encode the body of %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequest(method, s.GatewayURL+path, reader)
+	if err != nil {
+		s.t.Fatalf("Failed to create %s %s: %v", method, path, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		s.t.Fatalf("Failed to send %s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			s.t.Fatalf("Failed to decode the response to %s %s (status %d): %v", method, path, resp.StatusCode, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// CreateUser creates a user named name through the gateway, failing the test unless it is
+// created.
+func (s *Stack) CreateUser(name string) contracts.User {
+	s.t.Helper()
+	var response struct {
+		User  *contracts.User `json:"user"`
+		Error string          `json:"error"`
+	}
+	status := s.Do(http.MethodPost, "/public-api/users", map[string]string{"name": name}, &response)
+	if status >= 300 || response.User == nil {
+		s.t.Fatalf("Failed to create user %q: status %d: %s", name, status, response.Error)
+	}
+	return *response.User
+}
+
+// CreateListing creates a listing through the gateway, failing the test unless it is created.
+func (s *Stack) CreateListing(listing NewListing) contracts.Listing {
+	s.t.Helper()
+	var response struct {
+		Listing *contracts.Listing `json:"listing"`
+		Error   string             `json:"error"`
+	}
+	status := s.Do(http.MethodPost, "/public-api/listings", listing, &response)
+	if status >= 300 || response.Listing == nil {
+		s.t.Fatalf("Failed to create listing %+v: status %d: %s", listing, status, response.Error)
+	}
+	return *response.Listing
+}
+
+// Listings returns the first page of listings aggregated by the gateway, those of userID only
+// unless it is zero, failing the test unless they are served.
+func (s *Stack) Listings(userID int64) []PublicListing {
+	s.t.Helper()
+	query := url.Values{}
+	if userID != 0 {
+		query.Set("user_id", strconv.FormatInt(userID, 10))
+	}
+	var response struct {
+		Result   bool            `json:"result"`
+		Listings []PublicListing `json:"listings"`
+		Error    string          `json:"error"`
+	}
+	status := s.Do(http.MethodGet, "/public-api/listings?"+query.Encode(), nil, &response)
+	if status != http.StatusOK || !response.Result {
+		s.t.Fatalf("Failed to get listings: status %d: %s", status, response.Error)
+	}
+	return response.Listings
+}
@@ -0,0 +1,171 @@
+// Package app assembles the Public API Layer from its configuration: the clients of the
+// internal services and the routes aggregating them. The gateway's command serves it on a port,
+// and tests may boot it inside their own process.
+package app
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"contracts/client"
+	"httpmiddleware"
+	"public-api-layer/internal/handler"
+	"registry"
+
+	"github.com/gorilla/mux"
+)
+
+// Config configures the Public API Layer. Its fields are the settings named by their config
+// tags, loaded by package config from flags, environment variables and a file of settings.
+type Config struct {
+	UserServiceURL            string        `config:"user-service-url,required" usage:"URL of the User Service"`
+	ListingServiceURL         string        `config:"listing-service-url,required" usage:"URL of the Listing Service"`
+	SearchServiceURL          string        `config:"search-service-url" usage:"URL of the Search Service running listing searches (the Listing Service runs them when empty)"`
+	ReviewServiceURL          string        `config:"review-service-url" usage:"URL of the Review Service rating listings (listings carry no rating when empty)"`
+	RecommendationsServiceURL string        `config:"recommendations-service-url" usage:"URL of the Recommendations Service recommending listings (recommendations are disabled when empty)"`
+	BookingServiceURL         string        `config:"booking-service-url" usage:"URL of the Booking Service turning accepted offers into transactions (transactions are disabled when empty)"`
+	AnalyticsServiceURL       string        `config:"analytics-service-url" usage:"URL of the Analytics Service answering dashboard queries over domain events (analytics are disabled when empty)"`
+	AuthServiceURL            string        `config:"auth-service-url" usage:"URL of the Auth Service validating access tokens (personal access tokens are validated by the User Service when empty)"`
+	RegistryURL               string        `config:"registry-url" usage:"Registry resolving service URLs of the form registry://<service> to instances, e.g. http://localhost:8500 or dns://service.consul (such URLs cannot be used when empty)"`
+	InternalKey               string        `config:"internal-key,secret" usage:"keyID:secret used to HMAC-sign requests to internal services (requests are unsigned when empty)"`
+	RequireAuth               bool          `config:"require-auth" usage:"Reject requests that do not carry an access token"`
+	TokenCacheTTL             time.Duration `config:"token-cache-ttl" min:"0" usage:"How long access token validation results are cached"`
+	AccessLog                 bool          `config:"access-log" usage:"Log every request once it is served"`
+	RequestTimeout            time.Duration `config:"request-timeout" min:"0" usage:"How long a request may take before it is answered with 503 (0 disables it)"`
+	// Metrics records the requests served; nil disables metrics.
+	Metrics *httpmiddleware.Metrics
+	// Transport sends the requests to the services; nil sends them over the network.
+	Transport http.RoundTripper
+}
+
+// DefaultConfig returns the configuration of the gateway started without flags.
+func DefaultConfig() Config {
+	return Config{
+		UserServiceURL:    "http://localhost:7000",
+		ListingServiceURL: "http://localhost:6000",
+		TokenCacheTTL:     handler.DefaultTokenCacheTTL,
+		AccessLog:         true,
+		RequestTimeout:    10 * time.Second,
+	}
+}
+
+// New assembles the gateway of cfg, returning its handler wrapped with the shared middleware.
+func New(cfg Config) (http.Handler, error) {
+	// Initialize a custom HTTP client with timeouts for inter-service communication
+	// This is crucial for resilience and preventing resource exhaustion.
+	httpClient := client.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+	if cfg.Transport != nil {
+		httpClient.Transport = cfg.Transport
+	}
+
+	// Send requests to registry://<service> URLs to the instances registered for the service
+	if cfg.RegistryURL != "" {
+		resolver, err := registry.NewResolver(httpClient, cfg.RegistryURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid registry url: %w", err)
+		}
+		httpClient = registry.WithDiscovery(httpClient, resolver, registry.DefaultCacheTTL)
+	}
+
+	// Sign internal requests so services can reject calls that don't come from the gateway
+	if cfg.InternalKey != "" {
+		keyID, secret, ok := strings.Cut(cfg.InternalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			return nil, errors.New("invalid internal key, expected keyID:secret")
+		}
+		httpClient = client.WithRequestSigning(httpClient, keyID, secret)
+	}
+
+	// Initialize service clients
+	userServiceClient := client.NewUserServiceClient(httpClient, cfg.UserServiceURL)
+	listingServiceClient := client.NewListingServiceClient(httpClient, cfg.ListingServiceURL)
+
+	var searchServiceClient *client.SearchServiceClient
+	if cfg.SearchServiceURL != "" {
+		searchServiceClient = client.NewSearchServiceClient(httpClient, cfg.SearchServiceURL)
+	}
+
+	var reviewServiceClient *client.ReviewServiceClient
+	if cfg.ReviewServiceURL != "" {
+		reviewServiceClient = client.NewReviewServiceClient(httpClient, cfg.ReviewServiceURL)
+	}
+
+	var recommendationsServiceClient *client.RecommendationsServiceClient
+	if cfg.RecommendationsServiceURL != "" {
+		recommendationsServiceClient = client.NewRecommendationsServiceClient(httpClient, cfg.RecommendationsServiceURL)
+	}
+
+	var bookingServiceClient *client.BookingServiceClient
+	if cfg.BookingServiceURL != "" {
+		bookingServiceClient = client.NewBookingServiceClient(httpClient, cfg.BookingServiceURL)
+	}
+
+	var analyticsServiceClient *client.AnalyticsServiceClient
+	if cfg.AnalyticsServiceURL != "" {
+		analyticsServiceClient = client.NewAnalyticsServiceClient(httpClient, cfg.AnalyticsServiceURL)
+	}
+
+	// Initialize the Public API handler
+	publicAPIHandler := handler.NewPublicAPIHandler(userServiceClient, listingServiceClient, searchServiceClient, reviewServiceClient, recommendationsServiceClient, bookingServiceClient, analyticsServiceClient)
+
+	// Authenticate access tokens presented as Bearer credentials, delegating validation to the
+	// Auth Service when configured
+	var introspector handler.TokenIntrospector = userServiceClient
+	if cfg.AuthServiceURL != "" {
+		introspector = client.NewAuthServiceClient(httpClient, cfg.AuthServiceURL)
+	}
+	authenticator := handler.NewAuthenticator(introspector, cfg.RequireAuth, cfg.TokenCacheTTL)
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+	r.Use(authenticator.Middleware)
+
+	// Define Public API Layer routes
+	// GET /public-api/listings: Get all listings, enriched with user data
+	r.HandleFunc("/public-api/listings", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicListings)).Methods("GET")
+	// GET /public-api/listings/search: Full-text search over listings, enriched with user data
+	r.HandleFunc("/public-api/listings/search", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.SearchPublicListings)).Methods("GET")
+	// GET /public-api/listings/featured: Random sample of active listings, enriched with user data
+	r.HandleFunc("/public-api/listings/featured", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetFeaturedPublicListings)).Methods("GET")
+	// GET /public-api/listings/trending: Listings with the most recent views, enriched with user data
+	r.HandleFunc("/public-api/listings/trending", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetTrendingPublicListings)).Methods("GET")
+	// GET /public-api/recommendations: Listings recommended to a user, enriched with user data
+	r.HandleFunc("/public-api/recommendations", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetRecommendedPublicListings)).Methods("GET")
+	// GET /public-api/transactions: Transactions a user buys or sells in, enriched with listing data
+	r.HandleFunc("/public-api/transactions", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicTransactions)).Methods("GET")
+	// GET /public-api/transactions/{id}: A transaction along with its saga log, enriched with listing data
+	r.HandleFunc("/public-api/transactions/{id}", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicTransaction)).Methods("GET")
+	// GET /public-api/stats: User and listing statistics, fetched from both services concurrently
+	r.HandleFunc("/public-api/stats", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicStats)).Methods("GET")
+	// GET /public-api/analytics/timeseries: Number of domain events of a type per hour, day or week, from the Analytics Service
+	r.HandleFunc("/public-api/analytics/timeseries", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicTimeSeries)).Methods("GET")
+	// GET /public-api/analytics/funnel: Conversion funnel of the listings created between two dates, from the Analytics Service
+	r.HandleFunc("/public-api/analytics/funnel", handler.RequireScope(handler.ScopeListingsRead, publicAPIHandler.GetPublicFunnel)).Methods("GET")
+	// POST /public-api/users: Create a new user
+	r.HandleFunc("/public-api/users", handler.RequireScope(handler.ScopeUsersWrite, publicAPIHandler.CreatePublicUser)).Methods("POST")
+	// PUT /public-api/users/{id}: Update a user (optimistic concurrency via version)
+	r.HandleFunc("/public-api/users/{id}", handler.RequireScope(handler.ScopeUsersWrite, publicAPIHandler.UpdatePublicUser)).Methods("PUT")
+	// POST /public-api/listings: Create a new listing
+	r.HandleFunc("/public-api/listings", handler.RequireScope(handler.ScopeListingsWrite, publicAPIHandler.CreatePublicListing)).Methods("POST")
+
+	// POST /public-api/transactions/{id}/confirm: Confirm a held transaction as its buyer, selling the listing
+	r.HandleFunc("/public-api/transactions/{id}/confirm", handler.RequireScope(handler.ScopeListingsWrite, publicAPIHandler.ConfirmPublicTransaction)).Methods("POST")
+	// POST /public-api/transactions/{id}/cancel: Cancel a pending or held transaction as either party
+	r.HandleFunc("/public-api/transactions/{id}/cancel", handler.RequireScope(handler.ScopeListingsWrite, publicAPIHandler.CancelPublicTransaction)).Methods("POST")
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	return httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: cfg.AccessLog,
+		Metrics:   cfg.Metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   cfg.RequestTimeout,
+	}), nil
+}
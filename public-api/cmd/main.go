@@ -1,64 +1,66 @@
 package main
 
 import (
-	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
-	"public-api-layer/internal/client"
-	"public-api-layer/internal/handler"
-
-	"github.com/gorilla/mux"
+	"config"
+	"httpmiddleware"
+	"public-api-layer/app"
 )
 
-func main() {
-	// Define command-line flags for port and service URLs
-	port := flag.Int("port", 8000, "The port number to run the Public API Layer on")
-	userServiceURL := flag.String("user-service-url", "http://localhost:7000", "URL of the User Service")
-	listingServiceURL := flag.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service")
-	flag.Parse()
-
-	// Initialize a custom HTTP client with timeouts for inter-service communication
-	// This is crucial for resilience and preventing resource exhaustion.
-	httpClient := client.NewHTTPClient(
-		10*time.Second, // Overall request timeout
-		5*time.Second,  // Dial timeout
-		5*time.Second,  // TLS handshake timeout
-		5*time.Second,  // Response header timeout
-	)
+// settings are the settings of the command: those of the gateway, along with how it is served.
+type settings struct {
+	app.Config
+	Port        int `config:"port" min:"1" max:"65535" usage:"The port number to run the Public API Layer on"`
+	MetricsPort int `config:"metrics-port" min:"0" max:"65535" usage:"Port serving Prometheus metrics at /metrics, apart from the public API (0 disables it)"`
+}
 
-	// Initialize service clients
-	userServiceClient := client.NewUserServiceClient(httpClient, *userServiceURL)
-	listingServiceClient := client.NewListingServiceClient(httpClient, *listingServiceURL)
+func main() {
+	// Subcommands are dispatched before the server flags are parsed
+	if len(os.Args) > 1 && os.Args[1] == "pacts" {
+		runPacts(os.Args[2:])
+		return
+	}
 
-	// Initialize the Public API handler
-	publicAPIHandler := handler.NewPublicAPIHandler(userServiceClient, listingServiceClient)
+	// Load the settings over their defaults, from a file, the environment and flags
+	s := settings{Config: app.DefaultConfig(), Port: 8000}
+	if err := config.Load(&s, config.Options{EnvPrefix: "PUBLIC_API"}); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	cfg := s.Config
 
-	// Create a new Gorilla Mux router
-	r := mux.NewRouter()
+	if s.MetricsPort != 0 {
+		cfg.Metrics = httpmiddleware.NewMetrics("public-api")
+		go func() {
+			log.Printf("Serving metrics on port %d", s.MetricsPort)
+			if err := cfg.Metrics.ListenAndServe(fmt.Sprintf(":%d", s.MetricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", s.MetricsPort, err)
+			}
+		}()
+	}
 
-	// Define Public API Layer routes
-	// GET /public-api/listings: Get all listings, enriched with user data
-	r.HandleFunc("/public-api/listings", publicAPIHandler.GetPublicListings).Methods("GET")
-	// POST /public-api/users: Create a new user
-	r.HandleFunc("/public-api/users", publicAPIHandler.CreatePublicUser).Methods("POST")
-	// POST /public-api/listings: Create a new listing
-	r.HandleFunc("/public-api/listings", publicAPIHandler.CreatePublicListing).Methods("POST")
+	// Initialize the service clients and the routes aggregating them
+	gateway, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Could not start the Public API Layer: %v", err)
+	}
 
 	// Configure HTTP server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *port),
-		Handler:      r,
+		Addr:         fmt.Sprintf(":%d", s.Port),
+		Handler:      gateway,
 		ReadTimeout:  15 * time.Second, // Max time to read request from client
 		WriteTimeout: 15 * time.Second, // Max time to write response to client
 		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
 	}
 
 	// Start the HTTP server
-	log.Printf("Public API Layer starting on port %d", *port)
+	log.Printf("Public API Layer starting on port %d", s.Port)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Could not listen on port %d: %v", *port, err)
+		log.Fatalf("Could not listen on port %d: %v", s.Port, err)
 	}
 }
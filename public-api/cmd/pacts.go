@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"public-api-layer/internal/pacts"
+)
+
+// runPacts implements the "pacts" subcommand, which records the gateway's contracts with the
+// services it calls, for each service to verify that it still honors them.
+func runPacts(args []string) {
+	fs := flag.NewFlagSet("pacts", flag.ExitOnError)
+	dir := fs.String("dir", "../pacts", "Directory the pacts are written to")
+	fs.Parse(args)
+
+	paths, err := pacts.Record(*dir)
+	if err != nil {
+		log.Fatalf("Recording pacts failed: %v", err)
+	}
+	for _, path := range paths {
+		log.Printf("Pact written to %s", path)
+	}
+}
@@ -2,4 +2,24 @@ module public-api-layer
 
 go 1.24.4
 
-require github.com/gorilla/mux v1.8.1
+require (
+	config v0.0.0
+	contracts v0.0.0
+	contracttest v0.0.0
+	github.com/gorilla/mux v1.8.1
+	httpmiddleware v0.0.0
+	money v0.0.0
+	registry v0.0.0
+)
+
+require apperrors v0.0.0 // indirect
+
+replace (
+	apperrors => ../pkg/apperrors
+	config => ../pkg/config
+	contracts => ../pkg/contracts
+	contracttest => ../pkg/contracttest
+	httpmiddleware => ../pkg/httpmiddleware
+	money => ../pkg/money
+	registry => ../pkg/registry
+)
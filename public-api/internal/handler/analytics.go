@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"contracts"
+	"contracts/client"
+)
+
+// PublicSeriesResponse represents the structure for analytics time series responses.
+type PublicSeriesResponse struct {
+	Result bool                       `json:"result"`
+	Series *contracts.AnalyticsSeries `json:"series,omitempty"`
+	Error  string                     `json:"error,omitempty"`
+}
+
+// PublicFunnelResponse represents the structure for conversion funnel responses.
+type PublicFunnelResponse struct {
+	Result bool              `json:"result"`
+	Funnel *contracts.Funnel `json:"funnel,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// analyticsUnavailableError is returned with 404 Not Found when no Analytics Service is configured.
+const analyticsUnavailableError = "Analytics are not available"
+
+// GetPublicTimeSeries handles GET /public-api/analytics/timeseries requests.
+// It proxies the request to the Analytics Service, counting the events of event_type per
+// interval between the from and to dates for dashboards, e.g. the listings created per day.
+func (h *PublicAPIHandler) GetPublicTimeSeries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.analyticsServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(PublicSeriesResponse{Result: false, Error: analyticsUnavailableError})
+		return
+	}
+
+	query := r.URL.Query()
+	series, err := h.analyticsServiceClient.GetTimeSeries(query.Get("event_type"), query.Get("interval"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicSeriesResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error getting time series from Analytics Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicSeriesResponse{Result: false, Error: "Failed to retrieve time series"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicSeriesResponse{Result: true, Series: series})
+}
+
+// GetPublicFunnel handles GET /public-api/analytics/funnel requests.
+// It proxies the request to the Analytics Service, returning the conversion funnel of the
+// listings created between the from and to dates for dashboards.
+func (h *PublicAPIHandler) GetPublicFunnel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.analyticsServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(PublicFunnelResponse{Result: false, Error: analyticsUnavailableError})
+		return
+	}
+
+	funnel, err := h.analyticsServiceClient.GetFunnel(r.URL.Query().Get("from"), r.URL.Query().Get("to"))
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicFunnelResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error getting funnel from Analytics Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicFunnelResponse{Result: false, Error: "Failed to retrieve funnel"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicFunnelResponse{Result: true, Funnel: funnel})
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"contracts"
+)
+
+// Scopes an access token can grant.
+const (
+	ScopeUsersWrite    = "users:write"
+	ScopeListingsRead  = "listings:read"
+	ScopeListingsWrite = "listings:write"
+)
+
+// DefaultTokenCacheTTL is how long a token introspection result is reused by default.
+const DefaultTokenCacheTTL = 30 * time.Second
+
+// maxCachedTokens bounds the number of introspection results kept in memory.
+const maxCachedTokens = 1000
+
+// tokenContextKey is the context key under which the authenticated token is stored.
+type tokenContextKey struct{}
+
+// cachedToken is an introspection result, nil for tokens that are not valid.
+type cachedToken struct {
+	token   *contracts.AccessToken
+	expires time.Time
+}
+
+// TokenIntrospector validates tokens. It is implemented by the User Service client, which
+// knows personal access tokens, and by the Auth Service client, which also knows the access
+// tokens issued by the Auth Service.
+type TokenIntrospector interface {
+	IntrospectToken(token string) (*contracts.AccessToken, error)
+}
+
+// Authenticator validates access tokens presented as Bearer credentials.
+type Authenticator struct {
+	introspector TokenIntrospector
+	required     bool
+	cacheTTL     time.Duration
+
+	// cache holds recent introspection results keyed by the SHA-256 of the token, so the
+	// introspector is not consulted on every request. A revoked token can therefore keep
+	// working for up to cacheTTL.
+	mu    sync.Mutex
+	cache map[string]cachedToken
+}
+
+// NewAuthenticator creates a new Authenticator. When required is false, requests without
+// credentials are let through anonymously; requests with invalid credentials are always rejected.
+func NewAuthenticator(introspector TokenIntrospector, required bool, cacheTTL time.Duration) *Authenticator {
+	return &Authenticator{
+		introspector: introspector,
+		required:     required,
+		cacheTTL:     cacheTTL,
+		cache:        make(map[string]cachedToken),
+	}
+}
+
+// Middleware authenticates the Authorization header of each request and stores the
+// resulting token in the request context.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			if a.required {
+				writeUnauthorized(w, "Authentication required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scheme, credentials, ok := strings.Cut(header, " ")
+		credentials = strings.TrimSpace(credentials)
+		if !ok || !strings.EqualFold(scheme, "Bearer") || credentials == "" {
+			writeUnauthorized(w, "Authorization header must be of the form 'Bearer <token>'")
+			return
+		}
+
+		token, err := a.introspect(credentials)
+		if err != nil {
+			log.Printf("Error validating access token: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Unable to validate access token"})
+			return
+		}
+		if token == nil {
+			writeUnauthorized(w, "Access token is invalid, expired or revoked")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, token)))
+	})
+}
+
+// introspect returns the token for the given credentials, consulting the cache first.
+func (a *Authenticator) introspect(credentials string) (*contracts.AccessToken, error) {
+	sum := sha256.Sum256([]byte(credentials))
+	key := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	a.mu.Lock()
+	entry, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.token, nil
+	}
+
+	token, err := a.introspector.IntrospectToken(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	expires := now.Add(a.cacheTTL)
+	if token != nil && token.ExpiresAt > 0 {
+		if tokenExpiry := time.UnixMicro(token.ExpiresAt); tokenExpiry.Before(expires) {
+			expires = tokenExpiry
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, exists := a.cache[key]; !exists && len(a.cache) >= maxCachedTokens {
+		for evictKey := range a.cache {
+			delete(a.cache, evictKey)
+			break
+		}
+	}
+	a.cache[key] = cachedToken{token: token, expires: expires}
+	return token, nil
+}
+
+// RequireScope wraps a handler so that token-authenticated requests are only served if the
+// token grants scope. Anonymous requests, which the Authenticator only lets through when
+// authentication is optional, are passed on unchanged.
+func RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := tokenFromContext(r.Context()); token != nil && !slices.Contains(token.Scopes, scope) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Access token lacks the required scope: " + scope})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// tokenFromContext returns the access token the request was authenticated with, if any.
+func tokenFromContext(ctx context.Context) *contracts.AccessToken {
+	token, _ := ctx.Value(tokenContextKey{}).(*contracts.AccessToken)
+	return token
+}
+
+// actsForOtherUser reports whether the request is authenticated with a token belonging to a
+// user other than userID, and writes a 403 response if so.
+func actsForOtherUser(w http.ResponseWriter, r *http.Request, userID int64) bool {
+	token := tokenFromContext(r.Context())
+	if token == nil || token.UserID == userID {
+		return false
+	}
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]string{"error": "Access token cannot act on behalf of another user"})
+	return true
+}
+
+// writeUnauthorized writes a 401 response challenging the client for a Bearer token.
+func writeUnauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("WWW-Authenticate", `Bearer realm="public-api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
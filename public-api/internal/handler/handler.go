@@ -2,52 +2,132 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
+	"unicode/utf8"
 
-	"public-api-layer/internal/client"
+	"contracts"
+	"contracts/client"
+	"money"
+
+	"github.com/gorilla/mux"
 )
 
 // PublicAPIHandler handles public-facing HTTP requests.
 type PublicAPIHandler struct {
 	userServiceClient    *client.UserServiceClient
 	listingServiceClient *client.ListingServiceClient
+	searchServiceClient  *client.SearchServiceClient // nil when searches go to the Listing Service
+	reviewServiceClient  *client.ReviewServiceClient // nil when listings are not rated
+
+	recommendationsServiceClient *client.RecommendationsServiceClient // nil when recommendations are disabled
+	bookingServiceClient         *client.BookingServiceClient         // nil when transactions are disabled
+	analyticsServiceClient       *client.AnalyticsServiceClient       // nil when analytics are disabled
 }
 
-// NewPublicAPIHandler creates a new instance of PublicAPIHandler.
+// NewPublicAPIHandler creates a new instance of PublicAPIHandler. searchServiceClient may be
+// nil, in which case listing searches are run by the Listing Service, reviewServiceClient
+// may be nil, in which case listings carry no rating, recommendationsServiceClient may be
+// nil, in which case no listings are recommended, bookingServiceClient may be nil, in which
+// case there are no transactions, and analyticsServiceClient may be nil, in which case there
+// are no analytics.
 func NewPublicAPIHandler(
 	userServiceClient *client.UserServiceClient,
 	listingServiceClient *client.ListingServiceClient,
+	searchServiceClient *client.SearchServiceClient,
+	reviewServiceClient *client.ReviewServiceClient,
+	recommendationsServiceClient *client.RecommendationsServiceClient,
+	bookingServiceClient *client.BookingServiceClient,
+	analyticsServiceClient *client.AnalyticsServiceClient,
 ) *PublicAPIHandler {
 	return &PublicAPIHandler{
 		userServiceClient:    userServiceClient,
 		listingServiceClient: listingServiceClient,
+		searchServiceClient:  searchServiceClient,
+		reviewServiceClient:  reviewServiceClient,
+
+		recommendationsServiceClient: recommendationsServiceClient,
+		bookingServiceClient:         bookingServiceClient,
+		analyticsServiceClient:       analyticsServiceClient,
 	}
 }
 
 // PublicUserResponse represents the structure for public user creation response.
 type PublicUserResponse struct {
-	User *client.User `json:"user"`
+	User *contracts.User `json:"user"`
 }
 
+// nameTakenResponse is returned with 409 Conflict when a user name is already in use.
+var nameTakenResponse = map[string]string{"code": "name_taken", "error": "User name is already taken"}
+
+// quotaExceededResponse is returned with 409 Conflict when the user has too many active listings.
+var quotaExceededResponse = map[string]string{"code": "listing_quota_exceeded", "error": "User already has the maximum number of active listings"}
+
+// Maximum lengths, in characters, of listing titles and descriptions, as enforced by the Listing Service.
+const (
+	maxListingTitleLength       = 100
+	maxListingDescriptionLength = 2000
+)
+
 // PublicListing represents a listing with embedded user information for public API.
 type PublicListing struct {
-	ID          int64        `json:"id"`
-	ListingType string       `json:"listing_type"`
-	Price       int64        `json:"price"`
-	CreatedAt   int64        `json:"created_at"`
-	UpdatedAt   int64        `json:"updated_at"`
-	User        *client.User `json:"user"` // Embedded user object
+	ID          int64           `json:"id"`
+	ListingType string          `json:"listing_type"`
+	Price       int64           `json:"price"`
+	Currency    string          `json:"currency"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	CreatedAt   int64           `json:"created_at"`
+	UpdatedAt   int64           `json:"updated_at"`
+	UserName    *string         `json:"user_name"` // The Listing Service's copy of the user's name
+	User        *contracts.User `json:"user"`      // Embedded user object; nil with users=false
+
+	EffectivePrice int64                       `json:"effective_price"` // Price after the running promotion's discount
+	Promotion      *contracts.ListingPromotion `json:"promotion"`       // nil unless a promotion is running
+
+	// Price and EffectivePrice in major units with the currency's decimals, e.g. "1234.50"
+	FormattedPrice          string `json:"formatted_price"`
+	FormattedEffectivePrice string `json:"formatted_effective_price"`
+
+	Category *contracts.ListingCategory `json:"category"` // nil for uncategorized listings
+
+	Latitude   *float64 `json:"latitude"`
+	Longitude  *float64 `json:"longitude"`
+	DistanceKm *float64 `json:"distance_km,omitempty"` // Only set for radius queries
+
+	Images []contracts.ListingImage `json:"images"`
+
+	ExpiresAt     *int64 `json:"expires_at"`
+	AvailableFrom *int64 `json:"available_from"`
+	AvailableTo   *int64 `json:"available_to"`
+
+	RentalPeriod *string `json:"rental_period"`
+	Deposit      *int64  `json:"deposit"`
+	Negotiable   *bool   `json:"negotiable"`
+
+	Attributes map[string]any `json:"attributes"`
+
+	FavoritesCount int64 `json:"favorites_count"`
+	ViewCount      int64 `json:"view_count"`
+
+	Rating *contracts.Rating `json:"rating"` // Average rating and review count; nil when unavailable
+
+	TrendingScore *float64 `json:"trending_score,omitempty"` // Only set for trending listings
 }
 
 // PublicListingsResponse represents the structure for public listings response.
 type PublicListingsResponse struct {
-	Result   bool            `json:"result"`
-	Listings []PublicListing `json:"listings"`
-	Error    string          `json:"error,omitempty"`
+	Result   bool                     `json:"result"`
+	Listings []PublicListing          `json:"listings"`
+	Facets   *contracts.ListingFacets `json:"facets,omitempty"` // Only with facets=true
+	Error    string                   `json:"error,omitempty"`
+	// Cursor param of the next page, empty after the last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // CreatePublicUser handles POST /public-api/users requests.
@@ -74,6 +154,11 @@ func (h *PublicAPIHandler) CreatePublicUser(w http.ResponseWriter, r *http.Reque
 
 	user, err := h.userServiceClient.CreateUser(requestBody.Name)
 	if err != nil {
+		if errors.Is(err, client.ErrUserNameTaken) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(nameTakenResponse)
+			return
+		}
 		log.Printf("Error creating user via User Service: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create user"})
@@ -83,6 +168,67 @@ func (h *PublicAPIHandler) CreatePublicUser(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(PublicUserResponse{User: user})
 }
 
+// UpdatePublicUser handles PUT /public-api/users/{id} requests.
+// The body must carry the version the client last read; stale versions yield 409 Conflict
+// so concurrent edits cannot silently overwrite each other.
+func (h *PublicAPIHandler) UpdatePublicUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid user ID format"})
+		return
+	}
+	if actsForOtherUser(w, r, id) {
+		return
+	}
+
+	// Request body for public API is JSON
+	var requestBody struct {
+		Name    string `json:"name"`
+		Version int64  `json:"version"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	if requestBody.Name == "" || requestBody.Version < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User name and version are required"})
+		return
+	}
+
+	user, err := h.userServiceClient.UpdateUser(id, requestBody.Name, requestBody.Version)
+	if err != nil {
+		if errors.Is(err, client.ErrUserNameTaken) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(nameTakenResponse)
+			return
+		}
+		if errors.Is(err, client.ErrUserVersionConflict) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"error": "User was modified by another request; fetch the latest version and retry"})
+			return
+		}
+		log.Printf("Error updating user %d via User Service: %v", id, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to update user"})
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicUserResponse{User: user})
+}
+
 // CreatePublicListing handles POST /public-api/listings requests.
 // It proxies the request to the internal Listing Service.
 func (h *PublicAPIHandler) CreatePublicListing(w http.ResponseWriter, r *http.Request) {
@@ -93,6 +239,23 @@ func (h *PublicAPIHandler) CreatePublicListing(w http.ResponseWriter, r *http.Re
 		UserID      int64  `json:"user_id"`
 		ListingType string `json:"listing_type"`
 		Price       int64  `json:"price"`
+		Currency    string `json:"currency"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		CategoryID  int64  `json:"category_id"`
+
+		Latitude  *float64 `json:"latitude"`
+		Longitude *float64 `json:"longitude"`
+		ExpiresAt int64    `json:"expires_at"`
+
+		AvailableFrom int64 `json:"available_from"`
+		AvailableTo   int64 `json:"available_to"`
+
+		RentalPeriod string `json:"rental_period"`
+		Deposit      *int64 `json:"deposit"`
+		Negotiable   *bool  `json:"negotiable"`
+
+		Attributes map[string]any `json:"attributes"` // Validated by the Listing Service against the category
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
@@ -107,14 +270,77 @@ func (h *PublicAPIHandler) CreatePublicListing(w http.ResponseWriter, r *http.Re
 		json.NewEncoder(w).Encode(map[string]string{"error": "User ID, listing type, and price are required and valid"})
 		return
 	}
+	if requestBody.Price > money.MaxAmount || (requestBody.Deposit != nil && *requestBody.Deposit > money.MaxAmount) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Price and deposit must be at most %d minor units", int64(money.MaxAmount))})
+		return
+	}
 	if requestBody.ListingType != "rent" && requestBody.ListingType != "sale" {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Listing type must be 'rent' or 'sale'"})
 		return
 	}
+	if utf8.RuneCountInString(requestBody.Title) > maxListingTitleLength || utf8.RuneCountInString(requestBody.Description) > maxListingDescriptionLength {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": fmt.Sprintf("Title and description must be at most %d and %d characters long", maxListingTitleLength, maxListingDescriptionLength)})
+		return
+	}
+	if (requestBody.Latitude == nil) != (requestBody.Longitude == nil) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Latitude and longitude must be given together"})
+		return
+	}
+	if actsForOtherUser(w, r, requestBody.UserID) {
+		return
+	}
+
+	// Suspended users may not publish listings
+	owner, err := h.userServiceClient.GetUserByID(requestBody.UserID)
+	if err != nil {
+		log.Printf("Error fetching listing owner %d from User Service: %v", requestBody.UserID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create listing"})
+		return
+	}
+	if owner != nil && owner.Status == contracts.UserStatusSuspended {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(map[string]string{"error": "User is suspended and cannot create listings"})
+		return
+	}
+
+	listing, err := h.listingServiceClient.CreateListing(client.ListingInput{
+		UserID:      requestBody.UserID,
+		ListingType: requestBody.ListingType,
+		Price:       requestBody.Price,
+		Currency:    requestBody.Currency,
+		Title:       requestBody.Title,
+		Description: requestBody.Description,
+		CategoryID:  requestBody.CategoryID,
+		Latitude:    requestBody.Latitude,
+		Longitude:   requestBody.Longitude,
+		ExpiresAt:   requestBody.ExpiresAt,
+
+		AvailableFrom: requestBody.AvailableFrom,
+		AvailableTo:   requestBody.AvailableTo,
 
-	listing, err := h.listingServiceClient.CreateListing(requestBody.UserID, requestBody.ListingType, requestBody.Price)
+		RentalPeriod: requestBody.RentalPeriod,
+		Deposit:      requestBody.Deposit,
+		Negotiable:   requestBody.Negotiable,
+
+		Attributes: requestBody.Attributes,
+	})
 	if err != nil {
+		var invalid *client.InvalidListingError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "Invalid listing: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		if errors.Is(err, client.ErrListingQuotaExceeded) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(quotaExceededResponse)
+			return
+		}
 		log.Printf("Error creating listing via Listing Service: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to create listing"})
@@ -122,7 +348,7 @@ func (h *PublicAPIHandler) CreatePublicListing(w http.ResponseWriter, r *http.Re
 	}
 
 	// The public API response format for create listing is just the listing object
-	json.NewEncoder(w).Encode(map[string]*client.Listing{"listing": listing})
+	json.NewEncoder(w).Encode(map[string]*contracts.Listing{"listing": listing})
 }
 
 // GetPublicListings handles GET /public-api/listings requests.
@@ -141,31 +367,249 @@ func (h *PublicAPIHandler) GetPublicListings(w http.ResponseWriter, r *http.Requ
 	}
 	pageSize, err := strconv.Atoi(pageSizeStr)
 	if err != nil || pageSize < 1 {
-		pageSize = 10 // Default
+		pageSize = 0 // The Listing Service default: 10, or every listing of a batch lookup
+	}
+
+	// Optional batch lookup of comma-separated listing ids
+	var ids []int64
+	if idsStr := r.URL.Query().Get("ids"); idsStr != "" {
+		for _, idStr := range strings.Split(idsStr, ",") {
+			id, err := strconv.ParseInt(idStr, 10, 64)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid ids: must be a comma-separated list of listing IDs"})
+				return
+			}
+			ids = append(ids, id)
+		}
 	}
 
 	// 1. Get listings from Listing Service
-	listings, err := h.listingServiceClient.GetListings(pageNum, pageSize, userIDFilter)
+	page, err := h.listingServiceClient.GetListings(client.ListingQuery{
+		PageNum:  pageNum,
+		Cursor:   r.URL.Query().Get("cursor"), // Optional next_cursor of a previous page
+		PageSize: pageSize,
+		IDs:      ids,
+		UserID:   userIDFilter,
+		Near:     r.URL.Query().Get("near"), // Optional "lat,lng" of a radius query
+		RadiusKm: r.URL.Query().Get("radius_km"),
+		Filter:   r.URL.Query().Get("filter"), // Optional filter expression
+		Sort:     r.URL.Query().Get("sort"),
+		Order:    r.URL.Query().Get("order"),
+
+		AvailableOn: r.URL.Query().Get("available_on"), // Optional date rentals must be available on
+		Facets:      r.URL.Query().Get("facets") == "true",
+	})
 	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
 		log.Printf("Error getting listings from Listing Service: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to retrieve listings"})
 		return
 	}
 
-	if len(listings) == 0 {
+	// Simple list views can make do with user_name and skip fetching every user
+	publicListings := toPublicListings(page.Listings, nil)
+	if r.URL.Query().Get("users") != "false" {
+		publicListings = h.withUsers(page.Listings)
+	}
+	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(publicListings), Facets: page.Facets, NextCursor: page.NextCursor})
+}
+
+// SearchPublicListings handles GET /public-api/listings/search requests.
+// It runs the Listing Service's full-text search and enriches the matches with user data, or
+// when a Search Service is configured, gets both from its index in a single request.
+func (h *PublicAPIHandler) SearchPublicListings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	query := r.URL.Query().Get("q")
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default
+	}
+
+	var listings []contracts.Listing
+	var users map[int64]*contracts.User
+	if h.searchServiceClient != nil {
+		listings, users, err = h.searchServiceClient.SearchListings(query, pageNum, pageSize)
+	} else {
+		listings, err = h.listingServiceClient.SearchListings(query, pageNum, pageSize)
+	}
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid search: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error searching listings: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to search listings"})
+		return
+	}
+
+	if users != nil {
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(toPublicListings(listings, users))})
+		return
+	}
+	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(h.withUsers(listings))})
+}
+
+// GetFeaturedPublicListings handles GET /public-api/listings/featured requests.
+// It returns a random sample of active listings, e.g. for homepage carousels, enriched with user data.
+func (h *PublicAPIHandler) GetFeaturedPublicListings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 1 {
+		count = 10 // Default
+	}
+	weighted := r.URL.Query().Get("weighted") == "true"
+
+	listings, err := h.listingServiceClient.GetFeaturedListings(count, weighted)
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error getting featured listings from Listing Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to retrieve featured listings"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(h.withUsers(listings))})
+}
+
+// GetTrendingPublicListings handles GET /public-api/listings/trending requests.
+// It returns the listings with the most recent views, enriched with user data.
+func (h *PublicAPIHandler) GetTrendingPublicListings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default
+	}
+
+	listings, err := h.listingServiceClient.GetTrendingListings(r.URL.Query().Get("window"), pageNum, pageSize)
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error getting trending listings from Listing Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to retrieve trending listings"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(h.withUsers(listings))})
+}
+
+// GetRecommendedPublicListings handles GET /public-api/recommendations requests.
+// It returns up to limit (default 10) listings recommended to user_id by the Recommendations
+// Service, best first, enriched with user data.
+func (h *PublicAPIHandler) GetRecommendedPublicListings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.recommendationsServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Recommendations are not available"})
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Invalid or missing user_id"})
+		return
+	}
+	if actsForOtherUser(w, r, userID) {
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = 10 // Default
+	}
+	if limit > contracts.MaxRecommendations {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: fmt.Sprintf("Invalid query: limit must be at most %d", contracts.MaxRecommendations)})
+		return
+	}
+
+	// Recommended listings may have been sold or hidden since, so ask for spares
+	recommendations, err := h.recommendationsServiceClient.GetRecommendations(userID, min(2*limit, contracts.MaxRecommendations))
+	if err != nil {
+		log.Printf("Error getting recommendations from Recommendations Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to retrieve recommendations"})
+		return
+	}
+	if len(recommendations) == 0 {
 		json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: []PublicListing{}})
 		return
 	}
 
-	// 2. Extract unique user IDs from listings
+	// Batch lookups only return publicly visible listings
+	ids := make([]int64, len(recommendations))
+	for i, recommendation := range recommendations {
+		ids[i] = recommendation.ListingID
+	}
+	page, err := h.listingServiceClient.GetListings(client.ListingQuery{PageNum: 1, IDs: ids})
+	if err != nil {
+		log.Printf("Error getting recommended listings from Listing Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicListingsResponse{Result: false, Error: "Failed to retrieve recommendations"})
+		return
+	}
+
+	// Restore the order of the recommendations, which the Listing Service does not keep
+	found := make(map[int64]contracts.Listing, len(page.Listings))
+	for _, listing := range page.Listings {
+		found[listing.ID] = listing
+	}
+	listings := make([]contracts.Listing, 0, limit)
+	for _, id := range ids {
+		if listing, ok := found[id]; ok && len(listings) < limit {
+			listings = append(listings, listing)
+		}
+	}
+
+	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: h.withRatings(h.withUsers(listings))})
+}
+
+// withUsers converts listings to their public form, attaching the details of each listing's user.
+// Listings whose user cannot be fetched are returned with a nil user.
+func (h *PublicAPIHandler) withUsers(listings []contracts.Listing) []PublicListing {
+	if len(listings) == 0 {
+		return []PublicListing{}
+	}
+
+	// 1. Extract unique user IDs from listings
 	uniqueUserIDs := make(map[int64]struct{})
 	for _, listing := range listings {
 		uniqueUserIDs[listing.UserID] = struct{}{}
 	}
 
-	// 3. Concurrently fetch user details for unique user IDs
-	userMap := make(map[int64]*client.User)
+	// 2. Concurrently fetch user details for unique user IDs
+	userMap := make(map[int64]*contracts.User)
 	var wg sync.WaitGroup
 	var mu sync.Mutex // Mutex to protect userMap concurrent writes
 	errorsChan := make(chan error, len(uniqueUserIDs))
@@ -206,19 +650,78 @@ func (h *PublicAPIHandler) GetPublicListings(w http.ResponseWriter, r *http.Requ
 		}
 	}
 
-	// 4. Aggregate listings with user details
+	// 3. Aggregate listings with user details
+	return toPublicListings(listings, userMap)
+}
+
+// withRatings attaches the rating of each listing from the Review Service, when one is
+// configured. Ratings are decoration: if the Review Service fails, listings are returned
+// without them.
+func (h *PublicAPIHandler) withRatings(listings []PublicListing) []PublicListing {
+	if h.reviewServiceClient == nil || len(listings) == 0 {
+		return listings
+	}
+
+	// Pages of listings can be larger than the Review Service rates at once
+	for start := 0; start < len(listings); start += contracts.MaxRatingBatchSize {
+		batch := listings[start:min(start+contracts.MaxRatingBatchSize, len(listings))]
+		ids := make([]int64, len(batch))
+		for i, listing := range batch {
+			ids[i] = listing.ID
+		}
+		ratings, err := h.reviewServiceClient.GetListingRatings(ids)
+		if err != nil {
+			log.Printf("Error fetching listing ratings from Review Service: %v", err)
+			return listings
+		}
+		for i := range batch {
+			if rating, ok := ratings[batch[i].ID]; ok {
+				batch[i].Rating = &rating
+			}
+		}
+	}
+	return listings
+}
+
+// toPublicListings converts listings to their public form, attaching the user of each listing
+// found in users.
+func toPublicListings(listings []contracts.Listing, users map[int64]*contracts.User) []PublicListing {
 	publicListings := make([]PublicListing, 0, len(listings))
 	for _, listing := range listings {
 		publicListing := PublicListing{
-			ID:          listing.ID,
-			ListingType: listing.ListingType,
-			Price:       listing.Price,
-			CreatedAt:   listing.CreatedAt,
-			UpdatedAt:   listing.UpdatedAt,
-			User:        userMap[listing.UserID], // Will be nil if user not found/error
+			ID:             listing.ID,
+			ListingType:    listing.ListingType,
+			Price:          listing.Price,
+			Currency:       listing.Currency,
+			EffectivePrice: listing.EffectivePrice,
+			Promotion:      listing.Promotion,
+
+			FormattedPrice:          money.Money{Amount: listing.Price, Currency: listing.Currency}.Format(),
+			FormattedEffectivePrice: money.Money{Amount: listing.EffectivePrice, Currency: listing.Currency}.Format(),
+
+			Title:          listing.Title,
+			Description:    listing.Description,
+			CreatedAt:      listing.CreatedAt,
+			UpdatedAt:      listing.UpdatedAt,
+			UserName:       listing.UserName,
+			User:           users[listing.UserID], // Will be nil if user not found/error
+			Category:       listing.Category,
+			Latitude:       listing.Latitude,
+			Longitude:      listing.Longitude,
+			DistanceKm:     listing.DistanceKm,
+			Images:         listing.Images,
+			ExpiresAt:      listing.ExpiresAt,
+			AvailableFrom:  listing.AvailableFrom,
+			AvailableTo:    listing.AvailableTo,
+			RentalPeriod:   listing.RentalPeriod,
+			Deposit:        listing.Deposit,
+			Negotiable:     listing.Negotiable,
+			Attributes:     listing.Attributes,
+			FavoritesCount: listing.FavoritesCount,
+			ViewCount:      listing.ViewCount,
+			TrendingScore:  listing.TrendingScore,
 		}
 		publicListings = append(publicListings, publicListing)
 	}
-
-	json.NewEncoder(w).Encode(PublicListingsResponse{Result: true, Listings: publicListings})
+	return publicListings
 }
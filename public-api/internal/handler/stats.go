@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"contracts"
+	"contracts/client"
+)
+
+// maxStatsDays is the largest number of days of daily statistics, as allowed by the Listing Service.
+const maxStatsDays = 365
+
+// PublicStats combines the statistics of the User Service and the Listing Service.
+type PublicStats struct {
+	Users    *contracts.SignupStats  `json:"users"`
+	Listings *contracts.ListingStats `json:"listings"`
+}
+
+// PublicStatsResponse represents the structure for aggregated stats responses.
+type PublicStatsResponse struct {
+	Result bool         `json:"result"`
+	Stats  *PublicStats `json:"stats,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// GetPublicStats handles GET /public-api/stats requests.
+// It fetches user and listing statistics concurrently and returns them together, e.g. for
+// dashboards. The optional days parameter chooses how many days of daily counts are returned.
+func (h *PublicAPIHandler) GetPublicStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	days := 0 // The services' defaults
+	if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+		n, err := strconv.Atoi(daysStr)
+		if err != nil || n < 1 || n > maxStatsDays {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicStatsResponse{Result: false, Error: "Invalid days, expected an integer between 1 and " + strconv.Itoa(maxStatsDays)})
+			return
+		}
+		days = n
+	}
+
+	var (
+		wg                    sync.WaitGroup
+		stats                 PublicStats
+		usersErr, listingsErr error
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stats.Users, usersErr = h.userServiceClient.GetSignupStats(days)
+	}()
+	go func() {
+		defer wg.Done()
+		stats.Listings, listingsErr = h.listingServiceClient.GetListingStats(days)
+	}()
+	wg.Wait()
+
+	var invalid *client.InvalidQueryError
+	if errors.As(listingsErr, &invalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicStatsResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+		return
+	}
+	if usersErr != nil || listingsErr != nil {
+		log.Printf("Error getting stats: users: %v, listings: %v", usersErr, listingsErr)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicStatsResponse{Result: false, Error: "Failed to retrieve stats"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicStatsResponse{Result: true, Stats: &stats})
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"contracts"
+	"contracts/client"
+	"money"
+
+	"github.com/gorilla/mux"
+)
+
+// PublicTransaction represents a transaction with its embedded listing for the public API.
+type PublicTransaction struct {
+	contracts.Transaction
+	FormattedAmount string         `json:"formatted_amount"` // Amount in major units with the currency's decimals
+	Listing         *PublicListing `json:"listing"`          // nil once the listing is no longer publicly visible, e.g. deleted
+}
+
+// PublicTransactionResponse represents the structure for single transaction responses.
+type PublicTransactionResponse struct {
+	Result      bool               `json:"result"`
+	Transaction *PublicTransaction `json:"transaction,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// PublicTransactionsResponse represents the structure for pages of transactions.
+type PublicTransactionsResponse struct {
+	Result       bool                `json:"result"`
+	Transactions []PublicTransaction `json:"transactions"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// transactionsUnavailableResponse is returned with 404 Not Found when no Booking Service is configured.
+var transactionsUnavailableResponse = PublicTransactionResponse{Result: false, Error: "Transactions are not available"}
+
+// GetPublicTransactions handles GET /public-api/transactions requests.
+// It returns a page of the transactions user_id buys or sells in, most recent first,
+// optionally only those with the given status, enriched with their listings.
+func (h *PublicAPIHandler) GetPublicTransactions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.bookingServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(transactionsUnavailableResponse)
+		return
+	}
+
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicTransactionsResponse{Result: false, Error: "Invalid or missing user_id"})
+		return
+	}
+	if actsForOtherUser(w, r, userID) {
+		return
+	}
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default
+	}
+
+	transactions, err := h.bookingServiceClient.ListTransactions(userID, r.URL.Query().Get("status"), pageNum, pageSize)
+	if err != nil {
+		var invalid *client.InvalidQueryError
+		if errors.As(err, &invalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(PublicTransactionsResponse{Result: false, Error: "Invalid query: " + strings.Join(invalid.Messages, "; ")})
+			return
+		}
+		log.Printf("Error getting transactions from Booking Service: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicTransactionsResponse{Result: false, Error: "Failed to retrieve transactions"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(PublicTransactionsResponse{Result: true, Transactions: h.withListings(transactions)})
+}
+
+// GetPublicTransaction handles GET /public-api/transactions/{id} requests.
+// It returns the transaction along with its saga log, if user_id is its buyer or seller.
+func (h *PublicAPIHandler) GetPublicTransaction(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.bookingServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(transactionsUnavailableResponse)
+		return
+	}
+
+	id, ok := parseTransactionID(w, r)
+	if !ok {
+		return
+	}
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Invalid or missing user_id"})
+		return
+	}
+	if actsForOtherUser(w, r, userID) {
+		return
+	}
+
+	transaction, err := h.bookingServiceClient.GetTransaction(id, userID)
+	h.writeTransaction(w, id, transaction, err, "getting")
+}
+
+// ConfirmPublicTransaction handles POST /public-api/transactions/{id}/confirm requests.
+// The buyer confirms a held transaction, and the listing is then marked as sold.
+func (h *PublicAPIHandler) ConfirmPublicTransaction(w http.ResponseWriter, r *http.Request) {
+	h.actOnTransaction(w, r, "confirming", h.bookingServiceClient.ConfirmTransaction)
+}
+
+// CancelPublicTransaction handles POST /public-api/transactions/{id}/cancel requests.
+// Either party cancels a pending or held transaction, and the listing's hold is then released.
+func (h *PublicAPIHandler) CancelPublicTransaction(w http.ResponseWriter, r *http.Request) {
+	h.actOnTransaction(w, r, "cancelling", h.bookingServiceClient.CancelTransaction)
+}
+
+// actOnTransaction handles the requests of a party acting on a transaction, whose user_id is
+// carried by the JSON body. action describes the request in logs.
+func (h *PublicAPIHandler) actOnTransaction(w http.ResponseWriter, r *http.Request, action string, act func(id, userID int64) (*contracts.Transaction, error)) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if h.bookingServiceClient == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(transactionsUnavailableResponse)
+		return
+	}
+
+	id, ok := parseTransactionID(w, r)
+	if !ok {
+		return
+	}
+
+	// Request body for public API is JSON
+	var requestBody struct {
+		UserID int64 `json:"user_id"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Invalid request body"})
+		return
+	}
+	if requestBody.UserID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "User ID is required"})
+		return
+	}
+	if actsForOtherUser(w, r, requestBody.UserID) {
+		return
+	}
+
+	transaction, err := act(id, requestBody.UserID)
+	h.writeTransaction(w, id, transaction, err, action)
+}
+
+// parseTransactionID reads the transaction ID in the URL path, writing a 400 response and
+// returning false if it is invalid.
+func parseTransactionID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Invalid transaction ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeTransaction writes the response of a request for a single transaction, mapping the
+// errors of the Booking Service client to statuses. action describes the request in logs.
+func (h *PublicAPIHandler) writeTransaction(w http.ResponseWriter, id int64, transaction *contracts.Transaction, err error, action string) {
+	var stateErr *client.TransactionStateError
+	switch {
+	case errors.Is(err, client.ErrTransactionForbidden):
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "User is not allowed to act on this transaction"})
+	case errors.As(err, &stateErr):
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Invalid transaction state: " + stateErr.Message})
+	case err != nil:
+		log.Printf("Error %s transaction %d via Booking Service: %v", action, id, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Failed to process transaction"})
+	case transaction == nil:
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: false, Error: "Transaction not found"})
+	default:
+		publicTransaction := h.withListings([]contracts.Transaction{*transaction})[0]
+		json.NewEncoder(w).Encode(PublicTransactionResponse{Result: true, Transaction: &publicTransaction})
+	}
+}
+
+// withListings converts transactions to their public form, attaching each transaction's
+// listing while it is publicly visible. Listings are decoration: if the Listing Service
+// fails, transactions are returned without them.
+func (h *PublicAPIHandler) withListings(transactions []contracts.Transaction) []PublicTransaction {
+	publicTransactions := make([]PublicTransaction, len(transactions))
+	for i, transaction := range transactions {
+		publicTransactions[i] = PublicTransaction{
+			Transaction:     transaction,
+			FormattedAmount: money.Money{Amount: transaction.Amount, Currency: transaction.Currency}.Format(),
+		}
+	}
+	if len(transactions) == 0 {
+		return publicTransactions
+	}
+
+	// Pages of transactions are no larger than a batch lookup
+	seen := make(map[int64]struct{}, len(transactions))
+	ids := make([]int64, 0, len(transactions))
+	for _, transaction := range transactions {
+		if _, ok := seen[transaction.ListingID]; !ok {
+			seen[transaction.ListingID] = struct{}{}
+			ids = append(ids, transaction.ListingID)
+		}
+	}
+	page, err := h.listingServiceClient.GetListings(client.ListingQuery{PageNum: 1, IDs: ids})
+	if err != nil {
+		log.Printf("Error fetching transaction listings from Listing Service: %v", err)
+		return publicTransactions
+	}
+
+	listings := make(map[int64]*PublicListing, len(page.Listings))
+	for _, listing := range toPublicListings(page.Listings, nil) {
+		listings[listing.ID] = &listing
+	}
+	for i := range publicTransactions {
+		publicTransactions[i].Listing = listings[publicTransactions[i].ListingID]
+	}
+	return publicTransactions
+}
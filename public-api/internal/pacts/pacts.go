@@ -0,0 +1,305 @@
+// Package pacts records the contracts of the gateway with the services it calls: each
+// interaction is exercised through the gateway's own client against a mock of the service, so
+// the pacts hold the requests the clients really send and the parts of the responses they
+// decode. Services verify the saved pacts with the contracttest verifier.
+package pacts
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"contracts"
+	"contracts/client"
+	"contracttest"
+)
+
+// Consumer is the name the gateway goes by in its pacts.
+const Consumer = "public-api"
+
+// formHeaders are the headers of form-encoded requests.
+var formHeaders = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+
+// exampleUser is the user the mock User Service answers with.
+const exampleUser = `{"id": 1, "name": "pact-user-1", "created_at": 1700000000000000, "updated_at": 1700000000000000, "version": 1, "status": "active"}`
+
+// exampleListing is the listing the mock Listing Service answers with. Fields the Listing
+// Service may leave null are null, so they match any value.
+const exampleListing = `{"id": 1, "user_id": 1, "user_name": null, "listing_type": "sale", "price": 150000, "currency": "USD",
+	"title": "Pact listing", "description": "", "created_at": 1700000000000000, "updated_at": 1700000000000000,
+	"effective_price": 150000, "promotion": null, "category": null, "latitude": null, "longitude": null}`
+
+// createUser is the setup creating a user, captured as user_id and version.
+var createUser = contracttest.Request{
+	Method:  http.MethodPost,
+	Path:    "/users",
+	Headers: formHeaders,
+	Body:    "name=pact-owner-{{unique}}",
+	Capture: map[string]string{"user_id": "user.id", "version": "user.version"},
+}
+
+// interaction is an interaction of the gateway along with the client call making its request.
+type interaction struct {
+	contracttest.Interaction
+	// exercise sends the request through the client, checking the client decodes the response.
+	exercise func(httpClient *http.Client, baseURL string) error
+}
+
+// userServiceInteractions are the interactions of the gateway with the User Service.
+var userServiceInteractions = []interaction{
+	{
+		Interaction: contracttest.Interaction{
+			Description: "create a user",
+			Request:     contracttest.Request{Method: http.MethodPost, Path: "/users", Headers: formHeaders, Body: "name=pact-user-{{unique}}"},
+			Response:    contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "user": ` + exampleUser + `}`)},
+			Examples:    map[string]string{contracttest.Unique: "1"},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			user, err := client.NewUserServiceClient(httpClient, baseURL).CreateUser("pact-user-1")
+			return expectUser(user, err)
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "create a user with a taken name",
+			Setup:       []contracttest.Request{{Method: http.MethodPost, Path: "/users", Headers: formHeaders, Body: "name=pact-taken-{{unique}}"}},
+			Request:     contracttest.Request{Method: http.MethodPost, Path: "/users", Headers: formHeaders, Body: "name=pact-taken-{{unique}}"},
+			Response:    contracttest.Response{Status: http.StatusConflict},
+			Examples:    map[string]string{contracttest.Unique: "1"},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			_, err := client.NewUserServiceClient(httpClient, baseURL).CreateUser("pact-taken-1")
+			return expectError(err, client.ErrUserNameTaken)
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "get an existing user",
+			Setup:       []contracttest.Request{createUser},
+			Request:     contracttest.Request{Method: http.MethodGet, Path: "/users/{{user_id}}"},
+			Response:    contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "user": ` + exampleUser + `}`)},
+			Examples:    map[string]string{"user_id": "1"},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			user, err := client.NewUserServiceClient(httpClient, baseURL).GetUserByID(1)
+			return expectUser(user, err)
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "get a missing user",
+			Request:     contracttest.Request{Method: http.MethodGet, Path: "/users/999999999"},
+			Response:    contracttest.Response{Status: http.StatusNotFound},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			user, err := client.NewUserServiceClient(httpClient, baseURL).GetUserByID(999999999)
+			if err != nil || user != nil {
+				return fmt.Errorf("expected no user, got %v, %v", user, err)
+			}
+			return nil
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "update a user",
+			Setup:       []contracttest.Request{createUser},
+			Request: contracttest.Request{
+				Method:  http.MethodPut,
+				Path:    "/users/{{user_id}}",
+				Headers: formHeaders,
+				Body:    "name=pact-renamed-{{unique}}&version={{version}}",
+			},
+			Response: contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "user": ` + exampleUser + `}`)},
+			Examples: map[string]string{contracttest.Unique: "1", "user_id": "1", "version": "1"},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			user, err := client.NewUserServiceClient(httpClient, baseURL).UpdateUser(1, "pact-renamed-1", 1)
+			return expectUser(user, err)
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "update a user with a stale version",
+			Setup: []contracttest.Request{
+				createUser,
+				{Method: http.MethodPut, Path: "/users/{{user_id}}", Headers: formHeaders, Body: "name=pact-renamed-{{unique}}&version={{version}}"},
+			},
+			Request: contracttest.Request{
+				Method:  http.MethodPut,
+				Path:    "/users/{{user_id}}",
+				Headers: formHeaders,
+				Body:    "name=pact-stale-{{unique}}&version={{version}}",
+			},
+			Response: contracttest.Response{Status: http.StatusConflict},
+			Examples: map[string]string{contracttest.Unique: "1", "user_id": "1", "version": "1"},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			_, err := client.NewUserServiceClient(httpClient, baseURL).UpdateUser(1, "pact-stale-1", 1)
+			return expectError(err, client.ErrUserVersionConflict)
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "introspect an unknown access token",
+			Request: contracttest.Request{
+				Method:  http.MethodPost,
+				Path:    "/tokens/introspect",
+				Headers: map[string]string{"Content-Type": "application/json"},
+				Body:    `{"token":"pact-unknown-token"}`,
+			},
+			Response: contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "active": false}`)},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			token, err := client.NewUserServiceClient(httpClient, baseURL).IntrospectToken("pact-unknown-token")
+			if err != nil || token != nil {
+				return fmt.Errorf("expected an inactive token, got %v, %v", token, err)
+			}
+			return nil
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "get signup statistics",
+			Setup:       []contracttest.Request{createUser},
+			Request:     contracttest.Request{Method: http.MethodGet, Path: "/users/stats?days=7"},
+			Response: contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "stats": {
+				"totals": {"total": 1, "active": 1, "suspended": 0, "deleted": 0},
+				"daily": [{"start": 1700000000000000, "count": 1}],
+				"weekly": [{"start": 1700000000000000, "count": 1}],
+				"generated_at": 1700000000000000}}`)},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			stats, err := client.NewUserServiceClient(httpClient, baseURL).GetSignupStats(7)
+			if err != nil || stats == nil || stats.Totals.Total != 1 {
+				return fmt.Errorf("expected signup statistics, got %v, %v", stats, err)
+			}
+			return nil
+		},
+	},
+}
+
+// listingServiceInteractions are the interactions of the gateway with the Listing Service.
+var listingServiceInteractions = []interaction{
+	{
+		Interaction: contracttest.Interaction{
+			Description: "create a listing",
+			Request: contracttest.Request{
+				Method:  http.MethodPost,
+				Path:    "/listings",
+				Headers: formHeaders,
+				Body:    "listing_type=sale&price=150000&title=Pact+listing&user_id=1",
+			},
+			Response: contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "listing": ` + exampleListing + `}`)},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			listing, err := client.NewListingServiceClient(httpClient, baseURL).CreateListing(client.ListingInput{
+				UserID: 1, ListingType: "sale", Price: 150000, Title: "Pact listing",
+			})
+			if err != nil || listing == nil || listing.ID != 1 {
+				return fmt.Errorf("expected listing 1, got %v, %v", listing, err)
+			}
+			return nil
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "create an invalid listing",
+			Request: contracttest.Request{
+				Method:  http.MethodPost,
+				Path:    "/listings",
+				Headers: formHeaders,
+				Body:    "listing_type=swap&price=150000&title=Pact+listing&user_id=1",
+			},
+			Response: contracttest.Response{Status: http.StatusBadRequest, Body: json.RawMessage(`{"errors": ["listing_type must be one of rent, sale"]}`)},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			_, err := client.NewListingServiceClient(httpClient, baseURL).CreateListing(client.ListingInput{
+				UserID: 1, ListingType: "swap", Price: 150000, Title: "Pact listing",
+			})
+			var invalid *client.InvalidListingError
+			if !errors.As(err, &invalid) || len(invalid.Messages) != 1 {
+				return fmt.Errorf("expected an invalid listing error, got %v", err)
+			}
+			return nil
+		},
+	},
+	{
+		Interaction: contracttest.Interaction{
+			Description: "get a page of listings",
+			Setup: []contracttest.Request{{
+				Method:  http.MethodPost,
+				Path:    "/listings",
+				Headers: formHeaders,
+				Body:    "listing_type=sale&price=150000&title=Pact+listing&user_id=1",
+			}},
+			Request:  contracttest.Request{Method: http.MethodGet, Path: "/listings?page_num=1&page_size=10"},
+			Response: contracttest.Response{Status: http.StatusOK, Body: json.RawMessage(`{"result": true, "listings": [` + exampleListing + `]}`)},
+		},
+		exercise: func(httpClient *http.Client, baseURL string) error {
+			page, err := client.NewListingServiceClient(httpClient, baseURL).GetListings(client.ListingQuery{PageNum: 1, PageSize: 10})
+			if err != nil || len(page.Listings) != 1 {
+				return fmt.Errorf("expected a page of one listing, got %v, %v", page, err)
+			}
+			return nil
+		},
+	},
+}
+
+// Record exercises the gateway's clients against mocks of the User and Listing services, and
+// saves their pacts in dir once every interaction went through as expected.
+func Record(dir string) ([]string, error) {
+	httpClient := &http.Client{Timeout: 5 * time.Second}
+	var pacts []contracttest.Pact
+	for _, provider := range []struct {
+		name         string
+		interactions []interaction
+	}{
+		{"user-service", userServiceInteractions},
+		{"listing-service", listingServiceInteractions},
+	} {
+		expected := make([]contracttest.Interaction, len(provider.interactions))
+		for i, interaction := range provider.interactions {
+			expected[i] = interaction.Interaction
+		}
+		mock := contracttest.NewMockProvider(Consumer, provider.name, expected...)
+		var errs []error
+		for _, interaction := range provider.interactions {
+			if err := interaction.exercise(httpClient, mock.URL); err != nil {
+				errs = append(errs, fmt.Errorf("%q: %w", interaction.Description, err))
+			}
+		}
+		mock.Close()
+		if err := errors.Join(append(errs, mock.Err())...); err != nil {
+			return nil, fmt.Errorf("the gateway does not follow its pact with %s:\n%w", provider.name, err)
+		}
+		pacts = append(pacts, mock.Pact())
+	}
+
+	paths := make([]string, len(pacts))
+	for i, pact := range pacts {
+		path, err := pact.Save(dir)
+		if err != nil {
+			return nil, err
+		}
+		paths[i] = path
+	}
+	return paths, nil
+}
+
+// expectUser checks a client call returned a user.
+func expectUser(user *contracts.User, err error) error {
+	if err != nil || user == nil || user.ID != 1 {
+		return fmt.Errorf("expected user 1, got %v, %v", user, err)
+	}
+	return nil
+}
+
+// expectError checks a client call failed with target.
+func expectError(err, target error) error {
+	if !errors.Is(err, target) {
+		return fmt.Errorf("expected %v, got %v", target, err)
+	}
+	return nil
+}
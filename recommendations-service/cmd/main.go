@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"bus"
+	"httpmiddleware"
+	"recommendations-service/internal/api"
+	"recommendations-service/internal/handler"
+	"recommendations-service/internal/repository"
+	"recommendations-service/internal/service"
+	"registry"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "recommendations.db"
+
+func main() {
+	// Define command-line flags for port, database and ranking
+	port := flag.Int("port", 9500, "The port number to run the Recommendations Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding users' interactions with listings")
+	popularWindow := flag.Duration("popular-window", 30*24*time.Hour, "How far back interactions count towards popular listings")
+	registryURL := flag.String("registry-url", "", "URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)")
+	advertiseURL := flag.String("advertise-url", "", "URL the registry gives out for this instance (default http://<hostname>:<port>)")
+	eventBus := flag.String("event-bus", "", "URL of the message bus to consume domain events from: nats://host:port or kafka://host:port (events are only received on POST /events when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	if *popularWindow <= 0 {
+		log.Fatalf("Invalid -popular-window, expected a positive duration")
+	}
+
+	// Initialize the SQLite database
+	// By default this will create 'recommendations.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize repository, service, and handler layers
+	interactionRepo := repository.NewSQLiteInteractionRepository(db)
+	recommendationService := service.NewRecommendationService(interactionRepo, *popularWindow)
+	recommendationHandler := handler.NewRecommendationHandler(recommendationService)
+
+	// Consume domain events from the event bus as well, when there is one
+	if *eventBus != "" {
+		events, err := bus.Open(*eventBus)
+		if err != nil {
+			log.Fatalf("Failed to open event bus: %v", err)
+		}
+		defer events.Close()
+		if _, err := bus.SubscribeTopics(context.Background(), events, service.ConsumedEvents, "recommendations-service", recommendationHandler.ConsumeMessage); err != nil {
+			log.Fatalf("Failed to subscribe to the event bus: %v", err)
+		}
+		log.Printf("Consuming %v from %s", service.ConsumedEvents, *eventBus)
+	}
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Recommendations Service API routes
+	// GET /recommendations/{user_id}: Listings recommended to a user, as specified by api/openapi.yaml
+	api.RegisterRecommendationsRoutes(r, recommendationHandler)
+	// POST /events: Consume a domain event from the user or listing service's relay
+	r.HandleFunc("/events", recommendationHandler.ConsumeEvent).Methods("POST")
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("recommendations-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Register with the registry, so the gateway reaches this instance as registry://recommendations-service
+	if *registryURL != "" {
+		if err := registry.Announce(context.Background(), *registryURL, "recommendations-service", *advertiseURL, *port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
+	// Start the HTTP server
+	log.Printf("Recommendations Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
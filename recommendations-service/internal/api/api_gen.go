@@ -0,0 +1,32 @@
+// Code generated by openapi from recommendations-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// RecommendationsHandler handles the operations of the recommendations tag: listings recommended to
+// users.
+type RecommendationsHandler interface {
+	// GetRecommendations handles GET /recommendations/{user_id}: Get the listings recommended to a
+	// user, best first.
+	GetRecommendations(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedRecommendationsHandler answers every operation of RecommendationsHandler with 501
+// Not Implemented. Handlers embed it to implement the interface while their operations are written.
+type UnimplementedRecommendationsHandler struct{}
+
+// GetRecommendations answers GET /recommendations/{user_id} with 501 Not Implemented.
+func (UnimplementedRecommendationsHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterRecommendationsRoutes registers the routes of the operations of RecommendationsHandler on
+// r.
+func RegisterRecommendationsRoutes(r *mux.Router, h RecommendationsHandler) {
+	r.HandleFunc("/recommendations/{user_id}", h.GetRecommendations).Methods("GET")
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+
+	"apperrors"
+	"recommendations-service/internal/api"
+	"recommendations-service/internal/model"
+	"recommendations-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the JSON body of requests.
+const maxBodyBytes = 64 << 10
+
+// RecommendationsResponse is the response structure for the recommendations of a user.
+type RecommendationsResponse struct {
+	Result          bool                   `json:"result"`
+	Recommendations []model.Recommendation `json:"recommendations"`
+	Error           string                 `json:"error,omitempty"`
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RecommendationHandler handles HTTP requests related to recommendations.
+type RecommendationHandler struct {
+	recommendationService *service.RecommendationService
+}
+
+// RecommendationHandler implements the operations of the spec's recommendations tag.
+var _ api.RecommendationsHandler = (*RecommendationHandler)(nil)
+
+// NewRecommendationHandler creates a new instance of RecommendationHandler.
+func NewRecommendationHandler(recommendationService *service.RecommendationService) *RecommendationHandler {
+	return &RecommendationHandler{recommendationService: recommendationService}
+}
+
+// GetRecommendations handles GET /recommendations/{user_id} requests.
+// It returns up to limit (default 10) listing IDs recommended to the user, best first. The
+// listings are not checked against the Listing Service, so some may no longer be visible.
+func (h *RecommendationHandler) GetRecommendations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["user_id"], 10, 64)
+	if err != nil || userID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(RecommendationsResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limit < 1 {
+		limit = service.DefaultLimit
+	}
+
+	recommendations, err := h.recommendationService.Recommend(userID, limit)
+	if err != nil {
+		writeError(w, err, "recommending listings to user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(RecommendationsResponse{Result: true, Recommendations: recommendations})
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the user service's
+// and the listing service's event relays. Events of other types are acknowledged without effect.
+// Failures answer 500, so the relays retry the event.
+func (h *RecommendationHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.recommendationService.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "handling event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// writeError answers an error of RecommendationService with the status of its code, logging
+// internal errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
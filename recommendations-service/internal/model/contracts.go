@@ -0,0 +1,8 @@
+package model
+
+import "contracts"
+
+// This conversion breaks the build when the Recommendations Service's recommendations and the
+// contract its clients decode them into drift apart. It compares field names and types; the
+// JSON names are kept in sync by hand.
+var _ = contracts.Recommendation(Recommendation{})
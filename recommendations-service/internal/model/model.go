@@ -0,0 +1,28 @@
+package model
+
+import "encoding/json"
+
+// Reasons a listing is recommended.
+const (
+	// ReasonSimilarUsers marks listings that users who viewed or favorited the same listings
+	// as the user also viewed or favorited.
+	ReasonSimilarUsers = "similar_users"
+	// ReasonPopular marks listings with the most recent interactions, which fill in for users
+	// with little history.
+	ReasonPopular = "popular"
+)
+
+// Event is a domain event as POSTed by the user service's and the listing service's relays.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// Recommendation is a listing recommended to a user.
+type Recommendation struct {
+	ListingID int64   `json:"listing_id"`
+	Score     float64 `json:"score"` // Higher is better; only comparable between recommendations of the same reason
+	Reason    string  `json:"reason"`
+}
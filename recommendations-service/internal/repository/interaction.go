@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"recommendations-service/internal/model"
+)
+
+const (
+	// maxViewWeight caps how much repeated views of a listing by one user count, so a user
+	// reloading a page cannot outweigh everyone else.
+	maxViewWeight = 5
+	// favoriteWeight is how much a favorite counts, compared to a single view.
+	favoriteWeight = 3
+	// maxHistory is the number of the user's most recent interactions recommendations are
+	// based on.
+	maxHistory = 50
+)
+
+// interactionWeight is the SQL expression of the weight of an interactions row.
+var interactionWeight = fmt.Sprintf("(MIN(%%[1]s.views, %d) + %d * %%[1]s.favorited)", maxViewWeight, favoriteWeight)
+
+// InteractionRepository defines the interface for the storage of users' interactions with listings.
+type InteractionRepository interface {
+	RecordView(event model.Event, userID, listingID, viewedAt int64) (bool, error)
+	RecordFavorite(event model.Event, userID, listingID int64, favorited bool, at int64) (bool, error)
+	DeleteListing(listingID int64) error
+	DeleteUser(userID int64) error
+	MergeUsers(sourceUserID, targetUserID int64) error
+	SimilarUsersListings(userID int64, limit int) ([]model.Recommendation, error)
+	PopularListings(userID, since int64, limit int) ([]model.Recommendation, error)
+}
+
+// sqliteInteractionRepository implements InteractionRepository for SQLite database.
+type sqliteInteractionRepository struct {
+	db *sql.DB
+}
+
+// createInteractionsTableSQL creates the interactions table, holding how many times each user
+// viewed each listing and whether they favorite it.
+const createInteractionsTableSQL = `
+	CREATE TABLE IF NOT EXISTS interactions (
+		user_id INTEGER NOT NULL,
+		listing_id INTEGER NOT NULL,
+		views INTEGER NOT NULL DEFAULT 0,
+		favorited INTEGER NOT NULL DEFAULT 0,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, listing_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_interactions_listing_id ON interactions(listing_id, user_id);
+	CREATE INDEX IF NOT EXISTS idx_interactions_updated_at ON interactions(updated_at);`
+
+// createProcessedEventsTableSQL creates the processed_events table. Events are delivered at
+// least once, so each event is recorded to not count a view twice.
+const createProcessedEventsTableSQL = `
+	CREATE TABLE IF NOT EXISTS processed_events (
+		event_type TEXT NOT NULL,
+		event_id INTEGER NOT NULL,
+		processed_at INTEGER NOT NULL,
+		PRIMARY KEY (event_type, event_id)
+	);`
+
+// NewSQLiteInteractionRepository creates a new instance of sqliteInteractionRepository.
+func NewSQLiteInteractionRepository(db *sql.DB) InteractionRepository {
+	return &sqliteInteractionRepository{db: db}
+}
+
+// RecordView adds a view of a listing by a user. It returns false, recording nothing, if the
+// event was already processed.
+func (r *sqliteInteractionRepository) RecordView(event model.Event, userID, listingID, viewedAt int64) (bool, error) {
+	return r.recordEvent(event, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			`INSERT INTO interactions(user_id, listing_id, views, updated_at) VALUES(?, ?, 1, ?)
+			ON CONFLICT(user_id, listing_id) DO UPDATE SET views = views + 1, updated_at = MAX(updated_at, excluded.updated_at)`,
+			userID, listingID, viewedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record view: %w", err)
+		}
+		return nil
+	})
+}
+
+// RecordFavorite records that a user favorited or unfavorited a listing. It returns false,
+// recording nothing, if the event was already processed.
+func (r *sqliteInteractionRepository) RecordFavorite(event model.Event, userID, listingID int64, favorited bool, at int64) (bool, error) {
+	return r.recordEvent(event, func(tx *sql.Tx) error {
+		_, err := tx.Exec(
+			`INSERT INTO interactions(user_id, listing_id, favorited, updated_at) VALUES(?, ?, ?, ?)
+			ON CONFLICT(user_id, listing_id) DO UPDATE SET favorited = excluded.favorited, updated_at = MAX(updated_at, excluded.updated_at)`,
+			userID, listingID, favorited, at,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record favorite: %w", err)
+		}
+		// Unfavorited listings that were never viewed no longer say anything about the user
+		if _, err := tx.Exec(
+			`DELETE FROM interactions WHERE user_id = ? AND listing_id = ? AND views = 0 AND favorited = 0`,
+			userID, listingID,
+		); err != nil {
+			return fmt.Errorf("failed to record favorite: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteListing forgets every interaction with a listing.
+func (r *sqliteInteractionRepository) DeleteListing(listingID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM interactions WHERE listing_id = ?`, listingID); err != nil {
+		return fmt.Errorf("failed to delete listing interactions: %w", err)
+	}
+	return nil
+}
+
+// DeleteUser forgets every interaction of a user.
+func (r *sqliteInteractionRepository) DeleteUser(userID int64) error {
+	if _, err := r.db.Exec(`DELETE FROM interactions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to delete user interactions: %w", err)
+	}
+	return nil
+}
+
+// MergeUsers hands the interactions of the source user over to the target user, adding up the
+// views of listings both viewed. Merging again is harmless.
+func (r *sqliteInteractionRepository) MergeUsers(sourceUserID, targetUserID int64) error {
+	return r.withTx("merging users", func(tx *sql.Tx) error {
+		// WHERE true disambiguates the upsert from a join, as SQLite requires
+		if _, err := tx.Exec(
+			`INSERT INTO interactions(user_id, listing_id, views, favorited, updated_at)
+			SELECT ?, listing_id, views, favorited, updated_at FROM interactions WHERE user_id = ? AND true
+			ON CONFLICT(user_id, listing_id) DO UPDATE SET views = views + excluded.views,
+				favorited = MAX(favorited, excluded.favorited), updated_at = MAX(updated_at, excluded.updated_at)`,
+			targetUserID, sourceUserID,
+		); err != nil {
+			return fmt.Errorf("failed to merge interactions: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM interactions WHERE user_id = ?`, sourceUserID); err != nil {
+			return fmt.Errorf("failed to merge interactions: %w", err)
+		}
+		return nil
+	})
+}
+
+// SimilarUsersListings returns up to limit listings the user has not interacted with, scored
+// by how much the users who interacted with the same listings as the user interacted with
+// them, best first. Only the user's most recent interactions are considered.
+func (r *sqliteInteractionRepository) SimilarUsersListings(userID int64, limit int) ([]model.Recommendation, error) {
+	rows, err := r.db.Query(
+		`WITH history AS (
+			SELECT listing_id, `+fmt.Sprintf(interactionWeight, "interactions")+` AS weight FROM interactions
+			WHERE user_id = ? ORDER BY updated_at DESC LIMIT ?
+		)
+		SELECT candidate.listing_id, SUM(history.weight * `+fmt.Sprintf(interactionWeight, "candidate")+`) AS score
+		FROM history
+		JOIN interactions peer ON peer.listing_id = history.listing_id AND peer.user_id != ?
+		JOIN interactions candidate ON candidate.user_id = peer.user_id
+		WHERE candidate.listing_id NOT IN (SELECT listing_id FROM interactions WHERE user_id = ?)
+		GROUP BY candidate.listing_id
+		ORDER BY score DESC, candidate.listing_id DESC
+		LIMIT ?`,
+		userID, maxHistory, userID, userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query similar users' listings: %w", err)
+	}
+	return collectRecommendations(rows, model.ReasonSimilarUsers)
+}
+
+// PopularListings returns up to limit listings the user has not interacted with, scored by
+// their interactions since the given time, most popular first.
+func (r *sqliteInteractionRepository) PopularListings(userID, since int64, limit int) ([]model.Recommendation, error) {
+	rows, err := r.db.Query(
+		`SELECT listing_id, SUM(`+fmt.Sprintf(interactionWeight, "interactions")+`) AS score FROM interactions
+		WHERE updated_at >= ? AND listing_id NOT IN (SELECT listing_id FROM interactions WHERE user_id = ?)
+		GROUP BY listing_id
+		ORDER BY score DESC, listing_id DESC
+		LIMIT ?`,
+		since, userID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query popular listings: %w", err)
+	}
+	return collectRecommendations(rows, model.ReasonPopular)
+}
+
+// recordEvent marks an event as processed and runs fn, in a single transaction. It returns
+// false, running nothing, if the event was already processed.
+func (r *sqliteInteractionRepository) recordEvent(event model.Event, fn func(tx *sql.Tx) error) (bool, error) {
+	recorded := false
+	err := r.withTx("recording event", func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`INSERT OR IGNORE INTO processed_events(event_type, event_id, processed_at) VALUES(?, ?, ?)`,
+			event.Type, event.ID, event.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record event: %w", err)
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to record event: %w", err)
+		}
+		if affected == 0 {
+			return nil
+		}
+		recorded = true
+		return fn(tx)
+	})
+	return recorded && err == nil, err
+}
+
+// withTx runs fn in a transaction, committed if fn succeeds and rolled back otherwise. what
+// describes the operation in error messages.
+func (r *sqliteInteractionRepository) withTx(what string, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", what, err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for %s: %w", what, err)
+	}
+	return nil
+}
+
+// scanRecommendation reads a recommendation selected as listing_id, score.
+func scanRecommendation(row rowScanner, rec *model.Recommendation) error {
+	return row.Scan(&rec.ListingID, &rec.Score)
+}
+
+// collectRecommendations reads every recommendation from rows, all for the given reason, and
+// closes them.
+func collectRecommendations(rows *sql.Rows, reason string) ([]model.Recommendation, error) {
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	recommendations := []model.Recommendation{}
+	for rows.Next() {
+		rec := model.Recommendation{Reason: reason}
+		if err := scanRecommendation(rows, &rec); err != nil {
+			return nil, fmt.Errorf("failed to scan recommendation: %w", err)
+		}
+		recommendations = append(recommendations, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recommendations: %w", err)
+	}
+	return recommendations, nil
+}
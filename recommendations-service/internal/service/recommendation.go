@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"apperrors"
+	"recommendations-service/internal/model"
+	"recommendations-service/internal/repository"
+)
+
+const (
+	// DefaultLimit is the number of recommendations returned when none is requested.
+	DefaultLimit = 10
+	// MaxLimit is the maximum number of recommendations returned at once.
+	MaxLimit = 50
+)
+
+// Events the recommendations are built from.
+const (
+	eventListingViewed      = "listing.viewed"
+	eventListingFavorited   = "listing.favorited"
+	eventListingUnfavorited = "listing.unfavorited"
+	eventListingDeleted     = "listing.deleted"
+	eventListingArchived    = "listing.archived"
+	eventUserDeleted        = "user.deleted"
+	eventUserMerged         = "user.merged"
+)
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus.
+var ConsumedEvents = []string{
+	eventListingViewed, eventListingFavorited, eventListingUnfavorited, eventListingDeleted,
+	eventListingArchived, eventUserDeleted, eventUserMerged,
+}
+
+// Errors returned by RecommendationService.
+var (
+	// ErrInvalidQuery is returned for recommendation requests that cannot be served.
+	ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+	// ErrInvalidPayload is returned for events whose payload cannot be handled.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+)
+
+// RecommendationService recommends listings to users from the views and favorites of every
+// user, as reported by the Listing Service's events.
+type RecommendationService struct {
+	repo          repository.InteractionRepository
+	popularWindow time.Duration
+}
+
+// NewRecommendationService creates a new instance of RecommendationService. Popular listings
+// are ranked by their interactions over the last popularWindow.
+func NewRecommendationService(repo repository.InteractionRepository, popularWindow time.Duration) *RecommendationService {
+	return &RecommendationService{repo: repo, popularWindow: popularWindow}
+}
+
+// Recommend returns up to limit listings for a user, best first: listings that users with
+// the same interests viewed or favorited, then popular listings for users with too little
+// history. Listings the user already viewed or favorited are never recommended.
+func (s *RecommendationService) Recommend(userID int64, limit int) ([]model.Recommendation, error) {
+	if limit > MaxLimit {
+		return nil, fmt.Errorf("%w: limit must be at most %d", ErrInvalidQuery, MaxLimit)
+	}
+
+	recommendations, err := s.repo.SimilarUsersListings(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(recommendations) == limit {
+		return recommendations, nil
+	}
+
+	// Popular listings may have been recommended already, so ask for enough to fill in anyway
+	since := time.Now().Add(-s.popularWindow).UnixMicro()
+	popular, err := s.repo.PopularListings(userID, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	recommended := make(map[int64]bool, len(recommendations))
+	for _, rec := range recommendations {
+		recommended[rec.ListingID] = true
+	}
+	for _, rec := range popular {
+		if len(recommendations) == limit {
+			break
+		}
+		if !recommended[rec.ListingID] {
+			recommendations = append(recommendations, rec)
+		}
+	}
+	return recommendations, nil
+}
+
+// HandleEvent records listing views and favorites, forgets deleted and archived listings and
+// deleted users, and moves the history of merged users over to the user they were merged
+// into. It returns false for events of other types. Events are delivered at least once, and
+// handling one again is harmless.
+func (s *RecommendationService) HandleEvent(event model.Event) (bool, error) {
+	var p struct {
+		ListingID     int64 `json:"listing_id"`
+		UserID        int64 `json:"user_id"`
+		SourceUserID  int64 `json:"source_user_id"`
+		TargetUserID  int64 `json:"target_user_id"`
+		ViewedAt      int64 `json:"viewed_at"`
+		FavoritedAt   int64 `json:"favorited_at"`
+		UnfavoritedAt int64 `json:"unfavorited_at"`
+	}
+	switch event.Type {
+	case eventListingViewed:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		if _, err := s.repo.RecordView(event, p.UserID, p.ListingID, eventTime(event, p.ViewedAt)); err != nil {
+			return false, err
+		}
+	case eventListingFavorited, eventListingUnfavorited:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		favorited := event.Type == eventListingFavorited
+		at := eventTime(event, p.FavoritedAt)
+		if !favorited {
+			at = eventTime(event, p.UnfavoritedAt)
+		}
+		if _, err := s.repo.RecordFavorite(event, p.UserID, p.ListingID, favorited, at); err != nil {
+			return false, err
+		}
+	case eventListingDeleted, eventListingArchived:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		if err := s.repo.DeleteListing(p.ListingID); err != nil {
+			return false, err
+		}
+	case eventUserDeleted:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		if err := s.repo.DeleteUser(p.UserID); err != nil {
+			return false, err
+		}
+	case eventUserMerged:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.SourceUserID <= 0 || p.TargetUserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		if err := s.repo.MergeUsers(p.SourceUserID, p.TargetUserID); err != nil {
+			return false, err
+		}
+		log.Printf("Merged interactions of user %d into user %d", p.SourceUserID, p.TargetUserID)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// eventTime returns the time an interaction happened, falling back to the time its event was
+// created for payloads without one.
+func eventTime(event model.Event, at int64) int64 {
+	if at > 0 {
+		return at
+	}
+	return event.CreatedAt
+}
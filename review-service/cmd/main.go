@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bus"
+	"contracts/client"
+	"httpmiddleware"
+	"registry"
+	"review-service/internal/api"
+	"review-service/internal/handler"
+	"review-service/internal/repository"
+	"review-service/internal/service"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "reviews.db"
+
+func main() {
+	// Define command-line flags for port and service URLs
+	port := flag.Int("port", 9400, "The port number to run the Review Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding reviews")
+	userServiceURL := flag.String("user-service-url", "http://localhost:7000", "URL of the User Service")
+	listingServiceURL := flag.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service")
+	internalKey := flag.String("internal-key", "", "keyID:secret used to HMAC-sign requests to internal services (requests are unsigned when empty)")
+	registryURL := flag.String("registry-url", "", "URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)")
+	advertiseURL := flag.String("advertise-url", "", "URL the registry gives out for this instance (default http://<hostname>:<port>)")
+	eventBus := flag.String("event-bus", "", "URL of the message bus to consume domain events from: nats://host:port or kafka://host:port (events are only received on POST /events when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	// Initialize the SQLite database
+	// By default this will create 'reviews.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize a custom HTTP client with timeouts for inter-service communication
+	httpClient := client.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Sign internal requests for services that verify signatures
+	if *internalKey != "" {
+		keyID, secret, ok := strings.Cut(*internalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			log.Fatalf("Invalid -internal-key, expected keyID:secret")
+		}
+		httpClient = client.WithRequestSigning(httpClient, keyID, secret)
+	}
+
+	// Initialize repository, service, and handler layers
+	reviewRepo := repository.NewSQLiteReviewRepository(db)
+	reviewService := service.NewReviewService(
+		reviewRepo,
+		client.NewListingServiceClient(httpClient, *listingServiceURL),
+		client.NewUserServiceClient(httpClient, *userServiceURL),
+	)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+
+	// Consume domain events from the event bus as well, when there is one
+	if *eventBus != "" {
+		events, err := bus.Open(*eventBus)
+		if err != nil {
+			log.Fatalf("Failed to open event bus: %v", err)
+		}
+		defer events.Close()
+		if _, err := bus.SubscribeTopics(context.Background(), events, service.ConsumedEvents, "review-service", reviewHandler.ConsumeMessage); err != nil {
+			log.Fatalf("Failed to subscribe to the event bus: %v", err)
+		}
+		log.Printf("Consuming %v from %s", service.ConsumedEvents, *eventBus)
+	}
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Review Service API routes
+	// POST /reviews, DELETE /reviews/{id}, GET /listings/{id}/reviews, /users/{id}/reviews,
+	// /ratings/listings and /ratings/users: Review listings and users, delete reviews (author
+	// only), and get pages of reviews and ratings by ID, as specified by api/openapi.yaml
+	api.RegisterReviewsRoutes(r, reviewHandler)
+	// POST /events: Consume a domain event from the user or listing service's relay
+	r.HandleFunc("/events", reviewHandler.ConsumeEvent).Methods("POST")
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("review-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Register with the registry, so the gateway reaches this instance as registry://review-service
+	if *registryURL != "" {
+		if err := registry.Announce(context.Background(), *registryURL, "review-service", *advertiseURL, *port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
+	// Start the HTTP server
+	log.Printf("Review Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"apperrors"
+	"review-service/internal/api"
+	"review-service/internal/model"
+	"review-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the body of requests.
+const maxBodyBytes = 64 << 10
+
+// APIResponse is the response structure for requests that return no data.
+type APIResponse struct {
+	Result bool   `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReviewResponse is the response structure for created reviews.
+type ReviewResponse struct {
+	Result bool          `json:"result"`
+	Review *model.Review `json:"review,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// ReviewsResponse is the response structure for the reviews of a listing or user.
+type ReviewsResponse struct {
+	Result  bool           `json:"result"`
+	Reviews []model.Review `json:"reviews"`
+	Rating  *model.Rating  `json:"rating,omitempty"` // Over all the reviews, not only this page
+	Error   string         `json:"error,omitempty"`
+}
+
+// RatingsResponse is the response structure for rating lookups.
+type RatingsResponse struct {
+	Result  bool                   `json:"result"`
+	Ratings map[int64]model.Rating `json:"ratings"` // By listing or user ID
+	Error   string                 `json:"error,omitempty"`
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReviewHandler handles HTTP requests related to reviews.
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+}
+
+// ReviewHandler implements the operations of the spec's reviews tag.
+var _ api.ReviewsHandler = (*ReviewHandler)(nil)
+
+// NewReviewHandler creates a new instance of ReviewHandler.
+func NewReviewHandler(reviewService *service.ReviewService) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService}
+}
+
+// CreateReview handles POST /reviews requests.
+// It expects form fields reviewer_id, rating, an optional comment, and either listing_id or
+// user_id, the listing or user reviewed.
+func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReviewResponse{Result: false, Error: "Invalid request body"})
+		return
+	}
+
+	subjectType, subjectValue := model.SubjectListing, r.PostFormValue("listing_id")
+	if userValue := r.PostFormValue("user_id"); userValue != "" {
+		if subjectValue != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ReviewResponse{Result: false, Error: "Only one of listing_id and user_id can be given"})
+			return
+		}
+		subjectType, subjectValue = model.SubjectUser, userValue
+	}
+	subjectID, err := strconv.ParseInt(subjectValue, 10, 64)
+	if err != nil && subjectValue != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReviewResponse{Result: false, Error: "Invalid " + subjectType + "_id format"})
+		return
+	}
+	reviewerID, err := strconv.ParseInt(r.PostFormValue("reviewer_id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReviewResponse{Result: false, Error: "Invalid reviewer_id format"})
+		return
+	}
+	rating, err := strconv.Atoi(r.PostFormValue("rating"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReviewResponse{Result: false, Error: "Invalid rating format"})
+		return
+	}
+
+	review, err := h.reviewService.CreateReview(subjectType, subjectID, reviewerID, rating, r.PostFormValue("comment"))
+	if err != nil {
+		writeError(w, err, "creating review")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ReviewResponse{Result: true, Review: review})
+}
+
+// ListListingReviews handles GET /listings/{id}/reviews requests.
+func (h *ReviewHandler) ListListingReviews(w http.ResponseWriter, r *http.Request) {
+	h.listReviews(w, r, model.SubjectListing)
+}
+
+// ListUserReviews handles GET /users/{id}/reviews requests.
+func (h *ReviewHandler) ListUserReviews(w http.ResponseWriter, r *http.Request) {
+	h.listReviews(w, r, model.SubjectUser)
+}
+
+// listReviews responds with a page of the reviews of the listing or user in the URL path,
+// paged with page_num and page_size (default 1 and 10), and its rating.
+func (h *ReviewHandler) listReviews(w http.ResponseWriter, r *http.Request, subjectType string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	subjectID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || subjectID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ReviewsResponse{Result: false, Error: "Invalid " + subjectType + " ID format"})
+		return
+	}
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	reviews, rating, err := h.reviewService.ListReviews(subjectType, subjectID, pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "listing reviews of %s %d", subjectType, subjectID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(ReviewsResponse{Result: true, Reviews: reviews, Rating: &rating})
+}
+
+// GetListingRatings handles GET /ratings/listings requests.
+func (h *ReviewHandler) GetListingRatings(w http.ResponseWriter, r *http.Request) {
+	h.getRatings(w, r, model.SubjectListing)
+}
+
+// GetUserRatings handles GET /ratings/users requests.
+func (h *ReviewHandler) GetUserRatings(w http.ResponseWriter, r *http.Request) {
+	h.getRatings(w, r, model.SubjectUser)
+}
+
+// getRatings responds with the ratings of the listings or users whose comma-separated IDs are
+// given as ids, so a page of listings can be rated in a single request.
+func (h *ReviewHandler) getRatings(w http.ResponseWriter, r *http.Request, subjectType string) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var ids []int64
+	if value := r.URL.Query().Get("ids"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(field, 10, 64)
+			if err != nil || id < 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(RatingsResponse{Result: false, Error: "Invalid ids: must be a comma-separated list of " + subjectType + " IDs"})
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	ratings, err := h.reviewService.Ratings(subjectType, ids)
+	if err != nil {
+		writeError(w, err, "getting %s ratings", subjectType)
+		return
+	}
+
+	json.NewEncoder(w).Encode(RatingsResponse{Result: true, Ratings: ratings})
+}
+
+// DeleteReview handles DELETE /reviews/{id} requests.
+// Only the review's author, given as the reviewer_id query parameter, may delete it.
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid review ID format"})
+		return
+	}
+	reviewerID, err := strconv.ParseInt(r.URL.Query().Get("reviewer_id"), 10, 64)
+	if err != nil || reviewerID < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid reviewer_id format"})
+		return
+	}
+
+	if err := h.reviewService.DeleteReview(id, reviewerID); err != nil {
+		writeError(w, err, "deleting review %d", id)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true})
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the user service's
+// and the listing service's event relays. Events of other types are acknowledged without effect.
+// Failures answer 500, so the relays retry the event.
+func (h *ReviewHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.reviewService.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "handling event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// writeError answers an error of ReviewService with the status of its code, logging internal
+// errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
@@ -0,0 +1,11 @@
+package model
+
+import "contracts"
+
+// These conversions break the build when the Review Service's representations and the
+// contracts its clients decode them into drift apart. They compare field names and types; the
+// JSON names are kept in sync by hand.
+var (
+	_ = contracts.Review(Review{})
+	_ = contracts.Rating(Rating{})
+)
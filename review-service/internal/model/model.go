@@ -0,0 +1,41 @@
+package model
+
+import "encoding/json"
+
+// Kinds of things that can be reviewed.
+const (
+	SubjectListing = "listing"
+	SubjectUser    = "user"
+)
+
+// Bounds of review ratings, in stars.
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is a user's star rating of a listing or of another user, with an optional comment.
+// A user reviews each listing or user at most once.
+type Review struct {
+	ID          int64  `json:"id"`
+	SubjectType string `json:"subject_type"` // listing or user
+	SubjectID   int64  `json:"subject_id"`
+	ReviewerID  int64  `json:"reviewer_id"`
+	Rating      int    `json:"rating"` // From MinRating to MaxRating stars
+	Comment     string `json:"comment"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+// Rating aggregates the reviews of a listing or user.
+type Rating struct {
+	Average *float64 `json:"average"` // Average rating, rounded to 2 decimals; nil without reviews
+	Count   int64    `json:"count"`
+}
+
+// Event is a domain event as POSTed by the user service's and the listing service's relays.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
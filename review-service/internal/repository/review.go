@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"apperrors"
+	"review-service/internal/model"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Errors returned by ReviewRepository.
+var (
+	// ErrReviewNotFound is returned when no review has the requested ID.
+	ErrReviewNotFound = apperrors.New(apperrors.NotFound, "review not found")
+	// ErrDuplicateReview is returned when the reviewer already reviewed the subject.
+	ErrDuplicateReview = apperrors.New(apperrors.Conflict, "subject already reviewed by this reviewer")
+)
+
+// ReviewRepository defines the interface for review storage.
+type ReviewRepository interface {
+	Create(review *model.Review) error
+	GetByID(id int64) (*model.Review, error)
+	ListBySubject(subjectType string, subjectID int64, page, pageSize int) ([]model.Review, error)
+	Ratings(subjectType string, subjectIDs []int64) (map[int64]model.Rating, error)
+	Delete(id int64) error
+	DeleteBySubject(subjectType string, subjectID int64) (int64, error)
+	DeleteByUser(userID int64) (int64, error)
+}
+
+// sqliteReviewRepository implements ReviewRepository for SQLite database.
+type sqliteReviewRepository struct {
+	db *sql.DB
+}
+
+// createReviewsTableSQL creates the reviews table. Ratings are aggregated from it on read, by
+// subject, which its unique index covers.
+const createReviewsTableSQL = `
+	CREATE TABLE IF NOT EXISTS reviews (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		subject_type TEXT NOT NULL,
+		subject_id INTEGER NOT NULL,
+		reviewer_id INTEGER NOT NULL,
+		rating INTEGER NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		UNIQUE (subject_type, subject_id, reviewer_id)
+	);
+	CREATE INDEX IF NOT EXISTS idx_reviews_reviewer_id ON reviews(reviewer_id);`
+
+// reviewColumns lists the columns selected for a review, in the order expected by scanReview.
+const reviewColumns = `id, subject_type, subject_id, reviewer_id, rating, comment, created_at`
+
+// scanReview reads a review selected with reviewColumns.
+func scanReview(row rowScanner, r *model.Review) error {
+	return row.Scan(&r.ID, &r.SubjectType, &r.SubjectID, &r.ReviewerID, &r.Rating, &r.Comment, &r.CreatedAt)
+}
+
+// NewSQLiteReviewRepository creates a new instance of sqliteReviewRepository.
+func NewSQLiteReviewRepository(db *sql.DB) ReviewRepository {
+	return &sqliteReviewRepository{db: db}
+}
+
+// Create inserts a review, setting its ID. It returns ErrDuplicateReview if the reviewer
+// already reviewed the subject.
+func (r *sqliteReviewRepository) Create(review *model.Review) error {
+	result, err := r.db.Exec(
+		`INSERT INTO reviews(subject_type, subject_id, reviewer_id, rating, comment, created_at) VALUES(?, ?, ?, ?, ?, ?)`,
+		review.SubjectType, review.SubjectID, review.ReviewerID, review.Rating, review.Comment, review.CreatedAt,
+	)
+	if err != nil {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return ErrDuplicateReview
+		}
+		return fmt.Errorf("failed to insert review: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get review ID: %w", err)
+	}
+	review.ID = id
+	return nil
+}
+
+// GetByID returns the review with the given ID.
+func (r *sqliteReviewRepository) GetByID(id int64) (*model.Review, error) {
+	review := &model.Review{}
+	err := scanReview(r.db.QueryRow(`SELECT `+reviewColumns+` FROM reviews WHERE id = ?`, id), review)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrReviewNotFound
+		}
+		return nil, fmt.Errorf("failed to query review: %w", err)
+	}
+	return review, nil
+}
+
+// ListBySubject returns a page of the reviews of a listing or user, most recent first.
+func (r *sqliteReviewRepository) ListBySubject(subjectType string, subjectID int64, page, pageSize int) ([]model.Review, error) {
+	rows, err := r.db.Query(
+		`SELECT `+reviewColumns+` FROM reviews WHERE subject_type = ? AND subject_id = ?
+		ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`,
+		subjectType, subjectID, pageSize, (page-1)*pageSize,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reviews: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	reviews := []model.Review{}
+	for rows.Next() {
+		var review model.Review
+		if err := scanReview(rows, &review); err != nil {
+			return nil, fmt.Errorf("failed to scan review: %w", err)
+		}
+		reviews = append(reviews, review)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// Ratings returns the ratings of the given listings or users, by ID. Subjects without reviews
+// are included, with a nil average and a zero count.
+func (r *sqliteReviewRepository) Ratings(subjectType string, subjectIDs []int64) (map[int64]model.Rating, error) {
+	ratings := make(map[int64]model.Rating, len(subjectIDs))
+	if len(subjectIDs) == 0 {
+		return ratings, nil
+	}
+	args := []any{subjectType}
+	for _, id := range subjectIDs {
+		ratings[id] = model.Rating{}
+		args = append(args, id)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT subject_id, ROUND(AVG(rating), 2), COUNT(*) FROM reviews
+		WHERE subject_type = ? AND subject_id IN (?`+strings.Repeat(", ?", len(subjectIDs)-1)+`)
+		GROUP BY subject_id`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ratings: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var id int64
+		var average float64
+		var rating model.Rating
+		if err := rows.Scan(&id, &average, &rating.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan rating: %w", err)
+		}
+		rating.Average = &average
+		ratings[id] = rating
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ratings: %w", err)
+	}
+	return ratings, nil
+}
+
+// Delete deletes the review with the given ID.
+func (r *sqliteReviewRepository) Delete(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM reviews WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete review: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete review: %w", err)
+	}
+	if affected == 0 {
+		return ErrReviewNotFound
+	}
+	return nil
+}
+
+// DeleteBySubject deletes every review of a listing or user, and returns how many there were.
+func (r *sqliteReviewRepository) DeleteBySubject(subjectType string, subjectID int64) (int64, error) {
+	return r.deleteWhere(`subject_type = ? AND subject_id = ?`, subjectType, subjectID)
+}
+
+// DeleteByUser deletes every review written by or about a user, and returns how many there were.
+func (r *sqliteReviewRepository) DeleteByUser(userID int64) (int64, error) {
+	return r.deleteWhere(`reviewer_id = ? OR (subject_type = ? AND subject_id = ?)`, userID, model.SubjectUser, userID)
+}
+
+// deleteWhere deletes the reviews matching condition, and returns how many there were.
+func (r *sqliteReviewRepository) deleteWhere(condition string, args ...any) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM reviews WHERE `+condition, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete reviews: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete reviews: %w", err)
+	}
+	return affected, nil
+}
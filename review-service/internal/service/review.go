@@ -0,0 +1,213 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"apperrors"
+	"contracts"
+	"contracts/client"
+	"review-service/internal/model"
+	"review-service/internal/repository"
+)
+
+const (
+	// maxCommentLength is the maximum length, in characters, of review comments.
+	maxCommentLength = 2000
+	// MaxPageSize is the maximum number of reviews per page.
+	MaxPageSize = 100
+	// MaxRatingBatchSize is the maximum number of listings or users whose ratings are looked
+	// up at once, matching the Listing Service's page size limit.
+	MaxRatingBatchSize = 100
+)
+
+// Events after which reviews are deleted.
+const (
+	eventListingDeleted = "listing.deleted"
+	eventUserDeleted    = "user.deleted"
+)
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus.
+var ConsumedEvents = []string{eventListingDeleted, eventUserDeleted}
+
+// Errors returned by ReviewService.
+var (
+	// ErrInvalidReview is returned for reviews that fail validation.
+	ErrInvalidReview = apperrors.New(apperrors.Validation, "invalid review")
+	// ErrInvalidQuery is returned for rating lookups that cannot be run.
+	ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+	// ErrSubjectNotFound is returned when the reviewed listing or user does not exist, or the
+	// listing is not publicly visible.
+	ErrSubjectNotFound = apperrors.New(apperrors.NotFound, "review subject not found")
+	// ErrForbidden is returned when suspended users write reviews, or users delete reviews
+	// they did not write.
+	ErrForbidden = apperrors.New(apperrors.Forbidden, "forbidden")
+	// ErrInvalidPayload is returned for events whose payload cannot be handled.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+	// ErrReviewNotFound is returned when no review has the requested ID.
+	ErrReviewNotFound = repository.ErrReviewNotFound
+	// ErrDuplicateReview is returned when the reviewer already reviewed the subject.
+	ErrDuplicateReview = repository.ErrDuplicateReview
+)
+
+// ReviewService manages the reviews of listings and users, checking reviewers and subjects
+// against the User Service and the Listing Service.
+type ReviewService struct {
+	repo     repository.ReviewRepository
+	listings *client.ListingServiceClient
+	users    *client.UserServiceClient
+}
+
+// NewReviewService creates a new instance of ReviewService.
+func NewReviewService(repo repository.ReviewRepository, listings *client.ListingServiceClient, users *client.UserServiceClient) *ReviewService {
+	return &ReviewService{repo: repo, listings: listings, users: users}
+}
+
+// CreateReview records a reviewer's rating of a listing or user. Reviewers must exist and not
+// be suspended, and cannot review themselves or their own listings. Listings must be publicly
+// visible.
+func (s *ReviewService) CreateReview(subjectType string, subjectID, reviewerID int64, rating int, comment string) (*model.Review, error) {
+	comment = strings.TrimSpace(comment)
+	var problems []string
+	if subjectID < 1 {
+		problems = append(problems, "a listing_id or user_id is required")
+	}
+	if reviewerID < 1 {
+		problems = append(problems, "reviewer_id must be a positive integer")
+	}
+	if rating < model.MinRating || rating > model.MaxRating {
+		problems = append(problems, fmt.Sprintf("rating must be between %d and %d", model.MinRating, model.MaxRating))
+	}
+	if utf8.RuneCountInString(comment) > maxCommentLength {
+		problems = append(problems, fmt.Sprintf("comment must be at most %d characters long", maxCommentLength))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidReview, strings.Join(problems, "; "))
+	}
+
+	reviewer, err := s.users.GetUserByID(reviewerID)
+	if err != nil {
+		return nil, err
+	}
+	if reviewer == nil {
+		return nil, fmt.Errorf("%w: reviewer %d not found", ErrInvalidReview, reviewerID)
+	}
+	if reviewer.Status == contracts.UserStatusSuspended {
+		return nil, fmt.Errorf("%w: reviewer %d is suspended", ErrForbidden, reviewerID)
+	}
+
+	switch subjectType {
+	case model.SubjectListing:
+		listing, err := s.listings.GetListing(subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if listing == nil {
+			return nil, ErrSubjectNotFound
+		}
+		if listing.UserID == reviewerID {
+			return nil, fmt.Errorf("%w: users cannot review their own listings", ErrInvalidReview)
+		}
+	case model.SubjectUser:
+		if subjectID == reviewerID {
+			return nil, fmt.Errorf("%w: users cannot review themselves", ErrInvalidReview)
+		}
+		user, err := s.users.GetUserByID(subjectID)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, ErrSubjectNotFound
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown subject type %q", ErrInvalidReview, subjectType)
+	}
+
+	review := &model.Review{
+		SubjectType: subjectType,
+		SubjectID:   subjectID,
+		ReviewerID:  reviewerID,
+		Rating:      rating,
+		Comment:     comment,
+		CreatedAt:   time.Now().UnixMicro(),
+	}
+	if err := s.repo.Create(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// ListReviews returns a page of the reviews of a listing or user, most recent first, along
+// with its rating over all its reviews.
+func (s *ReviewService) ListReviews(subjectType string, subjectID int64, pageNum, pageSize int) ([]model.Review, model.Rating, error) {
+	if pageSize > MaxPageSize {
+		return nil, model.Rating{}, fmt.Errorf("%w: page_size must be at most %d", ErrInvalidQuery, MaxPageSize)
+	}
+	reviews, err := s.repo.ListBySubject(subjectType, subjectID, pageNum, pageSize)
+	if err != nil {
+		return nil, model.Rating{}, err
+	}
+	ratings, err := s.repo.Ratings(subjectType, []int64{subjectID})
+	if err != nil {
+		return nil, model.Rating{}, err
+	}
+	return reviews, ratings[subjectID], nil
+}
+
+// Ratings returns the ratings of the given listings or users, by ID, including those without
+// reviews.
+func (s *ReviewService) Ratings(subjectType string, subjectIDs []int64) (map[int64]model.Rating, error) {
+	if len(subjectIDs) > MaxRatingBatchSize {
+		return nil, fmt.Errorf("%w: at most %d ids can be looked up at once", ErrInvalidQuery, MaxRatingBatchSize)
+	}
+	return s.repo.Ratings(subjectType, subjectIDs)
+}
+
+// DeleteReview deletes a review written by the reviewer.
+func (s *ReviewService) DeleteReview(id, reviewerID int64) error {
+	review, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if review.ReviewerID != reviewerID {
+		return fmt.Errorf("%w: review %d was written by another user", ErrForbidden, id)
+	}
+	return s.repo.Delete(id)
+}
+
+// HandleEvent deletes the reviews of deleted listings, and the reviews written by and about
+// deleted users. It returns false for events of other types. Events are delivered at least
+// once, and handling one again is harmless.
+func (s *ReviewService) HandleEvent(event model.Event) (bool, error) {
+	var p struct {
+		ListingID int64 `json:"listing_id"`
+		UserID    int64 `json:"user_id"`
+	}
+	switch event.Type {
+	case eventListingDeleted:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		deleted, err := s.repo.DeleteBySubject(model.SubjectListing, p.ListingID)
+		if err != nil {
+			return false, err
+		}
+		log.Printf("Deleted %d reviews of deleted listing %d", deleted, p.ListingID)
+	case eventUserDeleted:
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		deleted, err := s.repo.DeleteByUser(p.UserID)
+		if err != nil {
+			return false, err
+		}
+		log.Printf("Deleted %d reviews by and about deleted user %d", deleted, p.UserID)
+	default:
+		return false, nil
+	}
+	return true, nil
+}
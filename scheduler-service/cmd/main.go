@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	contractsclient "contracts/client"
+	"httpmiddleware"
+	"scheduler-service/internal/api"
+	"scheduler-service/internal/client"
+	"scheduler-service/internal/handler"
+	"scheduler-service/internal/repository"
+	"scheduler-service/internal/service"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "jobs.db"
+
+func main() {
+	// Define command-line flags for port, database and dispatch settings
+	port := flag.Int("port", 9900, "The port number to run the Scheduler Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding jobs")
+	dispatchInterval := flag.Duration("dispatch-interval", time.Second, "How often due jobs are checked")
+	internalKey := flag.String("internal-key", "", "keyID:secret used to HMAC-sign callbacks to internal services (callbacks are unsigned when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it)")
+	flag.Parse()
+
+	if *dispatchInterval <= 0 {
+		log.Fatalf("Invalid -dispatch-interval, expected a positive duration")
+	}
+
+	// Initialize the SQLite database
+	// By default this will create 'jobs.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize a custom HTTP client with timeouts for callbacks
+	httpClient := contractsclient.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Sign callbacks for services that verify signatures
+	if *internalKey != "" {
+		keyID, secret, ok := strings.Cut(*internalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			log.Fatalf("Invalid -internal-key, expected keyID:secret")
+		}
+		httpClient = contractsclient.WithRequestSigning(httpClient, keyID, secret)
+	}
+
+	// Initialize repository, service, and handler layers
+	jobRepo := repository.NewSQLiteJobRepository(db)
+	dispatcher := service.NewDispatcher(jobRepo, client.NewCallbackClient(httpClient), *dispatchInterval)
+	schedulerHandler := handler.NewSchedulerHandler(service.NewSchedulerService(jobRepo, dispatcher))
+
+	// Fire due jobs in the background, including those left running by a restart
+	go dispatcher.Run(context.Background())
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Scheduler Service API routes
+	// POST and GET /jobs, POST /jobs/cancel, GET /jobs/{id} and POST /jobs/{id}/cancel: Schedule
+	// jobs, or reschedule the scheduled job with the same key, list them by the time they are
+	// scheduled to run, get them with the outcome of their attempts, and cancel them by key or
+	// ID, as specified by api/openapi.yaml
+	api.RegisterJobsRoutes(r, schedulerHandler)
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("scheduler-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Start the HTTP server
+	log.Printf("Scheduler Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
@@ -0,0 +1,68 @@
+// Code generated by openapi from scheduler-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// JobsHandler handles the operations of the jobs tag: callbacks POSTed to other services at a given
+// time.
+type JobsHandler interface {
+	// ScheduleJob handles POST /jobs: Schedule a job, or reschedule the scheduled job with the same
+	// key.
+	ScheduleJob(w http.ResponseWriter, r *http.Request)
+
+	// ListJobs handles GET /jobs: Get a page of the jobs, by the time they are scheduled to run.
+	ListJobs(w http.ResponseWriter, r *http.Request)
+
+	// CancelJobByKey handles POST /jobs/cancel: Cancel the active job with a key.
+	CancelJobByKey(w http.ResponseWriter, r *http.Request)
+
+	// GetJob handles GET /jobs/{id}: Get a job and the outcome of its attempts.
+	GetJob(w http.ResponseWriter, r *http.Request)
+
+	// CancelJob handles POST /jobs/{id}/cancel: Cancel a scheduled job. Jobs that already finished
+	// are returned unchanged, so cancellations can be retried.
+	CancelJob(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedJobsHandler answers every operation of JobsHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedJobsHandler struct{}
+
+// ScheduleJob answers POST /jobs with 501 Not Implemented.
+func (UnimplementedJobsHandler) ScheduleJob(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// ListJobs answers GET /jobs with 501 Not Implemented.
+func (UnimplementedJobsHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// CancelJobByKey answers POST /jobs/cancel with 501 Not Implemented.
+func (UnimplementedJobsHandler) CancelJobByKey(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// GetJob answers GET /jobs/{id} with 501 Not Implemented.
+func (UnimplementedJobsHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// CancelJob answers POST /jobs/{id}/cancel with 501 Not Implemented.
+func (UnimplementedJobsHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterJobsRoutes registers the routes of the operations of JobsHandler on r.
+func RegisterJobsRoutes(r *mux.Router, h JobsHandler) {
+	r.HandleFunc("/jobs", h.ScheduleJob).Methods("POST")
+	r.HandleFunc("/jobs", h.ListJobs).Methods("GET")
+	r.HandleFunc("/jobs/cancel", h.CancelJobByKey).Methods("POST")
+	r.HandleFunc("/jobs/{id}", h.GetJob).Methods("GET")
+	r.HandleFunc("/jobs/{id}/cancel", h.CancelJob).Methods("POST")
+}
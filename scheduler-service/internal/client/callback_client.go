@@ -0,0 +1,71 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"scheduler-service/internal/model"
+)
+
+// CallbackError is returned when a callback answers with a status other than 2xx.
+type CallbackError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *CallbackError) Error() string {
+	return "callback returned non-OK status: " + e.Status
+}
+
+// Permanent reports whether retrying the callback would not help: it rejected the request
+// with a client error other than a timeout or rate limit.
+func (e *CallbackError) Permanent() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 &&
+		e.StatusCode != http.StatusRequestTimeout && e.StatusCode != http.StatusTooManyRequests
+}
+
+// CallbackClient fires jobs by POSTing them to their callback URL.
+type CallbackClient struct {
+	httpClient *http.Client
+}
+
+// NewCallbackClient creates a new CallbackClient.
+func NewCallbackClient(httpClient *http.Client) *CallbackClient {
+	return &CallbackClient{httpClient: httpClient}
+}
+
+// Fire POSTs a job's payload as JSON to its URL, wrapped in a domain event when the job has an
+// event type. The X-Scheduler-Job-Id and X-Scheduler-Attempt headers let the callback tell
+// repeated deliveries of a job apart, as a job is fired again if the acknowledgement is lost.
+func (c *CallbackClient) Fire(ctx context.Context, job model.Job, attempt int) error {
+	body := []byte(job.Payload)
+	if job.EventType != nil {
+		var err error
+		body, err = json.Marshal(model.Event{ID: job.ID, Type: *job.EventType, Payload: job.Payload, CreatedAt: job.RunAt})
+		if err != nil {
+			return fmt.Errorf("failed to encode event: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Scheduler-Job-Id", strconv.FormatInt(job.ID, 10))
+	req.Header.Set("X-Scheduler-Attempt", strconv.Itoa(attempt))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send callback: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &CallbackError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return nil
+}
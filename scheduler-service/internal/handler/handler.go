@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"apperrors"
+	"contracts"
+	"scheduler-service/internal/api"
+	"scheduler-service/internal/model"
+	"scheduler-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// maxBodyBytes bounds the body of requests, and so the payload of jobs.
+const maxBodyBytes = 64 << 10
+
+// JobResponse is the response structure for single jobs.
+type JobResponse struct {
+	Result bool       `json:"result"`
+	Job    *model.Job `json:"job,omitempty"`
+	Error  string     `json:"error,omitempty"`
+}
+
+// JobsResponse is the response structure for pages of jobs.
+type JobsResponse struct {
+	Result bool        `json:"result"`
+	Jobs   []model.Job `json:"jobs"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// SchedulerHandler handles HTTP requests related to jobs.
+type SchedulerHandler struct {
+	schedulerService *service.SchedulerService
+}
+
+// SchedulerHandler implements the operations of the spec's jobs tag.
+var _ api.JobsHandler = (*SchedulerHandler)(nil)
+
+// NewSchedulerHandler creates a new instance of SchedulerHandler.
+func NewSchedulerHandler(schedulerService *service.SchedulerService) *SchedulerHandler {
+	return &SchedulerHandler{schedulerService: schedulerService}
+}
+
+// ScheduleJob handles POST /jobs requests.
+// It accepts a JSON body with the job's url, run_at and optional key, event_type, payload and
+// max_attempts. It answers 201 Created for a new job, and 200 OK when the key of a scheduled
+// job was registered again and that job rescheduled.
+func (h *SchedulerHandler) ScheduleJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var input contracts.ScheduleJobRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(JobResponse{Result: false, Error: "Invalid JSON request body"})
+		return
+	}
+
+	job, created, err := h.schedulerService.Schedule(service.ScheduleInput{
+		Key:         strings.TrimSpace(input.Key),
+		URL:         strings.TrimSpace(input.URL),
+		EventType:   input.EventType,
+		Payload:     input.Payload,
+		RunAt:       input.RunAt,
+		MaxAttempts: input.MaxAttempts,
+	})
+	if err != nil {
+		writeError(w, err, "scheduling job")
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(JobResponse{Result: true, Job: job})
+}
+
+// ListJobs handles GET /jobs requests.
+// It returns a page of jobs by the time they were scheduled to run, paged with page_num and
+// page_size (default 1 and 10, at most 100 per page), optionally only those with the given
+// status or key.
+func (h *SchedulerHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	jobs, err := h.schedulerService.List(r.URL.Query().Get("status"), r.URL.Query().Get("key"), pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "listing jobs")
+		return
+	}
+
+	json.NewEncoder(w).Encode(JobsResponse{Result: true, Jobs: jobs})
+}
+
+// GetJob handles GET /jobs/{id} requests.
+func (h *SchedulerHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.schedulerService.Get(id)
+	writeJob(w, job, err, "getting")
+}
+
+// CancelJob handles POST /jobs/{id}/cancel requests.
+func (h *SchedulerHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.schedulerService.Cancel(id)
+	writeJob(w, job, err, "cancelling")
+}
+
+// CancelJobByKey handles POST /jobs/cancel requests.
+// It expects the form field key, and cancels the active job with that key.
+func (h *SchedulerHandler) CancelJobByKey(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	key := strings.TrimSpace(r.PostFormValue("key"))
+	if key == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(JobResponse{Result: false, Error: "Key is required"})
+		return
+	}
+	job, err := h.schedulerService.CancelByKey(key)
+	writeJob(w, job, err, "cancelling")
+}
+
+// parseJobID reads the job ID in the URL path, writing a 400 response and returning false if
+// it is invalid.
+func parseJobID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id < 1 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(JobResponse{Result: false, Error: "Invalid job ID format"})
+		return 0, false
+	}
+	return id, true
+}
+
+// writeJob writes the response of a request for a single job. action describes the request
+// in logs.
+func writeJob(w http.ResponseWriter, job *model.Job, err error, action string) {
+	if err != nil {
+		writeError(w, err, action+" job")
+		return
+	}
+	json.NewEncoder(w).Encode(JobResponse{Result: true, Job: job})
+}
+
+// writeError answers an error of SchedulerService with the status of its code, logging
+// internal errors. action describes the request in logs.
+func writeError(w http.ResponseWriter, err error, action string) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error %s: %v", action, err)
+	}
+	apperrors.Write(w, err)
+}
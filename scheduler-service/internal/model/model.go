@@ -0,0 +1,47 @@
+package model
+
+import "encoding/json"
+
+// Statuses of a job. Scheduled and running jobs are active: the dispatcher fires them when
+// due, retrying failed callbacks until they succeed or the job runs out of attempts.
+const (
+	// StatusScheduled jobs wait for their next attempt to be due.
+	StatusScheduled = "scheduled"
+	// StatusRunning jobs are being fired. A job left running by a restart is fired again
+	// once its lease lapses.
+	StatusRunning = "running"
+	// StatusSucceeded jobs were acknowledged by their callback.
+	StatusSucceeded = "succeeded"
+	// StatusFailed jobs were rejected by their callback, or ran out of attempts.
+	StatusFailed = "failed"
+	// StatusCancelled jobs were cancelled before they succeeded.
+	StatusCancelled = "cancelled"
+)
+
+// Job is a delayed action registered by another service: a POST of its payload to a
+// callback URL at RunAt. When EventType is set, the payload is wrapped in a domain event, so
+// the callback can be a service's POST /events subscriber endpoint.
+type Job struct {
+	ID            int64           `json:"id"`
+	Key           *string         `json:"key"`        // Identifies the job to its registrant; unique among active jobs
+	URL           string          `json:"url"`        // The callback
+	EventType     *string         `json:"event_type"` // nil for plain callbacks
+	Payload       json.RawMessage `json:"payload"`
+	RunAt         int64           `json:"run_at"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	MaxAttempts   int             `json:"max_attempts"`
+	NextAttemptAt int64           `json:"next_attempt_at"` // When the job is fired next; the end of its lease while running, 0 once finished
+	LastError     *string         `json:"last_error"`      // Why the last attempt failed
+	CreatedAt     int64           `json:"created_at"`
+	UpdatedAt     int64           `json:"updated_at"`
+	FinishedAt    *int64          `json:"finished_at"` // When the job succeeded, failed or was cancelled
+}
+
+// Event is a domain event, as POSTed to the callbacks of jobs with an event type.
+type Event struct {
+	ID        int64           `json:"id"` // The job's ID
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"` // The job's RunAt
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"apperrors"
+	"scheduler-service/internal/model"
+)
+
+var (
+	// ErrJobNotFound is returned when no job has the requested ID or key.
+	ErrJobNotFound = apperrors.New(apperrors.NotFound, "job not found")
+	// ErrJobRunning is returned when a job cannot be changed because it is being fired.
+	ErrJobRunning = apperrors.New(apperrors.Conflict, "job is running")
+)
+
+// JobRepository defines the interface for the storage of jobs.
+type JobRepository interface {
+	Schedule(job *model.Job) (bool, error)
+	GetByID(id int64) (*model.Job, error)
+	List(status, key string, page, pageSize int) ([]model.Job, error)
+	Cancel(id int64, now int64) (*model.Job, error)
+	CancelByKey(key string, now int64) (*model.Job, error)
+	Claim(now, leaseUntil int64, limit int) ([]model.Job, error)
+	RecordAttempt(job *model.Job) error
+}
+
+// sqliteJobRepository implements JobRepository for SQLite database.
+type sqliteJobRepository struct {
+	db *sql.DB
+}
+
+// createJobsTableSQL creates the jobs table. A key identifies at most one active job, so
+// registering a key again reschedules its job instead of firing the action twice.
+const createJobsTableSQL = `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		job_key TEXT,
+		url TEXT NOT NULL,
+		event_type TEXT,
+		payload TEXT NOT NULL,
+		run_at INTEGER NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		max_attempts INTEGER NOT NULL,
+		next_attempt_at INTEGER NOT NULL,
+		last_error TEXT,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		finished_at INTEGER
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_active_key ON jobs(job_key) WHERE status IN ('scheduled', 'running');
+	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status, next_attempt_at);
+	CREATE INDEX IF NOT EXISTS idx_jobs_key ON jobs(job_key, id);`
+
+// jobColumns lists the columns selected for a job, in the order expected by scanJob.
+const jobColumns = `id, job_key, url, event_type, payload, run_at, status, attempts, max_attempts, next_attempt_at,
+	last_error, created_at, updated_at, finished_at`
+
+// scanJob reads a job selected with jobColumns.
+func scanJob(row rowScanner, job *model.Job) error {
+	var payload string
+	if err := row.Scan(
+		&job.ID, &job.Key, &job.URL, &job.EventType, &payload, &job.RunAt, &job.Status, &job.Attempts, &job.MaxAttempts,
+		&job.NextAttemptAt, &job.LastError, &job.CreatedAt, &job.UpdatedAt, &job.FinishedAt,
+	); err != nil {
+		return err
+	}
+	job.Payload = []byte(payload)
+	return nil
+}
+
+// NewSQLiteJobRepository creates a new instance of sqliteJobRepository.
+func NewSQLiteJobRepository(db *sql.DB) JobRepository {
+	return &sqliteJobRepository{db: db}
+}
+
+// Schedule inserts a job, setting its ID, and returns true. If the job has a key and a
+// scheduled job has the same key, that job is rescheduled with the job's fields instead, its
+// attempts reset, and false is returned. It returns ErrJobRunning if the job with the key is
+// being fired.
+func (r *sqliteJobRepository) Schedule(job *model.Job) (bool, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := dbTx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	created := true
+	if job.Key != nil {
+		var existing model.Job
+		err := scanJob(dbTx.QueryRow(
+			`SELECT `+jobColumns+` FROM jobs WHERE job_key = ? AND status IN (?, ?)`,
+			*job.Key, model.StatusScheduled, model.StatusRunning,
+		), &existing)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return false, fmt.Errorf("failed to query job: %w", err)
+		case existing.Status == model.StatusRunning:
+			return false, ErrJobRunning
+		default:
+			created = false
+			job.ID, job.CreatedAt = existing.ID, existing.CreatedAt
+		}
+	}
+
+	if created {
+		result, err := dbTx.Exec(
+			`INSERT INTO jobs(job_key, url, event_type, payload, run_at, status, attempts, max_attempts, next_attempt_at, created_at, updated_at)
+			VALUES(?, ?, ?, ?, ?, ?, 0, ?, ?, ?, ?)`,
+			job.Key, job.URL, job.EventType, string(job.Payload), job.RunAt, job.Status, job.MaxAttempts, job.NextAttemptAt, job.CreatedAt, job.UpdatedAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("failed to insert job: %w", err)
+		}
+		if job.ID, err = result.LastInsertId(); err != nil {
+			return false, fmt.Errorf("failed to get job ID: %w", err)
+		}
+	} else if _, err := dbTx.Exec(
+		`UPDATE jobs SET url = ?, event_type = ?, payload = ?, run_at = ?, attempts = 0, max_attempts = ?, next_attempt_at = ?,
+			last_error = NULL, updated_at = ?
+		WHERE id = ?`,
+		job.URL, job.EventType, string(job.Payload), job.RunAt, job.MaxAttempts, job.NextAttemptAt, job.UpdatedAt, job.ID,
+	); err != nil {
+		return false, fmt.Errorf("failed to reschedule job: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return created, nil
+}
+
+// GetByID returns the job with the given ID.
+func (r *sqliteJobRepository) GetByID(id int64) (*model.Job, error) {
+	job := &model.Job{}
+	if err := scanJob(r.db.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id), job); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to query job: %w", err)
+	}
+	return job, nil
+}
+
+// List returns a page of jobs, by the time they were scheduled to run, optionally only those with the given
+// status or key.
+func (r *sqliteJobRepository) List(status, key string, page, pageSize int) ([]model.Job, error) {
+	return r.query(
+		`SELECT `+jobColumns+` FROM jobs
+		WHERE (? = '' OR status = ?) AND (? = '' OR job_key = ?)
+		ORDER BY run_at, id LIMIT ? OFFSET ?`,
+		status, status, key, key, pageSize, (page-1)*pageSize,
+	)
+}
+
+// Cancel cancels the job with the given ID if it is scheduled, returning it. It returns
+// ErrJobRunning if the job is being fired, and the job unchanged if it already finished.
+func (r *sqliteJobRepository) Cancel(id int64, now int64) (*model.Job, error) {
+	return r.cancel(`id = ?`, id, now)
+}
+
+// CancelByKey cancels the active job with the given key, like Cancel. It returns
+// ErrJobNotFound if no job with the key is active.
+func (r *sqliteJobRepository) CancelByKey(key string, now int64) (*model.Job, error) {
+	return r.cancel(`job_key = ? AND status IN ('scheduled', 'running')`, key, now)
+}
+
+// cancel cancels the job matching where with arg.
+func (r *sqliteJobRepository) cancel(where string, arg any, now int64) (*model.Job, error) {
+	dbTx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := dbTx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	job := &model.Job{}
+	if err := scanJob(dbTx.QueryRow(`SELECT `+jobColumns+` FROM jobs WHERE `+where, arg), job); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+		return nil, fmt.Errorf("failed to query job: %w", err)
+	}
+	switch job.Status {
+	case model.StatusRunning:
+		return nil, ErrJobRunning
+	case model.StatusScheduled:
+	default:
+		return job, nil
+	}
+
+	job.Status, job.UpdatedAt, job.FinishedAt = model.StatusCancelled, now, &now
+	if _, err := dbTx.Exec(
+		`UPDATE jobs SET status = ?, updated_at = ?, finished_at = ? WHERE id = ?`, job.Status, now, now, job.ID,
+	); err != nil {
+		return nil, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return job, nil
+}
+
+// Claim marks up to limit jobs due by now as running until leaseUntil, and returns them, the
+// longest waiting first. Running jobs whose lease lapsed, left by a restart, are due again.
+func (r *sqliteJobRepository) Claim(now, leaseUntil int64, limit int) ([]model.Job, error) {
+	jobs, err := r.query(
+		`UPDATE jobs SET status = ?, next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM jobs WHERE status IN (?, ?) AND next_attempt_at <= ? ORDER BY next_attempt_at, id LIMIT ?
+		)
+		RETURNING `+jobColumns,
+		model.StatusRunning, leaseUntil, now, model.StatusScheduled, model.StatusRunning, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING does not keep the order of the subquery
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].RunAt != jobs[j].RunAt {
+			return jobs[i].RunAt < jobs[j].RunAt
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs, nil
+}
+
+// RecordAttempt writes the outcome of firing a running job: its status, attempts, last error,
+// next attempt and finish time. Jobs that are no longer running are left unchanged.
+func (r *sqliteJobRepository) RecordAttempt(job *model.Job) error {
+	if _, err := r.db.Exec(
+		`UPDATE jobs SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ?, updated_at = ?, finished_at = ?
+		WHERE id = ? AND status = ?`,
+		job.Status, job.Attempts, job.LastError, job.NextAttemptAt, job.UpdatedAt, job.FinishedAt, job.ID, model.StatusRunning,
+	); err != nil {
+		return fmt.Errorf("failed to record attempt of job %d: %w", job.ID, err)
+	}
+	return nil
+}
+
+// query runs a query selecting jobColumns and returns every job found.
+func (r *sqliteJobRepository) query(query string, args ...any) ([]model.Job, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	jobs := []model.Job{}
+	for rows.Next() {
+		var job model.Job
+		if err := scanJob(rows, &job); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read jobs: %w", err)
+	}
+	return jobs, nil
+}
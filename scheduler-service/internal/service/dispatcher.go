@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"scheduler-service/internal/client"
+	"scheduler-service/internal/model"
+	"scheduler-service/internal/repository"
+)
+
+const (
+	// dispatchBatchSize is the maximum number of jobs claimed per round.
+	dispatchBatchSize = 100
+	// dispatchConcurrency is the maximum number of callbacks in flight at once.
+	dispatchConcurrency = 8
+	// retryDelay is the delay before the first retry, doubling after each failed attempt up to maxRetryDelay.
+	retryDelay    = 5 * time.Second
+	maxRetryDelay = 10 * time.Minute
+	// fireTimeout bounds a single callback.
+	fireTimeout = 10 * time.Second
+	// leaseDuration is how long a claimed job is left to its attempt before it is fired again,
+	// e.g. after a restart in the middle of the attempt. It must exceed fireTimeout.
+	leaseDuration = time.Minute
+	// maxLoggedErrorLength bounds the errors kept with jobs.
+	maxLoggedErrorLength = 500
+)
+
+// Dispatcher fires due jobs. Each job is claimed for a lease before its callback is sent and
+// its outcome recorded after, so a job is fired at least once even if the service restarts in
+// the middle: it is claimed again once the lease lapses. Failed callbacks are retried with
+// exponential backoff until the job runs out of attempts; callbacks rejecting a job with a
+// client error fail it right away.
+type Dispatcher struct {
+	repo      repository.JobRepository
+	callbacks *client.CallbackClient
+	interval  time.Duration
+	kick      chan struct{}
+}
+
+// NewDispatcher creates a new Dispatcher polling for due jobs every interval.
+func NewDispatcher(repo repository.JobRepository, callbacks *client.CallbackClient, interval time.Duration) *Dispatcher {
+	return &Dispatcher{repo: repo, callbacks: callbacks, interval: interval, kick: make(chan struct{}, 1)}
+}
+
+// Run fires due jobs until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.kick:
+		}
+	}
+}
+
+// Kick makes the dispatcher run a round now rather than at the next tick, e.g. after a job
+// due right away was scheduled.
+func (d *Dispatcher) Kick() {
+	select {
+	case d.kick <- struct{}{}:
+	default: // A round is already due
+	}
+}
+
+// dispatchDue performs a single round, firing the claimed jobs concurrently.
+func (d *Dispatcher) dispatchDue(ctx context.Context) {
+	now := time.Now()
+	due, err := d.repo.Claim(now.UnixMicro(), now.Add(leaseDuration).UnixMicro(), dispatchBatchSize)
+	if err != nil {
+		log.Printf("Error claiming due jobs: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, dispatchConcurrency)
+	for _, job := range due {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(job model.Job) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			d.fire(ctx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+// fire sends a claimed job's callback and records the outcome.
+func (d *Dispatcher) fire(ctx context.Context, job model.Job) {
+	fireCtx, cancel := context.WithTimeout(ctx, fireTimeout)
+	err := d.callbacks.Fire(fireCtx, job, job.Attempts+1)
+	cancel()
+
+	now := time.Now()
+	job.Attempts++
+	job.UpdatedAt = now.UnixMicro()
+	var callbackErr *client.CallbackError
+	switch {
+	case err == nil:
+		job.Status = model.StatusSucceeded
+		job.LastError = nil
+	case errors.As(err, &callbackErr) && callbackErr.Permanent():
+		log.Printf("Job %d was rejected by its callback: %v", job.ID, err)
+		job.Status = model.StatusFailed
+	case job.Attempts >= job.MaxAttempts:
+		log.Printf("Giving up job %d after %d attempts: %v", job.ID, job.Attempts, err)
+		job.Status = model.StatusFailed
+	default:
+		job.Status = model.StatusScheduled
+		job.NextAttemptAt = now.Add(backoff(job.Attempts)).UnixMicro()
+	}
+	if err != nil {
+		message := err.Error()
+		if len(message) > maxLoggedErrorLength {
+			message = message[:maxLoggedErrorLength]
+		}
+		job.LastError = &message
+	}
+	if job.Status != model.StatusScheduled {
+		job.NextAttemptAt = 0
+		job.FinishedAt = &job.UpdatedAt
+	}
+
+	if err := d.repo.RecordAttempt(&job); err != nil {
+		log.Printf("Error recording attempt of job %d: %v", job.ID, err)
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	delay := retryDelay << (attempts - 1)
+	if delay > maxRetryDelay || delay <= 0 {
+		return maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+
+	"apperrors"
+	"scheduler-service/internal/model"
+	"scheduler-service/internal/repository"
+)
+
+const (
+	// DefaultMaxAttempts is the number of attempts made to fire a job when not specified.
+	DefaultMaxAttempts = 8
+	// MaxMaxAttempts is the largest number of attempts a job may ask for.
+	MaxMaxAttempts = 20
+	// maxKeyLength bounds job keys.
+	maxKeyLength = 200
+	// maxPageSize bounds the pages of jobs.
+	maxPageSize = 100
+)
+
+var (
+	// ErrJobNotFound is returned when no job has the requested ID or key.
+	ErrJobNotFound = repository.ErrJobNotFound
+	// ErrInvalidJob is returned when the fields of a job are invalid.
+	ErrInvalidJob = apperrors.New(apperrors.Validation, "invalid job")
+	// ErrInvalidQuery is returned for listing queries that cannot be served.
+	ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid query")
+	// ErrInvalidState is returned when a job cannot be changed in its current status.
+	ErrInvalidState = apperrors.New(apperrors.Conflict, "invalid job state")
+)
+
+// eventTypePattern matches event types, e.g. listing.expiry_due.
+var eventTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*(\.[a-z][a-z0-9_]*)+$`)
+
+// ScheduleInput holds the fields of a job to schedule.
+type ScheduleInput struct {
+	Key         string          // Optional
+	URL         string          // Required, http or https
+	EventType   string          // Optional
+	Payload     json.RawMessage // Optional, {} when empty
+	RunAt       int64           // Required, in microseconds
+	MaxAttempts int             // Optional, DefaultMaxAttempts when zero
+}
+
+// SchedulerService registers, looks up and cancels jobs, which its Dispatcher fires.
+type SchedulerService struct {
+	repo       repository.JobRepository
+	dispatcher *Dispatcher
+}
+
+// NewSchedulerService creates a new instance of SchedulerService.
+func NewSchedulerService(repo repository.JobRepository, dispatcher *Dispatcher) *SchedulerService {
+	return &SchedulerService{repo: repo, dispatcher: dispatcher}
+}
+
+// Schedule registers a job, returning it and whether it was created. Registering the key of a
+// scheduled job reschedules that job instead; the key of a running job is rejected with
+// ErrInvalidState. Jobs due in the past are fired right away.
+func (s *SchedulerService) Schedule(input ScheduleInput) (*model.Job, bool, error) {
+	if err := validateSchedule(&input); err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now().UnixMicro()
+	job := &model.Job{
+		URL:           input.URL,
+		Payload:       input.Payload,
+		RunAt:         input.RunAt,
+		Status:        model.StatusScheduled,
+		MaxAttempts:   input.MaxAttempts,
+		NextAttemptAt: input.RunAt,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if input.Key != "" {
+		job.Key = &input.Key
+	}
+	if input.EventType != "" {
+		job.EventType = &input.EventType
+	}
+
+	created, err := s.repo.Schedule(job)
+	if err != nil {
+		if errors.Is(err, repository.ErrJobRunning) {
+			return nil, false, fmt.Errorf("%w: the job with this key is running", ErrInvalidState)
+		}
+		return nil, false, err
+	}
+	if job.RunAt <= now {
+		s.dispatcher.Kick()
+	}
+	return job, created, nil
+}
+
+// Get returns the job with the given ID.
+func (s *SchedulerService) Get(id int64) (*model.Job, error) {
+	return s.repo.GetByID(id)
+}
+
+// List returns a page of jobs by the time they were scheduled to run, optionally only those
+// with the given status or key.
+func (s *SchedulerService) List(status, key string, pageNum, pageSize int) ([]model.Job, error) {
+	switch status {
+	case "", model.StatusScheduled, model.StatusRunning, model.StatusSucceeded, model.StatusFailed, model.StatusCancelled:
+	default:
+		return nil, fmt.Errorf("%w: status must be scheduled, running, succeeded, failed or cancelled", ErrInvalidQuery)
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+	return s.repo.List(status, key, pageNum, pageSize)
+}
+
+// Cancel cancels the scheduled job with the given ID, returning it. Cancelling a job that
+// already finished returns it unchanged, so cancellations can be retried safely; a running
+// job cannot be cancelled.
+func (s *SchedulerService) Cancel(id int64) (*model.Job, error) {
+	return cancelled(s.repo.Cancel(id, time.Now().UnixMicro()))
+}
+
+// CancelByKey cancels the active job with the given key, like Cancel.
+func (s *SchedulerService) CancelByKey(key string) (*model.Job, error) {
+	return cancelled(s.repo.CancelByKey(key, time.Now().UnixMicro()))
+}
+
+// cancelled maps the errors of a cancellation.
+func cancelled(job *model.Job, err error) (*model.Job, error) {
+	if errors.Is(err, repository.ErrJobRunning) {
+		return nil, fmt.Errorf("%w: the job is running", ErrInvalidState)
+	}
+	return job, err
+}
+
+// validateSchedule checks the fields of a job to schedule, filling in defaults.
+func validateSchedule(input *ScheduleInput) error {
+	if len(input.Key) > maxKeyLength {
+		return fmt.Errorf("%w: key must be at most %d characters", ErrInvalidJob, maxKeyLength)
+	}
+	u, err := url.Parse(input.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidJob)
+	}
+	if input.EventType != "" && !eventTypePattern.MatchString(input.EventType) {
+		return fmt.Errorf("%w: event_type must be dot-separated lowercase words, e.g. listing.expiry_due", ErrInvalidJob)
+	}
+	if len(input.Payload) == 0 || string(input.Payload) == "null" {
+		input.Payload = json.RawMessage(`{}`)
+	}
+	if input.RunAt <= 0 {
+		return fmt.Errorf("%w: run_at is required", ErrInvalidJob)
+	}
+	if input.MaxAttempts == 0 {
+		input.MaxAttempts = DefaultMaxAttempts
+	}
+	if input.MaxAttempts < 1 || input.MaxAttempts > MaxMaxAttempts {
+		return fmt.Errorf("%w: max_attempts must be between 1 and %d", ErrInvalidJob, MaxMaxAttempts)
+	}
+	return nil
+}
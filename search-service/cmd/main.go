@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"bus"
+	"contracts/client"
+	"httpmiddleware"
+	"registry"
+	"search-service/internal/api"
+	"search-service/internal/handler"
+	"search-service/internal/repository"
+	"search-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// dbPath is the SQLite database file used by default.
+const dbPath = "search.db"
+
+func main() {
+	// Define command-line flags for port, service URLs and admin access
+	port := flag.Int("port", 9200, "The port number to run the Search Service on")
+	dbDSN := flag.String("db-dsn", dbPath, "SQLite database file holding the search index")
+	userServiceURL := flag.String("user-service-url", "http://localhost:7000", "URL of the User Service")
+	listingServiceURL := flag.String("listing-service-url", "http://localhost:6000", "URL of the Listing Service")
+	internalKey := flag.String("internal-key", "", "keyID:secret used to HMAC-sign requests to internal services (requests are unsigned when empty)")
+	adminToken := flag.String("admin-token", "", "Token required in the X-Admin-Token header of admin endpoints (admin endpoints are disabled when empty)")
+	registryURL := flag.String("registry-url", "", "URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)")
+	advertiseURL := flag.String("advertise-url", "", "URL the registry gives out for this instance (default http://<hostname>:<port>)")
+	eventBus := flag.String("event-bus", "", "URL of the message bus to consume domain events from: nats://host:port or kafka://host:port (events are only received on POST /events when empty)")
+	accessLog := flag.Bool("access-log", true, "Log every request once it is served")
+	metricsPort := flag.Int("metrics-port", 0, "Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)")
+	requestTimeout := flag.Duration("request-timeout", 10*time.Second, "How long a request may take before it is answered with 503 (0 disables it; reindexes are exempt)")
+	flag.Parse()
+
+	// Initialize the SQLite database
+	// By default this will create 'search.db' in the current directory if it doesn't exist.
+	db, err := repository.NewSQLiteDB(*dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	// Initialize a custom HTTP client with timeouts for inter-service communication
+	httpClient := client.NewHTTPClient(
+		10*time.Second, // Overall request timeout
+		5*time.Second,  // Dial timeout
+		5*time.Second,  // TLS handshake timeout
+		5*time.Second,  // Response header timeout
+	)
+
+	// Sign internal requests for services that verify signatures
+	if *internalKey != "" {
+		keyID, secret, ok := strings.Cut(*internalKey, ":")
+		if !ok || keyID == "" || secret == "" {
+			log.Fatalf("Invalid -internal-key, expected keyID:secret")
+		}
+		httpClient = client.WithRequestSigning(httpClient, keyID, secret)
+	}
+
+	// The user export streams every user, so it is not bound by the overall request timeout
+	exportClient := *httpClient
+	exportClient.Timeout = 0
+
+	// Initialize repository, service, and handler layers
+	indexRepo := repository.NewSQLiteIndexRepository(db)
+	indexer := service.NewIndexer(
+		indexRepo,
+		client.NewListingServiceClient(httpClient, *listingServiceURL),
+		client.NewUserServiceClient(&exportClient, *userServiceURL),
+	)
+	searchHandler := handler.NewSearchHandler(service.NewSearchService(indexRepo), indexer)
+
+	// Consume domain events from the event bus as well, when there is one
+	if *eventBus != "" {
+		events, err := bus.Open(*eventBus)
+		if err != nil {
+			log.Fatalf("Failed to open event bus: %v", err)
+		}
+		defer events.Close()
+		if _, err := bus.SubscribeTopics(context.Background(), events, service.ConsumedEvents, "search-service", searchHandler.ConsumeMessage); err != nil {
+			log.Fatalf("Failed to subscribe to the event bus: %v", err)
+		}
+		log.Printf("Consuming %v from %s", service.ConsumedEvents, *eventBus)
+	}
+
+	// Build the index of a new database from both services, in the background so events are
+	// consumed meanwhile
+	if empty, err := indexer.IsEmpty(); err != nil {
+		log.Fatalf("Failed to read index: %v", err)
+	} else if empty {
+		go func() {
+			if _, err := indexer.Reindex(); err != nil {
+				log.Printf("Initial reindex failed, retry with POST /admin/reindex: %v", err)
+			}
+		}()
+	}
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define Search Service API routes
+	// GET /search: Full-text search over listings and users, as specified by api/openapi.yaml
+	api.RegisterSearchRoutes(r, searchHandler)
+	// POST /events: Consume a domain event from the user or listing service's relay
+	r.HandleFunc("/events", searchHandler.ConsumeEvent).Methods("POST")
+	// POST /admin/reindex: Rebuild the index from the user and listing services (admin)
+	r.Handle("/admin/reindex", handler.RequireAdmin(*adminToken, http.HandlerFunc(searchHandler.Reindex))).Methods("POST")
+
+	// Serve metrics apart from the API, when enabled
+	var metrics *httpmiddleware.Metrics
+	if *metricsPort != 0 {
+		metrics = httpmiddleware.NewMetrics("search-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", *metricsPort)
+			if err := metrics.ListenAndServe(fmt.Sprintf(":%d", *metricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", *metricsPort, err)
+			}
+		}()
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	apiHandler := httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: *accessLog,
+		Metrics:   metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   *requestTimeout,
+		NoTimeout: func(req *http.Request) bool {
+			// Reindexes read every user and listing
+			return req.URL.Path == "/admin/reindex"
+		},
+	})
+
+	// Configure HTTP server
+	server := &http.Server{
+		Addr:         fmt.Sprintf(":%d", *port),
+		Handler:      apiHandler,
+		ReadTimeout:  15 * time.Second, // Max time to read request from client
+		WriteTimeout: 15 * time.Second, // Max time to write response to client
+		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
+	}
+
+	// Register with the registry, so the gateway reaches this instance as registry://search-service
+	if *registryURL != "" {
+		if err := registry.Announce(context.Background(), *registryURL, "search-service", *advertiseURL, *port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
+	// Start the HTTP server
+	log.Printf("Search Service starting on port %d", *port)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatalf("Could not listen on port %d: %v", *port, err)
+	}
+}
@@ -0,0 +1,29 @@
+// Code generated by openapi from search-service/api/openapi.yaml. DO NOT EDIT.
+
+package api
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// SearchHandler handles the operations of the search tag: full-text search over listings and users.
+type SearchHandler interface {
+	// Search handles GET /search: Search listings and users, best matches first.
+	Search(w http.ResponseWriter, r *http.Request)
+}
+
+// UnimplementedSearchHandler answers every operation of SearchHandler with 501 Not Implemented.
+// Handlers embed it to implement the interface while their operations are written.
+type UnimplementedSearchHandler struct{}
+
+// Search answers GET /search with 501 Not Implemented.
+func (UnimplementedSearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	http.Error(w, http.StatusText(http.StatusNotImplemented), http.StatusNotImplemented)
+}
+
+// RegisterSearchRoutes registers the routes of the operations of SearchHandler on r.
+func RegisterSearchRoutes(r *mux.Router, h SearchHandler) {
+	r.HandleFunc("/search", h.Search).Methods("GET")
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"apperrors"
+	"search-service/internal/api"
+	"search-service/internal/model"
+	"search-service/internal/service"
+)
+
+// adminTokenHeader carries the admin token required by the admin endpoints.
+const adminTokenHeader = "X-Admin-Token"
+
+// maxBodyBytes bounds the JSON body of requests.
+const maxBodyBytes = 64 << 10
+
+// APIResponse is the response structure for requests that return no data.
+type APIResponse struct {
+	Result bool   `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SearchResponse is the response structure for searches.
+type SearchResponse struct {
+	Result bool        `json:"result"`
+	Hits   []model.Hit `json:"hits"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// EventResponse is the response structure for consumed events.
+type EventResponse struct {
+	Result  bool   `json:"result"`
+	Handled bool   `json:"handled"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReindexResponse is the response structure for reindexes.
+type ReindexResponse struct {
+	Result  bool                 `json:"result"`
+	Reindex *model.ReindexResult `json:"reindex,omitempty"`
+	Error   string               `json:"error,omitempty"`
+}
+
+// SearchHandler handles HTTP requests related to searches and indexing.
+type SearchHandler struct {
+	searchService *service.SearchService
+	indexer       *service.Indexer
+}
+
+// SearchHandler implements the operations of the spec's search tag.
+var _ api.SearchHandler = (*SearchHandler)(nil)
+
+// NewSearchHandler creates a new instance of SearchHandler.
+func NewSearchHandler(searchService *service.SearchService, indexer *service.Indexer) *SearchHandler {
+	return &SearchHandler{searchService: searchService, indexer: indexer}
+}
+
+// RequireAdmin wraps a handler so it only serves requests carrying the configured
+// admin token in the X-Admin-Token header. When no token is configured, admin
+// endpoints are disabled entirely.
+func RequireAdmin(adminToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin API is disabled"})
+			return
+		}
+
+		provided := r.Header.Get(adminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin token is missing or invalid"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Search handles GET /search requests.
+// It returns the listings and users matching q, best matches first. The optional type
+// parameter restricts hits to listing or user (comma-separated), and page_num and page_size
+// page through them (default 1 and 10).
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+	var types []string
+	if value := r.URL.Query().Get("type"); value != "" {
+		types = strings.Split(value, ",")
+	}
+
+	hits, err := h.searchService.Search(r.URL.Query().Get("q"), types, pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "searching index")
+		return
+	}
+
+	json.NewEncoder(w).Encode(SearchResponse{Result: true, Hits: hits})
+}
+
+// ConsumeEvent handles POST /events requests, the subscriber endpoint of the user service's
+// and the listing service's event relays. Events of other types are acknowledged without effect.
+// Failures answer 500, so the relays retry the event.
+func (h *SearchHandler) ConsumeEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var event model.Event
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil || event.Type == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(EventResponse{Result: false, Error: "Invalid event"})
+		return
+	}
+
+	handled, err := h.indexer.HandleEvent(event)
+	if err != nil {
+		writeError(w, err, "indexing event %s %d", event.Type, event.ID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(EventResponse{Result: true, Handled: handled})
+}
+
+// Reindex handles POST /admin/reindex requests.
+// It rebuilds the index from the user and listing services and responds once done.
+func (h *SearchHandler) Reindex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	// Reindexes can outlive the server's write timeout, so lift the deadline for this response only
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Could not clear write deadline for reindex: %v", err)
+	}
+
+	result, err := h.indexer.Reindex()
+	if err != nil {
+		if !apperrors.Is(err, apperrors.Internal) {
+			writeError(w, err, "reindexing")
+			return
+		}
+		// Other failures are failures to read the user and listing services
+		log.Printf("Error reindexing: %v", err)
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(ReindexResponse{Result: false, Error: "Reindex failed: " + err.Error()})
+		return
+	}
+
+	json.NewEncoder(w).Encode(ReindexResponse{Result: true, Reindex: result})
+}
+
+// writeError answers an error of SearchService or Indexer with the status of its code, logging
+// internal errors with the action they interrupted.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	if apperrors.Is(err, apperrors.Internal) {
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.Write(w, err)
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"encoding/json"
+
+	"contracts"
+)
+
+// Types of search hits.
+const (
+	HitTypeListing = "listing"
+	HitTypeUser    = "user"
+)
+
+// Event is a domain event as POSTed by the user service's and the listing service's relays.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt int64           `json:"created_at"`
+}
+
+// Hit is a search result: a listing, along with its owner when the index knows them, or a user.
+type Hit struct {
+	Type    string          `json:"type"`
+	ID      int64           `json:"id"`
+	Score   float64         `json:"score"` // Relevance; higher is better
+	Listing json.RawMessage `json:"listing,omitempty"`
+	User    *contracts.User `json:"user"`
+}
+
+// SearchQuery describes a search.
+type SearchQuery struct {
+	Match    string // FTS4 match expression
+	Types    []string
+	PageNum  int
+	PageSize int
+}
+
+// ReindexResult summarizes a rebuild of the index.
+type ReindexResult struct {
+	Listings        int64 `json:"listings"`         // Listings indexed
+	Users           int64 `json:"users"`            // Users indexed
+	RemovedListings int64 `json:"removed_listings"` // Listings no longer visible, dropped from the index
+	RemovedUsers    int64 `json:"removed_users"`    // Users no longer existing, dropped from the index
+	StartedAt       int64 `json:"started_at"`
+	FinishedAt      int64 `json:"finished_at"`
+}
@@ -0,0 +1,344 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"contracts"
+	"search-service/internal/model"
+)
+
+// IndexRepository defines the interface for the search index.
+type IndexRepository interface {
+	SaveListing(listing contracts.Listing, indexedAt int64) error
+	DeleteListing(id int64) (bool, error)
+	DeleteUserListings(userID int64) (int64, error)
+	ReassignListings(fromUserID, toUserID int64) (int64, error)
+	SaveUser(user contracts.User, indexedAt int64) error
+	DeleteUser(id int64) (bool, error)
+	Search(query model.SearchQuery) ([]model.Hit, error)
+	DeleteStale(indexedBefore int64) (listings int64, users int64, err error)
+	Count() (listings int64, users int64, err error)
+}
+
+// sqliteIndexRepository implements IndexRepository for SQLite database.
+type sqliteIndexRepository struct {
+	db *sql.DB
+}
+
+// createListingsTableSQL creates the listings table, holding each listing as returned by the
+// Listing Service. indexed_at is when the listing was last saved, so a reindex can drop the
+// listings it did not see.
+const createListingsTableSQL = `
+	CREATE TABLE IF NOT EXISTS listings (
+		id INTEGER NOT NULL PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		listing_type TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		indexed_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_listings_user_id ON listings(user_id);`
+
+// createListingsFTSTableSQL creates the full-text index of listings, whose docid is the listing ID.
+const createListingsFTSTableSQL = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS listings_fts USING fts4(title, description, tokenize=unicode61 "remove_diacritics=1");`
+
+// createUsersTableSQL creates the users table.
+const createUsersTableSQL = `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		indexed_at INTEGER NOT NULL
+	);`
+
+// createUsersFTSTableSQL creates the full-text index of users, whose docid is the user ID.
+const createUsersFTSTableSQL = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS users_fts USING fts4(name, tokenize=unicode61 "remove_diacritics=1");`
+
+// Column weights of the rank function: listing titles and user names count ten times as much
+// as listing descriptions.
+const (
+	listingHitsSQL = `
+	SELECT '` + model.HitTypeListing + `' AS type, l.id AS id, m.score AS score, l.created_at AS created_at, l.data,
+		u.id, u.name, u.created_at, u.updated_at
+	FROM (SELECT docid, rank(matchinfo(listings_fts, 'pcx'), 10.0, 1.0) AS score FROM listings_fts WHERE listings_fts MATCH ?) m
+	JOIN listings l ON l.id = m.docid
+	LEFT JOIN users u ON u.id = l.user_id`
+
+	userHitsSQL = `
+	SELECT '` + model.HitTypeUser + `' AS type, u.id AS id, m.score AS score, u.created_at AS created_at, NULL,
+		u.id, u.name, u.created_at, u.updated_at
+	FROM (SELECT docid, rank(matchinfo(users_fts, 'pcx'), 10.0) AS score FROM users_fts WHERE users_fts MATCH ?) m
+	JOIN users u ON u.id = m.docid`
+)
+
+// NewSQLiteIndexRepository creates a new instance of sqliteIndexRepository.
+func NewSQLiteIndexRepository(db *sql.DB) IndexRepository {
+	return &sqliteIndexRepository{db: db}
+}
+
+// SaveListing adds a listing to the index, or replaces it. The listing's user_name is replaced by
+// the owner's name when the index knows the owner, as their events may be more recent.
+func (r *sqliteIndexRepository) SaveListing(listing contracts.Listing, indexedAt int64) error {
+	data, err := json.Marshal(listing)
+	if err != nil {
+		return fmt.Errorf("failed to encode listing: %w", err)
+	}
+	return r.withTx("saving listing", func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`
+			INSERT INTO listings(id, user_id, listing_type, created_at, data, indexed_at)
+			VALUES(?, ?, ?, ?, COALESCE((SELECT json_set(?, '$.user_name', name) FROM users WHERE id = ?), ?), ?)
+			ON CONFLICT(id) DO UPDATE SET user_id = excluded.user_id, listing_type = excluded.listing_type,
+				created_at = excluded.created_at, data = excluded.data, indexed_at = excluded.indexed_at`,
+			listing.ID, listing.UserID, listing.ListingType, listing.CreatedAt,
+			string(data), listing.UserID, string(data), indexedAt,
+		); err != nil {
+			return fmt.Errorf("failed to save listing: %w", err)
+		}
+		return replaceFTS(tx, "listings_fts", listing.ID, "title, description", listing.Title, listing.Description)
+	})
+}
+
+// DeleteListing removes a listing from the index. It returns false if it was not indexed.
+func (r *sqliteIndexRepository) DeleteListing(id int64) (bool, error) {
+	var deleted int64
+	err := r.withTx("deleting listing", func(tx *sql.Tx) error {
+		var err error
+		deleted, err = deleteListings(tx, `id = ?`, id)
+		return err
+	})
+	return deleted > 0, err
+}
+
+// DeleteUserListings removes the listings of a user from the index, returning how many there were.
+func (r *sqliteIndexRepository) DeleteUserListings(userID int64) (int64, error) {
+	var deleted int64
+	err := r.withTx("deleting user listings", func(tx *sql.Tx) error {
+		var err error
+		deleted, err = deleteListings(tx, `user_id = ?`, userID)
+		return err
+	})
+	return deleted, err
+}
+
+// ReassignListings hands the listings of a user over to another one, like the Listing Service
+// does when users are merged, returning how many there were. Their user_name becomes the new
+// owner's name, null if the index does not know them.
+func (r *sqliteIndexRepository) ReassignListings(fromUserID, toUserID int64) (int64, error) {
+	result, err := r.db.Exec(`
+		UPDATE listings SET user_id = ?,
+			data = json_set(data, '$.user_id', ?, '$.user_name', (SELECT name FROM users WHERE id = ?))
+		WHERE user_id = ?`,
+		toUserID, toUserID, toUserID, fromUserID,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reassign listings: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// SaveUser adds a user to the index, or updates them. A user whose UpdatedAt is older than the
+// indexed one is stale and only marked as seen, so events delivered late cannot undo a rename.
+// A new name is copied to the user's listings, as the Listing Service does.
+func (r *sqliteIndexRepository) SaveUser(user contracts.User, indexedAt int64) error {
+	return r.withTx("saving user", func(tx *sql.Tx) error {
+		var name string
+		var updatedAt int64
+		err := tx.QueryRow(`SELECT name, updated_at FROM users WHERE id = ?`, user.ID).Scan(&name, &updatedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to load user: %w", err)
+		}
+		exists := err == nil
+		if exists && user.UpdatedAt < updatedAt {
+			if _, err := tx.Exec(`UPDATE users SET indexed_at = ? WHERE id = ?`, indexedAt, user.ID); err != nil {
+				return fmt.Errorf("failed to update user: %w", err)
+			}
+			return nil
+		}
+
+		// Rename events carry no creation time, so keep the known one
+		if _, err := tx.Exec(`
+			INSERT INTO users(id, name, created_at, updated_at, indexed_at) VALUES(?, ?, ?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET name = excluded.name,
+				created_at = CASE WHEN excluded.created_at > 0 THEN excluded.created_at ELSE users.created_at END,
+				updated_at = excluded.updated_at, indexed_at = excluded.indexed_at`,
+			user.ID, user.Name, user.CreatedAt, user.UpdatedAt, indexedAt,
+		); err != nil {
+			return fmt.Errorf("failed to save user: %w", err)
+		}
+		if exists && name == user.Name {
+			return nil
+		}
+		if err := replaceFTS(tx, "users_fts", user.ID, "name", user.Name); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(
+			`UPDATE listings SET data = json_set(data, '$.user_name', ?) WHERE user_id = ?`, user.Name, user.ID,
+		); err != nil {
+			return fmt.Errorf("failed to rename user in listings: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteUser removes a user from the index. It returns false if they were not indexed.
+func (r *sqliteIndexRepository) DeleteUser(id int64) (bool, error) {
+	var deleted int64
+	err := r.withTx("deleting user", func(tx *sql.Tx) error {
+		var err error
+		deleted, err = deleteUsers(tx, `id = ?`, id)
+		return err
+	})
+	return deleted > 0, err
+}
+
+// Search returns a page of the listings and users matching the query, best matches first and
+// most recently created first among equal matches.
+func (r *sqliteIndexRepository) Search(query model.SearchQuery) ([]model.Hit, error) {
+	var selects []string
+	var args []any
+	for _, hitType := range query.Types {
+		switch hitType {
+		case model.HitTypeListing:
+			selects = append(selects, listingHitsSQL)
+		case model.HitTypeUser:
+			selects = append(selects, userHitsSQL)
+		default:
+			return nil, fmt.Errorf("unknown hit type %q", hitType)
+		}
+		args = append(args, query.Match)
+	}
+	offset := (query.PageNum - 1) * query.PageSize
+	args = append(args, query.PageSize, offset)
+
+	rows, err := r.db.Query(strings.Join(selects, " UNION ALL ")+`
+		ORDER BY score DESC, created_at DESC, id DESC LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []model.Hit{}
+	for rows.Next() {
+		hit, err := scanHit(rows)
+		if err != nil {
+			return nil, err
+		}
+		hits = append(hits, *hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to search index: %w", err)
+	}
+	return hits, nil
+}
+
+// DeleteStale removes the listings and users last saved before indexedBefore, returning how
+// many of each there were.
+func (r *sqliteIndexRepository) DeleteStale(indexedBefore int64) (int64, int64, error) {
+	var listings, users int64
+	err := r.withTx("deleting stale entries", func(tx *sql.Tx) error {
+		var err error
+		if listings, err = deleteListings(tx, `indexed_at < ?`, indexedBefore); err != nil {
+			return err
+		}
+		users, err = deleteUsers(tx, `indexed_at < ?`, indexedBefore)
+		return err
+	})
+	return listings, users, err
+}
+
+// Count returns the number of indexed listings and users.
+func (r *sqliteIndexRepository) Count() (int64, int64, error) {
+	var listings, users int64
+	err := r.db.QueryRow(`SELECT (SELECT COUNT(*) FROM listings), (SELECT COUNT(*) FROM users)`).Scan(&listings, &users)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count index entries: %w", err)
+	}
+	return listings, users, nil
+}
+
+// withTx runs fn in a transaction, committed if fn succeeds and rolled back otherwise.
+func (r *sqliteIndexRepository) withTx(what string, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", what, err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for %s: %w", what, err)
+	}
+	return nil
+}
+
+// replaceFTS replaces the full-text entry of docid in table with the given column values.
+func replaceFTS(tx *sql.Tx, table string, docid int64, columns string, values ...any) error {
+	if _, err := tx.Exec(`DELETE FROM `+table+` WHERE docid = ?`, docid); err != nil {
+		return fmt.Errorf("failed to update %s: %w", table, err)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+	if _, err := tx.Exec(
+		`INSERT INTO `+table+`(docid, `+columns+`) VALUES(?, `+placeholders+`)`, append([]any{docid}, values...)...,
+	); err != nil {
+		return fmt.Errorf("failed to update %s: %w", table, err)
+	}
+	return nil
+}
+
+// deleteListings removes the listings matching where, and their full-text entries, within tx.
+func deleteListings(tx *sql.Tx, where string, args ...any) (int64, error) {
+	if _, err := tx.Exec(`DELETE FROM listings_fts WHERE docid IN (SELECT id FROM listings WHERE `+where+`)`, args...); err != nil {
+		return 0, fmt.Errorf("failed to delete listings: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM listings WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete listings: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// deleteUsers removes the users matching where, and their full-text entries, within tx.
+func deleteUsers(tx *sql.Tx, where string, args ...any) (int64, error) {
+	if _, err := tx.Exec(`DELETE FROM users_fts WHERE docid IN (SELECT id FROM users WHERE `+where+`)`, args...); err != nil {
+		return 0, fmt.Errorf("failed to delete users: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM users WHERE `+where, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete users: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// scanHit scans a row of the hit queries into a model.Hit.
+func scanHit(row rowScanner) (*model.Hit, error) {
+	var hit model.Hit
+	var createdAt int64
+	var data sql.NullString
+	var userID, userCreatedAt, userUpdatedAt sql.NullInt64
+	var userName sql.NullString
+	if err := row.Scan(&hit.Type, &hit.ID, &hit.Score, &createdAt, &data,
+		&userID, &userName, &userCreatedAt, &userUpdatedAt); err != nil {
+		return nil, fmt.Errorf("failed to scan search hit: %w", err)
+	}
+	if data.Valid {
+		hit.Listing = json.RawMessage(data.String)
+	}
+	if userID.Valid {
+		hit.User = &contracts.User{ID: userID.Int64, Name: userName.String, CreatedAt: userCreatedAt.Int64, UpdatedAt: userUpdatedAt.Int64}
+	}
+	return &hit, nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// driverName is the SQLite driver registered with the rank function.
+const driverName = "sqlite3_search"
+
+func init() {
+	sql.Register(driverName, &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			return conn.RegisterFunc("rank", rank, true)
+		},
+	})
+}
+
+// rank scores a full-text match from its matchinfo(table, 'pcx') blob, weighing each column by
+// the given weights (1 for columns without one). FTS4 has no built-in ranking; like the
+// example in its documentation, every phrase scores the share of its hits across all rows that
+// are in this row, so rare terms count more than common ones.
+func rank(matchinfo []byte, weights ...float64) float64 {
+	info := make([]uint32, len(matchinfo)/4)
+	for i := range info {
+		info[i] = binary.NativeEndian.Uint32(matchinfo[i*4:])
+	}
+	if len(info) < 2 {
+		return 0
+	}
+
+	phrases, columns := int(info[0]), int(info[1])
+	var score float64
+	for phrase := 0; phrase < phrases; phrase++ {
+		for column := 0; column < columns; column++ {
+			i := 2 + 3*(phrase*columns+column)
+			if i+1 >= len(info) {
+				return score
+			}
+			hitsInRow, hitsInAllRows := info[i], info[i+1]
+			if hitsInRow == 0 {
+				continue
+			}
+			weight := 1.0
+			if column < len(weights) {
+				weight = weights[column]
+			}
+			score += weight * float64(hitsInRow) / float64(hitsInAllRows)
+		}
+	}
+	return score
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// NewSQLiteDB initializes and returns a new SQLite database connection,
+// creating the index tables if necessary.
+func NewSQLiteDB(dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// SQLite supports a single writer. Events are indexed one at a time, so a single
+	// connection serializing every statement is enough to avoid SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	// Ping the database to verify connection
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	for _, stmt := range []string{createListingsTableSQL, createListingsFTSTableSQL, createUsersTableSQL, createUsersFTSTableSQL} {
+		if _, err := db.Exec(stmt); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to create tables: %w", err)
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"apperrors"
+	"contracts"
+	"contracts/client"
+	"search-service/internal/model"
+	"search-service/internal/repository"
+)
+
+// Event types of the user service the index is built from.
+const (
+	eventUserCreated = "user.created"
+	eventUserUpdated = "user.updated"
+	eventUserMerged  = "user.merged"
+	eventUserDeleted = "user.deleted"
+)
+
+// listingRefreshEvents are the listing service events after which a listing is looked up again,
+// as they may change its fields or whether it is publicly visible. Their payloads only carry
+// some of the listing's fields, so the Listing Service is the source of the indexed listing.
+var listingRefreshEvents = map[string]bool{
+	"listing.created":         true,
+	"listing.updated":         true,
+	"listing.price_dropped":   true,
+	"listing.sold":            true,
+	"listing.expired":         true,
+	"listing.promotion_ended": true,
+	"listing.approved":        true,
+	"listing.rejected":        true,
+	"listing.flagged":         true,
+}
+
+// listingRemovalEvents are the listing service events after which a listing is gone.
+var listingRemovalEvents = map[string]bool{
+	"listing.deleted":  true,
+	"listing.archived": true,
+}
+
+// reindexPageSize is the number of listings requested per page while reindexing.
+const reindexPageSize = 100
+
+// ConsumedEvents are the types of the events the service subscribes to on the event bus.
+var ConsumedEvents = slices.Concat(
+	[]string{eventUserCreated, eventUserUpdated, eventUserMerged, eventUserDeleted},
+	slices.Sorted(maps.Keys(listingRefreshEvents)),
+	slices.Sorted(maps.Keys(listingRemovalEvents)),
+)
+
+// Errors returned by Indexer.
+var (
+	// ErrInvalidPayload is returned for events whose payload lacks the IDs to index.
+	ErrInvalidPayload = apperrors.New(apperrors.Validation, "invalid event payload")
+	// ErrReindexRunning is returned when a reindex is requested while one is running.
+	ErrReindexRunning = apperrors.New(apperrors.Conflict, "a reindex is already running")
+)
+
+// Indexer keeps the search index up to date with the domain events of the user service and
+// the listing service, and rebuilds it from both services on demand.
+type Indexer struct {
+	index    repository.IndexRepository
+	listings *client.ListingServiceClient
+	users    *client.UserServiceClient
+
+	reindexing sync.Mutex
+}
+
+// NewIndexer creates a new instance of Indexer.
+func NewIndexer(index repository.IndexRepository, listings *client.ListingServiceClient, users *client.UserServiceClient) *Indexer {
+	return &Indexer{index: index, listings: listings, users: users}
+}
+
+// HandleEvent updates the index for an event. It returns false for events of other types.
+// Handling an event again has no further effect, as events are delivered at least once.
+func (i *Indexer) HandleEvent(event model.Event) (bool, error) {
+	switch {
+	case listingRefreshEvents[event.Type]:
+		listingID, err := decodeListingID(event)
+		if err != nil {
+			return false, err
+		}
+		return true, i.refreshListing(listingID)
+
+	case listingRemovalEvents[event.Type]:
+		listingID, err := decodeListingID(event)
+		if err != nil {
+			return false, err
+		}
+		_, err = i.index.DeleteListing(listingID)
+		return true, err
+
+	case event.Type == eventUserCreated || event.Type == eventUserUpdated:
+		var p struct {
+			UserID    int64  `json:"user_id"`
+			Name      string `json:"name"`
+			CreatedAt int64  `json:"created_at"`
+			UpdatedAt int64  `json:"updated_at"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		user := contracts.User{ID: p.UserID, Name: p.Name, CreatedAt: p.CreatedAt, UpdatedAt: p.UpdatedAt}
+		if event.Type == eventUserCreated {
+			user.UpdatedAt = p.CreatedAt
+		}
+		return true, i.index.SaveUser(user, time.Now().UnixMicro())
+
+	case event.Type == eventUserMerged:
+		var p struct {
+			SourceUserID int64 `json:"source_user_id"`
+			TargetUserID int64 `json:"target_user_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.SourceUserID <= 0 || p.TargetUserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		if _, err := i.index.ReassignListings(p.SourceUserID, p.TargetUserID); err != nil {
+			return false, err
+		}
+		_, err := i.index.DeleteUser(p.SourceUserID)
+		return true, err
+
+	case event.Type == eventUserDeleted:
+		var p struct {
+			UserID int64 `json:"user_id"`
+		}
+		if err := json.Unmarshal(event.Payload, &p); err != nil || p.UserID <= 0 {
+			return false, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+		}
+		// The Listing Service hides the listings of deleted users by default. With another
+		// orphan policy, they come back on their next event or the next reindex.
+		if _, err := i.index.DeleteUserListings(p.UserID); err != nil {
+			return false, err
+		}
+		_, err := i.index.DeleteUser(p.UserID)
+		return true, err
+	}
+	return false, nil
+}
+
+// Reindex rebuilds the index from the User Service's export and the Listing Service's public
+// listings, then drops the entries neither service returned. Events keep being indexed
+// meanwhile. Only one reindex runs at a time; others fail with ErrReindexRunning.
+func (i *Indexer) Reindex() (*model.ReindexResult, error) {
+	if !i.reindexing.TryLock() {
+		return nil, ErrReindexRunning
+	}
+	defer i.reindexing.Unlock()
+
+	result := &model.ReindexResult{StartedAt: time.Now().UnixMicro()}
+
+	// Users first, so listings are saved with their owner's latest name
+	err := i.users.ExportUsers(func(user contracts.User) error {
+		result.Users++
+		return i.index.SaveUser(user, time.Now().UnixMicro())
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reindex users: %w", err)
+	}
+
+	cursor := ""
+	for {
+		page, err := i.listings.GetListings(client.ListingQuery{PageNum: 1, Cursor: cursor, PageSize: reindexPageSize})
+		if err != nil {
+			return nil, fmt.Errorf("failed to reindex listings: %w", err)
+		}
+		for _, listing := range page.Listings {
+			if err := i.index.SaveListing(listing, time.Now().UnixMicro()); err != nil {
+				return nil, fmt.Errorf("failed to reindex listings: %w", err)
+			}
+			result.Listings++
+		}
+		if page.NextCursor == "" || len(page.Listings) == 0 {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	result.RemovedListings, result.RemovedUsers, err = i.index.DeleteStale(result.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.FinishedAt = time.Now().UnixMicro()
+	log.Printf("Reindexed %d listings and %d users in %s, removed %d listings and %d users",
+		result.Listings, result.Users, time.Duration(result.FinishedAt-result.StartedAt)*time.Microsecond,
+		result.RemovedListings, result.RemovedUsers)
+	return result, nil
+}
+
+// IsEmpty reports whether nothing is indexed yet.
+func (i *Indexer) IsEmpty() (bool, error) {
+	listings, users, err := i.index.Count()
+	if err != nil {
+		return false, err
+	}
+	return listings == 0 && users == 0, nil
+}
+
+// refreshListing looks a listing up in the Listing Service, and indexes it if it is publicly
+// visible or removes it from the index otherwise.
+func (i *Indexer) refreshListing(listingID int64) error {
+	listing, err := i.listings.GetListing(listingID)
+	if err != nil {
+		return err
+	}
+	if listing != nil {
+		return i.index.SaveListing(*listing, time.Now().UnixMicro())
+	}
+	_, err = i.index.DeleteListing(listingID)
+	return err
+}
+
+// decodeListingID returns the listing_id of a listing event's payload.
+func decodeListingID(event model.Event) (int64, error) {
+	var p struct {
+		ListingID int64 `json:"listing_id"`
+	}
+	if err := json.Unmarshal(event.Payload, &p); err != nil || p.ListingID <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPayload, event.Type)
+	}
+	return p.ListingID, nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"apperrors"
+	"search-service/internal/model"
+	"search-service/internal/repository"
+)
+
+const (
+	// maxQueryLength is the maximum length, in characters, of search queries.
+	maxQueryLength = 200
+	// maxQueryTerms is the maximum number of terms matched per search.
+	maxQueryTerms = 16
+	// MaxPageSize is the maximum number of hits per page.
+	MaxPageSize = 100
+)
+
+// ErrInvalidQuery is returned for searches that cannot be run.
+var ErrInvalidQuery = apperrors.New(apperrors.Validation, "invalid search")
+
+// SearchService runs searches over the index.
+type SearchService struct {
+	index repository.IndexRepository
+}
+
+// NewSearchService creates a new instance of SearchService.
+func NewSearchService(index repository.IndexRepository) *SearchService {
+	return &SearchService{index: index}
+}
+
+// Search returns a page of the hits of the given types (every type when empty) matching every
+// term of the query, best matches first. Terms match words they are a prefix of, ignoring case
+// and diacritics, in listing titles and descriptions and in user names.
+func (s *SearchService) Search(query string, types []string, pageNum, pageSize int) ([]model.Hit, error) {
+	if utf8.RuneCountInString(query) > maxQueryLength {
+		return nil, fmt.Errorf("%w: q must be at most %d characters long", ErrInvalidQuery, maxQueryLength)
+	}
+	match, err := matchExpression(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(types) == 0 {
+		types = []string{model.HitTypeListing, model.HitTypeUser}
+	}
+	for _, hitType := range types {
+		if hitType != model.HitTypeListing && hitType != model.HitTypeUser {
+			return nil, fmt.Errorf("%w: unknown type %q (expected %s or %s)", ErrInvalidQuery, hitType, model.HitTypeListing, model.HitTypeUser)
+		}
+	}
+	if pageSize > MaxPageSize {
+		return nil, fmt.Errorf("%w: page_size must be at most %d", ErrInvalidQuery, MaxPageSize)
+	}
+
+	return s.index.Search(model.SearchQuery{Match: match, Types: types, PageNum: pageNum, PageSize: pageSize})
+}
+
+// matchExpression builds the FTS4 match expression of a query: every word of the query as a
+// prefix. Words are split the way the index tokenizes text, so user input cannot use FTS4
+// query syntax, and lowercased so they are never taken for the AND, OR and NOT operators.
+func matchExpression(query string) (string, error) {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return "", fmt.Errorf("%w: q must contain at least one word", ErrInvalidQuery)
+	}
+	if len(words) > maxQueryTerms {
+		return "", fmt.Errorf("%w: q must contain at most %d words", ErrInvalidQuery, maxQueryTerms)
+	}
+	for i, word := range words {
+		words[i] = word + "*"
+	}
+	return strings.Join(words, " "), nil
+}
@@ -0,0 +1,308 @@
+// Package app assembles the User Service from its configuration: the database, the layers on
+// top of it and the routes serving them. The service's command serves it on a port, and tests
+// may boot it inside their own process.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"bus"
+	"httpmiddleware"
+	"user-service/internal/api"
+	"user-service/internal/authservice"
+	"user-service/internal/backup"
+	"user-service/internal/events"
+	"user-service/internal/handler"
+	"user-service/internal/repository"
+	"user-service/internal/service"
+	"user-service/internal/signing"
+
+	"github.com/gorilla/mux"
+	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+)
+
+// Config configures the User Service. Its fields are the settings named by their config tags,
+// loaded by package config from flags, environment variables and a file of settings.
+type Config struct {
+	DBDriver             string        `config:"db-driver" oneof:"sqlite postgres mysql memory" usage:"Database backend: sqlite, postgres, mysql or memory"`
+	DBDSN                string        `config:"db-dsn,required" usage:"Data source of the database: the SQLite file, a PostgreSQL or MySQL connection string, or the name of the in-memory database"`
+	DBConnectTimeout     time.Duration `config:"db-connect-timeout" min:"0" usage:"How long to keep retrying, with exponential backoff, when the database cannot be opened at startup (0 tries once)"`
+	ReadDSN              string        `config:"db-read-dsn" usage:"Data source of a read replica serving user listings and lookups, of the same backend as the primary, e.g. file:replica.db?mode=ro (reads use the primary when empty)"`
+	ReplicaStaleness     time.Duration `config:"replica-staleness" min:"0" usage:"How far the read replica may lag behind; reads within this long after a write are served by the primary"`
+	WriteQueueDepth      int           `config:"write-queue-depth" min:"0" usage:"Maximum number of database writes waiting to be executed"`
+	WriteQueueTimeout    time.Duration `config:"write-queue-timeout" min:"0" usage:"How long a database write may wait in the queue before the request fails with 503"`
+	CaseInsensitiveNames bool          `config:"case-insensitive-names" usage:"Treat user names differing only in letter case as the same name when enforcing uniqueness"`
+	BatchMaxSize         int           `config:"batch-max-size" min:"0" usage:"Maximum number of users accepted by POST /users/batch"`
+	ImportChunkSize      int           `config:"import-chunk-size" min:"0" usage:"Number of rows inserted per transaction by POST /users/import"`
+	UserCacheSize        int           `config:"user-cache-size" min:"0" usage:"Maximum number of users kept in the in-process GET /users/{id} cache (0 disables the cache)"`
+	UserCacheTTL         time.Duration `config:"user-cache-ttl" min:"0" usage:"How long a cached user is served before it is read again from the database"`
+	StatsCacheTTL        time.Duration `config:"stats-cache-ttl" min:"0" usage:"How long GET /users/stats results are reused (0 disables caching)"`
+	SessionTTL           time.Duration `config:"session-ttl" min:"0" usage:"How long a login session stays valid"`
+	AdminToken           string        `config:"admin-token,secret" usage:"Token required in the X-Admin-Token header for admin endpoints (admin endpoints are disabled when empty)"`
+	BackupDir            string        `config:"backup-dir" usage:"Directory where database backups are written (SQLite databases only)"`
+	BackupKeep           int           `config:"backup-keep" min:"0" usage:"Number of most recent backups to keep (0 keeps all)"`
+	EventBus             string        `config:"event-bus" usage:"URL of the message bus domain events are published to, one topic per event type: memory://, nats://host:port or kafka://host:port (events are only POSTed to subscribers when empty)"`
+	EventSubscribers     []string      `config:"event-subscribers" usage:"Comma-separated URLs that domain events are POSTed to (e.g. http://localhost:6000/events)"`
+	EventRelayInterval   time.Duration `config:"event-relay-interval" min:"1ms" usage:"How often pending domain events are delivered to the bus and subscribers"`
+	InternalKeys         string        `config:"internal-keys,secret" usage:"Comma-separated keyID:secret pairs accepted for HMAC-signed requests from the gateway (signature checks are disabled when empty)"`
+	SignatureMaxSkew     time.Duration `config:"signature-max-skew" min:"0" usage:"Maximum clock difference accepted for signed request timestamps"`
+	AuthServiceURL       string        `config:"auth-service-url" usage:"URL of the Auth Service; when set, its access tokens are accepted alongside session tokens on owner-only routes"`
+	AccessLog            bool          `config:"access-log" usage:"Log every request once it is served"`
+	RequestTimeout       time.Duration `config:"request-timeout" min:"0" usage:"How long a request may take before it is answered with 503 (0 disables it; exports, imports and backups are exempt)"`
+	// Metrics records the requests served; nil disables metrics.
+	Metrics *httpmiddleware.Metrics
+}
+
+// DefaultConfig returns the configuration of the service started without flags.
+func DefaultConfig() Config {
+	return Config{
+		DBDriver:             repository.DriverSQLite,
+		DBDSN:                "users.db",
+		DBConnectTimeout:     30 * time.Second,
+		ReplicaStaleness:     2 * time.Second,
+		WriteQueueDepth:      repository.DefaultWriteQueueDepth,
+		WriteQueueTimeout:    repository.DefaultWriteQueueTimeout,
+		CaseInsensitiveNames: true,
+		BatchMaxSize:         service.DefaultMaxBatchSize,
+		ImportChunkSize:      service.DefaultImportChunkSize,
+		UserCacheTTL:         service.DefaultUserCacheTTL,
+		StatsCacheTTL:        service.DefaultStatsCacheTTL,
+		SessionTTL:           service.DefaultSessionTTL,
+		BackupDir:            "backups",
+		BackupKeep:           7,
+		EventRelayInterval:   2 * time.Second,
+		SignatureMaxSkew:     5 * time.Minute,
+		AccessLog:            true,
+		RequestTimeout:       10 * time.Second,
+	}
+}
+
+// App is an assembled User Service.
+type App struct {
+	// Handler serves the API, wrapped with the shared middleware.
+	Handler http.Handler
+
+	db        *sql.DB
+	replicaDB *sql.DB
+	writes    *repository.WriteQueue
+	events    bus.Bus            // Nil without -event-bus
+	stop      context.CancelFunc // Stops the background workers
+	workers   sync.WaitGroup
+}
+
+// New opens the database of cfg and assembles the service on top of it. The App must be closed
+// to release them.
+func New(cfg Config) (*App, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	a := &App{db: db}
+	if err := a.assemble(cfg); err != nil {
+		a.Close()
+		return nil, err
+	}
+	return a, nil
+}
+
+// Backup takes a backup of the database of cfg while leaving it open to other processes, as
+// the service does for POST /admin/backup.
+func Backup(cfg Config) (*backup.Result, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return backup.NewManager(db, cfg.BackupDir, cfg.BackupKeep).Run()
+}
+
+// connect opens the database of cfg, retrying for cfg.DBConnectTimeout.
+// By default this will create 'users.db' in the current directory if it doesn't exist.
+func connect(cfg Config) (*sql.DB, error) {
+	db, err := repository.ConnectWithRetry(cfg.DBConnectTimeout, func() (*sql.DB, error) {
+		return repository.OpenDatabase(cfg.DBDriver, cfg.DBDSN)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return db, nil
+}
+
+// assemble builds the layers and routes of the service over a.db.
+func (a *App) assemble(cfg Config) error {
+	if err := repository.EnsureUniqueNameIndex(a.db, cfg.CaseInsensitiveNames); err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	// Initialize repository, service, and handler layers
+	// SQLite supports a single writer, so every write goes through one queue
+	a.writes = repository.NewWriteQueue(cfg.WriteQueueDepth, cfg.WriteQueueTimeout)
+	userRepo := repository.NewUserRepository(a.db, a.writes)
+	if cfg.ReadDSN != "" {
+		replicaDB, err := repository.ConnectWithRetry(cfg.DBConnectTimeout, func() (*sql.DB, error) {
+			return repository.OpenReplica(cfg.DBDriver, cfg.ReadDSN)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to initialize read replica: %w", err)
+		}
+		a.replicaDB = replicaDB
+		userRepo = repository.NewReplicaRouter(userRepo, repository.NewUserRepository(replicaDB, nil), cfg.ReplicaStaleness)
+		log.Printf("Serving user reads from replica %s (staleness tolerance %s)", cfg.ReadDSN, cfg.ReplicaStaleness)
+	}
+	auditRepo := repository.NewAuditRepository(a.db, a.writes)
+	userService := service.NewUserService(
+		userRepo,
+		auditRepo,
+		service.WithMaxBatchSize(cfg.BatchMaxSize),
+		service.WithImportChunkSize(cfg.ImportChunkSize),
+		service.WithUserCache(cfg.UserCacheSize, cfg.UserCacheTTL),
+		service.WithStatsCacheTTL(cfg.StatsCacheTTL),
+		service.WithCaseInsensitiveNames(cfg.CaseInsensitiveNames),
+	)
+	sessionService := service.NewSessionService(repository.NewSessionRepository(a.db, a.writes), userService, cfg.SessionTTL)
+	userHandler := handler.NewUserHandler(userService)
+	var authServiceClient *authservice.Client
+	if cfg.AuthServiceURL != "" {
+		authServiceClient = authservice.NewClient(&http.Client{Timeout: 5 * time.Second}, cfg.AuthServiceURL)
+		log.Printf("Accepting Auth Service access tokens, validated by %s", cfg.AuthServiceURL)
+	}
+	sessionHandler := handler.NewSessionHandler(sessionService, authServiceClient)
+	tokenService := service.NewAccessTokenService(repository.NewAccessTokenRepository(a.db, a.writes), userService)
+	tokenHandler := handler.NewAccessTokenHandler(tokenService)
+	backupHandler := handler.NewBackupHandler(backup.NewManager(a.db, cfg.BackupDir, cfg.BackupKeep))
+
+	// Deliver domain events from the outbox to the bus and subscribers in the background.
+	// Without either, events are kept in the outbox until some are configured.
+	ctx, stop := context.WithCancel(context.Background())
+	a.stop = stop
+	if cfg.EventBus != "" {
+		b, err := bus.Open(cfg.EventBus)
+		if err != nil {
+			return fmt.Errorf("failed to open event bus: %w", err)
+		}
+		a.events = b
+		log.Printf("Publishing domain events to %s", cfg.EventBus)
+	}
+	if a.events != nil || len(cfg.EventSubscribers) > 0 {
+		outboxRepo := repository.NewOutboxRepository(a.db, a.writes)
+		relay := events.NewRelay(outboxRepo, a.events, cfg.EventSubscribers, &http.Client{Timeout: 5 * time.Second}, cfg.EventRelayInterval)
+		a.workers.Add(1)
+		go func() {
+			defer a.workers.Done()
+			relay.Run(ctx)
+		}()
+		if len(cfg.EventSubscribers) > 0 {
+			log.Printf("Delivering domain events to %v", cfg.EventSubscribers)
+		}
+	}
+
+	adminToken := cfg.AdminToken
+
+	// Create a new Gorilla Mux router
+	r := mux.NewRouter()
+
+	// Define User Service API routes
+	// GET /users: Get all users with pagination
+	r.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
+	// GET /users/export: Stream all users as CSV or NDJSON
+	r.HandleFunc("/users/export", userHandler.ExportUsers).Methods("GET")
+	// GET /users/count: Count users, optionally filtered
+	r.HandleFunc("/users/count", userHandler.CountUsers).Methods("GET")
+	// POST /users, GET /users/stats, GET and PUT /users/{id}: Create, read and update users, as
+	// specified by api/openapi.yaml
+	api.RegisterUsersRoutes(r, userHandler)
+	// DELETE /users/{id}: Soft-delete a user and emit user.deleted (admin only)
+	r.Handle("/users/{id}", handler.RequireAdmin(adminToken, http.HandlerFunc(userHandler.DeleteUser))).Methods("DELETE")
+	// PUT /users/{id}/status: Activate or suspend a user (admin only)
+	r.Handle("/users/{id}/status", handler.RequireAdmin(adminToken, http.HandlerFunc(userHandler.SetUserStatus))).Methods("PUT")
+	// PUT /users/{id}/attributes/{key}: Set a profile attribute (the user itself or admin)
+	r.Handle("/users/{id}/attributes/{key}", sessionHandler.RequireOwnerOrAdmin(adminToken, http.HandlerFunc(userHandler.SetUserAttribute))).Methods("PUT")
+	// DELETE /users/{id}/attributes/{key}: Delete a profile attribute (the user itself or admin)
+	r.Handle("/users/{id}/attributes/{key}", sessionHandler.RequireOwnerOrAdmin(adminToken, http.HandlerFunc(userHandler.DeleteUserAttribute))).Methods("DELETE")
+	// POST /users/{id}/merge-into/{target}: Merge a duplicate account into another (admin only)
+	r.Handle("/users/{id}/merge-into/{target}", handler.RequireAdmin(adminToken, http.HandlerFunc(userHandler.MergeUser))).Methods("POST")
+	// GET /users/{id}/sessions: List the active sessions of a user (admin only)
+	r.Handle("/users/{id}/sessions", handler.RequireAdmin(adminToken, http.HandlerFunc(sessionHandler.ListSessions))).Methods("GET")
+	// DELETE /users/{id}/sessions/{session_id}: Revoke a session of a user (admin only)
+	r.Handle("/users/{id}/sessions/{session_id}", handler.RequireAdmin(adminToken, http.HandlerFunc(sessionHandler.RevokeSession))).Methods("DELETE")
+	// POST /users/{id}/tokens: Issue a personal access token (the user itself or admin)
+	r.Handle("/users/{id}/tokens", sessionHandler.RequireOwnerOrAdmin(adminToken, http.HandlerFunc(tokenHandler.IssueToken))).Methods("POST")
+	// GET /users/{id}/tokens: List the personal access tokens of a user (the user itself or admin)
+	r.Handle("/users/{id}/tokens", sessionHandler.RequireOwnerOrAdmin(adminToken, http.HandlerFunc(tokenHandler.ListTokens))).Methods("GET")
+	// DELETE /users/{id}/tokens/{token_id}: Revoke a personal access token (the user itself or admin)
+	r.Handle("/users/{id}/tokens/{token_id}", sessionHandler.RequireOwnerOrAdmin(adminToken, http.HandlerFunc(tokenHandler.RevokeToken))).Methods("DELETE")
+	// POST /tokens/introspect: Validate a personal access token (used by the gateway)
+	api.RegisterTokensRoutes(r, tokenHandler)
+	// GET /users/{id}/audit: Get the audit trail of a user (admin only)
+	r.Handle("/users/{id}/audit", handler.RequireAdmin(adminToken, http.HandlerFunc(userHandler.GetAuditLog))).Methods("GET")
+	// POST /login: Log in with a name and password, opening a session
+	r.HandleFunc("/login", sessionHandler.Login).Methods("POST")
+	// POST /credentials/verify: Check a name and password without opening a session (used by the Auth Service)
+	r.HandleFunc("/credentials/verify", sessionHandler.VerifyCredentials).Methods("POST")
+	// POST /users/batch: Create multiple users in a single transaction
+	r.HandleFunc("/users/batch", userHandler.CreateUsersBatch).Methods("POST")
+	// POST /users/import: Import users from a CSV upload
+	r.HandleFunc("/users/import", userHandler.ImportUsers).Methods("POST")
+
+	// POST /admin/backup: Snapshot the database while the service keeps running (admin only)
+	r.Handle("/admin/backup", handler.RequireAdmin(adminToken, http.HandlerFunc(backupHandler.CreateBackup))).Methods("POST")
+
+	// Only accept requests signed by the gateway when signing keys are configured
+	signingKeys, err := signing.ParseKeys(cfg.InternalKeys)
+	if err != nil {
+		return fmt.Errorf("invalid internal keys: %w", err)
+	}
+	if len(signingKeys) > 0 {
+		r.Use(handler.VerifySignature(signing.NewVerifier(signingKeys, cfg.SignatureMaxSkew)))
+		log.Printf("Request signature verification enabled with %d key(s)", len(signingKeys))
+	} else {
+		log.Printf("WARNING: -internal-keys not set, requests are accepted without signature verification")
+	}
+
+	// Wrap the router with the shared middleware: request IDs, recovery, access logs, metrics and timeouts
+	a.Handler = httpmiddleware.Wrap(r, httpmiddleware.Config{
+		AccessLog: cfg.AccessLog,
+		Metrics:   cfg.Metrics,
+		Route:     httpmiddleware.MuxRoute(r),
+		Timeout:   cfg.RequestTimeout,
+		NoTimeout: func(req *http.Request) bool {
+			// Streamed or long-running by design
+			switch req.URL.Path {
+			case "/users/export", "/users/import", "/admin/backup":
+				return true
+			}
+			return false
+		},
+	})
+	return nil
+}
+
+// Close stops the background workers and closes the event bus and the databases.
+func (a *App) Close() {
+	if a.stop != nil {
+		a.stop()
+	}
+	a.workers.Wait()
+	if a.events != nil {
+		if err := a.events.Close(); err != nil {
+			log.Printf("Error closing event bus: %v", err)
+		}
+	}
+	if a.writes != nil {
+		a.writes.Close()
+	}
+	if a.replicaDB != nil {
+		if err := a.replicaDB.Close(); err != nil {
+			log.Printf("Error closing read replica: %v", err)
+		}
+	}
+	if err := a.db.Close(); err != nil {
+		log.Printf("Error closing database: %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// benchOperation is a repository call measured by the "bench" subcommand. It receives the
+// 0-based index of the call.
+type benchOperation struct {
+	name string
+	run  func(repo repository.UserRepository, i int) error
+}
+
+// benchOperations lists the measured calls, in the order they are run. Reads rely on the
+// users inserted by the first operation.
+func benchOperations(ops int) []benchOperation {
+	return []benchOperation{
+		{"CreateUser", func(repo repository.UserRepository, i int) error {
+			_, err := repo.CreateUser(fmt.Sprintf("Bench User %d", i), "")
+			return err
+		}},
+		{"GetUserByID", func(repo repository.UserRepository, i int) error {
+			_, err := repo.GetUserByID(int64(i%ops + 1))
+			return err
+		}},
+		{"GetAllUsers", func(repo repository.UserRepository, i int) error {
+			_, err := repo.GetAllUsers(i%10+1, 10, model.UserFilter{}, model.UserSort{Field: model.SortByCreatedAt, Descending: true})
+			return err
+		}},
+		{"UpdateUser", func(repo repository.UserRepository, i int) error {
+			// Updates are conditional on the version, read first as clients do before a PUT
+			id := int64(i%ops + 1)
+			user, err := repo.GetUserByID(id)
+			if err != nil {
+				return err
+			}
+			if user == nil {
+				return fmt.Errorf("user %d not found", id)
+			}
+			_, err = repo.UpdateUser(id, fmt.Sprintf("Renamed Bench User %d", i), user.Version)
+			return err
+		}},
+	}
+}
+
+// runBench implements the "bench" subcommand, which measures repository throughput with
+// statements prepared on every call and with the statement cache, each against a fresh
+// scratch database.
+func runBench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	ops := fs.Int("ops", 20000, "Number of calls per operation")
+	concurrency := fs.Int("concurrency", 8, "Number of concurrent callers")
+	fs.Parse(args)
+
+	if *ops < 1 || *concurrency < 1 {
+		log.Fatalf("--ops and --concurrency must be positive")
+	}
+
+	dir, err := os.MkdirTemp("", "user-service-bench")
+	if err != nil {
+		log.Fatalf("Failed to create scratch directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	modes := []struct {
+		name string
+		opts []repository.UserRepositoryOption
+	}{
+		{"per-call prepare", []repository.UserRepositoryOption{repository.WithoutStatementCache()}},
+		{"statement cache", nil},
+	}
+
+	operations := benchOperations(*ops)
+	results := make([][]float64, len(modes)) // ops/s, by mode then operation
+	for m, mode := range modes {
+		db, err := repository.NewSQLiteDB(filepath.Join(dir, fmt.Sprintf("bench-%d.db", m)))
+		if err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+		writes := repository.NewWriteQueue(repository.DefaultWriteQueueDepth, time.Minute)
+		repo := repository.NewUserRepository(db, writes, mode.opts...)
+
+		for _, op := range operations {
+			elapsed, err := measure(*ops, *concurrency, func(i int) error { return op.run(repo, i) })
+			if err != nil {
+				log.Fatalf("%s (%s) failed: %v", op.name, mode.name, err)
+			}
+			results[m] = append(results[m], float64(*ops)/elapsed.Seconds())
+			log.Printf("%s (%s): %d calls in %s", op.name, mode.name, *ops, elapsed.Round(time.Millisecond))
+		}
+
+		writes.Close()
+		closeBenchDB(db)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "operation\t%s ops/s\t%s ops/s\tspeedup\t\n", modes[0].name, modes[1].name)
+	for i, op := range operations {
+		fmt.Fprintf(tw, "%s\t%.0f\t%.0f\t%.2fx\t\n", op.name, results[0][i], results[1][i], results[1][i]/results[0][i])
+	}
+	tw.Flush()
+}
+
+// measure runs fn for indices 0..n-1 across the given number of workers and returns the
+// wall-clock time taken, stopping at the first error.
+func measure(n, workers int, fn func(i int) error) (time.Duration, error) {
+	var next atomic.Int64
+	var failed atomic.Pointer[error]
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for failed.Load() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := fn(i); err != nil {
+					failed.CompareAndSwap(nil, &err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if err := failed.Load(); err != nil {
+		return elapsed, *err
+	}
+	return elapsed, nil
+}
+
+// closeBenchDB closes a scratch database, logging failures.
+func closeBenchDB(db *sql.DB) {
+	if err := db.Close(); err != nil {
+		log.Printf("Error closing database: %v", err)
+	}
+}
@@ -1,66 +1,98 @@
 package main
 
 import (
-	"flag"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
-	"user-service/internal/handler"
-	"user-service/internal/repository"
-	"user-service/internal/service"
-
-	"github.com/gorilla/mux"
-	_ "github.com/mattn/go-sqlite3" // Import for SQLite driver
+	"config"
+	"httpmiddleware"
+	"registry"
+	"user-service/app"
 )
 
+// dbPath is the SQLite database file used by default.
+const dbPath = "users.db"
+
+// settings are the settings of the command: those of the service, along with how it is served.
+type settings struct {
+	app.Config
+	Port         int    `config:"port" min:"1" max:"65535" usage:"The port number to run the User Service on"`
+	Debug        bool   `config:"debug" usage:"Runs the application in debug mode (currently no effect on auto-reload)"`
+	Backup       bool   `config:"backup,flag" usage:"Take a database backup and exit instead of starting the server"`
+	MetricsPort  int    `config:"metrics-port" min:"0" max:"65535" usage:"Port serving Prometheus metrics at /metrics, apart from the API (0 disables it)"`
+	RegistryURL  string `config:"registry-url" usage:"URL of the registry to register this instance with, e.g. http://localhost:8500 (the instance does not register when empty)"`
+	AdvertiseURL string `config:"advertise-url" usage:"URL the registry gives out for this instance (default http://<hostname>:<port>)"`
+}
+
 func main() {
-	// Define command-line flags for port and debug mode
-	port := flag.Int("port", 7000, "The port number to run the User Service on")
-	debug := flag.Bool("debug", true, "Runs the application in debug mode (currently no effect on auto-reload)")
-	flag.Parse()
+	// Subcommands are dispatched before the server flags are parsed
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "seed":
+			runSeed(os.Args[2:])
+			return
+		case "bench":
+			runBench(os.Args[2:])
+			return
+		}
+	}
 
-	// Initialize the SQLite database
-	// This will create 'users.db' in the current directory if it doesn't exist.
-	db, err := repository.NewSQLiteDB("users.db")
-	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// Load the settings over their defaults, from a file, the environment and flags
+	s := settings{Config: app.DefaultConfig(), Port: 7000, Debug: true}
+	if err := config.Load(&s, config.Options{EnvPrefix: "USER_SERVICE"}); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
 	}
-	defer func() {
-		if err := db.Close(); err != nil {
-			log.Printf("Error closing database: %v", err)
-		}
-	}()
+	cfg := s.Config
 
-	// Initialize repository, service, and handler layers
-	userRepo := repository.NewSQLiteUserRepository(db)
-	userService := service.NewUserService(userRepo)
-	userHandler := handler.NewUserHandler(userService)
+	if s.Backup {
+		result, err := app.Backup(cfg)
+		if err != nil {
+			log.Fatalf("Backup failed: %v", err)
+		}
+		log.Printf("Backup written to %s (%d bytes, pruned %d old backups)", result.Path, result.SizeBytes, len(result.Pruned))
+		return
+	}
 
-	// Create a new Gorilla Mux router
-	r := mux.NewRouter()
+	if s.MetricsPort != 0 {
+		cfg.Metrics = httpmiddleware.NewMetrics("user-service")
+		go func() {
+			log.Printf("Serving metrics on port %d", s.MetricsPort)
+			if err := cfg.Metrics.ListenAndServe(fmt.Sprintf(":%d", s.MetricsPort)); err != nil {
+				log.Fatalf("Could not serve metrics on port %d: %v", s.MetricsPort, err)
+			}
+		}()
+	}
 
-	// Define User Service API routes
-	// GET /users: Get all users with pagination
-	r.HandleFunc("/users", userHandler.GetAllUsers).Methods("GET")
-	// GET /users/{id}: Get a specific user by ID
-	r.HandleFunc("/users/{id}", userHandler.GetUserByID).Methods("GET")
-	// POST /users: Create a new user
-	r.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	// Initialize the database and the layers serving it
+	userService, err := app.New(cfg)
+	if err != nil {
+		log.Fatalf("Could not start the User Service: %v", err)
+	}
+	defer userService.Close()
 
 	// Configure HTTP server
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", *port),
-		Handler:      r,
+		Addr:         fmt.Sprintf(":%d", s.Port),
+		Handler:      userService.Handler,
 		ReadTimeout:  15 * time.Second, // Max time to read request from client
 		WriteTimeout: 15 * time.Second, // Max time to write response to client
 		IdleTimeout:  60 * time.Second, // Max time for connections to remain idle
 	}
 
+	// Register with the registry, so the gateway reaches this instance as registry://user-service
+	if s.RegistryURL != "" {
+		if err := registry.Announce(context.Background(), s.RegistryURL, "user-service", s.AdvertiseURL, s.Port); err != nil {
+			log.Fatalf("Could not register with the registry: %v", err)
+		}
+	}
+
 	// Start the HTTP server
-	log.Printf("User Service starting on port %d (Debug mode: %t)", *port, *debug)
+	log.Printf("User Service starting on port %d (Debug mode: %t)", s.Port, s.Debug)
 	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Could not listen on port %d: %v", *port, err)
+		log.Fatalf("Could not listen on port %d: %v", s.Port, err)
 	}
 }
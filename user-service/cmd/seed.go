@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"user-service/internal/repository"
+	"user-service/internal/seed"
+)
+
+// runSeed implements the "seed" subcommand, which fills the database with fake users
+// directly through the repository so load tests and demos have data to work with.
+func runSeed(args []string) {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := fs.Int("count", 1000, "Number of users to generate")
+	batchSize := fs.Int("batch-size", 500, "Number of users inserted per transaction")
+	randSeed := fs.Uint64("seed", uint64(time.Now().UnixNano()), "Random seed, for reproducible data sets")
+	dbDriver := fs.String("db-driver", repository.DriverSQLite, "Database backend: sqlite, postgres or mysql")
+	dbDSN := fs.String("db-dsn", dbPath, "Data source of the database: the SQLite file, or a PostgreSQL or MySQL connection string")
+	fs.Parse(args)
+
+	if *count < 1 {
+		log.Fatalf("--count must be positive, got %d", *count)
+	}
+
+	db, err := repository.OpenDatabase(*dbDriver, *dbDSN)
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("Error closing database: %v", err)
+		}
+	}()
+
+	userRepo := repository.NewUserRepository(db, nil) // batches are written one at a time, no queue needed
+	gen := seed.NewGenerator(*randSeed)
+
+	start := time.Now()
+	err = seed.Users(userRepo, gen, *count, *batchSize, func(done int) {
+		log.Printf("Seeded %d/%d users", done, *count)
+	})
+	if err != nil {
+		log.Fatalf("Seeding failed: %v", err)
+	}
+	log.Printf("Seeded %d users in %s (seed %d)", *count, time.Since(start).Round(time.Millisecond), *randSeed)
+}
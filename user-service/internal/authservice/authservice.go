@@ -0,0 +1,70 @@
+// Package authservice is a client for the Auth Service, which issues the short-lived access
+// tokens users may present instead of session tokens.
+package authservice
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// TokenInfo is the part of a valid token reported by the Auth Service's introspection.
+type TokenInfo struct {
+	Type      string   `json:"type"`
+	UserID    int64    `json:"user_id"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt int64    `json:"expires_at,omitempty"`
+}
+
+// Client is a client for the Auth Service.
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+// NewClient creates a new Client for the Auth Service at baseURL.
+func NewClient(httpClient *http.Client, baseURL string) *Client {
+	return &Client{httpClient: httpClient, baseURL: baseURL}
+}
+
+// Introspect asks the Auth Service whether a token is valid. It returns nil and a nil error
+// if the token is unknown, expired or revoked.
+func (c *Client) Introspect(token string) (*TokenInfo, error) {
+	body, err := json.Marshal(map[string]string{"token": token})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode introspection request: %w", err)
+	}
+	req, err := http.NewRequest("POST", c.baseURL+"/introspect", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request to Auth Service: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to Auth Service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Auth Service returned non-OK status: %s", resp.Status)
+	}
+
+	var apiResp struct {
+		Result bool       `json:"result"`
+		Active bool       `json:"active"`
+		Token  *TokenInfo `json:"token,omitempty"`
+		Error  string     `json:"error,omitempty"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		return nil, fmt.Errorf("failed to decode Auth Service response: %w", err)
+	}
+	if !apiResp.Result {
+		return nil, fmt.Errorf("Auth Service reported error: %s", apiResp.Error)
+	}
+	if !apiResp.Active {
+		return nil, nil
+	}
+	return apiResp.Token, nil
+}
@@ -0,0 +1,181 @@
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	// pagesPerStep is the number of database pages copied per backup step.
+	// Copying in small steps keeps the source database available to writers during the backup.
+	pagesPerStep = 256
+	// stepPause is the pause between backup steps, giving other connections a chance to run.
+	stepPause = 10 * time.Millisecond
+	// filePrefix and fileSuffix frame the timestamp in backup file names.
+	filePrefix = "users-"
+	fileSuffix = ".db"
+	// timestampLayout sorts lexicographically in chronological order.
+	timestampLayout = "20060102T150405.000000000"
+)
+
+// Result describes a completed backup.
+type Result struct {
+	Path      string   `json:"path"`       // Location of the new snapshot
+	SizeBytes int64    `json:"size_bytes"` // Size of the snapshot file
+	Pruned    []string `json:"pruned"`     // Older snapshots removed by the retention policy
+	CreatedAt int64    `json:"created_at"` // Timestamp of the backup in microseconds
+}
+
+// Manager snapshots a live SQLite database into a directory using SQLite's
+// online backup API and keeps only the most recent snapshots.
+type Manager struct {
+	db   *sql.DB
+	dir  string
+	keep int
+	mu   sync.Mutex // Serializes backups so retention never races with a running snapshot
+}
+
+// NewManager creates a backup Manager writing snapshots of db into dir and keeping the last keep of them.
+// A non-positive keep disables pruning.
+func NewManager(db *sql.DB, dir string, keep int) *Manager {
+	return &Manager{db: db, dir: dir, keep: keep}
+}
+
+// Run takes a consistent snapshot of the database without stopping the service,
+// then prunes snapshots beyond the retention limit.
+func (m *Manager) Run() (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now()
+	target := filepath.Join(m.dir, filePrefix+now.UTC().Format(timestampLayout)+fileSuffix)
+	if err := m.copyTo(target); err != nil {
+		os.Remove(target) // Never leave a partial snapshot behind
+		return nil, err
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat backup file: %w", err)
+	}
+
+	pruned, err := m.prune()
+	if err != nil {
+		// The snapshot itself succeeded, so only report the retention failure in the logs
+		log.Printf("Error pruning old backups in %s: %v", m.dir, err)
+	}
+
+	return &Result{
+		Path:      target,
+		SizeBytes: info.Size(),
+		Pruned:    pruned,
+		CreatedAt: now.UnixMicro(),
+	}, nil
+}
+
+// copyTo copies the live database into a new SQLite file at target.
+func (m *Manager) copyTo(target string) error {
+	ctx := context.Background()
+
+	destDB, err := sql.Open("sqlite3", target)
+	if err != nil {
+		return fmt.Errorf("failed to open backup target: %w", err)
+	}
+	defer destDB.Close()
+
+	destConn, err := destDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to backup target: %w", err)
+	}
+	defer destConn.Close()
+
+	srcConn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire source connection: %w", err)
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destRaw any) error {
+		return srcConn.Raw(func(srcRaw any) error {
+			dest, ok := destRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("backup target is not a SQLite connection")
+			}
+			src, ok := srcRaw.(*sqlite3.SQLiteConn)
+			if !ok {
+				return errors.New("source database is not a SQLite connection")
+			}
+
+			bk, err := dest.Backup("main", src, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+
+			for {
+				done, err := bk.Step(pagesPerStep)
+				if err != nil {
+					bk.Finish()
+					return fmt.Errorf("failed during backup step: %w", err)
+				}
+				if done {
+					break
+				}
+				time.Sleep(stepPause)
+			}
+
+			if err := bk.Finish(); err != nil {
+				return fmt.Errorf("failed to finish backup: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// prune removes the oldest snapshots so that at most m.keep remain.
+func (m *Manager) prune() ([]string, error) {
+	pruned := []string{}
+	if m.keep <= 0 {
+		return pruned, nil
+	}
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return pruned, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	var backups []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, name)
+		}
+	}
+	if len(backups) <= m.keep {
+		return pruned, nil
+	}
+
+	sort.Strings(backups) // Timestamped names sort oldest first
+	for _, name := range backups[:len(backups)-m.keep] {
+		path := filepath.Join(m.dir, name)
+		if err := os.Remove(path); err != nil {
+			return pruned, fmt.Errorf("failed to remove backup %s: %w", path, err)
+		}
+		pruned = append(pruned, path)
+	}
+	return pruned, nil
+}
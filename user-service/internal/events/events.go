@@ -0,0 +1,60 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// Event types emitted by the user service.
+const (
+	TypeUserCreated = "user.created"
+	TypeUserUpdated = "user.updated"
+	TypeUserMerged  = "user.merged"
+	TypeUserDeleted = "user.deleted"
+)
+
+// UserCreatedPayload is the payload of a user.created event.
+type UserCreatedPayload struct {
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// UserUpdatedPayload is the payload of a user.updated event. Consumers keeping a copy
+// of the user's name should replace it, unless they already saw a later update.
+type UserUpdatedPayload struct {
+	UserID    int64  `json:"user_id"`
+	Name      string `json:"name"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// UserMergedPayload is the payload of a user.merged event. Consumers holding
+// references to the source user should rewrite them to the target user.
+type UserMergedPayload struct {
+	SourceUserID int64 `json:"source_user_id"`
+	TargetUserID int64 `json:"target_user_id"`
+	MergedAt     int64 `json:"merged_at"`
+}
+
+// UserDeletedPayload is the payload of a user.deleted event. Consumers should stop
+// exposing data owned by the deleted user.
+type UserDeletedPayload struct {
+	UserID    int64 `json:"user_id"`
+	DeletedAt int64 `json:"deleted_at"`
+}
+
+// New builds an event of the given type with a JSON-encoded payload, timestamped now.
+func New(eventType string, payload any) (model.Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return model.Event{}, fmt.Errorf("failed to encode %s payload: %w", eventType, err)
+	}
+	return model.Event{
+		Type:      eventType,
+		Payload:   data,
+		CreatedAt: time.Now().UnixMicro(),
+	}, nil
+}
@@ -0,0 +1,138 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-service/internal/model"
+	"user-service/internal/repository"
+
+	"bus"
+)
+
+// relayBatchSize is the maximum number of outbox events delivered per polling round.
+const relayBatchSize = 100
+
+// Relay delivers outbox events to a message bus and to subscriber URLs over HTTP.
+// Each event is published as JSON to the topic of its type, then POSTed to every subscriber;
+// it is marked as published once the bus accepted it and all subscribers acknowledged it with
+// a 2xx status, and retried on the next round otherwise. Delivery is therefore at-least-once,
+// and consumers must handle duplicates.
+type Relay struct {
+	outbox      repository.OutboxRepository
+	publisher   bus.Publisher // Nil when events are only POSTed
+	subscribers []string
+	httpClient  *http.Client
+	interval    time.Duration
+}
+
+// NewRelay creates a new Relay polling the outbox every interval. The publisher may be nil, and
+// subscribers empty.
+func NewRelay(outbox repository.OutboxRepository, publisher bus.Publisher, subscribers []string, httpClient *http.Client, interval time.Duration) *Relay {
+	return &Relay{
+		outbox:      outbox,
+		publisher:   publisher,
+		subscribers: subscribers,
+		httpClient:  httpClient,
+		interval:    interval,
+	}
+}
+
+// Run polls and delivers pending events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.deliverPending(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverPending performs a single polling round.
+func (r *Relay) deliverPending(ctx context.Context) {
+	pending, err := r.outbox.FetchPendingEvents(relayBatchSize)
+	if err != nil {
+		log.Printf("Error fetching pending outbox events: %v", err)
+		return
+	}
+
+	var published []int64
+	for _, event := range pending {
+		if err := r.deliver(ctx, event); err != nil {
+			log.Printf("Error delivering event %d (%s): %v", event.ID, event.Type, err)
+			if err := r.outbox.MarkEventFailed(event.ID, err.Error()); err != nil {
+				log.Printf("Error recording delivery failure of event %d: %v", event.ID, err)
+			}
+			// Stop here so events are delivered in order
+			break
+		}
+		published = append(published, event.ID)
+	}
+
+	if err := r.outbox.MarkEventsPublished(published, time.Now().UnixMicro()); err != nil {
+		log.Printf("Error marking %d events as published: %v", len(published), err)
+	}
+}
+
+// deliver publishes an event to the bus, then sends it to every subscriber.
+func (r *Relay) deliver(ctx context.Context, event model.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	if r.publisher != nil {
+		msg := bus.Message{Topic: event.Type, Key: eventKey(event), Data: body}
+		if err := r.publisher.Publish(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	for _, url := range r.subscribers {
+		req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create request to %s: %w", url, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("failed to send event to %s: %w", url, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("subscriber %s returned non-OK status: %s", url, resp.Status)
+		}
+	}
+	return nil
+}
+
+// eventKey returns the ID of the user an event is about, so that brokers partitioning topics
+// keep the events of a user in order.
+func eventKey(event model.Event) string {
+	var about struct {
+		UserID       int64 `json:"user_id"`
+		TargetUserID int64 `json:"target_user_id"`
+	}
+	json.Unmarshal(event.Payload, &about)
+	if about.UserID == 0 {
+		about.UserID = about.TargetUserID
+	}
+	if about.UserID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(about.UserID, 10)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/model"
+
+	"github.com/gorilla/mux"
+)
+
+// SetUserAttribute handles PUT /users/{id}/attributes/{key} requests.
+// The value is read from a JSON body of the form {"value": "..."} or a form-encoded body.
+func (h *UserHandler) SetUserAttribute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	var value string
+	if isJSONRequest(r) {
+		var requestBody struct {
+			Value *string `json:"value"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxUserBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Value == nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid JSON request body, expected {\"value\": \"...\"}"})
+			return
+		}
+		value = *requestBody.Value
+	} else {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Failed to parse form data"})
+			return
+		}
+		if !r.PostForm.Has("value") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Attribute value is required"})
+			return
+		}
+		value = r.PostForm.Get("value")
+	}
+
+	user, err := h.userService.SetUserAttribute(id, mux.Vars(r)["key"], value, requestMeta(r))
+	writeAttributeResult(w, id, user, err)
+}
+
+// DeleteUserAttribute handles DELETE /users/{id}/attributes/{key} requests.
+func (h *UserHandler) DeleteUserAttribute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	user, err := h.userService.DeleteUserAttribute(id, mux.Vars(r)["key"], requestMeta(r))
+	writeAttributeResult(w, id, user, err)
+}
+
+// writeAttributeResult writes the response to an attribute change of user id.
+func writeAttributeResult(w http.ResponseWriter, id int64, user *model.User, err error) {
+	if err != nil {
+		writeError(w, err, "changing attributes of user %d", id)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "User not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+}
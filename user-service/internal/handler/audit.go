@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"user-service/internal/model"
+
+	"github.com/gorilla/mux"
+)
+
+// AuditResponse is the response structure for audit log requests.
+type AuditResponse struct {
+	Result  bool               `json:"result"`
+	Entries []model.AuditEntry `json:"entries,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
+
+// GetAuditLog handles GET /users/{id}/audit requests.
+// It returns the recorded mutations of a user, most recent first, with pagination.
+func (h *UserHandler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AuditResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	pageNum, err := strconv.Atoi(r.URL.Query().Get("page_num"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1 // Default page number
+	}
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("page_size"))
+	if err != nil || pageSize < 1 {
+		pageSize = 10 // Default page size
+	}
+
+	entries, err := h.userService.GetAuditLog(id, pageNum, pageSize)
+	if err != nil {
+		writeError(w, err, "getting audit log for user %d", id)
+		return
+	}
+
+	json.NewEncoder(w).Encode(AuditResponse{Result: true, Entries: entries})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"user-service/internal/backup"
+)
+
+// BackupHandler handles HTTP requests for database backups.
+type BackupHandler struct {
+	manager *backup.Manager
+}
+
+// NewBackupHandler creates a new instance of BackupHandler.
+func NewBackupHandler(manager *backup.Manager) *BackupHandler {
+	return &BackupHandler{manager: manager}
+}
+
+// BackupResponse is the response structure for backup requests.
+type BackupResponse struct {
+	Result bool           `json:"result"`
+	Backup *backup.Result `json:"backup,omitempty"`
+	Error  string         `json:"error,omitempty"`
+}
+
+// CreateBackup handles POST /admin/backup requests.
+// It snapshots the live database into the configured backup directory.
+func (h *BackupHandler) CreateBackup(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	result, err := h.manager.Run()
+	if err != nil {
+		log.Printf("Error creating database backup: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(BackupResponse{Result: false, Error: "Failed to create backup"})
+		return
+	}
+
+	log.Printf("Database backup written to %s (%d bytes)", result.Path, result.SizeBytes)
+	json.NewEncoder(w).Encode(BackupResponse{Result: true, Backup: result})
+}
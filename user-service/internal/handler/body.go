@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxUserBodyBytes bounds the JSON body of single-user requests.
+const maxUserBodyBytes = 64 << 10
+
+// userInput holds the fields a client may send when creating a user or logging in.
+type userInput struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+// isJSONRequest reports whether the request body is declared as JSON
+// (application/json or any application/*+json media type).
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// parseUserInput reads the user fields from the request body, which may be either
+// JSON or form-encoded depending on its Content-Type.
+func parseUserInput(w http.ResponseWriter, r *http.Request) (userInput, error) {
+	var input userInput
+	if isJSONRequest(r) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxUserBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			return input, errors.New("Invalid JSON request body")
+		}
+		return input, nil
+	}
+
+	// Parse the form data for application/x-www-form-urlencoded
+	if err := r.ParseForm(); err != nil {
+		return input, errors.New("Failed to parse form data")
+	}
+	input.Name = r.FormValue("name")
+	input.Password = r.FormValue("password")
+	return input, nil
+}
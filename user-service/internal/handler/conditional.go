@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// userLastChange returns the time (microseconds) the user's representation last changed:
+// any mutation bumps updated_at, and logins set last_login_at.
+func userLastChange(user *model.User) int64 {
+	return max(user.UpdatedAt, user.LastLoginAt)
+}
+
+// userETag derives a strong entity tag for a user from its ID and last change timestamp,
+// so the tag changes whenever the representation does. Representations trimmed to fields with
+// ?fields= get tags of their own, naming the fields in order, so that a tag of one
+// representation never validates another.
+func userETag(user *model.User, fields []string) string {
+	if fields == nil {
+		return fmt.Sprintf(`"%d-%d"`, user.ID, userLastChange(user))
+	}
+	return fmt.Sprintf(`"%d-%d-%s"`, user.ID, userLastChange(user), strings.Join(slices.Sorted(slices.Values(fields)), "."))
+}
+
+// userLastModified converts the user's last change (microseconds) into a time.Time
+// truncated to the second resolution used by HTTP dates.
+func userLastModified(user *model.User) time.Time {
+	return time.UnixMicro(userLastChange(user)).UTC().Truncate(time.Second)
+}
+
+// setUserValidators writes the ETag and Last-Modified headers for the given user, trimmed to
+// fields unless they are nil.
+func setUserValidators(w http.ResponseWriter, user *model.User, fields []string) {
+	w.Header().Set("ETag", userETag(user, fields))
+	w.Header().Set("Last-Modified", userLastModified(user).Format(http.TimeFormat))
+}
+
+// notModified reports whether the request's conditional headers match the current
+// representation of the user, trimmed to fields unless they are nil, in which case a 304
+// response should be sent. If-None-Match takes precedence over If-Modified-Since as mandated
+// by RFC 7232.
+func notModified(r *http.Request, user *model.User, fields []string) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etagMatches(inm, userETag(user, fields))
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false // Ignore malformed dates and serve the full response
+		}
+		return !userLastModified(user).After(t)
+	}
+
+	return false
+}
+
+// etagMatches performs a weak comparison of the current ETag against an
+// If-None-Match header value, which may be "*" or a comma-separated list.
+func etagMatches(header, current string) bool {
+	current = strings.TrimPrefix(current, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == current {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// CountResponse is the response structure for count requests.
+type CountResponse struct {
+	Result bool   `json:"result"`
+	Count  int64  `json:"count"`
+	Error  string `json:"error,omitempty"`
+}
+
+// CountUsers handles GET /users/count requests.
+// It returns the number of users matching the optional created_after, created_before
+// and deleted filters, without fetching any rows.
+func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(CountResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	count, err := h.userService.CountUsers(filter)
+	if err != nil {
+		writeError(w, err, "counting users")
+		return
+	}
+
+	json.NewEncoder(w).Encode(CountResponse{Result: true, Count: count})
+}
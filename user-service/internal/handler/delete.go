@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// DeleteUser handles DELETE /users/{id} requests.
+// It soft-deletes the user and returns it as it was before the deletion.
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	user, err := h.userService.DeleteUser(id, requestMeta(r))
+	if err != nil {
+		writeError(w, err, "deleting user %d", id)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "User not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+}
@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"user-service/internal/model"
+	"user-service/internal/service"
+)
+
+// exportFlushEvery controls how many rows are written between flushes of the response stream.
+const exportFlushEvery = 500
+
+// parseTimestampParam parses a timestamp query parameter given either as
+// microseconds since the Unix epoch or as an RFC3339 string.
+// An empty value yields 0, meaning "not set".
+func parseTimestampParam(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if micros, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return micros, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return 0, fmt.Errorf("timestamp must be in microseconds or RFC3339 format: %q", value)
+	}
+	return t.UnixMicro(), nil
+}
+
+// parseUserFilter builds a UserFilter from the created_after, created_before and deleted query parameters.
+// deleted accepts "false" (active users only, the default), "true" (soft-deleted users only) or "all".
+func parseUserFilter(r *http.Request) (model.UserFilter, error) {
+	var filter model.UserFilter
+	var err error
+	switch deleted := r.URL.Query().Get("deleted"); deleted {
+	case "", "false":
+		filter.Deleted = model.DeletedExclude
+	case "true":
+		filter.Deleted = model.DeletedOnly
+	case "all":
+		filter.Deleted = model.DeletedAny
+	default:
+		return filter, fmt.Errorf("invalid deleted: expected 'true', 'false' or 'all', got %q", deleted)
+	}
+	filter.Status = r.URL.Query().Get("status")
+	if filter.CreatedAfter, err = parseTimestampParam(r.URL.Query().Get("created_after")); err != nil {
+		return filter, fmt.Errorf("invalid created_after: %w", err)
+	}
+	if filter.CreatedBefore, err = parseTimestampParam(r.URL.Query().Get("created_before")); err != nil {
+		return filter, fmt.Errorf("invalid created_before: %w", err)
+	}
+	return filter, nil
+}
+
+// userRowWriter writes exported users in a specific format.
+type userRowWriter interface {
+	WriteUser(user *model.User) error
+	Flush() error
+}
+
+// csvUserWriter writes users as CSV rows, preceded by a header row.
+// The header is written lazily so nothing reaches the client before the query has started.
+type csvUserWriter struct {
+	w             *csv.Writer
+	headerWritten bool
+}
+
+func (c *csvUserWriter) writeHeader() error {
+	if c.headerWritten {
+		return nil
+	}
+	c.headerWritten = true
+	return c.w.Write([]string{"id", "name", "created_at", "updated_at"})
+}
+
+func (c *csvUserWriter) WriteUser(user *model.User) error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	return c.w.Write([]string{
+		strconv.FormatInt(user.ID, 10),
+		user.Name,
+		strconv.FormatInt(user.CreatedAt, 10),
+		strconv.FormatInt(user.UpdatedAt, 10),
+	})
+}
+
+func (c *csvUserWriter) Flush() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// ndjsonUserWriter writes users as newline-delimited JSON objects.
+type ndjsonUserWriter struct {
+	enc *json.Encoder
+}
+
+func (n *ndjsonUserWriter) WriteUser(user *model.User) error {
+	return n.enc.Encode(user) // Encode appends the trailing newline
+}
+
+func (n *ndjsonUserWriter) Flush() error {
+	return nil // Writes go straight to the response writer
+}
+
+// ExportUsers handles GET /users/export requests.
+// It streams every user as CSV (default) or NDJSON, optionally filtered by
+// created_after / created_before, without materializing the result set in memory.
+func (h *UserHandler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid format. Supported values: 'csv', 'ndjson'"})
+		return
+	}
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	// Exports can outlive the server's write timeout, so lift the deadline for this response only
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("Could not clear write deadline for export: %v", err)
+	}
+
+	var writer userRowWriter
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+		writer = &csvUserWriter{w: csv.NewWriter(w)}
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="users.ndjson"`)
+		writer = &ndjsonUserWriter{enc: json.NewEncoder(w)}
+	}
+
+	count := 0
+	err = h.userService.ExportUsers(filter, func(user *model.User) error {
+		if err := writer.WriteUser(user); err != nil {
+			return err
+		}
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			return rc.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFilter) && count == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Del("Content-Disposition")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+			return
+		}
+		// Headers (and possibly rows) are already sent, so the stream can only be cut short
+		log.Printf("Error exporting users after %d rows: %v", count, err)
+		return
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Printf("Error flushing user export: %v", err)
+	}
+}
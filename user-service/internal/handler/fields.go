@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"user-service/internal/model"
+)
+
+// userFields lists the JSON fields of a user that can be selected with ?fields=.
+var userFields = []string{
+	"id", "name", "created_at", "updated_at", "version",
+	"deleted_at", "merged_into", "last_login_at", "status", "attributes",
+}
+
+// projectedUser is a user trimmed to the requested fields.
+type projectedUser map[string]json.RawMessage
+
+// ProjectedResponse is the response structure for user reads restricted with ?fields=.
+type ProjectedResponse struct {
+	Result bool            `json:"result"`
+	Users  []projectedUser `json:"users,omitempty"`
+	User   projectedUser   `json:"user,omitempty"`
+}
+
+// parseUserFields reads the comma-separated fields query parameter. It returns nil when the
+// parameter is absent, meaning users are returned in full.
+func parseUserFields(r *http.Request) ([]string, error) {
+	raw := r.URL.Query().Get("fields")
+	if raw == "" {
+		return nil, nil
+	}
+	var fields []string
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if !slices.Contains(userFields, field) {
+			return nil, fmt.Errorf("Invalid fields. Supported values: %s", strings.Join(userFields, ", "))
+		}
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// projectUser returns the given fields of user. Fields that are omitted from the full
+// representation when empty (such as deleted_at) are omitted here as well.
+func projectUser(user *model.User, fields []string) (projectedUser, error) {
+	data, err := json.Marshal(user)
+	if err != nil {
+		return nil, err
+	}
+	var full projectedUser
+	if err := json.Unmarshal(data, &full); err != nil {
+		return nil, err
+	}
+	projected := make(projectedUser, len(fields))
+	for _, field := range fields {
+		if value, ok := full[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected, nil
+}
+
+// writeUsers writes a successful response carrying users, trimmed to fields if any are given.
+func writeUsers(w http.ResponseWriter, users []model.User, fields []string) {
+	if fields == nil {
+		json.NewEncoder(w).Encode(APIResponse{Result: true, Users: users})
+		return
+	}
+	projected := make([]projectedUser, len(users))
+	for i := range users {
+		var err error
+		if projected[i], err = projectUser(&users[i], fields); err != nil {
+			writeProjectionError(w, err)
+			return
+		}
+	}
+	json.NewEncoder(w).Encode(ProjectedResponse{Result: true, Users: projected})
+}
+
+// writeUser writes a successful response carrying user, trimmed to fields if any are given.
+func writeUser(w http.ResponseWriter, user *model.User, fields []string) {
+	if fields == nil {
+		json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+		return
+	}
+	projected, err := projectUser(user, fields)
+	if err != nil {
+		writeProjectionError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(ProjectedResponse{Result: true, User: projected})
+}
+
+// writeProjectionError reports a failure to trim a user to the requested fields.
+func writeProjectionError(w http.ResponseWriter, err error) {
+	log.Printf("Error projecting user fields: %v", err)
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+}
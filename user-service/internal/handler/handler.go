@@ -2,10 +2,14 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
 
+	"contracts"
+	"user-service/internal/api"
 	"user-service/internal/model"
 	"user-service/internal/service"
 
@@ -17,6 +21,9 @@ type UserHandler struct {
 	userService *service.UserService
 }
 
+// UserHandler implements the operations of the spec's users tag.
+var _ api.UsersHandler = (*UserHandler)(nil)
+
 // NewUserHandler creates a new instance of UserHandler.
 func NewUserHandler(userService *service.UserService) *UserHandler {
 	return &UserHandler{userService: userService}
@@ -27,11 +34,25 @@ type APIResponse struct {
 	Result bool         `json:"result"`
 	Users  []model.User `json:"users,omitempty"`
 	User   *model.User  `json:"user,omitempty"`
+	Code   string       `json:"code,omitempty"`
 	Error  string       `json:"error,omitempty"`
 }
 
+// BatchResponse is the response structure for batch operations, reporting a result per item.
+type BatchResponse struct {
+	Result  bool                      `json:"result"`
+	Results []service.BatchItemResult `json:"results,omitempty"`
+	Code    string                    `json:"code,omitempty"`
+	Error   string                    `json:"error,omitempty"`
+}
+
+// maxBatchBodyBytes bounds the size of a batch request body.
+const maxBatchBodyBytes = 1 << 20 // 1 MiB
+
 // GetAllUsers handles GET /users requests.
-// It retrieves all users from the service, applying pagination if parameters are provided.
+// It retrieves all users from the service, applying pagination, sorting and
+// created_at range filters if parameters are provided, and can be restricted to some
+// fields with ?fields=id,name.
 func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -49,19 +70,73 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		pageSize = 10 // Default page size
 	}
 
-	users, err := h.userService.GetAllUsers(pageNum, pageSize)
+	sort, err := parseUserSort(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	filter, err := parseUserFilter(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	fields, err := parseUserFields(r)
 	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	users, err := h.userService.GetAllUsers(pageNum, pageSize, filter, sort)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSort) || errors.Is(err, service.ErrInvalidFilter) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+			return
+		}
 		log.Printf("Error getting all users: %v", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
 		return
 	}
 
-	json.NewEncoder(w).Encode(APIResponse{Result: true, Users: users})
+	writeUsers(w, users, fields)
+}
+
+// parseUserSort reads the sort (name or created_at, default created_at) and order
+// (asc or desc) query parameters. The order defaults to newest first for created_at
+// and alphabetical for name.
+func parseUserSort(r *http.Request) (model.UserSort, error) {
+	sort := model.UserSort{Field: model.SortByCreatedAt}
+	switch field := r.URL.Query().Get("sort"); field {
+	case "", string(model.SortByCreatedAt):
+		sort.Descending = true
+	case string(model.SortByName):
+		sort.Field = model.SortByName
+	default:
+		return sort, errors.New("Invalid sort. Supported values: 'name', 'created_at'")
+	}
+
+	switch order := r.URL.Query().Get("order"); order {
+	case "":
+	case "asc":
+		sort.Descending = false
+	case "desc":
+		sort.Descending = true
+	default:
+		return sort, errors.New("Invalid order. Supported values: 'asc', 'desc'")
+	}
+
+	return sort, nil
 }
 
 // GetUserByID handles GET /users/{id} requests.
-// It retrieves a single user by their ID extracted from the URL path.
+// It retrieves a single user by their ID extracted from the URL path, optionally
+// restricted to some fields with ?fields=id,name.
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
@@ -75,11 +150,16 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := parseUserFields(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
 	user, err := h.userService.GetUserByID(id)
 	if err != nil {
-		log.Printf("Error getting user by ID %d: %v", id, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+		writeError(w, err, "getting user by ID %d", id)
 		return
 	}
 
@@ -89,14 +169,101 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+	// Expose validators so callers (e.g. the gateway's user cache) can revalidate cheaply
+	setUserValidators(w, user, fields)
+	if notModified(r, user, fields) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeUser(w, user, fields)
 }
 
 // CreateUser handles POST /users requests.
-// It parses form data to create a new user.
+// It accepts either form data or a JSON body of the form {"name": "...", "password": "..."}
+// to create a new user; the password is optional.
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	input, err := parseUserInput(w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	name := input.Name
+	if err := (contracts.CreateUserRequest{Name: name, Password: input.Password}).Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	user, err := h.userService.CreateUser(name, input.Password, requestMeta(r))
+	if err != nil {
+		writeError(w, err, "creating user with name '%s'", name)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+}
+
+// CreateUsersBatch handles POST /users/batch requests.
+// It accepts a JSON body of the form {"users": [{"name": "..."}, ...]} and
+// returns a per-item result, with all valid users inserted in a single transaction.
+func (h *UserHandler) CreateUsersBatch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var requestBody struct {
+		Users []struct {
+			Name string `json:"name"`
+		} `json:"users"`
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(BatchResponse{Result: false, Error: "Invalid request body"})
+		return
+	}
+
+	names := make([]string, len(requestBody.Users))
+	for i, u := range requestBody.Users {
+		names[i] = u.Name
+	}
+
+	results, err := h.userService.CreateUsers(names, requestMeta(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrBatchTooLarge):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(BatchResponse{
+				Result: false,
+				Error:  fmt.Sprintf("Batch size exceeds the maximum of %d users", h.userService.MaxBatchSize()),
+			})
+		default:
+			writeError(w, err, "creating users batch of %d", len(names))
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(BatchResponse{Result: true, Results: results})
+}
+
+// UpdateUser handles PUT /users/{id} requests.
+// It parses form data to update a user's name. The caller must state which version it is
+// updating, either with an If-Match header carrying the user's ETag or a "version" form field;
+// a stale version results in 409 Conflict so concurrent edits cannot clobber each other.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
 	// Parse the form data for application/x-www-form-urlencoded
 	if err := r.ParseForm(); err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -104,20 +271,98 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	name := r.FormValue("name")
-	if name == "" {
+	input, err := contracts.ParseUpdateUserRequest(r.Form)
+	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "User name is required"})
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
 		return
 	}
+	name := input.Name
 
-	user, err := h.userService.CreateUser(name)
+	expectedVersion, status, errMsg := h.expectedVersion(r, id, input.Version)
+	if status != http.StatusOK {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: errMsg})
+		return
+	}
+
+	user, err := h.userService.UpdateUser(id, name, expectedVersion, requestMeta(r))
 	if err != nil {
-		log.Printf("Error creating user with name '%s': %v", name, err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+		// Conflicting versions answer with the latest user, rather than a problem
+		if errors.Is(err, service.ErrVersionConflict) {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(APIResponse{
+				Result: false,
+				User:   user,
+				Error:  "User was modified by another request; fetch the latest version and retry",
+			})
+			return
+		}
+		writeError(w, err, "updating user %d", id)
 		return
 	}
 
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "User not found"})
+		return
+	}
+
+	setUserValidators(w, user, nil)
 	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
 }
+
+// expectedVersion determines the version an update is based on, from the If-Match header
+// and/or the version form field, 0 when unset. It returns an HTTP status other than 200 along
+// with an error message when the precondition is missing or already known to be stale.
+func (h *UserHandler) expectedVersion(r *http.Request, id int64, version int64) (int64, int, string) {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" && version == 0 {
+		return 0, http.StatusPreconditionRequired, "An If-Match header or version field is required"
+	}
+
+	if ifMatch != "" {
+		current, err := h.userService.GetUserByID(id)
+		if err != nil {
+			log.Printf("Error getting user by ID %d: %v", id, err)
+			return 0, http.StatusInternalServerError, "Internal server error"
+		}
+		if current == nil {
+			return 0, http.StatusNotFound, "User not found"
+		}
+		if !etagMatches(ifMatch, userETag(current, nil)) || (version != 0 && version != current.Version) {
+			return 0, http.StatusConflict, "User was modified by another request; fetch the latest version and retry"
+		}
+		version = current.Version
+	}
+
+	return version, http.StatusOK, ""
+}
+
+// MergeUser handles POST /users/{id}/merge-into/{target} requests.
+// It merges the duplicate account {id} into {target}, soft-deleting {id}, and returns the target user.
+func (h *UserHandler) MergeUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	sourceID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil || sourceID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+	targetID, err := strconv.ParseInt(vars["target"], 10, 64)
+	if err != nil || targetID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid target user ID format"})
+		return
+	}
+
+	target, err := h.userService.MergeUsers(sourceID, targetID, requestMeta(r))
+	if err != nil {
+		writeError(w, err, "merging user %d into %d", sourceID, targetID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: target})
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"user-service/internal/service"
+)
+
+// maxImportBodyBytes bounds the size of an import upload.
+const maxImportBodyBytes = 32 << 20 // 32 MiB
+
+// ImportResponse is the response structure for user imports.
+type ImportResponse struct {
+	Result bool                  `json:"result"`
+	Report *service.ImportReport `json:"report,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
+
+// ImportUsers handles POST /users/import requests.
+// The CSV can be uploaded as the "file" field of a multipart/form-data request or
+// sent directly as a text/csv body. The upload is streamed, never fully buffered.
+func (h *UserHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodyBytes)
+
+	src, err := importSource(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(ImportResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	report, err := h.userService.ImportUsersCSV(src, requestMeta(r))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.As(err, &maxBytesErr):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(ImportResponse{Result: false, Error: "Import file is too large"})
+		default:
+			writeError(w, err, "importing users")
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(ImportResponse{Result: true, Report: report})
+}
+
+// importSource returns a reader over the uploaded CSV content.
+func importSource(r *http.Request) (io.Reader, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		// Anything other than multipart is treated as a raw CSV body
+		return r.Body, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("Failed to parse multipart form data")
+	}
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, errors.New("Multipart form must contain a 'file' field")
+		}
+		if err != nil {
+			return nil, errors.New("Failed to parse multipart form data")
+		}
+		if part.FormName() == "file" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"apperrors"
+	"httpmiddleware"
+	"user-service/internal/model"
+	"user-service/internal/service"
+)
+
+// Headers used to identify the caller of a request.
+const (
+	actorHeader      = "X-Actor"
+	adminTokenHeader = "X-Admin-Token"
+)
+
+// defaultActor is recorded when the caller does not identify itself.
+const defaultActor = "anonymous"
+
+// requestMeta extracts the actor from the request headers, along with the request ID given by
+// the httpmiddleware.RequestID middleware (which echoes it back in the response).
+func requestMeta(r *http.Request) model.RequestMeta {
+	actor := strings.TrimSpace(r.Header.Get(actorHeader))
+	if actor == "" {
+		actor = defaultActor
+	}
+
+	return model.RequestMeta{Actor: actor, RequestID: httpmiddleware.RequestIDFromContext(r.Context())}
+}
+
+// RequireAdmin wraps a handler so it only serves requests carrying the configured
+// admin token in the X-Admin-Token header. When no token is configured, admin
+// endpoints are disabled entirely.
+func RequireAdmin(adminToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if adminToken == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin API is disabled"})
+			return
+		}
+
+		provided := r.Header.Get(adminTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) != 1 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Admin token is missing or invalid"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// busyMessage is returned when a write was rejected because the database is saturated.
+const busyMessage = "Server is busy, please retry shortly"
+
+// writeError answers an error of UserService with the status of its code, logging internal
+// errors with the action they interrupted. Taken names carry ErrorCodeNameTaken, and a saturated
+// database asks the client to retry after a short delay.
+func writeError(w http.ResponseWriter, err error, action string, args ...any) {
+	problem := apperrors.NewProblem(err)
+	switch {
+	case errors.Is(err, service.ErrNameTaken):
+		problem.Code, problem.Detail = service.ErrorCodeNameTaken, "User name is already taken"
+	case errors.Is(err, service.ErrBusy):
+		w.Header().Set("Retry-After", "1")
+		problem.Detail = busyMessage
+	case problem.Status == http.StatusInternalServerError:
+		log.Printf("Error "+action+": %v", append(args, err)...)
+	}
+	apperrors.WriteProblem(w, problem)
+}
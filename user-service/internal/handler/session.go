@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"user-service/internal/authservice"
+	"user-service/internal/model"
+	"user-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// SessionHandler handles HTTP requests related to logins and sessions.
+type SessionHandler struct {
+	sessionService *service.SessionService
+	authService    *authservice.Client
+}
+
+// NewSessionHandler creates a new instance of SessionHandler. When authService is not nil,
+// bearer tokens that are not session tokens are validated by the Auth Service.
+func NewSessionHandler(sessionService *service.SessionService, authService *authservice.Client) *SessionHandler {
+	return &SessionHandler{sessionService: sessionService, authService: authService}
+}
+
+// LoginResponse is the response structure for logins.
+type LoginResponse struct {
+	Result  bool           `json:"result"`
+	Token   string         `json:"token,omitempty"`
+	Session *model.Session `json:"session,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// SessionsResponse is the response structure for session listings.
+type SessionsResponse struct {
+	Result   bool            `json:"result"`
+	Sessions []model.Session `json:"sessions,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// Login handles POST /login requests.
+// It accepts either form data or a JSON body with the user's name and password and, on success,
+// returns a new session along with its token.
+func (h *SessionHandler) Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	input, err := parseUserInput(w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(LoginResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	session, token, err := h.sessionService.Login(input.Name, input.Password, r.UserAgent(), clientIP(r))
+	if err != nil {
+		writeError(w, err, "logging in user '%s'", input.Name)
+		return
+	}
+
+	json.NewEncoder(w).Encode(LoginResponse{Result: true, Token: token, Session: session})
+}
+
+// VerifyCredentials handles POST /credentials/verify requests, used by the Auth Service to check
+// passwords. It accepts the same body as POST /login and returns the user on success, without
+// opening a session or recording a login.
+func (h *SessionHandler) VerifyCredentials(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	input, err := parseUserInput(w, r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	user, err := h.sessionService.VerifyCredentials(input.Name, input.Password)
+	if err != nil {
+		writeError(w, err, "verifying credentials of user '%s'", input.Name)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+}
+
+// ListSessions handles GET /users/{id}/sessions requests.
+// It returns the user's sessions that are neither expired nor revoked.
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(SessionsResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	sessions, err := h.sessionService.ListSessions(userID)
+	if err != nil {
+		writeError(w, err, "listing sessions of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(SessionsResponse{Result: true, Sessions: sessions})
+}
+
+// RevokeSession handles DELETE /users/{id}/sessions/{session_id} requests.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+	sessionID, err := strconv.ParseInt(vars["session_id"], 10, 64)
+	if err != nil || sessionID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid session ID format"})
+		return
+	}
+
+	if err := h.sessionService.RevokeSession(userID, sessionID); err != nil {
+		writeError(w, err, "revoking session %d of user %d", sessionID, userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true})
+}
+
+// RequireOwnerOrAdmin wraps a handler for routes under /users/{id} so it only serves the admin
+// (X-Admin-Token, when configured) or the user {id} itself, authenticated with a session token
+// in an "Authorization: Bearer <token>" header. When the Auth Service is configured, the user may
+// also present one of its access tokens (or a personal access token), which must grant
+// users:read for GET requests and users:write otherwise.
+func (h *SessionHandler) RequireOwnerOrAdmin(adminToken string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provided := r.Header.Get(adminTokenHeader)
+		if adminToken != "" && provided != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := bearerToken(r)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "A session token or admin token is required"})
+			return
+		}
+		userID, err := h.authenticate(token, r.Method)
+		if err != nil {
+			log.Printf("Error authenticating session: %v", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+			return
+		}
+		if userID == 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Session is invalid or expired"})
+			return
+		}
+		if strconv.FormatInt(userID, 10) != mux.Vars(r)["id"] {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Access to another user's resources is not allowed"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticate returns the ID of the user a bearer token acts as, or 0 if the token is not valid
+// for a request with the given method. Session tokens are checked first, then the Auth Service
+// is asked, if configured.
+func (h *SessionHandler) authenticate(token, method string) (int64, error) {
+	session, err := h.sessionService.Authenticate(token)
+	if err != nil {
+		return 0, err
+	}
+	if session != nil {
+		return session.UserID, nil
+	}
+	if h.authService == nil {
+		return 0, nil
+	}
+
+	info, err := h.authService.Introspect(token)
+	if err != nil || info == nil {
+		return 0, err
+	}
+	scope := model.ScopeUsersWrite
+	if method == http.MethodGet {
+		scope = model.ScopeUsersRead
+	}
+	if !slices.Contains(info.Scopes, scope) {
+		return 0, nil
+	}
+	return info.UserID, nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// clientIP returns the IP address of the client that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
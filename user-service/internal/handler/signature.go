@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"user-service/internal/signing"
+)
+
+// maxSignedBodyBytes bounds the body read for signature verification.
+// It matches the largest body accepted by any endpoint (user imports).
+const maxSignedBodyBytes = maxImportBodyBytes
+
+// maxBufferedSignedBodyBytes is the size up to which bodies are held in memory while their
+// signature is verified. Larger bodies, i.e. user imports, are spooled to a temporary file, so
+// the handler still gets them only once they are verified without holding them in memory.
+const maxBufferedSignedBodyBytes = 1 << 20 // 1 MiB
+
+// VerifySignature returns a middleware rejecting requests that do not carry a valid
+// HMAC signature from the gateway, so the internal API cannot be invoked by anything else on the network.
+func VerifySignature(verifier *signing.Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keyID := r.Header.Get(signing.HeaderKeyID)
+			timestamp := r.Header.Get(signing.HeaderTimestamp)
+			signature := r.Header.Get(signing.HeaderSignature)
+			// Reject requests that cannot be valid before reading their body
+			if err := verifier.Check(keyID, timestamp, signature); err != nil {
+				rejectSignature(w, r, err)
+				return
+			}
+
+			bodyHash, body, err := spoolBody(http.MaxBytesReader(w, r.Body, maxSignedBodyBytes))
+			if err != nil {
+				w.Header().Set("Content-Type", "application/json")
+				var maxBytesErr *http.MaxBytesError
+				switch {
+				case errors.As(err, &maxBytesErr):
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+					json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Request body is too large"})
+				case errors.Is(err, errSpool):
+					log.Printf("Error reading signed request %s %s: %v", r.Method, r.URL.RequestURI(), err)
+					w.WriteHeader(http.StatusInternalServerError)
+					json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Internal server error"})
+				default:
+					w.WriteHeader(http.StatusBadRequest)
+					json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Failed to read request body"})
+				}
+				return
+			}
+			defer body.Close()
+			r.Body.Close()
+			r.Body = body
+
+			if err := verifier.Verify(r.Method, r.URL.RequestURI(), keyID, timestamp, signature, bodyHash); err != nil {
+				rejectSignature(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// rejectSignature answers a request whose signature is missing or invalid.
+func rejectSignature(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Rejected request %s %s from %s: %v", r.Method, r.URL.RequestURI(), r.RemoteAddr, err)
+	status := http.StatusForbidden
+	if errors.Is(err, signing.ErrMissingSignature) {
+		status = http.StatusUnauthorized
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Request signature is missing or invalid"})
+}
+
+// errSpool wraps the failures to spool a body to a temporary file.
+var errSpool = errors.New("failed to spool request body")
+
+// spoolBody reads body while hashing it, returning its SHA-256 hash and a reader replaying it.
+// Bodies larger than maxBufferedSignedBodyBytes are replayed from a temporary file, removed
+// when the reader is closed.
+func spoolBody(body io.Reader) ([]byte, io.ReadCloser, error) {
+	hash := sha256.New()
+	var buffered bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(hash, &buffered), io.LimitReader(body, maxBufferedSignedBodyBytes+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if n <= maxBufferedSignedBodyBytes {
+		return hash.Sum(nil), io.NopCloser(&buffered), nil
+	}
+
+	f, err := os.CreateTemp("", "signed-body-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", errSpool, err)
+	}
+	spooled := &spooledBody{f}
+	if _, err := f.Write(buffered.Bytes()); err != nil {
+		spooled.Close()
+		return nil, nil, fmt.Errorf("%w: %v", errSpool, err)
+	}
+	if _, err := io.Copy(io.MultiWriter(hash, f), body); err != nil {
+		spooled.Close()
+		return nil, nil, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, nil, fmt.Errorf("%w: %v", errSpool, err)
+	}
+	return hash.Sum(nil), spooled, nil
+}
+
+// spooledBody replays a request body from a temporary file.
+type spooledBody struct {
+	*os.File
+}
+
+// Close closes and removes the file.
+func (b *spooledBody) Close() error {
+	b.File.Close()
+	return os.Remove(b.File.Name())
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"contracts"
+	"user-service/internal/model"
+)
+
+// StatsResponse is the response structure for signup statistics requests.
+type StatsResponse struct {
+	Result bool               `json:"result"`
+	Stats  *model.SignupStats `json:"stats,omitempty"`
+	Error  string             `json:"error,omitempty"`
+}
+
+// GetSignupStats handles GET /users/stats requests.
+// It returns user totals and signups per day and per week; the optional days and weeks
+// parameters choose how many periods are returned.
+func (h *UserHandler) GetSignupStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	params, err := contracts.ParseGetSignupStatsParams(r.URL.Query())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(StatsResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	stats, err := h.userService.SignupStats(params.Days, params.Weeks)
+	if err != nil {
+		writeError(w, err, "computing signup statistics")
+		return
+	}
+
+	json.NewEncoder(w).Encode(StatsResponse{Result: true, Stats: stats})
+}
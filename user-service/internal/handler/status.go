@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// SetUserStatus handles PUT /users/{id}/status requests.
+// The new status ("active" or "suspended") is read from a JSON or form-encoded body.
+func (h *UserHandler) SetUserStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	var status string
+	if isJSONRequest(r) {
+		var requestBody struct {
+			Status string `json:"status"`
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxUserBodyBytes)
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Invalid JSON request body"})
+			return
+		}
+		status = requestBody.Status
+	} else {
+		if err := r.ParseForm(); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "Failed to parse form data"})
+			return
+		}
+		status = r.FormValue("status")
+	}
+
+	user, err := h.userService.SetUserStatus(id, status, requestMeta(r))
+	if err != nil {
+		writeError(w, err, "setting status of user %d", id)
+		return
+	}
+
+	if user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(APIResponse{Result: false, Error: "User not found"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(APIResponse{Result: true, User: user})
+}
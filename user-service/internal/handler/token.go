@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"contracts"
+	"user-service/internal/api"
+	"user-service/internal/model"
+	"user-service/internal/service"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessTokenHandler handles HTTP requests related to personal access tokens.
+type AccessTokenHandler struct {
+	tokenService *service.AccessTokenService
+}
+
+// AccessTokenHandler implements the operations of the spec's tokens tag.
+var _ api.TokensHandler = (*AccessTokenHandler)(nil)
+
+// NewAccessTokenHandler creates a new instance of AccessTokenHandler.
+func NewAccessTokenHandler(tokenService *service.AccessTokenService) *AccessTokenHandler {
+	return &AccessTokenHandler{tokenService: tokenService}
+}
+
+// AccessTokenResponse is the response structure for personal access token operations.
+type AccessTokenResponse struct {
+	Result       bool                `json:"result"`
+	Token        string              `json:"token,omitempty"`
+	Active       *bool               `json:"active,omitempty"`
+	AccessToken  *model.AccessToken  `json:"access_token,omitempty"`
+	AccessTokens []model.AccessToken `json:"access_tokens,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+// maxTokenBodyBytes bounds the JSON body of token requests.
+const maxTokenBodyBytes = 16 << 10
+
+// IssueToken handles POST /users/{id}/tokens requests.
+// It accepts a JSON body of the form {"name": "...", "scopes": ["..."], "expires_in_days": 90}
+// and returns the new token, which is only ever shown in this response.
+func (h *AccessTokenHandler) IssueToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	var requestBody struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxTokenBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid request body"})
+		return
+	}
+
+	ttl := time.Duration(requestBody.ExpiresInDays) * 24 * time.Hour
+	token, plaintext, err := h.tokenService.IssueToken(userID, requestBody.Name, requestBody.Scopes, ttl)
+	if err != nil {
+		writeError(w, err, "issuing access token for user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(AccessTokenResponse{Result: true, Token: plaintext, AccessToken: token})
+}
+
+// ListTokens handles GET /users/{id}/tokens requests.
+// It returns the user's tokens that have not been revoked, without the tokens themselves.
+func (h *AccessTokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(userID)
+	if err != nil {
+		writeError(w, err, "listing access tokens of user %d", userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(AccessTokenResponse{Result: true, AccessTokens: tokens})
+}
+
+// RevokeToken handles DELETE /users/{id}/tokens/{token_id} requests.
+func (h *AccessTokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	vars := mux.Vars(r)
+	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil || userID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid user ID format"})
+		return
+	}
+	tokenID, err := strconv.ParseInt(vars["token_id"], 10, 64)
+	if err != nil || tokenID <= 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid token ID format"})
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(userID, tokenID); err != nil {
+		writeError(w, err, "revoking access token %d of user %d", tokenID, userID)
+		return
+	}
+
+	json.NewEncoder(w).Encode(AccessTokenResponse{Result: true})
+}
+
+// IntrospectToken handles POST /tokens/introspect requests, used by the gateway to validate
+// tokens presented by clients. It accepts a JSON body of the form {"token": "..."} (tokens are
+// never put in URLs, which tend to end up in logs) and reports whether the token is active.
+func (h *AccessTokenHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var requestBody contracts.IntrospectTokenRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxTokenBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: "Invalid request body"})
+		return
+	}
+	if err := requestBody.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(AccessTokenResponse{Result: false, Error: err.Error()})
+		return
+	}
+
+	token, err := h.tokenService.Introspect(requestBody.Token)
+	if err != nil {
+		writeError(w, err, "introspecting access token")
+		return
+	}
+
+	active := token != nil
+	json.NewEncoder(w).Encode(AccessTokenResponse{Result: true, Active: &active, AccessToken: token})
+}
@@ -0,0 +1,27 @@
+package model
+
+import "contracts"
+
+// These conversions break the build when the User Service's representations and the contracts
+// its clients decode them into drift apart. They compare field names and types; the JSON names
+// are kept in sync by hand.
+var (
+	_ = contracts.User{
+		ID:        User{}.ID,
+		Name:      User{}.Name,
+		CreatedAt: User{}.CreatedAt,
+		UpdatedAt: User{}.UpdatedAt,
+		Version:   User{}.Version,
+		Status:    User{}.Status,
+	}
+	_ = contracts.AccessToken{
+		ID:        AccessToken{}.ID,
+		UserID:    AccessToken{}.UserID,
+		Name:      AccessToken{}.Name,
+		Scopes:    AccessToken{}.Scopes,
+		CreatedAt: AccessToken{}.CreatedAt,
+		ExpiresAt: AccessToken{}.ExpiresAt,
+	}
+	_ = contracts.UserTotals(UserTotals{})
+	_ = contracts.SignupCount(SignupCount{})
+)
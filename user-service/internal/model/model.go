@@ -1,5 +1,11 @@
 package model
 
+import (
+	"encoding/json"
+
+	"contracts"
+)
+
 // User represents the user entity in the system.
 // It includes JSON tags for correct serialization/deserialization to/from snake_case.
 type User struct {
@@ -7,4 +13,150 @@ type User struct {
 	Name      string `json:"name"`       // Full name of the user, required
 	CreatedAt int64  `json:"created_at"` // Timestamp of user creation in microseconds
 	UpdatedAt int64  `json:"updated_at"` // Timestamp of last update in microseconds
+	Version   int64  `json:"version"`    // Incremented on every update, used for optimistic concurrency
+
+	DeletedAt   int64 `json:"deleted_at,omitempty"`    // Timestamp of soft deletion in microseconds, 0 if active
+	MergedInto  int64 `json:"merged_into,omitempty"`   // ID of the user this account was merged into, if any
+	LastLoginAt int64 `json:"last_login_at,omitempty"` // Timestamp of the last successful login in microseconds, if any
+
+	Status string `json:"status"` // Account status, UserStatusActive or UserStatusSuspended
+
+	Attributes map[string]string `json:"attributes,omitempty"` // Arbitrary profile metadata, keyed by attribute name
+}
+
+// Supported values of User.Status. Suspended users are kept but cannot log in or
+// authenticate, and the gateway does not let them create listings.
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = contracts.UserStatusSuspended
+)
+
+// UserFilter narrows down user queries.
+// Zero values mean the corresponding condition is not applied.
+type UserFilter struct {
+	CreatedAfter  int64         // Only include users created strictly after this timestamp (microseconds)
+	CreatedBefore int64         // Only include users created strictly before this timestamp (microseconds)
+	Deleted       DeletedStatus // Which users to include with respect to soft deletion, active ones by default
+	Status        string        // Only include users with this account status, any status when empty
+}
+
+// UserSortField is a field users can be sorted by.
+type UserSortField string
+
+// Supported UserSortField values.
+const (
+	SortByCreatedAt UserSortField = "created_at"
+	SortByName      UserSortField = "name"
+)
+
+// UserSort describes the ordering of a user listing.
+type UserSort struct {
+	Field      UserSortField
+	Descending bool
+}
+
+// DeletedStatus selects users by soft deletion state.
+type DeletedStatus string
+
+// Supported DeletedStatus values.
+const (
+	DeletedExclude DeletedStatus = ""     // Only active users (default)
+	DeletedOnly    DeletedStatus = "only" // Only soft-deleted users
+	DeletedAny     DeletedStatus = "any"  // Both active and soft-deleted users
+)
+
+// UserTotals counts users by state. Merged users count as deleted.
+type UserTotals struct {
+	Total     int64 `json:"total"`     // Every user ever created, including deleted ones
+	Active    int64 `json:"active"`    // Users that are neither deleted nor suspended
+	Suspended int64 `json:"suspended"` // Users that are suspended but not deleted
+	Deleted   int64 `json:"deleted"`   // Soft-deleted users
+}
+
+// SignupCount is the number of users created in a period.
+type SignupCount struct {
+	Start int64 `json:"start"` // Start of the period (UTC midnight) in microseconds
+	Count int64 `json:"count"` // Users created during the period, including since-deleted ones
+}
+
+// SignupStats summarizes user signups for dashboards.
+type SignupStats struct {
+	Totals      UserTotals    `json:"totals"`
+	Daily       []SignupCount `json:"daily"`        // One entry per UTC day, oldest first
+	Weekly      []SignupCount `json:"weekly"`       // One entry per week starting on Monday, oldest first
+	GeneratedAt int64         `json:"generated_at"` // When the statistics were computed, in microseconds
+}
+
+// Audit actions recorded for user mutations.
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	AuditActionMerge  = "merge"
+)
+
+// RequestMeta carries information about the caller of a mutating operation,
+// recorded alongside the change in the audit log.
+type RequestMeta struct {
+	Actor     string // Identifier of whoever performed the change
+	RequestID string // Correlation ID of the request that caused the change
+}
+
+// AuditEntry represents a single recorded mutation of a user.
+type AuditEntry struct {
+	ID        int64           `json:"id"`         // Audit entry ID, auto-generated by the database
+	UserID    int64           `json:"user_id"`    // ID of the mutated user
+	Action    string          `json:"action"`     // One of create, update, delete
+	Actor     string          `json:"actor"`      // Who performed the change
+	RequestID string          `json:"request_id"` // Request that caused the change
+	Before    json.RawMessage `json:"before"`     // Snapshot before the change, null for creations
+	After     json.RawMessage `json:"after"`      // Snapshot after the change, null for deletions
+	CreatedAt int64           `json:"created_at"` // Timestamp of the change in microseconds
+}
+
+// Session represents a login session of a user. The session token itself is only
+// returned once, at login; the database stores a hash of it.
+type Session struct {
+	ID        int64  `json:"id"`                   // Session ID, auto-generated by the database
+	UserID    int64  `json:"user_id"`              // ID of the user who logged in
+	UserAgent string `json:"user_agent"`           // User-Agent of the client that logged in
+	IPAddress string `json:"ip_address"`           // Remote address of the client that logged in
+	CreatedAt int64  `json:"created_at"`           // Timestamp of the login in microseconds
+	ExpiresAt int64  `json:"expires_at"`           // Timestamp after which the session is no longer valid, in microseconds
+	RevokedAt int64  `json:"revoked_at,omitempty"` // Timestamp of revocation in microseconds, 0 if not revoked
+}
+
+// Access token scopes, naming what a personal access token may be used for.
+const (
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeListingsRead  = "listings:read"
+	ScopeListingsWrite = "listings:write"
+)
+
+// AccessTokenScopes lists every scope a personal access token can be granted.
+var AccessTokenScopes = []string{ScopeUsersRead, ScopeUsersWrite, ScopeListingsRead, ScopeListingsWrite}
+
+// AccessToken represents a long-lived personal access token, used by programmatic clients
+// instead of logging in. Like session tokens, only a hash of the token is stored.
+type AccessToken struct {
+	ID         int64    `json:"id"`                     // Token ID, auto-generated by the database
+	UserID     int64    `json:"user_id"`                // ID of the user the token acts as
+	Name       string   `json:"name"`                   // Label chosen by the user, e.g. "CI deploys"
+	Prefix     string   `json:"prefix"`                 // First characters of the token, to help recognise it
+	Scopes     []string `json:"scopes"`                 // What the token may be used for
+	CreatedAt  int64    `json:"created_at"`             // Timestamp of issuance in microseconds
+	ExpiresAt  int64    `json:"expires_at,omitempty"`   // Timestamp after which the token is invalid, 0 if it never expires
+	LastUsedAt int64    `json:"last_used_at,omitempty"` // Timestamp of the last successful validation in microseconds, if any
+	RevokedAt  int64    `json:"revoked_at,omitempty"`   // Timestamp of revocation in microseconds, 0 if not revoked
+}
+
+// Event is a domain event emitted by the user service for other services to consume.
+// Events are written to an outbox in the same transaction as the change they describe
+// and delivered asynchronously.
+type Event struct {
+	ID        int64           `json:"id"`         // Event ID, auto-generated by the database
+	Type      string          `json:"type"`       // Event type, e.g. "user.merged"
+	Payload   json.RawMessage `json:"payload"`    // Event-specific data
+	CreatedAt int64           `json:"created_at"` // Timestamp of the event in microseconds
 }
@@ -0,0 +1,68 @@
+// Package password hashes user passwords for storage and verifies them at login.
+package password
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// scheme identifies the hashing algorithm in encoded hashes.
+	scheme = "pbkdf2-sha256"
+	// iterations is the PBKDF2 work factor for new hashes, following current OWASP guidance.
+	iterations = 600_000
+	saltBytes  = 16
+	keyBytes   = 32
+)
+
+// ErrMalformedHash is returned when a stored hash cannot be parsed.
+var ErrMalformedHash = errors.New("malformed password hash")
+
+// Hash derives a salted hash of the password, encoded as "pbkdf2-sha256$<iterations>$<salt>$<key>".
+// The iteration count is stored with the hash so it can be raised without invalidating old hashes.
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, keyBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive password hash: %w", err)
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", scheme, iterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// Verify reports whether password matches the encoded hash, comparing in constant time.
+func Verify(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != scheme {
+		return false, ErrMalformedHash
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 {
+		return false, ErrMalformedHash
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false, ErrMalformedHash
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil || len(want) == 0 {
+		return false, ErrMalformedHash
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	if err != nil {
+		return false, fmt.Errorf("failed to derive password hash: %w", err)
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"apperrors"
+	"user-service/internal/model"
+)
+
+// ErrAttributeNotFound is returned when deleting an attribute the user does not have.
+var ErrAttributeNotFound = apperrors.New(apperrors.NotFound, "user attribute not found")
+
+// createAttributesTableSQL creates the user_attributes table, holding arbitrary key/value
+// profile metadata for users. The key column is quoted as MySQL reserves its name; queries
+// qualify it with the table name instead.
+const createAttributesTableSQL = `
+	CREATE TABLE IF NOT EXISTS user_attributes (
+		user_id INTEGER NOT NULL,
+		"key" VARCHAR(255) NOT NULL,
+		value TEXT NOT NULL,
+		updated_at INTEGER NOT NULL,
+		PRIMARY KEY (user_id, "key")
+	);`
+
+// upsertAttributeSQL returns the statement storing an attribute, which replaces its value if
+// the user already has it.
+func upsertAttributeSQL(d dialect) string {
+	if d == dialectMySQL {
+		return "INSERT INTO user_attributes(user_id, `key`, value, updated_at) VALUES(?, ?, ?, ?)" +
+			" ON DUPLICATE KEY UPDATE value = VALUES(value), updated_at = VALUES(updated_at)"
+	}
+	return `INSERT INTO user_attributes(user_id, key, value, updated_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(user_id, key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`
+}
+
+// SetUserAttribute sets (or replaces) an attribute of an active user, bumping the user's
+// version and updated_at. It returns the updated user, or nil if the user does not exist.
+func (r *sqlUserRepository) SetUserAttribute(id int64, key, value string) (*model.User, error) {
+	var user *model.User
+	err := r.writes.Do(func() error {
+		return r.transaction("setting user attribute", func(tx *sql.Tx) (err error) {
+			if user, err = touchUser(tx, r.d, id); err != nil || user == nil {
+				return err
+			}
+			_, err = tx.Exec(upsertAttributeSQL(r.d), id, key, value, user.UpdatedAt)
+			if err != nil {
+				return fmt.Errorf("failed to store user attribute: %w", err)
+			}
+			return loadAttributes(tx, user)
+		})
+	})
+	return user, err
+}
+
+// DeleteUserAttribute removes an attribute of an active user, bumping the user's version and
+// updated_at. It returns the updated user, nil if the user does not exist, or
+// ErrAttributeNotFound if the user has no such attribute.
+func (r *sqlUserRepository) DeleteUserAttribute(id int64, key string) (*model.User, error) {
+	var user *model.User
+	err := r.writes.Do(func() error {
+		return r.transaction("deleting user attribute", func(tx *sql.Tx) (err error) {
+			result, err := tx.Exec(
+				`DELETE FROM user_attributes WHERE user_id = ? AND user_attributes.key = ?
+				AND EXISTS (SELECT 1 FROM users WHERE id = ? AND `+activeUser+`)`,
+				id, key, id,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to delete user attribute: %w", err)
+			}
+			deleted, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			if user, err = touchUser(tx, r.d, id); err != nil || user == nil {
+				return err
+			}
+			if deleted == 0 {
+				return ErrAttributeNotFound // rolls back the version bump
+			}
+			return loadAttributes(tx, user)
+		})
+	})
+	return user, err
+}
+
+// touchUser bumps the version and updated_at of an active user, returning it, or nil if it
+// does not exist.
+func touchUser(tx *sql.Tx, d dialect, id int64) (*model.User, error) {
+	var user model.User
+	update := `UPDATE users SET updated_at = ?, version = version + 1 WHERE id = ? AND ` + activeUser
+	if err := updateReturning(tx, d, &user, update, id, time.Now().UnixMicro(), id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	return &user, nil
+}
+
+// loadAttributes fills in the attributes of the given users.
+func loadAttributes(q querier, users ...*model.User) error {
+	byID := make(map[int64]*model.User, len(users))
+	ids := make([]any, 0, len(users))
+	for _, user := range users {
+		if _, seen := byID[user.ID]; !seen {
+			ids = append(ids, user.ID)
+		}
+		byID[user.ID] = user
+	}
+
+	for start := 0; start < len(ids); start += maxNamesPerLookup {
+		chunk := ids[start:min(start+maxNamesPerLookup, len(ids))]
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(chunk)), ", ")
+		rows, err := q.Query(`SELECT user_id, user_attributes.key, value FROM user_attributes WHERE user_id IN (`+placeholders+`)`, chunk...)
+		if err != nil {
+			return fmt.Errorf("failed to query user attributes: %w", err)
+		}
+		if err := scanAttributes(rows, byID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanAttributes reads attribute rows into the matching users, closing rows.
+func scanAttributes(rows *sql.Rows, byID map[int64]*model.User) error {
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+	for rows.Next() {
+		var userID int64
+		var key, value string
+		if err := rows.Scan(&userID, &key, &value); err != nil {
+			return fmt.Errorf("failed to scan user attribute: %w", err)
+		}
+		user := byID[userID]
+		if user.Attributes == nil {
+			user.Attributes = make(map[string]string)
+		}
+		user.Attributes[key] = value
+	}
+	return rows.Err()
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"user-service/internal/model"
+)
+
+// AuditRepository defines the interface for persisting and reading the user audit log.
+type AuditRepository interface {
+	RecordAudit(entries []model.AuditEntry) error
+	GetAuditLog(userID int64, page, pageSize int) ([]model.AuditEntry, error)
+}
+
+// sqlAuditRepository implements AuditRepository for SQL databases.
+type sqlAuditRepository struct {
+	db     *sql.DB
+	writes *WriteQueue
+}
+
+// createAuditTableSQL creates the user_audit table and its lookup index.
+const createAuditTableSQL = `
+	CREATE TABLE IF NOT EXISTS user_audit (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		action TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		before_snapshot TEXT,
+		after_snapshot TEXT,
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_audit_user_id ON user_audit(user_id, created_at);`
+
+// NewAuditRepository creates a new instance of sqlAuditRepository.
+// Inserts are executed through the given write queue, which may be nil.
+func NewAuditRepository(db *sql.DB, writes *WriteQueue) AuditRepository {
+	return &sqlAuditRepository{db: db, writes: writes}
+}
+
+// RecordAudit inserts the given audit entries.
+func (r *sqlAuditRepository) RecordAudit(entries []model.AuditEntry) error {
+	err := r.writes.Do(func() error {
+		return insertAuditEntries(r.db, entries)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entries: %w", err)
+	}
+	return nil
+}
+
+// insertAuditEntries inserts audit entries using the given database handle or transaction,
+// with as few statements as possible.
+func insertAuditEntries(ex execer, entries []model.AuditEntry) error {
+	for start := 0; start < len(entries); start += maxRowsPerInsert {
+		end := min(start+maxRowsPerInsert, len(entries))
+		chunk := entries[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*7)
+		for i, e := range chunk {
+			placeholders[i] = "(?, ?, ?, ?, ?, ?, ?)"
+			args = append(args, e.UserID, e.Action, e.Actor, e.RequestID, nullableJSON(e.Before), nullableJSON(e.After), e.CreatedAt)
+		}
+
+		query := "INSERT INTO user_audit(user_id, action, actor, request_id, before_snapshot, after_snapshot, created_at) VALUES " +
+			strings.Join(placeholders, ", ")
+		if _, err := ex.Exec(query, args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetAuditLog retrieves the audit trail of a user with pagination, most recent first.
+func (r *sqlAuditRepository) GetAuditLog(userID int64, page, pageSize int) ([]model.AuditEntry, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	offset := (page - 1) * pageSize
+	query := `SELECT id, user_id, action, actor, request_id, before_snapshot, after_snapshot, created_at
+		FROM user_audit WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	rows, err := r.db.Query(query, userID, pageSize, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	entries := []model.AuditEntry{}
+	for rows.Next() {
+		var e model.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Action, &e.Actor, &e.RequestID, &before, &after, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit row: %w", err)
+		}
+		if before.Valid {
+			e.Before = []byte(before.String)
+		}
+		if after.Valid {
+			e.After = []byte(after.String)
+		}
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for GetAuditLog: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullableJSON maps an empty snapshot to SQL NULL.
+func nullableJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,231 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// dialect identifies the SQL flavour spoken by a database. Queries are written for SQLite,
+// with ? placeholders; the few statements that differ between backends ask the dialect.
+type dialect string
+
+const (
+	dialectSQLite   dialect = DriverSQLite
+	dialectPostgres dialect = DriverPostgres
+	dialectMySQL    dialect = DriverMySQL
+)
+
+// dialectOf returns the dialect of the database db is connected to.
+func dialectOf(db *sql.DB) dialect {
+	switch db.Driver().(type) {
+	case postgresDriver:
+		return dialectPostgres
+	case *mysql.MySQLDriver:
+		return dialectMySQL
+	default:
+		return dialectSQLite
+	}
+}
+
+// foldCase wraps a string expression so that comparisons and ordering ignore letter case.
+func (d dialect) foldCase(expr string) string {
+	if d == dialectSQLite {
+		return expr + " COLLATE NOCASE"
+	}
+	return "LOWER(" + expr + ")"
+}
+
+// intDiv returns the integer division of expr by divisor.
+func (d dialect) intDiv(expr string, divisor int64) string {
+	if d == dialectMySQL {
+		return fmt.Sprintf("%s DIV %d", expr, divisor)
+	}
+	return fmt.Sprintf("%s / %d", expr, divisor)
+}
+
+// returningID turns a single-row INSERT into one reporting the ID of the new row, for
+// backends where the ID is read from a RETURNING clause rather than from the result.
+func (d dialect) returningID(query string) string {
+	if d == dialectPostgres {
+		return query + " RETURNING id"
+	}
+	return query
+}
+
+// insertedID runs an INSERT prepared from returningID, returning the ID of the new row.
+// exec and queryRow run the statement with the given arguments.
+func (d dialect) insertedID(exec func(args ...any) (sql.Result, error), queryRow func(args ...any) *sql.Row, args ...any) (int64, error) {
+	if d == dialectPostgres {
+		var id int64
+		err := queryRow(args...).Scan(&id)
+		return id, err
+	}
+	result, err := exec(args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// insert runs a single-row INSERT on q, returning the ID of the new row.
+func (d dialect) insert(q querier, query string, args ...any) (int64, error) {
+	query = d.returningID(query)
+	return d.insertedID(
+		func(args ...any) (sql.Result, error) { return q.Exec(query, args...) },
+		func(args ...any) *sql.Row { return q.QueryRow(query, args...) },
+		args...,
+	)
+}
+
+// schema translates SQLite table definitions into statements for the dialect, one per
+// element. Auto-incremented keys and 64-bit integers are spelled the backend's way, and for
+// MySQL tables compare strings byte by byte like the other backends do.
+func (d dialect) schema(ddl string) []string {
+	var statements []string
+	for _, statement := range strings.Split(ddl, ";") {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		switch d {
+		case dialectPostgres:
+			statement = strings.NewReplacer(
+				"INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT", "BIGSERIAL PRIMARY KEY",
+				"INTEGER", "BIGINT",
+			).Replace(statement)
+		case dialectMySQL:
+			statement = strings.NewReplacer(
+				"INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT", "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY",
+				"INTEGER", "BIGINT",
+				`"`, "`",
+				// MySQL cannot skip existing indexes, see createSchema
+				"CREATE INDEX IF NOT EXISTS", "CREATE INDEX",
+			).Replace(statement)
+			if strings.HasPrefix(statement, "CREATE TABLE") {
+				statement += " DEFAULT CHARSET = utf8mb4 COLLATE = utf8mb4_bin"
+			}
+		}
+		statements = append(statements, statement)
+	}
+	return statements
+}
+
+// createSchema runs the table definitions of ddl, translated for the dialect of db.
+func createSchema(db *sql.DB, ddl string) error {
+	for _, statement := range dialectOf(db).schema(ddl) {
+		if _, err := db.Exec(statement); err != nil && !isDuplicateIndex(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isDuplicateIndex reports whether err is MySQL's refusal to create an index that exists.
+func isDuplicateIndex(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1061
+}
+
+// openPool applies the connection pool settings to db and checks that the database can be
+// reached, closing db if it cannot.
+func openPool(db *sql.DB, what string) (*sql.DB, error) {
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to %s: %w", what, err)
+	}
+	return db, nil
+}
+
+// openPostgres returns a connection pool to the PostgreSQL database described by dsn, whose
+// connections accept the ? placeholders the repositories are written with.
+func openPostgres(dsn string) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres data source: %w", err)
+	}
+	return sql.OpenDB(postgresConnector{stdlib.GetConnector(*config)}), nil
+}
+
+// postgresDriver identifies the connections opened by postgresConnector, see dialectOf.
+type postgresDriver struct {
+	driver.Driver
+}
+
+// postgresConnector opens pgx connections that rewrite ? placeholders to PostgreSQL's $n.
+type postgresConnector struct {
+	connector driver.Connector
+}
+
+func (c postgresConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return postgresConn{conn.(*stdlib.Conn)}, nil
+}
+
+func (c postgresConnector) Driver() driver.Driver {
+	return postgresDriver{c.connector.Driver()}
+}
+
+// postgresConn is a pgx connection rebinding the placeholders of every query it receives.
+type postgresConn struct {
+	*stdlib.Conn
+}
+
+func (c postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.PrepareContext(ctx, rebind(query))
+}
+
+func (c postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.ExecContext(ctx, rebind(query), args)
+}
+
+func (c postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.QueryContext(ctx, rebind(query), args)
+}
+
+// rebind numbers the ? placeholders of query ($1, $2, ...), leaving quoted text untouched.
+func rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
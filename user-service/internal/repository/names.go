@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"apperrors"
+	"user-service/internal/model"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ErrNameTaken is returned when a write would give an active user the same name as another one.
+var ErrNameTaken = apperrors.New(apperrors.Conflict, "user name already taken")
+
+// uniqueNameIndex is the index enforcing unique names among active users.
+const uniqueNameIndex = "idx_users_name_unique"
+
+// activeNameColumn is the generated column holding the names of active users (and NULL for
+// deleted ones), which MySQL indexes in lieu of a partial index.
+const activeNameColumn = "active_name"
+
+// maxNamesPerLookup bounds the names looked up by a single query.
+const maxNamesPerLookup = 500
+
+// EnsureUniqueNameIndex creates (or recreates, when the mode changed) the unique index on the
+// names of active users. With caseInsensitive set, names differing only in letter case (only
+// ASCII letters for SQLite) are considered equal. It fails if existing active users already share a name.
+func EnsureUniqueNameIndex(db *sql.DB, caseInsensitive bool) error {
+	d := dialectOf(db)
+	column := "name"
+	if caseInsensitive {
+		column = d.foldCase(column)
+	}
+	if d == dialectMySQL {
+		return ensureActiveNameColumn(db, column, caseInsensitive)
+	}
+	definition := "CREATE UNIQUE INDEX " + uniqueNameIndex + " ON users(" + column + ") WHERE " + activeUser
+
+	var existing string
+	var err error
+	if d == dialectPostgres {
+		// PostgreSQL keeps a normalized form of the definition, so only the folding is compared
+		err = db.QueryRow(`SELECT indexdef FROM pg_indexes WHERE schemaname = current_schema() AND indexname = ?`,
+			uniqueNameIndex).Scan(&existing)
+		if err == nil && strings.Contains(existing, "lower(") == caseInsensitive {
+			return nil
+		}
+	} else {
+		err = db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?`, uniqueNameIndex).Scan(&existing)
+		if err == nil && existing == definition {
+			return nil
+		}
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to inspect unique name index: %w", err)
+	}
+
+	// Swap the index in a transaction so a failed rebuild keeps the previous one
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for unique name index: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if _, err := tx.Exec(`DROP INDEX IF EXISTS ` + uniqueNameIndex); err != nil {
+		return fmt.Errorf("failed to drop unique name index: %w", err)
+	}
+	if _, err := tx.Exec(definition); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("cannot enforce unique user names, some active users share a name (merge them first): %w", err)
+		}
+		return fmt.Errorf("failed to create unique name index: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit unique name index: %w", err)
+	}
+	log.Printf("Unique user name index created (case-insensitive: %t)", caseInsensitive)
+	return nil
+}
+
+// ensureActiveNameColumn is EnsureUniqueNameIndex for MySQL, which has no partial indexes:
+// the unique index covers a column generated from the name of active users (folded with
+// expr) that is NULL for deleted users, and NULLs never collide. Changing the mode rewrites
+// the column, which fails without changing anything if active users would then share a name.
+func ensureActiveNameColumn(db *sql.DB, expr string, caseInsensitive bool) error {
+	definition := activeNameColumn + " VARCHAR(255) GENERATED ALWAYS AS (CASE WHEN " + activeUser +
+		" THEN " + expr + " END) STORED"
+
+	var existing string
+	err := db.QueryRow(
+		`SELECT GENERATION_EXPRESSION FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'users' AND COLUMN_NAME = ?`,
+		activeNameColumn,
+	).Scan(&existing)
+	var alter string
+	switch {
+	case err == sql.ErrNoRows:
+		alter = "ALTER TABLE users ADD COLUMN " + definition + ", ADD UNIQUE INDEX " + uniqueNameIndex + " (" + activeNameColumn + ")"
+	case err != nil:
+		return fmt.Errorf("failed to inspect unique name index: %w", err)
+	case strings.Contains(strings.ToLower(existing), "lower(") == caseInsensitive:
+		return nil
+	default:
+		alter = "ALTER TABLE users MODIFY COLUMN " + definition
+	}
+
+	if _, err := db.Exec(alter); err != nil {
+		if isUniqueViolation(err) {
+			return fmt.Errorf("cannot enforce unique user names, some active users share a name (merge them first): %w", err)
+		}
+		return fmt.Errorf("failed to create unique name index: %w", err)
+	}
+	log.Printf("Unique user name index created (case-insensitive: %t)", caseInsensitive)
+	return nil
+}
+
+// isUniqueViolation reports whether err is a UNIQUE constraint failure of any backend.
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	var pgErr *pgconn.PgError
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &sqliteErr):
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+	case errors.As(err, &pgErr):
+		return pgErr.Code == "23505" // unique_violation
+	case errors.As(err, &mysqlErr):
+		return mysqlErr.Number == 1062 // ER_DUP_ENTRY
+	}
+	return false
+}
+
+// FindActiveUsersByNames returns the active users whose name matches one of names, ignoring
+// letter case (only ASCII letters for SQLite). Callers that compare names case-sensitively filter the result further.
+func (r *sqlUserRepository) FindActiveUsersByNames(names []string) ([]model.User, error) {
+	var users []model.User
+	for start := 0; start < len(names); start += maxNamesPerLookup {
+		end := min(start+maxNamesPerLookup, len(names))
+		chunk := names[start:end]
+
+		args := make([]any, len(chunk))
+		for i, name := range chunk {
+			args[i] = name
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat(r.d.foldCase("?")+", ", len(chunk)), ", ")
+		query := `SELECT ` + userColumns + ` FROM users WHERE ` + activeUser +
+			` AND ` + r.d.foldCase("name") + ` IN (` + placeholders + `)`
+		if err := r.queryUsers(&users, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to look up users by name: %w", err)
+		}
+	}
+	return users, nil
+}
+
+// queryUsers appends the users selected (with userColumns) by query to dst.
+func (r *sqlUserRepository) queryUsers(dst *[]model.User, query string, args ...any) error {
+	rows, err := r.querier().Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	for rows.Next() {
+		var user model.User
+		if err := scanUser(rows, &user); err != nil {
+			return err
+		}
+		*dst = append(*dst, user)
+	}
+	return rows.Err()
+}
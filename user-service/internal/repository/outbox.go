@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"user-service/internal/model"
+)
+
+// OutboxRepository defines the interface for reading and acknowledging outbox events.
+// Events are written to the outbox by the user repository, inside the transaction of
+// the change they describe, which guarantees no event is lost or emitted for a rolled back change.
+type OutboxRepository interface {
+	FetchPendingEvents(limit int) ([]model.Event, error)
+	MarkEventsPublished(ids []int64, publishedAt int64) error
+	MarkEventFailed(id int64, reason string) error
+}
+
+// sqlOutboxRepository implements OutboxRepository for SQL databases.
+type sqlOutboxRepository struct {
+	db     *sql.DB
+	writes *WriteQueue
+}
+
+// createOutboxTableSQL creates the outbox table and the index used to find pending events.
+const createOutboxTableSQL = `
+	CREATE TABLE IF NOT EXISTS outbox_events (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		event_type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		published_at INTEGER,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT
+	);
+	CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(published_at, id);`
+
+// execer is implemented by both *sql.DB and *sql.Tx.
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// insertOutboxEvent appends an event to the outbox using the given database handle or transaction.
+func insertOutboxEvent(ex execer, event model.Event) error {
+	_, err := ex.Exec(
+		`INSERT INTO outbox_events(event_type, payload, created_at) VALUES(?, ?, ?)`,
+		event.Type, string(event.Payload), event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert outbox event %s: %w", event.Type, err)
+	}
+	return nil
+}
+
+// NewOutboxRepository creates a new instance of sqlOutboxRepository.
+// Updates are executed through the given write queue, which may be nil.
+func NewOutboxRepository(db *sql.DB, writes *WriteQueue) OutboxRepository {
+	return &sqlOutboxRepository{db: db, writes: writes}
+}
+
+// FetchPendingEvents returns up to limit unpublished events, oldest first.
+func (r *sqlOutboxRepository) FetchPendingEvents(limit int) ([]model.Event, error) {
+	rows, err := r.db.Query(
+		`SELECT id, event_type, payload, created_at FROM outbox_events
+		WHERE published_at IS NULL ORDER BY id ASC LIMIT ?`,
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending outbox events: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	var events []model.Event
+	for rows.Next() {
+		var e model.Event
+		var payload string
+		if err := rows.Scan(&e.ID, &e.Type, &payload, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event row: %w", err)
+		}
+		e.Payload = []byte(payload)
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for FetchPendingEvents: %w", err)
+	}
+
+	return events, nil
+}
+
+// MarkEventsPublished flags the given events as delivered.
+func (r *sqlOutboxRepository) MarkEventsPublished(ids []int64, publishedAt int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]any, 0, len(ids)+1)
+	args = append(args, publishedAt)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := `UPDATE outbox_events SET published_at = ?, attempts = attempts + 1, last_error = NULL WHERE id IN (` + placeholders + `)`
+	err := r.writes.Do(func() error {
+		_, err := r.db.Exec(query, args...)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox events as published: %w", err)
+	}
+	return nil
+}
+
+// MarkEventFailed records a failed delivery attempt so it is retried later.
+func (r *sqlOutboxRepository) MarkEventFailed(id int64, reason string) error {
+	err := r.writes.Do(func() error {
+		_, err := r.db.Exec(`UPDATE outbox_events SET attempts = attempts + 1, last_error = ? WHERE id = ?`, reason, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record outbox delivery failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// OpenSQLiteReplica opens a read-only connection pool to a copy of the users database, such
+// as a replica maintained by an external replication tool or the primary file opened with
+// "file:users.db?mode=ro". Unlike NewSQLiteDB it does not create or migrate the schema.
+func OpenSQLiteReplica(dataSourceName string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dataSourceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replica database: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(5)
+	db.SetConnMaxLifetime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect to replica database: %w", err)
+	}
+	return db, nil
+}
+
+// replicaRouter sends user listings and lookups to a read replica and everything else to the
+// primary. Replicas lag behind the primary, so for maxStaleness after any write made through
+// the router, reads go to the primary as well; callers therefore see their own writes.
+// Lookups used to enforce constraints (names) and exports always read from the primary.
+// Write methods added to UserRepository must be overridden here so they call wrote.
+type replicaRouter struct {
+	UserRepository // the primary, serving writes and unrouted reads
+
+	replica      UserRepository
+	maxStaleness time.Duration
+	lastWrite    atomic.Int64 // UnixNano of the last write sent to the primary
+}
+
+// NewReplicaRouter returns a UserRepository that routes GetAllUsers and GetUserByID to
+// replica and all other operations to primary, falling back to the primary for maxStaleness
+// after each write.
+func NewReplicaRouter(primary, replica UserRepository, maxStaleness time.Duration) UserRepository {
+	return &replicaRouter{UserRepository: primary, replica: replica, maxStaleness: maxStaleness}
+}
+
+// reader returns the repository reads should be served from.
+func (r *replicaRouter) reader() UserRepository {
+	if time.Since(time.Unix(0, r.lastWrite.Load())) < r.maxStaleness {
+		return r.UserRepository
+	}
+	return r.replica
+}
+
+// wrote records that a write was just sent to the primary.
+func (r *replicaRouter) wrote() {
+	r.lastWrite.Store(time.Now().UnixNano())
+}
+
+func (r *replicaRouter) GetAllUsers(page, pageSize int, filter model.UserFilter, sort model.UserSort) ([]model.User, error) {
+	return r.reader().GetAllUsers(page, pageSize, filter, sort)
+}
+
+func (r *replicaRouter) GetUserByID(id int64) (*model.User, error) {
+	return r.reader().GetUserByID(id)
+}
+
+func (r *replicaRouter) CreateUser(name, passwordHash string) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.CreateUser(name, passwordHash)
+}
+
+func (r *replicaRouter) CreateUsers(names []string) ([]model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.CreateUsers(names)
+}
+
+func (r *replicaRouter) UpdateUser(id int64, name string, expectedVersion int64) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.UpdateUser(id, name, expectedVersion)
+}
+
+func (r *replicaRouter) MergeUser(sourceID, targetID int64, event model.Event) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.MergeUser(sourceID, targetID, event)
+}
+
+func (r *replicaRouter) DeleteUser(id int64, event model.Event) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.DeleteUser(id, event)
+}
+
+func (r *replicaRouter) SetUserStatus(id int64, status string) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.SetUserStatus(id, status)
+}
+
+func (r *replicaRouter) WithTx(fn func(uow UnitOfWork) error) error {
+	defer r.wrote()
+	return r.UserRepository.WithTx(fn)
+}
+
+func (r *replicaRouter) SetUserAttribute(id int64, key, value string) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.SetUserAttribute(id, key, value)
+}
+
+func (r *replicaRouter) DeleteUserAttribute(id int64, key string) (*model.User, error) {
+	defer r.wrote()
+	return r.UserRepository.DeleteUserAttribute(id, key)
+}
@@ -2,25 +2,72 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"apperrors"
 	"user-service/internal/model"
 )
 
 // UserRepository defines the interface for user data operations.
-// This abstraction allows for different database implementations (e.g., SQLite, PostgreSQL)
+// This abstraction allows for different database implementations (SQLite, PostgreSQL, MySQL)
 // without changing the service layer logic.
 type UserRepository interface {
-	CreateUser(name string) (*model.User, error)
-	GetAllUsers(page, pageSize int) ([]model.User, error)
+	CreateUser(name, passwordHash string) (*model.User, error)
+	CreateUsers(names []string) ([]model.User, error)
+	GetAllUsers(page, pageSize int, filter model.UserFilter, sort model.UserSort) ([]model.User, error)
 	GetUserByID(id int64) (*model.User, error)
+	UpdateUser(id int64, name string, expectedVersion int64) (*model.User, error)
+	MergeUser(sourceID, targetID int64, event model.Event) (*model.User, error)
+	DeleteUser(id int64, event model.Event) (*model.User, error)
+	SetUserStatus(id int64, status string) (*model.User, error)
+	SetUserAttribute(id int64, key, value string) (*model.User, error)
+	DeleteUserAttribute(id int64, key string) (*model.User, error)
+	StreamUsers(filter model.UserFilter, fn func(*model.User) error) error
+	CountUsers(filter model.UserFilter) (int64, error)
+	CountUsersByState() (model.UserTotals, error)
+	CountSignupsByDay(since int64) (map[int64]int64, error)
+	FindActiveUsersByNames(names []string) ([]model.User, error)
+	WithTx(fn func(uow UnitOfWork) error) error
 }
 
-// sqliteUserRepository implements UserRepository for SQLite database.
-type sqliteUserRepository struct {
-	db *sql.DB
+// ErrVersionConflict is returned when an update's expected version does not match the stored one.
+var ErrVersionConflict = apperrors.New(apperrors.Conflict, "user version conflict")
+
+// userColumns lists the columns selected for a user, in the order expected by scanUser.
+const userColumns = "id, name, created_at, updated_at, version, deleted_at, merged_into, last_login_at, status"
+
+// activeUser is the condition selecting users that have not been soft-deleted.
+const activeUser = "deleted_at IS NULL"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanUser reads a user selected with userColumns, followed by any extra columns into extra.
+func scanUser(row rowScanner, user *model.User, extra ...any) error {
+	var deletedAt, mergedInto, lastLoginAt sql.NullInt64
+	dest := append([]any{&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt, &user.Version, &deletedAt, &mergedInto, &lastLoginAt, &user.Status}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	user.DeletedAt = deletedAt.Int64
+	user.MergedInto = mergedInto.Int64
+	user.LastLoginAt = lastLoginAt.Int64
+	return nil
+}
+
+// sqlUserRepository implements UserRepository for SQL databases (SQLite, PostgreSQL or MySQL).
+type sqlUserRepository struct {
+	db     *sql.DB
+	d      dialect
+	writes *WriteQueue
+	stmts  *statementCache
+	tx     *sql.Tx // set for repositories scoped to a unit of work, see WithTx
 }
 
 // NewSQLiteDB initializes and returns a new SQLite database connection.
@@ -42,51 +89,149 @@ func NewSQLiteDB(dataSourceName string) (*sql.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Create the users table if it doesn't exist
-	createTableSQL := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
-		name TEXT NOT NULL,
-		created_at INTEGER NOT NULL,
-		updated_at INTEGER NOT NULL
-	);`
-	_, err = db.Exec(createTableSQL)
-	if err != nil {
+	if err = createTables(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Databases created before optimistic concurrency was introduced lack the version column
+	if err = ensureColumn(db, "users", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	// Likewise for the soft deletion columns
+	for _, column := range []string{"deleted_at", "merged_into"} {
+		if err = ensureColumn(db, "users", column, "INTEGER"); err != nil {
+			db.Close()
+			return nil, err
+		}
+	}
+
+	// And for the login columns
+	if err = ensureColumn(db, "users", "password_hash", "TEXT"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err = ensureColumn(db, "users", "last_login_at", "INTEGER"); err != nil {
+		db.Close()
+		return nil, err
+	}
+	// And for account suspension
+	if err = ensureColumn(db, "users", "status", "TEXT NOT NULL DEFAULT 'active'"); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to create users table: %w", err)
+		return nil, err
 	}
 
 	log.Printf("SQLite database '%s' initialized successfully.", dataSourceName)
 	return db, nil
 }
 
-// NewSQLiteUserRepository creates a new instance of sqliteUserRepository.
-func NewSQLiteUserRepository(db *sql.DB) UserRepository {
-	return &sqliteUserRepository{db: db}
+// createUsersTableSQL creates the users table and the index on creation dates, used for the
+// default ordering and range filters. Columns that are indexed or have a default are
+// VARCHARs, which every backend can index.
+const createUsersTableSQL = `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		name VARCHAR(255) NOT NULL,
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1,
+		deleted_at INTEGER,
+		merged_into INTEGER,
+		password_hash TEXT,
+		last_login_at INTEGER,
+		status VARCHAR(32) NOT NULL DEFAULT 'active'
+	);
+	CREATE INDEX IF NOT EXISTS idx_users_created_at ON users(created_at);`
+
+// createTables creates the tables of the service that do not exist yet, translating their
+// definitions for the dialect of db.
+func createTables(db *sql.DB) error {
+	tables := []struct{ name, ddl string }{
+		{"users", createUsersTableSQL},
+		{"outbox_events", createOutboxTableSQL},
+		{"user_sessions", createSessionsTableSQL},
+		{"access_tokens", createAccessTokensTableSQL},
+		{"user_attributes", createAttributesTableSQL},
+		{"user_audit", createAuditTableSQL},
+	}
+	for _, table := range tables {
+		if err := createSchema(db, table.ddl); err != nil {
+			return fmt.Errorf("failed to create %s table: %w", table.name, err)
+		}
+	}
+	return nil
 }
 
-// CreateUser inserts a new user into the database.
-// It generates current timestamps in microseconds for created_at and updated_at.
-func (r *sqliteUserRepository) CreateUser(name string) (*model.User, error) {
-	stmt, err := r.db.Prepare("INSERT INTO users(name, created_at, updated_at) VALUES(?, ?, ?)")
+// ensureColumn adds a column to an existing table if it is missing.
+// This keeps databases created by earlier versions of the service usable.
+func ensureColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement for creating user: %w", err)
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
 	}
-	defer func() {
-		if err := stmt.Close(); err != nil {
-			log.Printf("Error closing statement: %v", err)
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return fmt.Errorf("failed to scan table info for %s: %w", table, err)
 		}
-	}()
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error during rows iteration for table info of %s: %w", table, err)
+	}
+	rows.Close() // Release the connection before altering the table
 
-	now := time.Now().UnixMicro() // Get current time in microseconds
-	result, err := stmt.Exec(name, now, now)
+	if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	log.Printf("Added missing column %s.%s", table, column)
+	return nil
+}
+
+// NewUserRepository creates a new instance of sqlUserRepository.
+// Inserts and updates are executed through the given write queue, which may be nil.
+// Frequently used statements are prepared on first use and reused afterwards.
+func NewUserRepository(db *sql.DB, writes *WriteQueue, opts ...UserRepositoryOption) UserRepository {
+	r := &sqlUserRepository{db: db, d: dialectOf(db), writes: writes, stmts: newStatementCache(db)}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// CreateUser inserts a new user into the database, with an optional password hash
+// (an empty hash means the user cannot log in).
+// It generates current timestamps in microseconds for created_at and updated_at.
+func (r *sqlUserRepository) CreateUser(name, passwordHash string) (*model.User, error) {
+	stmt, done, err := r.prepare(r.d.returningID("INSERT INTO users(name, password_hash, created_at, updated_at) VALUES(?, ?, ?, ?)"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute statement for creating user: %w", err)
+		return nil, fmt.Errorf("failed to prepare statement for creating user: %w", err)
 	}
+	defer done()
 
-	id, err := result.LastInsertId()
+	var now, id int64
+	err = r.writes.Do(func() (err error) {
+		now = time.Now().UnixMicro() // Get current time in microseconds
+		id, err = r.d.insertedID(stmt.Exec, stmt.QueryRow, name, sql.NullString{String: passwordHash, Valid: passwordHash != ""}, now, now)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert ID after creating user: %w", err)
+		if isUniqueViolation(err) {
+			return nil, ErrNameTaken
+		}
+		return nil, fmt.Errorf("failed to execute statement for creating user: %w", err)
 	}
 
 	return &model.User{
@@ -94,12 +239,140 @@ func (r *sqliteUserRepository) CreateUser(name string) (*model.User, error) {
 		Name:      name,
 		CreatedAt: now,
 		UpdatedAt: now,
+		Version:   1,
+		Status:    model.UserStatusActive,
 	}, nil
 }
 
-// GetAllUsers retrieves all users from the database with pagination.
-// Results are sorted by 'created_at' in descending order.
-func (r *sqliteUserRepository) GetAllUsers(page, pageSize int) ([]model.User, error) {
+// maxRowsPerInsert bounds the rows in a single multi-row INSERT so the statement stays
+// well below SQLite's limit on bound parameters (3 parameters per row).
+const maxRowsPerInsert = 300
+
+// CreateUsers inserts multiple users in a single transaction using multi-row INSERT statements.
+// Either all users are persisted or none are. The returned users are in the same order as names.
+func (r *sqlUserRepository) CreateUsers(names []string) ([]model.User, error) {
+	if len(names) == 0 {
+		return []model.User{}, nil
+	}
+
+	var users []model.User
+	err := r.writes.Do(func() error {
+		return r.transaction("creating users", func(tx *sql.Tx) (err error) {
+			users, err = insertUsers(tx, r.d, names)
+			return err
+		})
+	})
+	return users, err
+}
+
+// insertUsers inserts users within the given transaction.
+func insertUsers(tx *sql.Tx, d dialect, names []string) ([]model.User, error) {
+	now := time.Now().UnixMicro() // Get current time in microseconds
+	users := make([]model.User, 0, len(names))
+	for start := 0; start < len(names); start += maxRowsPerInsert {
+		end := min(start+maxRowsPerInsert, len(names))
+		chunk := names[start:end]
+
+		placeholders := make([]string, len(chunk))
+		args := make([]any, 0, len(chunk)*3)
+		for i, name := range chunk {
+			placeholders[i] = "(?, ?, ?)"
+			args = append(args, name, now, now)
+		}
+
+		query := "INSERT INTO users(name, created_at, updated_at) VALUES " + strings.Join(placeholders, ", ")
+		ids, err := insertRows(tx, d, query, len(chunk), args...)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return nil, ErrNameTaken
+			}
+			return nil, fmt.Errorf("failed to execute batch insert of users: %w", err)
+		}
+		for i, name := range chunk {
+			users = append(users, model.User{
+				ID:        ids[i],
+				Name:      name,
+				CreatedAt: now,
+				UpdatedAt: now,
+				Version:   1,
+				Status:    model.UserStatusActive,
+			})
+		}
+	}
+
+	return users, nil
+}
+
+// insertRows runs a multi-row INSERT of n rows into a table with an auto-incremented id,
+// returning the IDs of the new rows in the order of the VALUES.
+func insertRows(tx *sql.Tx, d dialect, query string, n int, args ...any) ([]int64, error) {
+	ids := make([]int64, 0, n)
+	if d == dialectPostgres {
+		rows, err := tx.Query(query+" RETURNING id", args...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id int64
+			if err := rows.Scan(&id); err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, rows.Err()
+	}
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	// Rows of a single INSERT within a transaction receive consecutive IDs. SQLite reports
+	// the last of them and MySQL the first.
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert ID: %w", err)
+	}
+	if d == dialectSQLite {
+		id -= int64(n) - 1
+	}
+	for i := range n {
+		ids = append(ids, id+int64(i))
+	}
+	return ids, nil
+}
+
+// userSortColumns maps sortable fields to their columns. Only fields listed here can
+// end up in an ORDER BY clause, so user input never reaches the SQL text.
+var userSortColumns = map[model.UserSortField]string{
+	model.SortByCreatedAt: "created_at",
+	model.SortByName:      "name",
+}
+
+// caseFoldedSortFields lists the sortable fields ordered ignoring letter case.
+var caseFoldedSortFields = map[model.UserSortField]bool{
+	model.SortByName: true,
+}
+
+// orderByClause builds the ORDER BY clause for a user listing, using the ID as a tie-breaker
+// so pagination is stable. Unknown fields fall back to created_at.
+func orderByClause(d dialect, sort model.UserSort) string {
+	column, ok := userSortColumns[sort.Field]
+	if !ok {
+		column = "created_at"
+	}
+	if caseFoldedSortFields[sort.Field] {
+		column = d.foldCase(column)
+	}
+	direction := "ASC"
+	if sort.Descending {
+		direction = "DESC"
+	}
+	return " ORDER BY " + column + " " + direction + ", id " + direction
+}
+
+// GetAllUsers retrieves the users matching the filter from the database with pagination, in the given order.
+func (r *sqlUserRepository) GetAllUsers(page, pageSize int, filter model.UserFilter, sort model.UserSort) ([]model.User, error) {
 	// Ensure page and pageSize are positive
 	if page < 1 {
 		page = 1
@@ -109,8 +382,13 @@ func (r *sqliteUserRepository) GetAllUsers(page, pageSize int) ([]model.User, er
 	}
 
 	offset := (page - 1) * pageSize
-	query := `SELECT id, name, created_at, updated_at FROM users ORDER BY created_at DESC LIMIT ? OFFSET ?`
-	rows, err := r.db.Query(query, pageSize, offset)
+	where, args := buildUserFilterClause(filter)
+	stmt, done, err := r.prepare(`SELECT ` + userColumns + ` FROM users` + where + orderByClause(r.d, sort) + ` LIMIT ? OFFSET ?`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement for querying all users: %w", err)
+	}
+	defer done()
+	rows, err := stmt.Query(append(args, pageSize, offset)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query all users: %w", err)
 	}
@@ -123,7 +401,7 @@ func (r *sqliteUserRepository) GetAllUsers(page, pageSize int) ([]model.User, er
 	var users []model.User
 	for rows.Next() {
 		var user model.User
-		if err := rows.Scan(&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err := scanUser(rows, &user); err != nil {
 			return nil, fmt.Errorf("failed to scan user row: %w", err)
 		}
 		users = append(users, user)
@@ -132,22 +410,275 @@ func (r *sqliteUserRepository) GetAllUsers(page, pageSize int) ([]model.User, er
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error during rows iteration for GetAllUsers: %w", err)
 	}
+	rows.Close() // Release the connection before loading attributes
 
+	loaded := make([]*model.User, len(users))
+	for i := range users {
+		loaded[i] = &users[i]
+	}
+	if err := loadAttributes(r.querier(), loaded...); err != nil {
+		return nil, err
+	}
 	return users, nil
 }
 
 // GetUserByID retrieves a single user by their ID.
-func (r *sqliteUserRepository) GetUserByID(id int64) (*model.User, error) {
-	query := `SELECT id, name, created_at, updated_at FROM users WHERE id = ?`
-	row := r.db.QueryRow(query, id)
+func (r *sqlUserRepository) GetUserByID(id int64) (*model.User, error) {
+	stmt, done, err := r.prepare(`SELECT ` + userColumns + ` FROM users WHERE id = ? AND ` + activeUser)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement for getting user by ID: %w", err)
+	}
+	defer done()
 
 	var user model.User
-	err := row.Scan(&user.ID, &user.Name, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
+	if err := scanUser(stmt.QueryRow(id), &user); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil // User not found
 		}
 		return nil, fmt.Errorf("failed to scan user by ID: %w", err)
 	}
+	if err := loadAttributes(r.querier(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes a user's name if its stored version equals expectedVersion,
+// bumping both version and updated_at. It returns nil if the user does not exist
+// and ErrVersionConflict if the user was modified concurrently.
+func (r *sqlUserRepository) UpdateUser(id int64, name string, expectedVersion int64) (*model.User, error) {
+	update := `UPDATE users SET name = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND version = ? AND ` + activeUser
+
+	var user model.User
+	err := r.writes.Do(func() error {
+		now := time.Now().UnixMicro() // Get current time in microseconds
+		return r.updateUser(&user, update, id, name, now, id, expectedVersion)
+	})
+	if err == nil {
+		if err := loadAttributes(r.querier(), &user); err != nil {
+			return nil, err
+		}
+		return &user, nil
+	}
+	if errors.Is(err, ErrWriteQueueTimeout) || errors.Is(err, ErrWriteQueueClosed) {
+		return nil, err
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrNameTaken
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to execute statement for updating user: %w", err)
+	}
+
+	// Nothing was updated: either the user does not exist or its version moved on
+	current, err := r.GetUserByID(id)
+	if err != nil || current == nil {
+		return current, err
+	}
+	return current, ErrVersionConflict // Return the current state so callers can report it
+}
+
+// SetUserStatus changes the account status of an active user, bumping both version and
+// updated_at. It returns nil if the user does not exist.
+func (r *sqlUserRepository) SetUserStatus(id int64, status string) (*model.User, error) {
+	update := `UPDATE users SET status = ?, updated_at = ?, version = version + 1
+		WHERE id = ? AND ` + activeUser
+
+	var user model.User
+	err := r.writes.Do(func() error {
+		now := time.Now().UnixMicro()
+		return r.updateUser(&user, update, id, status, now, id)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if errors.Is(err, ErrWriteQueueTimeout) || errors.Is(err, ErrWriteQueueClosed) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update user status: %w", err)
+	}
+	if err := loadAttributes(r.querier(), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// updateUser runs update, an UPDATE of the user with the given id, and reads the updated
+// row into user. It returns sql.ErrNoRows if no row was updated.
+func (r *sqlUserRepository) updateUser(user *model.User, update string, id int64, args ...any) error {
+	if r.d == dialectMySQL {
+		return r.transaction("updating user", func(tx *sql.Tx) error {
+			return updateReturning(tx, r.d, user, update, id, args...)
+		})
+	}
+
+	stmt, done, err := r.prepare(update + ` RETURNING ` + userColumns)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement for updating user: %w", err)
+	}
+	defer done()
+	return scanUser(stmt.QueryRow(args...), user)
+}
+
+// updateReturning runs update, an UPDATE of the user with the given id, on q and reads the
+// updated row into user. It returns sql.ErrNoRows if no row was updated. MySQL has no
+// RETURNING clause, so there the row is selected again, which q must run in the same
+// transaction as the update.
+func updateReturning(q querier, d dialect, user *model.User, update string, id int64, args ...any) error {
+	if d != dialectMySQL {
+		return scanUser(q.QueryRow(update+` RETURNING `+userColumns, args...), user)
+	}
+
+	result, err := q.Exec(update, args...)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return sql.ErrNoRows
+	}
+	return scanUser(q.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id), user)
+}
+
+// MergeUser soft-deletes the source user, recording the target it was merged into, and
+// enqueues the given event in the outbox within the same transaction.
+// The event's timestamp is used as the deletion time.
+// It returns the source user as it was before the merge, or nil if it does not exist (or was already deleted).
+func (r *sqlUserRepository) MergeUser(sourceID, targetID int64, event model.Event) (*model.User, error) {
+	var source *model.User
+	err := r.writes.Do(func() error {
+		return r.transaction("merging user", func(tx *sql.Tx) (err error) {
+			source, err = softDeleteUser(tx, sourceID, sql.NullInt64{Int64: targetID, Valid: true}, event)
+			return err
+		})
+	})
+	return source, err
+}
+
+// DeleteUser soft-deletes a user and enqueues the given event in the outbox within the same
+// transaction. The event's timestamp is used as the deletion time.
+// It returns the user as it was before the deletion, or nil if it does not exist (or was already deleted).
+func (r *sqlUserRepository) DeleteUser(id int64, event model.Event) (*model.User, error) {
+	var user *model.User
+	err := r.writes.Do(func() error {
+		return r.transaction("deleting user", func(tx *sql.Tx) (err error) {
+			user, err = softDeleteUser(tx, id, sql.NullInt64{}, event)
+			return err
+		})
+	})
+	return user, err
+}
+
+// softDeleteUser soft-deletes a user and enqueues the event within the given transaction,
+// on behalf of MergeUser and DeleteUser.
+func softDeleteUser(tx *sql.Tx, id int64, mergedInto sql.NullInt64, event model.Event) (*model.User, error) {
+	var user model.User
+	err := scanUser(tx.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ? AND `+activeUser, id), &user)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load user for deletion: %w", err)
+	}
+	if err := loadAttributes(tx, &user); err != nil {
+		return nil, err
+	}
+
+	now := event.CreatedAt
+	_, err = tx.Exec(
+		`UPDATE users SET deleted_at = ?, merged_into = ?, updated_at = ?, version = version + 1 WHERE id = ?`,
+		now, mergedInto, now, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to soft-delete user: %w", err)
+	}
+
+	if err := insertOutboxEvent(tx, event); err != nil {
+		return nil, err
+	}
+
 	return &user, nil
 }
+
+// buildUserFilterClause translates a UserFilter into a WHERE clause (including the keyword)
+// and its arguments. An empty string is returned when no condition applies.
+func buildUserFilterClause(filter model.UserFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	switch filter.Deleted {
+	case model.DeletedExclude:
+		conditions = append(conditions, activeUser)
+	case model.DeletedOnly:
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.CreatedAfter > 0 {
+		conditions = append(conditions, "created_at > ?")
+		args = append(args, filter.CreatedAfter)
+	}
+	if filter.CreatedBefore > 0 {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedBefore)
+	}
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// StreamUsers iterates over all users matching the filter in ascending ID order,
+// invoking fn for each row as it is read so the full result set is never held in memory.
+// Iteration stops at the first error returned by fn, which is then returned to the caller.
+func (r *sqlUserRepository) StreamUsers(filter model.UserFilter, fn func(*model.User) error) error {
+	where, args := buildUserFilterClause(filter)
+	query := `SELECT ` + userColumns + ` FROM users` + where + ` ORDER BY id ASC`
+	rows, err := r.querier().Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query users for streaming: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	var user model.User
+	for rows.Next() {
+		if err := scanUser(rows, &user); err != nil {
+			return fmt.Errorf("failed to scan user row: %w", err)
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error during rows iteration for StreamUsers: %w", err)
+	}
+
+	return nil
+}
+
+// CountUsers returns the number of users matching the filter.
+func (r *sqlUserRepository) CountUsers(filter model.UserFilter) (int64, error) {
+	where, args := buildUserFilterClause(filter)
+	stmt, done, err := r.prepare(`SELECT COUNT(*) FROM users` + where)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement for counting users: %w", err)
+	}
+	defer done()
+
+	var count int64
+	if err := stmt.QueryRow(args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count users: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	// initialConnectBackoff is the delay before the first connection retry.
+	initialConnectBackoff = 200 * time.Millisecond
+	// maxConnectBackoff caps the delay between connection retries.
+	maxConnectBackoff = 5 * time.Second
+)
+
+// ConnectWithRetry calls connect until it succeeds or the window has elapsed, doubling the
+// delay between attempts up to maxConnectBackoff. A non-positive window makes a single attempt.
+// It lets the service survive a database that is still starting up or temporarily unreachable.
+func ConnectWithRetry(window time.Duration, connect func() (*sql.DB, error)) (*sql.DB, error) {
+	deadline := time.Now().Add(window)
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		db, err := connect()
+		if err == nil {
+			return db, nil
+		}
+		if errors.Is(err, ErrUnsupportedDriver) {
+			return nil, err
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil, fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+		}
+		wait := min(backoff, remaining)
+		log.Printf("Database connection attempt %d failed: %v (retrying in %s)", attempt, err, wait)
+		time.Sleep(wait)
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
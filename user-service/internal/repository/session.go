@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"user-service/internal/model"
+)
+
+// SessionRepository defines the interface for login credentials and session storage.
+type SessionRepository interface {
+	GetCredentials(name string) (*model.User, string, error)
+	CreateSession(session model.Session, tokenHash string) (*model.Session, error)
+	ListActiveSessions(userID int64, now int64) ([]model.Session, error)
+	GetSessionByTokenHash(tokenHash string) (*model.Session, error)
+	RevokeSession(userID, sessionID int64, revokedAt int64) (bool, error)
+}
+
+// sqlSessionRepository implements SessionRepository for SQL databases.
+type sqlSessionRepository struct {
+	db     *sql.DB
+	d      dialect
+	writes *WriteQueue
+}
+
+// createSessionsTableSQL creates the user_sessions table. Only a hash of each session token is
+// stored, so a leaked database does not leak usable tokens.
+const createSessionsTableSQL = `
+	CREATE TABLE IF NOT EXISTS user_sessions (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		token_hash VARCHAR(255) NOT NULL UNIQUE,
+		user_agent TEXT NOT NULL,
+		ip_address TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER NOT NULL,
+		revoked_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id, created_at);`
+
+// sessionColumns lists the columns selected for a session, in the order expected by scanSession.
+const sessionColumns = "id, user_id, user_agent, ip_address, created_at, expires_at, revoked_at"
+
+// scanSession reads a session selected with sessionColumns.
+func scanSession(row rowScanner, session *model.Session) error {
+	var revokedAt sql.NullInt64
+	if err := row.Scan(&session.ID, &session.UserID, &session.UserAgent, &session.IPAddress,
+		&session.CreatedAt, &session.ExpiresAt, &revokedAt); err != nil {
+		return err
+	}
+	session.RevokedAt = revokedAt.Int64
+	return nil
+}
+
+// NewSessionRepository creates a new instance of sqlSessionRepository.
+// Inserts and updates are executed through the given write queue, which may be nil.
+func NewSessionRepository(db *sql.DB, writes *WriteQueue) SessionRepository {
+	return &sqlSessionRepository{db: db, d: dialectOf(db), writes: writes}
+}
+
+// GetCredentials returns the active user with the given name along with its password hash,
+// which is empty if the user has no password. Names are matched ignoring letter case,
+// preferring an exact match. It returns (nil, "", nil) if no such user exists.
+func (r *sqlSessionRepository) GetCredentials(name string) (*model.User, string, error) {
+	row := r.db.QueryRow(
+		`SELECT `+userColumns+`, password_hash FROM users
+		WHERE `+r.d.foldCase("name")+` = `+r.d.foldCase("?")+` AND `+activeUser+`
+		ORDER BY name = ? DESC LIMIT 1`,
+		name, name,
+	)
+
+	var user model.User
+	var passwordHash sql.NullString
+	if err := scanUser(row, &user, &passwordHash); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, "", nil
+		}
+		return nil, "", fmt.Errorf("failed to load user credentials: %w", err)
+	}
+	return &user, passwordHash.String, nil
+}
+
+// CreateSession stores a new session and records its creation time as the user's last login,
+// in a single transaction.
+func (r *sqlSessionRepository) CreateSession(session model.Session, tokenHash string) (*model.Session, error) {
+	err := r.writes.Do(func() error {
+		tx, err := r.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction for creating session: %w", err)
+		}
+		defer func() {
+			// Rollback is a no-op once the transaction has been committed
+			if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+				log.Printf("Error rolling back transaction: %v", err)
+			}
+		}()
+
+		session.ID, err = r.d.insert(tx,
+			`INSERT INTO user_sessions(user_id, token_hash, user_agent, ip_address, created_at, expires_at)
+			VALUES(?, ?, ?, ?, ?, ?)`,
+			session.UserID, tokenHash, session.UserAgent, session.IPAddress, session.CreatedAt, session.ExpiresAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert session: %w", err)
+		}
+
+		if _, err := tx.Exec(`UPDATE users SET last_login_at = ? WHERE id = ?`, session.CreatedAt, session.UserID); err != nil {
+			return fmt.Errorf("failed to record last login: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction for creating session: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListActiveSessions returns the user's sessions that are neither revoked nor expired at now,
+// most recent first.
+func (r *sqlSessionRepository) ListActiveSessions(userID int64, now int64) ([]model.Session, error) {
+	rows, err := r.db.Query(
+		`SELECT `+sessionColumns+` FROM user_sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC, id DESC`,
+		userID, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	sessions := []model.Session{}
+	for rows.Next() {
+		var session model.Session
+		if err := scanSession(rows, &session); err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for ListActiveSessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// GetSessionByTokenHash returns the session with the given token hash, whatever its state,
+// or nil if there is none.
+func (r *sqlSessionRepository) GetSessionByTokenHash(tokenHash string) (*model.Session, error) {
+	row := r.db.QueryRow(`SELECT `+sessionColumns+` FROM user_sessions WHERE token_hash = ?`, tokenHash)
+	var session model.Session
+	if err := scanSession(row, &session); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load session: %w", err)
+	}
+	return &session, nil
+}
+
+// RevokeSession marks one of the user's sessions as revoked. It returns false if the user
+// has no such session, or if it was already revoked.
+func (r *sqlSessionRepository) RevokeSession(userID, sessionID int64, revokedAt int64) (bool, error) {
+	var affected int64
+	err := r.writes.Do(func() error {
+		result, err := r.db.Exec(
+			`UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+			revokedAt, sessionID, userID,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return affected > 0, nil
+}
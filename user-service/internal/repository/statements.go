@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"database/sql"
+	"log"
+	"sync"
+)
+
+// statementCache prepares queries once and reuses the statements across calls, sparing
+// SQLite from parsing and planning the same SQL on every request. Statements are released
+// when the database is closed.
+// Only queries drawn from a bounded set of texts should go through the cache.
+type statementCache struct {
+	db       *sql.DB
+	disabled bool
+
+	mu    sync.RWMutex
+	stmts map[string]*sql.Stmt
+}
+
+// newStatementCache creates a statement cache for db.
+func newStatementCache(db *sql.DB) *statementCache {
+	return &statementCache{db: db, stmts: make(map[string]*sql.Stmt)}
+}
+
+// prepare returns a prepared statement for query, along with a function to call once the
+// statement is no longer in use. Cached statements stay open, so done only closes statements
+// prepared for a single call when caching is disabled.
+func (c *statementCache) prepare(query string) (stmt *sql.Stmt, done func(), err error) {
+	if c.disabled {
+		stmt, err := c.db.Prepare(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stmt, func() {
+			if err := stmt.Close(); err != nil {
+				log.Printf("Error closing statement: %v", err)
+			}
+		}, nil
+	}
+
+	if stmt, ok := c.cached(query); ok {
+		return stmt, func() {}, nil
+	}
+
+	// Prepare outside the lock; if another caller won the race, keep its statement
+	stmt, err = c.db.Prepare(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if existing, ok := c.stmts[query]; ok {
+		if err := stmt.Close(); err != nil {
+			log.Printf("Error closing statement: %v", err)
+		}
+		return existing, func() {}, nil
+	}
+	c.stmts[query] = stmt
+	return stmt, func() {}, nil
+}
+
+// cached returns the statement already prepared for query, if any.
+func (c *statementCache) cached(query string) (*sql.Stmt, bool) {
+	if c.disabled {
+		return nil, false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	stmt, ok := c.stmts[query]
+	return stmt, ok
+}
+
+// UserRepositoryOption configures the SQL user repository.
+type UserRepositoryOption func(*sqlUserRepository)
+
+// WithoutStatementCache makes the repository prepare its statements on every call instead of
+// reusing them. It exists to measure the benefit of the cache.
+func WithoutStatementCache() UserRepositoryOption {
+	return func(r *sqlUserRepository) {
+		r.stmts.disabled = true
+	}
+}
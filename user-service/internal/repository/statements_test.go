@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"user-service/internal/model"
+)
+
+// benchUsers is the number of users created before the benchmarks reading and updating them.
+const benchUsers = 1000
+
+// benchModes are the configurations of the repository each benchmark compares.
+var benchModes = []struct {
+	name string
+	opts []UserRepositoryOption
+}{
+	{"PreparePerCall", []UserRepositoryOption{WithoutStatementCache()}},
+	{"StatementCache", nil},
+}
+
+// newBenchRepository returns a repository over a fresh SQLite database with users users,
+// writing through a write queue as the service does.
+func newBenchRepository(b *testing.B, users int, opts []UserRepositoryOption) UserRepository {
+	b.Helper()
+	db, err := NewSQLiteDB(filepath.Join(b.TempDir(), "bench.db"))
+	if err != nil {
+		b.Fatalf("Failed to initialize database: %v", err)
+	}
+	writes := NewWriteQueue(DefaultWriteQueueDepth, DefaultWriteQueueTimeout)
+	b.Cleanup(func() {
+		writes.Close()
+		db.Close()
+	})
+
+	repo := NewUserRepository(db, writes, opts...)
+	for i := range users {
+		if _, err := repo.CreateUser(fmt.Sprintf("Bench User %d", i), ""); err != nil {
+			b.Fatalf("Failed to create user %d: %v", i, err)
+		}
+	}
+	return repo
+}
+
+// runBench runs op from parallel goroutines for each mode, handing it a sequence number
+// unique to the call.
+func runBench(b *testing.B, users int, op func(repo UserRepository, i int64) error) {
+	for _, mode := range benchModes {
+		b.Run(mode.name, func(b *testing.B) {
+			repo := newBenchRepository(b, users, mode.opts)
+			var next atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := op(repo, next.Add(1)); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkCreateUser(b *testing.B) {
+	runBench(b, 0, func(repo UserRepository, i int64) error {
+		_, err := repo.CreateUser(fmt.Sprintf("Created User %d", i), "")
+		return err
+	})
+}
+
+func BenchmarkGetUserByID(b *testing.B) {
+	runBench(b, benchUsers, func(repo UserRepository, i int64) error {
+		_, err := repo.GetUserByID(i%benchUsers + 1)
+		return err
+	})
+}
+
+func BenchmarkGetAllUsers(b *testing.B) {
+	sort := model.UserSort{Field: model.SortByCreatedAt, Descending: true}
+	runBench(b, benchUsers, func(repo UserRepository, i int64) error {
+		_, err := repo.GetAllUsers(int(i%10)+1, 10, model.UserFilter{}, sort)
+		return err
+	})
+}
+
+// BenchmarkUpdateUser reads the version of a user before renaming it, as clients do before a
+// PUT. Parallel calls may still race on a user, whose conflicts cost a round trip as well.
+func BenchmarkUpdateUser(b *testing.B) {
+	runBench(b, benchUsers, func(repo UserRepository, i int64) error {
+		id := i%benchUsers + 1
+		user, err := repo.GetUserByID(id)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			return fmt.Errorf("user %d not found", id)
+		}
+		_, err = repo.UpdateUser(id, fmt.Sprintf("Renamed User %d", i), user.Version)
+		if err == ErrVersionConflict {
+			return nil
+		}
+		return err
+	})
+}
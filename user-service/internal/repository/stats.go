@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+
+	"user-service/internal/model"
+)
+
+// microsPerDay is the length of a day in the microsecond timestamps stored in users.
+const microsPerDay = 24 * 60 * 60 * 1_000_000
+
+// CountUsersByState counts all users, split by deletion and account status.
+func (r *sqlUserRepository) CountUsersByState() (model.UserTotals, error) {
+	stmt, done, err := r.prepare(`SELECT
+		COUNT(*),
+		COALESCE(SUM(CASE WHEN deleted_at IS NULL AND status = '` + model.UserStatusActive + `' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN deleted_at IS NULL AND status = '` + model.UserStatusSuspended + `' THEN 1 ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN deleted_at IS NOT NULL THEN 1 ELSE 0 END), 0)
+		FROM users`)
+	if err != nil {
+		return model.UserTotals{}, fmt.Errorf("failed to prepare statement for counting users by state: %w", err)
+	}
+	defer done()
+
+	var totals model.UserTotals
+	if err := stmt.QueryRow().Scan(&totals.Total, &totals.Active, &totals.Suspended, &totals.Deleted); err != nil {
+		return model.UserTotals{}, fmt.Errorf("failed to count users by state: %w", err)
+	}
+	return totals, nil
+}
+
+// CountSignupsByDay counts the users created at or after since (microseconds), deleted ones
+// included, grouped by UTC day. Days are keyed by their number since the Unix epoch; days
+// without signups are absent.
+func (r *sqlUserRepository) CountSignupsByDay(since int64) (map[int64]int64, error) {
+	stmt, done, err := r.prepare(
+		`SELECT ` + r.d.intDiv("created_at", microsPerDay) + ` AS day, COUNT(*) FROM users WHERE created_at >= ? GROUP BY day`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement for counting signups: %w", err)
+	}
+	defer done()
+
+	rows, err := stmt.Query(since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var day, count int64
+		if err := rows.Scan(&day, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan signup count: %w", err)
+		}
+		counts[day] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for CountSignupsByDay: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+
+	"user-service/internal/model"
+)
+
+// AccessTokenRepository defines the interface for personal access token storage.
+type AccessTokenRepository interface {
+	CreateAccessToken(token model.AccessToken, tokenHash string) (*model.AccessToken, error)
+	ListAccessTokens(userID int64) ([]model.AccessToken, error)
+	GetAccessTokenByHash(tokenHash string) (*model.AccessToken, error)
+	TouchAccessToken(id int64, usedAt int64) error
+	RevokeAccessToken(userID, tokenID int64, revokedAt int64) (bool, error)
+}
+
+// sqlAccessTokenRepository implements AccessTokenRepository for SQL databases.
+type sqlAccessTokenRepository struct {
+	db     *sql.DB
+	d      dialect
+	writes *WriteQueue
+}
+
+// createAccessTokensTableSQL creates the access_tokens table. Only a hash of each token is stored.
+const createAccessTokensTableSQL = `
+	CREATE TABLE IF NOT EXISTS access_tokens (
+		id INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		token_hash VARCHAR(255) NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		created_at INTEGER NOT NULL,
+		expires_at INTEGER,
+		last_used_at INTEGER,
+		revoked_at INTEGER
+	);
+	CREATE INDEX IF NOT EXISTS idx_access_tokens_user_id ON access_tokens(user_id, created_at);`
+
+// accessTokenColumns lists the columns selected for a token, in the order expected by scanAccessToken.
+const accessTokenColumns = "id, user_id, name, prefix, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// scanAccessToken reads a token selected with accessTokenColumns.
+// Scopes are stored as a space-separated list.
+func scanAccessToken(row rowScanner, token *model.AccessToken) error {
+	var scopes string
+	var expiresAt, lastUsedAt, revokedAt sql.NullInt64
+	if err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.Prefix, &scopes,
+		&token.CreatedAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return err
+	}
+	token.Scopes = strings.Fields(scopes)
+	token.ExpiresAt = expiresAt.Int64
+	token.LastUsedAt = lastUsedAt.Int64
+	token.RevokedAt = revokedAt.Int64
+	return nil
+}
+
+// NewAccessTokenRepository creates a new instance of sqlAccessTokenRepository.
+// Inserts and updates are executed through the given write queue, which may be nil.
+func NewAccessTokenRepository(db *sql.DB, writes *WriteQueue) AccessTokenRepository {
+	return &sqlAccessTokenRepository{db: db, d: dialectOf(db), writes: writes}
+}
+
+// CreateAccessToken stores a newly issued token.
+func (r *sqlAccessTokenRepository) CreateAccessToken(token model.AccessToken, tokenHash string) (*model.AccessToken, error) {
+	err := r.writes.Do(func() error {
+		var err error
+		token.ID, err = r.d.insert(r.db,
+			`INSERT INTO access_tokens(user_id, name, prefix, token_hash, scopes, created_at, expires_at)
+			VALUES(?, ?, ?, ?, ?, ?, ?)`,
+			token.UserID, token.Name, token.Prefix, tokenHash, strings.Join(token.Scopes, " "), token.CreatedAt,
+			sql.NullInt64{Int64: token.ExpiresAt, Valid: token.ExpiresAt != 0},
+		)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert access token: %w", err)
+	}
+	return &token, nil
+}
+
+// ListAccessTokens returns the user's tokens that have not been revoked, most recent first.
+func (r *sqlAccessTokenRepository) ListAccessTokens(userID int64) ([]model.AccessToken, error) {
+	rows, err := r.db.Query(
+		`SELECT `+accessTokenColumns+` FROM access_tokens
+		WHERE user_id = ? AND revoked_at IS NULL ORDER BY created_at DESC, id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query access tokens: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			log.Printf("Error closing rows: %v", err)
+		}
+	}()
+
+	tokens := []model.AccessToken{}
+	for rows.Next() {
+		var token model.AccessToken
+		if err := scanAccessToken(rows, &token); err != nil {
+			return nil, fmt.Errorf("failed to scan access token row: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error during rows iteration for ListAccessTokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetAccessTokenByHash returns the token with the given hash, whatever its state,
+// or nil if there is none.
+func (r *sqlAccessTokenRepository) GetAccessTokenByHash(tokenHash string) (*model.AccessToken, error) {
+	row := r.db.QueryRow(`SELECT `+accessTokenColumns+` FROM access_tokens WHERE token_hash = ?`, tokenHash)
+	var token model.AccessToken
+	if err := scanAccessToken(row, &token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load access token: %w", err)
+	}
+	return &token, nil
+}
+
+// TouchAccessToken records when a token was last used.
+func (r *sqlAccessTokenRepository) TouchAccessToken(id int64, usedAt int64) error {
+	err := r.writes.Do(func() error {
+		_, err := r.db.Exec(`UPDATE access_tokens SET last_used_at = ? WHERE id = ?`, usedAt, id)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record access token use: %w", err)
+	}
+	return nil
+}
+
+// RevokeAccessToken marks one of the user's tokens as revoked. It returns false if the user
+// has no such token, or if it was already revoked.
+func (r *sqlAccessTokenRepository) RevokeAccessToken(userID, tokenID int64, revokedAt int64) (bool, error) {
+	var affected int64
+	err := r.writes.Do(func() error {
+		result, err := r.db.Exec(
+			`UPDATE access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+			revokedAt, tokenID, userID,
+		)
+		if err != nil {
+			return err
+		}
+		affected, err = result.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"user-service/internal/model"
+)
+
+// UnitOfWork gives access to writes that are committed or rolled back together.
+// It is only valid within the function passed to UserRepository.WithTx.
+type UnitOfWork interface {
+	// Users returns the user repository bound to the unit of work. Its reads see the
+	// unit's own uncommitted writes.
+	Users() UserRepository
+	// RecordAudit inserts audit entries as part of the unit of work.
+	RecordAudit(entries []model.AuditEntry) error
+	// EnqueueEvent adds a domain event to the outbox as part of the unit of work.
+	EnqueueEvent(event model.Event) error
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	execer
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlUnitOfWork implements UnitOfWork on top of a database transaction.
+type sqlUnitOfWork struct {
+	users *sqlUserRepository
+}
+
+func (u *sqlUnitOfWork) Users() UserRepository {
+	return u.users
+}
+
+func (u *sqlUnitOfWork) RecordAudit(entries []model.AuditEntry) error {
+	if err := insertAuditEntries(u.users.tx, entries); err != nil {
+		return fmt.Errorf("failed to insert audit entries: %w", err)
+	}
+	return nil
+}
+
+func (u *sqlUnitOfWork) EnqueueEvent(event model.Event) error {
+	return insertOutboxEvent(u.users.tx, event)
+}
+
+// WithTx runs fn within a single transaction, which is committed if fn returns nil and rolled
+// back otherwise; fn's error is returned as-is. The transaction holds the write queue for its
+// whole duration, so fn should not do slow work unrelated to the database.
+// Calling WithTx on the repository of a unit of work runs fn within that same unit.
+func (r *sqlUserRepository) WithTx(fn func(uow UnitOfWork) error) error {
+	if r.tx != nil {
+		return fn(&sqlUnitOfWork{users: r})
+	}
+	return r.writes.Do(func() error {
+		return r.transaction("unit of work", func(tx *sql.Tx) error {
+			// Writes are already serialized by the surrounding queue slot
+			return fn(&sqlUnitOfWork{users: &sqlUserRepository{db: r.db, d: r.d, stmts: r.stmts, tx: tx}})
+		})
+	})
+}
+
+// transaction runs fn in a transaction, committing it if fn succeeds. Within a unit of work,
+// fn joins the unit's transaction instead, which is committed by WithTx.
+func (r *sqlUserRepository) transaction(what string, fn func(tx *sql.Tx) error) error {
+	if r.tx != nil {
+		return fn(r.tx)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction for %s: %w", what, err)
+	}
+	defer func() {
+		// Rollback is a no-op once the transaction has been committed
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			log.Printf("Error rolling back transaction: %v", err)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction for %s: %w", what, err)
+	}
+	return nil
+}
+
+// querier returns the handle queries should run on: the unit of work's transaction, if any.
+func (r *sqlUserRepository) querier() querier {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+// prepare returns a prepared statement for query, along with the function to call once it is
+// no longer in use. Within a unit of work the statement is bound to its transaction; it reuses
+// a cached statement when there is one, but is otherwise prepared on the transaction itself,
+// as preparing it for the cache could wait for a connection that the transaction holds.
+func (r *sqlUserRepository) prepare(query string) (*sql.Stmt, func(), error) {
+	if r.tx == nil {
+		return r.stmts.prepare(query)
+	}
+
+	var txStmt *sql.Stmt
+	if stmt, ok := r.stmts.cached(query); ok {
+		txStmt = r.tx.Stmt(stmt)
+	} else {
+		var err error
+		if txStmt, err = r.tx.Prepare(query); err != nil {
+			return nil, nil, err
+		}
+	}
+	return txStmt, func() {
+		if err := txStmt.Close(); err != nil {
+			log.Printf("Error closing statement: %v", err)
+		}
+	}, nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"sync"
+	"time"
+
+	"apperrors"
+)
+
+const (
+	// DefaultWriteQueueDepth is the default number of writes that may wait for their turn.
+	DefaultWriteQueueDepth = 1000
+	// DefaultWriteQueueTimeout is the default time a write may wait before it is abandoned.
+	DefaultWriteQueueTimeout = 5 * time.Second
+)
+
+// ErrWriteQueueTimeout is returned when a write could not be executed within the queue timeout,
+// either because the queue was full or because earlier writes took too long.
+var ErrWriteQueueTimeout = apperrors.New(apperrors.Unavailable, "timed out waiting for write queue")
+
+// ErrWriteQueueClosed is returned for writes submitted once the queue is closed, e.g. by
+// background workers still running while the service shuts down.
+var ErrWriteQueueClosed = apperrors.New(apperrors.Unavailable, "write queue is closed")
+
+// writeJob is a write waiting in the queue.
+type writeJob struct {
+	fn       func() error
+	deadline time.Time
+	done     chan error
+}
+
+// WriteQueue executes database writes one at a time, in submission order, on a single goroutine.
+// SQLite allows only one writer at a time; funnelling every insert and update through the queue
+// avoids SQLITE_BUSY failures when many requests write concurrently.
+// A nil *WriteQueue executes writes directly on the calling goroutine.
+type WriteQueue struct {
+	jobs    chan writeJob
+	timeout time.Duration
+	mu      sync.RWMutex // Held for reading while a write is queued, so Close cannot close jobs under it
+	closed  bool
+	stopped chan struct{}
+}
+
+// NewWriteQueue starts a write queue holding up to depth pending writes. A write that cannot be
+// queued, or does not start, within timeout fails with ErrWriteQueueTimeout.
+// Non-positive values use DefaultWriteQueueDepth and DefaultWriteQueueTimeout.
+func NewWriteQueue(depth int, timeout time.Duration) *WriteQueue {
+	if depth <= 0 {
+		depth = DefaultWriteQueueDepth
+	}
+	if timeout <= 0 {
+		timeout = DefaultWriteQueueTimeout
+	}
+	q := &WriteQueue{
+		jobs:    make(chan writeJob, depth),
+		timeout: timeout,
+		stopped: make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// Do queues fn and waits for it to run, returning its error.
+// Once fn has started it always runs to completion, so a timeout never leaves a write half done.
+func (q *WriteQueue) Do(fn func() error) error {
+	if q == nil {
+		return fn()
+	}
+
+	job := writeJob{fn: fn, deadline: time.Now().Add(q.timeout), done: make(chan error, 1)}
+	if err := q.enqueue(job); err != nil {
+		return err
+	}
+	return <-job.done
+}
+
+// enqueue queues job, unless the queue is closed or stays full for the timeout.
+func (q *WriteQueue) enqueue(job writeJob) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		return ErrWriteQueueClosed
+	}
+
+	timer := time.NewTimer(q.timeout)
+	defer timer.Stop()
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-timer.C:
+		return ErrWriteQueueTimeout
+	}
+}
+
+// Close stops accepting writes and waits for the queued ones to finish. Later writes fail with
+// ErrWriteQueueClosed.
+func (q *WriteQueue) Close() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+	<-q.stopped
+}
+
+// run executes queued writes until the queue is closed. Writes that waited longer than the
+// timeout are skipped and fail with ErrWriteQueueTimeout, so a backlog cannot grow unbounded latency.
+func (q *WriteQueue) run() {
+	defer close(q.stopped)
+	for job := range q.jobs {
+		if time.Now().After(job.deadline) {
+			job.done <- ErrWriteQueueTimeout
+			continue
+		}
+		job.done <- job.fn()
+	}
+}
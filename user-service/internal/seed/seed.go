@@ -0,0 +1,109 @@
+package seed
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"user-service/internal/repository"
+)
+
+// firstNames and lastNames are combined to produce plausible full names.
+var firstNames = []string{
+	"Aarav", "Adi", "Aisha", "Amelia", "Ana", "Andi", "Arjun", "Ayu", "Bayu", "Budi",
+	"Carlos", "Chen", "Chloe", "Citra", "Daniel", "Dewi", "Diego", "Eka", "Elena", "Emma",
+	"Fajar", "Farah", "Gita", "Hana", "Hiroshi", "Ibrahim", "Indah", "Isabella", "James", "Jia",
+	"Kartika", "Kenji", "Lina", "Liam", "Lucas", "Maya", "Mei", "Michael", "Nadia", "Noah",
+	"Nur", "Olivia", "Omar", "Priya", "Putri", "Rahul", "Rizky", "Sakura", "Sari", "Sofia",
+	"Suresh", "Tariq", "Tomas", "Utami", "Valentina", "Wei", "Wulan", "Yuki", "Zahra", "Zhang",
+}
+
+var lastNames = []string{
+	"Anderson", "Chandra", "Chen", "Cruz", "Fernandez", "Garcia", "Gunawan", "Halim", "Hartono", "Ito",
+	"Kim", "Kumar", "Kusuma", "Lee", "Lim", "Lopez", "Martin", "Nakamura", "Nguyen", "Novak",
+	"Patel", "Pratama", "Rahman", "Santoso", "Saputra", "Sato", "Setiawan", "Silva", "Singh", "Smith",
+	"Subramaniam", "Suzuki", "Tan", "Taylor", "Wang", "Wibowo", "Wijaya", "Wong", "Yamamoto", "Zhou",
+}
+
+// Generator produces realistic fake user names.
+type Generator struct {
+	rng *rand.Rand
+}
+
+// NewGenerator creates a Generator. The same seed always yields the same sequence of names.
+func NewGenerator(seed uint64) *Generator {
+	return &Generator{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+}
+
+// Name returns a random full name, occasionally with a middle initial.
+func (g *Generator) Name() string {
+	first := firstNames[g.rng.IntN(len(firstNames))]
+	last := lastNames[g.rng.IntN(len(lastNames))]
+	if g.rng.IntN(5) == 0 {
+		return fmt.Sprintf("%s %c. %s", first, 'A'+rune(g.rng.IntN(26)), last)
+	}
+	return first + " " + last
+}
+
+// Users inserts count generated users through the repository in batches of batchSize,
+// calling progress (if non-nil) with the running total after each batch.
+// Generated names never collide, ignoring letter case, with each other or with active users
+// already in the database, so seeding works whatever the unique name index mode.
+func Users(repo repository.UserRepository, gen *Generator, count, batchSize int, progress func(done int)) error {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	used := make(map[string]bool)
+	names := make([]string, 0, batchSize)
+	for done := 0; done < count; {
+		names = names[:0]
+		for len(names) < batchSize && done+len(names) < count {
+			names = append(names, uniqueName(gen, used))
+		}
+		if err := replaceTakenNames(repo, gen, names, used); err != nil {
+			return fmt.Errorf("failed to seed users after %d inserted: %w", done, err)
+		}
+		if _, err := repo.CreateUsers(names); err != nil {
+			return fmt.Errorf("failed to seed users after %d inserted: %w", done, err)
+		}
+		done += len(names)
+		if progress != nil {
+			progress(done)
+		}
+	}
+	return nil
+}
+
+// uniqueName returns a generated name not in used, numbering it when the generator repeats itself.
+func uniqueName(gen *Generator, used map[string]bool) string {
+	name := gen.Name()
+	candidate := name
+	for n := 2; used[strings.ToLower(candidate)]; n++ {
+		candidate = fmt.Sprintf("%s %d", name, n)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// replaceTakenNames swaps names already used by active users for fresh ones, until none is taken.
+func replaceTakenNames(repo repository.UserRepository, gen *Generator, names []string, used map[string]bool) error {
+	for {
+		existing, err := repo.FindActiveUsersByNames(names)
+		if err != nil {
+			return err
+		}
+		if len(existing) == 0 {
+			return nil
+		}
+		taken := make(map[string]bool, len(existing))
+		for _, user := range existing {
+			taken[strings.ToLower(user.Name)] = true
+			used[strings.ToLower(user.Name)] = true
+		}
+		for i, name := range names {
+			if taken[strings.ToLower(name)] {
+				names[i] = uniqueName(gen, used)
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+
+	"apperrors"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// Limits on user attributes, keeping profile metadata small enough to return with every user.
+const (
+	maxAttributeValueLength = 1024
+	maxAttributesPerUser    = 50
+)
+
+// attributeKeyPattern matches valid attribute keys.
+var attributeKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,64}$`)
+
+// ErrInvalidAttribute is returned when an attribute key or value is not acceptable.
+var ErrInvalidAttribute = apperrors.New(apperrors.Validation, "invalid attribute")
+
+// ErrTooManyAttributes is returned when setting a new attribute would exceed the per-user limit.
+var ErrTooManyAttributes = apperrors.New(apperrors.Conflict, fmt.Sprintf("users cannot have more than %d attributes", maxAttributesPerUser))
+
+// ErrAttributeNotFound is returned when deleting an attribute the user does not have.
+var ErrAttributeNotFound = repository.ErrAttributeNotFound
+
+// validateAttributeKey checks that key is a valid attribute key.
+func validateAttributeKey(key string) error {
+	if !attributeKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: keys must be 1-64 letters, digits, '_', '.' or '-', got %q", ErrInvalidAttribute, key)
+	}
+	return nil
+}
+
+// SetUserAttribute sets an attribute of a user, replacing any previous value for the key.
+// It returns (nil, nil) if the user does not exist.
+func (s *UserService) SetUserAttribute(id int64, key, value string, meta model.RequestMeta) (*model.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", id)
+	}
+	if err := validateAttributeKey(key); err != nil {
+		return nil, err
+	}
+	if !utf8.ValidString(value) || utf8.RuneCountInString(value) > maxAttributeValueLength {
+		return nil, fmt.Errorf("%w: values must be valid UTF-8 of at most %d characters", ErrInvalidAttribute, maxAttributeValueLength)
+	}
+
+	var after *model.User
+	err := s.repo.WithTx(func(uow repository.UnitOfWork) error {
+		before, err := uow.Users().GetUserByID(id)
+		if err != nil || before == nil {
+			return err
+		}
+		current, exists := before.Attributes[key]
+		if exists && current == value {
+			after = before
+			return nil
+		}
+		if !exists && len(before.Attributes) >= maxAttributesPerUser {
+			return ErrTooManyAttributes
+		}
+		if after, err = uow.Users().SetUserAttribute(id, key, value); err != nil || after == nil {
+			return err
+		}
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionUpdate, meta, before, after)})
+	})
+	s.cache.invalidate(id)
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// DeleteUserAttribute removes an attribute of a user. It returns (nil, nil) if the user does
+// not exist and ErrAttributeNotFound if the user has no such attribute.
+func (s *UserService) DeleteUserAttribute(id int64, key string, meta model.RequestMeta) (*model.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", id)
+	}
+	if err := validateAttributeKey(key); err != nil {
+		return nil, err
+	}
+
+	var after *model.User
+	err := s.repo.WithTx(func(uow repository.UnitOfWork) error {
+		before, err := uow.Users().GetUserByID(id)
+		if err != nil || before == nil {
+			return err
+		}
+		if after, err = uow.Users().DeleteUserAttribute(id, key); err != nil || after == nil {
+			return err
+		}
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionUpdate, meta, before, after)})
+	})
+	s.cache.invalidate(id)
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
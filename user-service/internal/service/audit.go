@@ -0,0 +1,51 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// newAuditEntry builds an audit entry for a mutation of a user.
+// Either snapshot may be nil (no "before" for creations, no "after" for deletions).
+func newAuditEntry(action string, meta model.RequestMeta, before, after *model.User) model.AuditEntry {
+	entry := model.AuditEntry{
+		Action:    action,
+		Actor:     meta.Actor,
+		RequestID: meta.RequestID,
+		Before:    snapshot(before),
+		After:     snapshot(after),
+		CreatedAt: time.Now().UnixMicro(),
+	}
+	if after != nil {
+		entry.UserID = after.ID
+	} else if before != nil {
+		entry.UserID = before.ID
+	}
+	return entry
+}
+
+// snapshot serializes a user for storage in the audit log.
+func snapshot(user *model.User) json.RawMessage {
+	if user == nil {
+		return nil
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		// model.User only holds plain fields, so this cannot happen in practice
+		log.Printf("Error serializing user %d for audit: %v", user.ID, err)
+		return nil
+	}
+	return data
+}
+
+// GetAuditLog retrieves the audit trail of a user with pagination, most recent first.
+func (s *UserService) GetAuditLog(userID int64, page, pageSize int) ([]model.AuditEntry, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", userID)
+	}
+	return s.auditRepo.GetAuditLog(userID, page, pageSize)
+}
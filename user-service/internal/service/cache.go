@@ -0,0 +1,149 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// DefaultUserCacheTTL is how long a cached user is served before it is read again from the repository.
+const DefaultUserCacheTTL = 30 * time.Second
+
+// WithUserCache enables an in-process LRU cache in front of GetUserByID holding up to size users,
+// each for at most ttl. The cache is disabled when size is not positive; a non-positive ttl
+// uses DefaultUserCacheTTL.
+func WithUserCache(size int, ttl time.Duration) Option {
+	return func(s *UserService) {
+		if size <= 0 {
+			s.cache = nil
+			return
+		}
+		if ttl <= 0 {
+			ttl = DefaultUserCacheTTL
+		}
+		s.cache = newUserCache(size, ttl)
+	}
+}
+
+// userCacheEntry is a cached user along with the time it stops being served.
+type userCacheEntry struct {
+	id        int64
+	user      model.User
+	expiresAt time.Time
+}
+
+// userCache is a fixed-size, least-recently-used cache of users keyed by ID.
+// A nil *userCache is valid and caches nothing.
+//
+// A user read from the repository is only cached if it was not invalidated since the read
+// started, as the read may have returned the user from before the change: readers take the
+// cache's generation before reading and pass it to put, and invalidate advances it.
+type userCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	order   *list.List // front is most recently used
+	entries map[int64]*list.Element
+
+	generation  uint64           // Advanced by each invalidation
+	invalidated map[int64]uint64 // Generation of the last invalidation of each user, at most size of them
+	floor       uint64           // Generation invalidated was last cleared at; older reads are not cached
+}
+
+// newUserCache creates a cache holding at most size users for ttl each.
+func newUserCache(size int, ttl time.Duration) *userCache {
+	return &userCache{
+		size:        size,
+		ttl:         ttl,
+		order:       list.New(),
+		entries:     make(map[int64]*list.Element, size),
+		invalidated: make(map[int64]uint64),
+	}
+}
+
+// currentGeneration returns the generation to pass to put for a user read from now on.
+func (c *userCache) currentGeneration() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// get returns a copy of the cached user, if present and not expired.
+func (c *userCache) get(id int64) (*model.User, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*userCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, id)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	user := entry.user
+	return &user, true
+}
+
+// put stores a copy of the user read at generation, evicting the least recently used entry
+// when the cache is full. The user is not stored if it was invalidated after generation.
+func (c *userCache) put(user *model.User, generation uint64) {
+	if c == nil || user == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if generation < c.floor || c.invalidated[user.ID] > generation {
+		return
+	}
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.entries[user.ID]; ok {
+		entry := elem.Value.(*userCacheEntry)
+		entry.user = *user
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*userCacheEntry).id)
+	}
+	c.entries[user.ID] = c.order.PushFront(&userCacheEntry{id: user.ID, user: *user, expiresAt: expiresAt})
+}
+
+// invalidate drops the given users from the cache, and keeps reads in progress from caching them.
+func (c *userCache) invalidate(ids ...int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if len(c.invalidated)+len(ids) > c.size {
+		// Forget older invalidations, rather than let them pile up, by not caching any read
+		// in progress
+		clear(c.invalidated)
+		c.floor = c.generation
+	}
+	for _, id := range ids {
+		c.invalidated[id] = c.generation
+		if elem, ok := c.entries[id]; ok {
+			c.order.Remove(elem)
+			delete(c.entries, id)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// TestUserCacheSkipsReadsPredatingInvalidation checks that a user read before it was
+// invalidated is not cached, while one read afterwards is.
+func TestUserCacheSkipsReadsPredatingInvalidation(t *testing.T) {
+	c := newUserCache(2, time.Minute)
+
+	stale := c.currentGeneration()
+	c.invalidate(1)
+	c.put(&model.User{ID: 1, Name: "Before"}, stale)
+	if user, ok := c.get(1); ok {
+		t.Errorf("Expected no cached user, got %q", user.Name)
+	}
+
+	c.put(&model.User{ID: 1, Name: "After"}, c.currentGeneration())
+	if user, ok := c.get(1); !ok || user.Name != "After" {
+		t.Errorf("Expected the user read after the invalidation to be cached, got %v", user)
+	}
+
+	// Reads of other users are unaffected
+	c.put(&model.User{ID: 2, Name: "Other"}, stale)
+	if _, ok := c.get(2); !ok {
+		t.Errorf("Expected user 2 to be cached")
+	}
+}
+
+// TestUserCacheForgetsInvalidations checks that the invalidations kept stay bounded by the
+// cache's size, and that reads in progress when they are forgotten are not cached.
+func TestUserCacheForgetsInvalidations(t *testing.T) {
+	c := newUserCache(2, time.Minute)
+
+	stale := c.currentGeneration()
+	for id := int64(1); id <= 5; id++ {
+		c.invalidate(id)
+		if n := len(c.invalidated); n > 2 {
+			t.Fatalf("Expected at most 2 invalidations kept, got %d", n)
+		}
+	}
+	c.put(&model.User{ID: 1}, stale)
+	if _, ok := c.get(1); ok {
+		t.Errorf("Expected a read predating forgotten invalidations not to be cached")
+	}
+}
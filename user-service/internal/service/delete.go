@@ -0,0 +1,46 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"user-service/internal/events"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// DeleteUser soft-deletes a user and emits a user.deleted event, atomically, so that other
+// services can clean up data owned by the user. It returns the user as it was before the
+// deletion, or (nil, nil) if the user does not exist.
+func (s *UserService) DeleteUser(id int64, meta model.RequestMeta) (*model.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", id)
+	}
+
+	now := time.Now().UnixMicro()
+	event, err := events.New(events.TypeUserDeleted, events.UserDeletedPayload{
+		UserID:    id,
+		DeletedAt: now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.CreatedAt = now
+
+	var before *model.User
+	err = s.repo.WithTx(func(uow repository.UnitOfWork) (err error) {
+		if before, err = uow.Users().DeleteUser(id, event); err != nil || before == nil {
+			return err
+		}
+		after := *before
+		after.DeletedAt = now
+		after.UpdatedAt = now
+		after.Version++
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionDelete, meta, before, &after)})
+	})
+	s.cache.invalidate(id)
+	if err != nil {
+		return nil, err
+	}
+	return before, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"apperrors"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// DefaultImportChunkSize is the default number of rows inserted per transaction during imports.
+const DefaultImportChunkSize = 500
+
+// ErrInvalidImport is returned when an import file cannot be processed at all (e.g. a missing header).
+var ErrInvalidImport = apperrors.New(apperrors.Validation, "invalid import file")
+
+// ImportRowError describes why a single row of an import was rejected.
+// Row is the 1-based line number of the record in the file, the header being row 1.
+type ImportRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// ImportReport summarizes the outcome of an import.
+type ImportReport struct {
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Failed   int              `json:"failed"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+// WithImportChunkSize sets the number of rows inserted per transaction by ImportUsersCSV.
+// Non-positive values keep the default.
+func WithImportChunkSize(n int) Option {
+	return func(s *UserService) {
+		if n > 0 {
+			s.importChunkSize = n
+		}
+	}
+}
+
+// ImportUsersCSV reads users from a CSV stream and inserts them in chunked transactions.
+// The first record must be a header containing a "name" column; other columns are ignored,
+// so files produced by the export endpoint can be imported as-is.
+// Invalid rows, including names that are already taken, are reported and skipped; if a chunk
+// fails to persist, all its rows are reported as failed.
+func (s *UserService) ImportUsersCSV(r io.Reader, meta model.RequestMeta) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidImport)
+		}
+		return nil, fmt.Errorf("%w: failed to read header: %v", ErrInvalidImport, err)
+	}
+	nameCol := -1
+	for i, col := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(col, "\ufeff")), "name") {
+			nameCol = i
+			break
+		}
+	}
+	if nameCol < 0 {
+		return nil, fmt.Errorf("%w: header must contain a 'name' column", ErrInvalidImport)
+	}
+
+	report := &ImportReport{Errors: []ImportRowError{}}
+	chunkNames := make([]string, 0, s.importChunkSize)
+	chunkRows := make([]int, 0, s.importChunkSize)
+
+	flush := func() error {
+		if len(chunkNames) == 0 {
+			return nil
+		}
+		rejected, err := s.rejectTakenNames(chunkNames)
+		if err != nil {
+			return err
+		}
+		n := 0
+		for i, name := range chunkNames {
+			if rejected[i] {
+				report.Failed++
+				report.Errors = append(report.Errors, ImportRowError{Row: chunkRows[i], Error: nameTakenMessage})
+				continue
+			}
+			chunkNames[n] = name
+			chunkRows[n] = chunkRows[i]
+			n++
+		}
+		chunkNames = chunkNames[:n]
+		chunkRows = chunkRows[:n]
+
+		var imported int
+		err = s.repo.WithTx(func(uow repository.UnitOfWork) error {
+			users, err := uow.Users().CreateUsers(chunkNames)
+			if err != nil {
+				return err
+			}
+			imported = len(users)
+			return recordCreations(uow, meta, users...)
+		})
+		if err != nil {
+			for _, row := range chunkRows {
+				report.Errors = append(report.Errors, ImportRowError{Row: row, Error: "failed to store row: " + err.Error()})
+			}
+			report.Failed += len(chunkRows)
+		} else {
+			report.Imported += imported
+		}
+		chunkNames = chunkNames[:0]
+		chunkRows = chunkRows[:0]
+		return nil
+	}
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read import file: %w", err)
+			}
+			report.Total++
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+
+		report.Total++
+		row, _ := reader.FieldPos(0)
+		name := strings.TrimSpace(record[nameCol])
+		if name == "" {
+			report.Failed++
+			report.Errors = append(report.Errors, ImportRowError{Row: row, Error: "User name is required"})
+			continue
+		}
+
+		chunkNames = append(chunkNames, name)
+		chunkRows = append(chunkRows, row)
+		if len(chunkNames) >= s.importChunkSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"apperrors"
+	"user-service/internal/events"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// ErrUserNotFound is returned when an operation involving several users references a missing one.
+var ErrUserNotFound = apperrors.New(apperrors.NotFound, "user not found")
+
+// ErrSelfMerge is returned when a user is merged into itself.
+var ErrSelfMerge = apperrors.New(apperrors.Validation, "cannot merge a user into itself")
+
+// MergeUsers merges a duplicate account (source) into another one (target).
+// The source user is soft-deleted and a user.merged event is emitted, atomically, so that
+// other services can rewrite their references to the source user. It returns the target user.
+func (s *UserService) MergeUsers(sourceID, targetID int64, meta model.RequestMeta) (*model.User, error) {
+	if sourceID <= 0 || targetID <= 0 {
+		return nil, fmt.Errorf("invalid user IDs: %d, %d", sourceID, targetID)
+	}
+	if sourceID == targetID {
+		return nil, ErrSelfMerge
+	}
+
+	target, err := s.repo.GetUserByID(targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: target user %d", ErrUserNotFound, targetID)
+	}
+
+	now := time.Now().UnixMicro()
+	event, err := events.New(events.TypeUserMerged, events.UserMergedPayload{
+		SourceUserID: sourceID,
+		TargetUserID: targetID,
+		MergedAt:     now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.CreatedAt = now
+
+	err = s.repo.WithTx(func(uow repository.UnitOfWork) error {
+		before, err := uow.Users().MergeUser(sourceID, targetID, event)
+		if err != nil {
+			return err
+		}
+		if before == nil {
+			return fmt.Errorf("%w: source user %d", ErrUserNotFound, sourceID)
+		}
+		after := *before
+		after.DeletedAt = now
+		after.UpdatedAt = now
+		after.MergedInto = targetID
+		after.Version++
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionMerge, meta, before, &after)})
+	})
+	s.cache.invalidate(sourceID)
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
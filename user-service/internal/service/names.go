@@ -0,0 +1,61 @@
+package service
+
+import (
+	"strings"
+
+	"contracts"
+	"user-service/internal/repository"
+)
+
+// ErrNameTaken is returned when another active user already has the requested name.
+var ErrNameTaken = repository.ErrNameTaken
+
+// ErrorCodeNameTaken is the machine-readable code reported for names that are already taken.
+const ErrorCodeNameTaken = contracts.ErrorCodeNameTaken
+
+// nameTakenMessage is the per-item error reported for names that are already taken.
+const nameTakenMessage = "User name is already taken"
+
+// WithCaseInsensitiveNames sets whether names differing only in ASCII letter case are
+// considered the same name. It must match the mode of the database's unique name index.
+func WithCaseInsensitiveNames(enabled bool) Option {
+	return func(s *UserService) {
+		s.caseInsensitiveNames = enabled
+	}
+}
+
+// nameKey returns the form under which a name must be unique.
+func (s *UserService) nameKey(name string) string {
+	if !s.caseInsensitiveNames {
+		return name
+	}
+	// SQLite's NOCASE collation only folds ASCII letters, so do the same here
+	return strings.Map(func(r rune) rune {
+		if 'A' <= r && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, name)
+}
+
+// rejectTakenNames reports, for each name, whether it is already used by an active user or
+// repeats a name earlier in the list. Rejecting these up front lets batch operations report
+// duplicates per item instead of failing the whole transaction on the unique index.
+func (s *UserService) rejectTakenNames(names []string) ([]bool, error) {
+	existing, err := s.repo.FindActiveUsersByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing)+len(names))
+	for _, user := range existing {
+		taken[s.nameKey(user.Name)] = true
+	}
+
+	rejected := make([]bool, len(names))
+	for i, name := range names {
+		key := s.nameKey(name)
+		rejected[i] = taken[key]
+		taken[key] = true
+	}
+	return rejected, nil
+}
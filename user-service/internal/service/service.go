@@ -1,43 +1,326 @@
 package service
 
 import (
+	"errors"
 	"fmt"
 
+	"apperrors"
+	"user-service/internal/events"
 	"user-service/internal/model"
+	"user-service/internal/password"
 	"user-service/internal/repository"
 )
 
+// DefaultMaxBatchSize is the default maximum number of users accepted by a single batch create.
+const DefaultMaxBatchSize = 100
+
+// ErrBatchTooLarge is returned when a batch exceeds the configured maximum size.
+var ErrBatchTooLarge = apperrors.New(apperrors.Validation, "batch exceeds maximum size")
+
+// ErrInvalidFilter is returned when query filters are inconsistent.
+var ErrInvalidFilter = apperrors.New(apperrors.Validation, "invalid filter")
+
+// ErrVersionConflict is returned when an update targets a stale version of a user.
+var ErrVersionConflict = repository.ErrVersionConflict
+
+// ErrBusy is returned when a write could not be performed in time because too many writes are pending.
+var ErrBusy = repository.ErrWriteQueueTimeout
+
+// ErrInvalidSort is returned when a listing is requested in an unsupported order.
+var ErrInvalidSort = apperrors.New(apperrors.Validation, "invalid sort")
+
+// MinPasswordLength is the minimum length of a user password.
+const MinPasswordLength = 8
+
+// ErrWeakPassword is returned when a password does not meet the password policy.
+var ErrWeakPassword = apperrors.New(apperrors.Validation, fmt.Sprintf("password must be at least %d characters long", MinPasswordLength))
+
+// ErrEmptyBatch is returned when a batch contains no items.
+var ErrEmptyBatch = apperrors.New(apperrors.Validation, "batch must contain at least one user")
+
 // UserService defines the business logic for user management.
 // It interacts with the UserRepository interface.
 type UserService struct {
-	repo repository.UserRepository
+	repo            repository.UserRepository
+	auditRepo       repository.AuditRepository
+	maxBatchSize    int
+	importChunkSize int
+	cache           *userCache // nil when caching is disabled
+	stats           statsCache
+
+	caseInsensitiveNames bool
+}
+
+// Option configures optional behaviour of the UserService.
+type Option func(*UserService)
+
+// WithMaxBatchSize sets the maximum number of users accepted by CreateUsers.
+// Non-positive values keep the default.
+func WithMaxBatchSize(n int) Option {
+	return func(s *UserService) {
+		if n > 0 {
+			s.maxBatchSize = n
+		}
+	}
 }
 
 // NewUserService creates a new instance of UserService.
-func NewUserService(repo repository.UserRepository) *UserService {
-	return &UserService{repo: repo}
+// Every mutation performed through the service is audited in the same transaction as the
+// change itself; the audit repository serves the resulting trail.
+func NewUserService(repo repository.UserRepository, auditRepo repository.AuditRepository, opts ...Option) *UserService {
+	s := &UserService{
+		repo:            repo,
+		auditRepo:       auditRepo,
+		maxBatchSize:    DefaultMaxBatchSize,
+		importChunkSize: DefaultImportChunkSize,
+		stats:           statsCache{ttl: DefaultStatsCacheTTL},
+
+		caseInsensitiveNames: true,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// MaxBatchSize returns the maximum number of users accepted by CreateUsers.
+func (s *UserService) MaxBatchSize() int {
+	return s.maxBatchSize
 }
 
 // CreateUser handles the creation of a new user.
-// It performs basic validation and calls the repository to persist the user.
-func (s *UserService) CreateUser(name string) (*model.User, error) {
+// It performs basic validation and calls the repository to persist the user. The password is
+// optional; users without one cannot log in.
+func (s *UserService) CreateUser(name, pw string, meta model.RequestMeta) (*model.User, error) {
 	if name == "" {
 		return nil, fmt.Errorf("user name cannot be empty")
 	}
 	// Additional business logic/validation can be added here
-	return s.repo.CreateUser(name)
+	var passwordHash string
+	if pw != "" {
+		if len(pw) < MinPasswordLength {
+			return nil, ErrWeakPassword
+		}
+		hash, err := password.Hash(pw)
+		if err != nil {
+			return nil, err
+		}
+		passwordHash = hash
+	}
+	var user *model.User
+	err := s.repo.WithTx(func(uow repository.UnitOfWork) (err error) {
+		if user, err = uow.Users().CreateUser(name, passwordHash); err != nil {
+			return err
+		}
+		return recordCreations(uow, meta, *user)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// recordCreations enqueues a user.created event and records an audit entry for each of the
+// new users, as part of the unit of work that created them.
+func recordCreations(uow repository.UnitOfWork, meta model.RequestMeta, users ...model.User) error {
+	entries := make([]model.AuditEntry, len(users))
+	for i := range users {
+		event, err := events.New(events.TypeUserCreated, events.UserCreatedPayload{
+			UserID:    users[i].ID,
+			Name:      users[i].Name,
+			CreatedAt: users[i].CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := uow.EnqueueEvent(event); err != nil {
+			return err
+		}
+		entries[i] = newAuditEntry(model.AuditActionCreate, meta, nil, &users[i])
+	}
+	return uow.RecordAudit(entries)
+}
+
+// BatchItemResult describes the outcome of creating a single user within a batch.
+type BatchItemResult struct {
+	Index  int         `json:"index"`
+	Result bool        `json:"result"`
+	User   *model.User `json:"user,omitempty"`
+	Code   string      `json:"code,omitempty"`
+	Error  string      `json:"error,omitempty"`
 }
 
-// GetAllUsers retrieves all users with pagination.
-func (s *UserService) GetAllUsers(page, pageSize int) ([]model.User, error) {
+// CreateUsers creates multiple users at once.
+// Each item is validated individually; invalid items (including names that are already taken)
+// are reported in the results and skipped, while all valid items are inserted atomically in a
+// single transaction.
+// A non-nil error means the batch itself was rejected or could not be persisted.
+func (s *UserService) CreateUsers(names []string, meta model.RequestMeta) ([]BatchItemResult, error) {
+	if len(names) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(names) > s.maxBatchSize {
+		return nil, fmt.Errorf("%w: got %d users, maximum is %d", ErrBatchTooLarge, len(names), s.maxBatchSize)
+	}
+
+	results := make([]BatchItemResult, len(names))
+	validNames := make([]string, 0, len(names))
+	validIndexes := make([]int, 0, len(names))
+	for i, name := range names {
+		results[i].Index = i
+		if name == "" {
+			results[i].Error = "User name is required"
+			continue
+		}
+		validNames = append(validNames, name)
+		validIndexes = append(validIndexes, i)
+	}
+
+	rejected, err := s.rejectTakenNames(validNames)
+	if err != nil {
+		return nil, err
+	}
+	n := 0
+	for i, name := range validNames {
+		if rejected[i] {
+			result := &results[validIndexes[i]]
+			result.Code = ErrorCodeNameTaken
+			result.Error = nameTakenMessage
+			continue
+		}
+		validNames[n] = name
+		validIndexes[n] = validIndexes[i]
+		n++
+	}
+	validNames = validNames[:n]
+	validIndexes = validIndexes[:n]
+
+	var users []model.User
+	err = s.repo.WithTx(func(uow repository.UnitOfWork) (err error) {
+		if users, err = uow.Users().CreateUsers(validNames); err != nil {
+			return err
+		}
+		return recordCreations(uow, meta, users...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range users {
+		result := &results[validIndexes[i]]
+		result.Result = true
+		result.User = &users[i]
+	}
+
+	return results, nil
+}
+
+// UpdateUser changes a user's name, provided the caller's expected version is still current,
+// and emits a user.updated event.
+// It returns (nil, nil) if the user does not exist. On a version mismatch it returns the
+// current user together with ErrVersionConflict.
+func (s *UserService) UpdateUser(id int64, name string, expectedVersion int64, meta model.RequestMeta) (*model.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", id)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("user name cannot be empty")
+	}
+
+	var after *model.User
+	err := s.repo.WithTx(func(uow repository.UnitOfWork) error {
+		before, err := uow.Users().GetUserByID(id)
+		if err != nil || before == nil {
+			return err
+		}
+		if before.Version != expectedVersion {
+			after = before
+			return ErrVersionConflict
+		}
+		// On a conflict, the repository returns the current user
+		if after, err = uow.Users().UpdateUser(id, name, expectedVersion); err != nil || after == nil {
+			return err
+		}
+		event, err := events.New(events.TypeUserUpdated, events.UserUpdatedPayload{
+			UserID:    after.ID,
+			Name:      after.Name,
+			UpdatedAt: after.UpdatedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := uow.EnqueueEvent(event); err != nil {
+			return err
+		}
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionUpdate, meta, before, after)})
+	})
+	s.cache.invalidate(id)
+	if err != nil && !errors.Is(err, ErrVersionConflict) {
+		return nil, err
+	}
+	return after, err
+}
+
+// GetAllUsers retrieves the users matching the filter with pagination, in the given order.
+func (s *UserService) GetAllUsers(page, pageSize int, filter model.UserFilter, sort model.UserSort) ([]model.User, error) {
 	// Business logic for pagination defaults or limits can be applied here
-	return s.repo.GetAllUsers(page, pageSize)
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	switch sort.Field {
+	case model.SortByCreatedAt, model.SortByName:
+	default:
+		return nil, fmt.Errorf("%w: unsupported sort field %q", ErrInvalidSort, sort.Field)
+	}
+	return s.repo.GetAllUsers(page, pageSize, filter, sort)
 }
 
 // GetUserByID retrieves a user by their ID.
+// When the user cache is enabled, recently fetched users are served from memory.
 func (s *UserService) GetUserByID(id int64) (*model.User, error) {
 	if id <= 0 {
 		return nil, fmt.Errorf("invalid user ID: %d", id)
 	}
-	return s.repo.GetUserByID(id)
+	if user, ok := s.cache.get(id); ok {
+		return user, nil
+	}
+	generation := s.cache.currentGeneration()
+	user, err := s.repo.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.put(user, generation)
+	return user, nil
+}
+
+// ExportUsers streams every user matching the filter to fn, one at a time.
+func (s *UserService) ExportUsers(filter model.UserFilter, fn func(*model.User) error) error {
+	if err := validateFilter(filter); err != nil {
+		return err
+	}
+	return s.repo.StreamUsers(filter, fn)
+}
+
+// CountUsers returns the number of users matching the filter.
+func (s *UserService) CountUsers(filter model.UserFilter) (int64, error) {
+	if err := validateFilter(filter); err != nil {
+		return 0, err
+	}
+	return s.repo.CountUsers(filter)
+}
+
+// validateFilter checks that a filter's conditions are consistent.
+func validateFilter(filter model.UserFilter) error {
+	if filter.CreatedAfter > 0 && filter.CreatedBefore > 0 && filter.CreatedAfter >= filter.CreatedBefore {
+		return fmt.Errorf("%w: created_after must be before created_before", ErrInvalidFilter)
+	}
+	switch filter.Deleted {
+	case model.DeletedExclude, model.DeletedOnly, model.DeletedAny:
+	default:
+		return fmt.Errorf("%w: unknown deleted status %q", ErrInvalidFilter, filter.Deleted)
+	}
+	if filter.Status != "" && !validUserStatus(filter.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidFilter, filter.Status)
+	}
+	return nil
 }
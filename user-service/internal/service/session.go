@@ -0,0 +1,185 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"apperrors"
+	"user-service/internal/model"
+	"user-service/internal/password"
+	"user-service/internal/repository"
+)
+
+// DefaultSessionTTL is how long a session stays valid after login.
+const DefaultSessionTTL = 30 * 24 * time.Hour
+
+// ErrInvalidCredentials is returned when a login names an unknown user or the wrong password.
+var ErrInvalidCredentials = apperrors.New(apperrors.Unauthorized, "invalid credentials")
+
+// ErrSessionNotFound is returned when revoking a session the user does not have (or that is already revoked).
+var ErrSessionNotFound = apperrors.New(apperrors.NotFound, "session not found")
+
+// sessionTokenBytes is the amount of randomness in a session token.
+const sessionTokenBytes = 32
+
+// SessionService implements logins and the management of the resulting sessions.
+type SessionService struct {
+	repo  repository.SessionRepository
+	users *UserService
+	ttl   time.Duration
+
+	// dummyHash is verified when a login names an unknown user, so that response
+	// times do not reveal which names exist.
+	dummyHashOnce sync.Once
+	dummyHash     string
+}
+
+// NewSessionService creates a new instance of SessionService. Sessions last for ttl,
+// or DefaultSessionTTL if ttl is not positive.
+func NewSessionService(repo repository.SessionRepository, users *UserService, ttl time.Duration) *SessionService {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &SessionService{repo: repo, users: users, ttl: ttl}
+}
+
+// Login checks the user's password and opens a new session, recording the login time on the
+// user. It returns the session along with its token, which is not stored and cannot be retrieved later.
+func (s *SessionService) Login(name, pw, userAgent, ipAddress string) (*model.Session, string, error) {
+	user, err := s.VerifyCredentials(name, pw)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, tokenHash, err := newSessionToken()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	session, err := s.repo.CreateSession(model.Session{
+		UserID:    user.ID,
+		UserAgent: userAgent,
+		IPAddress: ipAddress,
+		CreatedAt: now.UnixMicro(),
+		ExpiresAt: now.Add(s.ttl).UnixMicro(),
+	}, tokenHash)
+	if err != nil {
+		return nil, "", err
+	}
+	s.users.cache.invalidate(user.ID) // last_login_at changed
+	return session, token, nil
+}
+
+// VerifyCredentials checks the user's password without opening a session. It returns
+// ErrInvalidCredentials for unknown names and wrong passwords, and ErrUserSuspended for
+// suspended users.
+func (s *SessionService) VerifyCredentials(name, pw string) (*model.User, error) {
+	if name == "" || pw == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	user, hash, err := s.repo.GetCredentials(name)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || hash == "" {
+		s.verifyDummy(pw)
+		return nil, ErrInvalidCredentials
+	}
+	ok, err := password.Verify(hash, pw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify password of user %d: %w", user.ID, err)
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Status == model.UserStatusSuspended {
+		return nil, ErrUserSuspended
+	}
+	return user, nil
+}
+
+// ListSessions returns the user's sessions that are still valid, most recent first.
+// It returns ErrUserNotFound if the user does not exist.
+func (s *SessionService) ListSessions(userID int64) ([]model.Session, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListActiveSessions(userID, time.Now().UnixMicro())
+}
+
+// RevokeSession ends one of the user's sessions.
+func (s *SessionService) RevokeSession(userID, sessionID int64) error {
+	if userID <= 0 || sessionID <= 0 {
+		return ErrSessionNotFound
+	}
+	revoked, err := s.repo.RevokeSession(userID, sessionID, time.Now().UnixMicro())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// Authenticate resolves a session token to its session. It returns (nil, nil) if the token is
+// unknown, expired or revoked, or if its user no longer exists or is suspended.
+func (s *SessionService) Authenticate(token string) (*model.Session, error) {
+	if token == "" {
+		return nil, nil
+	}
+	session, err := s.repo.GetSessionByTokenHash(hashToken(token))
+	if err != nil || session == nil {
+		return nil, err
+	}
+	if session.RevokedAt != 0 || session.ExpiresAt <= time.Now().UnixMicro() {
+		return nil, nil
+	}
+	user, err := s.users.GetUserByID(session.UserID)
+	if err != nil || user == nil || user.Status == model.UserStatusSuspended {
+		return nil, err
+	}
+	return session, nil
+}
+
+// verifyDummy spends as much time as verifying a real password.
+func (s *SessionService) verifyDummy(pw string) {
+	s.dummyHashOnce.Do(func() {
+		hash, err := password.Hash("dummy password")
+		if err != nil {
+			log.Printf("Error creating dummy password hash: %v", err)
+			return
+		}
+		s.dummyHash = hash
+	})
+	if s.dummyHash != "" {
+		password.Verify(s.dummyHash, pw)
+	}
+}
+
+// newSessionToken generates a random session token along with the hash stored in its place.
+func newSessionToken() (token, tokenHash string, err error) {
+	raw := make([]byte, sessionTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token = hex.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the hash under which a session or access token is stored.
+// Tokens are long random strings, so a fast unsalted hash is sufficient.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"user-service/internal/model"
+)
+
+// Defaults and limits for signup statistics.
+const (
+	DefaultStatsDays     = 30
+	DefaultStatsWeeks    = 12
+	MaxStatsDays         = 366
+	MaxStatsWeeks        = 104
+	DefaultStatsCacheTTL = 10 * time.Second
+)
+
+// WithStatsCacheTTL sets how long computed signup statistics are reused. A non-positive ttl
+// disables caching, so every request queries the database.
+func WithStatsCacheTTL(ttl time.Duration) Option {
+	return func(s *UserService) {
+		s.stats.ttl = ttl
+	}
+}
+
+// dayMicros is the length of a day in microseconds.
+const dayMicros = 24 * 60 * 60 * 1_000_000
+
+// statsKey identifies a statistics request.
+type statsKey struct {
+	days, weeks int
+}
+
+// statsCacheEntry is computed statistics along with the time they stop being served.
+type statsCacheEntry struct {
+	stats     *model.SignupStats
+	expiresAt time.Time
+}
+
+// statsCache briefly keeps computed signup statistics, which require scanning every user,
+// so dashboards polling the endpoint do not hit the database on each refresh. Entries are
+// not invalidated on writes; statistics may lag by up to ttl.
+type statsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[statsKey]statsCacheEntry
+}
+
+// get returns the cached statistics for key, if present and not expired.
+func (c *statsCache) get(key statsKey, now time.Time) (*model.SignupStats, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+// put stores stats for key, dropping expired entries so the cache stays small.
+func (c *statsCache) put(key statsKey, stats *model.SignupStats, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[statsKey]statsCacheEntry)
+	}
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = statsCacheEntry{stats: stats, expiresAt: now.Add(c.ttl)}
+}
+
+// SignupStats returns user totals along with the number of signups on each of the last days
+// UTC days and in each of the last weeks weeks (starting on Monday), including the current,
+// partial day and week. Zero values use DefaultStatsDays and DefaultStatsWeeks.
+// The returned statistics are shared with other callers and must not be modified.
+func (s *UserService) SignupStats(days, weeks int) (*model.SignupStats, error) {
+	if days == 0 {
+		days = DefaultStatsDays
+	}
+	if weeks == 0 {
+		weeks = DefaultStatsWeeks
+	}
+	if days < 1 || days > MaxStatsDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidFilter, MaxStatsDays)
+	}
+	if weeks < 1 || weeks > MaxStatsWeeks {
+		return nil, fmt.Errorf("%w: weeks must be between 1 and %d", ErrInvalidFilter, MaxStatsWeeks)
+	}
+
+	now := time.Now()
+	key := statsKey{days: days, weeks: weeks}
+	if stats, ok := s.stats.get(key, now); ok {
+		return stats, nil
+	}
+
+	// Days are numbered since the Unix epoch, a Thursday; weeks start on the Monday before it
+	today := now.UTC().Unix() / (24 * 60 * 60)
+	firstDay := today - int64(days) + 1
+	thisMonday := today - (today+3)%7
+	firstMonday := thisMonday - int64(weeks-1)*7
+
+	totals, err := s.repo.CountUsersByState()
+	if err != nil {
+		return nil, err
+	}
+	signups, err := s.repo.CountSignupsByDay(min(firstDay, firstMonday) * dayMicros)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &model.SignupStats{
+		Totals:      totals,
+		Daily:       make([]model.SignupCount, 0, days),
+		Weekly:      make([]model.SignupCount, 0, weeks),
+		GeneratedAt: now.UnixMicro(),
+	}
+	for day := firstDay; day <= today; day++ {
+		stats.Daily = append(stats.Daily, model.SignupCount{Start: day * dayMicros, Count: signups[day]})
+	}
+	for monday := firstMonday; monday <= thisMonday; monday += 7 {
+		count := model.SignupCount{Start: monday * dayMicros}
+		for day := monday; day < monday+7; day++ {
+			count.Count += signups[day]
+		}
+		stats.Weekly = append(stats.Weekly, count)
+	}
+
+	s.stats.put(key, stats, now)
+	return stats, nil
+}
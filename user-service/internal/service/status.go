@@ -0,0 +1,52 @@
+package service
+
+import (
+	"fmt"
+
+	"apperrors"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// ErrInvalidStatus is returned when a status change names an unknown status.
+var ErrInvalidStatus = apperrors.New(apperrors.Validation, "invalid status")
+
+// ErrUserSuspended is returned when a suspended user tries to log in.
+var ErrUserSuspended = apperrors.New(apperrors.Forbidden, "user is suspended")
+
+// validUserStatus reports whether status is a supported account status.
+func validUserStatus(status string) bool {
+	return status == model.UserStatusActive || status == model.UserStatusSuspended
+}
+
+// SetUserStatus activates or suspends a user. Setting the status a user already has is a
+// no-op that returns the user unchanged. It returns (nil, nil) if the user does not exist.
+func (s *UserService) SetUserStatus(id int64, status string, meta model.RequestMeta) (*model.User, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", id)
+	}
+	if !validUserStatus(status) {
+		return nil, fmt.Errorf("%w: expected %q or %q, got %q", ErrInvalidStatus, model.UserStatusActive, model.UserStatusSuspended, status)
+	}
+
+	var after *model.User
+	err := s.repo.WithTx(func(uow repository.UnitOfWork) error {
+		before, err := uow.Users().GetUserByID(id)
+		if err != nil || before == nil {
+			return err
+		}
+		if before.Status == status {
+			after = before
+			return nil
+		}
+		if after, err = uow.Users().SetUserStatus(id, status); err != nil || after == nil {
+			return err
+		}
+		return uow.RecordAudit([]model.AuditEntry{newAuditEntry(model.AuditActionUpdate, meta, before, after)})
+	})
+	s.cache.invalidate(id)
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
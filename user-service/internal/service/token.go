@@ -0,0 +1,156 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"apperrors"
+	"user-service/internal/model"
+	"user-service/internal/repository"
+)
+
+// ErrInvalidAccessToken is returned when a token request is malformed (missing name, unknown scopes, ...).
+var ErrInvalidAccessToken = apperrors.New(apperrors.Validation, "invalid access token request")
+
+// ErrAccessTokenNotFound is returned when revoking a token the user does not have (or that is already revoked).
+var ErrAccessTokenNotFound = apperrors.New(apperrors.NotFound, "access token not found")
+
+const (
+	// accessTokenPrefix starts every personal access token, making leaked tokens easy to spot.
+	accessTokenPrefix = "pat_"
+	// accessTokenBytes is the amount of randomness in an access token.
+	accessTokenBytes = 32
+	// accessTokenDisplayLength is how much of a token is kept to help users recognise it.
+	accessTokenDisplayLength = len(accessTokenPrefix) + 8
+	// accessTokenTouchInterval limits how often a token's last use is written back.
+	accessTokenTouchInterval = time.Minute
+)
+
+// AccessTokenService implements issuance, listing, revocation and validation of personal access tokens.
+type AccessTokenService struct {
+	repo  repository.AccessTokenRepository
+	users *UserService
+}
+
+// NewAccessTokenService creates a new instance of AccessTokenService.
+func NewAccessTokenService(repo repository.AccessTokenRepository, users *UserService) *AccessTokenService {
+	return &AccessTokenService{repo: repo, users: users}
+}
+
+// IssueToken creates a token acting as the user with the given scopes, valid for ttl
+// (0 means the token never expires). It returns the token metadata along with the token itself,
+// which is not stored and cannot be retrieved later.
+func (s *AccessTokenService) IssueToken(userID int64, name string, scopes []string, ttl time.Duration) (*model.AccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("%w: name is required", ErrInvalidAccessToken)
+	}
+	if len(scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", ErrInvalidAccessToken)
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(model.AccessTokenScopes, scope) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", ErrInvalidAccessToken, scope)
+		}
+	}
+	if ttl < 0 {
+		return nil, "", fmt.Errorf("%w: expiry must not be negative", ErrInvalidAccessToken)
+	}
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	raw := make([]byte, accessTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, "", fmt.Errorf("failed to generate access token: %w", err)
+	}
+	plaintext := accessTokenPrefix + hex.EncodeToString(raw)
+
+	now := time.Now()
+	token := model.AccessToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    plaintext[:accessTokenDisplayLength],
+		Scopes:    scopes,
+		CreatedAt: now.UnixMicro(),
+	}
+	if ttl > 0 {
+		token.ExpiresAt = now.Add(ttl).UnixMicro()
+	}
+	created, err := s.repo.CreateAccessToken(token, hashToken(plaintext))
+	if err != nil {
+		return nil, "", err
+	}
+	return created, plaintext, nil
+}
+
+// ListTokens returns the user's tokens that have not been revoked, most recent first.
+// It returns ErrUserNotFound if the user does not exist.
+func (s *AccessTokenService) ListTokens(userID int64) ([]model.AccessToken, error) {
+	user, err := s.users.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.ListAccessTokens(userID)
+}
+
+// RevokeToken permanently invalidates one of the user's tokens.
+func (s *AccessTokenService) RevokeToken(userID, tokenID int64) error {
+	if userID <= 0 || tokenID <= 0 {
+		return ErrAccessTokenNotFound
+	}
+	revoked, err := s.repo.RevokeAccessToken(userID, tokenID, time.Now().UnixMicro())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// Introspect resolves a token to its metadata, recording its use. It returns (nil, nil) if the
+// token is unknown, expired or revoked, or if its user no longer exists or is suspended.
+func (s *AccessTokenService) Introspect(plaintext string) (*model.AccessToken, error) {
+	if !strings.HasPrefix(plaintext, accessTokenPrefix) {
+		return nil, nil
+	}
+	token, err := s.repo.GetAccessTokenByHash(hashToken(plaintext))
+	if err != nil || token == nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token.RevokedAt != 0 || (token.ExpiresAt != 0 && token.ExpiresAt <= now.UnixMicro()) {
+		return nil, nil
+	}
+	user, err := s.users.GetUserByID(token.UserID)
+	if err != nil || user == nil || user.Status == model.UserStatusSuspended {
+		return nil, err
+	}
+
+	// Tokens may be validated on every request, so their last use is only written back periodically
+	if now.Sub(time.UnixMicro(token.LastUsedAt)) >= accessTokenTouchInterval {
+		if err := s.repo.TouchAccessToken(token.ID, now.UnixMicro()); err != nil {
+			log.Printf("Error recording use of access token %d: %v", token.ID, err)
+		} else {
+			token.LastUsedAt = now.UnixMicro()
+		}
+	}
+	return token, nil
+}